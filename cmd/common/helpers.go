@@ -7,7 +7,6 @@ import (
 	"github.com/golang/glog"
 	"github.com/openshift/machine-config-operator/internal/clients"
 	corev1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/uuid"
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
@@ -23,7 +22,9 @@ const (
 	RetryPeriod = 30 * time.Second
 )
 
-// CreateResourceLock returns an interface for the resource lock.
+// CreateResourceLock returns an interface for the resource lock. The lock is held on both a
+// configmap and a lease, so that leaders of earlier releases, which only lock the configmap,
+// still exclude the new ones during an upgrade.
 func CreateResourceLock(cb *clients.Builder, componentNamespace, componentName string) resourcelock.Interface {
 	scheme := runtime.NewScheme()
 	corev1.AddToScheme(scheme)
@@ -39,15 +40,13 @@ func CreateResourceLock(cb *clients.Builder, componentNamespace, componentName s
 	// add a uniquifier so that two processes on the same host don't accidentally both become active
 	id = id + "_" + string(uuid.NewUUID())
 
-	return &resourcelock.ConfigMapLock{
-		ConfigMapMeta: metav1.ObjectMeta{
-			Namespace: componentNamespace,
-			Name:      componentName,
-		},
-		Client: cb.KubeClientOrDie("leader-election").CoreV1(),
-		LockConfig: resourcelock.ResourceLockConfig{
-			Identity:      id,
-			EventRecorder: recorder,
-		},
+	client := cb.KubeClientOrDie("leader-election")
+	lock, err := resourcelock.New(resourcelock.ConfigMapsLeasesResourceLock, componentNamespace, componentName, client.CoreV1(), client.CoordinationV1(), resourcelock.ResourceLockConfig{
+		Identity:      id,
+		EventRecorder: recorder,
+	})
+	if err != nil {
+		glog.Fatalf("error creating lock: %v", err)
 	}
+	return lock
 }
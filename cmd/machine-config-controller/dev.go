@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/internal/clients"
+	"github.com/openshift/machine-config-operator/internal/stubdaemon"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/version"
+	"github.com/spf13/cobra"
+)
+
+var (
+	devCmd = &cobra.Command{
+		Use:   "dev",
+		Short: "Runs the Machine Config Controller against a local development cluster",
+		Long: `Runs the controllers against a local development cluster such as kind, without leader
+election and with the machine-config-daemon of every node stubbed out. See hack/dev-controller.sh
+to set up the cluster.`,
+		Run: runDevCmd,
+	}
+
+	devOpts struct {
+		kubeconfig      string
+		templates       string
+		stubDaemons     bool
+		stubDaemonDelay time.Duration
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(devCmd)
+	devCmd.PersistentFlags().StringVar(&devOpts.kubeconfig, "kubeconfig", "", "Kubeconfig file of the development cluster")
+	devCmd.PersistentFlags().StringVar(&devOpts.templates, "templates", "templates", "Path to the template files used for creating MachineConfig objects")
+	devCmd.PersistentFlags().BoolVar(&devOpts.stubDaemons, "stub-daemons", true, "Move the nodes to their desired config in place of the machine-config-daemon")
+	devCmd.PersistentFlags().DurationVar(&devOpts.stubDaemonDelay, "stub-daemon-delay", 5*time.Second, "How long the stubbed daemons take to update a node")
+}
+
+func runDevCmd(cmd *cobra.Command, args []string) {
+	flag.Set("logtostderr", "true")
+	flag.Parse()
+
+	// To help debugging, immediately log version
+	glog.Infof("Version: %+v (%s)", version.Raw, version.Hash)
+
+	rootOpts.templates = devOpts.templates
+
+	cb, err := clients.NewBuilder(devOpts.kubeconfig)
+	if err != nil {
+		glog.Fatalf("error creating clients: %v", err)
+	}
+	ctrlctx := ctrlcommon.CreateControllerContext(cb, make(chan struct{}), componentName)
+
+	controllers := createControllers(ctrlctx)
+	if devOpts.stubDaemons {
+		controllers = append(controllers, stubdaemon.New(
+			ctrlctx.KubeInformerFactory.Core().V1().Nodes(),
+			ctrlctx.InformerFactory.Machineconfiguration().V1().MachineConfigPools(),
+			cb.KubeClientOrDie("stub-daemon"),
+			devOpts.stubDaemonDelay,
+		))
+	}
+
+	startControllers(ctrlctx, controllers)
+
+	select {}
+}
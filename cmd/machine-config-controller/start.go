@@ -33,12 +33,14 @@ var (
 		templates  string
 
 		resourceLockNamespace string
+		promMetricsURL        string
 	}
 )
 
 func init() {
 	rootCmd.AddCommand(startCmd)
 	startCmd.PersistentFlags().StringVar(&startOpts.kubeconfig, "kubeconfig", "", "Kubeconfig file to access a remote cluster (testing only)")
+	startCmd.PersistentFlags().StringVar(&startOpts.promMetricsURL, "metrics-url", ctrlcommon.DefaultMetricsBindAddress, "URL for prometheus metrics listener")
 	startCmd.PersistentFlags().StringVar(&startOpts.resourceLockNamespace, "resourcelock-namespace", metav1.NamespaceSystem, "Path to the template files used for creating MachineConfig objects")
 }
 
@@ -56,20 +58,11 @@ func runStartCmd(cmd *cobra.Command, args []string) {
 	run := func(ctx context.Context) {
 		ctrlctx := ctrlcommon.CreateControllerContext(cb, ctx.Done(), componentName)
 
-		controllers := createControllers(ctrlctx)
-
-		// Start the shared factory informers that you need to use in your controller
-		ctrlctx.InformerFactory.Start(ctrlctx.Stop)
-		ctrlctx.KubeInformerFactory.Start(ctrlctx.Stop)
-		ctrlctx.OpenShiftConfigKubeNamespacedInformerFactory.Start(ctrlctx.Stop)
-		ctrlctx.ConfigInformerFactory.Start(ctrlctx.Stop)
-		ctrlctx.OperatorInformerFactory.Start(ctrlctx.Stop)
+		go ctrlcommon.StartMetricsListener(startOpts.promMetricsURL, ctrlctx.Stop)
 
-		close(ctrlctx.InformersStarted)
+		controllers := createControllers(ctrlctx)
 
-		for _, c := range controllers {
-			go c.Run(2, ctrlctx.Stop)
-		}
+		startControllers(ctrlctx, controllers)
 
 		select {}
 	}
@@ -89,6 +82,22 @@ func runStartCmd(cmd *cobra.Command, args []string) {
 	panic("unreachable")
 }
 
+// startControllers starts the shared factory informers and runs the controllers using them
+func startControllers(ctrlctx *ctrlcommon.ControllerContext, controllers []ctrlcommon.Controller) {
+	// Start the shared factory informers that you need to use in your controller
+	ctrlctx.InformerFactory.Start(ctrlctx.Stop)
+	ctrlctx.KubeInformerFactory.Start(ctrlctx.Stop)
+	ctrlctx.OpenShiftConfigKubeNamespacedInformerFactory.Start(ctrlctx.Stop)
+	ctrlctx.ConfigInformerFactory.Start(ctrlctx.Stop)
+	ctrlctx.OperatorInformerFactory.Start(ctrlctx.Stop)
+
+	close(ctrlctx.InformersStarted)
+
+	for _, c := range controllers {
+		go c.Run(2, ctrlctx.Stop)
+	}
+}
+
 func createControllers(ctx *ctrlcommon.ControllerContext) []ctrlcommon.Controller {
 	var controllers []ctrlcommon.Controller
 
@@ -109,6 +118,8 @@ func createControllers(ctx *ctrlcommon.ControllerContext) []ctrlcommon.Controlle
 			ctx.InformerFactory.Machineconfiguration().V1().ControllerConfigs(),
 			ctx.InformerFactory.Machineconfiguration().V1().KubeletConfigs(),
 			ctx.ConfigInformerFactory.Config().V1().FeatureGates(),
+			ctx.InformerFactory.Machineconfiguration().V1().NodeConfigs(),
+			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigs(),
 			ctx.ClientBuilder.KubeClientOrDie("kubelet-config-controller"),
 			ctx.ClientBuilder.MachineConfigClientOrDie("kubelet-config-controller"),
 		),
@@ -130,6 +141,9 @@ func createControllers(ctx *ctrlcommon.ControllerContext) []ctrlcommon.Controlle
 			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigPools(),
 			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigs(),
 			ctx.InformerFactory.Machineconfiguration().V1().ControllerConfigs(),
+			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigNodeOverrides(),
+			ctx.InformerFactory.Machineconfiguration().V1().KernelArgumentPolicies(),
+			ctx.KubeInformerFactory.Core().V1().Nodes(),
 			ctx.ClientBuilder.KubeClientOrDie("render-controller"),
 			ctx.ClientBuilder.MachineConfigClientOrDie("render-controller"),
 		),
@@ -138,6 +152,7 @@ func createControllers(ctx *ctrlcommon.ControllerContext) []ctrlcommon.Controlle
 			ctx.InformerFactory.Machineconfiguration().V1().ControllerConfigs(),
 			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigPools(),
 			ctx.KubeInformerFactory.Core().V1().Nodes(),
+			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigNodeOverrides(),
 			ctx.ConfigInformerFactory.Config().V1().Schedulers(),
 			ctx.ClientBuilder.KubeClientOrDie("node-update-controller"),
 			ctx.ClientBuilder.MachineConfigClientOrDie("node-update-controller"),
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"syscall"
+
+	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/lib/resourceread"
+	daemon "github.com/openshift/machine-config-operator/pkg/daemon"
+	"github.com/openshift/machine-config-operator/pkg/version"
+	errors "github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	applyCmd = &cobra.Command{
+		Use:                   "apply --config rendered.yaml (--once | --dry-run)",
+		DisableFlagsInUseLine: true,
+		Short:                 "Apply a rendered MachineConfig to the host without a cluster",
+		Long: `Apply a local rendered MachineConfig to the host a single time and exit, without any
+cluster connection. This is meant for image building pipelines, appliance provisioning
+and recovering nodes whose kubelet can't reach the API server.`,
+		Args: cobra.MaximumNArgs(0),
+		Run:  executeApply,
+	}
+
+	applyOpts struct {
+		config     string
+		once       bool
+		rootMount  string
+		skipReboot bool
+		dryRun     bool
+		output     string
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+	applyCmd.PersistentFlags().StringVar(&applyOpts.config, "config", "", "Path to the rendered MachineConfig to apply, in YAML or JSON")
+	applyCmd.PersistentFlags().BoolVar(&applyOpts.once, "once", false, "Apply the config a single time and exit, required as apply does not watch the config for changes")
+	applyCmd.PersistentFlags().StringVar(&applyOpts.rootMount, "root-mount", "/", "where the nodes root filesystem is mounted for chroot and file manipulation.")
+	applyCmd.PersistentFlags().BoolVar(&applyOpts.skipReboot, "skip-reboot", false, "Skips the reboot after applying the config")
+	applyCmd.PersistentFlags().BoolVar(&applyOpts.dryRun, "dry-run", false, "Print what applying the config would do to the host without doing it")
+	applyCmd.PersistentFlags().StringVarP(&applyOpts.output, "output", "o", "text", "Format of the --dry-run plan, text or json")
+}
+
+func runApply(_ *cobra.Command, _ []string) error {
+	flag.Set("logtostderr", "true")
+	flag.Parse()
+
+	glog.Infof("Version: %+v (%s)", version.Raw, version.Hash)
+
+	if applyOpts.config == "" {
+		return errors.New("--config is required")
+	}
+	if applyOpts.output != "text" && applyOpts.output != "json" {
+		return errors.Errorf("invalid --output %q, must be text or json", applyOpts.output)
+	}
+	if !applyOpts.once && !applyOpts.dryRun {
+		return errors.New("--once is required, apply does not watch the config for changes")
+	}
+
+	// See https://github.com/coreos/rpm-ostree/pull/1880
+	os.Setenv("RPMOSTREE_CLIENT_ID", "machine-config-operator")
+
+	// The config is read before the chroot so paths outside of the root mount keep working
+	content, err := ioutil.ReadFile(applyOpts.config)
+	if err != nil {
+		return err
+	}
+	mc, err := resourceread.ReadMachineConfigV1(content)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse MachineConfig from %s", applyOpts.config)
+	}
+
+	if applyOpts.rootMount != "/" {
+		glog.Infof(`Calling chroot("%s")`, applyOpts.rootMount)
+		if err := syscall.Chroot(applyOpts.rootMount); err != nil {
+			return errors.Wrapf(err, "unable to chroot to %s", applyOpts.rootMount)
+		}
+		if err := os.Chdir("/"); err != nil {
+			return errors.Wrap(err, "unable to change directory to /")
+		}
+	}
+
+	exitCh := make(chan error)
+	defer close(exitCh)
+
+	dn, err := daemon.New(daemon.NewNodeUpdaterClient(), exitCh)
+	if err != nil {
+		return err
+	}
+
+	if applyOpts.dryRun {
+		plan, err := dn.PlanApply(mc)
+		if err != nil {
+			return err
+		}
+		if applyOpts.output == "json" {
+			out, err := json.MarshalIndent(plan, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+		} else {
+			fmt.Print(plan.String())
+		}
+		return nil
+	}
+
+	return dn.RunApply(context.Background(), mc, applyOpts.skipReboot)
+}
+
+func executeApply(cmd *cobra.Command, args []string) {
+	err := runApply(cmd, args)
+	if err != nil {
+		fmt.Printf("error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
@@ -36,7 +37,7 @@ func runFirstBootCompleteMachineConfig(_ *cobra.Command, _ []string) error {
 		return err
 	}
 
-	return dn.RunFirstbootCompleteMachineconfig()
+	return dn.RunFirstbootCompleteMachineconfig(context.Background())
 }
 
 func executeFirstbootCompleteMachineConfig(cmd *cobra.Command, args []string) {
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"io/ioutil"
@@ -61,11 +62,16 @@ func run(_ *cobra.Command, args []string) (retErr error) {
 
 	client := daemon.NewNodeUpdaterClient()
 
-	osImageContentDir, err := daemon.ExtractOSImage(container)
-	if err != nil {
-		return err
+	// rpm-ostree pulls the OSTree container images itself
+	var osImageContentDir string
+	if !daemon.IsOSTreeContainerReference(container) {
+		var err error
+		osImageContentDir, err = daemon.ExtractOSImage(container)
+		if err != nil {
+			return err
+		}
 	}
-	changed, err := client.Rebase(container, osImageContentDir)
+	changed, err := client.Rebase(context.Background(), container, osImageContentDir)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"syscall"
+
+	"github.com/golang/glog"
+	daemon "github.com/openshift/machine-config-operator/pkg/daemon"
+	"github.com/openshift/machine-config-operator/pkg/version"
+	errors "github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	recoverKubeletCertCmd = &cobra.Command{
+		Use:                   "recover-kubelet-cert [--pool worker]",
+		DisableFlagsInUseLine: true,
+		Short:                 "Re-bootstrap the kubelet if its client certificate expired",
+		Long: `Re-bootstrap the kubelet of a node whose client certificate expired, e.g. while it was
+powered off, using the bootstrap kubeconfig served by the MCS. The daemon does this itself
+once it runs, this is for nodes where it can't be scheduled because the kubelet is down.`,
+		Args: cobra.MaximumNArgs(0),
+		Run:  executeRecoverKubeletCert,
+	}
+
+	recoverKubeletCertOpts struct {
+		pool      string
+		rootMount string
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(recoverKubeletCertCmd)
+	recoverKubeletCertCmd.PersistentFlags().StringVar(&recoverKubeletCertOpts.pool, "pool", "worker", "MachineConfigPool whose config the MCS serves the bootstrap kubeconfig with")
+	recoverKubeletCertCmd.PersistentFlags().StringVar(&recoverKubeletCertOpts.rootMount, "root-mount", "/", "where the nodes root filesystem is mounted for chroot and file manipulation.")
+}
+
+func runRecoverKubeletCert(_ *cobra.Command, _ []string) error {
+	flag.Set("logtostderr", "true")
+	flag.Parse()
+
+	glog.Infof("Version: %+v (%s)", version.Raw, version.Hash)
+
+	if recoverKubeletCertOpts.rootMount != "/" {
+		glog.Infof(`Calling chroot("%s")`, recoverKubeletCertOpts.rootMount)
+		if err := syscall.Chroot(recoverKubeletCertOpts.rootMount); err != nil {
+			return errors.Wrapf(err, "unable to chroot to %s", recoverKubeletCertOpts.rootMount)
+		}
+		if err := os.Chdir("/"); err != nil {
+			return errors.Wrap(err, "unable to change directory to /")
+		}
+	}
+
+	recovered, err := daemon.RecoverKubeletClientCert(recoverKubeletCertOpts.pool)
+	if err != nil {
+		return err
+	}
+	if !recovered {
+		glog.Info("Kubelet client certificate has not expired, nothing to do")
+	}
+	return nil
+}
+
+func executeRecoverKubeletCert(cmd *cobra.Command, args []string) {
+	err := runRecoverKubeletCert(cmd, args)
+	if err != nil {
+		fmt.Printf("error: %v\n", err)
+		os.Exit(1)
+	}
+}
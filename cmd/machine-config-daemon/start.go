@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"flag"
 	"io"
 	"os"
@@ -153,7 +154,7 @@ func runStartCmd(cmd *cobra.Command, args []string) {
 	// If we are asked to run once and it's a valid file system path use
 	// the bare Daemon
 	if startOpts.onceFrom != "" {
-		err = dn.RunOnceFrom(startOpts.onceFrom, startOpts.skipReboot)
+		err = dn.RunOnceFrom(context.Background(), startOpts.onceFrom, startOpts.skipReboot)
 		if err != nil {
 			glog.Fatalf("%v", err)
 		}
@@ -184,13 +185,20 @@ func runStartCmd(cmd *cobra.Command, args []string) {
 	// Start local metrics listener
 	go daemon.StartMetricsListener(startOpts.promMetricsURL, stopCh)
 
+	mcfgClient, err := cb.MachineConfigClient(componentName)
+	if err != nil {
+		glog.Fatalf("Cannot initialize mcfgClient: %v", err)
+	}
+
 	ctx := ctrlcommon.CreateControllerContext(cb, stopCh, componentName)
 	// create the daemon instance. this also initializes kube client items
 	// which need to come from the container and not the chroot.
 	dn.ClusterConnect(
 		startOpts.nodeName,
 		kubeClient,
+		mcfgClient,
 		ctx.InformerFactory.Machineconfiguration().V1().MachineConfigs(),
+		ctx.InformerFactory.Machineconfiguration().V1().KernelArgumentPolicies(),
 		ctx.KubeInformerFactory.Core().V1().Nodes(),
 		startOpts.kubeletHealthzEnabled,
 		startOpts.kubeletHealthzEndpoint,
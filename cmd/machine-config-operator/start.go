@@ -4,6 +4,7 @@ import (
 	"context"
 	"flag"
 	"os"
+	"time"
 
 	"github.com/golang/glog"
 	"github.com/openshift/machine-config-operator/cmd/common"
@@ -12,6 +13,7 @@ import (
 	"github.com/openshift/machine-config-operator/pkg/operator"
 	"github.com/openshift/machine-config-operator/pkg/version"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/leaderelection"
 )
 
@@ -24,8 +26,10 @@ var (
 	}
 
 	startOpts struct {
-		kubeconfig string
-		imagesFile string
+		kubeconfig          string
+		imagesFile          string
+		degradedGracePeriod time.Duration
+		healthAddr          string
 	}
 )
 
@@ -33,6 +37,8 @@ func init() {
 	rootCmd.AddCommand(startCmd)
 	startCmd.PersistentFlags().StringVar(&startOpts.kubeconfig, "kubeconfig", "", "Kubeconfig file to access a remote cluster (testing only)")
 	startCmd.PersistentFlags().StringVar(&startOpts.imagesFile, "images-json", "", "images.json file for MCO.")
+	startCmd.PersistentFlags().DurationVar(&startOpts.degradedGracePeriod, "degraded-grace-period", operator.DefaultDegradedGracePeriod, "How long syncs have to keep failing before the MCO reports Degraded.")
+	startCmd.PersistentFlags().StringVar(&startOpts.healthAddr, "health-addr", operator.DefaultHealthBindAddress, "Address to serve the /healthz and /readyz probes on.")
 }
 
 func runStartCmd(cmd *cobra.Command, args []string) {
@@ -50,11 +56,17 @@ func runStartCmd(cmd *cobra.Command, args []string) {
 	if err != nil {
 		glog.Fatalf("error creating clients: %v", err)
 	}
+	// the probes are served by standby operators too, the operator updating the health once leading
+	health := operator.NewHealth()
+	go health.StartHealthListener(startOpts.healthAddr, wait.NeverStop)
+
 	run := func(ctx context.Context) {
 		ctrlctx := ctrlcommon.CreateControllerContext(cb, ctx.Done(), componentNamespace)
 		controller := operator.New(
 			componentNamespace, componentName,
 			startOpts.imagesFile,
+			startOpts.degradedGracePeriod,
+			health,
 			ctrlctx.NamespacedInformerFactory.Machineconfiguration().V1().MachineConfigPools(),
 			ctrlctx.NamespacedInformerFactory.Machineconfiguration().V1().MachineConfigs(),
 			ctrlctx.NamespacedInformerFactory.Machineconfiguration().V1().ControllerConfigs(),
@@ -70,6 +82,8 @@ func runStartCmd(cmd *cobra.Command, args []string) {
 			ctrlctx.ConfigInformerFactory.Config().V1().Networks(),
 			ctrlctx.ConfigInformerFactory.Config().V1().Proxies(),
 			ctrlctx.ConfigInformerFactory.Config().V1().DNSes(),
+			ctrlctx.KubeInformerFactory.Core().V1().Nodes(),
+			ctrlctx.NamespacedInformerFactory.Machineconfiguration().V1().MachineConfigNodes(),
 			ctrlctx.ClientBuilder.MachineConfigClientOrDie(componentName),
 			ctrlctx.ClientBuilder.KubeClientOrDie(componentName),
 			ctrlctx.ClientBuilder.APIExtClientOrDie(componentName),
@@ -0,0 +1,55 @@
+#!/usr/bin/env bash
+
+# Run the machine-config-controller from this checkout against a local development
+# cluster, e.g. kind, with the daemons of the nodes stubbed out.
+#
+# Assumptions: KUBECONFIG points to the cluster, which is not running the MCO.
+# Extra arguments are passed to `machine-config-controller dev`.
+
+set -euo pipefail
+
+REPO=$(cd "$(dirname "${BASH_SOURCE[0]}")/.." && pwd)
+cd "${REPO}"
+
+API=vendor/github.com/openshift/api
+kubectl apply \
+    -f install/0000_80_machine-config-operator_01_containerruntimeconfig.crd.yaml \
+    -f install/0000_80_machine-config-operator_01_kernelargumentpolicy.crd.yaml \
+    -f install/0000_80_machine-config-operator_01_kubeletconfig.crd.yaml \
+    -f install/0000_80_machine-config-operator_01_machineconfig.crd.yaml \
+    -f install/0000_80_machine-config-operator_01_machineconfignode.crd.yaml \
+    -f install/0000_80_machine-config-operator_01_machineconfignodeoverride.crd.yaml \
+    -f install/0000_80_machine-config-operator_01_machineconfigpool.crd.yaml \
+    -f install/0000_80_machine-config-operator_01_nodeconfig.crd.yaml \
+    -f manifests/controllerconfig.crd.yaml \
+    -f ${API}/config/v1/0000_00_cluster-version-operator_01_clusterversion.crd.yaml \
+    -f ${API}/config/v1/0000_10_config-operator_01_featuregate.crd.yaml \
+    -f ${API}/config/v1/0000_10_config-operator_01_image.crd.yaml \
+    -f ${API}/config/v1/0000_10_config-operator_01_scheduler.crd.yaml \
+    -f ${API}/operator/v1alpha1/0000_10_config-operator_01_imagecontentsourcepolicy.crd.yaml
+kubectl wait --for condition=established --timeout=60s crd --all
+
+for ns in openshift-config openshift-machine-config-operator; do
+    kubectl create namespace "${ns}" --dry-run=client -o yaml | kubectl apply -f -
+done
+
+FIXTURES=pkg/controller/bootstrap/testdata/bootstrap
+kubectl apply \
+    -f ${FIXTURES}/machineconfigcontroller-pull-secret \
+    -f ${FIXTURES}/machineconfigcontroller-controllerconfig.yaml \
+    -f ${FIXTURES}/master.machineconfigpool.yaml \
+    -f ${FIXTURES}/worker.machineconfigpool.yaml \
+    -f ${FIXTURES}/99_openshift-machineconfig_master.yaml \
+    -f ${FIXTURES}/99_openshift-machineconfig_worker.yaml
+
+# kind labels its control plane nodes control-plane, the pools select on master and worker
+for node in $(kubectl get nodes -o name); do
+    if kubectl get "${node}" -o jsonpath='{.metadata.labels}' | grep -q 'node-role.kubernetes.io/\(control-plane\|master\)'; then
+        kubectl label --overwrite "${node}" node-role.kubernetes.io/master=
+    else
+        kubectl label --overwrite "${node}" node-role.kubernetes.io/worker=
+    fi
+done
+
+WHAT=machine-config-controller hack/build-go.sh
+exec _output/$(go env GOHOSTOS)/$(go env GOHOSTARCH)/machine-config-controller dev --kubeconfig "${KUBECONFIG:-${HOME}/.kube/config}" "$@"
@@ -0,0 +1,229 @@
+// Package stubdaemon stands in for the machine-config-daemon of every node, so the controllers
+// can be run in development against clusters without CoreOS nodes, e.g. kind.
+package stubdaemon
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	coreinformersv1 "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	corelisterv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/openshift/machine-config-operator/internal"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	mcfginformersv1 "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions/machineconfiguration.openshift.io/v1"
+	mcfglistersv1 "github.com/openshift/machine-config-operator/pkg/generated/listers/machineconfiguration.openshift.io/v1"
+)
+
+// StubDaemon moves the nodes to their desired config by updating their annotations the way
+// the daemon does, after pretending to work on the update for a while
+type StubDaemon struct {
+	kubeClient kubernetes.Interface
+
+	nodeLister       corelisterv1.NodeLister
+	nodeListerSynced cache.InformerSynced
+	mcpLister        mcfglistersv1.MachineConfigPoolLister
+	mcpListerSynced  cache.InformerSynced
+
+	queue workqueue.RateLimitingInterface
+	// updateDuration is how long a node stays Working before it is at its desired config
+	updateDuration time.Duration
+}
+
+// New returns a StubDaemon
+func New(
+	nodeInformer coreinformersv1.NodeInformer,
+	mcpInformer mcfginformersv1.MachineConfigPoolInformer,
+	kubeClient kubernetes.Interface,
+	updateDuration time.Duration,
+) *StubDaemon {
+	s := &StubDaemon{
+		kubeClient:     kubeClient,
+		queue:          workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "machineconfigcontroller-stubdaemon"),
+		updateDuration: updateDuration,
+	}
+
+	nodeInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    s.enqueueNode,
+		UpdateFunc: func(old, cur interface{}) { s.enqueueNode(cur) },
+	})
+	// nodes without any annotation wait for their pool to have a config
+	mcpInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { s.enqueueAllNodes() },
+		UpdateFunc: func(old, cur interface{}) { s.enqueueAllNodes() },
+	})
+
+	s.nodeLister = nodeInformer.Lister()
+	s.nodeListerSynced = nodeInformer.Informer().HasSynced
+	s.mcpLister = mcpInformer.Lister()
+	s.mcpListerSynced = mcpInformer.Informer().HasSynced
+	return s
+}
+
+// Run executes the stub daemon.
+func (s *StubDaemon) Run(workers int, stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	defer s.queue.ShutDown()
+
+	if !cache.WaitForCacheSync(stopCh, s.nodeListerSynced, s.mcpListerSynced) {
+		return
+	}
+
+	glog.Info("Starting MachineConfigController-StubDaemon")
+	defer glog.Info("Shutting down MachineConfigController-StubDaemon")
+
+	for i := 0; i < workers; i++ {
+		go wait.Until(s.worker, time.Second, stopCh)
+	}
+
+	<-stopCh
+}
+
+func (s *StubDaemon) enqueueNode(obj interface{}) {
+	node, ok := obj.(*corev1.Node)
+	if !ok {
+		utilruntime.HandleError(fmt.Errorf("couldn't get node from object %#v", obj))
+		return
+	}
+	s.queue.Add(node.Name)
+}
+
+func (s *StubDaemon) enqueueAllNodes() {
+	nodes, err := s.nodeLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	for _, node := range nodes {
+		s.queue.Add(node.Name)
+	}
+}
+
+func (s *StubDaemon) worker() {
+	for s.processNextWorkItem() {
+	}
+}
+
+func (s *StubDaemon) processNextWorkItem() bool {
+	key, quit := s.queue.Get()
+	if quit {
+		return false
+	}
+	defer s.queue.Done(key)
+
+	if err := s.syncNode(key.(string)); err != nil {
+		utilruntime.HandleError(err)
+		s.queue.AddRateLimited(key)
+		return true
+	}
+	s.queue.Forget(key)
+	return true
+}
+
+func (s *StubDaemon) syncNode(name string) error {
+	node, err := s.nodeLister.Get(name)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	pools, err := s.mcpLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	annotations := nextNodeAnnotations(node, pools)
+	if len(annotations) == 0 {
+		return nil
+	}
+	glog.Infof("Stub daemon setting %v on node %s", annotations, name)
+	_, err = internal.UpdateNodeRetry(s.kubeClient.CoreV1().Nodes(), s.nodeLister, name, func(node *corev1.Node) {
+		if node.Annotations == nil {
+			node.Annotations = map[string]string{}
+		}
+		for k, v := range annotations {
+			node.Annotations[k] = v
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if annotations[constants.MachineConfigDaemonStateAnnotationKey] == constants.MachineConfigDaemonStateWorking {
+		s.queue.AddAfter(name, s.updateDuration)
+	}
+	return nil
+}
+
+// nextNodeAnnotations returns the annotations the daemon of node would set next:
+// the config of its pool if it has none yet, Working once it has a new desired config
+// and the desired config once it worked on it
+func nextNodeAnnotations(node *corev1.Node, pools []*mcfgv1.MachineConfigPool) map[string]string {
+	current := node.Annotations[constants.CurrentMachineConfigAnnotationKey]
+	desired := node.Annotations[constants.DesiredMachineConfigAnnotationKey]
+	state := node.Annotations[constants.MachineConfigDaemonStateAnnotationKey]
+
+	if current == "" {
+		// a new node, served the config of its pool
+		config := poolConfigForNode(node, pools)
+		if config == "" {
+			return nil
+		}
+		return map[string]string{
+			constants.CurrentMachineConfigAnnotationKey:     config,
+			constants.DesiredMachineConfigAnnotationKey:     config,
+			constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateDone,
+		}
+	}
+	if desired == "" || desired == current {
+		if state == constants.MachineConfigDaemonStateWorking {
+			return map[string]string{constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateDone}
+		}
+		return nil
+	}
+	if state != constants.MachineConfigDaemonStateWorking {
+		return map[string]string{constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateWorking}
+	}
+	return map[string]string{
+		constants.CurrentMachineConfigAnnotationKey:     desired,
+		constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateDone,
+	}
+}
+
+// poolConfigForNode returns the rendered config of the pool of node, custom pools taking
+// precedence over the worker pool like in the node controller
+func poolConfigForNode(node *corev1.Node, pools []*mcfgv1.MachineConfigPool) string {
+	var matching []*mcfgv1.MachineConfigPool
+	for _, pool := range pools {
+		selector, err := metav1.LabelSelectorAsSelector(pool.Spec.NodeSelector)
+		if err != nil || selector.Empty() || !selector.Matches(labels.Set(node.Labels)) {
+			continue
+		}
+		if pool.Spec.Configuration.Name == "" {
+			continue
+		}
+		matching = append(matching, pool)
+	}
+	sort.Slice(matching, func(i, j int) bool {
+		if (matching[i].Name == "worker") != (matching[j].Name == "worker") {
+			return matching[j].Name == "worker"
+		}
+		return matching[i].Name < matching[j].Name
+	})
+	if len(matching) == 0 {
+		return ""
+	}
+	return matching[0].Spec.Configuration.Name
+}
@@ -0,0 +1,94 @@
+package stubdaemon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func newNode(labels map[string]string, current, desired, state string) *corev1.Node {
+	annotations := map[string]string{}
+	if current != "" {
+		annotations[constants.CurrentMachineConfigAnnotationKey] = current
+	}
+	if desired != "" {
+		annotations[constants.DesiredMachineConfigAnnotationKey] = desired
+	}
+	if state != "" {
+		annotations[constants.MachineConfigDaemonStateAnnotationKey] = state
+	}
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-0", Labels: labels, Annotations: annotations},
+	}
+}
+
+func TestNextNodeAnnotations(t *testing.T) {
+	infraSelector := metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/infra", "")
+	pools := []*mcfgv1.MachineConfigPool{
+		helpers.NewMachineConfigPool("master", nil, helpers.MasterSelector, "rendered-master-1"),
+		helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "rendered-worker-1"),
+		helpers.NewMachineConfigPool("infra", nil, infraSelector, "rendered-infra-1"),
+		helpers.NewMachineConfigPool("unrendered", nil, helpers.WorkerSelector, ""),
+	}
+	worker := map[string]string{"node-role/worker": ""}
+	infra := map[string]string{"node-role/worker": "", "node-role/infra": ""}
+
+	tests := []struct {
+		name     string
+		node     *corev1.Node
+		expected map[string]string
+	}{{
+		name: "new node gets the config of its pool",
+		node: newNode(worker, "", "", ""),
+		expected: map[string]string{
+			constants.CurrentMachineConfigAnnotationKey:     "rendered-worker-1",
+			constants.DesiredMachineConfigAnnotationKey:     "rendered-worker-1",
+			constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateDone,
+		},
+	}, {
+		name: "custom pool takes precedence over worker",
+		node: newNode(infra, "", "", ""),
+		expected: map[string]string{
+			constants.CurrentMachineConfigAnnotationKey:     "rendered-infra-1",
+			constants.DesiredMachineConfigAnnotationKey:     "rendered-infra-1",
+			constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateDone,
+		},
+	}, {
+		name: "new node without a pool is left alone",
+		node: newNode(map[string]string{"node-role/other": ""}, "", "", ""),
+	}, {
+		name: "node at its desired config is left alone",
+		node: newNode(worker, "rendered-worker-1", "rendered-worker-1", constants.MachineConfigDaemonStateDone),
+	}, {
+		name:     "new desired config starts working",
+		node:     newNode(worker, "rendered-worker-1", "rendered-worker-2", constants.MachineConfigDaemonStateDone),
+		expected: map[string]string{constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateWorking},
+	}, {
+		name: "working node reaches its desired config",
+		node: newNode(worker, "rendered-worker-1", "rendered-worker-2", constants.MachineConfigDaemonStateWorking),
+		expected: map[string]string{
+			constants.CurrentMachineConfigAnnotationKey:     "rendered-worker-2",
+			constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateDone,
+		},
+	}, {
+		name:     "desired config reverted while working",
+		node:     newNode(worker, "rendered-worker-1", "rendered-worker-1", constants.MachineConfigDaemonStateWorking),
+		expected: map[string]string{constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateDone},
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := nextNodeAnnotations(test.node, pools)
+			if test.expected == nil {
+				assert.Empty(t, got)
+			} else {
+				assert.Equal(t, test.expected, got)
+			}
+		})
+	}
+}
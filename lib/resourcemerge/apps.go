@@ -18,6 +18,14 @@ func EnsureDeployment(modified *bool, existing *appsv1.Deployment, required apps
 		*modified = true
 		existing.Spec.Selector = required.Spec.Selector
 	}
+	// the replicas and the strategy are defaulted by the API server
+	if required.Spec.Replicas != nil {
+		setInt32Ptr(modified, &existing.Spec.Replicas, required.Spec.Replicas)
+	}
+	if required.Spec.Strategy.Type != "" && !equality.Semantic.DeepEqual(existing.Spec.Strategy, required.Spec.Strategy) {
+		*modified = true
+		existing.Spec.Strategy = required.Spec.Strategy
+	}
 
 	ensurePodTemplateSpec(modified, &existing.Spec.Template, required.Spec.Template)
 }
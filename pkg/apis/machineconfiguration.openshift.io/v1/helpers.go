@@ -101,6 +101,26 @@ func NewContainerRuntimeConfigCondition(condType ContainerRuntimeConfigStatusCon
 	}
 }
 
+// NewNodeConfigCondition returns an instance of a NodeConfigCondition
+func NewNodeConfigCondition(condType NodeConfigStatusConditionType, status corev1.ConditionStatus, message string) *NodeConfigCondition {
+	return &NodeConfigCondition{
+		Type:               condType,
+		Status:             status,
+		LastTransitionTime: metav1.Now(),
+		Message:            message,
+	}
+}
+
+// NewMachineConfigNodeOverrideCondition returns an instance of a MachineConfigNodeOverrideCondition
+func NewMachineConfigNodeOverrideCondition(condType MachineConfigNodeOverrideStatusConditionType, status corev1.ConditionStatus, message string) *MachineConfigNodeOverrideCondition {
+	return &MachineConfigNodeOverrideCondition{
+		Type:               condType,
+		Status:             status,
+		LastTransitionTime: metav1.Now(),
+		Message:            message,
+	}
+}
+
 // NewControllerConfigStatusCondition creates a new ControllerConfigStatus condition.
 func NewControllerConfigStatusCondition(condType ControllerConfigStatusConditionType, status corev1.ConditionStatus, reason, message string) *ControllerConfigStatusCondition {
 	return &ControllerConfigStatusCondition{
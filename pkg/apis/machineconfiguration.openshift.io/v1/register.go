@@ -28,12 +28,20 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&ContainerRuntimeConfigList{},
 		&ControllerConfig{},
 		&ControllerConfigList{},
+		&KernelArgumentPolicy{},
+		&KernelArgumentPolicyList{},
 		&KubeletConfig{},
 		&KubeletConfigList{},
 		&MachineConfig{},
 		&MachineConfigList{},
+		&MachineConfigNode{},
+		&MachineConfigNodeList{},
+		&MachineConfigNodeOverride{},
+		&MachineConfigNodeOverrideList{},
 		&MachineConfigPool{},
 		&MachineConfigPoolList{},
+		&NodeConfig{},
+		&NodeConfigList{},
 	)
 
 	metav1.AddToGroupVersion(scheme, GroupVersion)
@@ -151,6 +151,10 @@ const (
 
 	// TemplateControllerFailing means the template controller is failing.
 	TemplateControllerFailing ControllerConfigStatusConditionType = "TemplateControllerFailing"
+
+	// ControllerConfigSchemaMigrated means the stored ControllerConfig was migrated to the latest
+	// schema version by the operator, it is False with the error when a migration failed.
+	ControllerConfigSchemaMigrated ControllerConfigStatusConditionType = "SchemaMigrated"
 )
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -234,10 +238,149 @@ type MachineConfigPoolSpec struct {
 	// default is 1.
 	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
 
+	// maxUnavailablePerZone additionally limits the machines that can be updating at any given time
+	// in each topology zone, as set by the topology.kubernetes.io/zone label of the nodes. It specifies
+	// the percentage of the machines of the pool in the zone or a constant number, at least 1.
+	// Machines aren't limited per zone when unset.
+	// +optional
+	MaxUnavailablePerZone *intstr.IntOrString `json:"maxUnavailablePerZone,omitempty"`
+
 	// The targeted MachineConfig object for the machine config pool.
 	Configuration MachineConfigPoolStatusConfiguration `json:"configuration"`
+
+	// nodeLabels are set on the nodes of the pool once they are updated to the
+	// targeted MachineConfig. Labels removed from the list are removed from the nodes.
+	// +optional
+	NodeLabels map[string]string `json:"nodeLabels,omitempty"`
+
+	// nodeTaints are set on the nodes of the pool once they are updated to the
+	// targeted MachineConfig. Taints removed from the list are removed from the nodes.
+	// +optional
+	NodeTaints []corev1.Taint `json:"nodeTaints,omitempty"`
+
+	// maxConcurrentImagePulls enables pre-pulling the OS image of the targeted MachineConfig on
+	// the machines of the pool before their update, including while the pool is paused, and
+	// specifies the percentage or constant number of machines that can be pulling it at any given time.
+	// Pre-pulling is disabled when unset.
+	// +optional
+	MaxConcurrentImagePulls *intstr.IntOrString `json:"maxConcurrentImagePulls,omitempty"`
+
+	// stageOSUpdates also stages the OS update of the machines pre-pulling the OS image of the
+	// targeted MachineConfig, only leaving the drain and reboot to their update. A machine
+	// rebooting before its update discards the staged OS update. It requires maxConcurrentImagePulls.
+	// +optional
+	StageOSUpdates bool `json:"stageOSUpdates,omitempty"`
+
+	// rollbackDeployments is how many OS deployments the machines of the pool keep to roll back
+	// to once updated, besides the pinned last known-good one. Only 0 and 1 are supported,
+	// rpm-ostree keeps a single rollback deployment so more of them can't be kept. 0 removes it
+	// to save space on small disks. Unset keeps the rpm-ostree default of 1.
+	// +optional
+	RollbackDeployments *int32 `json:"rollbackDeployments,omitempty"`
+
+	// drainWatchdog configures how long draining a node of the pool can take before the drain is
+	// reported stuck, and the escalation taken then.
+	// +optional
+	DrainWatchdog *MachineConfigPoolDrainWatchdog `json:"drainWatchdog,omitempty"`
+
+	// gracefulReboot schedules the reboots of the machines of the pool with systemd-logind,
+	// giving their workloads a predictable window, instead of rebooting them at once. The
+	// reboot after an update is cancelled if the machine is targeted at another config meanwhile.
+	// +optional
+	GracefulReboot *MachineConfigPoolGracefulReboot `json:"gracefulReboot,omitempty"`
+
+	// maintenanceWindow restricts when the machines of the pool start updating, or rebooting for
+	// a reboot request. The rendered config is still generated at once, and the machines already
+	// updating finish. Machines update at any time when unset.
+	// +optional
+	MaintenanceWindow *MachineConfigPoolMaintenanceWindow `json:"maintenanceWindow,omitempty"`
+
+	// updateOrder is the order the machines of the pool are updated in, and rebooted in for a
+	// reboot request. Machines are updated in the alphabetical order of their names when unset.
+	// +optional
+	UpdateOrder *MachineConfigPoolUpdateOrder `json:"updateOrder,omitempty"`
+}
+
+// MachineConfigPoolMaintenanceWindow is a recurring window the machines of a pool update in.
+// Only UTC is supported, the window doesn't follow a time zone or its daylight saving time.
+type MachineConfigPoolMaintenanceWindow struct {
+	// startTime is the time of day the window opens at, HH:MM in UTC. Time zones aren't supported.
+	StartTime string `json:"startTime"`
+
+	// duration is how long the window stays open.
+	Duration metav1.Duration `json:"duration"`
+
+	// days are the days of the week the window opens on, e.g. Saturday. default is every day.
+	// +optional
+	Days []string `json:"days,omitempty"`
+}
+
+// MachineConfigPoolUpdateOrder configures the order the machines of a pool are updated in.
+type MachineConfigPoolUpdateOrder struct {
+	// strategy orders the machines. default is Alphabetical.
+	// +optional
+	Strategy UpdateOrderStrategy `json:"strategy,omitempty"`
+
+	// labelKey is the label of the nodes the Label strategy orders the machines by.
+	// +optional
+	LabelKey string `json:"labelKey,omitempty"`
+}
+
+// UpdateOrderStrategy is how the machines of a pool are ordered for their update. Machines
+// ordered the same are updated in the alphabetical order of their names.
+type UpdateOrderStrategy string
+
+const (
+	// UpdateOrderAlphabetical updates the machines in the alphabetical order of their names.
+	UpdateOrderAlphabetical UpdateOrderStrategy = "Alphabetical"
+	// UpdateOrderCreationTime updates the oldest machines first.
+	UpdateOrderCreationTime UpdateOrderStrategy = "CreationTime"
+	// UpdateOrderZone updates the machines one topology zone at a time, in the alphabetical order
+	// of the zones, only starting on a zone once the machines of the previous one are updated.
+	// Machines without a zone are updated last.
+	UpdateOrderZone UpdateOrderStrategy = "Zone"
+	// UpdateOrderLabel updates the machines in the alphabetical order of the value of their
+	// labelKey label. Machines without the label are updated last.
+	UpdateOrderLabel UpdateOrderStrategy = "Label"
+)
+
+// MachineConfigPoolGracefulReboot configures the scheduled reboots of the machines of a pool.
+type MachineConfigPoolGracefulReboot struct {
+	// delay is how long after a machine is ready to reboot it reboots.
+	Delay metav1.Duration `json:"delay"`
+
+	// wallMessage is broadcast to the users logged in to the machine when its reboot is scheduled.
+	// +optional
+	WallMessage string `json:"wallMessage,omitempty"`
+}
+
+// MachineConfigPoolDrainWatchdog configures the detection and escalation of stuck drains.
+type MachineConfigPoolDrainWatchdog struct {
+	// timeout is how long draining a node can take before it is reported stuck. default is 1h.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// escalation is taken once the drain of a node is stuck. default is Alert.
+	// +optional
+	Escalation DrainEscalation `json:"escalation,omitempty"`
 }
 
+// DrainEscalation is what is done about a stuck drain.
+type DrainEscalation string
+
+const (
+	// DrainEscalationAlert only reports the stuck drain, in the pool status, events and an alert.
+	DrainEscalationAlert DrainEscalation = "Alert"
+	// DrainEscalationRetry moves the node back to its current config, uncordoning it, and updates
+	// it again after the other nodes of the pool.
+	DrainEscalationRetry DrainEscalation = "Retry"
+	// DrainEscalationForce deletes the pods left on the node, ignoring their PodDisruptionBudgets.
+	DrainEscalationForce DrainEscalation = "Force"
+	// DrainEscalationSkipNode moves the node back to its current config, uncordoning it, and
+	// doesn't update it to the targeted config until its drainSkipped annotation is removed.
+	DrainEscalationSkipNode DrainEscalation = "SkipNode"
+)
+
 // MachineConfigPoolStatus is the status for MachineConfigPool resource.
 type MachineConfigPoolStatus struct {
 	// observedGeneration represents the generation observed by the controller.
@@ -264,11 +407,88 @@ type MachineConfigPoolStatus struct {
 	// A node is marked degraded if applying a configuration failed..
 	DegradedMachineCount int32 `json:"degradedMachineCount"`
 
+	// updateStatistics summarizes how long the last node updates of the pool took.
+	// +optional
+	UpdateStatistics *MachineConfigPoolUpdateStatistics `json:"updateStatistics,omitempty"`
+
+	// estimatedCompletionTime is when the update in progress is estimated to complete, from the
+	// updateStatistics and maxUnavailable of the pool. It is unset when the pool isn't updating
+	// or no node update was recorded yet.
+	// +optional
+	// +nullable
+	EstimatedCompletionTime *metav1.Time `json:"estimatedCompletionTime,omitempty"`
+
+	// stuckDrains are the drains of nodes of the pool exceeding the timeout of the drain watchdog,
+	// and the nodes skipped because of one.
+	// +optional
+	StuckDrains []MachineConfigPoolStuckDrain `json:"stuckDrains,omitempty"`
+
+	// hostOperatingSystems counts the machines of the pool by the operating system they run,
+	// as published by their daemon.
+	// +optional
+	HostOperatingSystems []MachineConfigPoolHostOperatingSystem `json:"hostOperatingSystems,omitempty"`
+
 	// conditions represents the latest available observations of current state.
 	// +optional
 	Conditions []MachineConfigPoolCondition `json:"conditions"`
 }
 
+// MachineConfigPoolHostOperatingSystem is an operating system run by machines of the pool.
+type MachineConfigPoolHostOperatingSystem struct {
+	// variant is the variant of the operating system, one of rhcos, fcos, scos or rhel.
+	Variant string `json:"variant"`
+
+	// version is the version of the operating system.
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// machineCount is the number of machines running the operating system.
+	MachineCount int32 `json:"machineCount"`
+}
+
+// MachineConfigPoolStuckDrain is a drain of a node of the pool exceeding the timeout of the drain watchdog.
+type MachineConfigPoolStuckDrain struct {
+	// node is the name of the node being drained.
+	Node string `json:"node"`
+
+	// startTime is when the drain started.
+	StartTime metav1.Time `json:"startTime"`
+
+	// blockingPods are the pods left to drain from the node, as namespace/name.
+	// +optional
+	BlockingPods []string `json:"blockingPods,omitempty"`
+
+	// blockingPodDisruptionBudgets are the PodDisruptionBudgets of the blocking pods allowing no
+	// disruption, as namespace/name.
+	// +optional
+	BlockingPodDisruptionBudgets []string `json:"blockingPodDisruptionBudgets,omitempty"`
+
+	// escalation is the escalation taken, empty until it is.
+	// +optional
+	Escalation DrainEscalation `json:"escalation,omitempty"`
+
+	// lastUpdateTime is when the blocking pods were last looked up.
+	LastUpdateTime metav1.Time `json:"lastUpdateTime"`
+}
+
+// MachineConfigPoolUpdateStatistics summarizes the durations of the last node updates of a pool,
+// from the node being targeted at a new config to it being done updating.
+type MachineConfigPoolUpdateStatistics struct {
+	// recentDurations are the durations of the last node updates, oldest first.
+	// +optional
+	RecentDurations []metav1.Duration `json:"recentDurations,omitempty"`
+
+	// averageDuration is the average of recentDurations.
+	AverageDuration metav1.Duration `json:"averageDuration"`
+
+	// maxDuration is the longest of recentDurations.
+	MaxDuration metav1.Duration `json:"maxDuration"`
+
+	// lastUpdateTime is when the last node update recorded completed.
+	// +nullable
+	LastUpdateTime metav1.Time `json:"lastUpdateTime"`
+}
+
 // MachineConfigPoolStatusConfiguration stores the current configuration for the pool, and
 // optionally also stores the list of MachineConfig objects used to generate the configuration.
 type MachineConfigPoolStatusConfiguration struct {
@@ -322,6 +542,13 @@ const (
 
 	// MachineConfigPoolDegraded is the overall status of the pool based, today, on whether we fail with NodeDegraded or RenderDegraded
 	MachineConfigPoolDegraded MachineConfigPoolConditionType = "Degraded"
+
+	// MachineConfigPoolMaintenanceWindowInvalid means the maintenance window of the pool can't be parsed, holding the updates of its machines
+	MachineConfigPoolMaintenanceWindowInvalid MachineConfigPoolConditionType = "MaintenanceWindowInvalid"
+
+	// MachineConfigPoolCRIODropInConflict means MachineConfigs of the pool ship CRI-O drop-ins overriding what ContainerRuntimeConfigs
+	// asked for. The pool is still rendered.
+	MachineConfigPoolCRIODropInConflict MachineConfigPoolConditionType = "CRIODropInConflict"
 )
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -361,6 +588,89 @@ type KubeletConfigSpec struct {
 	// is VersionTLS12.
 	// +optional
 	TLSSecurityProfile *configv1.TLSSecurityProfile `json:"tlsSecurityProfile,omitempty"`
+
+	// credentialProviders lists the image credential provider plugins the kubelet
+	// execs to fetch credentials for the registries they match.
+	// +optional
+	CredentialProviders []KubeletCredentialProvider `json:"credentialProviders,omitempty"`
+
+	// gracefulShutdown delays node shutdowns, including the reboots of updates, for pods to
+	// terminate. The kubelet grace periods and the systemd-logind inhibitor delay they rely
+	// on are set together.
+	// +optional
+	GracefulShutdown *KubeletGracefulShutdown `json:"gracefulShutdown,omitempty"`
+}
+
+// KubeletGracefulShutdown describes how long the kubelet delays a node shutdown for its pods
+type KubeletGracefulShutdown struct {
+	// shutdownGracePeriod is how long a shutdown is delayed for all the pods to terminate.
+	// +required
+	ShutdownGracePeriod metav1.Duration `json:"shutdownGracePeriod"`
+
+	// criticalPodGracePeriod is the part of shutdownGracePeriod reserved for the critical
+	// pods, which are terminated after the others. It must not exceed shutdownGracePeriod.
+	// +optional
+	CriticalPodGracePeriod metav1.Duration `json:"criticalPodGracePeriod,omitempty"`
+}
+
+// KubeletCredentialProviderType is the kind of an image credential provider plugin
+type KubeletCredentialProviderType string
+
+const (
+	// KubeletCredentialProviderECR is the Amazon Elastic Container Registry helper
+	KubeletCredentialProviderECR KubeletCredentialProviderType = "ECR"
+	// KubeletCredentialProviderGCR is the Google Container Registry and Artifact Registry helper
+	KubeletCredentialProviderGCR KubeletCredentialProviderType = "GCR"
+	// KubeletCredentialProviderACR is the Azure Container Registry helper
+	KubeletCredentialProviderACR KubeletCredentialProviderType = "ACR"
+	// KubeletCredentialProviderExec is any other plugin implementing the kubelet credential provider exec API
+	KubeletCredentialProviderExec KubeletCredentialProviderType = "Exec"
+)
+
+// KubeletCredentialProvider describes an image credential provider plugin. The ECR, GCR and
+// ACR types default the binary name and the matched images of the well known helpers.
+type KubeletCredentialProvider struct {
+	// type of the plugin, one of ECR, GCR, ACR or Exec.
+	// +required
+	Type KubeletCredentialProviderType `json:"type"`
+
+	// name of the plugin binary. Required for the Exec type.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// matchImages is the list of image patterns the plugin provides credentials for.
+	// Required for the Exec type.
+	// +optional
+	MatchImages []string `json:"matchImages,omitempty"`
+
+	// defaultCacheDuration is how long the kubelet caches credentials when the plugin
+	// response does not say. Defaults to 12h.
+	// +optional
+	DefaultCacheDuration *metav1.Duration `json:"defaultCacheDuration,omitempty"`
+
+	// apiVersion of the CredentialProviderRequest exchanged with the plugin.
+	// Defaults to credentialprovider.kubelet.k8s.io/v1alpha1.
+	// +optional
+	APIVersion string `json:"apiVersion,omitempty"`
+
+	// args passed to the plugin.
+	// +optional
+	Args []string `json:"args,omitempty"`
+
+	// env variables set for the plugin.
+	// +optional
+	Env []KubeletCredentialProviderEnvVar `json:"env,omitempty"`
+
+	// binary is the plugin executable, encoded as a data URL. When unset, the binary
+	// must already be present in the credential provider directory of the nodes.
+	// +optional
+	Binary string `json:"binary,omitempty"`
+}
+
+// KubeletCredentialProviderEnvVar is an environment variable set for a credential provider plugin
+type KubeletCredentialProviderEnvVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
 }
 
 // KubeletConfigStatus defines the observed state of a KubeletConfig
@@ -453,8 +763,87 @@ type ContainerRuntimeConfiguration struct {
 	// overlaySize specifies the maximum size of a container image.
 	// This flag can be used to set quota on the size of container images. (default: 10GB)
 	OverlaySize resource.Quantity `json:"overlaySize,omitempty"`
+
+	// defaultRuntime is the name of the OCI runtime to be used as the default.
+	// Options are runc and crun. Changing only this field is applied by draining
+	// the node and restarting CRI-O, without a reboot.
+	DefaultRuntime ContainerRuntimeDefaultRuntime `json:"defaultRuntime,omitempty"`
+
+	// imagePolicy specifies the signature verification policy of the images pulled
+	// by the container runtime. It is rendered into /etc/containers/policy.json and is
+	// applied by reloading CRI-O, without a reboot.
+	ImagePolicy *ContainerRuntimeImagePolicy `json:"imagePolicy,omitempty"`
+}
+
+// ContainerRuntimeImagePolicy describes which images the container runtime accepts to pull
+type ContainerRuntimeImagePolicy struct {
+	// scopes lists the requirements for registries, repositories or images. The most
+	// specific scope matching an image applies, images matching no scope follow the
+	// cluster wide policy.
+	Scopes []ContainerRuntimeImagePolicyScope `json:"scopes,omitempty"`
 }
 
+// ContainerRuntimeImagePolicyScope is the requirement images of a registry, repository or image must meet
+type ContainerRuntimeImagePolicyScope struct {
+	// scope is a registry host, a repository or an image reference, e.g.
+	// registry.example.com, registry.example.com/team or registry.example.com/team/app:v1.
+	Scope string `json:"scope"`
+
+	// requirement is one of InsecureAcceptAnything, Reject or SignedBy.
+	Requirement ContainerRuntimeImagePolicyRequirement `json:"requirement"`
+
+	// keyData is the ASCII armored GPG keyring the images must be signed with.
+	// Required for the SignedBy requirement.
+	KeyData string `json:"keyData,omitempty"`
+
+	// signedIdentity is how the identity in the signature must match the pulled image,
+	// one of MatchRepoDigestOrExact, MatchRepository or MatchExact. Defaults to
+	// MatchRepoDigestOrExact.
+	SignedIdentity ContainerRuntimeImagePolicySignedIdentity `json:"signedIdentity,omitempty"`
+
+	// sigstore is the URL of the lookaside storage the signatures of the scope are
+	// fetched from, when the registry does not serve them.
+	Sigstore string `json:"sigstore,omitempty"`
+}
+
+// ContainerRuntimeImagePolicyRequirement is a requirement of containers policy.json
+type ContainerRuntimeImagePolicyRequirement string
+
+const (
+	// ContainerRuntimeImagePolicyInsecureAcceptAnything accepts any image
+	ContainerRuntimeImagePolicyInsecureAcceptAnything ContainerRuntimeImagePolicyRequirement = "InsecureAcceptAnything"
+	// ContainerRuntimeImagePolicyReject rejects every image
+	ContainerRuntimeImagePolicyReject ContainerRuntimeImagePolicyRequirement = "Reject"
+	// ContainerRuntimeImagePolicySignedBy only accepts images signed by keyData
+	ContainerRuntimeImagePolicySignedBy ContainerRuntimeImagePolicyRequirement = "SignedBy"
+)
+
+// ContainerRuntimeImagePolicySignedIdentity is how a signature identity is matched against a pulled image
+type ContainerRuntimeImagePolicySignedIdentity string
+
+const (
+	// ContainerRuntimeImagePolicyMatchRepoDigestOrExact matches the repository when pulling by digest, the exact reference otherwise
+	ContainerRuntimeImagePolicyMatchRepoDigestOrExact ContainerRuntimeImagePolicySignedIdentity = "MatchRepoDigestOrExact"
+	// ContainerRuntimeImagePolicyMatchRepository matches the repository, whatever the tag or digest
+	ContainerRuntimeImagePolicyMatchRepository ContainerRuntimeImagePolicySignedIdentity = "MatchRepository"
+	// ContainerRuntimeImagePolicyMatchExact matches the exact reference
+	ContainerRuntimeImagePolicyMatchExact ContainerRuntimeImagePolicySignedIdentity = "MatchExact"
+)
+
+// ContainerRuntimeDefaultRuntime is the name of an OCI runtime CRI-O can use as its default
+type ContainerRuntimeDefaultRuntime string
+
+const (
+	// ContainerRuntimeDefaultRuntimeEmpty means the default runtime is left unchanged
+	ContainerRuntimeDefaultRuntimeEmpty ContainerRuntimeDefaultRuntime = ""
+	// ContainerRuntimeDefaultRuntimeRunc selects runc as the default runtime
+	ContainerRuntimeDefaultRuntimeRunc ContainerRuntimeDefaultRuntime = "runc"
+	// ContainerRuntimeDefaultRuntimeCrun selects crun as the default runtime
+	ContainerRuntimeDefaultRuntimeCrun ContainerRuntimeDefaultRuntime = "crun"
+	// ContainerRuntimeDefaultRuntimeDefault is the runtime CRI-O uses when none is configured
+	ContainerRuntimeDefaultRuntimeDefault = ContainerRuntimeDefaultRuntimeRunc
+)
+
 // ContainerRuntimeConfigStatus defines the observed state of a ContainerRuntimeConfig
 type ContainerRuntimeConfigStatus struct {
 	// observedGeneration represents the generation observed by the controller.
@@ -506,3 +895,338 @@ type ContainerRuntimeConfigList struct {
 
 	Items []ContainerRuntimeConfig `json:"items"`
 }
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NodeConfig describes settings that apply to all the nodes of the cluster and that the
+// MachineConfigController rolls out pool by pool. Only the instance named "cluster" is honored.
+type NodeConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +required
+	Spec NodeConfigSpec `json:"spec"`
+	// +optional
+	Status NodeConfigStatus `json:"status"`
+}
+
+// NodeConfigSpec defines the desired state of NodeConfig
+type NodeConfigSpec struct {
+	// cgroupMode is the cgroup hierarchy the nodes run with, v1 or v2.
+	// If unset, the default of the release (v1) is used.
+	// +optional
+	CgroupMode CgroupMode `json:"cgroupMode,omitempty"`
+}
+
+// CgroupMode is the cgroup hierarchy a node runs with
+type CgroupMode string
+
+const (
+	// CgroupModeEmpty means the default cgroup hierarchy of the release
+	CgroupModeEmpty CgroupMode = ""
+	// CgroupModeV1 is the legacy cgroup v1 hierarchy
+	CgroupModeV1 CgroupMode = "v1"
+	// CgroupModeV2 is the unified cgroup v2 hierarchy
+	CgroupModeV2 CgroupMode = "v2"
+	// CgroupModeDefault is the cgroup hierarchy used when cgroupMode is unset
+	CgroupModeDefault = CgroupModeV1
+)
+
+// NodeConfigStatus defines the observed state of a NodeConfig
+type NodeConfigStatus struct {
+	// observedGeneration represents the generation observed by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// pools lists, in rollout order, the cgroup mode every MachineConfigPool has been switched to.
+	// +optional
+	Pools []NodeConfigPoolStatus `json:"pools,omitempty"`
+
+	// conditions represents the latest available observations of current state.
+	// +optional
+	Conditions []NodeConfigCondition `json:"conditions"`
+}
+
+// NodeConfigPoolStatus is the rollout state of a NodeConfig on a single MachineConfigPool
+type NodeConfigPoolStatus struct {
+	// name is the name of the MachineConfigPool.
+	Name string `json:"name"`
+
+	// cgroupMode is the cgroup mode the pool has been rendered with.
+	CgroupMode CgroupMode `json:"cgroupMode"`
+
+	// updated is true once every node of the pool runs with cgroupMode and is ready.
+	Updated bool `json:"updated"`
+}
+
+// NodeConfigCondition defines the state of the NodeConfig
+type NodeConfigCondition struct {
+	// type specifies the state of the operator's reconciliation functionality.
+	Type NodeConfigStatusConditionType `json:"type"`
+
+	// status of the condition, one of True, False, Unknown.
+	Status corev1.ConditionStatus `json:"status"`
+
+	// lastTransitionTime is the time of the last update to the current status object.
+	// +nullable
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+
+	// reason is the reason for the condition's last transition.  Reasons are PascalCase
+	Reason string `json:"reason,omitempty"`
+
+	// message provides additional information about the current condition.
+	// This is only to be consumed by humans.
+	Message string `json:"message,omitempty"`
+}
+
+// NodeConfigStatusConditionType is the state of the operator's reconciliation functionality.
+type NodeConfigStatusConditionType string
+
+const (
+	// NodeConfigProgressing designates a NodeConfig that is being rolled out to the pools.
+	NodeConfigProgressing NodeConfigStatusConditionType = "Progressing"
+
+	// NodeConfigSuccess designates a NodeConfig rolled out to every pool.
+	NodeConfigSuccess NodeConfigStatusConditionType = "Success"
+
+	// NodeConfigFailure designates a failure applying a NodeConfig.
+	NodeConfigFailure NodeConfigStatusConditionType = "Failure"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NodeConfigList is a list of NodeConfig resources
+type NodeConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []NodeConfig `json:"items"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// MachineConfigNodeOverride layers additional config on top of the rendered config of the pool
+// of a single node, e.g. a static IP or node specific certificates, instead of giving the node
+// a pool of its own.
+type MachineConfigNodeOverride struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +required
+	Spec MachineConfigNodeOverrideSpec `json:"spec"`
+	// +optional
+	Status MachineConfigNodeOverrideStatus `json:"status"`
+}
+
+// MachineConfigNodeOverrideSpec defines the desired state of MachineConfigNodeOverride
+type MachineConfigNodeOverrideSpec struct {
+	// nodeName is the name of the node the config applies to.
+	NodeName string `json:"nodeName"`
+
+	// config is the Ignition config layered on top of the rendered config of the pool of the node.
+	// +nullable
+	Config runtime.RawExtension `json:"config"`
+}
+
+// MachineConfigNodeOverrideStatus defines the observed state of a MachineConfigNodeOverride
+type MachineConfigNodeOverrideStatus struct {
+	// observedGeneration represents the generation observed by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// pool is the name of the MachineConfigPool of the node.
+	// +optional
+	Pool string `json:"pool,omitempty"`
+
+	// configurations lists, most recent first, the rendered configs of the node for the last
+	// rendered configs of its pool.
+	// +optional
+	Configurations []MachineConfigNodeOverrideConfiguration `json:"configurations,omitempty"`
+
+	// conditions represents the latest available observations of current state.
+	// +optional
+	Conditions []MachineConfigNodeOverrideCondition `json:"conditions"`
+}
+
+// MachineConfigNodeOverrideConfiguration is the rendered config of the node for a rendered config of its pool
+type MachineConfigNodeOverrideConfiguration struct {
+	// poolConfig is the name of a rendered config of the pool.
+	PoolConfig string `json:"poolConfig"`
+
+	// nodeConfig is the name of the rendered config made of poolConfig with config layered on top.
+	NodeConfig string `json:"nodeConfig"`
+}
+
+// MachineConfigNodeOverrideCondition defines the state of the MachineConfigNodeOverride
+type MachineConfigNodeOverrideCondition struct {
+	// type specifies the state of the operator's reconciliation functionality.
+	Type MachineConfigNodeOverrideStatusConditionType `json:"type"`
+
+	// status of the condition, one of True, False, Unknown.
+	Status corev1.ConditionStatus `json:"status"`
+
+	// lastTransitionTime is the time of the last update to the current status object.
+	// +nullable
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+
+	// reason is the reason for the condition's last transition.  Reasons are PascalCase
+	Reason string `json:"reason,omitempty"`
+
+	// message provides additional information about the current condition.
+	// This is only to be consumed by humans.
+	Message string `json:"message,omitempty"`
+}
+
+// MachineConfigNodeOverrideStatusConditionType is the state of the operator's reconciliation functionality.
+type MachineConfigNodeOverrideStatusConditionType string
+
+const (
+	// MachineConfigNodeOverrideSuccess designates a MachineConfigNodeOverride rendered on top of the
+	// current config of the pool of the node.
+	MachineConfigNodeOverrideSuccess MachineConfigNodeOverrideStatusConditionType = "Success"
+
+	// MachineConfigNodeOverrideFailure designates a failure rendering a MachineConfigNodeOverride.
+	MachineConfigNodeOverrideFailure MachineConfigNodeOverrideStatusConditionType = "Failure"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// MachineConfigNodeOverrideList is a list of MachineConfigNodeOverride resources
+type MachineConfigNodeOverrideList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []MachineConfigNodeOverride `json:"items"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// KernelArgumentPolicy permits or denies kernel arguments for the MachineConfigs of the cluster.
+// Kernel arguments disabling security features node-wide, e.g. selinux=0, are denied unless a
+// KernelArgumentPolicy allows them.
+type KernelArgumentPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +required
+	Spec KernelArgumentPolicySpec `json:"spec"`
+}
+
+// KernelArgumentPolicySpec defines the desired state of KernelArgumentPolicy
+type KernelArgumentPolicySpec struct {
+	// allowedKernelArguments are the kernel arguments denied by default MachineConfigs may set,
+	// e.g. selinux=0. An argument without a value, e.g. selinux, allows any value.
+	// +optional
+	AllowedKernelArguments []string `json:"allowedKernelArguments,omitempty"`
+
+	// deniedKernelArguments are the kernel arguments MachineConfigs may not set, on top of the
+	// ones denied by default. An argument without a value, e.g. nosmt, denies any value.
+	// Denied arguments aren't allowed by allowedKernelArguments.
+	// +optional
+	DeniedKernelArguments []string `json:"deniedKernelArguments,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// KernelArgumentPolicyList is a list of KernelArgumentPolicy resources
+type KernelArgumentPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []KernelArgumentPolicy `json:"items"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// MachineConfigNode reports the state of the update of a node. It's written by the daemon of the
+// node, is named after it and is deleted along with it.
+type MachineConfigNode struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +required
+	Spec MachineConfigNodeSpec `json:"spec"`
+	// +optional
+	Status MachineConfigNodeStatus `json:"status"`
+}
+
+// MachineConfigNodeSpec defines the node a MachineConfigNode reports on
+type MachineConfigNodeSpec struct {
+	// nodeName is the name of the node.
+	NodeName string `json:"nodeName"`
+}
+
+// MachineConfigNodeStatus defines the observed state of the update of a node
+type MachineConfigNodeStatus struct {
+	// currentConfig is the rendered MachineConfig the node is at.
+	// +optional
+	CurrentConfig string `json:"currentConfig,omitempty"`
+
+	// desiredConfig is the rendered MachineConfig the node is targeted at.
+	// +optional
+	DesiredConfig string `json:"desiredConfig,omitempty"`
+
+	// phase is the state of the daemon of the node: Done, Working, Degraded or Unreconcilable.
+	// +optional
+	Phase MachineConfigNodePhase `json:"phase,omitempty"`
+
+	// lastError is why the node is Degraded or Unreconcilable.
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+
+	// pendingReboot is set while the node has a reboot ahead of it.
+	// +optional
+	PendingReboot *MachineConfigNodeReboot `json:"pendingReboot,omitempty"`
+
+	// stagedOSImage is the OS image staged for the next boot of the node ahead of its update.
+	// +optional
+	StagedOSImage string `json:"stagedOSImage,omitempty"`
+
+	// lastTransitionTime is the last time the phase changed.
+	// +nullable
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+}
+
+// MachineConfigNodePhase is the state of the daemon of a node
+type MachineConfigNodePhase string
+
+const (
+	// MachineConfigNodeDone designates a node at its desired config.
+	MachineConfigNodeDone MachineConfigNodePhase = "Done"
+	// MachineConfigNodeWorking designates a node updating to its desired config.
+	MachineConfigNodeWorking MachineConfigNodePhase = "Working"
+	// MachineConfigNodeDegraded designates a node that failed to update, and is retrying.
+	MachineConfigNodeDegraded MachineConfigNodePhase = "Degraded"
+	// MachineConfigNodeUnreconcilable designates a node that can't be updated to its desired config.
+	MachineConfigNodeUnreconcilable MachineConfigNodePhase = "Unreconcilable"
+)
+
+// MachineConfigNodeReboot is a reboot ahead of a node
+type MachineConfigNodeReboot struct {
+	// reason is why the node reboots: ScheduledUpdate for the graceful reboot completing its update,
+	// RebootRequested for the reboot request of its pool.
+	Reason string `json:"reason"`
+
+	// time is when the graceful reboot of the node is scheduled at.
+	// +optional
+	Time *metav1.Time `json:"time,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// MachineConfigNodeList is a list of MachineConfigNode resources
+type MachineConfigNodeList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []MachineConfigNode `json:"items"`
+}
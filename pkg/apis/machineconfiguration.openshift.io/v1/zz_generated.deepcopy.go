@@ -149,6 +149,11 @@ func (in *ContainerRuntimeConfiguration) DeepCopyInto(out *ContainerRuntimeConfi
 	}
 	out.LogSizeMax = in.LogSizeMax.DeepCopy()
 	out.OverlaySize = in.OverlaySize.DeepCopy()
+	if in.ImagePolicy != nil {
+		in, out := &in.ImagePolicy, &out.ImagePolicy
+		*out = new(ContainerRuntimeImagePolicy)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -162,6 +167,43 @@ func (in *ContainerRuntimeConfiguration) DeepCopy() *ContainerRuntimeConfigurati
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContainerRuntimeImagePolicy) DeepCopyInto(out *ContainerRuntimeImagePolicy) {
+	*out = *in
+	if in.Scopes != nil {
+		in, out := &in.Scopes, &out.Scopes
+		*out = make([]ContainerRuntimeImagePolicyScope, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContainerRuntimeImagePolicy.
+func (in *ContainerRuntimeImagePolicy) DeepCopy() *ContainerRuntimeImagePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ContainerRuntimeImagePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContainerRuntimeImagePolicyScope) DeepCopyInto(out *ContainerRuntimeImagePolicyScope) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContainerRuntimeImagePolicyScope.
+func (in *ContainerRuntimeImagePolicyScope) DeepCopy() *ContainerRuntimeImagePolicyScope {
+	if in == nil {
+		return nil
+	}
+	out := new(ContainerRuntimeImagePolicyScope)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ControllerConfig) DeepCopyInto(out *ControllerConfig) {
 	*out = *in
@@ -326,6 +368,92 @@ func (in *ControllerConfigStatusCondition) DeepCopy() *ControllerConfigStatusCon
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KernelArgumentPolicy) DeepCopyInto(out *KernelArgumentPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KernelArgumentPolicy.
+func (in *KernelArgumentPolicy) DeepCopy() *KernelArgumentPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(KernelArgumentPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KernelArgumentPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KernelArgumentPolicyList) DeepCopyInto(out *KernelArgumentPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KernelArgumentPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KernelArgumentPolicyList.
+func (in *KernelArgumentPolicyList) DeepCopy() *KernelArgumentPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(KernelArgumentPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KernelArgumentPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KernelArgumentPolicySpec) DeepCopyInto(out *KernelArgumentPolicySpec) {
+	*out = *in
+	if in.AllowedKernelArguments != nil {
+		in, out := &in.AllowedKernelArguments, &out.AllowedKernelArguments
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DeniedKernelArguments != nil {
+		in, out := &in.DeniedKernelArguments, &out.DeniedKernelArguments
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KernelArgumentPolicySpec.
+func (in *KernelArgumentPolicySpec) DeepCopy() *KernelArgumentPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KernelArgumentPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KubeletConfig) DeepCopyInto(out *KubeletConfig) {
 	*out = *in
@@ -432,6 +560,18 @@ func (in *KubeletConfigSpec) DeepCopyInto(out *KubeletConfigSpec) {
 		*out = new(configv1.TLSSecurityProfile)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.CredentialProviders != nil {
+		in, out := &in.CredentialProviders, &out.CredentialProviders
+		*out = make([]KubeletCredentialProvider, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.GracefulShutdown != nil {
+		in, out := &in.GracefulShutdown, &out.GracefulShutdown
+		*out = new(KubeletGracefulShutdown)
+		**out = **in
+	}
 	return
 }
 
@@ -468,6 +608,76 @@ func (in *KubeletConfigStatus) DeepCopy() *KubeletConfigStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeletCredentialProvider) DeepCopyInto(out *KubeletCredentialProvider) {
+	*out = *in
+	if in.MatchImages != nil {
+		in, out := &in.MatchImages, &out.MatchImages
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DefaultCacheDuration != nil {
+		in, out := &in.DefaultCacheDuration, &out.DefaultCacheDuration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]KubeletCredentialProviderEnvVar, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeletCredentialProvider.
+func (in *KubeletCredentialProvider) DeepCopy() *KubeletCredentialProvider {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeletCredentialProvider)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeletCredentialProviderEnvVar) DeepCopyInto(out *KubeletCredentialProviderEnvVar) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeletCredentialProviderEnvVar.
+func (in *KubeletCredentialProviderEnvVar) DeepCopy() *KubeletCredentialProviderEnvVar {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeletCredentialProviderEnvVar)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeletGracefulShutdown) DeepCopyInto(out *KubeletGracefulShutdown) {
+	*out = *in
+	out.ShutdownGracePeriod = in.ShutdownGracePeriod
+	out.CriticalPodGracePeriod = in.CriticalPodGracePeriod
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeletGracefulShutdown.
+func (in *KubeletGracefulShutdown) DeepCopy() *KubeletGracefulShutdown {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeletGracefulShutdown)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MachineConfig) DeepCopyInto(out *MachineConfig) {
 	*out = *in
@@ -529,27 +739,27 @@ func (in *MachineConfigList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MachineConfigPool) DeepCopyInto(out *MachineConfigPool) {
+func (in *MachineConfigNode) DeepCopyInto(out *MachineConfigNode) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
+	out.Spec = in.Spec
 	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPool.
-func (in *MachineConfigPool) DeepCopy() *MachineConfigPool {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigNode.
+func (in *MachineConfigNode) DeepCopy() *MachineConfigNode {
 	if in == nil {
 		return nil
 	}
-	out := new(MachineConfigPool)
+	out := new(MachineConfigNode)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *MachineConfigPool) DeepCopyObject() runtime.Object {
+func (in *MachineConfigNode) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -557,49 +767,60 @@ func (in *MachineConfigPool) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MachineConfigPoolCondition) DeepCopyInto(out *MachineConfigPoolCondition) {
+func (in *MachineConfigNodeList) DeepCopyInto(out *MachineConfigNodeList) {
 	*out = *in
-	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MachineConfigNode, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolCondition.
-func (in *MachineConfigPoolCondition) DeepCopy() *MachineConfigPoolCondition {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigNodeList.
+func (in *MachineConfigNodeList) DeepCopy() *MachineConfigNodeList {
 	if in == nil {
 		return nil
 	}
-	out := new(MachineConfigPoolCondition)
+	out := new(MachineConfigNodeList)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MachineConfigNodeList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MachineConfigPoolList) DeepCopyInto(out *MachineConfigPoolList) {
+func (in *MachineConfigNodeOverride) DeepCopyInto(out *MachineConfigNodeOverride) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]MachineConfigPool, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolList.
-func (in *MachineConfigPoolList) DeepCopy() *MachineConfigPoolList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigNodeOverride.
+func (in *MachineConfigNodeOverride) DeepCopy() *MachineConfigNodeOverride {
 	if in == nil {
 		return nil
 	}
-	out := new(MachineConfigPoolList)
+	out := new(MachineConfigNodeOverride)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *MachineConfigPoolList) DeepCopyObject() runtime.Object {
+func (in *MachineConfigNodeOverride) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -607,44 +828,46 @@ func (in *MachineConfigPoolList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MachineConfigPoolSpec) DeepCopyInto(out *MachineConfigPoolSpec) {
+func (in *MachineConfigNodeOverrideCondition) DeepCopyInto(out *MachineConfigNodeOverrideCondition) {
 	*out = *in
-	if in.MachineConfigSelector != nil {
-		in, out := &in.MachineConfigSelector, &out.MachineConfigSelector
-		*out = new(metav1.LabelSelector)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.NodeSelector != nil {
-		in, out := &in.NodeSelector, &out.NodeSelector
-		*out = new(metav1.LabelSelector)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.MaxUnavailable != nil {
-		in, out := &in.MaxUnavailable, &out.MaxUnavailable
-		*out = new(intstr.IntOrString)
-		**out = **in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigNodeOverrideCondition.
+func (in *MachineConfigNodeOverrideCondition) DeepCopy() *MachineConfigNodeOverrideCondition {
+	if in == nil {
+		return nil
 	}
-	in.Configuration.DeepCopyInto(&out.Configuration)
+	out := new(MachineConfigNodeOverrideCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigNodeOverrideConfiguration) DeepCopyInto(out *MachineConfigNodeOverrideConfiguration) {
+	*out = *in
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolSpec.
-func (in *MachineConfigPoolSpec) DeepCopy() *MachineConfigPoolSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigNodeOverrideConfiguration.
+func (in *MachineConfigNodeOverrideConfiguration) DeepCopy() *MachineConfigNodeOverrideConfiguration {
 	if in == nil {
 		return nil
 	}
-	out := new(MachineConfigPoolSpec)
+	out := new(MachineConfigNodeOverrideConfiguration)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MachineConfigPoolStatus) DeepCopyInto(out *MachineConfigPoolStatus) {
+func (in *MachineConfigNodeOverrideList) DeepCopyInto(out *MachineConfigNodeOverrideList) {
 	*out = *in
-	in.Configuration.DeepCopyInto(&out.Configuration)
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make([]MachineConfigPoolCondition, len(*in))
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MachineConfigNodeOverride, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -652,48 +875,507 @@ func (in *MachineConfigPoolStatus) DeepCopyInto(out *MachineConfigPoolStatus) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolStatus.
-func (in *MachineConfigPoolStatus) DeepCopy() *MachineConfigPoolStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigNodeOverrideList.
+func (in *MachineConfigNodeOverrideList) DeepCopy() *MachineConfigNodeOverrideList {
 	if in == nil {
 		return nil
 	}
-	out := new(MachineConfigPoolStatus)
+	out := new(MachineConfigNodeOverrideList)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MachineConfigNodeOverrideList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MachineConfigPoolStatusConfiguration) DeepCopyInto(out *MachineConfigPoolStatusConfiguration) {
+func (in *MachineConfigNodeOverrideSpec) DeepCopyInto(out *MachineConfigNodeOverrideSpec) {
 	*out = *in
-	out.ObjectReference = in.ObjectReference
-	if in.Source != nil {
-		in, out := &in.Source, &out.Source
-		*out = make([]corev1.ObjectReference, len(*in))
-		copy(*out, *in)
-	}
+	in.Config.DeepCopyInto(&out.Config)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolStatusConfiguration.
-func (in *MachineConfigPoolStatusConfiguration) DeepCopy() *MachineConfigPoolStatusConfiguration {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigNodeOverrideSpec.
+func (in *MachineConfigNodeOverrideSpec) DeepCopy() *MachineConfigNodeOverrideSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(MachineConfigPoolStatusConfiguration)
+	out := new(MachineConfigNodeOverrideSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MachineConfigSpec) DeepCopyInto(out *MachineConfigSpec) {
+func (in *MachineConfigNodeOverrideStatus) DeepCopyInto(out *MachineConfigNodeOverrideStatus) {
 	*out = *in
-	in.Config.DeepCopyInto(&out.Config)
-	if in.KernelArguments != nil {
-		in, out := &in.KernelArguments, &out.KernelArguments
-		*out = make([]string, len(*in))
+	if in.Configurations != nil {
+		in, out := &in.Configurations, &out.Configurations
+		*out = make([]MachineConfigNodeOverrideConfiguration, len(*in))
 		copy(*out, *in)
 	}
-	if in.Extensions != nil {
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]MachineConfigNodeOverrideCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigNodeOverrideStatus.
+func (in *MachineConfigNodeOverrideStatus) DeepCopy() *MachineConfigNodeOverrideStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigNodeOverrideStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigNodeReboot) DeepCopyInto(out *MachineConfigNodeReboot) {
+	*out = *in
+	if in.Time != nil {
+		in, out := &in.Time, &out.Time
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigNodeReboot.
+func (in *MachineConfigNodeReboot) DeepCopy() *MachineConfigNodeReboot {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigNodeReboot)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigNodeSpec) DeepCopyInto(out *MachineConfigNodeSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigNodeSpec.
+func (in *MachineConfigNodeSpec) DeepCopy() *MachineConfigNodeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigNodeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigNodeStatus) DeepCopyInto(out *MachineConfigNodeStatus) {
+	*out = *in
+	if in.PendingReboot != nil {
+		in, out := &in.PendingReboot, &out.PendingReboot
+		*out = new(MachineConfigNodeReboot)
+		(*in).DeepCopyInto(*out)
+	}
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigNodeStatus.
+func (in *MachineConfigNodeStatus) DeepCopy() *MachineConfigNodeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigNodeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigPool) DeepCopyInto(out *MachineConfigPool) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPool.
+func (in *MachineConfigPool) DeepCopy() *MachineConfigPool {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigPool)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MachineConfigPool) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigPoolCondition) DeepCopyInto(out *MachineConfigPoolCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolCondition.
+func (in *MachineConfigPoolCondition) DeepCopy() *MachineConfigPoolCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigPoolCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigPoolDrainWatchdog) DeepCopyInto(out *MachineConfigPoolDrainWatchdog) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolDrainWatchdog.
+func (in *MachineConfigPoolDrainWatchdog) DeepCopy() *MachineConfigPoolDrainWatchdog {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigPoolDrainWatchdog)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigPoolGracefulReboot) DeepCopyInto(out *MachineConfigPoolGracefulReboot) {
+	*out = *in
+	out.Delay = in.Delay
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolGracefulReboot.
+func (in *MachineConfigPoolGracefulReboot) DeepCopy() *MachineConfigPoolGracefulReboot {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigPoolGracefulReboot)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigPoolHostOperatingSystem) DeepCopyInto(out *MachineConfigPoolHostOperatingSystem) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolHostOperatingSystem.
+func (in *MachineConfigPoolHostOperatingSystem) DeepCopy() *MachineConfigPoolHostOperatingSystem {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigPoolHostOperatingSystem)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigPoolList) DeepCopyInto(out *MachineConfigPoolList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MachineConfigPool, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolList.
+func (in *MachineConfigPoolList) DeepCopy() *MachineConfigPoolList {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigPoolList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MachineConfigPoolList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigPoolMaintenanceWindow) DeepCopyInto(out *MachineConfigPoolMaintenanceWindow) {
+	*out = *in
+	out.Duration = in.Duration
+	if in.Days != nil {
+		in, out := &in.Days, &out.Days
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolMaintenanceWindow.
+func (in *MachineConfigPoolMaintenanceWindow) DeepCopy() *MachineConfigPoolMaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigPoolMaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigPoolSpec) DeepCopyInto(out *MachineConfigPoolSpec) {
+	*out = *in
+	if in.MachineConfigSelector != nil {
+		in, out := &in.MachineConfigSelector, &out.MachineConfigSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.MaxUnavailablePerZone != nil {
+		in, out := &in.MaxUnavailablePerZone, &out.MaxUnavailablePerZone
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	in.Configuration.DeepCopyInto(&out.Configuration)
+	if in.NodeLabels != nil {
+		in, out := &in.NodeLabels, &out.NodeLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.NodeTaints != nil {
+		in, out := &in.NodeTaints, &out.NodeTaints
+		*out = make([]corev1.Taint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MaxConcurrentImagePulls != nil {
+		in, out := &in.MaxConcurrentImagePulls, &out.MaxConcurrentImagePulls
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.RollbackDeployments != nil {
+		in, out := &in.RollbackDeployments, &out.RollbackDeployments
+		*out = new(int32)
+		**out = **in
+	}
+	if in.DrainWatchdog != nil {
+		in, out := &in.DrainWatchdog, &out.DrainWatchdog
+		*out = new(MachineConfigPoolDrainWatchdog)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GracefulReboot != nil {
+		in, out := &in.GracefulReboot, &out.GracefulReboot
+		*out = new(MachineConfigPoolGracefulReboot)
+		**out = **in
+	}
+	if in.MaintenanceWindow != nil {
+		in, out := &in.MaintenanceWindow, &out.MaintenanceWindow
+		*out = new(MachineConfigPoolMaintenanceWindow)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UpdateOrder != nil {
+		in, out := &in.UpdateOrder, &out.UpdateOrder
+		*out = new(MachineConfigPoolUpdateOrder)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolSpec.
+func (in *MachineConfigPoolSpec) DeepCopy() *MachineConfigPoolSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigPoolSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigPoolStatus) DeepCopyInto(out *MachineConfigPoolStatus) {
+	*out = *in
+	in.Configuration.DeepCopyInto(&out.Configuration)
+	if in.UpdateStatistics != nil {
+		in, out := &in.UpdateStatistics, &out.UpdateStatistics
+		*out = new(MachineConfigPoolUpdateStatistics)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EstimatedCompletionTime != nil {
+		in, out := &in.EstimatedCompletionTime, &out.EstimatedCompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.StuckDrains != nil {
+		in, out := &in.StuckDrains, &out.StuckDrains
+		*out = make([]MachineConfigPoolStuckDrain, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.HostOperatingSystems != nil {
+		in, out := &in.HostOperatingSystems, &out.HostOperatingSystems
+		*out = make([]MachineConfigPoolHostOperatingSystem, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]MachineConfigPoolCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolStatus.
+func (in *MachineConfigPoolStatus) DeepCopy() *MachineConfigPoolStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigPoolStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigPoolStatusConfiguration) DeepCopyInto(out *MachineConfigPoolStatusConfiguration) {
+	*out = *in
+	out.ObjectReference = in.ObjectReference
+	if in.Source != nil {
+		in, out := &in.Source, &out.Source
+		*out = make([]corev1.ObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolStatusConfiguration.
+func (in *MachineConfigPoolStatusConfiguration) DeepCopy() *MachineConfigPoolStatusConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigPoolStatusConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigPoolStuckDrain) DeepCopyInto(out *MachineConfigPoolStuckDrain) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.BlockingPods != nil {
+		in, out := &in.BlockingPods, &out.BlockingPods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.BlockingPodDisruptionBudgets != nil {
+		in, out := &in.BlockingPodDisruptionBudgets, &out.BlockingPodDisruptionBudgets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolStuckDrain.
+func (in *MachineConfigPoolStuckDrain) DeepCopy() *MachineConfigPoolStuckDrain {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigPoolStuckDrain)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigPoolUpdateOrder) DeepCopyInto(out *MachineConfigPoolUpdateOrder) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolUpdateOrder.
+func (in *MachineConfigPoolUpdateOrder) DeepCopy() *MachineConfigPoolUpdateOrder {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigPoolUpdateOrder)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigPoolUpdateStatistics) DeepCopyInto(out *MachineConfigPoolUpdateStatistics) {
+	*out = *in
+	if in.RecentDurations != nil {
+		in, out := &in.RecentDurations, &out.RecentDurations
+		*out = make([]metav1.Duration, len(*in))
+		copy(*out, *in)
+	}
+	out.AverageDuration = in.AverageDuration
+	out.MaxDuration = in.MaxDuration
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolUpdateStatistics.
+func (in *MachineConfigPoolUpdateStatistics) DeepCopy() *MachineConfigPoolUpdateStatistics {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigPoolUpdateStatistics)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigSpec) DeepCopyInto(out *MachineConfigSpec) {
+	*out = *in
+	in.Config.DeepCopyInto(&out.Config)
+	if in.KernelArguments != nil {
+		in, out := &in.KernelArguments, &out.KernelArguments
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Extensions != nil {
 		in, out := &in.Extensions, &out.Extensions
 		*out = make([]string, len(*in))
 		copy(*out, *in)
@@ -710,3 +1392,141 @@ func (in *MachineConfigSpec) DeepCopy() *MachineConfigSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeConfig) DeepCopyInto(out *NodeConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeConfig.
+func (in *NodeConfig) DeepCopy() *NodeConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NodeConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeConfigCondition) DeepCopyInto(out *NodeConfigCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeConfigCondition.
+func (in *NodeConfigCondition) DeepCopy() *NodeConfigCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeConfigCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeConfigList) DeepCopyInto(out *NodeConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]NodeConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeConfigList.
+func (in *NodeConfigList) DeepCopy() *NodeConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NodeConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeConfigPoolStatus) DeepCopyInto(out *NodeConfigPoolStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeConfigPoolStatus.
+func (in *NodeConfigPoolStatus) DeepCopy() *NodeConfigPoolStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeConfigPoolStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeConfigSpec) DeepCopyInto(out *NodeConfigSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeConfigSpec.
+func (in *NodeConfigSpec) DeepCopy() *NodeConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeConfigStatus) DeepCopyInto(out *NodeConfigStatus) {
+	*out = *in
+	if in.Pools != nil {
+		in, out := &in.Pools, &out.Pools
+		*out = make([]NodeConfigPoolStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]NodeConfigCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeConfigStatus.
+func (in *NodeConfigStatus) DeepCopy() *NodeConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
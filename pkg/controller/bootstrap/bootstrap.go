@@ -72,6 +72,7 @@ func (b *Bootstrap) Run(destDir string) error {
 	var cconfig *mcfgv1.ControllerConfig
 	var pools []*mcfgv1.MachineConfigPool
 	var configs []*mcfgv1.MachineConfig
+	var policies []*mcfgv1.KernelArgumentPolicy
 	var icspRules []*apioperatorsv1alpha1.ImageContentSourcePolicy
 	var imgCfg *apicfgv1.Image
 	for _, info := range infos {
@@ -108,6 +109,8 @@ func (b *Bootstrap) Run(destDir string) error {
 				configs = append(configs, obj)
 			case *mcfgv1.ControllerConfig:
 				cconfig = obj
+			case *mcfgv1.KernelArgumentPolicy:
+				policies = append(policies, obj)
 			case *apioperatorsv1alpha1.ImageContentSourcePolicy:
 				icspRules = append(icspRules, obj)
 			case *apicfgv1.Image:
@@ -133,7 +136,7 @@ func (b *Bootstrap) Run(destDir string) error {
 	}
 	configs = append(configs, rconfigs...)
 
-	fpools, gconfigs, err := render.RunBootstrap(pools, configs, cconfig)
+	fpools, gconfigs, err := render.RunBootstrap(pools, configs, cconfig, policies)
 	if err != nil {
 		return err
 	}
@@ -4,6 +4,37 @@ const (
 	// GeneratedByControllerVersionAnnotationKey is used to tag the machineconfigs generated by the controller with the version of the controller.
 	GeneratedByControllerVersionAnnotationKey = "machineconfiguration.openshift.io/generated-by-controller-version"
 
+	// MachineConfigExpiresAnnotationKey is set on a MachineConfig to the RFC 3339 time after which the render
+	// controller deletes it, e.g. for temporary debugging configs.
+	MachineConfigExpiresAnnotationKey = "machineconfiguration.openshift.io/expires"
+
+	// OCIArtifactAnnotationKey is set on a MachineConfig to an OCI artifact pinned by digest, whose single layer is the
+	// Ignition config of the MachineConfig, e.g. quay.io/example/configs@sha256:...
+	OCIArtifactAnnotationKey = "machineconfiguration.openshift.io/oci-artifact"
+
+	// PreviousRenderedConfigAnnotationKey is set on a pool by the render controller to the rendered config the pool targeted
+	// before the current one.
+	PreviousRenderedConfigAnnotationKey = "machineconfiguration.openshift.io/previousRenderedConfig"
+
+	// RollbackToAnnotationKey is set on a pool by admins to a rendered config of the pool, or to RollbackToPrevious, to
+	// pin the pool to that config instead of rendering its MachineConfigs, until the annotation is removed.
+	RollbackToAnnotationKey = "machineconfiguration.openshift.io/rollbackTo"
+
+	// RollbackToPrevious is the RollbackToAnnotationKey value rolling back to the PreviousRenderedConfigAnnotationKey config.
+	RollbackToPrevious = "previous"
+
+	// SourcesAnnotationKey is set on rendered configs to the JSON list of the MachineConfigs they were rendered from, see
+	// MachineConfigSource.
+	SourcesAnnotationKey = "machineconfiguration.openshift.io/sources"
+
+	// FileSourcesAnnotationKey is set on rendered configs to the JSON object mapping the path of each file and systemd unit
+	// they write to the name of the MachineConfig it comes from.
+	FileSourcesAnnotationKey = "machineconfiguration.openshift.io/file-sources"
+
+	// IgnitionSpec2MigrationAnnotationKey is set by the operator on the MachineConfigs it converted from Ignition spec 2 to
+	// spec 3, to the report of what the conversion dropped.
+	IgnitionSpec2MigrationAnnotationKey = "machineconfiguration.openshift.io/ignition-spec2-migration"
+
 	// ControllerConfigName is the name of the ControllerConfig object that controllers use
 	ControllerConfigName = "machine-config-controller"
 
@@ -13,9 +44,18 @@ const (
 	// KernelTypeRealtime denominates the realtime kernel type
 	KernelTypeRealtime = "realtime"
 
+	// KernelType64kPages denominates the kernel type with 64k memory pages, only available on aarch64
+	KernelType64kPages = "64k-pages"
+
 	// MasterLabel defines the label associated with master node. The master taint uses the same label as taint's key
 	MasterLabel = "node-role.kubernetes.io/master"
 
 	// MCNameSuffixAnnotationKey is used to keep track of the machine config name associated with a CR
 	MCNameSuffixAnnotationKey = "machineconfiguration.openshift.io/mc-name-suffix"
+
+	// CRIODropInDir is the directory CRI-O reads its configuration drop-ins from, in lexical order
+	CRIODropInDir = "/etc/crio/crio.conf.d"
+
+	// CRIOManagedDropInPrefix is the file name prefix of the CRI-O drop-ins generated from ContainerRuntimeConfigs
+	CRIOManagedDropInPrefix = "01-ctrcfg-"
 )
@@ -4,8 +4,12 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"path/filepath"
 	"reflect"
 	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
 
 	"github.com/clarketm/json"
 	fcctbase "github.com/coreos/fcct/base/v0_1"
@@ -29,6 +33,7 @@ import (
 	"github.com/ghodss/yaml"
 	"github.com/golang/glog"
 	"github.com/pkg/errors"
+	"github.com/vincent-petithory/dataurl"
 	kerr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -81,16 +86,17 @@ func MergeMachineConfigs(configs []*mcfgv1.MachineConfig, osImageURL string) (*m
 	}
 
 	// sets the KernelType if specified in any of the MachineConfig
-	// Setting kerneType to realtime in any of MachineConfig takes priority
+	// Setting a kernelType other than default in any of MachineConfig takes priority,
+	// the realtime and 64k-pages kernels being exclusive of each other
 	// also if any of the config has FIPS enabled, it'll be set
 	for _, cfg := range configs {
 		if cfg.Spec.FIPS {
 			fips = true
 		}
-		if cfg.Spec.KernelType == KernelTypeRealtime {
-			kernelType = cfg.Spec.KernelType
-			break
-		} else if kernelType == KernelTypeDefault {
+		if cfg.Spec.KernelType == KernelTypeRealtime || cfg.Spec.KernelType == KernelType64kPages {
+			if kernelType != "" && kernelType != cfg.Spec.KernelType {
+				return nil, fmt.Errorf("kernelType %s and %s can't be combined", kernelType, cfg.Spec.KernelType)
+			}
 			kernelType = cfg.Spec.KernelType
 		}
 	}
@@ -316,9 +322,15 @@ func InSlice(elem string, slice []string) bool {
 	return false
 }
 
+// KernelTypeSupportsArchitecture returns whether the kernel of kernelType is available for the
+// architecture arch, as reported by the nodes
+func KernelTypeSupportsArchitecture(kernelType, arch string) bool {
+	return kernelType != KernelType64kPages || arch == "arm64"
+}
+
 // ValidateMachineConfig validates that given MachineConfig Spec is valid.
 func ValidateMachineConfig(cfg mcfgv1.MachineConfigSpec) error {
-	if !(cfg.KernelType == "" || cfg.KernelType == KernelTypeDefault || cfg.KernelType == KernelTypeRealtime) {
+	if !(cfg.KernelType == "" || cfg.KernelType == KernelTypeDefault || cfg.KernelType == KernelTypeRealtime || cfg.KernelType == KernelType64kPages) {
 		return errors.Errorf("kernelType=%s is invalid", cfg.KernelType)
 	}
 
@@ -334,6 +346,109 @@ func ValidateMachineConfig(cfg mcfgv1.MachineConfigSpec) error {
 	return nil
 }
 
+// ValidateMachineConfigs validates every MachineConfig of a pool before rendering them. All the
+// invalid configs are returned together so they can be reported at once on the pool status,
+// rather than one at a time.
+func ValidateMachineConfigs(configs []*mcfgv1.MachineConfig) error {
+	var findings []string
+	for _, config := range configs {
+		if err := ValidateMachineConfig(config.Spec); err != nil {
+			findings = append(findings, fmt.Sprintf("MachineConfig %s: %v", config.Name, err))
+		}
+	}
+	if len(findings) > 0 {
+		return errors.Errorf("invalid MachineConfigs found: %s", strings.Join(findings, "; "))
+	}
+	return nil
+}
+
+// ValidateMachineConfigsReconcilable looks for what the daemon can't apply on a running node in
+// the valid configs of a pool: file appends, non data URL sources and compression, and users other
+// than core. Like the daemon, it only rejects them when they differ from current, the rendered
+// config the pool targets, nil if there is none yet: the ones current has already are returned as
+// warnings, so pools running them still get new configs.
+func ValidateMachineConfigsReconcilable(configs []*mcfgv1.MachineConfig, current *mcfgv1.MachineConfig) ([]string, error) {
+	var currentIgn *ign3types.Config
+	if current != nil && current.Spec.Config.Raw != nil {
+		ignCfg, err := ParseAndConvertConfig(current.Spec.Config.Raw)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing the current rendered config %s", current.Name)
+		}
+		currentIgn = &ignCfg
+	}
+	var findings, warnings []string
+	for _, config := range configs {
+		if config.Spec.Config.Raw == nil {
+			continue
+		}
+		ignCfg, err := ParseAndConvertConfig(config.Spec.Config.Raw)
+		if err != nil {
+			findings = append(findings, fmt.Sprintf("MachineConfig %s: %v", config.Name, err))
+			continue
+		}
+		changed, unchanged := unsupportedIgnitionSections(ignCfg, currentIgn)
+		for _, unsupported := range changed {
+			findings = append(findings, fmt.Sprintf("MachineConfig %s: %s", config.Name, unsupported))
+		}
+		for _, unsupported := range unchanged {
+			warnings = append(warnings, fmt.Sprintf("MachineConfig %s: %s", config.Name, unsupported))
+		}
+	}
+	if len(findings) > 0 {
+		return warnings, errors.Errorf("unreconcilable MachineConfigs found: %s", strings.Join(findings, "; "))
+	}
+	return warnings, nil
+}
+
+// unsupportedIgnitionSections returns what in the valid ignCfg the daemon can't apply, split
+// between what changes from currentIgn and what currentIgn has already. Everything is unchanged
+// when currentIgn is nil, nodes get it from Ignition when they first boot then.
+func unsupportedIgnitionSections(ignCfg ign3types.Config, currentIgn *ign3types.Config) (changed, unchanged []string) {
+	report := func(same bool, finding string) {
+		if currentIgn == nil || same {
+			unchanged = append(unchanged, finding)
+		} else {
+			changed = append(changed, finding)
+		}
+	}
+	for _, f := range ignCfg.Storage.Files {
+		same := false
+		if currentIgn != nil {
+			for _, current := range currentIgn.Storage.Files {
+				if current.Path == f.Path {
+					same = reflect.DeepEqual(current, f)
+					break
+				}
+			}
+		}
+		if len(f.Append) > 0 {
+			report(same, fmt.Sprintf("ignition file %s includes append", f.Path))
+		}
+		if f.Contents.Source != nil && !strings.HasPrefix(*f.Contents.Source, "data:") {
+			report(same, fmt.Sprintf("ignition file %s has a remote source, only data URLs are supported", f.Path))
+		}
+		if f.Contents.Compression != nil && *f.Contents.Compression != "" {
+			report(same, fmt.Sprintf("ignition file %s is compressed, compression is not supported", f.Path))
+		}
+	}
+	for _, u := range ignCfg.Passwd.Users {
+		if u.Name == "core" {
+			continue
+		}
+		same := false
+		if currentIgn != nil {
+			for _, current := range currentIgn.Passwd.Users {
+				if current.Name == u.Name {
+					same = reflect.DeepEqual(current, u)
+					break
+				}
+			}
+		}
+		report(same, fmt.Sprintf("ignition passwd user %s is not supported, only the core user can be configured", u.Name))
+	}
+	return changed, unchanged
+}
+
 // IgnParseWrapper parses rawIgn for both V2 and V3 ignition configs and returns
 // a V2 or V3 Config or an error. This wrapper is necessary since V2 and V3 use different parsers.
 func IgnParseWrapper(rawIgn []byte) (interface{}, error) {
@@ -591,3 +706,124 @@ func GetManagedKey(pool *mcfgv1.MachineConfigPool, client mcfgclientset.Interfac
 	err = client.MachineconfigurationV1().MachineConfigs().Delete(context.TODO(), deprecatedKey, metav1.DeleteOptions{})
 	return managedKey, err
 }
+
+// crioDropIn is a CRI-O drop-in as written by the last MachineConfig of a pool that sets it
+type crioDropIn struct {
+	path    string
+	mcName  string
+	managed bool
+	keys    []string
+}
+
+// isCRIODropInManaged returns true if path is a drop-in the ContainerRuntimeConfig controller
+// generates and mc is one of the MachineConfigs that controller owns
+func isCRIODropInManaged(mc *mcfgv1.MachineConfig, path string) bool {
+	ref := metav1.GetControllerOf(mc)
+	if ref == nil || ref.Kind != "ContainerRuntimeConfig" {
+		return false
+	}
+	return strings.HasPrefix(filepath.Base(path), CRIOManagedDropInPrefix)
+}
+
+// flattenTOMLKeys returns the dotted path of every value set in a decoded TOML document
+func flattenTOMLKeys(prefix string, tree map[string]interface{}) []string {
+	var keys []string
+	for k, v := range tree {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if sub, ok := v.(map[string]interface{}); ok {
+			keys = append(keys, flattenTOMLKeys(key, sub)...)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// getCRIODropIns returns the CRI-O drop-ins the given MachineConfigs render to, keyed by path.
+// MachineConfigs are merged in increasing order of their name, so the last one writing a path wins.
+// It also returns the managed drop-ins that a foreign MachineConfig overwrites.
+func getCRIODropIns(configs []*mcfgv1.MachineConfig) (map[string]crioDropIn, []string, error) {
+	sorted := make([]*mcfgv1.MachineConfig, len(configs))
+	copy(sorted, configs)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	dropIns := make(map[string]crioDropIn)
+	var overwritten []string
+	for _, mc := range sorted {
+		if mc.Spec.Config.Raw == nil {
+			continue
+		}
+		ignCfg, err := ParseAndConvertConfig(mc.Spec.Config.Raw)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parsing Ignition config from MachineConfig %s failed: %v", mc.Name, err)
+		}
+		for _, f := range ignCfg.Storage.Files {
+			if filepath.Dir(f.Path) != CRIODropInDir {
+				continue
+			}
+			dropIn := crioDropIn{path: f.Path, mcName: mc.Name, managed: isCRIODropInManaged(mc, f.Path)}
+			if f.Contents.Source != nil && (f.Contents.Compression == nil || *f.Contents.Compression == "") {
+				contents, err := dataurl.DecodeString(*f.Contents.Source)
+				if err != nil {
+					return nil, nil, fmt.Errorf("decoding %s from MachineConfig %s failed: %v", f.Path, mc.Name, err)
+				}
+				tree := make(map[string]interface{})
+				if _, err := toml.Decode(string(contents.Data), &tree); err != nil {
+					glog.Warningf("Could not parse CRI-O drop-in %s from MachineConfig %s, skipping conflict detection for it: %v", f.Path, mc.Name, err)
+				} else {
+					dropIn.keys = flattenTOMLKeys("", tree)
+				}
+			}
+			if prev, ok := dropIns[f.Path]; ok && prev.managed && !dropIn.managed {
+				overwritten = append(overwritten, fmt.Sprintf("%s generated by MachineConfig %s is overwritten by MachineConfig %s", f.Path, prev.mcName, mc.Name))
+			}
+			dropIns[f.Path] = dropIn
+		}
+	}
+	return dropIns, overwritten, nil
+}
+
+// ValidateCRIODropIns makes sure no CRI-O drop-in coming from a MachineConfig not owned by the
+// ContainerRuntimeConfig controller overrides a setting of a drop-in that controller generated.
+// CRI-O applies drop-ins in lexical order, so without this check the resulting configuration
+// silently depends on file naming rather than on the ContainerRuntimeConfig.
+func ValidateCRIODropIns(configs []*mcfgv1.MachineConfig) error {
+	dropIns, conflicts, err := getCRIODropIns(configs)
+	if err != nil {
+		return err
+	}
+
+	var paths []string
+	for path := range dropIns {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for i, path := range paths {
+		managed := dropIns[path]
+		if !managed.managed {
+			continue
+		}
+		// only drop-ins read after the managed one can override its settings
+		for _, laterPath := range paths[i+1:] {
+			foreign := dropIns[laterPath]
+			if foreign.managed {
+				continue
+			}
+			for _, key := range managed.keys {
+				if InSlice(key, foreign.keys) {
+					conflicts = append(conflicts, fmt.Sprintf("%s from MachineConfig %s overrides %s set in %s", laterPath, foreign.mcName, key, path))
+				}
+			}
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Errorf("conflicting CRI-O drop-ins: %s", strings.Join(conflicts, "; "))
+	}
+	return nil
+}
@@ -8,6 +8,8 @@ import (
 	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/vincent-petithory/dataurl"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
@@ -264,6 +266,19 @@ func TestMergeMachineConfigs(t *testing.T) {
 	}
 	assert.Equal(t, *mergedMachineConfig, *expectedMachineConfig)
 
+	// the 64k-pages kernel can't be combined with the realtime one
+	machineConfig64kPages := &mcfgv1.MachineConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "99-64k-pages"},
+		Spec: mcfgv1.MachineConfigSpec{
+			KernelType: KernelType64kPages,
+		},
+	}
+	mergedMachineConfig, err = MergeMachineConfigs([]*mcfgv1.MachineConfig{machineConfigFIPS, machineConfig64kPages}, osImageURL)
+	require.Nil(t, err)
+	assert.Equal(t, KernelType64kPages, mergedMachineConfig.Spec.KernelType)
+	_, err = MergeMachineConfigs([]*mcfgv1.MachineConfig{machineConfigKernelType, machineConfig64kPages}, osImageURL)
+	assert.EqualError(t, err, "kernelType realtime and 64k-pages can't be combined")
+
 }
 
 func TestRemoveIgnDuplicateFilesAndUnits(t *testing.T) {
@@ -374,3 +389,197 @@ func TestRemoveIgnDuplicateFilesAndUnits(t *testing.T) {
 
 	assert.Equal(t, expectedIgn2Config, convertedIgn2Config)
 }
+
+func TestValidateCRIODropIns(t *testing.T) {
+	dropIn := func(path, contents string) ign3types.File {
+		return ign3types.File{
+			Node: ign3types.Node{Path: path},
+			FileEmbedded1: ign3types.FileEmbedded1{
+				Contents: ign3types.Resource{
+					Source: helpers.StrToPtr(dataurl.EncodeBytes([]byte(contents))),
+				},
+			},
+		}
+	}
+	ctrcfgMC := func(files ...ign3types.File) *mcfgv1.MachineConfig {
+		mc := helpers.NewMachineConfig("99-worker-generated-containerruntime", nil, "", files)
+		isController := true
+		mc.SetOwnerReferences([]metav1.OwnerReference{{Kind: "ContainerRuntimeConfig", Name: "set-log-level", Controller: &isController}})
+		return mc
+	}
+	logLevel := dropIn("/etc/crio/crio.conf.d/01-ctrcfg-logLevel", "[crio]\n[crio.runtime]\nlog_level = \"debug\"\n")
+
+	tests := []struct {
+		name      string
+		configs   []*mcfgv1.MachineConfig
+		conflicts bool
+	}{
+		{
+			name:    "managed drop-in only",
+			configs: []*mcfgv1.MachineConfig{ctrcfgMC(logLevel)},
+		},
+		{
+			name: "foreign drop-in setting other keys",
+			configs: []*mcfgv1.MachineConfig{
+				ctrcfgMC(logLevel),
+				helpers.NewMachineConfig("50-worker-crio", nil, "", []ign3types.File{dropIn("/etc/crio/crio.conf.d/99-custom", "[crio.runtime]\npids_limit = 2048\n")}),
+			},
+		},
+		{
+			name: "foreign drop-in read before the managed one",
+			configs: []*mcfgv1.MachineConfig{
+				ctrcfgMC(logLevel),
+				helpers.NewMachineConfig("50-worker-crio", nil, "", []ign3types.File{dropIn("/etc/crio/crio.conf.d/00-custom", "[crio.runtime]\nlog_level = \"info\"\n")}),
+			},
+		},
+		{
+			name: "foreign drop-in overriding a managed key",
+			configs: []*mcfgv1.MachineConfig{
+				ctrcfgMC(logLevel),
+				helpers.NewMachineConfig("50-worker-crio", nil, "", []ign3types.File{dropIn("/etc/crio/crio.conf.d/99-custom", "[crio.runtime]\nlog_level = \"info\"\n")}),
+			},
+			conflicts: true,
+		},
+		{
+			name: "foreign MachineConfig overwriting a managed drop-in",
+			configs: []*mcfgv1.MachineConfig{
+				ctrcfgMC(logLevel),
+				helpers.NewMachineConfig("99-worker-zz-crio", nil, "", []ign3types.File{dropIn("/etc/crio/crio.conf.d/01-ctrcfg-logLevel", "[crio.runtime]\nlog_level = \"info\"\n")}),
+			},
+			conflicts: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateCRIODropIns(test.configs)
+			if test.conflicts {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateMachineConfigs(t *testing.T) {
+	file := func(path, source string) ign3types.File {
+		return ign3types.File{
+			Node:          ign3types.Node{Path: path},
+			FileEmbedded1: ign3types.FileEmbedded1{Contents: ign3types.Resource{Source: helpers.StrToPtr(source)}},
+		}
+	}
+	configs := []*mcfgv1.MachineConfig{
+		helpers.NewMachineConfig("00-worker", nil, "", []ign3types.File{file("/etc/a", "data:,a")}),
+		helpers.NewMachineConfig("99-worker-ssh", nil, "", nil),
+	}
+	assert.Nil(t, ValidateMachineConfigs(configs))
+
+	configs = append(configs,
+		helpers.NewMachineConfig("50-dup", nil, "", []ign3types.File{file("/etc/a", "data:,a"), file("/etc/a", "data:,b")}),
+		helpers.NewMachineConfig("60-dup", nil, "", []ign3types.File{file("/etc/b", "data:,a"), file("/etc/b", "data:,b")}),
+	)
+	err := ValidateMachineConfigs(configs)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "MachineConfig 50-dup: ")
+	assert.Contains(t, err.Error(), "MachineConfig 60-dup: ")
+	assert.Contains(t, err.Error(), "duplicate entry defined")
+}
+
+func TestValidateMachineConfigsReconcilable(t *testing.T) {
+	file := func(path, source string) ign3types.File {
+		return ign3types.File{
+			Node:          ign3types.Node{Path: path},
+			FileEmbedded1: ign3types.FileEmbedded1{Contents: ign3types.Resource{Source: helpers.StrToPtr(source)}},
+		}
+	}
+	withUsers := func(mc *mcfgv1.MachineConfig, users ...string) *mcfgv1.MachineConfig {
+		ignCfg, err := ParseAndConvertConfig(mc.Spec.Config.Raw)
+		require.Nil(t, err)
+		for _, user := range users {
+			ignCfg.Passwd.Users = append(ignCfg.Passwd.Users, ign3types.PasswdUser{Name: user})
+		}
+		mc.Spec.Config.Raw = helpers.MarshalOrDie(ignCfg)
+		return mc
+	}
+	appended := file("/etc/appended", "data:,a")
+	appended.Append = []ign3types.Resource{{Source: helpers.StrToPtr("data:,b")}}
+	compressed := file("/etc/compressed", "data:,a")
+	compressed.Contents.Compression = helpers.StrToPtr("gzip")
+	remote := file("/etc/remote", "https://example.com/remote")
+	unsupported := []*mcfgv1.MachineConfig{
+		helpers.NewMachineConfig("50-files", nil, "", []ign3types.File{appended, compressed, remote}),
+		withUsers(helpers.NewMachineConfig("60-users", nil, "", nil), "admin"),
+	}
+	allFindings := []string{
+		"MachineConfig 50-files: ignition file /etc/appended includes append",
+		"MachineConfig 50-files: ignition file /etc/compressed is compressed",
+		"MachineConfig 50-files: ignition file /etc/remote has a remote source",
+		"MachineConfig 60-users: ignition passwd user admin is not supported",
+	}
+	changedRemote := file("/etc/remote", "https://example.com/other")
+
+	tests := []struct {
+		name     string
+		configs  []*mcfgv1.MachineConfig
+		current  *mcfgv1.MachineConfig
+		findings []string
+		warnings []string
+	}{
+		{
+			name: "supported configs",
+			configs: []*mcfgv1.MachineConfig{
+				helpers.NewMachineConfig("00-worker", nil, "", []ign3types.File{file("/etc/a", "data:,a")}),
+				helpers.NewMachineConfig("99-worker-ssh", nil, "", nil),
+			},
+			current: helpers.NewMachineConfig("rendered-worker-0", nil, "", nil),
+		},
+		{
+			name:     "unsupported sections without a current config",
+			configs:  unsupported,
+			warnings: allFindings,
+		},
+		{
+			name:     "unsupported sections added",
+			configs:  unsupported,
+			current:  helpers.NewMachineConfig("rendered-worker-0", nil, "", []ign3types.File{file("/etc/remote", "data:,a")}),
+			findings: allFindings,
+		},
+		{
+			name:     "unsupported sections already rendered",
+			configs:  unsupported,
+			current:  withUsers(helpers.NewMachineConfig("rendered-worker-0", nil, "", []ign3types.File{appended, compressed, remote}), "admin"),
+			warnings: allFindings,
+		},
+		{
+			name:    "unsupported section changed",
+			configs: []*mcfgv1.MachineConfig{helpers.NewMachineConfig("50-files", nil, "", []ign3types.File{appended, changedRemote})},
+			current: helpers.NewMachineConfig("rendered-worker-0", nil, "", []ign3types.File{appended, remote}),
+			findings: []string{
+				"MachineConfig 50-files: ignition file /etc/remote has a remote source",
+			},
+			warnings: []string{
+				"MachineConfig 50-files: ignition file /etc/appended includes append",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			warnings, err := ValidateMachineConfigsReconcilable(test.configs, test.current)
+			assert.Equal(t, len(test.warnings), len(warnings))
+			for i, warning := range test.warnings {
+				if i < len(warnings) {
+					assert.Contains(t, warnings[i], warning)
+				}
+			}
+			if len(test.findings) == 0 {
+				assert.Nil(t, err)
+				return
+			}
+			require.NotNil(t, err)
+			for _, finding := range test.findings {
+				assert.Contains(t, err.Error(), finding)
+			}
+		})
+	}
+}
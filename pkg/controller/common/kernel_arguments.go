@@ -0,0 +1,65 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+// DefaultDeniedKernelArguments are the kernel arguments disabling security features node-wide,
+// which MachineConfigs may only set when a KernelArgumentPolicy allows them
+var DefaultDeniedKernelArguments = []string{"selinux=0", "enforcing=0"}
+
+// ValidateKernelArguments makes sure the policies don't deny any of the kernel arguments. An
+// argument is denied when a policy denies it, or when it's denied by default and no policy
+// allows it.
+func ValidateKernelArguments(kargs []string, policies []*mcfgv1.KernelArgumentPolicy) error {
+	var allowed, denied []string
+	for _, policy := range policies {
+		allowed = append(allowed, policy.Spec.AllowedKernelArguments...)
+		denied = append(denied, policy.Spec.DeniedKernelArguments...)
+	}
+
+	var rejected []string
+	for _, entry := range kargs {
+		for _, karg := range strings.Fields(entry) {
+			if matchKernelArgument(karg, denied) ||
+				(matchKernelArgument(karg, DefaultDeniedKernelArguments) && !matchKernelArgument(karg, allowed)) {
+				rejected = append(rejected, karg)
+			}
+		}
+	}
+	if len(rejected) > 0 {
+		return fmt.Errorf("kernel arguments denied by policy: %s", strings.Join(rejected, " "))
+	}
+	return nil
+}
+
+// ValidateMachineConfigsKernelArguments makes sure the policies don't deny any of the kernel
+// arguments of the MachineConfigs of a pool, reporting all of them at once on the pool status
+func ValidateMachineConfigsKernelArguments(configs []*mcfgv1.MachineConfig, policies []*mcfgv1.KernelArgumentPolicy) error {
+	var findings []string
+	for _, config := range configs {
+		if err := ValidateKernelArguments(config.Spec.KernelArguments, policies); err != nil {
+			findings = append(findings, fmt.Sprintf("MachineConfig %s: %v", config.Name, err))
+		}
+	}
+	if len(findings) > 0 {
+		return errors.New(strings.Join(findings, "; "))
+	}
+	return nil
+}
+
+// matchKernelArgument returns whether karg is one of the arguments, an argument without a value
+// matching any value of its key
+func matchKernelArgument(karg string, arguments []string) bool {
+	key := strings.SplitN(karg, "=", 2)[0]
+	for _, argument := range arguments {
+		if argument == karg || (!strings.Contains(argument, "=") && argument == key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+func TestValidateKernelArguments(t *testing.T) {
+	allowSELinux := &mcfgv1.KernelArgumentPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "allow-selinux"},
+		Spec:       mcfgv1.KernelArgumentPolicySpec{AllowedKernelArguments: []string{"selinux"}},
+	}
+	denySMT := &mcfgv1.KernelArgumentPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "deny-smt"},
+		Spec:       mcfgv1.KernelArgumentPolicySpec{DeniedKernelArguments: []string{"nosmt", "enforcing=0"}},
+	}
+
+	tests := []struct {
+		name     string
+		kargs    []string
+		policies []*mcfgv1.KernelArgumentPolicy
+		err      string
+	}{{
+		name:  "allowed by default",
+		kargs: []string{"nosmt", "selinux=1"},
+	}, {
+		name:  "denied by default",
+		kargs: []string{"foo=bar selinux=0", "enforcing=0"},
+		err:   "kernel arguments denied by policy: selinux=0 enforcing=0",
+	}, {
+		name:     "allowed by a policy",
+		kargs:    []string{"selinux=0"},
+		policies: []*mcfgv1.KernelArgumentPolicy{allowSELinux},
+	}, {
+		name:     "denied by a policy",
+		kargs:    []string{"nosmt=force", "selinux=0"},
+		policies: []*mcfgv1.KernelArgumentPolicy{allowSELinux, denySMT},
+		err:      "kernel arguments denied by policy: nosmt=force",
+	}, {
+		name:     "denied by a policy over allowed by another",
+		kargs:    []string{"enforcing=0"},
+		policies: []*mcfgv1.KernelArgumentPolicy{denySMT, {Spec: mcfgv1.KernelArgumentPolicySpec{AllowedKernelArguments: []string{"enforcing=0"}}}},
+		err:      "kernel arguments denied by policy: enforcing=0",
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateKernelArguments(test.kargs, test.policies)
+			if test.err == "" {
+				assert.Nil(t, err)
+			} else {
+				assert.EqualError(t, err, test.err)
+			}
+		})
+	}
+}
+
+func TestValidateMachineConfigsKernelArguments(t *testing.T) {
+	configs := []*mcfgv1.MachineConfig{
+		{ObjectMeta: metav1.ObjectMeta{Name: "00-worker"}, Spec: mcfgv1.MachineConfigSpec{KernelArguments: []string{"nosmt"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "99-selinux"}, Spec: mcfgv1.MachineConfigSpec{KernelArguments: []string{"selinux=0"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "99-enforcing"}, Spec: mcfgv1.MachineConfigSpec{KernelArguments: []string{"enforcing=0"}}},
+	}
+	assert.EqualError(t, ValidateMachineConfigsKernelArguments(configs, nil),
+		"MachineConfig 99-selinux: kernel arguments denied by policy: selinux=0; MachineConfig 99-enforcing: kernel arguments denied by policy: enforcing=0")
+	assert.Nil(t, ValidateMachineConfigsKernelArguments(configs[:1], nil))
+}
@@ -0,0 +1,79 @@
+package common
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	// DefaultMetricsBindAddress is the address of the metrics listener of the controller
+	DefaultMetricsBindAddress = "127.0.0.1:8797"
+
+	// MCCNodeUpdateDuration is how long nodes of a pool took to update to a new config
+	MCCNodeUpdateDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "mcc_node_update_duration_seconds",
+			Help:    "time from a node being targeted at a new config to it being done updating",
+			Buckets: prometheus.ExponentialBuckets(60, 2, 8),
+		}, []string{"pool"})
+
+	// MCCPoolUpdateEstimatedCompletion is when the update in progress of a pool is estimated to complete
+	MCCPoolUpdateEstimatedCompletion = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mcc_pool_update_estimated_completion_timestamp_seconds",
+			Help: "estimated completion time of the update in progress of the pool",
+		}, []string{"pool"})
+
+	// MCCDrainStuck is set for the nodes whose drain exceeds the timeout of the drain watchdog of their pool
+	MCCDrainStuck = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mcc_drain_stuck",
+			Help: "drain of the node exceeding the timeout of the drain watchdog of its pool",
+		}, []string{"pool", "node"})
+
+	controllerMetricsList = []prometheus.Collector{
+		MCCNodeUpdateDuration,
+		MCCPoolUpdateEstimatedCompletion,
+		MCCDrainStuck,
+	}
+)
+
+func registerMCCMetrics() error {
+	for _, metric := range controllerMetricsList {
+		if err := prometheus.Register(metric); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartMetricsListener serves the metrics of the controller over http
+func StartMetricsListener(addr string, stopCh <-chan struct{}) {
+	if addr == "" {
+		addr = DefaultMetricsBindAddress
+	}
+
+	glog.Info("Registering Prometheus metrics")
+	if err := registerMCCMetrics(); err != nil {
+		glog.Errorf("unable to register metrics: %v", err)
+	}
+
+	glog.Infof("Starting metrics listener on %s", addr)
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	s := http.Server{Addr: addr, Handler: mux}
+
+	go func() {
+		if err := s.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			glog.Errorf("metrics listener exited with error: %v", err)
+		}
+	}()
+	<-stopCh
+	if err := s.Shutdown(context.Background()); err != http.ErrServerClosed {
+		glog.Errorf("error stopping metrics listener: %v", err)
+	}
+}
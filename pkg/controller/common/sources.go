@@ -0,0 +1,151 @@
+package common
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+// systemdUnitDir is where Ignition writes systemd units and their drop-ins
+const systemdUnitDir = "/etc/systemd/system"
+
+// MachineConfigSource identifies the exact MachineConfig a rendered config was rendered from
+type MachineConfigSource struct {
+	Name       string    `json:"name"`
+	UID        types.UID `json:"uid"`
+	Generation int64     `json:"generation"`
+	// Hash is the sha256 of the spec of the MachineConfig
+	Hash string `json:"hash"`
+}
+
+// ignitionPaths is the subset of an Ignition config, the same in spec 2 and 3, naming what it
+// writes. Decoding only this doesn't copy the contents of the files.
+type ignitionPaths struct {
+	Storage struct {
+		Files []struct {
+			Path string `json:"path"`
+		} `json:"files"`
+	} `json:"storage"`
+	Systemd struct {
+		Units []struct {
+			Name     string          `json:"name"`
+			Contents json.RawMessage `json:"contents"`
+			Dropins  []struct {
+				Name     string          `json:"name"`
+				Contents json.RawMessage `json:"contents"`
+			} `json:"dropins"`
+		} `json:"units"`
+	} `json:"systemd"`
+}
+
+// hasContents returns whether a unit or drop-in sets its contents
+func hasContents(contents json.RawMessage) bool {
+	return len(contents) > 0 && string(contents) != "null"
+}
+
+// machineConfigSources returns the sources of a config rendered from configs, by name
+func machineConfigSources(configs []*mcfgv1.MachineConfig) ([]MachineConfigSource, error) {
+	sources := []MachineConfigSource{}
+	for _, mc := range configs {
+		// hash the Ignition config as is rather than copying it into the marshalled spec
+		hasher := sha256.New()
+		hasher.Write(mc.Spec.Config.Raw)
+		spec := mc.Spec
+		spec.Config.Raw = nil
+		if err := json.NewEncoder(hasher).Encode(spec); err != nil {
+			return nil, err
+		}
+		sources = append(sources, MachineConfigSource{
+			Name:       mc.Name,
+			UID:        mc.UID,
+			Generation: mc.Generation,
+			Hash:       fmt.Sprintf("%x", hasher.Sum(nil)),
+		})
+	}
+	sort.Slice(sources, func(i, j int) bool { return sources[i].Name < sources[j].Name })
+	return sources, nil
+}
+
+// fileSources maps the path of each file and systemd unit written by a config rendered from
+// configs to the config it comes from: like when merging, the last config by name wins.
+func fileSources(configs []*mcfgv1.MachineConfig) (map[string]string, error) {
+	sorted := make([]*mcfgv1.MachineConfig, len(configs))
+	copy(sorted, configs)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	sources := map[string]string{}
+	for _, mc := range sorted {
+		if len(mc.Spec.Config.Raw) == 0 {
+			continue
+		}
+		var paths ignitionPaths
+		if err := json.Unmarshal(mc.Spec.Config.Raw, &paths); err != nil {
+			return nil, fmt.Errorf("parsing Ignition config of MachineConfig %s: %v", mc.Name, err)
+		}
+		for _, file := range paths.Storage.Files {
+			sources[file.Path] = mc.Name
+		}
+		for _, unit := range paths.Systemd.Units {
+			if hasContents(unit.Contents) {
+				sources[filepath.Join(systemdUnitDir, unit.Name)] = mc.Name
+			}
+			for _, dropin := range unit.Dropins {
+				if hasContents(dropin.Contents) {
+					sources[filepath.Join(systemdUnitDir, unit.Name+".d", dropin.Name)] = mc.Name
+				}
+			}
+		}
+	}
+	return sources, nil
+}
+
+// SetSourceAnnotations annotates the rendered config with the MachineConfigs it was rendered
+// from and with where each of its files comes from
+func SetSourceAnnotations(rendered *mcfgv1.MachineConfig, configs []*mcfgv1.MachineConfig) error {
+	sources, err := machineConfigSources(configs)
+	if err != nil {
+		return err
+	}
+	files, err := fileSources(configs)
+	if err != nil {
+		return err
+	}
+	sourcesJSON, err := json.Marshal(sources)
+	if err != nil {
+		return err
+	}
+	filesJSON, err := json.Marshal(files)
+	if err != nil {
+		return err
+	}
+	if rendered.Annotations == nil {
+		rendered.Annotations = map[string]string{}
+	}
+	rendered.Annotations[SourcesAnnotationKey] = string(sourcesJSON)
+	rendered.Annotations[FileSourcesAnnotationKey] = string(filesJSON)
+	return nil
+}
+
+// GetFileSource returns the name of the MachineConfig which introduced the file or systemd
+// unit at path in the rendered config
+func GetFileSource(rendered *mcfgv1.MachineConfig, path string) (string, error) {
+	value, ok := rendered.Annotations[FileSourcesAnnotationKey]
+	if !ok {
+		return "", fmt.Errorf("%s has no %s annotation", rendered.Name, FileSourcesAnnotationKey)
+	}
+	files := map[string]string{}
+	if err := json.Unmarshal([]byte(value), &files); err != nil {
+		return "", fmt.Errorf("parsing %s of %s: %v", FileSourcesAnnotationKey, rendered.Name, err)
+	}
+	source, ok := files[filepath.Clean(path)]
+	if !ok {
+		return "", fmt.Errorf("%s isn't written by %s", path, rendered.Name)
+	}
+	return source, nil
+}
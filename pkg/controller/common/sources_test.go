@@ -0,0 +1,58 @@
+package common
+
+import (
+	"testing"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func TestSetSourceAnnotations(t *testing.T) {
+	base := helpers.NewMachineConfigExtended("00-worker", nil,
+		[]ign3types.File{{Node: ign3types.Node{Path: "/etc/chrony.conf"}}, {Node: ign3types.Node{Path: "/etc/motd"}}},
+		[]ign3types.Unit{
+			{Name: "kubelet.service", Contents: helpers.StrToPtr("[Unit]")},
+			{Name: "crio.service", Dropins: []ign3types.Dropin{{Name: "10-proxy.conf", Contents: helpers.StrToPtr("[Service]")}}},
+			{Name: "sshd.service", Enabled: helpers.BoolToPtr(true)},
+		},
+		nil, nil, false, nil, "", "dummy://")
+	base.UID = "uid-00"
+	base.Generation = 3
+	override := helpers.NewMachineConfig("99-chrony", nil, "", []ign3types.File{{Node: ign3types.Node{Path: "/etc/chrony.conf"}}})
+	override.UID = "uid-99"
+	override.Generation = 1
+
+	rendered := &mcfgv1.MachineConfig{}
+	rendered.Name = "rendered-worker-1"
+	require.Nil(t, SetSourceAnnotations(rendered, []*mcfgv1.MachineConfig{override, base}))
+	assert.Regexp(t, `^\[\{"name":"00-worker","uid":"uid-00","generation":3,"hash":"[0-9a-f]{64}"\},\{"name":"99-chrony","uid":"uid-99","generation":1,"hash":"[0-9a-f]{64}"\}\]$`, rendered.Annotations[SourcesAnnotationKey])
+
+	for path, expected := range map[string]string{
+		"/etc/chrony.conf":                    "99-chrony",
+		"/etc/motd":                           "00-worker",
+		"/etc/systemd/system/kubelet.service": "00-worker",
+		"/etc/systemd/system/crio.service.d/10-proxy.conf": "00-worker",
+		"/etc/systemd//system/./kubelet.service":           "00-worker",
+	} {
+		source, err := GetFileSource(rendered, path)
+		require.Nil(t, err, path)
+		assert.Equal(t, expected, source, path)
+	}
+	_, err := GetFileSource(rendered, "/etc/systemd/system/sshd.service")
+	assert.NotNil(t, err)
+
+	// the hash follows the contents, not the metadata
+	before := rendered.Annotations[SourcesAnnotationKey]
+	override.Generation = 2
+	require.Nil(t, SetSourceAnnotations(rendered, []*mcfgv1.MachineConfig{override, base}))
+	sources, err := machineConfigSources([]*mcfgv1.MachineConfig{override})
+	require.Nil(t, err)
+	previous, err := machineConfigSources([]*mcfgv1.MachineConfig{helpers.NewMachineConfig("99-chrony", nil, "", []ign3types.File{{Node: ign3types.Node{Path: "/etc/chrony.conf"}}})})
+	require.Nil(t, err)
+	assert.Equal(t, previous[0].Hash, sources[0].Hash)
+	assert.NotEqual(t, before, rendered.Annotations[SourcesAnnotationKey])
+}
@@ -0,0 +1,81 @@
+package common
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/clarketm/json"
+	ign2types "github.com/coreos/ignition/config/v2_2/types"
+	"github.com/pkg/errors"
+)
+
+// ignitionVersion is the part of an Ignition config telling its spec version
+type ignitionVersion struct {
+	Ignition struct {
+		Version string `json:"version"`
+	} `json:"ignition"`
+}
+
+// spec2ConversionLosses returns what converting the spec 2 config to spec 3 drops: spec 3
+// doesn't allow duplicated files, units or users, so only the last of them is kept
+func spec2ConversionLosses(ignConfig ign2types.Config) []string {
+	files := map[string]int{}
+	for _, file := range ignConfig.Storage.Files {
+		files[file.Path]++
+	}
+	units := map[string]int{}
+	for _, unit := range ignConfig.Systemd.Units {
+		units[unit.Name]++
+	}
+
+	var losses []string
+	for path, count := range files {
+		if count > 1 {
+			losses = append(losses, fmt.Sprintf("file %s is written %d times, only the last one is kept", path, count))
+		}
+	}
+	for name, count := range units {
+		if count > 1 {
+			losses = append(losses, fmt.Sprintf("unit %s is defined %d times, only the last one is kept along with the drop-ins of all of them", name, count))
+		}
+	}
+	if len(ignConfig.Passwd.Users) > 1 {
+		losses = append(losses, fmt.Sprintf("user core is defined %d times, only the last one is kept along with the SSH keys of all of them", len(ignConfig.Passwd.Users)))
+	}
+	sort.Strings(losses)
+	return losses
+}
+
+// ConvertSpec2Config converts a spec 2 Ignition config to spec 3 the way rendering does, along
+// with what the conversion dropped. It returns a nil config if rawIgn isn't a spec 2 config, and
+// an error if it can't be converted.
+func ConvertSpec2Config(rawIgn []byte) ([]byte, []string, error) {
+	var version ignitionVersion
+	if err := json.Unmarshal(rawIgn, &version); err != nil || !strings.HasPrefix(version.Ignition.Version, "2.") {
+		return nil, nil, nil
+	}
+	ignconfigi, err := IgnParseWrapper(rawIgn)
+	if err != nil {
+		return nil, nil, err
+	}
+	ignConfig, ok := ignconfigi.(ign2types.Config)
+	if !ok {
+		return nil, nil, errors.Errorf("unexpected type for ignition config: %T", ignconfigi)
+	}
+
+	losses := spec2ConversionLosses(ignConfig)
+	deduped, err := removeIgnDuplicateFilesUnitsUsers(ignConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	converted, err := convertIgnition2to3(deduped)
+	if err != nil {
+		return nil, nil, err
+	}
+	raw, err := json.Marshal(converted)
+	if err != nil {
+		return nil, nil, err
+	}
+	return raw, losses, nil
+}
@@ -0,0 +1,50 @@
+package common
+
+import (
+	"testing"
+
+	ign2types "github.com/coreos/ignition/config/v2_2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func newSpec2File(path, source string) ign2types.File {
+	return ign2types.File{
+		Node:          ign2types.Node{Filesystem: "root", Path: path},
+		FileEmbedded1: ign2types.FileEmbedded1{Contents: ign2types.FileContents{Source: source}},
+	}
+}
+
+func TestConvertSpec2Config(t *testing.T) {
+	// spec 3 configs are left alone
+	converted, losses, err := ConvertSpec2Config(helpers.MarshalOrDie(map[string]interface{}{"ignition": map[string]string{"version": "3.2.0"}}))
+	require.Nil(t, err)
+	assert.Nil(t, converted)
+	assert.Nil(t, losses)
+
+	ign2Config := ign2types.Config{Ignition: ign2types.Ignition{Version: "2.2.0"}}
+	ign2Config.Storage.Files = []ign2types.File{
+		newSpec2File("/etc/motd", "data:,hello"),
+		newSpec2File("/etc/chrony.conf", "data:,old"),
+		newSpec2File("/etc/chrony.conf", "data:,new"),
+	}
+	converted, losses, err = ConvertSpec2Config(helpers.MarshalOrDie(ign2Config))
+	require.Nil(t, err)
+	assert.Equal(t, []string{"file /etc/chrony.conf is written 2 times, only the last one is kept"}, losses)
+	ign3Config, err := ParseAndConvertConfig(converted)
+	require.Nil(t, err)
+	assert.Equal(t, "3.2.0", ign3Config.Ignition.Version)
+	require.Len(t, ign3Config.Storage.Files, 2)
+	for _, file := range ign3Config.Storage.Files {
+		if file.Path == "/etc/chrony.conf" {
+			assert.Equal(t, "data:,new", *file.Contents.Source)
+		}
+	}
+
+	// users other than core can't be converted
+	ign2Config.Passwd.Users = []ign2types.PasswdUser{{Name: "admin"}}
+	_, _, err = ConvertSpec2Config(helpers.MarshalOrDie(ign2Config))
+	assert.NotNil(t, err)
+}
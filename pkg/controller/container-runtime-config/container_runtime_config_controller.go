@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"time"
 
@@ -235,6 +236,8 @@ func (ctrl *Controller) updateContainerRuntimeConfig(oldObj, newObj interface{})
 	if ctrConfigTriggerObjectChange(oldCtrCfg, newCtrCfg) {
 		glog.V(4).Infof("Update ContainerRuntimeConfig %s", oldCtrCfg.Name)
 		ctrl.enqueueContainerRuntimeConfig(newCtrCfg)
+		// the image policy is rendered with the registries config
+		ctrl.imgQueue.Add("openshift-config")
 	}
 }
 
@@ -242,6 +245,7 @@ func (ctrl *Controller) addContainerRuntimeConfig(obj interface{}) {
 	cfg := obj.(*mcfgv1.ContainerRuntimeConfig)
 	glog.V(4).Infof("Adding ContainerRuntimeConfig %s", cfg.Name)
 	ctrl.enqueueContainerRuntimeConfig(cfg)
+	ctrl.imgQueue.Add("openshift-config")
 }
 
 func (ctrl *Controller) deleteContainerRuntimeConfig(obj interface{}) {
@@ -263,6 +267,7 @@ func (ctrl *Controller) deleteContainerRuntimeConfig(obj interface{}) {
 	} else {
 		glog.V(4).Infof("Deleted ContainerRuntimeConfig %s and restored default config", cfg.Name)
 	}
+	ctrl.imgQueue.Add("openshift-config")
 }
 
 func (ctrl *Controller) cascadeDelete(cfg *mcfgv1.ContainerRuntimeConfig) error {
@@ -563,7 +568,8 @@ func (ctrl *Controller) syncContainerRuntimeConfig(key string) error {
 		}
 
 		// Create the cri-o drop-in files
-		if ctrcfg.LogLevel != "" || ctrcfg.PidsLimit != nil || ctrcfg.LogSizeMax != (resource.Quantity{}) {
+		if ctrcfg.LogLevel != "" || ctrcfg.PidsLimit != nil || ctrcfg.LogSizeMax != (resource.Quantity{}) ||
+			ctrcfg.DefaultRuntime != mcfgv1.ContainerRuntimeDefaultRuntimeEmpty {
 			crioFileConfigs := createCRIODropinFiles(cfg)
 			configFileList = append(configFileList, crioFileConfigs...)
 		}
@@ -710,10 +716,18 @@ func (ctrl *Controller) syncImageConfig(key string) error {
 		if err != nil {
 			return err
 		}
+		releaseImage := ""
+		if clusterVersionCfg != nil {
+			releaseImage = clusterVersionCfg.Status.Desired.Image
+		}
+		imagePolicies, err := ctrl.getImagePoliciesForPool(pool, releaseImage)
+		if err != nil {
+			return err
+		}
 		if err := retry.RetryOnConflict(updateBackoff, func() error {
 			registriesIgn, err := registriesConfigIgnition(ctrl.templatesDir, controllerConfig, role,
 				imgcfg.Spec.RegistrySources.InsecureRegistries, blockedRegs, imgcfg.Spec.RegistrySources.AllowedRegistries,
-				imgcfg.Spec.RegistrySources.ContainerRuntimeSearchRegistries, icspRules)
+				imgcfg.Spec.RegistrySources.ContainerRuntimeSearchRegistries, icspRules, imagePolicies)
 			if err != nil {
 				return err
 			}
@@ -774,11 +788,13 @@ func (ctrl *Controller) syncImageConfig(key string) error {
 }
 
 func registriesConfigIgnition(templateDir string, controllerConfig *mcfgv1.ControllerConfig, role string,
-	insecureRegs, blockedRegs, allowedRegs, searchRegs []string, icspRules []*apioperatorsv1alpha1.ImageContentSourcePolicy) (*ign3types.Config, error) {
+	insecureRegs, blockedRegs, allowedRegs, searchRegs []string, icspRules []*apioperatorsv1alpha1.ImageContentSourcePolicy,
+	imagePolicies []*mcfgv1.ContainerRuntimeImagePolicy) (*ign3types.Config, error) {
 
 	var (
 		registriesTOML []byte
 		policyJSON     []byte
+		sigstoreYAML   []byte
 	)
 
 	// Generate the original registries config
@@ -813,9 +829,30 @@ func registriesConfigIgnition(templateDir string, controllerConfig *mcfgv1.Contr
 			return nil, fmt.Errorf("could not update policy json with new changes: %v", err)
 		}
 	}
+	if len(imagePolicies) != 0 {
+		if policyJSON == nil {
+			if originalPolicyIgn.Contents.Source == nil {
+				return nil, fmt.Errorf("original policy json is empty")
+			}
+			dataURL, err := dataurl.DecodeString(*originalPolicyIgn.Contents.Source)
+			if err != nil {
+				return nil, fmt.Errorf("could not decode original policy json: %v", err)
+			}
+			policyJSON = dataURL.Data
+		}
+		policyJSON, err = updatePolicyJSONImagePolicies(policyJSON, imagePolicies)
+		if err != nil {
+			return nil, fmt.Errorf("could not update policy json with the image policies: %v", err)
+		}
+		sigstoreYAML, err = imagePolicySigstoreConfig(imagePolicies)
+		if err != nil {
+			return nil, fmt.Errorf("could not generate the image policies sigstore config: %v", err)
+		}
+	}
 	generatedConfigFileList := []generatedConfigFile{
 		{filePath: registriesConfigPath, data: registriesTOML},
 		{filePath: policyConfigPath, data: policyJSON},
+		{filePath: ImagePolicySigstoreConfigPath, data: sigstoreYAML},
 	}
 	if searchRegs != nil {
 		generatedConfigFileList = append(generatedConfigFileList, updateSearchRegistriesConfig(searchRegs)...)
@@ -857,7 +894,7 @@ func RunImageBootstrap(templateDir string, controllerConfig *mcfgv1.ControllerCo
 			return nil, err
 		}
 		registriesIgn, err := registriesConfigIgnition(templateDir, controllerConfig, role,
-			insecureRegs, blockedRegs, allowedRegs, searchRegs, icspRules)
+			insecureRegs, blockedRegs, allowedRegs, searchRegs, icspRules, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -952,6 +989,47 @@ func (ctrl *Controller) addFinalizerToContainerRuntimeConfig(ctrCfg *mcfgv1.Cont
 	})
 }
 
+// getImagePoliciesForPool returns the image policies of the valid ContainerRuntimeConfigs selecting the pool,
+// sorted by ContainerRuntimeConfig name. The policies are rendered with the registries config of the pool, which
+// owns policy.json, rather than with the ContainerRuntimeConfig MachineConfig. Scopes preventing pulling the
+// release image are dropped with a warning event.
+func (ctrl *Controller) getImagePoliciesForPool(pool *mcfgv1.MachineConfigPool, releaseImage string) ([]*mcfgv1.ContainerRuntimeImagePolicy, error) {
+	cfgs, err := ctrl.mccrLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(cfgs, func(i, j int) bool { return cfgs[i].Name < cfgs[j].Name })
+
+	var policies []*mcfgv1.ContainerRuntimeImagePolicy
+	for _, cfg := range cfgs {
+		if cfg.DeletionTimestamp != nil || cfg.Spec.ContainerRuntimeConfig == nil || cfg.Spec.ContainerRuntimeConfig.ImagePolicy == nil {
+			continue
+		}
+		// invalid configs are reported in the status of the ContainerRuntimeConfig
+		if err := validateUserContainerRuntimeConfig(cfg); err != nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(cfg.Spec.MachineConfigPoolSelector)
+		if err != nil || selector.Empty() || !selector.Matches(labels.Set(pool.Labels)) {
+			continue
+		}
+		policy := cfg.Spec.ContainerRuntimeConfig.ImagePolicy
+		if releaseImage != "" {
+			var dropped []string
+			policy, dropped, err = getValidImagePolicy(releaseImage, policy)
+			if err != nil {
+				return nil, err
+			}
+			for _, scope := range dropped {
+				glog.V(2).Infof("imagePolicy scope %q of ContainerRuntimeConfig %s covers the payload image, skipping....", scope, cfg.Name)
+				ctrl.eventRecorder.Eventf(cfg, corev1.EventTypeWarning, "ImagePolicyScopeDropped", "imagePolicy scope %q cannot reject the payload image %s, skipping it", scope, releaseImage)
+			}
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
 func (ctrl *Controller) getPoolsForContainerRuntimeConfig(config *mcfgv1.ContainerRuntimeConfig) ([]*mcfgv1.MachineConfigPool, error) {
 	pList, err := ctrl.mcpLister.List(labels.Everything())
 	if err != nil {
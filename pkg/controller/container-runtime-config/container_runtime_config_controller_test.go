@@ -897,6 +897,12 @@ func TestContainerRuntimeConfigOptions(t *testing.T) {
 				LogLevel: "invalid",
 			},
 		},
+		{
+			name: "invalid value of default runtime",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				DefaultRuntime: "kata",
+			},
+		},
 	}
 
 	successTests := []struct {
@@ -927,6 +933,12 @@ func TestContainerRuntimeConfigOptions(t *testing.T) {
 				LogLevel: "debug",
 			},
 		},
+		{
+			name: "valid default runtime",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				DefaultRuntime: mcfgv1.ContainerRuntimeDefaultRuntimeCrun,
+			},
+		},
 	}
 
 	// Failure Tests
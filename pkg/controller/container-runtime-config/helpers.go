@@ -6,8 +6,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
 	"reflect"
 	"strconv"
+	"strings"
 
 	"github.com/BurntSushi/toml"
 	"github.com/containers/image/docker/reference"
@@ -15,6 +17,7 @@ import (
 	signature "github.com/containers/image/signature"
 	storageconfig "github.com/containers/storage/pkg/config"
 	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/ghodss/yaml"
 	"github.com/golang/glog"
 	apicfgv1 "github.com/openshift/api/config/v1"
 	apioperatorsv1alpha1 "github.com/openshift/api/operator/v1alpha1"
@@ -40,6 +43,14 @@ const (
 	CRIODropInFilePathLogLevel   = "/etc/crio/crio.conf.d/01-ctrcfg-logLevel"
 	crioDropInFilePathPidsLimit  = "/etc/crio/crio.conf.d/01-ctrcfg-pidsLimit"
 	crioDropInFilePathLogSizeMax = "/etc/crio/crio.conf.d/01-ctrcfg-logSizeMax"
+	// CRIODropInFilePathDefaultRuntime is the path at which changes to the crio config for the
+	// default runtime will be dropped. The daemon keys off this path to apply a change of the
+	// default runtime with a crio restart instead of a reboot, keep them in sync.
+	CRIODropInFilePathDefaultRuntime = "/etc/crio/crio.conf.d/01-ctrcfg-defaultRuntime"
+	// ImagePolicySigstoreConfigPath is the registries.d file the lookaside signature storages of the
+	// ContainerRuntimeConfig image policies are written to. The daemon reloads CRI-O instead of
+	// rebooting when only this file and the policy.json change, keep them in sync.
+	ImagePolicySigstoreConfigPath = "/etc/containers/registries.d/99-ctrcfg-imagePolicy.yaml"
 )
 
 var errParsingReference = errors.New("error parsing reference of release image")
@@ -91,6 +102,25 @@ type tomlConfigCRIOLogSizeMax struct {
 	} `toml:"crio"`
 }
 
+// tomlConfigCRIODefaultRuntime is used for conversions when default-runtime is changed
+// TOML-friendly (it has all of the explicit tables). It's just used for
+// conversions.
+type tomlConfigCRIODefaultRuntime struct {
+	Crio struct {
+		Runtime struct {
+			DefaultRuntime string                                  `toml:"default_runtime,omitempty"`
+			Runtimes       map[string]tomlConfigCRIORuntimeHandler `toml:"runtimes,omitempty"`
+		} `toml:"runtime"`
+	} `toml:"crio"`
+}
+
+// tomlConfigCRIORuntimeHandler is a single [crio.runtime.runtimes.<name>] table
+type tomlConfigCRIORuntimeHandler struct {
+	RuntimePath string `toml:"runtime_path"`
+	RuntimeType string `toml:"runtime_type"`
+	RuntimeRoot string `toml:"runtime_root"`
+}
+
 // generatedConfigFile is a struct that holds the filepath and data of the various configs
 // Using a struct array ensures that the order of the ignition files always stay the same
 // ensuring that double MCs are not created due to a change in the order
@@ -336,6 +366,24 @@ func createCRIODropinFiles(cfg *mcfgv1.ContainerRuntimeConfig) []generatedConfig
 			glog.V(2).Infoln(cfg, err, "error updating user changes for log-size-max to crio.conf.d: %v", err)
 		}
 	}
+	if ctrcfg.DefaultRuntime != mcfgv1.ContainerRuntimeDefaultRuntimeEmpty {
+		tomlConf := tomlConfigCRIODefaultRuntime{}
+		tomlConf.Crio.Runtime.DefaultRuntime = string(ctrcfg.DefaultRuntime)
+		// runc is configured by the base crio.conf, any other runtime needs its own handler table
+		if ctrcfg.DefaultRuntime != mcfgv1.ContainerRuntimeDefaultRuntimeRunc {
+			tomlConf.Crio.Runtime.Runtimes = map[string]tomlConfigCRIORuntimeHandler{
+				string(ctrcfg.DefaultRuntime): {
+					RuntimePath: "/usr/bin/" + string(ctrcfg.DefaultRuntime),
+					RuntimeType: "oci",
+					RuntimeRoot: "/run/" + string(ctrcfg.DefaultRuntime),
+				},
+			}
+		}
+		generatedConfigFileList, err = addTOMLgeneratedConfigFile(generatedConfigFileList, CRIODropInFilePathDefaultRuntime, tomlConf)
+		if err != nil {
+			glog.V(2).Infoln(cfg, err, "error updating user changes for default-runtime to crio.conf.d: %v", err)
+		}
+	}
 	return generatedConfigFileList
 }
 
@@ -450,6 +498,18 @@ func validateUserContainerRuntimeConfig(cfg *mcfgv1.ContainerRuntimeConfig) erro
 		return fmt.Errorf("invalid overlaySize %q, cannot be less than 0", ctrcfg.OverlaySize.String())
 	}
 
+	if ctrcfg.DefaultRuntime != mcfgv1.ContainerRuntimeDefaultRuntimeEmpty &&
+		ctrcfg.DefaultRuntime != mcfgv1.ContainerRuntimeDefaultRuntimeRunc &&
+		ctrcfg.DefaultRuntime != mcfgv1.ContainerRuntimeDefaultRuntimeCrun {
+		return fmt.Errorf("invalid DefaultRuntime %q, must be one of runc or crun", ctrcfg.DefaultRuntime)
+	}
+
+	if ctrcfg.ImagePolicy != nil {
+		if err := validateImagePolicy(ctrcfg.ImagePolicy); err != nil {
+			return err
+		}
+	}
+
 	if ctrcfg.LogLevel != "" {
 		validLogLevels := map[string]bool{
 			"error": true,
@@ -468,6 +528,152 @@ func validateUserContainerRuntimeConfig(cfg *mcfgv1.ContainerRuntimeConfig) erro
 	return nil
 }
 
+// validateImagePolicy ensures the image policy can be rendered into a policy.json containers/image accepts
+func validateImagePolicy(policy *mcfgv1.ContainerRuntimeImagePolicy) error {
+	scopes := make(map[string]bool)
+	for _, scope := range policy.Scopes {
+		if scope.Scope == "" || strings.ContainsAny(scope.Scope, " \t") || strings.Contains(scope.Scope, "://") {
+			return fmt.Errorf("invalid imagePolicy scope %q, must be a registry, repository or image reference", scope.Scope)
+		}
+		if scopes[scope.Scope] {
+			return fmt.Errorf("invalid imagePolicy, scope %q is set more than once", scope.Scope)
+		}
+		scopes[scope.Scope] = true
+
+		switch scope.Requirement {
+		case mcfgv1.ContainerRuntimeImagePolicyInsecureAcceptAnything, mcfgv1.ContainerRuntimeImagePolicyReject:
+			if scope.KeyData != "" {
+				return fmt.Errorf("invalid imagePolicy scope %q, keyData is only allowed with the SignedBy requirement", scope.Scope)
+			}
+		case mcfgv1.ContainerRuntimeImagePolicySignedBy:
+			if scope.KeyData == "" {
+				return fmt.Errorf("invalid imagePolicy scope %q, keyData is required with the SignedBy requirement", scope.Scope)
+			}
+		default:
+			return fmt.Errorf("invalid imagePolicy scope %q, requirement %q must be one of InsecureAcceptAnything, Reject or SignedBy", scope.Scope, scope.Requirement)
+		}
+
+		if _, err := imagePolicySignedIdentity(scope.SignedIdentity); err != nil {
+			return fmt.Errorf("invalid imagePolicy scope %q: %v", scope.Scope, err)
+		}
+
+		if scope.Sigstore != "" {
+			u, err := url.Parse(scope.Sigstore)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "file") {
+				return fmt.Errorf("invalid imagePolicy scope %q, sigstore %q must be a http, https or file URL", scope.Scope, scope.Sigstore)
+			}
+		}
+	}
+	return nil
+}
+
+func imagePolicySignedIdentity(identity mcfgv1.ContainerRuntimeImagePolicySignedIdentity) (signature.PolicyReferenceMatch, error) {
+	switch identity {
+	case "", mcfgv1.ContainerRuntimeImagePolicyMatchRepoDigestOrExact:
+		return signature.NewPRMMatchRepoDigestOrExact(), nil
+	case mcfgv1.ContainerRuntimeImagePolicyMatchRepository:
+		return signature.NewPRMMatchRepository(), nil
+	case mcfgv1.ContainerRuntimeImagePolicyMatchExact:
+		return signature.NewPRMMatchExact(), nil
+	}
+	return nil, fmt.Errorf("signedIdentity %q must be one of MatchRepoDigestOrExact, MatchRepository or MatchExact", identity)
+}
+
+func imagePolicyRequirement(scope mcfgv1.ContainerRuntimeImagePolicyScope) (signature.PolicyRequirement, error) {
+	switch scope.Requirement {
+	case mcfgv1.ContainerRuntimeImagePolicyInsecureAcceptAnything:
+		return signature.NewPRInsecureAcceptAnything(), nil
+	case mcfgv1.ContainerRuntimeImagePolicyReject:
+		return signature.NewPRReject(), nil
+	case mcfgv1.ContainerRuntimeImagePolicySignedBy:
+		identity, err := imagePolicySignedIdentity(scope.SignedIdentity)
+		if err != nil {
+			return nil, err
+		}
+		return signature.NewPRSignedByKeyData(signature.SBKeyTypeGPGKeys, []byte(scope.KeyData), identity)
+	}
+	return nil, fmt.Errorf("unknown requirement %q", scope.Requirement)
+}
+
+// updatePolicyJSONImagePolicies decodes the policy JSON and sets the requirements of the image policy scopes
+// on the docker transport. Policies are applied in order, so a later policy overrides the scopes of an earlier one.
+func updatePolicyJSONImagePolicies(data []byte, policies []*mcfgv1.ContainerRuntimeImagePolicy) ([]byte, error) {
+	policyObj := &signature.Policy{}
+	if err := json.NewDecoder(bytes.NewBuffer(data)).Decode(policyObj); err != nil {
+		return nil, fmt.Errorf("error decoding policy json: %v", err)
+	}
+	if policyObj.Transports == nil {
+		policyObj.Transports = make(map[string]signature.PolicyTransportScopes)
+	}
+	// The docker and atomic transports may share the same scopes, don't leak the image policies into atomic
+	dockerScopes := make(signature.PolicyTransportScopes)
+	for scope, reqs := range policyObj.Transports["docker"] {
+		dockerScopes[scope] = reqs
+	}
+	for _, policy := range policies {
+		for _, scope := range policy.Scopes {
+			req, err := imagePolicyRequirement(scope)
+			if err != nil {
+				return nil, fmt.Errorf("invalid imagePolicy scope %q: %v", scope.Scope, err)
+			}
+			dockerScopes[scope.Scope] = signature.PolicyRequirements{req}
+		}
+	}
+	policyObj.Transports["docker"] = dockerScopes
+
+	return json.Marshal(policyObj)
+}
+
+// imagePolicySigstoreConfig returns the registries.d configuration of the lookaside signature storages of
+// the image policies, or nil if none is set
+func imagePolicySigstoreConfig(policies []*mcfgv1.ContainerRuntimeImagePolicy) ([]byte, error) {
+	type registryConfiguration struct {
+		Sigstore string `json:"sigstore"`
+	}
+	docker := make(map[string]registryConfiguration)
+	for _, policy := range policies {
+		for _, scope := range policy.Scopes {
+			if scope.Sigstore != "" {
+				docker[scope.Scope] = registryConfiguration{Sigstore: scope.Sigstore}
+			}
+		}
+	}
+	if len(docker) == 0 {
+		return nil, nil
+	}
+	return yaml.Marshal(map[string]interface{}{"docker": docker})
+}
+
+// getValidImagePolicy returns the image policy without the Reject scopes covering the image being used
+// by the payload, along with the dropped scopes. As for blocked registries, the policy must not prevent
+// pulling the payload. SignedBy scopes are kept, the payload is signed.
+func getValidImagePolicy(releaseImage string, policy *mcfgv1.ContainerRuntimeImagePolicy) (*mcfgv1.ContainerRuntimeImagePolicy, []string, error) {
+	payload, err := reference.ParseNamed(releaseImage)
+	if err != nil {
+		return nil, nil, errParsingReference
+	}
+	var dropped []string
+	valid := policy.DeepCopy()
+	valid.Scopes = nil
+	for _, scope := range policy.Scopes {
+		if scope.Requirement == mcfgv1.ContainerRuntimeImagePolicyReject && imagePolicyScopeCoversImage(scope.Scope, payload) {
+			dropped = append(dropped, scope.Scope)
+			continue
+		}
+		valid.Scopes = append(valid.Scopes, scope)
+	}
+	return valid, dropped, nil
+}
+
+// imagePolicyScopeCoversImage returns true if the policy.json scope applies to the image: the scope is
+// the image, its repository, one of its namespaces or its registry
+func imagePolicyScopeCoversImage(scope string, image reference.Named) bool {
+	if ref, err := reference.ParseNamed(scope); err == nil && ref.Name() == image.Name() {
+		return true
+	}
+	return scope == reference.Domain(image) || strings.HasPrefix(image.Name(), scope+"/")
+}
+
 // getValidBlockedRegistries gets the blocked registries in the image spec and validates that the user is not adding
 // the registry being used by the payload to the list of blocked registries.
 // If the user is, we drop that registry and continue with syncing the registries.conf with the other registry options
@@ -12,10 +12,13 @@ import (
 	"github.com/containers/image/pkg/sysregistriesv2"
 	signature "github.com/containers/image/signature"
 	"github.com/containers/image/types"
+	"github.com/ghodss/yaml"
 	apioperatorsv1alpha1 "github.com/openshift/api/operator/v1alpha1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"k8s.io/apimachinery/pkg/util/diff"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
 )
 
 func TestUpdateRegistriesConfig(t *testing.T) {
@@ -259,3 +262,149 @@ func TestUpdatePolicyJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateImagePolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		scope   mcfgv1.ContainerRuntimeImagePolicyScope
+		wantErr bool
+	}{{
+		name:  "signed",
+		scope: mcfgv1.ContainerRuntimeImagePolicyScope{Scope: "registry.example.com", Requirement: mcfgv1.ContainerRuntimeImagePolicySignedBy, KeyData: "key", Sigstore: "https://sigstore.example.com"},
+	}, {
+		name:  "reject",
+		scope: mcfgv1.ContainerRuntimeImagePolicyScope{Scope: "registry.example.com/team/app:v1", Requirement: mcfgv1.ContainerRuntimeImagePolicyReject},
+	}, {
+		name:    "empty scope",
+		scope:   mcfgv1.ContainerRuntimeImagePolicyScope{Requirement: mcfgv1.ContainerRuntimeImagePolicyReject},
+		wantErr: true,
+	}, {
+		name:    "scope with a transport",
+		scope:   mcfgv1.ContainerRuntimeImagePolicyScope{Scope: "docker://registry.example.com", Requirement: mcfgv1.ContainerRuntimeImagePolicyReject},
+		wantErr: true,
+	}, {
+		name:    "unknown requirement",
+		scope:   mcfgv1.ContainerRuntimeImagePolicyScope{Scope: "registry.example.com", Requirement: "SignedBySomeone"},
+		wantErr: true,
+	}, {
+		name:    "signed without key",
+		scope:   mcfgv1.ContainerRuntimeImagePolicyScope{Scope: "registry.example.com", Requirement: mcfgv1.ContainerRuntimeImagePolicySignedBy},
+		wantErr: true,
+	}, {
+		name:    "key without signature requirement",
+		scope:   mcfgv1.ContainerRuntimeImagePolicyScope{Scope: "registry.example.com", Requirement: mcfgv1.ContainerRuntimeImagePolicyInsecureAcceptAnything, KeyData: "key"},
+		wantErr: true,
+	}, {
+		name:    "unknown identity",
+		scope:   mcfgv1.ContainerRuntimeImagePolicyScope{Scope: "registry.example.com", Requirement: mcfgv1.ContainerRuntimeImagePolicySignedBy, KeyData: "key", SignedIdentity: "MatchAnything"},
+		wantErr: true,
+	}, {
+		name:    "invalid sigstore",
+		scope:   mcfgv1.ContainerRuntimeImagePolicyScope{Scope: "registry.example.com", Requirement: mcfgv1.ContainerRuntimeImagePolicySignedBy, KeyData: "key", Sigstore: "sigstore.example.com"},
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateImagePolicy(&mcfgv1.ContainerRuntimeImagePolicy{Scopes: []mcfgv1.ContainerRuntimeImagePolicyScope{tt.scope}})
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	// duplicated scopes
+	err := validateImagePolicy(&mcfgv1.ContainerRuntimeImagePolicy{Scopes: []mcfgv1.ContainerRuntimeImagePolicyScope{
+		{Scope: "registry.example.com", Requirement: mcfgv1.ContainerRuntimeImagePolicyReject},
+		{Scope: "registry.example.com", Requirement: mcfgv1.ContainerRuntimeImagePolicyInsecureAcceptAnything},
+	}})
+	assert.Error(t, err)
+}
+
+func TestUpdatePolicyJSONImagePolicies(t *testing.T) {
+	templateBytes, err := updatePolicyJSON([]byte(`{"default":[{"type":"insecureAcceptAnything"}],"transports":{"docker-daemon":{"":[{"type":"insecureAcceptAnything"}]}}}`),
+		[]string{"block.com"}, nil)
+	require.NoError(t, err)
+
+	policies := []*mcfgv1.ContainerRuntimeImagePolicy{
+		{Scopes: []mcfgv1.ContainerRuntimeImagePolicyScope{
+			{Scope: "registry.example.com", Requirement: mcfgv1.ContainerRuntimeImagePolicySignedBy, KeyData: "key", Sigstore: "https://sigstore.example.com"},
+			{Scope: "quay.io/team", Requirement: mcfgv1.ContainerRuntimeImagePolicyReject},
+		}},
+		// later policies override earlier ones
+		{Scopes: []mcfgv1.ContainerRuntimeImagePolicyScope{
+			{Scope: "quay.io/team", Requirement: mcfgv1.ContainerRuntimeImagePolicySignedBy, KeyData: "key", SignedIdentity: mcfgv1.ContainerRuntimeImagePolicyMatchRepository},
+		}},
+	}
+	got, err := updatePolicyJSONImagePolicies(templateBytes, policies)
+	require.NoError(t, err)
+	// Ensure that the generated configuration is actually valid.
+	_, err = signature.NewPolicyFromBytes(got)
+	require.NoError(t, err)
+
+	signedByExample, err := signature.NewPRSignedByKeyData(signature.SBKeyTypeGPGKeys, []byte("key"), signature.NewPRMMatchRepoDigestOrExact())
+	require.NoError(t, err)
+	signedByTeam, err := signature.NewPRSignedByKeyData(signature.SBKeyTypeGPGKeys, []byte("key"), signature.NewPRMMatchRepository())
+	require.NoError(t, err)
+	want := signature.Policy{
+		Default: signature.PolicyRequirements{signature.NewPRInsecureAcceptAnything()},
+		Transports: map[string]signature.PolicyTransportScopes{
+			"atomic": map[string]signature.PolicyRequirements{
+				"block.com": signature.PolicyRequirements{signature.NewPRReject()},
+			},
+			"docker": map[string]signature.PolicyRequirements{
+				"block.com":            signature.PolicyRequirements{signature.NewPRReject()},
+				"registry.example.com": signature.PolicyRequirements{signedByExample},
+				"quay.io/team":         signature.PolicyRequirements{signedByTeam},
+			},
+			"docker-daemon": map[string]signature.PolicyRequirements{
+				"": signature.PolicyRequirements{signature.NewPRInsecureAcceptAnything()},
+			},
+		},
+	}
+	gotConf := signature.Policy{}
+	require.NoError(t, json.Unmarshal(got, &gotConf))
+	if !reflect.DeepEqual(gotConf, want) {
+		t.Errorf("updatePolicyJSONImagePolicies() Diff:\n %s", diff.ObjectGoPrintDiff(want, gotConf))
+	}
+
+	sigstore, err := imagePolicySigstoreConfig(policies)
+	require.NoError(t, err)
+	sigstoreConf := map[string]map[string]map[string]string{}
+	require.NoError(t, yaml.Unmarshal(sigstore, &sigstoreConf))
+	assert.Equal(t, map[string]map[string]map[string]string{
+		"docker": {"registry.example.com": {"sigstore": "https://sigstore.example.com"}},
+	}, sigstoreConf)
+
+	sigstore, err = imagePolicySigstoreConfig(policies[1:])
+	require.NoError(t, err)
+	assert.Nil(t, sigstore)
+}
+
+func TestGetValidImagePolicy(t *testing.T) {
+	policy := &mcfgv1.ContainerRuntimeImagePolicy{Scopes: []mcfgv1.ContainerRuntimeImagePolicyScope{
+		{Scope: "quay.io", Requirement: mcfgv1.ContainerRuntimeImagePolicySignedBy, KeyData: "key"},
+		{Scope: "quay.io/openshift-release-dev", Requirement: mcfgv1.ContainerRuntimeImagePolicyReject},
+		{Scope: "quay.io/openshift-release-dev/ocp-release", Requirement: mcfgv1.ContainerRuntimeImagePolicyReject},
+		{Scope: "quay.io/openshift-release-dev/ocp-release:4.5", Requirement: mcfgv1.ContainerRuntimeImagePolicySignedBy, KeyData: "key"},
+		{Scope: "quay.io/openshift-release-dev/ocp-release", Requirement: mcfgv1.ContainerRuntimeImagePolicyInsecureAcceptAnything},
+		{Scope: "quay.io/openshift-release-dev/ocp-release-nightly", Requirement: mcfgv1.ContainerRuntimeImagePolicyReject},
+		{Scope: "quay.io/openshift", Requirement: mcfgv1.ContainerRuntimeImagePolicyReject},
+		{Scope: "registry.example.com", Requirement: mcfgv1.ContainerRuntimeImagePolicyReject},
+	}}
+
+	got, dropped, err := getValidImagePolicy("quay.io/openshift-release-dev/ocp-release@sha256:7ffa1f7d8ed7e3e8f2fbc2a0b29c3a1e1c9e6e9e8ea1c6b2d0f5c0c1f8a0e6b4", policy)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"quay.io/openshift-release-dev",
+		"quay.io/openshift-release-dev/ocp-release",
+	}, dropped)
+	// the signatures of the payload can still be required
+	assert.Equal(t, append([]mcfgv1.ContainerRuntimeImagePolicyScope{policy.Scopes[0], policy.Scopes[3]}, policy.Scopes[4:]...), got.Scopes)
+	// the policy of the ContainerRuntimeConfig is left untouched
+	assert.Len(t, policy.Scopes, 8)
+
+	_, _, err = getValidImagePolicy("not a reference", policy)
+	assert.Equal(t, errParsingReference, err)
+}
@@ -217,6 +217,12 @@ func validateUserKubeletConfig(cfg *mcfgv1.KubeletConfig) error {
 	if cfg.Spec.LogLevel != nil && (*cfg.Spec.LogLevel < 1 || *cfg.Spec.LogLevel > 10) {
 		return fmt.Errorf("KubeletConfig's LogLevel is not valid [1,10]: %v", cfg.Spec.LogLevel)
 	}
+	if err := validateCredentialProviders(cfg.Spec.CredentialProviders); err != nil {
+		return err
+	}
+	if err := validateGracefulShutdown(cfg.Spec.GracefulShutdown); err != nil {
+		return err
+	}
 	if cfg.Spec.KubeletConfig == nil || cfg.Spec.KubeletConfig.Raw == nil {
 		return nil
 	}
@@ -248,6 +254,9 @@ func validateUserKubeletConfig(cfg *mcfgv1.KubeletConfig) error {
 	if kcDecoded.StaticPodPath != "" {
 		return fmt.Errorf("KubeletConfiguration: staticPodPath is not allowed to be set, but contains: %s", kcDecoded.StaticPodPath)
 	}
+	if kcDecoded.ShutdownGracePeriod.Duration != 0 || kcDecoded.ShutdownGracePeriodCriticalPods.Duration != 0 {
+		return fmt.Errorf("KubeletConfiguration: shutdownGracePeriod and shutdownGracePeriodCriticalPods are not allowed to be set, use gracefulShutdown instead")
+	}
 	if kcDecoded.SystemReserved != nil && len(kcDecoded.SystemReserved) > 0 &&
 		cfg.Spec.AutoSizingReserved != nil && *cfg.Spec.AutoSizingReserved {
 		return fmt.Errorf("KubeletConfiguration: autoSizingReserved and systemdReserved cannot be set together")
@@ -310,3 +319,25 @@ func newKubeletconfigJSONEncoder(targetVersion schema.GroupVersion) (runtime.Enc
 	}
 	return codecs.EncoderForVersion(info.Serializer, targetVersion), nil
 }
+
+func dataURLString(contents []byte) string {
+	du := dataurl.New(contents, "text/plain")
+	du.Encoding = dataurl.EncodingASCII
+	return du.String()
+}
+
+func newIgnitionFile(path string, mode int, source string) ign3types.File {
+	overwrite := true
+	return ign3types.File{
+		Node: ign3types.Node{
+			Path:      path,
+			Overwrite: &overwrite,
+		},
+		FileEmbedded1: ign3types.FileEmbedded1{
+			Mode: &mode,
+			Contents: ign3types.Resource{
+				Source: &source,
+			},
+		},
+	}
+}
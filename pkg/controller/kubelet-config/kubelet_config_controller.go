@@ -88,8 +88,15 @@ type Controller struct {
 	featLister       oselistersv1.FeatureGateLister
 	featListerSynced cache.InformerSynced
 
-	queue        workqueue.RateLimitingInterface
-	featureQueue workqueue.RateLimitingInterface
+	nodeConfigLister       mcfglistersv1.NodeConfigLister
+	nodeConfigListerSynced cache.InformerSynced
+
+	mcLister       mcfglistersv1.MachineConfigLister
+	mcListerSynced cache.InformerSynced
+
+	queue           workqueue.RateLimitingInterface
+	featureQueue    workqueue.RateLimitingInterface
+	nodeConfigQueue workqueue.RateLimitingInterface
 }
 
 // New returns a new kubelet config controller
@@ -99,6 +106,8 @@ func New(
 	ccInformer mcfginformersv1.ControllerConfigInformer,
 	mkuInformer mcfginformersv1.KubeletConfigInformer,
 	featInformer oseinformersv1.FeatureGateInformer,
+	nodeConfigInformer mcfginformersv1.NodeConfigInformer,
+	mcInformer mcfginformersv1.MachineConfigInformer,
 	kubeClient clientset.Interface,
 	mcfgClient mcfgclientset.Interface,
 ) *Controller {
@@ -107,11 +116,12 @@ func New(
 	eventBroadcaster.StartRecordingToSink(&coreclientsetv1.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
 
 	ctrl := &Controller{
-		templatesDir:  templatesDir,
-		client:        mcfgClient,
-		eventRecorder: eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "machineconfigcontroller-kubeletconfigcontroller"}),
-		queue:         workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "machineconfigcontroller-kubeletconfigcontroller"),
-		featureQueue:  workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "machineconfigcontroller-featurecontroller"),
+		templatesDir:    templatesDir,
+		client:          mcfgClient,
+		eventRecorder:   eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "machineconfigcontroller-kubeletconfigcontroller"}),
+		queue:           workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "machineconfigcontroller-kubeletconfigcontroller"),
+		featureQueue:    workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "machineconfigcontroller-featurecontroller"),
+		nodeConfigQueue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "machineconfigcontroller-nodeconfigcontroller"),
 	}
 
 	mkuInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -126,6 +136,16 @@ func New(
 		DeleteFunc: ctrl.deleteFeature,
 	})
 
+	nodeConfigInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.addNodeConfig,
+		UpdateFunc: ctrl.updateNodeConfig,
+		DeleteFunc: ctrl.deleteNodeConfig,
+	})
+
+	mcpInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: ctrl.updatePoolForNodeConfig,
+	})
+
 	ctrl.syncHandler = ctrl.syncKubeletConfig
 	ctrl.enqueueKubeletConfig = ctrl.enqueue
 
@@ -141,6 +161,12 @@ func New(
 	ctrl.featLister = featInformer.Lister()
 	ctrl.featListerSynced = featInformer.Informer().HasSynced
 
+	ctrl.nodeConfigLister = nodeConfigInformer.Lister()
+	ctrl.nodeConfigListerSynced = nodeConfigInformer.Informer().HasSynced
+
+	ctrl.mcLister = mcInformer.Lister()
+	ctrl.mcListerSynced = mcInformer.Informer().HasSynced
+
 	return ctrl
 }
 
@@ -149,8 +175,9 @@ func (ctrl *Controller) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
 	defer ctrl.queue.ShutDown()
 	defer ctrl.featureQueue.ShutDown()
+	defer ctrl.nodeConfigQueue.ShutDown()
 
-	if !cache.WaitForCacheSync(stopCh, ctrl.mcpListerSynced, ctrl.mckListerSynced, ctrl.ccListerSynced, ctrl.featListerSynced) {
+	if !cache.WaitForCacheSync(stopCh, ctrl.mcpListerSynced, ctrl.mckListerSynced, ctrl.ccListerSynced, ctrl.featListerSynced, ctrl.nodeConfigListerSynced, ctrl.mcListerSynced) {
 		return
 	}
 
@@ -165,6 +192,10 @@ func (ctrl *Controller) Run(workers int, stopCh <-chan struct{}) {
 		go wait.Until(ctrl.featureWorker, time.Second, stopCh)
 	}
 
+	for i := 0; i < workers; i++ {
+		go wait.Until(ctrl.nodeConfigWorker, time.Second, stopCh)
+	}
+
 	<-stopCh
 }
 
@@ -473,6 +504,7 @@ func (ctrl *Controller) syncKubeletConfig(key string) error {
 		var kubeletIgnition *ign3types.File
 		var logLevelIgnition *ign3types.File
 		var autoSizingReservedIgnition *ign3types.File
+		var credentialProvidersIgnition []ign3types.File
 		userDefinedSystemReserved := make(map[string]string, 2)
 
 		// Generate the original KubeletConfig
@@ -497,6 +529,17 @@ func (ctrl *Controller) syncKubeletConfig(key string) error {
 		originalKubeConfig.TLSMinVersion = observedMinTLSVersion
 		originalKubeConfig.TLSCipherSuites = observedCipherSuites
 
+		if len(cfg.Spec.CredentialProviders) > 0 {
+			if originalKubeConfig.FeatureGates == nil {
+				originalKubeConfig.FeatureGates = map[string]bool{}
+			}
+			originalKubeConfig.FeatureGates[credentialProvidersFeatureGate] = true
+		}
+
+		if cfg.Spec.GracefulShutdown != nil {
+			applyGracefulShutdown(originalKubeConfig, cfg.Spec.GracefulShutdown)
+		}
+
 		if cfg.Spec.KubeletConfig != nil && cfg.Spec.KubeletConfig.Raw != nil {
 			specKubeletConfig, err := decodeKubeletConfig(cfg.Spec.KubeletConfig.Raw)
 			if err != nil {
@@ -568,6 +611,13 @@ func (ctrl *Controller) syncKubeletConfig(key string) error {
 			autoSizingReservedIgnition = createNewKubeletDynamicSystemReservedIgnition(nil, userDefinedSystemReserved)
 		}
 
+		if len(cfg.Spec.CredentialProviders) > 0 {
+			credentialProvidersIgnition, err = createNewKubeletCredentialProvidersIgnition(cfg.Spec.CredentialProviders)
+			if err != nil {
+				return ctrl.syncStatusOnly(cfg, err, "could not generate the credential providers config: %v", err)
+			}
+		}
+
 		tempIgnConfig := ctrlcommon.NewIgnConfig()
 		if autoSizingReservedIgnition != nil {
 			tempIgnConfig.Storage.Files = append(tempIgnConfig.Storage.Files, *autoSizingReservedIgnition)
@@ -578,6 +628,10 @@ func (ctrl *Controller) syncKubeletConfig(key string) error {
 		if kubeletIgnition != nil {
 			tempIgnConfig.Storage.Files = append(tempIgnConfig.Storage.Files, *kubeletIgnition)
 		}
+		tempIgnConfig.Storage.Files = append(tempIgnConfig.Storage.Files, credentialProvidersIgnition...)
+		if cfg.Spec.GracefulShutdown != nil {
+			tempIgnConfig.Storage.Files = append(tempIgnConfig.Storage.Files, createNewGracefulShutdownLogindIgnition(cfg.Spec.GracefulShutdown))
+		}
 
 		rawIgn, err := json.Marshal(tempIgnConfig)
 		if err != nil {
@@ -50,6 +50,8 @@ type fixture struct {
 	mcpLister  []*mcfgv1.MachineConfigPool
 	mckLister  []*mcfgv1.KubeletConfig
 	featLister []*osev1.FeatureGate
+	mcLister   []*mcfgv1.MachineConfig
+	ncLister   []*mcfgv1.NodeConfig
 
 	actions []core.Action
 
@@ -147,6 +149,8 @@ func (f *fixture) newController() *Controller {
 		i.Machineconfiguration().V1().ControllerConfigs(),
 		i.Machineconfiguration().V1().KubeletConfigs(),
 		featinformer.Config().V1().FeatureGates(),
+		i.Machineconfiguration().V1().NodeConfigs(),
+		i.Machineconfiguration().V1().MachineConfigs(),
 		k8sfake.NewSimpleClientset(),
 		f.client,
 	)
@@ -154,6 +158,8 @@ func (f *fixture) newController() *Controller {
 	c.mckListerSynced = alwaysReady
 	c.ccListerSynced = alwaysReady
 	c.featListerSynced = alwaysReady
+	c.nodeConfigListerSynced = alwaysReady
+	c.mcListerSynced = alwaysReady
 	c.eventRecorder = &record.FakeRecorder{}
 
 	stopCh := make(chan struct{})
@@ -173,6 +179,12 @@ func (f *fixture) newController() *Controller {
 	for _, c := range f.featLister {
 		featinformer.Config().V1().FeatureGates().Informer().GetIndexer().Add(c)
 	}
+	for _, c := range f.mcLister {
+		i.Machineconfiguration().V1().MachineConfigs().Informer().GetIndexer().Add(c)
+	}
+	for _, c := range f.ncLister {
+		i.Machineconfiguration().V1().NodeConfigs().Informer().GetIndexer().Add(c)
+	}
 
 	return c
 }
@@ -229,7 +241,9 @@ func filterInformerActions(actions []core.Action) []core.Action {
 				action.Matches("list", "kubeletconfigs") ||
 				action.Matches("watch", "kubeletconfigs") ||
 				action.Matches("list", "machineconfigs") ||
-				action.Matches("watch", "machineconfigs")) {
+				action.Matches("watch", "machineconfigs") ||
+				action.Matches("list", "nodeconfigs") ||
+				action.Matches("watch", "nodeconfigs")) {
 			continue
 		}
 		ret = append(ret, action)
@@ -550,6 +564,12 @@ func TestKubeletConfigDenylistedOptions(t *testing.T) {
 				StaticPodPath: "some_value",
 			},
 		},
+		{
+			name: "test banned shutdowngraceperiod",
+			config: &kubeletconfigv1beta1.KubeletConfiguration{
+				ShutdownGracePeriod: metav1.Duration{Duration: 1 * time.Minute},
+			},
+		},
 		{
 			name: "user cannot supply features gates",
 			config: &kubeletconfigv1beta1.KubeletConfiguration{
@@ -0,0 +1,163 @@
+package kubeletconfig
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/ghodss/yaml"
+	"github.com/vincent-petithory/dataurl"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+const (
+	credentialProviderConfigPath = "/etc/kubernetes/credential-providers.yaml"
+	// credentialProviderBinDir lives under /etc as /usr is read-only on RHCOS
+	credentialProviderBinDir        = "/etc/kubernetes/credential-providers"
+	credentialProviderKubeletDropIn = "/etc/systemd/system/kubelet.service.d/30-credential-providers.conf"
+
+	// credentialProvidersFeatureGate is alpha in the kubelet we ship and has to be enabled
+	// for the flags to be honored
+	credentialProvidersFeatureGate = "KubeletCredentialProviders"
+
+	defaultCredentialProviderAPIVersion    = "credentialprovider.kubelet.k8s.io/v1alpha1"
+	defaultCredentialProviderCacheDuration = 12 * time.Hour
+)
+
+// credentialProviderDefaults are the binary names and registries of the well known helpers
+var credentialProviderDefaults = map[mcfgv1.KubeletCredentialProviderType]struct {
+	name        string
+	matchImages []string
+}{
+	mcfgv1.KubeletCredentialProviderECR: {
+		name:        "ecr-credential-provider",
+		matchImages: []string{"*.dkr.ecr.*.amazonaws.com", "*.dkr.ecr.*.amazonaws.com.cn", "*.dkr.ecr-fips.*.amazonaws.com"},
+	},
+	mcfgv1.KubeletCredentialProviderGCR: {
+		name:        "gcr-credential-provider",
+		matchImages: []string{"gcr.io", "*.gcr.io", "container.cloud.google.com", "*.pkg.dev"},
+	},
+	mcfgv1.KubeletCredentialProviderACR: {
+		name:        "acr-credential-provider",
+		matchImages: []string{"*.azurecr.io", "*.azurecr.cn", "*.azurecr.de", "*.azurecr.us"},
+	},
+}
+
+// credentialProviderConfig mirrors the kubelet.config.k8s.io/v1alpha1 CredentialProviderConfig,
+// which is not vendored
+type credentialProviderConfig struct {
+	APIVersion string               `json:"apiVersion"`
+	Kind       string               `json:"kind"`
+	Providers  []credentialProvider `json:"providers"`
+}
+
+type credentialProvider struct {
+	Name                 string                                   `json:"name"`
+	MatchImages          []string                                 `json:"matchImages"`
+	DefaultCacheDuration *metav1.Duration                         `json:"defaultCacheDuration"`
+	APIVersion           string                                   `json:"apiVersion"`
+	Args                 []string                                 `json:"args,omitempty"`
+	Env                  []mcfgv1.KubeletCredentialProviderEnvVar `json:"env,omitempty"`
+}
+
+// resolveCredentialProvider fills in the defaults of the provider type
+func resolveCredentialProvider(provider mcfgv1.KubeletCredentialProvider) credentialProvider {
+	resolved := credentialProvider{
+		Name:                 provider.Name,
+		MatchImages:          provider.MatchImages,
+		DefaultCacheDuration: provider.DefaultCacheDuration,
+		APIVersion:           provider.APIVersion,
+		Args:                 provider.Args,
+		Env:                  provider.Env,
+	}
+	if defaults, ok := credentialProviderDefaults[provider.Type]; ok {
+		if resolved.Name == "" {
+			resolved.Name = defaults.name
+		}
+		if len(resolved.MatchImages) == 0 {
+			resolved.MatchImages = defaults.matchImages
+		}
+	}
+	if resolved.DefaultCacheDuration == nil {
+		resolved.DefaultCacheDuration = &metav1.Duration{Duration: defaultCredentialProviderCacheDuration}
+	}
+	if resolved.APIVersion == "" {
+		resolved.APIVersion = defaultCredentialProviderAPIVersion
+	}
+	return resolved
+}
+
+// validateCredentialProviders returns an error if the providers can't be rendered into a
+// configuration the kubelet accepts
+func validateCredentialProviders(providers []mcfgv1.KubeletCredentialProvider) error {
+	names := map[string]bool{}
+	for idx, provider := range providers {
+		switch provider.Type {
+		case mcfgv1.KubeletCredentialProviderECR, mcfgv1.KubeletCredentialProviderGCR, mcfgv1.KubeletCredentialProviderACR:
+		case mcfgv1.KubeletCredentialProviderExec:
+			if provider.Name == "" {
+				return fmt.Errorf("credentialProviders[%d]: name is required for the Exec type", idx)
+			}
+			if len(provider.MatchImages) == 0 {
+				return fmt.Errorf("credentialProviders[%d]: matchImages is required for the Exec type", idx)
+			}
+		default:
+			return fmt.Errorf("credentialProviders[%d]: invalid type %q, must be one of ECR, GCR, ACR or Exec", idx, provider.Type)
+		}
+
+		resolved := resolveCredentialProvider(provider)
+		if strings.Contains(resolved.Name, "/") || resolved.Name == "." || resolved.Name == ".." {
+			return fmt.Errorf("credentialProviders[%d]: name %q must be a file name, not a path", idx, resolved.Name)
+		}
+		if names[resolved.Name] {
+			return fmt.Errorf("credentialProviders[%d]: duplicate provider %q", idx, resolved.Name)
+		}
+		names[resolved.Name] = true
+
+		if resolved.DefaultCacheDuration.Duration < 0 {
+			return fmt.Errorf("credentialProviders[%d]: defaultCacheDuration must not be negative", idx)
+		}
+		for _, env := range resolved.Env {
+			if env.Name == "" {
+				return fmt.Errorf("credentialProviders[%d]: env variables must have a name", idx)
+			}
+		}
+		if provider.Binary != "" {
+			if _, err := dataurl.DecodeString(provider.Binary); err != nil {
+				return fmt.Errorf("credentialProviders[%d]: binary is not a valid data URL: %v", idx, err)
+			}
+		}
+	}
+	return nil
+}
+
+// createNewKubeletCredentialProvidersIgnition returns the provider configuration, the binaries
+// shipped in the KubeletConfig and the kubelet drop-in pointing the kubelet at both
+func createNewKubeletCredentialProvidersIgnition(providers []mcfgv1.KubeletCredentialProvider) ([]ign3types.File, error) {
+	config := credentialProviderConfig{
+		APIVersion: "kubelet.config.k8s.io/v1alpha1",
+		Kind:       "CredentialProviderConfig",
+	}
+	var files []ign3types.File
+	for _, provider := range providers {
+		resolved := resolveCredentialProvider(provider)
+		config.Providers = append(config.Providers, resolved)
+		if provider.Binary != "" {
+			files = append(files, newIgnitionFile(filepath.Join(credentialProviderBinDir, resolved.Name), 0755, provider.Binary))
+		}
+	}
+	configYAML, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal credential provider config: %v", err)
+	}
+	files = append(files, newIgnitionFile(credentialProviderConfigPath, 0644, dataURLString(configYAML)))
+
+	dropIn := fmt.Sprintf("[Service]\nEnvironment=\"KUBELET_CREDENTIAL_PROVIDER_ARGS=--image-credential-provider-config=%s --image-credential-provider-bin-dir=%s\"\n",
+		credentialProviderConfigPath, credentialProviderBinDir)
+	files = append(files, newIgnitionFile(credentialProviderKubeletDropIn, 0644, dataURLString([]byte(dropIn))))
+	return files, nil
+}
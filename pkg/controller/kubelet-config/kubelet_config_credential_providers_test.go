@@ -0,0 +1,98 @@
+package kubeletconfig
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vincent-petithory/dataurl"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+func TestValidateCredentialProviders(t *testing.T) {
+	tests := []struct {
+		name      string
+		providers []mcfgv1.KubeletCredentialProvider
+		wantErr   bool
+	}{{
+		name:      "well known helpers",
+		providers: []mcfgv1.KubeletCredentialProvider{{Type: mcfgv1.KubeletCredentialProviderECR}, {Type: mcfgv1.KubeletCredentialProviderGCR}, {Type: mcfgv1.KubeletCredentialProviderACR}},
+	}, {
+		name:      "exec",
+		providers: []mcfgv1.KubeletCredentialProvider{{Type: mcfgv1.KubeletCredentialProviderExec, Name: "helper", MatchImages: []string{"registry.example.com"}, Binary: dataURLString([]byte("#!/bin/sh"))}},
+	}, {
+		name:      "unknown type",
+		providers: []mcfgv1.KubeletCredentialProvider{{Type: "Quay"}},
+		wantErr:   true,
+	}, {
+		name:      "exec without name",
+		providers: []mcfgv1.KubeletCredentialProvider{{Type: mcfgv1.KubeletCredentialProviderExec, MatchImages: []string{"registry.example.com"}}},
+		wantErr:   true,
+	}, {
+		name:      "exec without matchImages",
+		providers: []mcfgv1.KubeletCredentialProvider{{Type: mcfgv1.KubeletCredentialProviderExec, Name: "helper"}},
+		wantErr:   true,
+	}, {
+		name:      "name is a path",
+		providers: []mcfgv1.KubeletCredentialProvider{{Type: mcfgv1.KubeletCredentialProviderECR, Name: "../../usr/bin/helper"}},
+		wantErr:   true,
+	}, {
+		name:      "duplicate",
+		providers: []mcfgv1.KubeletCredentialProvider{{Type: mcfgv1.KubeletCredentialProviderECR}, {Type: mcfgv1.KubeletCredentialProviderExec, Name: "ecr-credential-provider", MatchImages: []string{"*"}}},
+		wantErr:   true,
+	}, {
+		name:      "negative cache duration",
+		providers: []mcfgv1.KubeletCredentialProvider{{Type: mcfgv1.KubeletCredentialProviderGCR, DefaultCacheDuration: &metav1.Duration{Duration: -time.Minute}}},
+		wantErr:   true,
+	}, {
+		name:      "invalid binary",
+		providers: []mcfgv1.KubeletCredentialProvider{{Type: mcfgv1.KubeletCredentialProviderACR, Binary: "not a data url"}},
+		wantErr:   true,
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateCredentialProviders(test.providers)
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCreateNewKubeletCredentialProvidersIgnition(t *testing.T) {
+	files, err := createNewKubeletCredentialProvidersIgnition([]mcfgv1.KubeletCredentialProvider{
+		{Type: mcfgv1.KubeletCredentialProviderECR},
+		{Type: mcfgv1.KubeletCredentialProviderExec, Name: "helper", MatchImages: []string{"registry.example.com"}, Args: []string{"--verbose"}, Binary: dataURLString([]byte("#!/bin/sh"))},
+	})
+	require.NoError(t, err)
+
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	assert.Equal(t, []string{"/etc/kubernetes/credential-providers/helper", credentialProviderConfigPath, credentialProviderKubeletDropIn}, paths)
+	assert.Equal(t, 0755, *files[0].Mode)
+
+	du, err := dataurl.DecodeString(*files[1].Contents.Source)
+	require.NoError(t, err)
+	var config credentialProviderConfig
+	require.NoError(t, yaml.Unmarshal(du.Data, &config))
+	assert.Equal(t, "CredentialProviderConfig", config.Kind)
+	require.Len(t, config.Providers, 2)
+	assert.Equal(t, "ecr-credential-provider", config.Providers[0].Name)
+	assert.Equal(t, credentialProviderDefaults[mcfgv1.KubeletCredentialProviderECR].matchImages, config.Providers[0].MatchImages)
+	assert.Equal(t, defaultCredentialProviderCacheDuration, config.Providers[0].DefaultCacheDuration.Duration)
+	assert.Equal(t, defaultCredentialProviderAPIVersion, config.Providers[1].APIVersion)
+	assert.Equal(t, []string{"--verbose"}, config.Providers[1].Args)
+
+	du, err = dataurl.DecodeString(*files[2].Contents.Source)
+	require.NoError(t, err)
+	assert.Contains(t, string(du.Data), "--image-credential-provider-config="+credentialProviderConfigPath)
+	assert.Contains(t, string(du.Data), "--image-credential-provider-bin-dir="+credentialProviderBinDir)
+}
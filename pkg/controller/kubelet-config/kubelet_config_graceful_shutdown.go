@@ -0,0 +1,59 @@
+package kubeletconfig
+
+import (
+	"fmt"
+	"math"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	kubeletconfigv1beta1 "k8s.io/kubelet/config/v1beta1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+const (
+	// gracefulShutdownLogindDropIn raises the delay logind grants the kubelet's shutdown
+	// inhibitor lock, which defaults to 5s and caps the kubelet's grace period
+	gracefulShutdownLogindDropIn = "/etc/systemd/logind.conf.d/20-kubelet-graceful-shutdown.conf"
+
+	// gracefulShutdownFeatureGate is alpha in the kubelet we ship and has to be enabled
+	// for the grace periods to be honored
+	gracefulShutdownFeatureGate = "GracefulNodeShutdown"
+)
+
+// validateGracefulShutdown returns an error if the grace periods can't be rendered into a
+// configuration the kubelet and logind accept
+func validateGracefulShutdown(shutdown *mcfgv1.KubeletGracefulShutdown) error {
+	if shutdown == nil {
+		return nil
+	}
+	if shutdown.ShutdownGracePeriod.Duration <= 0 {
+		return fmt.Errorf("gracefulShutdown: shutdownGracePeriod must be positive")
+	}
+	if shutdown.CriticalPodGracePeriod.Duration < 0 {
+		return fmt.Errorf("gracefulShutdown: criticalPodGracePeriod must not be negative")
+	}
+	if shutdown.CriticalPodGracePeriod.Duration > shutdown.ShutdownGracePeriod.Duration {
+		return fmt.Errorf("gracefulShutdown: criticalPodGracePeriod %s must not exceed shutdownGracePeriod %s",
+			shutdown.CriticalPodGracePeriod.Duration, shutdown.ShutdownGracePeriod.Duration)
+	}
+	return nil
+}
+
+// applyGracefulShutdown sets the grace periods in the kubelet configuration
+func applyGracefulShutdown(kubeletConfig *kubeletconfigv1beta1.KubeletConfiguration, shutdown *mcfgv1.KubeletGracefulShutdown) {
+	kubeletConfig.ShutdownGracePeriod = shutdown.ShutdownGracePeriod
+	kubeletConfig.ShutdownGracePeriodCriticalPods = shutdown.CriticalPodGracePeriod
+	if kubeletConfig.FeatureGates == nil {
+		kubeletConfig.FeatureGates = map[string]bool{}
+	}
+	kubeletConfig.FeatureGates[gracefulShutdownFeatureGate] = true
+}
+
+// createNewGracefulShutdownLogindIgnition returns the logind drop-in letting the kubelet
+// delay the shutdown for the whole grace period
+func createNewGracefulShutdownLogindIgnition(shutdown *mcfgv1.KubeletGracefulShutdown) ign3types.File {
+	// round up, logind only takes whole seconds
+	seconds := int64(math.Ceil(shutdown.ShutdownGracePeriod.Seconds()))
+	dropIn := fmt.Sprintf("[Login]\nInhibitDelayMaxSec=%d\n", seconds)
+	return newIgnitionFile(gracefulShutdownLogindDropIn, 0644, dataURLString([]byte(dropIn)))
+}
@@ -0,0 +1,76 @@
+package kubeletconfig
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vincent-petithory/dataurl"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeletconfigv1beta1 "k8s.io/kubelet/config/v1beta1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+func newGracefulShutdown(total, critical time.Duration) *mcfgv1.KubeletGracefulShutdown {
+	return &mcfgv1.KubeletGracefulShutdown{
+		ShutdownGracePeriod:    metav1.Duration{Duration: total},
+		CriticalPodGracePeriod: metav1.Duration{Duration: critical},
+	}
+}
+
+func TestValidateGracefulShutdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		shutdown *mcfgv1.KubeletGracefulShutdown
+		wantErr  bool
+	}{{
+		name: "unset",
+	}, {
+		name:     "valid",
+		shutdown: newGracefulShutdown(90*time.Second, 30*time.Second),
+	}, {
+		name:     "no critical period",
+		shutdown: newGracefulShutdown(90*time.Second, 0),
+	}, {
+		name:     "no grace period",
+		shutdown: newGracefulShutdown(0, 0),
+		wantErr:  true,
+	}, {
+		name:     "negative critical period",
+		shutdown: newGracefulShutdown(90*time.Second, -time.Second),
+		wantErr:  true,
+	}, {
+		name:     "critical period longer than grace period",
+		shutdown: newGracefulShutdown(30*time.Second, 90*time.Second),
+		wantErr:  true,
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateGracefulShutdown(test.shutdown)
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestGracefulShutdownRendering(t *testing.T) {
+	shutdown := newGracefulShutdown(90500*time.Millisecond, 30*time.Second)
+
+	kubeletConfig := &kubeletconfigv1beta1.KubeletConfiguration{}
+	applyGracefulShutdown(kubeletConfig, shutdown)
+	assert.Equal(t, 90500*time.Millisecond, kubeletConfig.ShutdownGracePeriod.Duration)
+	assert.Equal(t, 30*time.Second, kubeletConfig.ShutdownGracePeriodCriticalPods.Duration)
+	assert.True(t, kubeletConfig.FeatureGates[gracefulShutdownFeatureGate])
+
+	// logind has to allow at least the whole grace period
+	file := createNewGracefulShutdownLogindIgnition(shutdown)
+	assert.Equal(t, gracefulShutdownLogindDropIn, file.Path)
+	contents, err := dataurl.DecodeString(*file.Contents.Source)
+	require.NoError(t, err)
+	assert.Equal(t, "[Login]\nInhibitDelayMaxSec=91\n", string(contents.Data))
+}
@@ -0,0 +1,416 @@
+package kubeletconfig
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/golang/glog"
+	"github.com/vincent-petithory/dataurl"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/retry"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/version"
+)
+
+const (
+	clusterNodeConfigInstanceName = "cluster"
+
+	// crioDropInFilePathCgroups is the CRI-O drop-in of the cgroup mode. It's read after the
+	// default crio.conf.d/00-default and before the drop-ins of ContainerRuntimeConfigs.
+	crioDropInFilePathCgroups = "/etc/crio/crio.conf.d/00-nodeconfig-cgroups"
+)
+
+// cgroupsV2KernelArgs switch systemd, and with it the kubelet and CRI-O which both use the systemd
+// cgroup driver, to the unified hierarchy. cgroup v1 is the default and needs no kernel arguments.
+var cgroupsV2KernelArgs = []string{
+	"systemd.unified_cgroup_hierarchy=1",
+	`cgroup_no_v1="all"`,
+	"psi=1",
+}
+
+// crioCgroupsV2DropIn makes sure CRI-O runs with the systemd cgroup manager, and conmon in the
+// cgroup of the pod, the only setup supported on the unified hierarchy, and makes crun, which
+// supports every cgroup v2 controller, available as a runtime handler. The kubelet needs no
+// change: it always uses the systemd cgroup driver, which KubeletConfigs can't change.
+var crioCgroupsV2DropIn = `[crio.runtime]
+cgroup_manager = "systemd"
+conmon_cgroup = "pod"
+
+[crio.runtime.runtimes.crun]
+runtime_path = "/usr/bin/crun"
+runtime_type = "oci"
+runtime_root = "/run/crun"
+`
+
+func (ctrl *Controller) nodeConfigWorker() {
+	for ctrl.processNextNodeConfigWorkItem() {
+	}
+}
+
+func (ctrl *Controller) processNextNodeConfigWorkItem() bool {
+	key, quit := ctrl.nodeConfigQueue.Get()
+	if quit {
+		return false
+	}
+	defer ctrl.nodeConfigQueue.Done(key)
+
+	err := ctrl.syncNodeConfigHandler(key.(string))
+	ctrl.handleNodeConfigErr(err, key)
+	return true
+}
+
+func (ctrl *Controller) handleNodeConfigErr(err error, key interface{}) {
+	if err == nil {
+		ctrl.nodeConfigQueue.Forget(key)
+		return
+	}
+
+	if _, ok := err.(*forgetError); ok {
+		ctrl.nodeConfigQueue.Forget(key)
+		return
+	}
+
+	if ctrl.nodeConfigQueue.NumRequeues(key) < maxRetries {
+		glog.V(2).Infof("Error syncing nodeconfig %v: %v", key, err)
+		ctrl.nodeConfigQueue.AddRateLimited(key)
+		return
+	}
+
+	utilruntime.HandleError(err)
+	glog.V(2).Infof("Dropping nodeconfig %q out of the queue: %v", key, err)
+	ctrl.nodeConfigQueue.Forget(key)
+	ctrl.nodeConfigQueue.AddAfter(key, 1*time.Minute)
+}
+
+// syncNodeConfigHandler switches the pools to the cgroup mode requested by the cluster NodeConfig,
+// one pool at a time: the next pool is only switched once every node of the previous one
+// rebooted into the new mode and is ready to run workloads again. The rollout moves forward on
+// the status updates of the pools.
+func (ctrl *Controller) syncNodeConfigHandler(key string) error {
+	startTime := time.Now()
+	glog.V(4).Infof("Started syncing nodeconfig handler %q (%v)", key, startTime)
+	defer func() {
+		glog.V(4).Infof("Finished syncing nodeconfig handler %q (%v)", key, time.Since(startTime))
+	}()
+
+	nodeConfig, err := ctrl.nodeConfigLister.Get(clusterNodeConfigInstanceName)
+	if errors.IsNotFound(err) {
+		switching, err := ctrl.isSwitchingBackToDefaultCgroupMode()
+		if err != nil || !switching {
+			return err
+		}
+		glog.V(2).Infof("NodeConfig %v is missing, using default", key)
+		nodeConfig = &mcfgv1.NodeConfig{}
+	} else if err != nil {
+		return err
+	}
+	mode := nodeConfig.Spec.CgroupMode
+	if mode == mcfgv1.CgroupModeEmpty {
+		mode = mcfgv1.CgroupModeDefault
+	}
+	if mode != mcfgv1.CgroupModeV1 && mode != mcfgv1.CgroupModeV2 {
+		return ctrl.syncNodeConfigStatus(nodeConfig, nil, newForgetError(fmt.Errorf("invalid cgroupMode %q, must be one of v1 or v2", mode)))
+	}
+
+	mcpPools, err := ctrl.mcpLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	pools := sortPoolsForNodeConfigRollout(mcpPools)
+
+	var poolStatuses []mcfgv1.NodeConfigPoolStatus
+	for idx, pool := range pools {
+		updated, err := ctrl.isPoolInCgroupMode(pool, mode)
+		if err != nil {
+			return ctrl.syncNodeConfigStatus(nodeConfig, poolStatuses, err)
+		}
+		poolStatuses = append(poolStatuses, mcfgv1.NodeConfigPoolStatus{Name: pool.Name, CgroupMode: mode, Updated: updated})
+		if updated {
+			continue
+		}
+		// Pools also selecting the MachineConfigs of a pool switched before them (e.g. custom pools
+		// selecting worker MachineConfigs) follow that pool instead of getting a MachineConfig of their own.
+		if !inheritsMachineConfigsOf(pool, pools[:idx]) {
+			if err := ctrl.ensureCgroupsMachineConfig(pool, mode); err != nil {
+				return ctrl.syncNodeConfigStatus(nodeConfig, poolStatuses, err)
+			}
+		}
+		glog.Infof("Waiting for MachineConfigPool %s to switch to cgroup %s", pool.Name, mode)
+		return ctrl.syncNodeConfigStatus(nodeConfig, poolStatuses, nil)
+	}
+	return ctrl.syncNodeConfigStatus(nodeConfig, poolStatuses, nil)
+}
+
+// sortPoolsForNodeConfigRollout returns the pools in the order they are switched: master first, so
+// that a broken mode is caught before it reaches workloads, then worker, then any custom pool.
+func sortPoolsForNodeConfigRollout(pools []*mcfgv1.MachineConfigPool) []*mcfgv1.MachineConfigPool {
+	rank := func(pool *mcfgv1.MachineConfigPool) int {
+		switch pool.Name {
+		case "master":
+			return 0
+		case "worker":
+			return 1
+		}
+		return 2
+	}
+	sorted := make([]*mcfgv1.MachineConfigPool, len(pools))
+	copy(sorted, pools)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if rank(sorted[i]) != rank(sorted[j]) {
+			return rank(sorted[i]) < rank(sorted[j])
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+// inheritsMachineConfigsOf returns true if pool selects the MachineConfigs generated for any of others
+func inheritsMachineConfigsOf(pool *mcfgv1.MachineConfigPool, others []*mcfgv1.MachineConfigPool) bool {
+	selector, err := metav1.LabelSelectorAsSelector(pool.Spec.MachineConfigSelector)
+	if err != nil || selector.Empty() {
+		return false
+	}
+	for _, other := range others {
+		if selector.Matches(labels.Set{mcfgv1.MachineConfigRoleLabelKey: other.Name}) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPoolInCgroupMode returns true once the pool is rendered with the kernel arguments of mode and
+// every one of its nodes is updated to that rendered config and ready.
+func (ctrl *Controller) isPoolInCgroupMode(pool *mcfgv1.MachineConfigPool, mode mcfgv1.CgroupMode) (bool, error) {
+	if pool.Status.Configuration.Name == "" || pool.Spec.Configuration.Name != pool.Status.Configuration.Name {
+		return false, nil
+	}
+	rendered, err := ctrl.mcLister.Get(pool.Status.Configuration.Name)
+	if err != nil {
+		return false, fmt.Errorf("could not get rendered MachineConfig %s of pool %s: %v", pool.Status.Configuration.Name, pool.Name, err)
+	}
+	if hasCgroupsV2KernelArgs(rendered.Spec.KernelArguments) != (mode == mcfgv1.CgroupModeV2) {
+		return false, nil
+	}
+	status := pool.Status
+	return status.UpdatedMachineCount == status.MachineCount &&
+		status.ReadyMachineCount == status.MachineCount &&
+		status.DegradedMachineCount == 0, nil
+}
+
+func hasCgroupsV2KernelArgs(kargs []string) bool {
+	for _, karg := range cgroupsV2KernelArgs {
+		if !ctrlcommon.InSlice(karg, kargs) {
+			return false
+		}
+	}
+	return true
+}
+
+// isSwitchingBackToDefaultCgroupMode returns true while a pool still has a cgroups MachineConfig,
+// after the cluster NodeConfig was deleted
+func (ctrl *Controller) isSwitchingBackToDefaultCgroupMode() (bool, error) {
+	pools, err := ctrl.mcpLister.List(labels.Everything())
+	if err != nil {
+		return false, err
+	}
+	for _, pool := range pools {
+		_, err := ctrl.mcLister.Get(getManagedCgroupsKey(pool))
+		if err == nil {
+			return true, nil
+		}
+		if !errors.IsNotFound(err) {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// newCgroupsV2IgnConfig returns the Ignition config of the files switching CRI-O to cgroup v2
+func newCgroupsV2IgnConfig() ign3types.Config {
+	mode := 0644
+	overwrite := true
+	du := dataurl.New([]byte(crioCgroupsV2DropIn), "text/plain")
+	du.Encoding = dataurl.EncodingASCII
+	duStr := du.String()
+
+	ignConfig := ctrlcommon.NewIgnConfig()
+	ignConfig.Storage.Files = []ign3types.File{{
+		Node: ign3types.Node{
+			Path:      crioDropInFilePathCgroups,
+			Overwrite: &overwrite,
+		},
+		FileEmbedded1: ign3types.FileEmbedded1{
+			Mode: &mode,
+			Contents: ign3types.Resource{
+				Source: &duStr,
+			},
+		},
+	}}
+	return ignConfig
+}
+
+// ensureCgroupsMachineConfig makes the pool's cgroups MachineConfig match mode. cgroup v1 being
+// the default, going back to it removes the MachineConfig.
+func (ctrl *Controller) ensureCgroupsMachineConfig(pool *mcfgv1.MachineConfigPool, mode mcfgv1.CgroupMode) error {
+	managedKey := getManagedCgroupsKey(pool)
+	mc, err := ctrl.mcLister.Get(managedKey)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	isNotFound := errors.IsNotFound(err)
+
+	if mode != mcfgv1.CgroupModeV2 {
+		if isNotFound {
+			return nil
+		}
+		if err := ctrl.client.MachineconfigurationV1().MachineConfigs().Delete(context.TODO(), managedKey, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("could not delete MachineConfig %s: %v", managedKey, err)
+		}
+		glog.Infof("Switching MachineConfigPool %s to cgroup %s", pool.Name, mode)
+		return nil
+	}
+
+	desired, err := ctrlcommon.MachineConfigFromIgnConfig(pool.Name, managedKey, newCgroupsV2IgnConfig())
+	if err != nil {
+		return err
+	}
+	if isNotFound {
+		mc = desired
+	} else if reflect.DeepEqual(mc.Spec.KernelArguments, cgroupsV2KernelArgs) && bytes.Equal(mc.Spec.Config.Raw, desired.Spec.Config.Raw) {
+		return nil
+	} else {
+		mc = mc.DeepCopy()
+		mc.Spec.Config = desired.Spec.Config
+	}
+	mc.Spec.KernelArguments = append([]string{}, cgroupsV2KernelArgs...)
+	mc.ObjectMeta.Annotations = map[string]string{
+		ctrlcommon.GeneratedByControllerVersionAnnotationKey: version.Hash,
+	}
+	if err := retry.RetryOnConflict(updateBackoff, func() error {
+		var err error
+		if isNotFound {
+			_, err = ctrl.client.MachineconfigurationV1().MachineConfigs().Create(context.TODO(), mc, metav1.CreateOptions{})
+		} else {
+			_, err = ctrl.client.MachineconfigurationV1().MachineConfigs().Update(context.TODO(), mc, metav1.UpdateOptions{})
+		}
+		return err
+	}); err != nil {
+		return fmt.Errorf("Could not Create/Update MachineConfig: %v", err)
+	}
+	glog.Infof("Switching MachineConfigPool %s to cgroup %s", pool.Name, mode)
+	return nil
+}
+
+func getManagedCgroupsKey(pool *mcfgv1.MachineConfigPool) string {
+	return fmt.Sprintf("97-%s-generated-cgroups", pool.Name)
+}
+
+// syncNodeConfigStatus records the rollout state on the cluster NodeConfig, if there is one, and returns err
+func (ctrl *Controller) syncNodeConfigStatus(nodeConfig *mcfgv1.NodeConfig, pools []mcfgv1.NodeConfigPoolStatus, err error) error {
+	if nodeConfig.Name == "" {
+		return err
+	}
+	var condition *mcfgv1.NodeConfigCondition
+	switch {
+	case err != nil:
+		condition = mcfgv1.NewNodeConfigCondition(mcfgv1.NodeConfigFailure, corev1.ConditionFalse, fmt.Sprintf("Error: %v", err))
+	case len(pools) > 0 && !pools[len(pools)-1].Updated:
+		last := pools[len(pools)-1]
+		condition = mcfgv1.NewNodeConfigCondition(mcfgv1.NodeConfigProgressing, corev1.ConditionTrue, fmt.Sprintf("Switching MachineConfigPool %s to cgroup %s", last.Name, last.CgroupMode))
+	default:
+		condition = mcfgv1.NewNodeConfigCondition(mcfgv1.NodeConfigSuccess, corev1.ConditionTrue, "Success")
+	}
+
+	statusUpdateError := retry.RetryOnConflict(updateBackoff, func() error {
+		newcfg, getErr := ctrl.nodeConfigLister.Get(nodeConfig.Name)
+		if getErr != nil {
+			return getErr
+		}
+		newcfg = newcfg.DeepCopy()
+		newcfg.Status.ObservedGeneration = newcfg.GetGeneration()
+		if err == nil {
+			newcfg.Status.Pools = pools
+		}
+		// Same as for KubeletConfigs, only append a condition if its message changed
+		if len(newcfg.Status.Conditions) == 0 || condition.Message != newcfg.Status.Conditions[len(newcfg.Status.Conditions)-1].Message {
+			newcfg.Status.Conditions = append(newcfg.Status.Conditions, *condition)
+		} else {
+			newcfg.Status.Conditions[len(newcfg.Status.Conditions)-1] = *condition
+		}
+		_, lerr := ctrl.client.MachineconfigurationV1().NodeConfigs().UpdateStatus(context.TODO(), newcfg, metav1.UpdateOptions{})
+		return lerr
+	})
+	if statusUpdateError != nil {
+		glog.Warningf("error updating nodeconfig status: %v", statusUpdateError)
+	}
+	return err
+}
+
+func (ctrl *Controller) enqueueNodeConfig(nodeConfig *mcfgv1.NodeConfig) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(nodeConfig)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("Couldn't get key for object %#v: %v", nodeConfig, err))
+		return
+	}
+	ctrl.nodeConfigQueue.Add(key)
+}
+
+func (ctrl *Controller) updateNodeConfig(old, cur interface{}) {
+	oldNodeConfig := old.(*mcfgv1.NodeConfig)
+	newNodeConfig := cur.(*mcfgv1.NodeConfig)
+	if !reflect.DeepEqual(oldNodeConfig.Spec, newNodeConfig.Spec) {
+		glog.V(4).Infof("Update NodeConfig %s", newNodeConfig.Name)
+		ctrl.enqueueNodeConfig(newNodeConfig)
+	}
+}
+
+func (ctrl *Controller) addNodeConfig(obj interface{}) {
+	nodeConfig := obj.(*mcfgv1.NodeConfig)
+	glog.V(4).Infof("Adding NodeConfig %s", nodeConfig.Name)
+	ctrl.enqueueNodeConfig(nodeConfig)
+}
+
+func (ctrl *Controller) deleteNodeConfig(obj interface{}) {
+	nodeConfig, ok := obj.(*mcfgv1.NodeConfig)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Couldn't get object from tombstone %#v", obj))
+			return
+		}
+		nodeConfig, ok = tombstone.Obj.(*mcfgv1.NodeConfig)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Tombstone contained object that is not a NodeConfig %#v", obj))
+			return
+		}
+	}
+	glog.V(4).Infof("Deleted NodeConfig %s, restoring default cgroup mode", nodeConfig.Name)
+	ctrl.enqueueNodeConfig(nodeConfig)
+}
+
+// updatePoolForNodeConfig moves a cgroup mode rollout forward as soon as a pool finished updating
+func (ctrl *Controller) updatePoolForNodeConfig(old, cur interface{}) {
+	oldPool := old.(*mcfgv1.MachineConfigPool)
+	newPool := cur.(*mcfgv1.MachineConfigPool)
+	if reflect.DeepEqual(oldPool.Status, newPool.Status) {
+		return
+	}
+	if _, err := ctrl.nodeConfigLister.Get(clusterNodeConfigInstanceName); errors.IsNotFound(err) {
+		if switching, err := ctrl.isSwitchingBackToDefaultCgroupMode(); err == nil && !switching {
+			return
+		}
+	}
+	ctrl.nodeConfigQueue.Add(clusterNodeConfigInstanceName)
+}
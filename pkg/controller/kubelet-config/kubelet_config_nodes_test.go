@@ -0,0 +1,199 @@
+package kubeletconfig
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	core "k8s.io/client-go/testing"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/version"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func TestSortPoolsForNodeConfigRollout(t *testing.T) {
+	pools := []*mcfgv1.MachineConfigPool{
+		helpers.NewMachineConfigPool("infra", nil, helpers.InfraSelector, "v0"),
+		helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v0"),
+		helpers.NewMachineConfigPool("edge", nil, helpers.WorkerSelector, "v0"),
+		helpers.NewMachineConfigPool("master", nil, helpers.MasterSelector, "v0"),
+	}
+	var names []string
+	for _, pool := range sortPoolsForNodeConfigRollout(pools) {
+		names = append(names, pool.Name)
+	}
+	assert.Equal(t, []string{"master", "worker", "edge", "infra"}, names)
+	// the input is left untouched
+	assert.Equal(t, "infra", pools[0].Name)
+}
+
+func TestInheritsMachineConfigsOf(t *testing.T) {
+	roleSelector := func(role string) *metav1.LabelSelector {
+		return metav1.AddLabelToSelector(&metav1.LabelSelector{}, mcfgv1.MachineConfigRoleLabelKey, role)
+	}
+	master := helpers.NewMachineConfigPool("master", roleSelector("master"), helpers.MasterSelector, "v0")
+	worker := helpers.NewMachineConfigPool("worker", roleSelector("worker"), helpers.WorkerSelector, "v0")
+	infra := helpers.NewMachineConfigPool("infra", &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{{
+			Key:      mcfgv1.MachineConfigRoleLabelKey,
+			Operator: metav1.LabelSelectorOpIn,
+			Values:   []string{"worker", "infra"},
+		}},
+	}, helpers.InfraSelector, "v0")
+
+	assert.False(t, inheritsMachineConfigsOf(worker, []*mcfgv1.MachineConfigPool{master}))
+	assert.True(t, inheritsMachineConfigsOf(infra, []*mcfgv1.MachineConfigPool{master, worker}))
+	assert.False(t, inheritsMachineConfigsOf(infra, []*mcfgv1.MachineConfigPool{master}))
+}
+
+func TestHasCgroupsV2KernelArgs(t *testing.T) {
+	assert.True(t, hasCgroupsV2KernelArgs(append([]string{"foo=bar"}, cgroupsV2KernelArgs...)))
+	assert.False(t, hasCgroupsV2KernelArgs(cgroupsV2KernelArgs[:1]))
+	assert.False(t, hasCgroupsV2KernelArgs(nil))
+}
+
+func newCgroupsNodeConfig(mode mcfgv1.CgroupMode) *mcfgv1.NodeConfig {
+	return &mcfgv1.NodeConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: clusterNodeConfigInstanceName},
+		Spec:       mcfgv1.NodeConfigSpec{CgroupMode: mode},
+	}
+}
+
+func newRenderedConfig(name string, kargs []string) *mcfgv1.MachineConfig {
+	mc := helpers.NewMachineConfig(name, nil, "", nil)
+	mc.Spec.KernelArguments = kargs
+	return mc
+}
+
+func (f *fixture) expectCreateMachineConfigNamed(name string) {
+	f.expectCreateMachineConfigAction(&mcfgv1.MachineConfig{ObjectMeta: metav1.ObjectMeta{Name: name}})
+}
+
+func (f *fixture) expectDeleteMachineConfigAction(name string) {
+	f.actions = append(f.actions, core.NewRootDeleteAction(schema.GroupVersionResource{Version: "v1", Group: "machineconfiguration.openshift.io", Resource: "machineconfigs"}, name))
+}
+
+func (f *fixture) expectUpdateNodeConfigStatusAction(config *mcfgv1.NodeConfig) {
+	f.actions = append(f.actions, core.NewRootUpdateSubresourceAction(schema.GroupVersionResource{Version: "v1", Group: "machineconfiguration.openshift.io", Resource: "nodeconfigs"}, "status", config))
+}
+
+func (f *fixture) runNodeConfig() *Controller {
+	c := f.newController()
+	if err := c.syncNodeConfigHandler(clusterNodeConfigInstanceName); err != nil {
+		f.t.Errorf("error syncing nodeconfig: %v", err)
+	}
+	f.validateActions()
+	return c
+}
+
+func TestNodeConfigSwitchesMasterFirst(t *testing.T) {
+	f := newFixture(t)
+	nodeConfig := newCgroupsNodeConfig(mcfgv1.CgroupModeV2)
+	f.ncLister = append(f.ncLister, nodeConfig)
+	f.objects = append(f.objects, nodeConfig)
+	f.mcpLister = append(f.mcpLister,
+		helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "rendered-worker-0"),
+		helpers.NewMachineConfigPool("master", nil, helpers.MasterSelector, "rendered-master-0"))
+	f.mcLister = append(f.mcLister, newRenderedConfig("rendered-worker-0", nil), newRenderedConfig("rendered-master-0", nil))
+
+	f.expectCreateMachineConfigNamed("97-master-generated-cgroups")
+	f.expectUpdateNodeConfigStatusAction(nodeConfig)
+	f.runNodeConfig()
+
+	mc, err := f.client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), "97-master-generated-cgroups", metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Equal(t, cgroupsV2KernelArgs, mc.Spec.KernelArguments)
+	ignConfig, err := ctrlcommon.ParseAndConvertConfig(mc.Spec.Config.Raw)
+	require.Nil(t, err)
+	require.Len(t, ignConfig.Storage.Files, 1)
+	assert.Equal(t, crioDropInFilePathCgroups, ignConfig.Storage.Files[0].Path)
+
+	nodeConfig, err = f.client.MachineconfigurationV1().NodeConfigs().Get(context.TODO(), clusterNodeConfigInstanceName, metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Equal(t, []mcfgv1.NodeConfigPoolStatus{{Name: "master", CgroupMode: mcfgv1.CgroupModeV2}}, nodeConfig.Status.Pools)
+	assert.Equal(t, mcfgv1.NodeConfigProgressing, nodeConfig.Status.Conditions[0].Type)
+}
+
+func TestNodeConfigSwitchesWorkerOnceMasterIsUpdated(t *testing.T) {
+	f := newFixture(t)
+	nodeConfig := newCgroupsNodeConfig(mcfgv1.CgroupModeV2)
+	f.ncLister = append(f.ncLister, nodeConfig)
+	f.objects = append(f.objects, nodeConfig)
+	f.mcpLister = append(f.mcpLister,
+		helpers.NewMachineConfigPool("master", nil, helpers.MasterSelector, "rendered-master-1"),
+		helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "rendered-worker-0"))
+	f.mcLister = append(f.mcLister, newRenderedConfig("rendered-master-1", cgroupsV2KernelArgs), newRenderedConfig("rendered-worker-0", nil))
+
+	f.expectCreateMachineConfigNamed("97-worker-generated-cgroups")
+	f.expectUpdateNodeConfigStatusAction(nodeConfig)
+	f.runNodeConfig()
+}
+
+func TestNodeConfigMissing(t *testing.T) {
+	f := newFixture(t)
+	f.mcpLister = append(f.mcpLister,
+		helpers.NewMachineConfigPool("master", nil, helpers.MasterSelector, "rendered-master-0"),
+		helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "rendered-worker-0"))
+	f.mcLister = append(f.mcLister, newRenderedConfig("rendered-master-0", nil), newRenderedConfig("rendered-worker-0", nil))
+
+	// nothing to switch back
+	c := f.runNodeConfig()
+	c.updatePoolForNodeConfig(f.mcpLister[1], helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "rendered-worker-1"))
+	assert.Equal(t, 0, c.nodeConfigQueue.Len())
+}
+
+func TestNodeConfigDeletedSwitchesBack(t *testing.T) {
+	f := newFixture(t)
+	worker := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "rendered-worker-1")
+	f.mcpLister = append(f.mcpLister, helpers.NewMachineConfigPool("master", nil, helpers.MasterSelector, "rendered-master-2"), worker)
+	cgroups, err := ctrlcommon.MachineConfigFromIgnConfig("worker", "97-worker-generated-cgroups", newCgroupsV2IgnConfig())
+	require.Nil(t, err)
+	f.mcLister = append(f.mcLister, newRenderedConfig("rendered-master-2", nil), newRenderedConfig("rendered-worker-1", cgroupsV2KernelArgs), cgroups)
+	f.objects = append(f.objects, cgroups)
+
+	f.expectDeleteMachineConfigAction("97-worker-generated-cgroups")
+	c := f.runNodeConfig()
+	c.updatePoolForNodeConfig(worker, helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "rendered-worker-2"))
+	assert.Equal(t, 1, c.nodeConfigQueue.Len())
+}
+
+func TestEnsureCgroupsMachineConfig(t *testing.T) {
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "rendered-worker-0")
+	current, err := ctrlcommon.MachineConfigFromIgnConfig("worker", "97-worker-generated-cgroups", newCgroupsV2IgnConfig())
+	require.Nil(t, err)
+	current.Spec.KernelArguments = cgroupsV2KernelArgs
+
+	// up to date
+	f := newFixture(t)
+	f.mcLister = append(f.mcLister, current)
+	f.objects = append(f.objects, current)
+	require.Nil(t, f.newController().ensureCgroupsMachineConfig(pool, mcfgv1.CgroupModeV2))
+	f.validateActions()
+
+	// only has the kernel arguments
+	f = newFixture(t)
+	outdated, err := ctrlcommon.MachineConfigFromIgnConfig("worker", "97-worker-generated-cgroups", ctrlcommon.NewIgnConfig())
+	require.Nil(t, err)
+	outdated.Spec.KernelArguments = cgroupsV2KernelArgs
+	f.mcLister = append(f.mcLister, outdated)
+	f.objects = append(f.objects, outdated)
+	expected := outdated.DeepCopy()
+	expected.Spec.Config = current.Spec.Config
+	expected.Annotations = map[string]string{ctrlcommon.GeneratedByControllerVersionAnnotationKey: version.Hash}
+	f.expectUpdateMachineConfigAction(expected)
+	require.Nil(t, f.newController().ensureCgroupsMachineConfig(pool, mcfgv1.CgroupModeV2))
+	f.validateActions()
+
+	// back to cgroup v1
+	f = newFixture(t)
+	f.mcLister = append(f.mcLister, current)
+	f.objects = append(f.objects, current)
+	f.expectDeleteMachineConfigAction("97-worker-generated-cgroups")
+	require.Nil(t, f.newController().ensureCgroupsMachineConfig(pool, mcfgv1.CgroupModeV1))
+	f.validateActions()
+}
@@ -0,0 +1,233 @@
+package node
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/machine-config-operator/internal"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+const (
+	// defaultDrainTimeout is how long draining a node can take before it is reported stuck
+	defaultDrainTimeout = time.Hour
+	// drainBlockersRefreshInterval is how often the pods blocking a stuck drain are looked up again
+	drainBlockersRefreshInterval = 5 * time.Minute
+)
+
+// syncDrainWatchdog records the drains of the nodes of the pool exceeding the timeout of its drain
+// watchdog in the pool status, along with the pods blocking them, and takes the escalation of the
+// watchdog once per stuck drain. The pool is updated in place, for syncStatusOnly to write.
+func (ctrl *Controller) syncDrainWatchdog(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) error {
+	timeout, escalation := drainWatchdogPolicy(pool)
+	now := time.Now()
+	previous := map[string]mcfgv1.MachineConfigPoolStuckDrain{}
+	for _, stuck := range pool.Status.StuckDrains {
+		previous[stuck.Node] = stuck
+	}
+
+	var stuckDrains []mcfgv1.MachineConfigPoolStuckDrain
+	stuckNodes := sets.NewString()
+	for _, node := range nodes {
+		// the nodes skipped stay listed until they are updated again
+		nodeConfig := nodeTargetConfig(pool.Spec.Configuration.Name, node)
+		if nodeConfig != "" && node.Annotations[daemonconsts.DrainSkippedAnnotationKey] == nodeConfig {
+			if stuck, ok := previous[node.Name]; ok {
+				stuckDrains = append(stuckDrains, stuck)
+			}
+			continue
+		}
+		start, err := time.Parse(time.RFC3339, node.Annotations[daemonconsts.DrainStartedAnnotationKey])
+		if err != nil {
+			continue
+		}
+		if remaining := start.Add(timeout).Sub(now); remaining > 0 {
+			// check again once the drain reaches the timeout
+			ctrl.enqueueAfter(pool, remaining)
+			continue
+		}
+
+		stuck, ok := previous[node.Name]
+		if !ok || !stuck.StartTime.Time.Equal(start) {
+			stuck = mcfgv1.MachineConfigPoolStuckDrain{Node: node.Name, StartTime: metav1.NewTime(start)}
+		}
+		if now.Sub(stuck.LastUpdateTime.Time) >= drainBlockersRefreshInterval {
+			pods, pdbs, err := ctrl.getDrainBlockers(node.Name)
+			if err != nil {
+				return err
+			}
+			if stuck.LastUpdateTime.IsZero() {
+				ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, "DrainStuck", "Drain of node %s exceeded %v, blocked by pods [%s] and PodDisruptionBudgets [%s]",
+					node.Name, timeout, strings.Join(pods, ", "), strings.Join(pdbs, ", "))
+			}
+			stuck.BlockingPods = pods
+			stuck.BlockingPodDisruptionBudgets = pdbs
+			stuck.LastUpdateTime = metav1.NewTime(now).Rfc3339Copy()
+		}
+		ctrl.enqueueAfter(pool, drainBlockersRefreshInterval)
+
+		if stuck.Escalation == "" {
+			taken, err := ctrl.escalateDrain(pool, node, escalation)
+			if err != nil {
+				return err
+			}
+			stuck.Escalation = taken
+			ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, "DrainEscalated", "Escalated the stuck drain of node %s: %s", node.Name, taken)
+		}
+		// the node retried isn't draining anymore
+		if stuck.Escalation == mcfgv1.DrainEscalationRetry {
+			continue
+		}
+		stuckDrains = append(stuckDrains, stuck)
+		if stuck.Escalation != mcfgv1.DrainEscalationSkipNode {
+			stuckNodes.Insert(node.Name)
+		}
+	}
+
+	for _, stuck := range pool.Status.StuckDrains {
+		if !stuckNodes.Has(stuck.Node) {
+			ctrlcommon.MCCDrainStuck.DeleteLabelValues(pool.Name, stuck.Node)
+		}
+	}
+	for _, node := range stuckNodes.List() {
+		ctrlcommon.MCCDrainStuck.WithLabelValues(pool.Name, node).Set(1)
+	}
+	pool.Status.StuckDrains = stuckDrains
+	return nil
+}
+
+// drainWatchdogPolicy returns the timeout and escalation of the drain watchdog of the pool
+func drainWatchdogPolicy(pool *mcfgv1.MachineConfigPool) (time.Duration, mcfgv1.DrainEscalation) {
+	timeout, escalation := defaultDrainTimeout, mcfgv1.DrainEscalationAlert
+	if watchdog := pool.Spec.DrainWatchdog; watchdog != nil {
+		if watchdog.Timeout != nil {
+			timeout = watchdog.Timeout.Duration
+		}
+		if watchdog.Escalation != "" {
+			escalation = watchdog.Escalation
+		}
+	}
+	return timeout, escalation
+}
+
+// escalateDrain takes the escalation for the stuck drain of the node and returns the one taken:
+// drains for a reboot rather than an update can't be retried or skipped, they are only reported.
+func (ctrl *Controller) escalateDrain(pool *mcfgv1.MachineConfigPool, node *corev1.Node, escalation mcfgv1.DrainEscalation) (mcfgv1.DrainEscalation, error) {
+	currentConfig := node.Annotations[daemonconsts.CurrentMachineConfigAnnotationKey]
+	desiredConfig := node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey]
+	switch escalation {
+	case mcfgv1.DrainEscalationForce:
+		ctrl.logPoolNode(pool, node, "Forcing the stuck drain")
+		if err := ctrl.setNodeAnnotation(node.Name, daemonconsts.ForceDrainAnnotationKey, desiredConfig); err != nil {
+			return "", err
+		}
+		return escalation, nil
+	case mcfgv1.DrainEscalationRetry, mcfgv1.DrainEscalationSkipNode:
+		if currentConfig == "" || currentConfig == desiredConfig {
+			return mcfgv1.DrainEscalationAlert, nil
+		}
+		nodeConfig := nodeTargetConfig(pool.Spec.Configuration.Name, node)
+		ctrl.logPoolNode(pool, node, "Moving the node back to %s after its drain got stuck (%s)", currentConfig, escalation)
+		_, err := internal.UpdateNodeRetry(ctrl.kubeClient.CoreV1().Nodes(), ctrl.nodeLister, node.Name, func(node *corev1.Node) {
+			node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] = currentConfig
+			delete(node.Annotations, daemonconsts.DrainStartedAnnotationKey)
+			if escalation == mcfgv1.DrainEscalationSkipNode {
+				node.Annotations[daemonconsts.DrainSkippedAnnotationKey] = nodeConfig
+			}
+		})
+		if err != nil {
+			return "", err
+		}
+		if escalation == mcfgv1.DrainEscalationRetry {
+			ctrl.drainRetriesLock.Lock()
+			ctrl.drainRetries[node.Name] = nodeConfig
+			ctrl.drainRetriesLock.Unlock()
+		}
+		return escalation, nil
+	default:
+		return mcfgv1.DrainEscalationAlert, nil
+	}
+}
+
+// orderRetriedDrainsLast moves the candidates whose drain got stuck updating to their target config
+// after the other candidates, so the pool progresses meanwhile
+func (ctrl *Controller) orderRetriedDrainsLast(pool *mcfgv1.MachineConfigPool, candidates []*corev1.Node) []*corev1.Node {
+	ctrl.drainRetriesLock.Lock()
+	defer ctrl.drainRetriesLock.Unlock()
+	retried := func(node *corev1.Node) bool {
+		config, ok := ctrl.drainRetries[node.Name]
+		return ok && config == nodeTargetConfig(pool.Spec.Configuration.Name, node)
+	}
+	ordered := make([]*corev1.Node, len(candidates))
+	copy(ordered, candidates)
+	sort.SliceStable(ordered, func(i, j int) bool { return !retried(ordered[i]) && retried(ordered[j]) })
+	return ordered
+}
+
+// getDrainBlockers returns the pods left to drain from the node and the PodDisruptionBudgets
+// allowing none of them to be evicted, as namespace/name
+func (ctrl *Controller) getDrainBlockers(nodeName string) ([]string, []string, error) {
+	pods, err := ctrl.kubeClient.CoreV1().Pods(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	var blockingPods []string
+	blockingPDBs := sets.NewString()
+	namespacePDBs := map[string][]policyv1beta1.PodDisruptionBudget{}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Spec.NodeName != nodeName || !isDrainedPod(pod) {
+			continue
+		}
+		blockingPods = append(blockingPods, pod.Namespace+"/"+pod.Name)
+		pdbs, ok := namespacePDBs[pod.Namespace]
+		if !ok {
+			list, err := ctrl.kubeClient.PolicyV1beta1().PodDisruptionBudgets(pod.Namespace).List(context.TODO(), metav1.ListOptions{})
+			if err != nil {
+				return nil, nil, err
+			}
+			pdbs = list.Items
+			namespacePDBs[pod.Namespace] = pdbs
+		}
+		for _, pdb := range pdbs {
+			if pdb.Status.DisruptionsAllowed > 0 {
+				continue
+			}
+			selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+			if err != nil || selector.Empty() || !selector.Matches(labels.Set(pod.Labels)) {
+				continue
+			}
+			blockingPDBs.Insert(pdb.Namespace + "/" + pdb.Name)
+		}
+	}
+	sort.Strings(blockingPods)
+	return blockingPods, blockingPDBs.List(), nil
+}
+
+// isDrainedPod returns whether draining the node evicts the pod, like the drain of the daemon
+// which ignores DaemonSets
+func isDrainedPod(pod *corev1.Pod) bool {
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return false
+	}
+	if _, mirror := pod.Annotations[corev1.MirrorPodAnnotationKey]; mirror {
+		return false
+	}
+	if ref := metav1.GetControllerOf(pod); ref != nil && ref.Kind == "DaemonSet" {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+package node
+
+import (
+	goerrs "github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	intstrutil "k8s.io/apimachinery/pkg/util/intstr"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+// syncImagePulls sets the nodes of the pool not updated to its target config yet to pre-pull
+// the OS image of their config, maxConcurrentImagePulls of them at a time, so their update
+// doesn't download it while they are drained. They stage the OS update too if the pool
+// stageOSUpdates.
+func (ctrl *Controller) syncImagePulls(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) error {
+	candidates, capacity, err := getImagePullCandidates(pool, nodes)
+	if err != nil {
+		return err
+	}
+	if capacity < uint(len(candidates)) {
+		candidates = candidates[:capacity]
+	}
+	for _, node := range candidates {
+		config := nodeTargetConfig(pool.Spec.Configuration.Name, node)
+		ctrl.logPoolNode(pool, node, "Setting node to pre-pull the OS image of %s", config)
+		stage := ""
+		if pool.Spec.StageOSUpdates {
+			stage = "true"
+		}
+		if err := ctrl.setNodeAnnotation(node.Name, daemonconsts.StageOSUpdateAnnotationKey, stage); err != nil {
+			return goerrs.Wrapf(err, "setting OS update staging for node %s", node.Name)
+		}
+		if err := ctrl.setNodeAnnotation(node.Name, daemonconsts.DesiredImagePullAnnotationKey, config); err != nil {
+			return goerrs.Wrapf(err, "setting desired image pull for node %s", node.Name)
+		}
+	}
+	return nil
+}
+
+// getImagePullCandidates returns the nodes of the pool to pre-pull the OS image of their target
+// config, along with a maximum capacity. Only the nodes done updating pull, the others pull the
+// image as part of their update.
+func getImagePullCandidates(pool *mcfgv1.MachineConfigPool, nodesInPool []*corev1.Node) ([]*corev1.Node, uint, error) {
+	if pool.Spec.MaxConcurrentImagePulls == nil {
+		return nil, 0, nil
+	}
+	maxPulls, err := intstrutil.GetScaledValueFromIntOrPercent(pool.Spec.MaxConcurrentImagePulls, len(nodesInPool), false)
+	if err != nil {
+		return nil, 0, err
+	}
+	if maxPulls == 0 {
+		maxPulls = 1
+	}
+
+	pulling := 0
+	var nodes []*corev1.Node
+	for _, node := range nodesInPool {
+		if !isNodeReady(node) || !isNodeDone(node) {
+			continue
+		}
+		desired := node.Annotations[daemonconsts.DesiredImagePullAnnotationKey]
+		if desired != "" && desired != node.Annotations[daemonconsts.CurrentImagePullAnnotationKey] {
+			pulling++
+			continue
+		}
+		config := nodeTargetConfig(pool.Spec.Configuration.Name, node)
+		if config == "" || config == desired || config == node.Annotations[daemonconsts.CurrentMachineConfigAnnotationKey] {
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+	if pulling >= maxPulls {
+		return nil, 0, nil
+	}
+	return nodes, uint(maxPulls - pulling), nil
+}
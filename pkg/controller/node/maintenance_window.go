@@ -0,0 +1,102 @@
+package node
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+// parseMaintenanceWindow returns the time of day the window opens at and the days it opens on,
+// none for every day
+func parseMaintenanceWindow(window *mcfgv1.MachineConfigPoolMaintenanceWindow) (time.Time, map[time.Weekday]bool, error) {
+	start, err := time.Parse("15:04", window.StartTime)
+	if err != nil {
+		return time.Time{}, nil, fmt.Errorf("invalid startTime %q, expected HH:MM in UTC", window.StartTime)
+	}
+	if window.Duration.Duration <= 0 {
+		return time.Time{}, nil, fmt.Errorf("invalid duration %v", window.Duration.Duration)
+	}
+	days := map[time.Weekday]bool{}
+	for _, day := range window.Days {
+		weekday, ok := weekdays[day]
+		if !ok {
+			return time.Time{}, nil, fmt.Errorf("invalid day %q", day)
+		}
+		days[weekday] = true
+	}
+	return start, days, nil
+}
+
+// maintenanceWindowOpen returns whether the maintenance window of the pool is open at now and,
+// when it isn't, how long until it opens. A pool without a window is always open.
+func maintenanceWindowOpen(window *mcfgv1.MachineConfigPoolMaintenanceWindow, now time.Time) (bool, time.Duration, error) {
+	if window == nil {
+		return true, 0, nil
+	}
+	start, days, err := parseMaintenanceWindow(window)
+	if err != nil {
+		return false, 0, err
+	}
+
+	now = now.UTC()
+	// a window opened on one of the previous days may still be open, the next one opens within a week
+	next := time.Duration(-1)
+	for offset := -7; offset <= 7; offset++ {
+		day := now.AddDate(0, 0, offset)
+		if len(days) > 0 && !days[day.Weekday()] {
+			continue
+		}
+		opens := time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), 0, 0, time.UTC)
+		if !now.Before(opens) && now.Before(opens.Add(window.Duration.Duration)) {
+			return true, 0, nil
+		}
+		if opens.After(now) && (next < 0 || opens.Sub(now) < next) {
+			next = opens.Sub(now)
+		}
+	}
+	return false, next, nil
+}
+
+var weekdays = map[string]time.Weekday{
+	"Sunday":    time.Sunday,
+	"Monday":    time.Monday,
+	"Tuesday":   time.Tuesday,
+	"Wednesday": time.Wednesday,
+	"Thursday":  time.Thursday,
+	"Friday":    time.Friday,
+	"Saturday":  time.Saturday,
+}
+
+// checkMaintenanceWindow returns whether the nodes of the pool can start updating now. When the
+// maintenance window of the pool is closed, the pool is synced again once it opens. An invalid
+// window holds the updates, it's reported in the MaintenanceWindowInvalid condition of the pool.
+func (ctrl *Controller) checkMaintenanceWindow(pool *mcfgv1.MachineConfigPool) bool {
+	open, until, err := maintenanceWindowOpen(pool.Spec.MaintenanceWindow, time.Now())
+	if err != nil {
+		glog.V(4).Infof("Pool %s: not updating the nodes, invalid maintenance window: %v", pool.Name, err)
+		return false
+	}
+	if !open {
+		ctrl.logPool(pool, "Maintenance window closed, opening in %v", until.Round(time.Second))
+		ctrl.enqueueAfter(pool, until)
+	}
+	return open
+}
+
+// setMaintenanceWindowCondition sets the MaintenanceWindowInvalid condition of the status when the
+// maintenance window of the pool is invalid, the condition is removed otherwise
+func setMaintenanceWindowCondition(pool *mcfgv1.MachineConfigPool, status *mcfgv1.MachineConfigPoolStatus) {
+	if window := pool.Spec.MaintenanceWindow; window != nil {
+		if _, _, err := parseMaintenanceWindow(window); err != nil {
+			cond := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolMaintenanceWindowInvalid, corev1.ConditionTrue, "InvalidMaintenanceWindow",
+				fmt.Sprintf("Not updating the nodes of the pool: %v", err))
+			mcfgv1.SetMachineConfigPoolCondition(status, *cond)
+			return
+		}
+	}
+	mcfgv1.RemoveMachineConfigPoolCondition(status, mcfgv1.MachineConfigPoolMaintenanceWindowInvalid)
+}
@@ -5,6 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/golang/glog"
@@ -58,6 +62,16 @@ const (
 	// osLabel is used to identify which type of OS the node has
 	osLabel = "kubernetes.io/os"
 
+	// poolNodeLabelsAnnotationKey and poolNodeTaintsAnnotationKey record the labels and taints of the pool
+	// last set on the node, so that the ones removed from the pool can be removed from the node
+	// without touching those set by other tools.
+	poolNodeLabelsAnnotationKey = "machineconfiguration.openshift.io/poolNodeLabels"
+	poolNodeTaintsAnnotationKey = "machineconfiguration.openshift.io/poolNodeTaints"
+
+	// nodeOverrideConfigsAnnotationKey maps, in JSON, the rendered configs of the pool of a node with a
+	// MachineConfigNodeOverride to the configs rendered from them with the override.
+	nodeOverrideConfigsAnnotationKey = "machineconfiguration.openshift.io/overrideConfigs"
+
 	// schedulerCRName that we're interested in watching.
 	schedulerCRName = "cluster"
 
@@ -83,15 +97,25 @@ type Controller struct {
 	ccLister   mcfglistersv1.ControllerConfigLister
 	mcpLister  mcfglistersv1.MachineConfigPoolLister
 	nodeLister corelisterv1.NodeLister
+	mcnoLister mcfglistersv1.MachineConfigNodeOverrideLister
 
 	ccListerSynced   cache.InformerSynced
 	mcpListerSynced  cache.InformerSynced
 	nodeListerSynced cache.InformerSynced
+	mcnoListerSynced cache.InformerSynced
 
 	schedulerList         cligolistersv1.SchedulerLister
 	schedulerListerSynced cache.InformerSynced
 
 	queue workqueue.RateLimitingInterface
+
+	// updateStarts tracks when the nodes updating were targeted at their config, by node name
+	updateStarts     map[string]nodeUpdateStart
+	updateStartsLock sync.Mutex
+
+	// drainRetries tracks the config the nodes retried after a stuck drain were updating to, by node name
+	drainRetries     map[string]string
+	drainRetriesLock sync.Mutex
 }
 
 // New returns a new node controller.
@@ -99,6 +123,7 @@ func New(
 	ccInformer mcfginformersv1.ControllerConfigInformer,
 	mcpInformer mcfginformersv1.MachineConfigPoolInformer,
 	nodeInformer coreinformersv1.NodeInformer,
+	mcnoInformer mcfginformersv1.MachineConfigNodeOverrideInformer,
 	schedulerInformer cligoinformersv1.SchedulerInformer,
 	kubeClient clientset.Interface,
 	mcfgClient mcfgclientset.Interface,
@@ -112,6 +137,8 @@ func New(
 		kubeClient:    kubeClient,
 		eventRecorder: eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "machineconfigcontroller-nodecontroller"}),
 		queue:         workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "machineconfigcontroller-nodecontroller"),
+		updateStarts:  map[string]nodeUpdateStart{},
+		drainRetries:  map[string]string{},
 	}
 
 	mcpInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -124,6 +151,11 @@ func New(
 		UpdateFunc: ctrl.updateNode,
 		DeleteFunc: ctrl.deleteNode,
 	})
+	mcnoInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.addMachineConfigNodeOverride,
+		UpdateFunc: ctrl.updateMachineConfigNodeOverride,
+		DeleteFunc: ctrl.deleteMachineConfigNodeOverride,
+	})
 	schedulerInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc:    ctrl.checkMasterNodesOnAdd,
 		UpdateFunc: ctrl.checkMasterNodesOnUpdate,
@@ -138,6 +170,8 @@ func New(
 	ctrl.ccListerSynced = ccInformer.Informer().HasSynced
 	ctrl.mcpListerSynced = mcpInformer.Informer().HasSynced
 	ctrl.nodeListerSynced = nodeInformer.Informer().HasSynced
+	ctrl.mcnoLister = mcnoInformer.Lister()
+	ctrl.mcnoListerSynced = mcnoInformer.Informer().HasSynced
 
 	ctrl.schedulerList = schedulerInformer.Lister()
 	ctrl.schedulerListerSynced = schedulerInformer.Informer().HasSynced
@@ -150,7 +184,7 @@ func (ctrl *Controller) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
 	defer ctrl.queue.ShutDown()
 
-	if !cache.WaitForCacheSync(stopCh, ctrl.ccListerSynced, ctrl.mcpListerSynced, ctrl.nodeListerSynced, ctrl.schedulerListerSynced) {
+	if !cache.WaitForCacheSync(stopCh, ctrl.ccListerSynced, ctrl.mcpListerSynced, ctrl.nodeListerSynced, ctrl.mcnoListerSynced, ctrl.schedulerListerSynced) {
 		return
 	}
 
@@ -472,6 +506,8 @@ func (ctrl *Controller) updateNode(old, cur interface{}) {
 			daemonconsts.CurrentMachineConfigAnnotationKey,
 			daemonconsts.DesiredMachineConfigAnnotationKey,
 			daemonconsts.MachineConfigDaemonStateAnnotationKey,
+			daemonconsts.CurrentRebootAnnotationKey,
+			nodeOverrideConfigsAnnotationKey,
 		}
 		for _, anno := range annos {
 			newValue := curNode.Annotations[anno]
@@ -744,6 +780,17 @@ func (ctrl *Controller) syncMachineConfigPool(key string) error {
 		if mcfgv1.IsMachineConfigPoolConditionTrue(pool.Status.Conditions, mcfgv1.MachineConfigPoolUpdating) {
 			glog.Infof("Pool %s is paused and will not update.", pool.Name)
 		}
+		nodes, err := ctrl.getNodesForPool(pool)
+		if err != nil {
+			return err
+		}
+		// the nodes still pre-pull the OS image of the update on hold, and finish draining
+		if err := ctrl.syncImagePulls(pool, nodes); err != nil {
+			return goerrs.Wrapf(err, "error syncing the image pulls of pool %q", pool.Name)
+		}
+		if err := ctrl.syncDrainWatchdog(pool, nodes); err != nil {
+			return goerrs.Wrapf(err, "error watching the drains of pool %q", pool.Name)
+		}
 		return ctrl.syncStatusOnly(pool)
 	}
 
@@ -755,6 +802,19 @@ func (ctrl *Controller) syncMachineConfigPool(key string) error {
 		return err
 	}
 
+	nodes, err = ctrl.syncNodeOverrides(pool, nodes)
+	if err != nil {
+		return goerrs.Wrapf(err, "error syncing the node overrides of pool %q", pool.Name)
+	}
+
+	if err := ctrl.recordUpdateDurations(pool, nodes); err != nil {
+		return goerrs.Wrapf(err, "error recording the node update durations of pool %q", pool.Name)
+	}
+
+	if err := ctrl.syncDrainWatchdog(pool, nodes); err != nil {
+		return goerrs.Wrapf(err, "error watching the drains of pool %q", pool.Name)
+	}
+
 	maxunavail, err := maxUnavailable(pool, nodes)
 	if err != nil {
 		if syncErr := ctrl.syncStatusOnly(pool); syncErr != nil {
@@ -763,12 +823,44 @@ func (ctrl *Controller) syncMachineConfigPool(key string) error {
 		return err
 	}
 
+	zoneLimits, err := maxUnavailablePerZone(pool, nodes)
+	if err != nil {
+		if syncErr := ctrl.syncStatusOnly(pool); syncErr != nil {
+			return goerrs.Wrapf(err, "error getting max unavailable count per zone for pool %q, sync error: %v", pool.Name, syncErr)
+		}
+		return err
+	}
+
+	if err := validateUpdateOrder(pool); err != nil {
+		if syncErr := ctrl.syncStatusOnly(pool); syncErr != nil {
+			return goerrs.Wrapf(err, "error getting the update order of pool %q, sync error: %v", pool.Name, syncErr)
+		}
+		return err
+	}
+
 	if err := ctrl.setClusterConfigAnnotation(nodes); err != nil {
 		return goerrs.Wrapf(err, "error setting clusterConfig Annotation for node in pool %q, error: %v", pool.Name, err)
 	}
 
+	if err := ctrl.setPoolNodeLabelsAndTaints(pool, nodes); err != nil {
+		return goerrs.Wrapf(err, "error setting labels and taints of pool %q on its nodes", pool.Name)
+	}
+
+	if err := ctrl.syncImagePulls(pool, nodes); err != nil {
+		return goerrs.Wrapf(err, "error syncing the image pulls of pool %q", pool.Name)
+	}
+
 	candidates, capacity := getAllCandidateMachines(pool, nodes, maxunavail)
 	if len(candidates) > 0 {
+		if !ctrl.checkMaintenanceWindow(pool) {
+			return ctrl.syncStatusOnly(pool)
+		}
+		candidates = ctrl.orderRetriedDrainsLast(pool, orderNodes(pool, candidates))
+		candidates = limitCandidatesPerZone(nodes, restrictToUpdatingZone(pool, nodes, candidates), zoneLimits)
+		if len(candidates) == 0 {
+			ctrl.logPool(pool, "No candidate node for update can start updating in its zone")
+			return ctrl.syncStatusOnly(pool)
+		}
 		ctrl.logPool(pool, "%d candidate nodes for update, capacity: %d", len(candidates), capacity)
 		if err := ctrl.updateCandidateMachines(pool, candidates, capacity); err != nil {
 			if syncErr := ctrl.syncStatusOnly(pool); syncErr != nil {
@@ -776,8 +868,30 @@ func (ctrl *Controller) syncMachineConfigPool(key string) error {
 			}
 			return err
 		}
+		return ctrl.syncStatusOnly(pool)
+	}
+
+	rebootCandidates, rebootCapacity, err := getAllRebootCandidateMachines(pool, nodes, maxunavail)
+	if err != nil {
+		ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, "InvalidRebootRequest", "Ignoring the reboot request: %v", err)
+	}
+	rebootCandidates = limitCandidatesPerZone(nodes, orderNodes(pool, rebootCandidates), zoneLimits)
+	if len(rebootCandidates) > 0 && ctrl.checkMaintenanceWindow(pool) {
+		ctrl.logPool(pool, "%d candidate nodes for reboot, capacity: %d", len(rebootCandidates), rebootCapacity)
+		if err := ctrl.updateRebootCandidateMachines(pool, rebootCandidates, rebootCapacity); err != nil {
+			if syncErr := ctrl.syncStatusOnly(pool); syncErr != nil {
+				return goerrs.Wrapf(err, "error setting desired reboot annotation for pool %q, sync error: %v", pool.Name, syncErr)
+			}
+			return err
+		}
+	}
+	if err := ctrl.syncStatusOnly(pool); err != nil {
+		return err
+	}
+	if isRebootRequestCompleted(pool, nodes) {
+		return ctrl.setRebootCompleted(pool)
 	}
-	return ctrl.syncStatusOnly(pool)
+	return nil
 }
 
 func (ctrl *Controller) getNodesForPool(pool *mcfgv1.MachineConfigPool) ([]*corev1.Node, error) {
@@ -833,7 +947,155 @@ func (ctrl *Controller) setClusterConfigAnnotation(nodes []*corev1.Node) error {
 	return nil
 }
 
+// setPoolNodeLabelsAndTaints makes the nodes updated to the targeted config of the pool carry its
+// nodeLabels and nodeTaints. Nodes still updating get them once they are done.
+func (ctrl *Controller) setPoolNodeLabelsAndTaints(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) error {
+	for _, node := range nodes {
+		if !isNodeDoneAt(node, pool.Spec.Configuration.Name) {
+			continue
+		}
+		if !applyPoolNodeLabelsAndTaints(pool, node.DeepCopy()) {
+			continue
+		}
+		_, err := internal.UpdateNodeRetry(ctrl.kubeClient.CoreV1().Nodes(), ctrl.nodeLister, node.Name, func(node *corev1.Node) {
+			applyPoolNodeLabelsAndTaints(pool, node)
+		})
+		if err != nil {
+			return err
+		}
+		ctrl.logPoolNode(pool, node, "Updated pool labels and taints")
+	}
+	return nil
+}
+
+// applyPoolNodeLabelsAndTaints sets the nodeLabels and nodeTaints of the pool on the node, removes the
+// ones the pool previously set and no longer has, and returns whether the node changed.
+func applyPoolNodeLabelsAndTaints(pool *mcfgv1.MachineConfigPool, node *corev1.Node) bool {
+	changed := false
+	if node.Labels == nil {
+		node.Labels = map[string]string{}
+	}
+	if node.Annotations == nil {
+		node.Annotations = map[string]string{}
+	}
+
+	for _, key := range splitPoolNodeAnnotation(node.Annotations[poolNodeLabelsAnnotationKey]) {
+		if _, ok := pool.Spec.NodeLabels[key]; ok {
+			continue
+		}
+		if _, ok := node.Labels[key]; ok {
+			delete(node.Labels, key)
+			changed = true
+		}
+	}
+	labelKeys := []string{}
+	for key, value := range pool.Spec.NodeLabels {
+		if cur, ok := node.Labels[key]; !ok || cur != value {
+			node.Labels[key] = value
+			changed = true
+		}
+		labelKeys = append(labelKeys, key)
+	}
+
+	taintKey := func(taint corev1.Taint) string {
+		return taint.Key + ":" + string(taint.Effect)
+	}
+	desiredTaints := map[string]corev1.Taint{}
+	taintKeys := []string{}
+	for _, taint := range pool.Spec.NodeTaints {
+		desiredTaints[taintKey(taint)] = taint
+		taintKeys = append(taintKeys, taintKey(taint))
+	}
+	previousTaints := map[string]bool{}
+	for _, key := range splitPoolNodeAnnotation(node.Annotations[poolNodeTaintsAnnotationKey]) {
+		previousTaints[key] = true
+	}
+	newTaints := []corev1.Taint{}
+	for _, taint := range node.Spec.Taints {
+		desired, ok := desiredTaints[taintKey(taint)]
+		switch {
+		case ok:
+			if taint.Value != desired.Value {
+				taint.Value = desired.Value
+				changed = true
+			}
+			delete(desiredTaints, taintKey(taint))
+		case previousTaints[taintKey(taint)]:
+			changed = true
+			continue
+		}
+		newTaints = append(newTaints, taint)
+	}
+	for _, taint := range pool.Spec.NodeTaints {
+		if _, ok := desiredTaints[taintKey(taint)]; ok {
+			newTaints = append(newTaints, taint)
+			changed = true
+		}
+	}
+	node.Spec.Taints = newTaints
+
+	changed = setPoolNodeAnnotation(node, poolNodeLabelsAnnotationKey, labelKeys) || changed
+	changed = setPoolNodeAnnotation(node, poolNodeTaintsAnnotationKey, taintKeys) || changed
+	return changed
+}
+
+func splitPoolNodeAnnotation(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+func setPoolNodeAnnotation(node *corev1.Node, key string, values []string) bool {
+	sort.Strings(values)
+	value := strings.Join(values, ",")
+	if cur, ok := node.Annotations[key]; (ok && cur == value) || (!ok && value == "") {
+		return false
+	}
+	if value == "" {
+		delete(node.Annotations, key)
+	} else {
+		node.Annotations[key] = value
+	}
+	return true
+}
+
+// rollbackDeploymentsAnnotation returns the value of the RollbackDeploymentsAnnotationKey annotation
+// of the nodes of the pool, set along their desired config for the daemon to apply once updated
+func rollbackDeploymentsAnnotation(pool *mcfgv1.MachineConfigPool) string {
+	if pool.Spec.RollbackDeployments == nil {
+		return ""
+	}
+	return strconv.Itoa(int(*pool.Spec.RollbackDeployments))
+}
+
+// setGracefulRebootAnnotations sets the gracefulReboot of the pool on the node, for the daemon to
+// schedule its next reboot with
+func (ctrl *Controller) setGracefulRebootAnnotations(pool *mcfgv1.MachineConfigPool, node *corev1.Node) error {
+	delay, wallMessage := "", ""
+	if pool.Spec.GracefulReboot != nil {
+		delay = pool.Spec.GracefulReboot.Delay.Duration.String()
+		wallMessage = pool.Spec.GracefulReboot.WallMessage
+	}
+	for key, value := range map[string]string{
+		daemonconsts.RebootDelayAnnotationKey:       delay,
+		daemonconsts.RebootWallMessageAnnotationKey: wallMessage,
+	} {
+		if node.Annotations[key] == value {
+			continue
+		}
+		if err := ctrl.setNodeAnnotation(node.Name, key, value); err != nil {
+			return goerrs.Wrapf(err, "setting graceful reboot for node %s", node.Name)
+		}
+	}
+	return nil
+}
+
 func (ctrl *Controller) setDesiredMachineConfigAnnotation(nodeName, currentConfig string) error {
+	return ctrl.setNodeAnnotation(nodeName, daemonconsts.DesiredMachineConfigAnnotationKey, currentConfig)
+}
+
+func (ctrl *Controller) setNodeAnnotation(nodeName, key, value string) error {
 	return clientretry.RetryOnConflict(nodeUpdateBackoff, func() error {
 		oldNode, err := ctrl.kubeClient.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
 		if err != nil {
@@ -849,10 +1111,10 @@ func (ctrl *Controller) setDesiredMachineConfigAnnotation(nodeName, currentConfi
 			newNode.Annotations = map[string]string{}
 		}
 
-		if newNode.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] == currentConfig {
+		if newNode.Annotations[key] == value {
 			return nil
 		}
-		newNode.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] = currentConfig
+		newNode.Annotations[key] = value
 		newData, err := json.Marshal(newNode)
 		if err != nil {
 			return err
@@ -882,12 +1144,25 @@ func getAllCandidateMachines(pool *mcfgv1.MachineConfigPool, nodesInPool []*core
 	// We only look at nodes which aren't already targeting our desired config
 	var nodes []*corev1.Node
 	for _, node := range nodesInPool {
-		if node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] == targetConfig {
+		nodeConfig := nodeTargetConfig(targetConfig, node)
+		// The override of the node isn't rendered on top of the target config yet
+		if nodeConfig == "" {
+			continue
+		}
+		if node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] == nodeConfig {
 			if isNodeMCDFailing(node) {
 				failingThisConfig++
 			}
 			continue
 		}
+		// The drain of the node got stuck updating to this config
+		if node.Annotations[daemonconsts.DrainSkippedAnnotationKey] == nodeConfig {
+			continue
+		}
+		// The daemon is recovering the kubelet of the node, it can't be drained until then
+		if isNodeRecoveringKubeletCert(node) {
+			continue
+		}
 
 		nodes = append(nodes, node)
 	}
@@ -903,6 +1178,89 @@ func getAllCandidateMachines(pool *mcfgv1.MachineConfigPool, nodesInPool []*core
 	return nodes, uint(capacity)
 }
 
+// getAllRebootCandidateMachines returns the nodes to reboot for the reboot request of the pool,
+// along with a maximum capacity. Nodes are only rebooted once they are all updated to the target
+// config, nodes created after the request don't need a reboot.
+func getAllRebootCandidateMachines(pool *mcfgv1.MachineConfigPool, nodesInPool []*corev1.Node, maxUnavailable int) ([]*corev1.Node, uint, error) {
+	request := pool.Annotations[daemonconsts.RebootRequestedAnnotationKey]
+	if request == "" {
+		return nil, 0, nil
+	}
+	requestTime, err := time.Parse(time.RFC3339, request)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s must be an RFC 3339 time: %v", daemonconsts.RebootRequestedAnnotationKey, err)
+	}
+
+	unavail := getUnavailableMachines(nodesInPool)
+	if len(unavail) >= maxUnavailable {
+		return nil, 0, nil
+	}
+	targetConfig := pool.Spec.Configuration.Name
+	var nodes []*corev1.Node
+	for _, node := range nodesInPool {
+		nodeConfig := nodeTargetConfig(targetConfig, node)
+		if nodeConfig == "" ||
+			node.Annotations[daemonconsts.CurrentMachineConfigAnnotationKey] != nodeConfig ||
+			node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] != nodeConfig {
+			return nil, 0, nil
+		}
+		if node.Annotations[daemonconsts.DesiredRebootAnnotationKey] == request || !node.CreationTimestamp.Time.Before(requestTime) {
+			continue
+		}
+		if isNodeRecoveringKubeletCert(node) {
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, uint(maxUnavailable - len(unavail)), nil
+}
+
+// isRebootRequestCompleted returns true if every node of the pool created before its reboot request
+// rebooted for it and is done, and the request hasn't been marked completed yet.
+func isRebootRequestCompleted(pool *mcfgv1.MachineConfigPool, nodesInPool []*corev1.Node) bool {
+	request := pool.Annotations[daemonconsts.RebootRequestedAnnotationKey]
+	if request == "" || pool.Annotations[daemonconsts.RebootCompletedAnnotationKey] == request {
+		return false
+	}
+	requestTime, err := time.Parse(time.RFC3339, request)
+	if err != nil {
+		return false
+	}
+	for _, node := range nodesInPool {
+		if !node.CreationTimestamp.Time.Before(requestTime) {
+			continue
+		}
+		if node.Annotations[daemonconsts.CurrentRebootAnnotationKey] != request || !isNodeDone(node) {
+			return false
+		}
+	}
+	return true
+}
+
+// setRebootCompleted marks the reboot request of the pool as completed
+func (ctrl *Controller) setRebootCompleted(pool *mcfgv1.MachineConfigPool) error {
+	request := pool.Annotations[daemonconsts.RebootRequestedAnnotationKey]
+	err := clientretry.RetryOnConflict(clientretry.DefaultRetry, func() error {
+		newPool, err := ctrl.client.MachineconfigurationV1().MachineConfigPools().Get(context.TODO(), pool.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if newPool.Annotations[daemonconsts.RebootRequestedAnnotationKey] != request {
+			// the request changed meanwhile
+			return nil
+		}
+		newPool.Annotations[daemonconsts.RebootCompletedAnnotationKey] = request
+		_, err = ctrl.client.MachineconfigurationV1().MachineConfigPools().Update(context.TODO(), newPool, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		return goerrs.Wrapf(err, "marking the reboot request of pool %q completed", pool.Name)
+	}
+	ctrl.logPool(pool, "All nodes rebooted for the request %s", request)
+	ctrl.eventRecorder.Eventf(pool, corev1.EventTypeNormal, "RebootCompleted", "All nodes rebooted for the request %s", request)
+	return nil
+}
+
 // getCandidateMachines returns the maximum subset of nodes which can be updated to the target config given availability constraints.
 func getCandidateMachines(pool *mcfgv1.MachineConfigPool, nodesInPool []*corev1.Node, maxUnavailable int) []*corev1.Node {
 	nodes, capacity := getAllCandidateMachines(pool, nodesInPool, maxUnavailable)
@@ -953,26 +1311,61 @@ func (ctrl *Controller) updateCandidateMachines(pool *mcfgv1.MachineConfigPool,
 		ctrl.logPool(pool, "filtered to %d candidate nodes for update, capacity: %d", len(candidates), capacity)
 	}
 	if capacity < uint(len(candidates)) {
-		// Arbitrarily pick the first N candidates; no attempt at sorting.
-		// Perhaps later we allow admins to weight somehow, or do something more intelligent.
+		// Pick the first N candidates, they are in the update order of the pool
 		candidates = candidates[:capacity]
 	}
 	targetConfig := pool.Spec.Configuration.Name
 	for _, node := range candidates {
-		ctrl.logPool(pool, "Setting node %s target to %s", node.Name, targetConfig)
-		if err := ctrl.setDesiredMachineConfigAnnotation(node.Name, targetConfig); err != nil {
+		nodeConfig := nodeTargetConfig(targetConfig, node)
+		ctrl.logPool(pool, "Setting node %s target to %s", node.Name, nodeConfig)
+		if rollbackDeployments := rollbackDeploymentsAnnotation(pool); node.Annotations[daemonconsts.RollbackDeploymentsAnnotationKey] != rollbackDeployments {
+			if err := ctrl.setNodeAnnotation(node.Name, daemonconsts.RollbackDeploymentsAnnotationKey, rollbackDeployments); err != nil {
+				return goerrs.Wrapf(err, "setting rollback deployments for node %s", node.Name)
+			}
+		}
+		if err := ctrl.setGracefulRebootAnnotations(pool, node); err != nil {
+			return err
+		}
+		if err := ctrl.setDesiredMachineConfigAnnotation(node.Name, nodeConfig); err != nil {
 			return goerrs.Wrapf(err, "setting desired config for node %s", node.Name)
 		}
+		ctrl.trackUpdateStart(node.Name, nodeConfig)
 	}
 	if len(candidates) == 1 {
 		candidate := candidates[0]
-		ctrl.eventRecorder.Eventf(pool, corev1.EventTypeNormal, "SetDesiredConfig", "Targeted node %s to config %s", candidate.Name, targetConfig)
+		ctrl.eventRecorder.Eventf(pool, corev1.EventTypeNormal, "SetDesiredConfig", "Targeted node %s to config %s", candidate.Name, nodeTargetConfig(targetConfig, candidate))
 	} else {
 		ctrl.eventRecorder.Eventf(pool, corev1.EventTypeNormal, "SetDesiredConfig", "Set target for %d nodes to config %s", targetConfig)
 	}
 	return nil
 }
 
+// updateRebootCandidateMachines sets the desiredReboot annotation on the candidate machines
+func (ctrl *Controller) updateRebootCandidateMachines(pool *mcfgv1.MachineConfigPool, candidates []*corev1.Node, capacity uint) error {
+	if pool.Name == masterPoolName {
+		var err error
+		candidates, capacity, err = ctrl.filterControlPlaneCandidateNodes(pool, candidates, capacity)
+		if err != nil {
+			return err
+		}
+	}
+	if capacity < uint(len(candidates)) {
+		candidates = candidates[:capacity]
+	}
+	request := pool.Annotations[daemonconsts.RebootRequestedAnnotationKey]
+	for _, node := range candidates {
+		ctrl.logPool(pool, "Setting node %s to reboot for the request %s", node.Name, request)
+		if err := ctrl.setGracefulRebootAnnotations(pool, node); err != nil {
+			return err
+		}
+		if err := ctrl.setNodeAnnotation(node.Name, daemonconsts.DesiredRebootAnnotationKey, request); err != nil {
+			return goerrs.Wrapf(err, "setting desired reboot for node %s", node.Name)
+		}
+	}
+	ctrl.eventRecorder.Eventf(pool, corev1.EventTypeNormal, "SetDesiredReboot", "Set %d nodes to reboot for the request %s", len(candidates), request)
+	return nil
+}
+
 func maxUnavailable(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) (int, error) {
 	intOrPercent := intstrutil.FromInt(1)
 	if pool.Spec.MaxUnavailable != nil {
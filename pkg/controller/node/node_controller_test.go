@@ -1,6 +1,7 @@
 package node
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"reflect"
@@ -8,6 +9,7 @@ import (
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -33,6 +35,7 @@ import (
 	"github.com/openshift/machine-config-operator/pkg/version"
 	"github.com/openshift/machine-config-operator/test/helpers"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	utilrand "k8s.io/apimachinery/pkg/util/rand"
 )
 
@@ -51,6 +54,7 @@ type fixture struct {
 	ccLister   []*mcfgv1.ControllerConfig
 	mcpLister  []*mcfgv1.MachineConfigPool
 	nodeLister []*corev1.Node
+	mcnoLister []*mcfgv1.MachineConfigNodeOverride
 
 	kubeactions []core.Action
 	actions     []core.Action
@@ -78,11 +82,12 @@ func (f *fixture) newController() *Controller {
 	k8sI := kubeinformers.NewSharedInformerFactory(f.kubeclient, noResyncPeriodFunc())
 	ci := configv1informer.NewSharedInformerFactory(f.schedulerClient, noResyncPeriodFunc())
 	c := New(i.Machineconfiguration().V1().ControllerConfigs(), i.Machineconfiguration().V1().MachineConfigPools(), k8sI.Core().V1().Nodes(),
-		ci.Config().V1().Schedulers(), f.kubeclient, f.client)
+		i.Machineconfiguration().V1().MachineConfigNodeOverrides(), ci.Config().V1().Schedulers(), f.kubeclient, f.client)
 
 	c.ccListerSynced = alwaysReady
 	c.mcpListerSynced = alwaysReady
 	c.nodeListerSynced = alwaysReady
+	c.mcnoListerSynced = alwaysReady
 	c.schedulerListerSynced = alwaysReady
 	c.eventRecorder = &record.FakeRecorder{}
 
@@ -103,6 +108,9 @@ func (f *fixture) newController() *Controller {
 	for _, m := range f.nodeLister {
 		k8sI.Core().V1().Nodes().Informer().GetIndexer().Add(m)
 	}
+	for _, o := range f.mcnoLister {
+		i.Machineconfiguration().V1().MachineConfigNodeOverrides().Informer().GetIndexer().Add(o)
+	}
 	for _, c := range f.schedulerLister {
 		ci.Config().V1().Schedulers().Informer().GetIndexer().Add(c)
 	}
@@ -215,7 +223,9 @@ func filterInformerActions(actions []core.Action) []core.Action {
 				action.Matches("list", "controllerconfigs") ||
 				action.Matches("watch", "controllerconfigs") ||
 				action.Matches("list", "nodes") ||
-				action.Matches("watch", "nodes")) {
+				action.Matches("watch", "nodes") ||
+				action.Matches("list", "machineconfignodeoverrides") ||
+				action.Matches("watch", "machineconfignodeoverrides")) {
 			continue
 		}
 		ret = append(ret, action)
@@ -512,6 +522,218 @@ func TestMaxUnavailable(t *testing.T) {
 	}
 }
 
+func newZoneNode(name, currentConfig, desiredConfig, zone string) *corev1.Node {
+	node := newNodeWithReady(name, currentConfig, desiredConfig, corev1.ConditionTrue)
+	node.Labels = map[string]string{}
+	if zone != "" {
+		node.Labels[corev1.LabelTopologyZone] = zone
+	}
+	return node
+}
+
+func TestMaxUnavailablePerZone(t *testing.T) {
+	nodes := []*corev1.Node{
+		newZoneNode("node-0", "v1", "v1", "a"),
+		newZoneNode("node-1", "v1", "v1", "a"),
+		newZoneNode("node-2", "v1", "v1", "a"),
+		newZoneNode("node-3", "v1", "v1", "a"),
+		newZoneNode("node-4", "v1", "v1", "b"),
+		newZoneNode("node-5", "v1", "v1", ""),
+	}
+	tests := []struct {
+		maxUnavail *intstr.IntOrString
+		expected   map[string]int
+		err        bool
+	}{{
+		maxUnavail: nil,
+		expected:   nil,
+	}, {
+		maxUnavail: intStrPtr(intstr.FromInt(2)),
+		expected:   map[string]int{"a": 2, "b": 2},
+	}, {
+		maxUnavail: intStrPtr(intstr.FromString("50%")),
+		expected:   map[string]int{"a": 2, "b": 1},
+	}, {
+		maxUnavail: intStrPtr(intstr.FromInt(0)),
+		expected:   map[string]int{"a": 1, "b": 1},
+	}, {
+		maxUnavail: intStrPtr(intstr.FromString("50 percent")),
+		err:        true,
+	}}
+
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("case#%d", idx), func(t *testing.T) {
+			pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+			pool.Spec.MaxUnavailablePerZone = test.maxUnavail
+			got, err := maxUnavailablePerZone(pool, nodes)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, got)
+		})
+	}
+}
+
+func TestLimitCandidatesPerZone(t *testing.T) {
+	tests := []struct {
+		nodes    []*corev1.Node
+		limits   map[string]int
+		expected []string
+	}{{
+		// no limits
+		nodes: []*corev1.Node{
+			newZoneNode("node-0", "v0", "v0", "a"),
+			newZoneNode("node-1", "v0", "v0", "a"),
+		},
+		limits:   nil,
+		expected: []string{"node-0", "node-1"},
+	}, {
+		// one node per zone
+		nodes: []*corev1.Node{
+			newZoneNode("node-0", "v0", "v0", "a"),
+			newZoneNode("node-1", "v0", "v0", "a"),
+			newZoneNode("node-2", "v0", "v0", "b"),
+			newZoneNode("node-3", "v0", "v0", ""),
+		},
+		limits:   map[string]int{"a": 1, "b": 1},
+		expected: []string{"node-0", "node-2", "node-3"},
+	}, {
+		// zone a already has an updating node
+		nodes: []*corev1.Node{
+			newZoneNode("node-0", "v0", "v1", "a"),
+			newZoneNode("node-1", "v0", "v0", "a"),
+			newZoneNode("node-2", "v0", "v0", "b"),
+		},
+		limits:   map[string]int{"a": 1, "b": 1},
+		expected: []string{"node-2"},
+	}, {
+		// an unready node is already unavailable
+		nodes: []*corev1.Node{
+			newZoneNode("node-0", "v0", "v0", "a"),
+			func() *corev1.Node {
+				node := newNodeWithReady("node-1", "v0", "v0", corev1.ConditionFalse)
+				node.Labels = map[string]string{corev1.LabelTopologyZone: "a"}
+				return node
+			}(),
+		},
+		limits:   map[string]int{"a": 1},
+		expected: []string{"node-1"},
+	}}
+
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("case#%d", idx), func(t *testing.T) {
+			var candidates []*corev1.Node
+			for _, node := range test.nodes {
+				if node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] != "v1" {
+					candidates = append(candidates, node)
+				}
+			}
+			var got []string
+			for _, node := range limitCandidatesPerZone(test.nodes, candidates, test.limits) {
+				got = append(got, node.Name)
+			}
+			assert.Equal(t, test.expected, got)
+		})
+	}
+}
+
+func TestOrderNodes(t *testing.T) {
+	newOrderNode := func(name, zone, rack string, age int) *corev1.Node {
+		node := newZoneNode(name, "v0", "v0", zone)
+		if rack != "" {
+			node.Labels["rack"] = rack
+		}
+		node.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Duration(age) * time.Hour))
+		return node
+	}
+	nodes := []*corev1.Node{
+		newOrderNode("node-c", "b", "", 3),
+		newOrderNode("node-a", "b", "r2", 1),
+		newOrderNode("node-d", "", "r1", 2),
+		newOrderNode("node-b", "a", "r1", 4),
+	}
+	tests := []struct {
+		order    *mcfgv1.MachineConfigPoolUpdateOrder
+		expected []string
+	}{{
+		order:    nil,
+		expected: []string{"node-a", "node-b", "node-c", "node-d"},
+	}, {
+		order:    &mcfgv1.MachineConfigPoolUpdateOrder{Strategy: mcfgv1.UpdateOrderCreationTime},
+		expected: []string{"node-b", "node-c", "node-d", "node-a"},
+	}, {
+		order:    &mcfgv1.MachineConfigPoolUpdateOrder{Strategy: mcfgv1.UpdateOrderZone},
+		expected: []string{"node-b", "node-a", "node-c", "node-d"},
+	}, {
+		order:    &mcfgv1.MachineConfigPoolUpdateOrder{Strategy: mcfgv1.UpdateOrderLabel, LabelKey: "rack"},
+		expected: []string{"node-b", "node-d", "node-a", "node-c"},
+	}}
+
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("case#%d", idx), func(t *testing.T) {
+			pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+			pool.Spec.UpdateOrder = test.order
+			require.NoError(t, validateUpdateOrder(pool))
+			var got []string
+			for _, node := range orderNodes(pool, nodes) {
+				got = append(got, node.Name)
+			}
+			assert.Equal(t, test.expected, got)
+		})
+	}
+
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	pool.Spec.UpdateOrder = &mcfgv1.MachineConfigPoolUpdateOrder{Strategy: mcfgv1.UpdateOrderLabel}
+	assert.Error(t, validateUpdateOrder(pool))
+}
+
+func TestRestrictToUpdatingZone(t *testing.T) {
+	tests := []struct {
+		nodes    []*corev1.Node
+		expected []string
+	}{{
+		// zone a first
+		nodes: []*corev1.Node{
+			newZoneNode("node-0", "v0", "v0", "b"),
+			newZoneNode("node-1", "v0", "v0", "a"),
+			newZoneNode("node-2", "v0", "v0", "a"),
+		},
+		expected: []string{"node-1", "node-2"},
+	}, {
+		// zone a is still updating
+		nodes: []*corev1.Node{
+			newZoneNode("node-0", "v0", "v0", "b"),
+			newZoneNode("node-1", "v1", "v1", "a"),
+			newZoneNode("node-2", "v0", "v1", "a"),
+		},
+		expected: nil,
+	}, {
+		// zone a is updated
+		nodes: []*corev1.Node{
+			newZoneNode("node-0", "v0", "v0", "b"),
+			newZoneNode("node-1", "v1", "v1", "a"),
+			newZoneNode("node-2", "v1", "v1", "a"),
+			newZoneNode("node-3", "v0", "v0", ""),
+		},
+		expected: []string{"node-0"},
+	}}
+
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("case#%d", idx), func(t *testing.T) {
+			pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+			pool.Spec.UpdateOrder = &mcfgv1.MachineConfigPoolUpdateOrder{Strategy: mcfgv1.UpdateOrderZone}
+			candidates, _ := getAllCandidateMachines(pool, test.nodes, len(test.nodes))
+			var got []string
+			for _, node := range restrictToUpdatingZone(pool, test.nodes, orderNodes(pool, candidates)) {
+				got = append(got, node.Name)
+			}
+			assert.Equal(t, test.expected, got)
+		})
+	}
+}
+
 func TestGetCandidateMachines(t *testing.T) {
 	tests := []struct {
 		nodes    []*corev1.Node
@@ -643,6 +865,22 @@ func TestGetCandidateMachines(t *testing.T) {
 		expected:        []string{"node-3", "node-4"},
 		otherCandidates: []string{"node-5", "node-6"},
 		capacity:        2,
+	}, {
+		// A node recovering its kubelet is unavailable and not updated
+		progress: 2,
+		nodes: []*corev1.Node{
+			newNodeWithReady("node-0", "v1", "v1", corev1.ConditionTrue),
+			newNodeWithAnnotations("node-1", map[string]string{
+				daemonconsts.CurrentMachineConfigAnnotationKey: "v0",
+				daemonconsts.DesiredMachineConfigAnnotationKey: "v0",
+				daemonconsts.KubeletCertRecoveryAnnotationKey:  daemonconsts.KubeletCertRecoveryInProgress,
+			}),
+			newNodeWithReady("node-2", "v0", "v0", corev1.ConditionTrue),
+			newNodeWithReady("node-3", "v0", "v0", corev1.ConditionTrue),
+		},
+		expected:        []string{"node-2"},
+		otherCandidates: []string{"node-3"},
+		capacity:        1,
 	}}
 
 	for idx, test := range tests {
@@ -675,6 +913,134 @@ func TestGetCandidateMachines(t *testing.T) {
 	}
 }
 
+func TestGetAllRebootCandidateMachines(t *testing.T) {
+	requested := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	request := requested.Format(time.RFC3339)
+	newRebootNode := func(name, current, desired string, created time.Time) *corev1.Node {
+		node := newNodeWithReady(name, current, desired, corev1.ConditionTrue)
+		node.CreationTimestamp = metav1.NewTime(created)
+		return node
+	}
+	before := requested.Add(-time.Hour)
+	after := requested.Add(time.Hour)
+
+	tests := []struct {
+		request  string
+		nodes    []*corev1.Node
+		progress int
+		expected []string
+		capacity uint
+		err      bool
+	}{{
+		// no reboot requested
+		nodes:    []*corev1.Node{newRebootNode("node-0", "v1", "v1", before)},
+		progress: 1,
+	}, {
+		request:  "yesterday",
+		nodes:    []*corev1.Node{newRebootNode("node-0", "v1", "v1", before)},
+		progress: 1,
+		err:      true,
+	}, {
+		// the config update goes first
+		request: request,
+		nodes: []*corev1.Node{
+			newRebootNode("node-0", "v1", "v1", before),
+			newRebootNode("node-1", "v0", "v1", before),
+		},
+		progress: 2,
+	}, {
+		request: request,
+		nodes: []*corev1.Node{
+			newRebootNode("node-0", "v1", "v1", before),
+			newRebootNode("node-1", "v1", "v1", before),
+			newRebootNode("node-2", "v1", "v1", before),
+		},
+		progress: 1,
+		expected: []string{"node-0", "node-1", "node-2"},
+		capacity: 1,
+	}, {
+		// node-0 is rebooting, node-2 joined after the request
+		request: request,
+		nodes: []*corev1.Node{
+			func() *corev1.Node {
+				node := newRebootNode("node-0", "v1", "v1", before)
+				node.Annotations[daemonconsts.DesiredRebootAnnotationKey] = request
+				return node
+			}(),
+			newRebootNode("node-1", "v1", "v1", before),
+			newRebootNode("node-2", "v1", "v1", after),
+		},
+		progress: 2,
+		expected: []string{"node-1"},
+		capacity: 1,
+	}, {
+		// node-0 rebooted, no capacity left while node-1 reboots
+		request: request,
+		nodes: []*corev1.Node{
+			func() *corev1.Node {
+				node := newRebootNode("node-0", "v1", "v1", before)
+				node.Annotations[daemonconsts.DesiredRebootAnnotationKey] = request
+				node.Annotations[daemonconsts.CurrentRebootAnnotationKey] = request
+				return node
+			}(),
+			func() *corev1.Node {
+				node := newRebootNode("node-1", "v1", "v1", before)
+				node.Annotations[daemonconsts.DesiredRebootAnnotationKey] = request
+				return node
+			}(),
+			newRebootNode("node-2", "v1", "v1", before),
+		},
+		progress: 1,
+	}}
+
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("case#%d", idx), func(t *testing.T) {
+			pool := &mcfgv1.MachineConfigPool{
+				Spec: mcfgv1.MachineConfigPoolSpec{
+					Configuration: mcfgv1.MachineConfigPoolStatusConfiguration{ObjectReference: corev1.ObjectReference{Name: "v1"}},
+				},
+			}
+			if test.request != "" {
+				pool.Annotations = map[string]string{daemonconsts.RebootRequestedAnnotationKey: test.request}
+			}
+
+			got, capacity, err := getAllRebootCandidateMachines(pool, test.nodes, test.progress)
+			if test.err {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			var nodeNames []string
+			for _, node := range got {
+				nodeNames = append(nodeNames, node.Name)
+			}
+			assert.Equal(t, test.expected, nodeNames)
+			assert.Equal(t, test.capacity, capacity)
+		})
+	}
+}
+
+func TestIsRebootRequestCompleted(t *testing.T) {
+	request := "2021-03-01T12:00:00Z"
+	rebooted := func(name string) *corev1.Node {
+		node := newNodeWithReady(name, "v1", "v1", corev1.ConditionTrue)
+		node.Annotations[daemonconsts.DesiredRebootAnnotationKey] = request
+		node.Annotations[daemonconsts.CurrentRebootAnnotationKey] = request
+		return node
+	}
+	joined := newNodeWithReady("node-2", "v1", "v1", corev1.ConditionTrue)
+	joined.CreationTimestamp = metav1.NewTime(time.Date(2021, 3, 2, 0, 0, 0, 0, time.UTC))
+	pool := &mcfgv1.MachineConfigPool{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{daemonconsts.RebootRequestedAnnotationKey: request}},
+	}
+
+	assert.True(t, isRebootRequestCompleted(pool, []*corev1.Node{rebooted("node-0"), rebooted("node-1"), joined}))
+	assert.False(t, isRebootRequestCompleted(pool, []*corev1.Node{rebooted("node-0"), newNodeWithReady("node-1", "v1", "v1", corev1.ConditionTrue)}))
+
+	pool.Annotations[daemonconsts.RebootCompletedAnnotationKey] = request
+	assert.False(t, isRebootRequestCompleted(pool, []*corev1.Node{rebooted("node-0"), rebooted("node-1")}))
+}
+
 func assertPatchesNode0ToV1(t *testing.T, actions []core.Action) {
 	if !assert.Equal(t, 2, len(actions)) {
 		t.Fatal("actions")
@@ -782,6 +1148,26 @@ func TestSetDesiredMachineConfigAnnotation(t *testing.T) {
 	}
 }
 
+func TestSetGracefulRebootAnnotations(t *testing.T) {
+	f := newFixture(t)
+	node := newNode("node-0", "v0", "v0")
+	f.nodeLister = append(f.nodeLister, node)
+	f.kubeobjects = append(f.kubeobjects, node)
+	c := f.newController()
+
+	// nothing to set without a graceful reboot
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	require.Nil(t, c.setGracefulRebootAnnotations(pool, node))
+	assert.Empty(t, filterInformerActions(f.kubeclient.Actions()))
+
+	pool.Spec.GracefulReboot = &mcfgv1.MachineConfigPoolGracefulReboot{Delay: metav1.Duration{Duration: 10 * time.Minute}, WallMessage: "Rebooting"}
+	require.Nil(t, c.setGracefulRebootAnnotations(pool, node))
+	updated, err := f.kubeclient.CoreV1().Nodes().Get(context.TODO(), node.Name, metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Equal(t, "10m0s", updated.Annotations[daemonconsts.RebootDelayAnnotationKey])
+	assert.Equal(t, "Rebooting", updated.Annotations[daemonconsts.RebootWallMessageAnnotationKey])
+}
+
 func TestShouldMakeProgress(t *testing.T) {
 	f := newFixture(t)
 	cc := newControllerConfig(ctrlcommon.ControllerConfigName, configv1.TopologyMode(""))
@@ -1003,3 +1389,467 @@ func filterLastTransitionTime(obj runtime.Object) runtime.Object {
 	}
 	return o
 }
+
+func TestApplyPoolNodeLabelsAndTaints(t *testing.T) {
+	pool := helpers.NewMachineConfigPool("infra", nil, helpers.InfraSelector, "v1")
+	pool.Spec.NodeLabels = map[string]string{"node-role.kubernetes.io/infra": "", "team": "a"}
+	pool.Spec.NodeTaints = []corev1.Taint{{Key: "node-role.kubernetes.io/infra", Effect: corev1.TaintEffectNoSchedule}}
+
+	node := newNodeWithLabel("node-0", "v1", "v1", map[string]string{"node-role/infra": "", "team": "b"})
+	node.Spec.Taints = []corev1.Taint{{Key: "other", Effect: corev1.TaintEffectNoExecute}}
+
+	assert.True(t, applyPoolNodeLabelsAndTaints(pool, node))
+	assert.Equal(t, map[string]string{"node-role/infra": "", "node-role.kubernetes.io/infra": "", "team": "a"}, node.Labels)
+	assert.Equal(t, []corev1.Taint{
+		{Key: "other", Effect: corev1.TaintEffectNoExecute},
+		{Key: "node-role.kubernetes.io/infra", Effect: corev1.TaintEffectNoSchedule},
+	}, node.Spec.Taints)
+	assert.Equal(t, "node-role.kubernetes.io/infra,team", node.Annotations[poolNodeLabelsAnnotationKey])
+	assert.Equal(t, "node-role.kubernetes.io/infra:NoSchedule", node.Annotations[poolNodeTaintsAnnotationKey])
+
+	// applying again is a no-op
+	assert.False(t, applyPoolNodeLabelsAndTaints(pool, node))
+
+	// labels and taints dropped from the pool are removed, the ones set by others are kept
+	pool.Spec.NodeLabels = map[string]string{"node-role.kubernetes.io/infra": ""}
+	pool.Spec.NodeTaints = nil
+	assert.True(t, applyPoolNodeLabelsAndTaints(pool, node))
+	assert.Equal(t, map[string]string{"node-role/infra": "", "node-role.kubernetes.io/infra": ""}, node.Labels)
+	assert.Equal(t, []corev1.Taint{{Key: "other", Effect: corev1.TaintEffectNoExecute}}, node.Spec.Taints)
+	assert.NotContains(t, node.Annotations, poolNodeTaintsAnnotationKey)
+}
+
+func TestSetPoolNodeLabelsAndTaints(t *testing.T) {
+	f := newFixture(t)
+	cc := newControllerConfig(ctrlcommon.ControllerConfigName, configv1.TopologyMode(""))
+	mcp := helpers.NewMachineConfigPool("test-cluster-infra", nil, helpers.InfraSelector, "v1")
+	mcpWorker := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	mcp.Spec.MaxUnavailable = intStrPtr(intstr.FromInt(1))
+	mcp.Spec.NodeTaints = []corev1.Taint{{Key: "infra", Effect: corev1.TaintEffectNoSchedule}}
+	nodes := []*corev1.Node{
+		newNodeWithLabel("node-0", "v1", "v1", map[string]string{"node-role/worker": "", "node-role/infra": ""}),
+		// still updating, tainted once done
+		newNodeWithLabel("node-1", "v0", "v1", map[string]string{"node-role/worker": "", "node-role/infra": ""}),
+	}
+	status := calculateStatus(mcp, nodes)
+	mcp.Status = status
+
+	f.ccLister = append(f.ccLister, cc)
+	f.mcpLister = append(f.mcpLister, mcp, mcpWorker)
+	f.objects = append(f.objects, mcp, mcpWorker)
+	f.nodeLister = append(f.nodeLister, nodes...)
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+
+	expNode := nodes[0].DeepCopy()
+	applyPoolNodeLabelsAndTaints(mcp, expNode)
+	oldData, err := json.Marshal(nodes[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	newData, err := json.Marshal(expNode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exppatch, err := strategicpatch.CreateTwoWayMergePatch(oldData, newData, corev1.Node{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.expectPatchNodeAction(expNode, exppatch)
+
+	f.run(getKey(mcp, t))
+}
+
+func TestNodeTargetConfig(t *testing.T) {
+	overridden := func(configs string) *corev1.Node {
+		node := newNode("node-0", "v1", "v1")
+		node.Annotations[nodeOverrideConfigsAnnotationKey] = configs
+		return node
+	}
+	for _, test := range []struct {
+		node     *corev1.Node
+		expected string
+	}{
+		{newNode("node-0", "v1", "v1"), "v2"},
+		{overridden(`{"v1": "v1-node-0", "v2": "v2-node-0"}`), "v2-node-0"},
+		// not rendered yet
+		{overridden(`{"v1": "v1-node-0"}`), ""},
+		{overridden(`{}`), ""},
+		{overridden(`invalid`), ""},
+	} {
+		assert.Equal(t, test.expected, nodeTargetConfig("v2", test.node), test.node.Annotations[nodeOverrideConfigsAnnotationKey])
+	}
+}
+
+func TestSyncNodeOverrides(t *testing.T) {
+	f := newFixture(t)
+	mcp := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	nodes := []*corev1.Node{
+		newNode("node-0", "v1", "v1"),
+		newNode("node-1", "v1", "v1"),
+		newNode("node-2", "v1", "v1"),
+	}
+	nodes[2].Annotations[nodeOverrideConfigsAnnotationKey] = `{"v1": "v1-node-2"}`
+	newOverride := func(name, nodeName, poolName string) *mcfgv1.MachineConfigNodeOverride {
+		return &mcfgv1.MachineConfigNodeOverride{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       mcfgv1.MachineConfigNodeOverrideSpec{NodeName: nodeName},
+			Status: mcfgv1.MachineConfigNodeOverrideStatus{
+				Pool:           poolName,
+				Configurations: []mcfgv1.MachineConfigNodeOverrideConfiguration{{PoolConfig: "v1", NodeConfig: "v1-" + nodeName}},
+			},
+		}
+	}
+	// node-0 just joined the pool
+	moved := newOverride("node-0", "node-0", "infra")
+	rendered := newOverride("node-1", "node-1", "worker")
+	// the first override of a node by name wins
+	duplicate := newOverride("zz-node-1", "node-1", "worker")
+	duplicate.Status.Configurations[0].NodeConfig = "v1-other"
+
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp, moved, rendered, duplicate)
+	f.mcnoLister = append(f.mcnoLister, moved, rendered, duplicate)
+	f.nodeLister = append(f.nodeLister, nodes...)
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+	c := f.newController()
+
+	synced, err := c.syncNodeOverrides(mcp, nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, `{}`, synced[0].Annotations[nodeOverrideConfigsAnnotationKey])
+	assert.Equal(t, `{"v1":"v1-node-1"}`, synced[1].Annotations[nodeOverrideConfigsAnnotationKey])
+	assert.NotContains(t, synced[2].Annotations, nodeOverrideConfigsAnnotationKey)
+
+	override, err := f.client.MachineconfigurationV1().MachineConfigNodeOverrides().Get(context.TODO(), "node-0", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "worker", override.Status.Pool)
+	assert.Empty(t, override.Status.Configurations)
+
+	// node-0 is held until its override is rendered, node-1 moves to its own config
+	mcp.Spec.Configuration.Name = "v1"
+	candidates, _ := getAllCandidateMachines(mcp, synced, 3)
+	if assert.Len(t, candidates, 1) {
+		assert.Equal(t, "node-1", candidates[0].Name)
+	}
+	assert.Equal(t, []*corev1.Node{synced[2]}, getUpdatedMachines("v1", synced))
+}
+
+func TestAddUpdateDurations(t *testing.T) {
+	now := time.Now()
+	stats := addUpdateDurations(nil, []time.Duration{90 * time.Second, 150*time.Second + 400*time.Millisecond}, now)
+	assert.Equal(t, []metav1.Duration{{Duration: 90 * time.Second}, {Duration: 150 * time.Second}}, stats.RecentDurations)
+	assert.Equal(t, 120*time.Second, stats.AverageDuration.Duration)
+	assert.Equal(t, 150*time.Second, stats.MaxDuration.Duration)
+	assert.Equal(t, metav1.NewTime(now).Rfc3339Copy(), stats.LastUpdateTime)
+
+	// only the most recent durations are kept
+	durations := make([]time.Duration, maxRecentUpdateDurations)
+	for i := range durations {
+		durations[i] = time.Minute
+	}
+	stats = addUpdateDurations(stats, durations, now)
+	assert.Len(t, stats.RecentDurations, maxRecentUpdateDurations)
+	assert.Equal(t, time.Minute, stats.AverageDuration.Duration)
+	assert.Equal(t, time.Minute, stats.MaxDuration.Duration)
+}
+
+func TestEstimateCompletionTime(t *testing.T) {
+	mcp := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	nodes := []*corev1.Node{
+		newNode("node-0", "v1", "v1"),
+		newNode("node-1", "v0", "v1"),
+		newNode("node-2", "v0", "v0"),
+	}
+	assert.Nil(t, estimateCompletionTime(mcp, nodes, 1), "no estimate without update statistics")
+
+	mcp.Status.UpdateStatistics = addUpdateDurations(nil, []time.Duration{10 * time.Minute}, time.Now())
+	estimate := estimateCompletionTime(mcp, nodes, 1)
+	if assert.NotNil(t, estimate) {
+		// two nodes left, one at a time
+		assert.WithinDuration(t, time.Now().Add(20*time.Minute), estimate.Time, time.Minute)
+	}
+	assert.Nil(t, estimateCompletionTime(mcp, nodes, 3), "no estimate once all nodes are updated")
+
+	// the estimate is kept until a node completes its update
+	mcp.Status.EstimatedCompletionTime = &metav1.Time{Time: time.Now().Add(time.Hour)}
+	mcp.Status.UpdatedMachineCount = 1
+	mcp.Status.MachineCount = 3
+	assert.Equal(t, mcp.Status.EstimatedCompletionTime, estimateCompletionTime(mcp, nodes, 1))
+	estimate = estimateCompletionTime(mcp, nodes, 2)
+	if assert.NotNil(t, estimate) {
+		assert.WithinDuration(t, time.Now().Add(10*time.Minute), estimate.Time, time.Minute)
+	}
+
+	mcp.Spec.Paused = true
+	assert.Nil(t, estimateCompletionTime(mcp, nodes, 1), "no estimate while the pool is paused")
+}
+
+func TestRecordUpdateDurations(t *testing.T) {
+	f := newFixture(t)
+	mcp := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	nodes := []*corev1.Node{
+		newNode("node-0", "v1", "v1"),
+		newNode("node-1", "v0", "v1"),
+		newNode("node-2", "v1", "v1"),
+	}
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	c := f.newController()
+
+	c.trackUpdateStart("node-0", "v1")
+	c.trackUpdateStart("node-1", "v1")
+	// node-2 was already at v1 when the controller started
+	if err := c.recordUpdateDurations(mcp, nodes); err != nil {
+		t.Fatal(err)
+	}
+	if assert.NotNil(t, mcp.Status.UpdateStatistics) {
+		assert.Len(t, mcp.Status.UpdateStatistics.RecentDurations, 1)
+	}
+	assert.NotContains(t, c.updateStarts, "node-0")
+	assert.Contains(t, c.updateStarts, "node-1")
+
+	// nothing new completed, the pool isn't updated
+	f.client.ClearActions()
+	if err := c.recordUpdateDurations(mcp, nodes); err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, filterInformerActions(f.client.Actions()))
+}
+
+func TestGetImagePullCandidates(t *testing.T) {
+	newPullingNode := func(name, currentConfig, desiredPull, currentPull string) *corev1.Node {
+		node := newNodeWithReady(name, currentConfig, currentConfig, corev1.ConditionTrue)
+		if desiredPull != "" {
+			node.Annotations[daemonconsts.DesiredImagePullAnnotationKey] = desiredPull
+		}
+		if currentPull != "" {
+			node.Annotations[daemonconsts.CurrentImagePullAnnotationKey] = currentPull
+		}
+		return node
+	}
+
+	mcp := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	nodes := []*corev1.Node{
+		newPullingNode("node-0", "v0", "", ""),
+		newPullingNode("node-1", "v0", "v1", "v1"),
+		newPullingNode("node-2", "v1", "", ""),
+		newNodeWithReady("node-3", "v0", "v1", corev1.ConditionTrue),
+		newPullingNode("node-4", "v0", "v0", "v0"),
+	}
+	candidates, capacity, err := getImagePullCandidates(mcp, nodes)
+	assert.Nil(t, err)
+	assert.Empty(t, candidates, "pre-pulling is disabled without maxConcurrentImagePulls")
+	assert.Zero(t, capacity)
+
+	mcp.Spec.MaxConcurrentImagePulls = intStrPtr(intstr.FromInt(2))
+	candidates, capacity, err = getImagePullCandidates(mcp, nodes)
+	assert.Nil(t, err)
+	// node-1 already pulled v1, node-2 is updated and node-3 is updating
+	assert.Equal(t, []*corev1.Node{nodes[0], nodes[4]}, candidates)
+	assert.Equal(t, uint(2), capacity)
+
+	// node-1 still pulling takes one of the pulls
+	nodes[1] = newPullingNode("node-1", "v0", "v1", "v0")
+	candidates, capacity, err = getImagePullCandidates(mcp, nodes)
+	assert.Nil(t, err)
+	assert.Equal(t, []*corev1.Node{nodes[0], nodes[4]}, candidates)
+	assert.Equal(t, uint(1), capacity)
+
+	mcp.Spec.MaxConcurrentImagePulls = intStrPtr(intstr.FromString("10%"))
+	candidates, capacity, err = getImagePullCandidates(mcp, nodes)
+	assert.Nil(t, err)
+	assert.Empty(t, candidates)
+	assert.Zero(t, capacity)
+}
+
+func TestSyncDrainWatchdog(t *testing.T) {
+	f := newFixture(t)
+	mcp := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	mcp.Spec.DrainWatchdog = &mcfgv1.MachineConfigPoolDrainWatchdog{
+		Timeout:    &metav1.Duration{Duration: 10 * time.Minute},
+		Escalation: mcfgv1.DrainEscalationSkipNode,
+	}
+	stuckStart := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	nodes := []*corev1.Node{
+		newNodeWithReady("node-0", "v0", "v1", corev1.ConditionTrue),
+		newNodeWithReady("node-1", "v0", "v1", corev1.ConditionTrue),
+		newNodeWithReady("node-2", "v0", "v0", corev1.ConditionTrue),
+	}
+	nodes[0].Annotations[daemonconsts.DrainStartedAnnotationKey] = stuckStart.Format(time.RFC3339)
+	nodes[1].Annotations[daemonconsts.DrainStartedAnnotationKey] = time.Now().UTC().Format(time.RFC3339)
+	blocking := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-0", Namespace: "db", Labels: map[string]string{"app": "db"}},
+		Spec:       corev1.PodSpec{NodeName: "node-0"},
+	}
+	daemonSetPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "agent-0", Namespace: "db",
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(&metav1.ObjectMeta{Name: "agent"}, schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "DaemonSet"})},
+		},
+		Spec: corev1.PodSpec{NodeName: "node-0"},
+	}
+	pdb := &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "db"},
+		Spec:       policyv1beta1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}}},
+	}
+
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.nodeLister = append(f.nodeLister, nodes...)
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+	f.kubeobjects = append(f.kubeobjects, blocking, daemonSetPod, pdb)
+	c := f.newController()
+
+	if err := c.syncDrainWatchdog(mcp, nodes); err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, mcp.Status.StuckDrains, 1) {
+		stuck := mcp.Status.StuckDrains[0]
+		assert.Equal(t, "node-0", stuck.Node)
+		assert.True(t, stuck.StartTime.Time.Equal(stuckStart))
+		assert.Equal(t, []string{"db/db-0"}, stuck.BlockingPods)
+		assert.Equal(t, []string{"db/db"}, stuck.BlockingPodDisruptionBudgets)
+		assert.Equal(t, mcfgv1.DrainEscalationSkipNode, stuck.Escalation)
+	}
+
+	// node-0 is moved back to its config and isn't updated again
+	skipped, err := f.kubeclient.CoreV1().Nodes().Get(context.TODO(), "node-0", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "v0", skipped.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey])
+	assert.Equal(t, "v1", skipped.Annotations[daemonconsts.DrainSkippedAnnotationKey])
+	assert.NotContains(t, skipped.Annotations, daemonconsts.DrainStartedAnnotationKey)
+	skipped.Annotations[daemonconsts.MachineConfigDaemonStateAnnotationKey] = daemonconsts.MachineConfigDaemonStateDone
+	candidates, _ := getAllCandidateMachines(mcp, []*corev1.Node{skipped, nodes[2]}, 2)
+	assert.Equal(t, []*corev1.Node{nodes[2]}, candidates)
+
+	// the skipped node stays listed
+	if err := c.syncDrainWatchdog(mcp, []*corev1.Node{skipped, nodes[1], nodes[2]}); err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, mcp.Status.StuckDrains, 1)
+}
+
+func TestOrderRetriedDrainsLast(t *testing.T) {
+	f := newFixture(t)
+	c := f.newController()
+	mcp := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v2")
+	nodes := []*corev1.Node{
+		newNode("node-0", "v1", "v1"),
+		newNode("node-1", "v1", "v1"),
+		newNode("node-2", "v1", "v1"),
+	}
+	c.drainRetries["node-0"] = "v2"
+	// retried for a previous config
+	c.drainRetries["node-1"] = "v1"
+	assert.Equal(t, []*corev1.Node{nodes[1], nodes[2], nodes[0]}, c.orderRetriedDrainsLast(mcp, nodes))
+}
+
+func TestMaintenanceWindowOpen(t *testing.T) {
+	// a Wednesday
+	now := time.Date(2021, time.March, 3, 23, 30, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name   string
+		window *mcfgv1.MachineConfigPoolMaintenanceWindow
+		open   bool
+		until  time.Duration
+		err    bool
+	}{
+		{name: "no window", open: true},
+		{
+			name:   "open",
+			window: &mcfgv1.MachineConfigPoolMaintenanceWindow{StartTime: "23:00", Duration: metav1.Duration{Duration: time.Hour}},
+			open:   true,
+		},
+		{
+			name:   "opened the day before",
+			window: &mcfgv1.MachineConfigPoolMaintenanceWindow{StartTime: "22:00", Duration: metav1.Duration{Duration: 26 * time.Hour}, Days: []string{"Tuesday"}},
+			open:   true,
+		},
+		{
+			name:   "closed until tomorrow",
+			window: &mcfgv1.MachineConfigPoolMaintenanceWindow{StartTime: "02:00", Duration: metav1.Duration{Duration: 2 * time.Hour}},
+			until:  150 * time.Minute,
+		},
+		{
+			name:   "closed until saturday",
+			window: &mcfgv1.MachineConfigPoolMaintenanceWindow{StartTime: "23:00", Duration: metav1.Duration{Duration: time.Hour}, Days: []string{"Saturday", "Sunday"}},
+			until:  71*time.Hour + 30*time.Minute,
+		},
+		{
+			name:   "invalid start time",
+			window: &mcfgv1.MachineConfigPoolMaintenanceWindow{StartTime: "11pm", Duration: metav1.Duration{Duration: time.Hour}},
+			err:    true,
+		},
+		{
+			name:   "invalid day",
+			window: &mcfgv1.MachineConfigPoolMaintenanceWindow{StartTime: "23:00", Duration: metav1.Duration{Duration: time.Hour}, Days: []string{"Caturday"}},
+			err:    true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			open, until, err := maintenanceWindowOpen(tc.window, now)
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, tc.open, open)
+			assert.Equal(t, tc.until, until)
+		})
+	}
+}
+
+func TestMaintenanceWindowHoldsUpdates(t *testing.T) {
+	f := newFixture(t)
+	cc := newControllerConfig(ctrlcommon.ControllerConfigName, configv1.TopologyMode(""))
+	mcp := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	opens := time.Now().UTC().Add(2 * time.Hour)
+	mcp.Spec.MaintenanceWindow = &mcfgv1.MachineConfigPoolMaintenanceWindow{StartTime: opens.Format("15:04"), Duration: metav1.Duration{Duration: time.Hour}}
+	nodes := []*corev1.Node{
+		newNodeWithLabel("node-0", "v0", "v0", map[string]string{"node-role/worker": ""}),
+	}
+
+	f.ccLister = append(f.ccLister, cc)
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.nodeLister = append(f.nodeLister, nodes...)
+	f.kubeobjects = append(f.kubeobjects, nodes[0])
+
+	// the node isn't targeted at v1 until the window opens
+	expMcp := mcp.DeepCopy()
+	expMcp.Status = calculateStatus(mcp, nodes)
+	f.expectUpdateMachineConfigPoolStatus(expMcp)
+
+	f.run(getKey(mcp, t))
+}
+
+func TestMaintenanceWindowCondition(t *testing.T) {
+	mcp := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	nodes := []*corev1.Node{
+		newNodeWithLabel("node-0", "v0", "v0", map[string]string{"node-role/worker": ""}),
+	}
+
+	mcp.Spec.MaintenanceWindow = &mcfgv1.MachineConfigPoolMaintenanceWindow{StartTime: "02:00"}
+	status := calculateStatus(mcp, nodes)
+	cond := mcfgv1.GetMachineConfigPoolCondition(status, mcfgv1.MachineConfigPoolMaintenanceWindowInvalid)
+	require.NotNil(t, cond)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Equal(t, "Not updating the nodes of the pool: invalid duration 0s", cond.Message)
+
+	// the condition goes away once the window is fixed
+	mcp.Status = status
+	mcp.Spec.MaintenanceWindow.Duration = metav1.Duration{Duration: time.Hour}
+	status = calculateStatus(mcp, nodes)
+	assert.Nil(t, mcfgv1.GetMachineConfigPoolCondition(status, mcfgv1.MachineConfigPoolMaintenanceWindowInvalid))
+}
@@ -0,0 +1,177 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+	clientretry "k8s.io/client-go/util/retry"
+
+	"github.com/openshift/machine-config-operator/internal"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+func (ctrl *Controller) addMachineConfigNodeOverride(obj interface{}) {
+	override := obj.(*mcfgv1.MachineConfigNodeOverride)
+	glog.V(4).Infof("MachineConfigNodeOverride %s added", override.Name)
+	ctrl.enqueueNodeOverridePool(override.Spec.NodeName)
+}
+
+func (ctrl *Controller) updateMachineConfigNodeOverride(old, cur interface{}) {
+	oldOverride := old.(*mcfgv1.MachineConfigNodeOverride)
+	curOverride := cur.(*mcfgv1.MachineConfigNodeOverride)
+	glog.V(4).Infof("MachineConfigNodeOverride %s updated", curOverride.Name)
+	if oldOverride.Spec.NodeName != curOverride.Spec.NodeName {
+		ctrl.enqueueNodeOverridePool(oldOverride.Spec.NodeName)
+	}
+	ctrl.enqueueNodeOverridePool(curOverride.Spec.NodeName)
+}
+
+func (ctrl *Controller) deleteMachineConfigNodeOverride(obj interface{}) {
+	override, ok := obj.(*mcfgv1.MachineConfigNodeOverride)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Couldn't get object from tombstone %#v", obj))
+			return
+		}
+		override, ok = tombstone.Obj.(*mcfgv1.MachineConfigNodeOverride)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Tombstone contained object that is not a MachineConfigNodeOverride %#v", obj))
+			return
+		}
+	}
+	glog.V(4).Infof("MachineConfigNodeOverride %s deleted", override.Name)
+	ctrl.enqueueNodeOverridePool(override.Spec.NodeName)
+}
+
+// enqueueNodeOverridePool enqueues the pool of the node a MachineConfigNodeOverride applies to
+func (ctrl *Controller) enqueueNodeOverridePool(nodeName string) {
+	node, err := ctrl.nodeLister.Get(nodeName)
+	if err != nil {
+		glog.V(4).Infof("Couldn't get node %s of MachineConfigNodeOverride: %v", nodeName, err)
+		return
+	}
+	pool, err := ctrl.getPrimaryPoolForNode(node)
+	if err != nil {
+		glog.Errorf("error finding pool for node: %v", err)
+		return
+	}
+	if pool == nil {
+		return
+	}
+	ctrl.enqueueMachineConfigPool(pool)
+}
+
+// syncNodeOverrides records the pool of the nodes with a MachineConfigNodeOverride in its status,
+// for the render controller to render it on top of the configs of the pool, and annotates the
+// nodes with the configs it rendered. It returns the nodes as annotated.
+func (ctrl *Controller) syncNodeOverrides(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) ([]*corev1.Node, error) {
+	overrides, err := ctrl.mcnoLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	// the first by name wins when several target the same node
+	sort.Slice(overrides, func(i, j int) bool { return overrides[i].Name < overrides[j].Name })
+	nodeOverrides := map[string]*mcfgv1.MachineConfigNodeOverride{}
+	for _, override := range overrides {
+		if previous, ok := nodeOverrides[override.Spec.NodeName]; ok {
+			glog.Warningf("Ignoring MachineConfigNodeOverride %s: node %s already has %s", override.Name, override.Spec.NodeName, previous.Name)
+			continue
+		}
+		nodeOverrides[override.Spec.NodeName] = override
+	}
+
+	synced := make([]*corev1.Node, len(nodes))
+	for i, node := range nodes {
+		synced[i] = node
+		override, ok := nodeOverrides[node.Name]
+		var configurations []mcfgv1.MachineConfigNodeOverrideConfiguration
+		if ok {
+			if override.Status.Pool != pool.Name {
+				if err := ctrl.setNodeOverridePool(override.Name, pool.Name); err != nil {
+					return nil, err
+				}
+				ctrl.logPoolNode(pool, node, "Rendering MachineConfigNodeOverride %s", override.Name)
+			} else {
+				configurations = override.Status.Configurations
+			}
+		}
+
+		value, err := nodeOverrideConfigsAnnotation(configurations)
+		if err != nil {
+			return nil, err
+		}
+		current, annotated := node.Annotations[nodeOverrideConfigsAnnotationKey]
+		if annotated == ok && (!ok || current == value) {
+			continue
+		}
+		updated, err := internal.UpdateNodeRetry(ctrl.kubeClient.CoreV1().Nodes(), ctrl.nodeLister, node.Name, func(node *corev1.Node) {
+			if !ok {
+				delete(node.Annotations, nodeOverrideConfigsAnnotationKey)
+				return
+			}
+			if node.Annotations == nil {
+				node.Annotations = map[string]string{}
+			}
+			node.Annotations[nodeOverrideConfigsAnnotationKey] = value
+		})
+		if err != nil {
+			return nil, err
+		}
+		synced[i] = updated
+	}
+	return synced, nil
+}
+
+// setNodeOverridePool records the pool the node of the override is in. The configs rendered for
+// another pool don't apply any more.
+func (ctrl *Controller) setNodeOverridePool(name, pool string) error {
+	return clientretry.RetryOnConflict(clientretry.DefaultRetry, func() error {
+		override, err := ctrl.client.MachineconfigurationV1().MachineConfigNodeOverrides().Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		override.Status.Pool = pool
+		override.Status.Configurations = nil
+		_, err = ctrl.client.MachineconfigurationV1().MachineConfigNodeOverrides().UpdateStatus(context.TODO(), override, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// nodeOverrideConfigsAnnotation returns the value of the nodeOverrideConfigsAnnotationKey
+// annotation for the configurations of an override
+func nodeOverrideConfigsAnnotation(configurations []mcfgv1.MachineConfigNodeOverrideConfiguration) (string, error) {
+	configs := map[string]string{}
+	for _, configuration := range configurations {
+		configs[configuration.PoolConfig] = configuration.NodeConfig
+	}
+	value, err := json.Marshal(configs)
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+// nodeTargetConfig returns the config the node should have when its pool targets poolTargetConfig:
+// the config rendered from it with the MachineConfigNodeOverride of the node, if it has one.
+// It returns "" while that config isn't rendered yet, holding the node at its current config.
+func nodeTargetConfig(poolTargetConfig string, node *corev1.Node) string {
+	value, ok := node.Annotations[nodeOverrideConfigsAnnotationKey]
+	if !ok {
+		return poolTargetConfig
+	}
+	configs := map[string]string{}
+	if err := json.Unmarshal([]byte(value), &configs); err != nil {
+		glog.Warningf("Ignoring invalid %s annotation of node %s: %v", nodeOverrideConfigsAnnotationKey, node.Name, err)
+		return ""
+	}
+	return configs[poolTargetConfig]
+}
@@ -3,6 +3,7 @@ package node
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/golang/glog"
@@ -20,10 +21,12 @@ func (ctrl *Controller) syncStatusOnly(pool *mcfgv1.MachineConfigPool) error {
 	}
 
 	newStatus := calculateStatus(pool, nodes)
+	setEstimatedCompletionMetric(pool.Name, newStatus.EstimatedCompletionTime)
 	if equality.Semantic.DeepEqual(pool.Status, newStatus) {
 		return nil
 	}
 
+	wasInvalid := mcfgv1.IsMachineConfigPoolConditionTrue(pool.Status.Conditions, mcfgv1.MachineConfigPoolMaintenanceWindowInvalid)
 	newPool := pool
 	newPool.Status = newStatus
 	_, err = ctrl.client.MachineconfigurationV1().MachineConfigPools().UpdateStatus(context.TODO(), newPool, metav1.UpdateOptions{})
@@ -33,6 +36,9 @@ func (ctrl *Controller) syncStatusOnly(pool *mcfgv1.MachineConfigPool) error {
 	if pool.Status.Configuration.Name != newPool.Status.Configuration.Name {
 		ctrl.eventRecorder.Eventf(pool, corev1.EventTypeNormal, "Completed", "Pool %s has completed update to %s", pool.Name, newPool.Status.Configuration.Name)
 	}
+	if cond := mcfgv1.GetMachineConfigPoolCondition(newStatus, mcfgv1.MachineConfigPoolMaintenanceWindowInvalid); cond != nil && !wasInvalid {
+		ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, cond.Reason, cond.Message)
+	}
 	return err
 }
 
@@ -68,6 +74,10 @@ func calculateStatus(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) mcfgv
 	}
 
 	status.Configuration = pool.Status.Configuration
+	status.UpdateStatistics = pool.Status.UpdateStatistics
+	status.StuckDrains = pool.Status.StuckDrains
+	status.HostOperatingSystems = getHostOperatingSystems(nodes)
+	status.EstimatedCompletionTime = estimateCompletionTime(pool, nodes, updatedMachineCount)
 
 	conditions := pool.Status.Conditions
 	for i := range conditions {
@@ -124,6 +134,8 @@ func calculateStatus(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) mcfgv
 		mcfgv1.SetMachineConfigPoolCondition(&status, *sdegraded)
 	}
 
+	setMaintenanceWindowCondition(pool, &status)
+
 	return status
 }
 
@@ -143,7 +155,8 @@ func isNodeManaged(node *corev1.Node) bool {
 	return true
 }
 
-// isNodeDone returns true if the current == desired and the MCD has marked done.
+// isNodeDone returns true if the current == desired, the node rebooted for the reboot request
+// it was targeted for, if any, and the MCD has marked done.
 func isNodeDone(node *corev1.Node) bool {
 	if node.Annotations == nil {
 		return false
@@ -157,12 +170,18 @@ func isNodeDone(node *corev1.Node) bool {
 		return false
 	}
 
+	if node.Annotations[daemonconsts.DesiredRebootAnnotationKey] != node.Annotations[daemonconsts.CurrentRebootAnnotationKey] {
+		return false
+	}
+
 	return cconfig == dconfig && isNodeMCDState(node, daemonconsts.MachineConfigDaemonStateDone)
 }
 
-// isNodeDoneAt checks whether a node is fully updated to a targetConfig
-func isNodeDoneAt(node *corev1.Node, targetConfig string) bool {
-	return isNodeDone(node) && node.Annotations[daemonconsts.CurrentMachineConfigAnnotationKey] == targetConfig
+// isNodeDoneAt checks whether a node is fully updated to the config it should have when its pool
+// targets poolTargetConfig
+func isNodeDoneAt(node *corev1.Node, poolTargetConfig string) bool {
+	nodeConfig := nodeTargetConfig(poolTargetConfig, node)
+	return nodeConfig != "" && isNodeDone(node) && node.Annotations[daemonconsts.CurrentMachineConfigAnnotationKey] == nodeConfig
 }
 
 // isNodeMCDState checks the MCD state against the state parameter
@@ -245,6 +264,10 @@ func isNodeUnavailable(node *corev1.Node) bool {
 	if !isNodeReady(node) {
 		return true
 	}
+	// Nodes whose kubelet is being re-bootstrapped are too, even if they look ready meanwhile
+	if isNodeRecoveringKubeletCert(node) {
+		return true
+	}
 	// Ready nodes are not unavailable
 	if isNodeDone(node) {
 		return false
@@ -257,6 +280,11 @@ func isNodeUnavailable(node *corev1.Node) bool {
 	return !isNodeMCDFailing(node)
 }
 
+// isNodeRecoveringKubeletCert returns whether the daemon of node is recovering its expired kubelet client certificate
+func isNodeRecoveringKubeletCert(node *corev1.Node) bool {
+	return node.Annotations[daemonconsts.KubeletCertRecoveryAnnotationKey] == daemonconsts.KubeletCertRecoveryInProgress
+}
+
 // getUnavailableMachines returns the set of nodes which are
 // either marked unscheduleable, or have a MCD actively working.
 // If the MCD is actively working (or hasn't started) then the
@@ -294,3 +322,28 @@ func getDegradedMachines(nodes []*corev1.Node) []*corev1.Node {
 	}
 	return degraded
 }
+
+// getHostOperatingSystems counts the nodes by the operating system their daemon labeled them with.
+// The nodes whose daemon didn't yet aren't counted.
+func getHostOperatingSystems(nodes []*corev1.Node) []mcfgv1.MachineConfigPoolHostOperatingSystem {
+	counts := map[mcfgv1.MachineConfigPoolHostOperatingSystem]int32{}
+	for _, node := range nodes {
+		variant := node.Labels[daemonconsts.OSVariantLabelKey]
+		if variant == "" {
+			continue
+		}
+		counts[mcfgv1.MachineConfigPoolHostOperatingSystem{Variant: variant, Version: node.Labels[daemonconsts.OSVersionLabelKey]}]++
+	}
+	var hostOSes []mcfgv1.MachineConfigPoolHostOperatingSystem
+	for hostOS, count := range counts {
+		hostOS.MachineCount = count
+		hostOSes = append(hostOSes, hostOS)
+	}
+	sort.Slice(hostOSes, func(i, j int) bool {
+		if hostOSes[i].Variant != hostOSes[j].Variant {
+			return hostOSes[i].Variant < hostOSes[j].Variant
+		}
+		return hostOSes[i].Version < hostOSes[j].Version
+	})
+	return hostOSes
+}
@@ -289,6 +289,24 @@ func TestGetUnavailableMachines(t *testing.T) {
 		},
 		currentConfig: "v1",
 		unavail:       []string{"node-0", "node-2"},
+	}, {
+		// 1 node targeted for a reboot it hasn't done yet, 1 rebooted
+		nodes: []*corev1.Node{
+			newNodeWithReady("node-0", "v1", "v1", corev1.ConditionTrue),
+			func() *corev1.Node {
+				node := newNodeWithReady("node-1", "v1", "v1", corev1.ConditionTrue)
+				node.Annotations[daemonconsts.DesiredRebootAnnotationKey] = "2021-03-01T12:00:00Z"
+				return node
+			}(),
+			func() *corev1.Node {
+				node := newNodeWithReady("node-2", "v1", "v1", corev1.ConditionTrue)
+				node.Annotations[daemonconsts.DesiredRebootAnnotationKey] = "2021-03-01T12:00:00Z"
+				node.Annotations[daemonconsts.CurrentRebootAnnotationKey] = "2021-03-01T12:00:00Z"
+				return node
+			}(),
+		},
+		currentConfig: "v1",
+		unavail:       []string{"node-1"},
 	}}
 
 	for idx, test := range tests {
@@ -306,6 +324,31 @@ func TestGetUnavailableMachines(t *testing.T) {
 	}
 }
 
+func TestGetHostOperatingSystems(t *testing.T) {
+	hostOS := func(variant, version string) map[string]string {
+		return map[string]string{daemonconsts.OSVariantLabelKey: variant, daemonconsts.OSVersionLabelKey: version}
+	}
+	nodes := []*corev1.Node{
+		newNodeWithLabels("node-0", hostOS("rhel", "8.4")),
+		newNodeWithLabels("node-1", hostOS("rhcos", "48.84")),
+		newNodeWithLabels("node-2", hostOS("rhcos", "48.84")),
+		newNodeWithLabels("node-3", hostOS("rhcos", "47.83")),
+		// the daemon didn't publish the OS yet
+		newNodeWithLabels("node-4", nil),
+	}
+	expected := []mcfgv1.MachineConfigPoolHostOperatingSystem{
+		{Variant: "rhcos", Version: "47.83", MachineCount: 1},
+		{Variant: "rhcos", Version: "48.84", MachineCount: 2},
+		{Variant: "rhel", Version: "8.4", MachineCount: 1},
+	}
+	if got := getHostOperatingSystems(nodes); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("mismatch host operating systems: got %v want: %v", got, expected)
+	}
+	if got := getHostOperatingSystems(nodes[4:]); got != nil {
+		t.Fatalf("mismatch host operating systems: got %v want: nil", got)
+	}
+}
+
 func TestCalculateStatus(t *testing.T) {
 	tests := []struct {
 		nodes         []*corev1.Node
@@ -0,0 +1,107 @@
+package node
+
+import (
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+// updateOrderStrategy returns the update order strategy of the pool, Alphabetical by default
+func updateOrderStrategy(pool *mcfgv1.MachineConfigPool) mcfgv1.UpdateOrderStrategy {
+	if pool.Spec.UpdateOrder == nil || pool.Spec.UpdateOrder.Strategy == "" {
+		return mcfgv1.UpdateOrderAlphabetical
+	}
+	return pool.Spec.UpdateOrder.Strategy
+}
+
+// validateUpdateOrder returns an error if the nodes of the pool can't be ordered by its updateOrder
+func validateUpdateOrder(pool *mcfgv1.MachineConfigPool) error {
+	switch updateOrderStrategy(pool) {
+	case mcfgv1.UpdateOrderAlphabetical, mcfgv1.UpdateOrderCreationTime, mcfgv1.UpdateOrderZone:
+		return nil
+	case mcfgv1.UpdateOrderLabel:
+		if pool.Spec.UpdateOrder.LabelKey == "" {
+			return fmt.Errorf("the %s update order requires a labelKey", mcfgv1.UpdateOrderLabel)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown update order strategy %q", pool.Spec.UpdateOrder.Strategy)
+	}
+}
+
+// orderNodes returns the nodes in the update order of the pool, breaking ties by name
+func orderNodes(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) []*corev1.Node {
+	// keys ordering nodes without one last
+	keyLess := func(a, b string) (less, equal bool) {
+		switch {
+		case a == b:
+			return false, true
+		case a == "":
+			return false, false
+		case b == "":
+			return true, false
+		}
+		return a < b, false
+	}
+	var less func(a, b *corev1.Node) (bool, bool)
+	switch updateOrderStrategy(pool) {
+	case mcfgv1.UpdateOrderCreationTime:
+		less = func(a, b *corev1.Node) (bool, bool) {
+			return a.CreationTimestamp.Before(&b.CreationTimestamp), a.CreationTimestamp.Equal(&b.CreationTimestamp)
+		}
+	case mcfgv1.UpdateOrderZone:
+		less = func(a, b *corev1.Node) (bool, bool) {
+			return keyLess(nodeZone(a), nodeZone(b))
+		}
+	case mcfgv1.UpdateOrderLabel:
+		key := pool.Spec.UpdateOrder.LabelKey
+		less = func(a, b *corev1.Node) (bool, bool) {
+			return keyLess(a.Labels[key], b.Labels[key])
+		}
+	default:
+		less = func(a, b *corev1.Node) (bool, bool) { return false, true }
+	}
+
+	ordered := make([]*corev1.Node, len(nodes))
+	copy(ordered, nodes)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if less, equal := less(ordered[i], ordered[j]); !equal {
+			return less
+		}
+		return ordered[i].Name < ordered[j].Name
+	})
+	return ordered
+}
+
+// restrictToUpdatingZone keeps the ordered candidates in the first zone with nodes of the pool left
+// to update, when the pool updates one zone at a time. Nodes held at their current config, because
+// their drain got skipped or their override isn't rendered yet, don't hold the next zones.
+func restrictToUpdatingZone(pool *mcfgv1.MachineConfigPool, nodesInPool, candidates []*corev1.Node) []*corev1.Node {
+	if updateOrderStrategy(pool) != mcfgv1.UpdateOrderZone {
+		return candidates
+	}
+	targetConfig := pool.Spec.Configuration.Name
+	var left []*corev1.Node
+	for _, node := range nodesInPool {
+		nodeConfig := nodeTargetConfig(targetConfig, node)
+		if nodeConfig == "" || isNodeDoneAt(node, targetConfig) || node.Annotations[daemonconsts.DrainSkippedAnnotationKey] == nodeConfig {
+			continue
+		}
+		left = append(left, node)
+	}
+	if len(left) == 0 {
+		return candidates
+	}
+	zone := nodeZone(orderNodes(pool, left)[0])
+	var nodes []*corev1.Node
+	for _, node := range candidates {
+		if nodeZone(node) == zone {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
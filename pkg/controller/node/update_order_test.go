@@ -0,0 +1,31 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func TestRestrictToUpdatingZoneWithOverride(t *testing.T) {
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	pool.Spec.UpdateOrder = &mcfgv1.MachineConfigPoolUpdateOrder{Strategy: mcfgv1.UpdateOrderZone}
+	// node-0 is done updating to its override of v1
+	overridden := newZoneNode("node-0", "v1-node-0", "v1-node-0", "a")
+	overridden.Annotations[nodeOverrideConfigsAnnotationKey] = `{"v1": "v1-node-0"}`
+	nodes := []*corev1.Node{
+		overridden,
+		newZoneNode("node-1", "v0", "v0", "b"),
+		newZoneNode("node-2", "v0", "v0", "b"),
+	}
+
+	candidates, _ := getAllCandidateMachines(pool, nodes, len(nodes))
+	var got []string
+	for _, node := range restrictToUpdatingZone(pool, nodes, orderNodes(pool, candidates)) {
+		got = append(got, node.Name)
+	}
+	assert.Equal(t, []string{"node-1", "node-2"}, got)
+}
@@ -0,0 +1,122 @@
+package node
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+// maxRecentUpdateDurations is how many node update durations the update statistics of a pool
+// are computed from
+const maxRecentUpdateDurations = 20
+
+// nodeUpdateStart is when a node was targeted at a config
+type nodeUpdateStart struct {
+	config string
+	time   time.Time
+}
+
+// trackUpdateStart records that the node was just targeted at config
+func (ctrl *Controller) trackUpdateStart(nodeName, config string) {
+	ctrl.updateStartsLock.Lock()
+	defer ctrl.updateStartsLock.Unlock()
+	ctrl.updateStarts[nodeName] = nodeUpdateStart{config: config, time: time.Now()}
+}
+
+// recordUpdateDurations records in the update statistics of the pool how long the nodes which
+// completed their update since the last sync took. The pool is updated in place. The updates in
+// progress when the controller started aren't recorded.
+func (ctrl *Controller) recordUpdateDurations(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) error {
+	now := time.Now()
+	var durations []time.Duration
+	ctrl.updateStartsLock.Lock()
+	for _, node := range nodes {
+		start, ok := ctrl.updateStarts[node.Name]
+		if !ok || !isNodeDone(node) || node.Annotations[daemonconsts.CurrentMachineConfigAnnotationKey] != start.config {
+			continue
+		}
+		durations = append(durations, now.Sub(start.time))
+		delete(ctrl.updateStarts, node.Name)
+	}
+	ctrl.updateStartsLock.Unlock()
+	if len(durations) == 0 {
+		return nil
+	}
+
+	newPool := pool.DeepCopy()
+	newPool.Status.UpdateStatistics = addUpdateDurations(pool.Status.UpdateStatistics, durations, now)
+	updated, err := ctrl.client.MachineconfigurationV1().MachineConfigPools().UpdateStatus(context.TODO(), newPool, metav1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+	*pool = *updated
+	for _, duration := range durations {
+		ctrlcommon.MCCNodeUpdateDuration.WithLabelValues(pool.Name).Observe(duration.Seconds())
+	}
+	return nil
+}
+
+// addUpdateDurations returns the update statistics with the durations of the node updates
+// completed at now added
+func addUpdateDurations(stats *mcfgv1.MachineConfigPoolUpdateStatistics, durations []time.Duration, now time.Time) *mcfgv1.MachineConfigPoolUpdateStatistics {
+	newStats := &mcfgv1.MachineConfigPoolUpdateStatistics{}
+	if stats != nil {
+		newStats.RecentDurations = append(newStats.RecentDurations, stats.RecentDurations...)
+	}
+	for _, duration := range durations {
+		newStats.RecentDurations = append(newStats.RecentDurations, metav1.Duration{Duration: duration.Round(time.Second)})
+	}
+	if len(newStats.RecentDurations) > maxRecentUpdateDurations {
+		newStats.RecentDurations = newStats.RecentDurations[len(newStats.RecentDurations)-maxRecentUpdateDurations:]
+	}
+
+	var total time.Duration
+	for _, duration := range newStats.RecentDurations {
+		total += duration.Duration
+		if duration.Duration > newStats.MaxDuration.Duration {
+			newStats.MaxDuration = duration
+		}
+	}
+	newStats.AverageDuration = metav1.Duration{Duration: (total / time.Duration(len(newStats.RecentDurations))).Round(time.Second)}
+	newStats.LastUpdateTime = metav1.NewTime(now).Rfc3339Copy()
+	return newStats
+}
+
+// estimateCompletionTime estimates when the nodes of the pool not updated yet will be, updating
+// maxUnavailable of them at a time for the average update duration of the pool. The estimate
+// only changes as nodes complete their update, so it doesn't churn the pool status.
+func estimateCompletionTime(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node, updatedMachineCount int32) *metav1.Time {
+	stats := pool.Status.UpdateStatistics
+	remaining := len(nodes) - int(updatedMachineCount)
+	if stats == nil || len(stats.RecentDurations) == 0 || pool.Spec.Paused || remaining <= 0 {
+		return nil
+	}
+	if pool.Status.EstimatedCompletionTime != nil &&
+		pool.Status.UpdatedMachineCount == updatedMachineCount &&
+		pool.Status.MachineCount == int32(len(nodes)) {
+		return pool.Status.EstimatedCompletionTime
+	}
+
+	parallel, err := maxUnavailable(pool, nodes)
+	if err != nil || parallel < 1 {
+		parallel = 1
+	}
+	batches := (remaining + parallel - 1) / parallel
+	estimate := metav1.NewTime(time.Now().Add(time.Duration(batches) * stats.AverageDuration.Duration)).Rfc3339Copy()
+	return &estimate
+}
+
+// setEstimatedCompletionMetric exports the estimated completion time of the update of the pool
+func setEstimatedCompletionMetric(pool string, estimate *metav1.Time) {
+	if estimate == nil {
+		ctrlcommon.MCCPoolUpdateEstimatedCompletion.DeleteLabelValues(pool)
+		return
+	}
+	ctrlcommon.MCCPoolUpdateEstimatedCompletion.WithLabelValues(pool).Set(float64(estimate.Unix()))
+}
@@ -0,0 +1,67 @@
+package node
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	intstrutil "k8s.io/apimachinery/pkg/util/intstr"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+// nodeZone returns the topology zone of the node, or "" if it has none
+func nodeZone(node *corev1.Node) string {
+	if zone := node.Labels[corev1.LabelTopologyZone]; zone != "" {
+		return zone
+	}
+	return node.Labels[corev1.LabelFailureDomainBetaZone]
+}
+
+// maxUnavailablePerZone returns the maximum number of unavailable nodes of the pool in each zone,
+// or nil if the pool doesn't limit them per zone
+func maxUnavailablePerZone(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) (map[string]int, error) {
+	if pool.Spec.MaxUnavailablePerZone == nil {
+		return nil, nil
+	}
+	zoneNodes := map[string]int{}
+	for _, node := range nodes {
+		if zone := nodeZone(node); zone != "" {
+			zoneNodes[zone]++
+		}
+	}
+	limits := map[string]int{}
+	for zone, count := range zoneNodes {
+		limit, err := intstrutil.GetScaledValueFromIntOrPercent(pool.Spec.MaxUnavailablePerZone, count, false)
+		if err != nil {
+			return nil, err
+		}
+		if limit == 0 {
+			limit = 1
+		}
+		limits[zone] = limit
+	}
+	return limits, nil
+}
+
+// limitCandidatesPerZone drops the candidates whose zone would have more than its limit of
+// unavailable nodes if they started updating, keeping the order of the others. Candidates
+// without a zone, or already unavailable, aren't limited.
+func limitCandidatesPerZone(nodesInPool, candidates []*corev1.Node, limits map[string]int) []*corev1.Node {
+	if limits == nil {
+		return candidates
+	}
+	unavail := map[string]int{}
+	for _, node := range getUnavailableMachines(nodesInPool) {
+		unavail[nodeZone(node)]++
+	}
+	var nodes []*corev1.Node
+	for _, node := range candidates {
+		zone := nodeZone(node)
+		if zone != "" && !isNodeUnavailable(node) {
+			if unavail[zone] >= limits[zone] {
+				continue
+			}
+			unavail[zone]++
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
@@ -0,0 +1,86 @@
+package render
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+// expiryWarningPeriod is how long before their expiry MachineConfigs get warning events
+const expiryWarningPeriod = 24 * time.Hour
+
+// expiry is where a MachineConfig stands regarding its expiry at a given time
+type expiry struct {
+	// expired configs have to be deleted
+	expired bool
+	// expiring configs expire within expiryWarningPeriod
+	expiring bool
+	// recheckIn is when the state changes next, zero if it won't
+	recheckIn time.Duration
+}
+
+// getExpiry returns the expiry state of the config at now, the zero state if the config doesn't expire
+func getExpiry(mc *mcfgv1.MachineConfig, now time.Time) (expiry, error) {
+	value, ok := mc.Annotations[ctrlcommon.MachineConfigExpiresAnnotationKey]
+	if !ok {
+		return expiry{}, nil
+	}
+	expires, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return expiry{}, fmt.Errorf("%s must be an RFC 3339 time: %v", ctrlcommon.MachineConfigExpiresAnnotationKey, err)
+	}
+	left := expires.Sub(now)
+	switch {
+	case left <= 0:
+		return expiry{expired: true}, nil
+	case left <= expiryWarningPeriod:
+		return expiry{expiring: true, recheckIn: left}, nil
+	default:
+		return expiry{recheckIn: left - expiryWarningPeriod}, nil
+	}
+}
+
+// syncExpiredMachineConfigs deletes the expired configs and warns about the ones expiring soon.
+// It returns the configs left to render the pool from, and requeues the pool for the next
+// expiry or warning.
+func (ctrl *Controller) syncExpiredMachineConfigs(pool *mcfgv1.MachineConfigPool, configs []*mcfgv1.MachineConfig) ([]*mcfgv1.MachineConfig, error) {
+	now := time.Now()
+	var live []*mcfgv1.MachineConfig
+	var recheckIn time.Duration
+	for _, mc := range configs {
+		exp, err := getExpiry(mc, now)
+		if err != nil {
+			ctrl.eventRecorder.Eventf(mc, corev1.EventTypeWarning, "InvalidExpiry", "Ignoring the expiry of MachineConfig %s: %v", mc.Name, err)
+			live = append(live, mc)
+			continue
+		}
+		if exp.expired {
+			glog.Infof("Deleting expired MachineConfig %s, expired at %s", mc.Name, mc.Annotations[ctrlcommon.MachineConfigExpiresAnnotationKey])
+			err := ctrl.client.MachineconfigurationV1().MachineConfigs().Delete(context.TODO(), mc.Name, metav1.DeleteOptions{})
+			if err != nil && !apierrors.IsNotFound(err) {
+				return nil, fmt.Errorf("deleting expired MachineConfig %s: %v", mc.Name, err)
+			}
+			ctrl.eventRecorder.Eventf(pool, corev1.EventTypeNormal, "MachineConfigExpired", "Deleted MachineConfig %s, expired at %s", mc.Name, mc.Annotations[ctrlcommon.MachineConfigExpiresAnnotationKey])
+			continue
+		}
+		if exp.expiring {
+			ctrl.eventRecorder.Eventf(mc, corev1.EventTypeWarning, "MachineConfigExpiring", "MachineConfig %s expires at %s and will be deleted", mc.Name, mc.Annotations[ctrlcommon.MachineConfigExpiresAnnotationKey])
+		}
+		if exp.recheckIn > 0 && (recheckIn == 0 || exp.recheckIn < recheckIn) {
+			recheckIn = exp.recheckIn
+		}
+		live = append(live, mc)
+	}
+	if recheckIn > 0 {
+		ctrl.enqueueAfter(pool, recheckIn)
+	}
+	return live, nil
+}
@@ -0,0 +1,65 @@
+package render
+
+import (
+	"fmt"
+
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+func (ctrl *Controller) addKernelArgumentPolicy(obj interface{}) {
+	policy := obj.(*mcfgv1.KernelArgumentPolicy)
+	glog.V(4).Infof("KernelArgumentPolicy %s added", policy.Name)
+	ctrl.enqueueAllMachineConfigPools()
+}
+
+func (ctrl *Controller) updateKernelArgumentPolicy(old, cur interface{}) {
+	curPolicy := cur.(*mcfgv1.KernelArgumentPolicy)
+	glog.V(4).Infof("KernelArgumentPolicy %s updated", curPolicy.Name)
+	ctrl.enqueueAllMachineConfigPools()
+}
+
+func (ctrl *Controller) deleteKernelArgumentPolicy(obj interface{}) {
+	policy, ok := obj.(*mcfgv1.KernelArgumentPolicy)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Couldn't get object from tombstone %#v", obj))
+			return
+		}
+		policy, ok = tombstone.Obj.(*mcfgv1.KernelArgumentPolicy)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Tombstone contained object that is not a KernelArgumentPolicy %#v", obj))
+			return
+		}
+	}
+	glog.V(4).Infof("KernelArgumentPolicy %s deleted", policy.Name)
+	ctrl.enqueueAllMachineConfigPools()
+}
+
+// enqueueAllMachineConfigPools enqueues every pool, a KernelArgumentPolicy applying to all of them
+func (ctrl *Controller) enqueueAllMachineConfigPools() {
+	pools, err := ctrl.mcpLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("Couldn't list MachineConfigPools: %v", err))
+		return
+	}
+	for _, pool := range pools {
+		ctrl.enqueueMachineConfigPool(pool)
+	}
+}
+
+// validateKernelArguments makes sure the KernelArgumentPolicies don't deny any of the kernel
+// arguments of the MachineConfigs of a pool before rendering them
+func (ctrl *Controller) validateKernelArguments(configs []*mcfgv1.MachineConfig) error {
+	policies, err := ctrl.kapLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	return ctrlcommon.ValidateMachineConfigsKernelArguments(configs, policies)
+}
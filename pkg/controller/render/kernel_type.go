@@ -0,0 +1,49 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+func (ctrl *Controller) addNode(obj interface{}) {
+	node := obj.(*corev1.Node)
+	glog.V(4).Infof("Node %s added", node.Name)
+	// the kernel type of the pool the node joins may not be available for its architecture
+	ctrl.enqueueAllMachineConfigPools()
+}
+
+// validateKernelType makes sure the kernel type of the config rendered for the pool is available
+// for the architecture of all of its nodes, so none of them starts updating to a kernel it can't
+// boot
+func (ctrl *Controller) validateKernelType(pool *mcfgv1.MachineConfigPool, config *mcfgv1.MachineConfig) error {
+	if config.Spec.KernelType == "" || config.Spec.KernelType == ctrlcommon.KernelTypeDefault {
+		return nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(pool.Spec.NodeSelector)
+	if err != nil {
+		return fmt.Errorf("invalid label selector: %v", err)
+	}
+	nodes, err := ctrl.nodeLister.List(selector)
+	if err != nil {
+		return err
+	}
+
+	var unsupported []string
+	for _, node := range nodes {
+		arch := node.Status.NodeInfo.Architecture
+		if !ctrlcommon.KernelTypeSupportsArchitecture(config.Spec.KernelType, arch) {
+			unsupported = append(unsupported, fmt.Sprintf("%s (%s)", node.Name, arch))
+		}
+	}
+	if len(unsupported) > 0 {
+		return fmt.Errorf("kernelType %s is not supported on the architecture of nodes %s", config.Spec.KernelType, strings.Join(unsupported, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package render
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+	clientretry "k8s.io/client-go/util/retry"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+// maxNodeOverrideConfigurations is how many rendered configs of its pool a
+// MachineConfigNodeOverride keeps the node configs of, so nodes still updating
+// from a previous pool config keep theirs.
+const maxNodeOverrideConfigurations = 10
+
+func (ctrl *Controller) addMachineConfigNodeOverride(obj interface{}) {
+	override := obj.(*mcfgv1.MachineConfigNodeOverride)
+	glog.V(4).Infof("MachineConfigNodeOverride %s added", override.Name)
+	ctrl.enqueueNodeOverridePool(override)
+}
+
+func (ctrl *Controller) updateMachineConfigNodeOverride(old, cur interface{}) {
+	oldOverride := old.(*mcfgv1.MachineConfigNodeOverride)
+	curOverride := cur.(*mcfgv1.MachineConfigNodeOverride)
+	// the status we write ourselves doesn't need another render
+	if oldOverride.Generation == curOverride.Generation && oldOverride.Status.Pool == curOverride.Status.Pool {
+		return
+	}
+	glog.V(4).Infof("MachineConfigNodeOverride %s updated", curOverride.Name)
+	ctrl.enqueueNodeOverridePool(curOverride)
+}
+
+func (ctrl *Controller) deleteMachineConfigNodeOverride(obj interface{}) {
+	override, ok := obj.(*mcfgv1.MachineConfigNodeOverride)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Couldn't get object from tombstone %#v", obj))
+			return
+		}
+		override, ok = tombstone.Obj.(*mcfgv1.MachineConfigNodeOverride)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Tombstone contained object that is not a MachineConfigNodeOverride %#v", obj))
+			return
+		}
+	}
+	// the node controller moves the node back to the config of its pool
+	glog.V(4).Infof("MachineConfigNodeOverride %s deleted", override.Name)
+}
+
+// enqueueNodeOverridePool enqueues the pool the node controller found the node of the override in
+func (ctrl *Controller) enqueueNodeOverridePool(override *mcfgv1.MachineConfigNodeOverride) {
+	if override.Status.Pool == "" {
+		return
+	}
+	pool, err := ctrl.mcpLister.Get(override.Status.Pool)
+	if err != nil {
+		glog.V(4).Infof("Couldn't get pool %s of MachineConfigNodeOverride %s: %v", override.Status.Pool, override.Name, err)
+		return
+	}
+	ctrl.enqueueMachineConfigPool(pool)
+}
+
+// syncNodeOverrides renders the MachineConfigNodeOverrides of the nodes of the pool on top of
+// poolConfig, the rendered config the pool targets, and records the node configs in their status
+// for the node controller. An override failing to render doesn't fail the pool: its node is held
+// at its current config until the override is fixed.
+func (ctrl *Controller) syncNodeOverrides(pool *mcfgv1.MachineConfigPool, poolConfig *mcfgv1.MachineConfig) error {
+	overrides, err := ctrl.mcnoLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, override := range overrides {
+		if override.Status.Pool != pool.Name || hasNodeOverrideConfiguration(override, poolConfig.Name) {
+			continue
+		}
+		cc, err := ctrl.ccLister.Get(ctrlcommon.ControllerConfigName)
+		if err != nil {
+			return err
+		}
+		nodeConfig, renderErr := ctrl.renderNodeOverride(pool, poolConfig, override, cc)
+		if renderErr != nil {
+			ctrl.eventRecorder.Eventf(override, corev1.EventTypeWarning, "RenderFailed", "Failed to render MachineConfigNodeOverride %s on top of %s: %v", override.Name, poolConfig.Name, renderErr)
+		} else {
+			glog.V(2).Infof("Pool %s: rendered %s for node %s from %s", pool.Name, nodeConfig, override.Spec.NodeName, poolConfig.Name)
+		}
+		if err := ctrl.syncNodeOverrideStatus(override.Name, poolConfig.Name, nodeConfig, renderErr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasNodeOverrideConfiguration returns whether the current generation of the override was
+// rendered on top of poolConfig
+func hasNodeOverrideConfiguration(override *mcfgv1.MachineConfigNodeOverride, poolConfig string) bool {
+	if override.Status.ObservedGeneration != override.Generation {
+		return false
+	}
+	for _, configuration := range override.Status.Configurations {
+		if configuration.PoolConfig == poolConfig {
+			return true
+		}
+	}
+	return false
+}
+
+// renderNodeOverride renders the override on top of poolConfig like any other config of the pool
+// and returns the name of the result
+func (ctrl *Controller) renderNodeOverride(pool *mcfgv1.MachineConfigPool, poolConfig *mcfgv1.MachineConfig, override *mcfgv1.MachineConfigNodeOverride, cc *mcfgv1.ControllerConfig) (string, error) {
+	// named after the pool config so it sorts, and merges, after it
+	overrideConfig := &mcfgv1.MachineConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       poolConfig.Name + "-" + override.Name,
+			UID:        override.UID,
+			Generation: override.Generation,
+		},
+		Spec: mcfgv1.MachineConfigSpec{Config: override.Spec.Config},
+	}
+	// keep the OS of the pool config, which differs from the current one when rolling back
+	cconfig := cc.DeepCopy()
+	cconfig.Spec.OSImageURL = poolConfig.Spec.OSImageURL
+
+	generated, err := generateRenderedMachineConfig(pool, []*mcfgv1.MachineConfig{poolConfig, overrideConfig}, cconfig)
+	if err != nil {
+		return "", err
+	}
+	if _, err := ctrl.mcLister.Get(generated.Name); apierrors.IsNotFound(err) {
+		if _, err := ctrl.client.MachineconfigurationV1().MachineConfigs().Create(context.TODO(), generated, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+			return "", err
+		}
+	} else if err != nil {
+		return "", err
+	}
+	return generated.Name, nil
+}
+
+// syncNodeOverrideStatus records the node config rendered for poolConfig, or the error rendering it
+func (ctrl *Controller) syncNodeOverrideStatus(name, poolConfig, nodeConfig string, renderErr error) error {
+	return clientretry.RetryOnConflict(clientretry.DefaultRetry, func() error {
+		override, err := ctrl.client.MachineconfigurationV1().MachineConfigNodeOverrides().Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if renderErr != nil {
+			override.Status.Conditions = []mcfgv1.MachineConfigNodeOverrideCondition{
+				*mcfgv1.NewMachineConfigNodeOverrideCondition(mcfgv1.MachineConfigNodeOverrideFailure, corev1.ConditionTrue, fmt.Sprintf("Error rendering on top of %s: %v", poolConfig, renderErr)),
+			}
+		} else {
+			// the node configs of a previous generation don't have the current override
+			configurations := []mcfgv1.MachineConfigNodeOverrideConfiguration{{PoolConfig: poolConfig, NodeConfig: nodeConfig}}
+			if override.Status.ObservedGeneration == override.Generation {
+				for _, configuration := range override.Status.Configurations {
+					if configuration.PoolConfig != poolConfig && len(configurations) < maxNodeOverrideConfigurations {
+						configurations = append(configurations, configuration)
+					}
+				}
+			}
+			override.Status.Configurations = configurations
+			override.Status.ObservedGeneration = override.Generation
+			override.Status.Conditions = []mcfgv1.MachineConfigNodeOverrideCondition{
+				*mcfgv1.NewMachineConfigNodeOverrideCondition(mcfgv1.MachineConfigNodeOverrideSuccess, corev1.ConditionTrue, fmt.Sprintf("Rendered %s on top of %s", nodeConfig, poolConfig)),
+			}
+		}
+		_, err = ctrl.client.MachineconfigurationV1().MachineConfigNodeOverrides().UpdateStatus(context.TODO(), override, metav1.UpdateOptions{})
+		return err
+	})
+}
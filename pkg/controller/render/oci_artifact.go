@@ -0,0 +1,257 @@
+package render
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/containers/image/v5/docker"
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/containers/image/v5/manifest"
+	"github.com/containers/image/v5/pkg/blobinfocache/none"
+	"github.com/containers/image/v5/types"
+	"github.com/golang/glog"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/vincent-petithory/dataurl"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+const (
+	// registriesConfigPath is where the pools get their registry mirrors from, artifacts are
+	// pulled through the same mirrors
+	registriesConfigPath = "/etc/containers/registries.conf"
+
+	// maxArtifactSize caps the size of the Ignition config read from an artifact
+	maxArtifactSize = 4 * 1024 * 1024
+
+	// artifactPullTimeout bounds the pull of an artifact, an unresponsive registry would block
+	// the worker rendering the pool otherwise
+	artifactPullTimeout = 2 * time.Minute
+)
+
+// artifactDigest returns the digest an artifact reference is pinned to
+func artifactDigest(artifact string) (digest.Digest, error) {
+	named, err := reference.ParseNormalizedNamed(artifact)
+	if err != nil {
+		return "", fmt.Errorf("invalid OCI artifact reference %q: %v", artifact, err)
+	}
+	canonical, ok := named.(reference.Canonical)
+	if !ok {
+		return "", fmt.Errorf("OCI artifact reference %q must be pinned by digest", artifact)
+	}
+	return canonical.Digest(), nil
+}
+
+// fileContents returns the contents of the file at path in the configs, the last config by
+// name winning like when they are merged, nil if none of them writes it
+func fileContents(configs []*mcfgv1.MachineConfig, path string) ([]byte, error) {
+	sorted := make([]*mcfgv1.MachineConfig, len(configs))
+	copy(sorted, configs)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	var contents []byte
+	for _, mc := range sorted {
+		if len(mc.Spec.Config.Raw) == 0 {
+			continue
+		}
+		ignCfg, err := ctrlcommon.ParseAndConvertConfig(mc.Spec.Config.Raw)
+		if err != nil {
+			return nil, fmt.Errorf("parsing Ignition config of MachineConfig %s: %v", mc.Name, err)
+		}
+		for _, file := range ignCfg.Storage.Files {
+			if file.Path != path || file.Contents.Source == nil {
+				continue
+			}
+			decoded, err := dataurl.DecodeString(*file.Contents.Source)
+			if err != nil {
+				return nil, fmt.Errorf("decoding %s of MachineConfig %s: %v", path, mc.Name, err)
+			}
+			contents = decoded.Data
+		}
+	}
+	return contents, nil
+}
+
+// artifactSystemContext returns how to pull artifacts for the pool: with the cluster pull
+// secret and through the registry mirrors of the pool. The caller must remove the returned dir.
+func (ctrl *Controller) artifactSystemContext(configs []*mcfgv1.MachineConfig, cc *mcfgv1.ControllerConfig) (*types.SystemContext, string, error) {
+	dir, err := ioutil.TempDir("", "mcc-artifact")
+	if err != nil {
+		return nil, "", err
+	}
+	sys := &types.SystemContext{
+		// don't pick the registries of the controller's own image
+		SystemRegistriesConfPath:    filepath.Join(dir, "registries.conf"),
+		SystemRegistriesConfDirPath: filepath.Join(dir, "registries.conf.d"),
+	}
+
+	registries, err := fileContents(configs, registriesConfigPath)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, "", err
+	}
+	if err := ioutil.WriteFile(sys.SystemRegistriesConfPath, registries, 0600); err != nil {
+		os.RemoveAll(dir)
+		return nil, "", err
+	}
+
+	if cc.Spec.PullSecret != nil {
+		secret, err := ctrl.kubeClient.CoreV1().Secrets(cc.Spec.PullSecret.Namespace).Get(context.TODO(), cc.Spec.PullSecret.Name, metav1.GetOptions{})
+		if err != nil {
+			os.RemoveAll(dir)
+			return nil, "", fmt.Errorf("getting the pull secret: %v", err)
+		}
+		if secret.Type != corev1.SecretTypeDockerConfigJson {
+			os.RemoveAll(dir)
+			return nil, "", fmt.Errorf("expected secret type %s found %s", corev1.SecretTypeDockerConfigJson, secret.Type)
+		}
+		sys.AuthFilePath = filepath.Join(dir, "auth.json")
+		if err := ioutil.WriteFile(sys.AuthFilePath, secret.Data[corev1.DockerConfigJsonKey], 0600); err != nil {
+			os.RemoveAll(dir)
+			return nil, "", err
+		}
+	}
+	return sys, dir, nil
+}
+
+// fetchArtifact pulls the Ignition config of the artifact, verifying the digests of its
+// manifest and layer. The artifact has to have a single layer.
+func fetchArtifact(ctx context.Context, sys *types.SystemContext, artifact string) ([]byte, error) {
+	expected, err := artifactDigest(artifact)
+	if err != nil {
+		return nil, err
+	}
+	ref, err := docker.ParseReference("//" + strings.TrimPrefix(artifact, "//"))
+	if err != nil {
+		return nil, err
+	}
+	src, err := ref.NewImageSource(ctx, sys)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	manifestBlob, mimeType, err := src.GetManifest(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if matches, err := manifest.MatchesDigest(manifestBlob, expected); err != nil || !matches {
+		return nil, fmt.Errorf("manifest of %s doesn't match its digest", artifact)
+	}
+	m, err := manifest.FromBlob(manifestBlob, manifest.NormalizedMIMEType(mimeType))
+	if err != nil {
+		return nil, fmt.Errorf("parsing manifest of %s: %v", artifact, err)
+	}
+	layers := m.LayerInfos()
+	if len(layers) != 1 {
+		return nil, fmt.Errorf("OCI artifact %s must have a single layer, found %d", artifact, len(layers))
+	}
+
+	blob, _, err := src.GetBlob(ctx, layers[0].BlobInfo, none.NoCache)
+	if err != nil {
+		return nil, err
+	}
+	defer blob.Close()
+	verifier := layers[0].Digest.Verifier()
+	content, err := ioutil.ReadAll(io.TeeReader(io.LimitReader(blob, maxArtifactSize+1), verifier))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > maxArtifactSize {
+		return nil, fmt.Errorf("OCI artifact %s exceeds %d bytes", artifact, maxArtifactSize)
+	}
+	if !verifier.Verified() {
+		return nil, fmt.Errorf("layer of %s doesn't match its digest %s", artifact, layers[0].Digest)
+	}
+	return content, nil
+}
+
+// resolveArtifactConfigs returns the configs with the Ignition config of the OCI artifacts they
+// reference. Artifacts are pinned by digest, so they are only pulled once.
+func (ctrl *Controller) resolveArtifactConfigs(configs []*mcfgv1.MachineConfig, cc *mcfgv1.ControllerConfig) ([]*mcfgv1.MachineConfig, error) {
+	var sys *types.SystemContext
+	resolved := make([]*mcfgv1.MachineConfig, 0, len(configs))
+	for _, mc := range configs {
+		artifact, ok := mc.Annotations[ctrlcommon.OCIArtifactAnnotationKey]
+		if !ok {
+			resolved = append(resolved, mc)
+			continue
+		}
+		if len(mc.Spec.Config.Raw) > 0 {
+			return nil, fmt.Errorf("MachineConfig %s can't have both an Ignition config and an OCI artifact", mc.Name)
+		}
+		expected, err := artifactDigest(artifact)
+		if err != nil {
+			return nil, fmt.Errorf("MachineConfig %s: %v", mc.Name, err)
+		}
+
+		ctrl.artifactsLock.Lock()
+		content, cached := ctrl.artifacts[expected]
+		ctrl.artifactsLock.Unlock()
+		if !cached {
+			if sys == nil {
+				var dir string
+				sys, dir, err = ctrl.artifactSystemContext(configs, cc)
+				if err != nil {
+					return nil, fmt.Errorf("preparing to pull OCI artifacts: %v", err)
+				}
+				defer os.RemoveAll(dir)
+			}
+			glog.Infof("Pulling OCI artifact %s of MachineConfig %s", artifact, mc.Name)
+			ctx, cancel := context.WithTimeout(context.Background(), artifactPullTimeout)
+			content, err = fetchArtifact(ctx, sys, artifact)
+			cancel()
+			if err != nil {
+				return nil, fmt.Errorf("pulling OCI artifact of MachineConfig %s: %v", mc.Name, err)
+			}
+			if _, err := ctrlcommon.ParseAndConvertConfig(content); err != nil {
+				return nil, fmt.Errorf("OCI artifact of MachineConfig %s isn't a valid Ignition config: %v", mc.Name, err)
+			}
+			ctrl.artifactsLock.Lock()
+			ctrl.artifacts[expected] = content
+			ctrl.artifactsLock.Unlock()
+		}
+
+		mc = mc.DeepCopy()
+		mc.Spec.Config.Raw = content
+		resolved = append(resolved, mc)
+	}
+	return resolved, nil
+}
+
+// pruneArtifacts evicts the cached artifacts no MachineConfig references anymore
+func (ctrl *Controller) pruneArtifacts() {
+	configs, err := ctrl.mcLister.List(labels.Everything())
+	if err != nil {
+		glog.Errorf("error listing MachineConfigs to prune the OCI artifacts cache: %v", err)
+		return
+	}
+	referenced := map[digest.Digest]bool{}
+	for _, mc := range configs {
+		if artifact, ok := mc.Annotations[ctrlcommon.OCIArtifactAnnotationKey]; ok {
+			if d, err := artifactDigest(artifact); err == nil {
+				referenced[d] = true
+			}
+		}
+	}
+
+	ctrl.artifactsLock.Lock()
+	defer ctrl.artifactsLock.Unlock()
+	for d := range ctrl.artifacts {
+		if !referenced[d] {
+			glog.V(4).Infof("Evicting OCI artifact %s from the cache", d)
+			delete(ctrl.artifacts, d)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/vincent-petithory/dataurl"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+// The benchmarks measure generateRenderedMachineConfig, which every config rollout waits on,
+// for pools with many MachineConfigs and large file payloads. Run them with:
+//
+//	make bench-render
+
+const (
+	kib = 1024
+	mib = 1024 * kib
+)
+
+// newBenchMachineConfigs returns count MachineConfigs for the master pool, each writing
+// filesPerConfig files of fileSize bytes
+func newBenchMachineConfigs(count, filesPerConfig, fileSize int) []*mcfgv1.MachineConfig {
+	contents := dataurl.EncodeBytes([]byte(strings.Repeat("x", fileSize)))
+	mcs := make([]*mcfgv1.MachineConfig, 0, count)
+	for i := 0; i < count; i++ {
+		files := make([]ign3types.File, 0, filesPerConfig)
+		for j := 0; j < filesPerConfig; j++ {
+			files = append(files, ign3types.File{
+				Node: ign3types.Node{
+					Path: fmt.Sprintf("/etc/bench/%d/%d", i, j),
+				},
+				FileEmbedded1: ign3types.FileEmbedded1{
+					Contents: ign3types.Resource{Source: helpers.StrToPtr(contents)},
+				},
+			})
+		}
+		name := fmt.Sprintf("%05d-bench-master", i)
+		mcs = append(mcs, helpers.NewMachineConfig(name, map[string]string{"node-role/master": ""}, "", files))
+	}
+	return mcs
+}
+
+func benchmarkRender(b *testing.B, mcs []*mcfgv1.MachineConfig) {
+	mcp := helpers.NewMachineConfigPool("master", helpers.MasterSelector, nil, "")
+	cc := newControllerConfig(ctrlcommon.ControllerConfigName)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := generateRenderedMachineConfig(mcp, mcs, cc); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGenerateRenderedMachineConfig(b *testing.B) {
+	for _, count := range []int{10, 100, 500} {
+		b.Run(fmt.Sprintf("configs=%d", count), func(b *testing.B) {
+			benchmarkRender(b, newBenchMachineConfigs(count, 5, kib))
+		})
+	}
+}
+
+func BenchmarkGenerateRenderedMachineConfigLargeFiles(b *testing.B) {
+	for _, size := range []int{mib, 4 * mib, 16 * mib} {
+		b.Run(fmt.Sprintf("size=%dMiB", size/mib), func(b *testing.B) {
+			benchmarkRender(b, newBenchMachineConfigs(4, 1, size))
+		})
+	}
+}
+
+// TestRenderScaling guards against render regressions that benchmarks only show to whoever
+// runs them: allocations have to grow linearly with the number of MachineConfigs and the size
+// of their payloads. Allocation counts, unlike timings, are stable enough for CI.
+func TestRenderScaling(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping render scaling test in short mode")
+	}
+	mcp := helpers.NewMachineConfigPool("master", helpers.MasterSelector, nil, "")
+	cc := newControllerConfig(ctrlcommon.ControllerConfigName)
+
+	render := func(mcs []*mcfgv1.MachineConfig) testing.BenchmarkResult {
+		return testing.Benchmark(func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := generateRenderedMachineConfig(mcp, mcs, cc); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+
+	// 5 times the configs may take up to 5 times the allocations, plus slack
+	small := render(newBenchMachineConfigs(50, 5, kib))
+	large := render(newBenchMachineConfigs(250, 5, kib))
+	if limit := small.AllocsPerOp() * 5 * 3 / 2; large.AllocsPerOp() > limit {
+		t.Errorf("rendering 250 configs took %d allocations, more than %d for 5 times the 50 configs (%d)", large.AllocsPerOp(), limit, small.AllocsPerOp())
+	}
+
+	// a payload is copied a bounded number of times, whatever its size
+	payload := render(newBenchMachineConfigs(1, 1, 4*mib))
+	const maxCopies = 48
+	if payload.AllocedBytesPerOp() > maxCopies*4*mib {
+		t.Errorf("rendering a 4MiB file allocated %dMiB, more than %d copies of it", payload.AllocedBytesPerOp()/mib, maxCopies)
+	}
+}
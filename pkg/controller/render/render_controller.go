@@ -4,9 +4,12 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/golang/glog"
+	digest "github.com/opencontainers/go-digest"
 	"github.com/openshift/machine-config-operator/lib/resourceapply"
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
@@ -23,8 +26,10 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
+	coreinformersv1 "k8s.io/client-go/informers/core/v1"
 	clientset "k8s.io/client-go/kubernetes"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisterv1 "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
@@ -53,6 +58,7 @@ var (
 // Controller defines the render controller.
 type Controller struct {
 	client        mcfgclientset.Interface
+	kubeClient    clientset.Interface
 	eventRecorder record.EventRecorder
 
 	syncHandler              func(mcp string) error
@@ -67,7 +73,21 @@ type Controller struct {
 	ccLister       mcfglistersv1.ControllerConfigLister
 	ccListerSynced cache.InformerSynced
 
+	mcnoLister       mcfglistersv1.MachineConfigNodeOverrideLister
+	mcnoListerSynced cache.InformerSynced
+
+	kapLister       mcfglistersv1.KernelArgumentPolicyLister
+	kapListerSynced cache.InformerSynced
+
+	nodeLister       corelisterv1.NodeLister
+	nodeListerSynced cache.InformerSynced
+
 	queue workqueue.RateLimitingInterface
+
+	// artifacts caches the contents of the OCI artifacts MachineConfigs reference, by digest,
+	// until no MachineConfig references them anymore
+	artifacts     map[digest.Digest][]byte
+	artifactsLock sync.Mutex
 }
 
 // New returns a new render controller.
@@ -75,6 +95,9 @@ func New(
 	mcpInformer mcfginformersv1.MachineConfigPoolInformer,
 	mcInformer mcfginformersv1.MachineConfigInformer,
 	ccInformer mcfginformersv1.ControllerConfigInformer,
+	mcnoInformer mcfginformersv1.MachineConfigNodeOverrideInformer,
+	kapInformer mcfginformersv1.KernelArgumentPolicyInformer,
+	nodeInformer coreinformersv1.NodeInformer,
 	kubeClient clientset.Interface,
 	mcfgClient mcfgclientset.Interface,
 ) *Controller {
@@ -84,8 +107,10 @@ func New(
 
 	ctrl := &Controller{
 		client:        mcfgClient,
+		kubeClient:    kubeClient,
 		eventRecorder: eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "machineconfigcontroller-rendercontroller"}),
 		queue:         workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "machineconfigcontroller-rendercontroller"),
+		artifacts:     map[digest.Digest][]byte{},
 	}
 
 	mcpInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -98,6 +123,19 @@ func New(
 		UpdateFunc: ctrl.updateMachineConfig,
 		DeleteFunc: ctrl.deleteMachineConfig,
 	})
+	mcnoInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.addMachineConfigNodeOverride,
+		UpdateFunc: ctrl.updateMachineConfigNodeOverride,
+		DeleteFunc: ctrl.deleteMachineConfigNodeOverride,
+	})
+	kapInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.addKernelArgumentPolicy,
+		UpdateFunc: ctrl.updateKernelArgumentPolicy,
+		DeleteFunc: ctrl.deleteKernelArgumentPolicy,
+	})
+	nodeInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: ctrl.addNode,
+	})
 
 	ctrl.syncHandler = ctrl.syncMachineConfigPool
 	ctrl.enqueueMachineConfigPool = ctrl.enqueueDefault
@@ -108,6 +146,12 @@ func New(
 	ctrl.mcListerSynced = mcInformer.Informer().HasSynced
 	ctrl.ccLister = ccInformer.Lister()
 	ctrl.ccListerSynced = ccInformer.Informer().HasSynced
+	ctrl.mcnoLister = mcnoInformer.Lister()
+	ctrl.mcnoListerSynced = mcnoInformer.Informer().HasSynced
+	ctrl.kapLister = kapInformer.Lister()
+	ctrl.kapListerSynced = kapInformer.Informer().HasSynced
+	ctrl.nodeLister = nodeInformer.Lister()
+	ctrl.nodeListerSynced = nodeInformer.Informer().HasSynced
 
 	return ctrl
 }
@@ -117,7 +161,7 @@ func (ctrl *Controller) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
 	defer ctrl.queue.ShutDown()
 
-	if !cache.WaitForCacheSync(stopCh, ctrl.mcpListerSynced, ctrl.mcListerSynced, ctrl.ccListerSynced) {
+	if !cache.WaitForCacheSync(stopCh, ctrl.mcpListerSynced, ctrl.mcListerSynced, ctrl.ccListerSynced, ctrl.mcnoListerSynced, ctrl.kapListerSynced, ctrl.nodeListerSynced) {
 		return
 	}
 
@@ -196,6 +240,10 @@ func (ctrl *Controller) updateMachineConfig(old, cur interface{}) {
 	oldMC := old.(*mcfgv1.MachineConfig)
 	curMC := cur.(*mcfgv1.MachineConfig)
 
+	if oldMC.Annotations[ctrlcommon.OCIArtifactAnnotationKey] != curMC.Annotations[ctrlcommon.OCIArtifactAnnotationKey] {
+		ctrl.pruneArtifacts()
+	}
+
 	curControllerRef := metav1.GetControllerOf(curMC)
 	oldControllerRef := metav1.GetControllerOf(oldMC)
 	controllerRefChanged := !reflect.DeepEqual(curControllerRef, oldControllerRef)
@@ -240,6 +288,10 @@ func (ctrl *Controller) deleteMachineConfig(obj interface{}) {
 		}
 	}
 
+	if _, ok := mc.Annotations[ctrlcommon.OCIArtifactAnnotationKey]; ok {
+		ctrl.pruneArtifacts()
+	}
+
 	controllerRef := metav1.GetControllerOf(mc)
 	if controllerRef != nil {
 		if pool := ctrl.resolveControllerRef(controllerRef); pool != nil {
@@ -416,6 +468,13 @@ func (ctrl *Controller) syncMachineConfigPool(key string) error {
 		return nil
 	}
 
+	if _, ok := pool.Annotations[ctrlcommon.RollbackToAnnotationKey]; ok {
+		if err := ctrl.syncRollback(pool); err != nil {
+			return ctrl.syncFailingStatus(pool, err)
+		}
+		return ctrl.syncAvailableStatus(pool)
+	}
+
 	selector, err := metav1.LabelSelectorAsSelector(pool.Spec.MachineConfigSelector)
 	if err != nil {
 		return err
@@ -430,6 +489,10 @@ func (ctrl *Controller) syncMachineConfigPool(key string) error {
 	if err != nil {
 		return err
 	}
+	mcs, err = ctrl.syncExpiredMachineConfigs(pool, mcs)
+	if err != nil {
+		return ctrl.syncFailingStatus(pool, err)
+	}
 	if len(mcs) == 0 {
 		return ctrl.syncFailingStatus(pool, fmt.Errorf("no MachineConfigs found matching selector %v", selector))
 	}
@@ -482,13 +545,36 @@ func (ctrl *Controller) syncGeneratedMachineConfig(pool *mcfgv1.MachineConfigPoo
 		return err
 	}
 
-	generated, err := generateRenderedMachineConfig(pool, configs, cc)
+	resolved, err := ctrl.resolveArtifactConfigs(configs, cc)
+	if err != nil {
+		return err
+	}
+
+	if err := ctrl.validateKernelArguments(resolved); err != nil {
+		return err
+	}
+
+	generated, err := generateRenderedMachineConfig(pool, resolved, cc)
+	if err != nil {
+		return err
+	}
+
+	if err := ctrl.validateKernelType(pool, generated); err != nil {
+		return err
+	}
+
+	if err := ctrl.validateReconcilable(pool, resolved); err != nil {
+		return err
+	}
+
+	pool, err = ctrl.reportCRIODropInConflicts(pool, resolved)
 	if err != nil {
 		return err
 	}
 
+	// resolved was sorted by name when merged
 	source := []corev1.ObjectReference{}
-	for _, cfg := range configs {
+	for _, cfg := range resolved {
 		source = append(source, corev1.ObjectReference{Kind: machineconfigKind.Kind, Name: cfg.GetName(), APIVersion: machineconfigKind.GroupVersion().String()})
 	}
 
@@ -501,6 +587,11 @@ func (ctrl *Controller) syncGeneratedMachineConfig(pool *mcfgv1.MachineConfigPoo
 		return err
 	}
 
+	// render the node overrides before the pool targets the config, so their nodes never get it
+	if err := ctrl.syncNodeOverrides(pool, generated); err != nil {
+		return err
+	}
+
 	newPool := pool.DeepCopy()
 	newPool.Spec.Configuration.Source = source
 
@@ -513,6 +604,12 @@ func (ctrl *Controller) syncGeneratedMachineConfig(pool *mcfgv1.MachineConfigPoo
 		return err
 	}
 
+	if pool.Spec.Configuration.Name != "" {
+		if newPool.Annotations == nil {
+			newPool.Annotations = map[string]string{}
+		}
+		newPool.Annotations[ctrlcommon.PreviousRenderedConfigAnnotationKey] = pool.Spec.Configuration.Name
+	}
 	newPool.Spec.Configuration.Name = generated.Name
 	// TODO(walters) Use subresource or JSON patch, but the latter isn't supported by the unit test mocks
 	pool, err = ctrl.client.MachineconfigurationV1().MachineConfigPools().Update(context.TODO(), newPool, metav1.UpdateOptions{})
@@ -528,6 +625,52 @@ func (ctrl *Controller) syncGeneratedMachineConfig(pool *mcfgv1.MachineConfigPoo
 	return nil
 }
 
+// validateReconcilable rejects the configs the daemon can't apply on the running nodes of the pool,
+// compared to the rendered config it targets. What that config has already is only warned about.
+func (ctrl *Controller) validateReconcilable(pool *mcfgv1.MachineConfigPool, configs []*mcfgv1.MachineConfig) error {
+	var current *mcfgv1.MachineConfig
+	if pool.Spec.Configuration.Name != "" {
+		mc, err := ctrl.mcLister.Get(pool.Spec.Configuration.Name)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		current = mc
+	}
+	warnings, err := ctrlcommon.ValidateMachineConfigsReconcilable(configs, current)
+	if len(warnings) > 0 {
+		msg := strings.Join(warnings, "; ")
+		glog.Warningf("Pool %s: the daemon can't update these sections when they change: %s", pool.Name, msg)
+		ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, "UnsupportedIgnitionSections", "The daemon can't update these sections when they change: %s", msg)
+	}
+	return err
+}
+
+// reportCRIODropInConflicts sets the CRIODropInConflict condition of the pool when drop-ins override
+// what ContainerRuntimeConfigs asked for, and removes it otherwise. The pool is still rendered,
+// failing it would stop existing clusters with such drop-ins from getting new configs.
+func (ctrl *Controller) reportCRIODropInConflicts(pool *mcfgv1.MachineConfigPool, configs []*mcfgv1.MachineConfig) (*mcfgv1.MachineConfigPool, error) {
+	conflictErr := ctrlcommon.ValidateCRIODropIns(configs)
+	existing := mcfgv1.GetMachineConfigPoolCondition(pool.Status, mcfgv1.MachineConfigPoolCRIODropInConflict)
+	newPool := pool.DeepCopy()
+	if conflictErr == nil {
+		if existing == nil {
+			return pool, nil
+		}
+		mcfgv1.RemoveMachineConfigPoolCondition(&newPool.Status, mcfgv1.MachineConfigPoolCRIODropInConflict)
+	} else {
+		if existing != nil && existing.Message == conflictErr.Error() {
+			return pool, nil
+		}
+		glog.Warningf("Pool %s: %v", pool.Name, conflictErr)
+		if existing == nil {
+			ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, "CRIODropInConflict", "%v", conflictErr)
+		}
+		cond := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolCRIODropInConflict, corev1.ConditionTrue, "CRIODropInConflict", conflictErr.Error())
+		mcfgv1.SetMachineConfigPoolCondition(&newPool.Status, *cond)
+	}
+	return ctrl.client.MachineconfigurationV1().MachineConfigPools().UpdateStatus(context.TODO(), newPool, metav1.UpdateOptions{})
+}
+
 // generateRenderedMachineConfig takes all MCs for a given pool and returns a single rendered MC. For ex master-XXXX or worker-XXXX
 func generateRenderedMachineConfig(pool *mcfgv1.MachineConfigPool, configs []*mcfgv1.MachineConfig, cconfig *mcfgv1.ControllerConfig) (*mcfgv1.MachineConfig, error) {
 	// Suppress rendered config generation until a corresponding new controller can roll out too.
@@ -541,16 +684,18 @@ func generateRenderedMachineConfig(pool *mcfgv1.MachineConfigPool, configs []*mc
 	}
 
 	// Before merging all MCs for a specific pool, let's make sure MachineConfigs are valid
-	for _, config := range configs {
-		if err := ctrlcommon.ValidateMachineConfig(config.Spec); err != nil {
-			return nil, err
-		}
+	if err := ctrlcommon.ValidateMachineConfigs(configs); err != nil {
+		return nil, err
 	}
 
 	merged, err := ctrlcommon.MergeMachineConfigs(configs, cconfig.Spec.OSImageURL)
 	if err != nil {
 		return nil, err
 	}
+	// Merging can't make valid configs invalid, unless Ignition's merge semantics change under us
+	if err := ctrlcommon.ValidateMachineConfig(merged.Spec); err != nil {
+		return nil, fmt.Errorf("invalid rendered MachineConfig: %v", err)
+	}
 	hashedName, err := getMachineConfigHashedName(pool, merged)
 	if err != nil {
 		return nil, err
@@ -563,6 +708,9 @@ func generateRenderedMachineConfig(pool *mcfgv1.MachineConfigPool, configs []*mc
 		merged.Annotations = map[string]string{}
 	}
 	merged.Annotations[ctrlcommon.GeneratedByControllerVersionAnnotationKey] = version.Hash
+	if err := ctrlcommon.SetSourceAnnotations(merged, configs); err != nil {
+		return nil, err
+	}
 
 	return merged, nil
 }
@@ -570,7 +718,7 @@ func generateRenderedMachineConfig(pool *mcfgv1.MachineConfigPool, configs []*mc
 // RunBootstrap runs the render controller in bootstrap mode.
 // For each pool, it matches the machineconfigs based on label selector and
 // returns the generated machineconfigs and pool with CurrentMachineConfig status field set.
-func RunBootstrap(pools []*mcfgv1.MachineConfigPool, configs []*mcfgv1.MachineConfig, cconfig *mcfgv1.ControllerConfig) ([]*mcfgv1.MachineConfigPool, []*mcfgv1.MachineConfig, error) {
+func RunBootstrap(pools []*mcfgv1.MachineConfigPool, configs []*mcfgv1.MachineConfig, cconfig *mcfgv1.ControllerConfig, policies []*mcfgv1.KernelArgumentPolicy) ([]*mcfgv1.MachineConfigPool, []*mcfgv1.MachineConfig, error) {
 	var (
 		opools   []*mcfgv1.MachineConfigPool
 		oconfigs []*mcfgv1.MachineConfig
@@ -580,6 +728,15 @@ func RunBootstrap(pools []*mcfgv1.MachineConfigPool, configs []*mcfgv1.MachineCo
 		if err != nil {
 			return nil, nil, err
 		}
+		// the bootstrap node can't pull from registries with the cluster's mirrors and pull secret yet
+		for _, mc := range pcs {
+			if _, ok := mc.Annotations[ctrlcommon.OCIArtifactAnnotationKey]; ok {
+				return nil, nil, fmt.Errorf("MachineConfig %s references an OCI artifact, which isn't supported at install time", mc.Name)
+			}
+		}
+		if err := ctrlcommon.ValidateMachineConfigsKernelArguments(pcs, policies); err != nil {
+			return nil, nil, err
+		}
 
 		generated, err := generateRenderedMachineConfig(pool, pcs, cconfig)
 		if err != nil {
@@ -1,16 +1,24 @@
 package render
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/clarketm/json"
+	imagetypes "github.com/containers/image/v5/types"
 	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	digest "github.com/opencontainers/go-digest"
+	imgspecv1 "github.com/opencontainers/image-spec/specs-go/v1"
 	configv1 "github.com/openshift/api/config/v1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/vincent-petithory/dataurl"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -19,6 +27,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/diff"
 	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	kubeinformers "k8s.io/client-go/informers"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
 	core "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/cache"
@@ -43,9 +52,12 @@ type fixture struct {
 
 	client *fake.Clientset
 
-	mcpLister []*mcfgv1.MachineConfigPool
-	mcLister  []*mcfgv1.MachineConfig
-	ccLister  []*mcfgv1.ControllerConfig
+	mcpLister  []*mcfgv1.MachineConfigPool
+	mcLister   []*mcfgv1.MachineConfig
+	ccLister   []*mcfgv1.ControllerConfig
+	mcnoLister []*mcfgv1.MachineConfigNodeOverride
+	kapLister  []*mcfgv1.KernelArgumentPolicy
+	nodeLister []*corev1.Node
 
 	actions []core.Action
 
@@ -63,19 +75,26 @@ func (f *fixture) newController() *Controller {
 	f.client = fake.NewSimpleClientset(f.objects...)
 
 	i := informers.NewSharedInformerFactory(f.client, noResyncPeriodFunc())
+	k8sI := kubeinformers.NewSharedInformerFactory(k8sfake.NewSimpleClientset(), noResyncPeriodFunc())
 
 	c := New(i.Machineconfiguration().V1().MachineConfigPools(), i.Machineconfiguration().V1().MachineConfigs(),
-		i.Machineconfiguration().V1().ControllerConfigs(), k8sfake.NewSimpleClientset(), f.client)
+		i.Machineconfiguration().V1().ControllerConfigs(), i.Machineconfiguration().V1().MachineConfigNodeOverrides(),
+		i.Machineconfiguration().V1().KernelArgumentPolicies(), k8sI.Core().V1().Nodes(), k8sfake.NewSimpleClientset(), f.client)
 
 	c.mcpListerSynced = alwaysReady
 	c.mcListerSynced = alwaysReady
 	c.ccListerSynced = alwaysReady
+	c.mcnoListerSynced = alwaysReady
+	c.kapListerSynced = alwaysReady
+	c.nodeListerSynced = alwaysReady
 	c.eventRecorder = &record.FakeRecorder{}
 
 	stopCh := make(chan struct{})
 	defer close(stopCh)
 	i.Start(stopCh)
 	i.WaitForCacheSync(stopCh)
+	k8sI.Start(stopCh)
+	k8sI.WaitForCacheSync(stopCh)
 
 	for _, c := range f.ccLister {
 		i.Machineconfiguration().V1().ControllerConfigs().Informer().GetIndexer().Add(c)
@@ -86,6 +105,15 @@ func (f *fixture) newController() *Controller {
 	for _, m := range f.mcLister {
 		i.Machineconfiguration().V1().MachineConfigs().Informer().GetIndexer().Add(m)
 	}
+	for _, o := range f.mcnoLister {
+		i.Machineconfiguration().V1().MachineConfigNodeOverrides().Informer().GetIndexer().Add(o)
+	}
+	for _, p := range f.kapLister {
+		i.Machineconfiguration().V1().KernelArgumentPolicies().Informer().GetIndexer().Add(p)
+	}
+	for _, n := range f.nodeLister {
+		k8sI.Core().V1().Nodes().Informer().GetIndexer().Add(n)
+	}
 
 	for _, m := range f.ccLister {
 		i.Machineconfiguration().V1().ControllerConfigs().Informer().GetIndexer().Add(m)
@@ -184,7 +212,11 @@ func filterInformerActions(actions []core.Action) []core.Action {
 				action.Matches("list", "controllerconfigs") ||
 				action.Matches("watch", "controllerconfigs") ||
 				action.Matches("list", "machineconfigs") ||
-				action.Matches("watch", "machineconfigs")) {
+				action.Matches("watch", "machineconfigs") ||
+				action.Matches("list", "machineconfignodeoverrides") ||
+				action.Matches("watch", "machineconfignodeoverrides") ||
+				action.Matches("list", "kernelargumentpolicies") ||
+				action.Matches("watch", "kernelargumentpolicies")) {
 			continue
 		}
 		ret = append(ret, action)
@@ -316,6 +348,40 @@ func TestIgnValidationGenerateRenderedMachineConfig(t *testing.T) {
 
 }
 
+// Configs the daemon can't apply are rejected at render time when they change the rendered config
+// of the pool, and only warned about when it has them already
+func TestValidateReconcilable(t *testing.T) {
+	f := newFixture(t)
+	remote := ign3types.File{
+		Node:          ign3types.Node{Path: "/etc/remote"},
+		FileEmbedded1: ign3types.FileEmbedded1{Contents: ign3types.Resource{Source: helpers.StrToPtr("https://example.com/remote")}},
+	}
+	appended := ign3types.File{
+		Node:          ign3types.Node{Path: "/etc/appended"},
+		FileEmbedded1: ign3types.FileEmbedded1{Append: []ign3types.Resource{{Source: helpers.StrToPtr("data:,a")}}},
+	}
+	mcs := []*mcfgv1.MachineConfig{
+		helpers.NewMachineConfig("00-test-cluster-master", map[string]string{"node-role/master": ""}, "dummy://", nil),
+		helpers.NewMachineConfig("05-remote-master", map[string]string{"node-role/master": ""}, "", []ign3types.File{remote}),
+		helpers.NewMachineConfig("06-append-master", map[string]string{"node-role/master": ""}, "", []ign3types.File{appended}),
+	}
+	current := helpers.NewMachineConfig("rendered-master-0", nil, "dummy://", []ign3types.File{remote})
+	f.mcLister = append(f.mcLister, current)
+	c := f.newController()
+	recorder := record.NewFakeRecorder(10)
+	c.eventRecorder = recorder
+	mcp := helpers.NewMachineConfigPool("test-cluster-master", helpers.MasterSelector, nil, current.Name)
+
+	err := c.validateReconcilable(mcp, mcs)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "MachineConfig 06-append-master: ignition file /etc/appended includes append")
+	assert.NotContains(t, err.Error(), "05-remote-master")
+	assert.Contains(t, <-recorder.Events, "MachineConfig 05-remote-master: ignition file /etc/remote has a remote source")
+
+	require.Nil(t, c.validateReconcilable(mcp, mcs[:2]))
+	assert.Contains(t, <-recorder.Events, "UnsupportedIgnitionSections")
+}
+
 func TestUpdatesGeneratedMachineConfig(t *testing.T) {
 	f := newFixture(t)
 	mcp := helpers.NewMachineConfigPool("test-cluster-master", helpers.MasterSelector, nil, "")
@@ -515,3 +581,391 @@ func TestMachineConfigsNoBailWithoutPool(t *testing.T) {
 	c.deleteMachineConfig(mc)
 	require.Len(t, queue, 3)
 }
+
+func TestGetExpiry(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	newExpiringMC := func(expires string) *mcfgv1.MachineConfig {
+		mc := helpers.NewMachineConfig("99-debug", map[string]string{"node-role/worker": ""}, "", nil)
+		mc.Annotations = map[string]string{ctrlcommon.MachineConfigExpiresAnnotationKey: expires}
+		return mc
+	}
+
+	exp, err := getExpiry(helpers.NewMachineConfig("00-worker", nil, "", nil), now)
+	require.Nil(t, err)
+	assert.Equal(t, expiry{}, exp)
+
+	exp, err = getExpiry(newExpiringMC("2021-03-01T11:00:00Z"), now)
+	require.Nil(t, err)
+	assert.Equal(t, expiry{expired: true}, exp)
+
+	exp, err = getExpiry(newExpiringMC("2021-03-01T18:00:00Z"), now)
+	require.Nil(t, err)
+	assert.Equal(t, expiry{expiring: true, recheckIn: 6 * time.Hour}, exp)
+
+	exp, err = getExpiry(newExpiringMC("2021-03-03T12:00:00Z"), now)
+	require.Nil(t, err)
+	assert.Equal(t, expiry{recheckIn: 24 * time.Hour}, exp)
+
+	_, err = getExpiry(newExpiringMC("tomorrow"), now)
+	assert.NotNil(t, err)
+}
+
+func TestSyncExpiredMachineConfigs(t *testing.T) {
+	f := newFixture(t)
+	mcp := helpers.NewMachineConfigPool("test-cluster-worker", helpers.WorkerSelector, nil, "")
+	expired := helpers.NewMachineConfig("99-debug", map[string]string{"node-role/worker": ""}, "", nil)
+	expired.Annotations = map[string]string{ctrlcommon.MachineConfigExpiresAnnotationKey: time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)}
+	expiring := helpers.NewMachineConfig("99-verbose", map[string]string{"node-role/worker": ""}, "", nil)
+	expiring.Annotations = map[string]string{ctrlcommon.MachineConfigExpiresAnnotationKey: time.Now().Add(time.Hour).UTC().Format(time.RFC3339)}
+	mcs := []*mcfgv1.MachineConfig{
+		helpers.NewMachineConfig("00-worker", map[string]string{"node-role/worker": ""}, "", nil),
+		expired,
+		expiring,
+	}
+	for _, mc := range mcs {
+		f.objects = append(f.objects, mc)
+	}
+	c := f.newController()
+	recorder := record.NewFakeRecorder(10)
+	c.eventRecorder = recorder
+
+	live, err := c.syncExpiredMachineConfigs(mcp, mcs)
+	require.Nil(t, err)
+	assert.Equal(t, []*mcfgv1.MachineConfig{mcs[0], expiring}, live)
+
+	actions := filterInformerActions(f.client.Actions())
+	require.Len(t, actions, 1)
+	assert.True(t, actions[0].Matches("delete", "machineconfigs"))
+	assert.Equal(t, "99-debug", actions[0].(core.DeleteAction).GetName())
+	assert.Contains(t, <-recorder.Events, "MachineConfigExpired")
+	assert.Contains(t, <-recorder.Events, "MachineConfigExpiring")
+}
+
+// newArtifactRegistry serves an OCI artifact whose single layer is content, returning the
+// registry and the artifact reference
+func newArtifactRegistry(t *testing.T, content []byte) (*httptest.Server, string) {
+	layerDigest := digest.FromBytes(content)
+	manifestBlob, err := json.Marshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"mediaType":     imgspecv1.MediaTypeImageManifest,
+		"config": map[string]interface{}{
+			"mediaType": "application/vnd.openshift.machineconfig.config.v1+json",
+			"digest":    digest.FromBytes([]byte("{}")),
+			"size":      2,
+		},
+		"layers": []map[string]interface{}{{
+			"mediaType": "application/vnd.coreos.ignition+json",
+			"digest":    layerDigest,
+			"size":      len(content),
+		}},
+	})
+	require.Nil(t, err)
+	manifestDigest := digest.FromBytes(manifestBlob)
+
+	registry := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/":
+		case "/v2/configs/manifests/" + manifestDigest.String():
+			w.Header().Set("Content-Type", imgspecv1.MediaTypeImageManifest)
+			w.Write(manifestBlob)
+		case "/v2/configs/blobs/" + layerDigest.String():
+			w.Write(content)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	return registry, strings.TrimPrefix(registry.URL, "https://") + "/configs@" + manifestDigest.String()
+}
+
+func TestFetchArtifact(t *testing.T) {
+	content := helpers.MarshalOrDie(ctrlcommon.NewIgnConfig())
+	registry, artifact := newArtifactRegistry(t, content)
+	defer registry.Close()
+	sys := &imagetypes.SystemContext{DockerInsecureSkipTLSVerify: imagetypes.OptionalBoolTrue}
+
+	fetched, err := fetchArtifact(context.TODO(), sys, artifact)
+	require.Nil(t, err)
+	assert.Equal(t, content, fetched)
+
+	// a different digest than the one the artifact is pinned to
+	_, err = fetchArtifact(context.TODO(), sys, strings.Split(artifact, "@")[0]+"@"+digest.FromBytes([]byte("other")).String())
+	assert.NotNil(t, err)
+
+	_, err = fetchArtifact(context.TODO(), sys, strings.Split(artifact, "@")[0]+":latest")
+	assert.NotNil(t, err)
+}
+
+func TestResolveArtifactConfigs(t *testing.T) {
+	content := helpers.MarshalOrDie(ctrlcommon.NewIgnConfig())
+	pinned := "quay.io/example/configs@" + digest.FromBytes([]byte("manifest")).String()
+	base := helpers.NewMachineConfig("00-worker", map[string]string{"node-role/worker": ""}, "", nil)
+	fromArtifact := helpers.NewMachineConfig("50-worker-bundle", map[string]string{"node-role/worker": ""}, "", nil)
+	fromArtifact.Spec.Config.Raw = nil
+	fromArtifact.Annotations = map[string]string{ctrlcommon.OCIArtifactAnnotationKey: pinned}
+
+	f := newFixture(t)
+	c := f.newController()
+	c.artifacts[digest.FromBytes([]byte("manifest"))] = content
+	resolved, err := c.resolveArtifactConfigs([]*mcfgv1.MachineConfig{base, fromArtifact}, newControllerConfig(ctrlcommon.ControllerConfigName))
+	require.Nil(t, err)
+	assert.Equal(t, base, resolved[0])
+	assert.Equal(t, content, resolved[1].Spec.Config.Raw)
+	assert.Nil(t, fromArtifact.Spec.Config.Raw, "the lister's copy must not be modified")
+
+	fromArtifact.Annotations[ctrlcommon.OCIArtifactAnnotationKey] = "quay.io/example/configs:latest"
+	_, err = c.resolveArtifactConfigs([]*mcfgv1.MachineConfig{base, fromArtifact}, newControllerConfig(ctrlcommon.ControllerConfigName))
+	assert.NotNil(t, err)
+
+	fromArtifact.Annotations[ctrlcommon.OCIArtifactAnnotationKey] = pinned
+	fromArtifact.Spec.Config.Raw = content
+	_, err = c.resolveArtifactConfigs([]*mcfgv1.MachineConfig{base, fromArtifact}, newControllerConfig(ctrlcommon.ControllerConfigName))
+	assert.NotNil(t, err)
+}
+
+func TestPruneArtifacts(t *testing.T) {
+	referenced := digest.FromBytes([]byte("referenced"))
+	unreferenced := digest.FromBytes([]byte("unreferenced"))
+	mc := helpers.NewMachineConfig("50-worker-bundle", map[string]string{"node-role/worker": ""}, "", nil)
+	mc.Annotations = map[string]string{ctrlcommon.OCIArtifactAnnotationKey: "quay.io/example/configs@" + referenced.String()}
+
+	f := newFixture(t)
+	f.mcLister = append(f.mcLister, mc)
+	c := f.newController()
+	c.artifacts[referenced] = []byte("{}")
+	c.artifacts[unreferenced] = []byte("{}")
+
+	c.pruneArtifacts()
+	assert.Contains(t, c.artifacts, referenced)
+	assert.NotContains(t, c.artifacts, unreferenced)
+}
+
+func TestFileContents(t *testing.T) {
+	newRegistriesMC := func(name, contents string) *mcfgv1.MachineConfig {
+		return helpers.NewMachineConfig(name, nil, "", []ign3types.File{{
+			Node: ign3types.Node{Path: registriesConfigPath},
+			FileEmbedded1: ign3types.FileEmbedded1{
+				Contents: ign3types.Resource{Source: helpers.StrToPtr(dataurl.EncodeBytes([]byte(contents)))},
+			},
+		}})
+	}
+	configs := []*mcfgv1.MachineConfig{
+		newRegistriesMC("99-worker-generated-registries", "mirrored"),
+		newRegistriesMC("00-worker", "default"),
+		helpers.NewMachineConfig("01-worker-kubelet", nil, "", nil),
+	}
+	contents, err := fileContents(configs, registriesConfigPath)
+	require.Nil(t, err)
+	assert.Equal(t, "mirrored", string(contents))
+
+	contents, err = fileContents(configs, "/etc/missing")
+	require.Nil(t, err)
+	assert.Nil(t, contents)
+}
+
+func TestSyncRollback(t *testing.T) {
+	newRendered := func(name string, pool *mcfgv1.MachineConfigPool) *mcfgv1.MachineConfig {
+		mc := helpers.NewMachineConfig(name, nil, "", nil)
+		mc.OwnerReferences = []metav1.OwnerReference{*metav1.NewControllerRef(pool, controllerKind)}
+		return mc
+	}
+	mcp := helpers.NewMachineConfigPool("worker", helpers.WorkerSelector, nil, "rendered-worker-2")
+	other := helpers.NewMachineConfigPool("infra", helpers.InfraSelector, nil, "rendered-infra-1")
+	mcp.Annotations = map[string]string{
+		ctrlcommon.PreviousRenderedConfigAnnotationKey: "rendered-worker-1",
+		ctrlcommon.RollbackToAnnotationKey:             ctrlcommon.RollbackToPrevious,
+	}
+
+	f := newFixture(t)
+	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newRendered("rendered-worker-1", mcp), newRendered("rendered-worker-2", mcp), newRendered("rendered-infra-1", other))
+	c := f.newController()
+
+	require.Nil(t, c.syncRollback(mcp))
+	actions := filterInformerActions(f.client.Actions())
+	require.Len(t, actions, 1)
+	updated := actions[0].(core.UpdateAction).GetObject().(*mcfgv1.MachineConfigPool)
+	assert.Equal(t, "rendered-worker-1", updated.Spec.Configuration.Name)
+	assert.Equal(t, "rendered-worker-1", updated.Annotations[ctrlcommon.RollbackToAnnotationKey])
+	assert.Equal(t, "rendered-worker-2", updated.Annotations[ctrlcommon.PreviousRenderedConfigAnnotationKey])
+
+	// pinned already
+	require.Nil(t, c.syncRollback(updated))
+	assert.Len(t, filterInformerActions(f.client.Actions()), 1)
+
+	mcp.Annotations[ctrlcommon.RollbackToAnnotationKey] = "rendered-infra-1"
+	assert.NotNil(t, c.syncRollback(mcp))
+	mcp.Annotations[ctrlcommon.RollbackToAnnotationKey] = "rendered-worker-0"
+	assert.NotNil(t, c.syncRollback(mcp))
+	delete(mcp.Annotations, ctrlcommon.PreviousRenderedConfigAnnotationKey)
+	mcp.Annotations[ctrlcommon.RollbackToAnnotationKey] = ctrlcommon.RollbackToPrevious
+	assert.NotNil(t, c.syncRollback(mcp))
+}
+
+func TestSyncNodeOverrides(t *testing.T) {
+	pool := helpers.NewMachineConfigPool("worker", helpers.WorkerSelector, nil, "rendered-worker-1")
+	poolConfig := helpers.NewMachineConfig("rendered-worker-1", nil, "dummy", []ign3types.File{{Node: ign3types.Node{Path: "/etc/motd"}}})
+	newOverride := func(name, poolName string, config []byte) *mcfgv1.MachineConfigNodeOverride {
+		return &mcfgv1.MachineConfigNodeOverride{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Generation: 1},
+			Spec:       mcfgv1.MachineConfigNodeOverrideSpec{NodeName: name, Config: runtime.RawExtension{Raw: config}},
+			Status:     mcfgv1.MachineConfigNodeOverrideStatus{Pool: poolName},
+		}
+	}
+	ignCfg := ctrlcommon.NewIgnConfig()
+	ignCfg.Storage.Files = []ign3types.File{{Node: ign3types.Node{Path: "/etc/hostname"}}}
+	node0 := newOverride("node-0", "worker", helpers.MarshalOrDie(ignCfg))
+	invalid := newOverride("node-1", "worker", []byte(`{"ignition": {"version": "9.9.9"}}`))
+	infra := newOverride("node-2", "infra", helpers.MarshalOrDie(ignCfg))
+	rendered := newOverride("node-3", "worker", helpers.MarshalOrDie(ignCfg))
+	rendered.Status.ObservedGeneration = 1
+	rendered.Status.Configurations = []mcfgv1.MachineConfigNodeOverrideConfiguration{{PoolConfig: "rendered-worker-1", NodeConfig: "rendered-worker-2"}}
+
+	f := newFixture(t)
+	f.ccLister = append(f.ccLister, newControllerConfig(ctrlcommon.ControllerConfigName))
+	f.mcpLister = append(f.mcpLister, pool)
+	f.mcLister = append(f.mcLister, poolConfig)
+	f.mcnoLister = append(f.mcnoLister, node0, invalid, infra, rendered)
+	f.objects = append(f.objects, pool, poolConfig, node0, invalid, infra, rendered)
+	c := f.newController()
+
+	require.Nil(t, c.syncNodeOverrides(pool, poolConfig))
+
+	created, err := f.client.MachineconfigurationV1().MachineConfigs().List(context.TODO(), metav1.ListOptions{})
+	require.Nil(t, err)
+	require.Len(t, created.Items, 2)
+	nodeConfig := created.Items[0]
+	if nodeConfig.Name == poolConfig.Name {
+		nodeConfig = created.Items[1]
+	}
+	assert.Equal(t, "dummy", nodeConfig.Spec.OSImageURL)
+	for _, path := range []string{"/etc/motd", "/etc/hostname"} {
+		_, err := ctrlcommon.GetFileSource(&nodeConfig, path)
+		assert.Nil(t, err, path)
+	}
+
+	override, err := f.client.MachineconfigurationV1().MachineConfigNodeOverrides().Get(context.TODO(), "node-0", metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Equal(t, int64(1), override.Status.ObservedGeneration)
+	assert.Equal(t, []mcfgv1.MachineConfigNodeOverrideConfiguration{{PoolConfig: "rendered-worker-1", NodeConfig: nodeConfig.Name}}, override.Status.Configurations)
+	assert.Equal(t, mcfgv1.MachineConfigNodeOverrideSuccess, override.Status.Conditions[0].Type)
+
+	override, err = f.client.MachineconfigurationV1().MachineConfigNodeOverrides().Get(context.TODO(), "node-1", metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Empty(t, override.Status.Configurations)
+	assert.Equal(t, mcfgv1.MachineConfigNodeOverrideFailure, override.Status.Conditions[0].Type)
+
+	// the overrides of other pools and the ones rendered already are left alone
+	for _, name := range []string{"node-2", "node-3"} {
+		override, err = f.client.MachineconfigurationV1().MachineConfigNodeOverrides().Get(context.TODO(), name, metav1.GetOptions{})
+		require.Nil(t, err)
+		assert.Empty(t, override.Status.Conditions, name)
+	}
+
+	// the node configs for previous pool configs are kept, unless the override changed since
+	require.Nil(t, c.syncNodeOverrideStatus("node-3", "rendered-worker-4", "rendered-worker-5", nil))
+	override, err = f.client.MachineconfigurationV1().MachineConfigNodeOverrides().Get(context.TODO(), "node-3", metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Equal(t, []mcfgv1.MachineConfigNodeOverrideConfiguration{
+		{PoolConfig: "rendered-worker-4", NodeConfig: "rendered-worker-5"},
+		{PoolConfig: "rendered-worker-1", NodeConfig: "rendered-worker-2"},
+	}, override.Status.Configurations)
+	override.Generation = 2
+	_, err = f.client.MachineconfigurationV1().MachineConfigNodeOverrides().Update(context.TODO(), override, metav1.UpdateOptions{})
+	require.Nil(t, err)
+	require.Nil(t, c.syncNodeOverrideStatus("node-3", "rendered-worker-4", "rendered-worker-6", nil))
+	override, err = f.client.MachineconfigurationV1().MachineConfigNodeOverrides().Get(context.TODO(), "node-3", metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Equal(t, []mcfgv1.MachineConfigNodeOverrideConfiguration{{PoolConfig: "rendered-worker-4", NodeConfig: "rendered-worker-6"}}, override.Status.Configurations)
+	assert.Equal(t, int64(2), override.Status.ObservedGeneration)
+}
+
+func TestValidateKernelArguments(t *testing.T) {
+	f := newFixture(t)
+	mc := helpers.NewMachineConfig("99-selinux", map[string]string{"node-role/master": ""}, "", nil)
+	mc.Spec.KernelArguments = []string{"selinux=0"}
+
+	c := f.newController()
+	assert.EqualError(t, c.validateKernelArguments([]*mcfgv1.MachineConfig{mc}),
+		"MachineConfig 99-selinux: kernel arguments denied by policy: selinux=0")
+
+	f.kapLister = append(f.kapLister, &mcfgv1.KernelArgumentPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "allow-selinux"},
+		Spec:       mcfgv1.KernelArgumentPolicySpec{AllowedKernelArguments: []string{"selinux=0"}},
+	})
+	c = f.newController()
+	assert.Nil(t, c.validateKernelArguments([]*mcfgv1.MachineConfig{mc}))
+}
+
+func TestReportCRIODropInConflicts(t *testing.T) {
+	f := newFixture(t)
+	mcp := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "")
+	dropIn := func(path, contents string) ign3types.File {
+		return ign3types.File{
+			Node: ign3types.Node{Path: path},
+			FileEmbedded1: ign3types.FileEmbedded1{
+				Contents: ign3types.Resource{Source: helpers.StrToPtr(dataurl.EncodeBytes([]byte(contents)))},
+			},
+		}
+	}
+	ctrcfg := helpers.NewMachineConfig("99-worker-generated-containerruntime", nil, "", []ign3types.File{
+		dropIn("/etc/crio/crio.conf.d/01-ctrcfg-logLevel", "[crio.runtime]\nlog_level = \"debug\"\n"),
+	})
+	isController := true
+	ctrcfg.SetOwnerReferences([]metav1.OwnerReference{{Kind: "ContainerRuntimeConfig", Name: "set-log-level", Controller: &isController}})
+	custom := helpers.NewMachineConfig("50-worker-crio", nil, "", []ign3types.File{
+		dropIn("/etc/crio/crio.conf.d/99-custom", "[crio.runtime]\nlog_level = \"info\"\n"),
+	})
+
+	f.objects = append(f.objects, mcp)
+	c := f.newController()
+	recorder := record.NewFakeRecorder(10)
+	c.eventRecorder = recorder
+
+	pool, err := c.reportCRIODropInConflicts(mcp, []*mcfgv1.MachineConfig{ctrcfg})
+	require.Nil(t, err)
+	assert.Nil(t, mcfgv1.GetMachineConfigPoolCondition(pool.Status, mcfgv1.MachineConfigPoolCRIODropInConflict))
+	assert.Empty(t, recorder.Events)
+	assert.Empty(t, filterInformerActions(f.client.Actions()))
+
+	// the conflict is reported in a condition, the event is only emitted when it appears
+	pool, err = c.reportCRIODropInConflicts(pool, []*mcfgv1.MachineConfig{ctrcfg, custom})
+	require.Nil(t, err)
+	cond := mcfgv1.GetMachineConfigPoolCondition(pool.Status, mcfgv1.MachineConfigPoolCRIODropInConflict)
+	require.NotNil(t, cond)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Contains(t, cond.Message, "/etc/crio/crio.conf.d/99-custom from MachineConfig 50-worker-crio overrides")
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, "/etc/crio/crio.conf.d/99-custom from MachineConfig 50-worker-crio overrides")
+	pool, err = c.reportCRIODropInConflicts(pool, []*mcfgv1.MachineConfig{ctrcfg, custom})
+	require.Nil(t, err)
+	assert.Empty(t, recorder.Events)
+	assert.Len(t, filterInformerActions(f.client.Actions()), 1)
+
+	pool, err = c.reportCRIODropInConflicts(pool, []*mcfgv1.MachineConfig{ctrcfg})
+	require.Nil(t, err)
+	assert.Nil(t, mcfgv1.GetMachineConfigPoolCondition(pool.Status, mcfgv1.MachineConfigPoolCRIODropInConflict))
+}
+
+func TestValidateKernelType(t *testing.T) {
+	f := newFixture(t)
+	mcp := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "")
+	node := func(name, arch string) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"node-role/worker": ""}},
+			Status:     corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{Architecture: arch}},
+		}
+	}
+	mc := helpers.NewMachineConfig("rendered-worker", nil, "", nil)
+	mc.Spec.KernelType = ctrlcommon.KernelType64kPages
+
+	f.nodeLister = append(f.nodeLister, node("node-0", "arm64"))
+	c := f.newController()
+	assert.Nil(t, c.validateKernelType(mcp, mc))
+
+	f.nodeLister = append(f.nodeLister, node("node-1", "amd64"))
+	c = f.newController()
+	assert.EqualError(t, c.validateKernelType(mcp, mc), "kernelType 64k-pages is not supported on the architecture of nodes node-1 (amd64)")
+
+	mc.Spec.KernelType = ctrlcommon.KernelTypeRealtime
+	assert.Nil(t, c.validateKernelType(mcp, mc))
+}
@@ -0,0 +1,67 @@
+package render
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+// rollbackTarget returns the rendered config the pool is rolled back to
+func (ctrl *Controller) rollbackTarget(pool *mcfgv1.MachineConfigPool) (string, error) {
+	target := pool.Annotations[ctrlcommon.RollbackToAnnotationKey]
+	if target == ctrlcommon.RollbackToPrevious {
+		target = pool.Annotations[ctrlcommon.PreviousRenderedConfigAnnotationKey]
+		if target == "" {
+			return "", fmt.Errorf("pool %s has no previous rendered config to roll back to", pool.Name)
+		}
+	}
+	mc, err := ctrl.mcLister.Get(target)
+	if err != nil {
+		return "", fmt.Errorf("getting rendered config %s to roll back to: %v", target, err)
+	}
+	if ref := metav1.GetControllerOf(mc); ref == nil || ref.Kind != controllerKind.Kind || ref.Name != pool.Name {
+		return "", fmt.Errorf("%s isn't a rendered config of pool %s", target, pool.Name)
+	}
+	return target, nil
+}
+
+// syncRollback pins the pool to the rendered config it is rolled back to. The node controller
+// then rolls the nodes back like for any other config change.
+func (ctrl *Controller) syncRollback(pool *mcfgv1.MachineConfigPool) error {
+	target, err := ctrl.rollbackTarget(pool)
+	if err != nil {
+		return err
+	}
+	targetConfig, err := ctrl.mcLister.Get(target)
+	if err != nil {
+		return err
+	}
+	if err := ctrl.syncNodeOverrides(pool, targetConfig); err != nil {
+		return err
+	}
+	if pool.Spec.Configuration.Name == target && pool.Annotations[ctrlcommon.RollbackToAnnotationKey] == target {
+		return nil
+	}
+
+	newPool := pool.DeepCopy()
+	// resolve previous once, so the pool stays on the same config until the annotation is removed
+	newPool.Annotations[ctrlcommon.RollbackToAnnotationKey] = target
+	if newPool.Spec.Configuration.Name != target {
+		newPool.Annotations[ctrlcommon.PreviousRenderedConfigAnnotationKey] = pool.Spec.Configuration.Name
+		newPool.Spec.Configuration.Name = target
+	}
+	if _, err := ctrl.client.MachineconfigurationV1().MachineConfigPools().Update(context.TODO(), newPool, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	if pool.Spec.Configuration.Name != target {
+		glog.Infof("Pool %s: rolling back from %s to %s", pool.Name, pool.Spec.Configuration.Name, target)
+		ctrl.eventRecorder.Eventf(pool, corev1.EventTypeNormal, "RollingBack", "Rolling back from %s to %s", pool.Spec.Configuration.Name, target)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package template
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/vincent-petithory/dataurl"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+// updateGolden regenerates the golden files instead of comparing against them:
+//
+//	go test ./pkg/controller/template/ -run TestGoldenTemplates -update
+var updateGolden = flag.Bool("update", false, "update the golden files of TestGoldenTemplates")
+
+const goldenDir = "./test_data/golden"
+
+// TestGoldenTemplates renders the templates for every platform of the test_data ControllerConfigs
+// and compares the MachineConfigs to test_data/golden/<platform>/<machineconfig>. Any change to
+// the rendered files and units, including their order and whitespace, shows up in the golden files.
+func TestGoldenTemplates(t *testing.T) {
+	var platforms []string
+	for platform := range configs {
+		platforms = append(platforms, platform)
+	}
+	sort.Strings(platforms)
+
+	for _, platform := range platforms {
+		t.Run(platform, func(t *testing.T) {
+			controllerConfig, err := controllerConfigFromFile(configs[platform])
+			if err != nil {
+				t.Fatalf("failed to get controllerconfig config: %v", err)
+			}
+			cfgs, err := generateTemplateMachineConfigs(&RenderConfig{&controllerConfig.Spec, `{"dummy":"dummy"}`, nil}, templateDir)
+			if err != nil {
+				t.Fatalf("failed to generate machine configs: %v", err)
+			}
+
+			dir := filepath.Join(goldenDir, platform)
+			if *updateGolden {
+				if err := os.RemoveAll(dir); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.MkdirAll(dir, 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			rendered := make(map[string]bool)
+			for _, cfg := range cfgs {
+				rendered[cfg.Name] = true
+				actual, err := goldenDump(cfg)
+				if err != nil {
+					t.Fatalf("failed to dump %s: %v", cfg.Name, err)
+				}
+				path := filepath.Join(dir, cfg.Name)
+				if *updateGolden {
+					if err := ioutil.WriteFile(path, []byte(actual), 0644); err != nil {
+						t.Fatal(err)
+					}
+					continue
+				}
+				expected, err := ioutil.ReadFile(path)
+				if err != nil {
+					t.Errorf("no golden file for %s, run the test with -update to create it: %v", cfg.Name, err)
+					continue
+				}
+				if string(expected) != actual {
+					diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+						A:        difflib.SplitLines(string(expected)),
+						B:        difflib.SplitLines(actual),
+						FromFile: path,
+						ToFile:   "rendered",
+						Context:  3,
+					})
+					t.Errorf("%s differs from its golden file, run the test with -update if the change is intended:\n%s", cfg.Name, diff)
+				}
+			}
+
+			golden, err := ioutil.ReadDir(dir)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", dir, err)
+			}
+			for _, f := range golden {
+				if !rendered[f.Name()] {
+					t.Errorf("golden file %s isn't rendered anymore, run the test with -update to remove it", filepath.Join(dir, f.Name()))
+				}
+			}
+		})
+	}
+}
+
+// goldenDump prints the Ignition config of cfg with the file contents decoded, in the order
+// the templates rendered them
+func goldenDump(cfg *mcfgv1.MachineConfig) (string, error) {
+	ign, err := ctrlcommon.ParseAndConvertConfig(cfg.Spec.Config.Raw)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# MachineConfig %s\n", cfg.Name)
+	var labels []string
+	for k, v := range cfg.Labels {
+		labels = append(labels, k+"="+v)
+	}
+	sort.Strings(labels)
+	fmt.Fprintf(&b, "# labels: %s\n", strings.Join(labels, ", "))
+
+	for _, f := range ign.Storage.Files {
+		mode := "<default>"
+		if f.Mode != nil {
+			mode = fmt.Sprintf("%#o", *f.Mode)
+		}
+		fmt.Fprintf(&b, "\n=== file %s (mode %s)\n", f.Path, mode)
+		if f.Contents.Source == nil {
+			continue
+		}
+		contents, err := dataurl.DecodeString(*f.Contents.Source)
+		if err != nil {
+			return "", fmt.Errorf("decoding %s: %v", f.Path, err)
+		}
+		b.Write(contents.Data)
+	}
+	for _, u := range ign.Systemd.Units {
+		enabled := "<default>"
+		if u.Enabled != nil {
+			enabled = fmt.Sprintf("%t", *u.Enabled)
+		}
+		fmt.Fprintf(&b, "\n=== unit %s (enabled %s)\n", u.Name, enabled)
+		if u.Contents != nil {
+			b.WriteString(*u.Contents)
+		}
+		for _, d := range u.Dropins {
+			fmt.Fprintf(&b, "\n=== dropin %s/%s\n", u.Name, d.Name)
+			if d.Contents != nil {
+				b.WriteString(*d.Contents)
+			}
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,108 @@
+package daemon
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/golang/glog"
+)
+
+// Failures can be injected at a few points of the update to exercise its resumability and
+// rollback logic in CI. The hooks are compiled in only with the mco_chaos build tag and are
+// selected by listing the chaos points, comma separated, in the MCD_CHAOS environment variable
+// of the daemon, e.g. MCD_CHAOS=fail-after-drain,corrupt-file=/etc/kubernetes/kubelet.conf.
+// Each point fires once per boot.
+
+type chaosPoint string
+
+const (
+	// chaosFailAfterDrain fails the update once the node is drained
+	chaosFailAfterDrain chaosPoint = "fail-after-drain"
+	// chaosFailDuringPivot fails the update once the new OS deployment is staged
+	chaosFailDuringPivot chaosPoint = "fail-during-pivot"
+	// chaosCrashBeforeReboot exits the daemon right before it reboots the node
+	chaosCrashBeforeReboot chaosPoint = "crash-before-reboot"
+	// chaosCorruptFile corrupts the file given as argument once the update wrote it
+	chaosCorruptFile chaosPoint = "corrupt-file"
+
+	chaosEnvVar = "MCD_CHAOS"
+)
+
+var (
+	// chaosEnabled is only set by builds with the mco_chaos tag
+	chaosEnabled = false
+	// chaosStampDir records the points that fired, it is in /run so they fire again after a reboot
+	chaosStampDir = "/run/machine-config-daemon-chaos"
+)
+
+// parseChaos returns the chaos points listed in value with their argument, if any
+func parseChaos(value string) map[chaosPoint]string {
+	points := map[chaosPoint]string{}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.SplitN(item, "=", 2)
+		arg := ""
+		if len(parts) == 2 {
+			arg = parts[1]
+		}
+		points[chaosPoint(parts[0])] = arg
+	}
+	return points
+}
+
+// chaosFires returns true if point is selected with the argument arg, when given, and did
+// not fire yet in this boot
+func chaosFires(point chaosPoint, arg string) bool {
+	if !chaosEnabled {
+		return false
+	}
+	selectedArg, ok := parseChaos(os.Getenv(chaosEnvVar))[point]
+	if !ok || (arg != "" && selectedArg != arg) {
+		return false
+	}
+	stamp := filepath.Join(chaosStampDir, string(point))
+	if _, err := os.Stat(stamp); err == nil {
+		return false
+	}
+	if err := os.MkdirAll(chaosStampDir, 0755); err != nil {
+		glog.Warningf("Unable to record chaos point %s: %v", point, err)
+		return false
+	}
+	if err := ioutil.WriteFile(stamp, nil, 0644); err != nil {
+		glog.Warningf("Unable to record chaos point %s: %v", point, err)
+		return false
+	}
+	glog.Warningf("Injecting failure at chaos point %s", point)
+	return true
+}
+
+// injectChaos returns an error, or exits for chaosCrashBeforeReboot, if point fires
+func injectChaos(point chaosPoint) error {
+	if !chaosFires(point, "") {
+		return nil
+	}
+	if point == chaosCrashBeforeReboot {
+		os.Exit(1)
+	}
+	return fmt.Errorf("injected failure at chaos point %s", point)
+}
+
+// injectFileCorruption appends garbage to path if chaosCorruptFile fires for it
+func injectFileCorruption(path string) error {
+	if !chaosFires(chaosCorruptFile, path) {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.WriteString("\nmachine-config-daemon chaos\n")
+	return err
+}
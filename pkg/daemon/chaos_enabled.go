@@ -0,0 +1,8 @@
+//go:build mco_chaos
+// +build mco_chaos
+
+package daemon
+
+func init() {
+	chaosEnabled = true
+}
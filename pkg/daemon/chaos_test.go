@@ -0,0 +1,51 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseChaos(t *testing.T) {
+	assert.Equal(t, map[chaosPoint]string{}, parseChaos(""))
+	assert.Equal(t, map[chaosPoint]string{
+		chaosFailAfterDrain: "",
+		chaosCorruptFile:    "/etc/kubernetes/kubelet.conf",
+	}, parseChaos("fail-after-drain, corrupt-file=/etc/kubernetes/kubelet.conf,"))
+}
+
+func TestInjectChaos(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chaos")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	oldStampDir := chaosStampDir
+	chaosStampDir = filepath.Join(dir, "stamps")
+	defer func() {
+		chaosEnabled = false
+		chaosStampDir = oldStampDir
+		os.Unsetenv(chaosEnvVar)
+	}()
+
+	file := filepath.Join(dir, "file")
+	require.Nil(t, ioutil.WriteFile(file, []byte("contents"), 0644))
+	os.Setenv(chaosEnvVar, "fail-after-drain,corrupt-file="+file)
+
+	// nothing is injected unless built with the mco_chaos tag
+	assert.Nil(t, injectChaos(chaosFailAfterDrain))
+
+	chaosEnabled = true
+	assert.Nil(t, injectChaos(chaosFailDuringPivot))
+	assert.NotNil(t, injectChaos(chaosFailAfterDrain))
+	// fires once per boot
+	assert.Nil(t, injectChaos(chaosFailAfterDrain))
+
+	require.Nil(t, injectFileCorruption(filepath.Join(dir, "other")))
+	require.Nil(t, injectFileCorruption(file))
+	contents, err := ioutil.ReadFile(file)
+	require.Nil(t, err)
+	assert.NotEqual(t, "contents", string(contents))
+}
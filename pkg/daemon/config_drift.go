@@ -0,0 +1,173 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+const (
+	configDriftCheckInterval = 10 * time.Minute
+
+	// NodeConfigDrift is the type of the node condition reporting whether the files and units
+	// on disk drifted from the current config of the node
+	NodeConfigDrift corev1.NodeConditionType = "MachineConfigDrift"
+	// configDriftDetected is the reason of the NodeConfigDrift condition when files or units drifted
+	configDriftDetected = "ConfigDriftDetected"
+	// configDriftNone is the reason of the NodeConfigDrift condition when nothing drifted
+	configDriftNone = "NoConfigDrift"
+)
+
+// configDriftError lists the files and units on disk which don't match the ones of a config
+type configDriftError struct {
+	drift []error
+}
+
+// newConfigDriftError returns a *configDriftError for the drift, nil if there is none
+func newConfigDriftError(drift []error) error {
+	if len(drift) == 0 {
+		return nil
+	}
+	return &configDriftError{drift: drift}
+}
+
+func (e *configDriftError) Error() string {
+	msgs := make([]string, 0, len(e.drift))
+	for _, err := range e.drift {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%d files and units drifted: %s", len(e.drift), strings.Join(msgs, "; "))
+}
+
+// combineConfigDrift merges the drift of the errors in a single *configDriftError. Any other
+// error is returned as is, the drift can't be known in full then.
+func combineConfigDrift(errs ...error) error {
+	var drift []error
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		driftErr, ok := err.(*configDriftError)
+		if !ok {
+			return err
+		}
+		drift = append(drift, driftErr.drift...)
+	}
+	return newConfigDriftError(drift)
+}
+
+// runConfigDriftMonitor periodically checks the files and units of the node didn't drift from
+// its current config, e.g. after they were edited by hand
+func (dn *Daemon) runConfigDriftMonitor(stopCh <-chan struct{}) {
+	for {
+		if err := dn.checkConfigDrift(); err != nil {
+			glog.Errorf("Failed to check the config drift: %v", err)
+		}
+		select {
+		case <-stopCh:
+			return
+		case <-hostClock.After(configDriftCheckInterval):
+		}
+	}
+}
+
+// checkConfigDrift compares the files and units on disk with the ones of the current config of
+// the node, and reports the drift in the NodeConfigDrift condition of the node
+func (dn *Daemon) checkConfigDrift() error {
+	if dn.node == nil {
+		return nil
+	}
+	dn.updateActiveLock.Lock()
+	defer dn.updateActiveLock.Unlock()
+
+	// the files and units change along with updates, and are validated on startup
+	current := dn.node.Annotations[constants.CurrentMachineConfigAnnotationKey]
+	if dn.booting || current == "" || current != dn.node.Annotations[constants.DesiredMachineConfigAnnotationKey] ||
+		dn.node.Annotations[constants.MachineConfigDaemonStateAnnotationKey] == constants.MachineConfigDaemonStateWorking {
+		return nil
+	}
+	config, err := dn.mcLister.Get(current)
+	if err != nil {
+		return err
+	}
+	return dn.reportConfigDrift(current, validateOnDiskFilesAndUnits(config))
+}
+
+// reportConfigDrift sets the NodeConfigDrift condition of the node from the result of the
+// validation of the files and units against the config. Errors other than drift aren't reported.
+func (dn *Daemon) reportConfigDrift(config string, validationErr error) error {
+	drift, ok := validationErr.(*configDriftError)
+	if validationErr != nil && !ok {
+		return validationErr
+	}
+	condition := configDriftCondition(config, drift)
+	if drift != nil {
+		glog.Warningf("Config %s drifted on disk: %v", config, drift)
+	}
+	return dn.setNodeCondition(condition, func() {
+		if drift != nil && dn.recorder != nil {
+			dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeWarning, "ConfigDrift", "Config %s drifted on disk: %v", config, drift)
+		}
+	})
+}
+
+// configDriftCondition returns the NodeConfigDrift condition for the drift of the config, with
+// its times left unset
+func configDriftCondition(config string, drift *configDriftError) corev1.NodeCondition {
+	if drift == nil {
+		return corev1.NodeCondition{
+			Type:    NodeConfigDrift,
+			Status:  corev1.ConditionFalse,
+			Reason:  configDriftNone,
+			Message: fmt.Sprintf("Files and units on disk match config %s", config),
+		}
+	}
+	return corev1.NodeCondition{
+		Type:    NodeConfigDrift,
+		Status:  corev1.ConditionTrue,
+		Reason:  configDriftDetected,
+		Message: fmt.Sprintf("Config %s drifted on disk: %v", config, drift),
+	}
+}
+
+// setNodeCondition sets the condition in the status of the node, calling changed once it did.
+// Nothing is written if the status and message of the condition didn't change. The node is left
+// for the informer to update, like every node write of the daemon.
+func (dn *Daemon) setNodeCondition(condition corev1.NodeCondition, changed func()) error {
+	now := metav1.Now()
+	condition.LastHeartbeatTime = now
+	condition.LastTransitionTime = now
+	for _, c := range dn.node.Status.Conditions {
+		if c.Type != condition.Type {
+			continue
+		}
+		if c.Status == condition.Status && c.Reason == condition.Reason && c.Message == condition.Message {
+			return nil
+		}
+		if c.Status == condition.Status {
+			condition.LastTransitionTime = c.LastTransitionTime
+		}
+	}
+	// conditions are merged by type
+	patch, err := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{
+			"conditions": []corev1.NodeCondition{condition},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := dn.kubeClient.CoreV1().Nodes().PatchStatus(context.TODO(), dn.name, patch); err != nil {
+		return err
+	}
+	changed()
+	return nil
+}
@@ -0,0 +1,97 @@
+package daemon
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vincent-petithory/dataurl"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func TestCheckV3FilesReportsAllDrift(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-drift")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	mode := int(defaultFilePermissions)
+	file := func(path, contents string) ign3types.File {
+		return ign3types.File{
+			Node: ign3types.Node{Path: path},
+			FileEmbedded1: ign3types.FileEmbedded1{
+				Contents: ign3types.Resource{Source: helpers.StrToPtr(dataurl.EncodeBytes([]byte(contents)))},
+				Mode:     &mode,
+			},
+		}
+	}
+	matching := filepath.Join(dir, "matching")
+	edited := filepath.Join(dir, "edited")
+	removed := filepath.Join(dir, "removed")
+	require.Nil(t, ioutil.WriteFile(matching, []byte("foo"), defaultFilePermissions))
+	require.Nil(t, ioutil.WriteFile(edited, []byte("edited by hand"), defaultFilePermissions))
+
+	err = checkV3Files([]ign3types.File{file(matching, "foo"), file(edited, "bar"), file(removed, "baz")})
+	require.IsType(t, &configDriftError{}, err)
+	assert.Len(t, err.(*configDriftError).drift, 2)
+	assert.Contains(t, err.Error(), edited)
+	assert.Contains(t, err.Error(), removed)
+	assert.NotContains(t, err.Error(), matching)
+
+	require.Nil(t, ioutil.WriteFile(edited, []byte("bar"), defaultFilePermissions))
+	require.Nil(t, ioutil.WriteFile(removed, []byte("baz"), defaultFilePermissions))
+	assert.Nil(t, checkV3Files([]ign3types.File{file(matching, "foo"), file(edited, "bar"), file(removed, "baz")}))
+}
+
+func TestCombineConfigDrift(t *testing.T) {
+	files := newConfigDriftError([]error{os.ErrNotExist})
+	units := newConfigDriftError([]error{os.ErrPermission})
+	assert.Nil(t, combineConfigDrift(nil, nil))
+	assert.Equal(t, &configDriftError{drift: []error{os.ErrNotExist, os.ErrPermission}}, combineConfigDrift(files, units))
+	// the drift isn't known in full
+	assert.Equal(t, os.ErrClosed, combineConfigDrift(files, os.ErrClosed))
+}
+
+func TestReportConfigDrift(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0"}}
+	client := k8sfake.NewSimpleClientset(node)
+	dn := &Daemon{name: node.Name, node: node, kubeClient: client}
+	// the informer updates the node of the daemon
+	syncNode := func() {
+		updated, err := client.CoreV1().Nodes().Get(context.TODO(), node.Name, metav1.GetOptions{})
+		require.Nil(t, err)
+		dn.node = updated
+	}
+
+	drift := newConfigDriftError([]error{os.ErrNotExist})
+	require.Nil(t, dn.reportConfigDrift("rendered-worker", drift))
+	assert.Same(t, node, dn.node)
+	syncNode()
+	require.Len(t, dn.node.Status.Conditions, 1)
+	condition := dn.node.Status.Conditions[0]
+	assert.Equal(t, NodeConfigDrift, condition.Type)
+	assert.Equal(t, corev1.ConditionTrue, condition.Status)
+	assert.Contains(t, condition.Message, drift.Error())
+
+	// nothing is written while the drift doesn't change
+	actions := len(client.Actions())
+	require.Nil(t, dn.reportConfigDrift("rendered-worker", drift))
+	assert.Len(t, client.Actions(), actions)
+
+	require.Nil(t, dn.reportConfigDrift("rendered-worker", nil))
+	syncNode()
+	require.Len(t, dn.node.Status.Conditions, 1)
+	assert.Equal(t, corev1.ConditionFalse, dn.node.Status.Conditions[0].Status)
+	assert.Equal(t, configDriftNone, dn.node.Status.Conditions[0].Reason)
+
+	// other validation errors aren't drift
+	assert.Equal(t, os.ErrClosed, dn.reportConfigDrift("rendered-worker", os.ErrClosed))
+}
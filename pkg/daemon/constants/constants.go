@@ -34,6 +34,72 @@ const (
 	MachineConfigDaemonStateUnreconcilable = "Unreconcilable"
 	// MachineConfigDaemonReasonAnnotationKey is set by the daemon when it needs to report a human readable reason for its state. E.g. when state flips to degraded/unreconcilable.
 	MachineConfigDaemonReasonAnnotationKey = "machineconfiguration.openshift.io/reason"
+	// KubeletCertRecoveryAnnotationKey is set by the daemon while it recovers the expired kubelet client certificate of
+	// the node, the node controller doesn't update nodes in recovery
+	KubeletCertRecoveryAnnotationKey = "machineconfiguration.openshift.io/kubeletCertRecovery"
+	// KubeletCertRecoveryInProgress is the KubeletCertRecoveryAnnotationKey value while the recovery is in progress.
+	KubeletCertRecoveryInProgress = "InProgress"
+	// RebootRequestedAnnotationKey is set on a pool by admins, to the RFC 3339 time of the request, to reboot
+	// all of its nodes once, in a rolling fashion honoring maxUnavailable.
+	RebootRequestedAnnotationKey = "machineconfiguration.openshift.io/rebootRequested"
+	// DesiredRebootAnnotationKey is set by the node controller to the reboot request of the pool a node has to reboot for.
+	DesiredRebootAnnotationKey = "machineconfiguration.openshift.io/desiredReboot"
+	// CurrentRebootAnnotationKey is set by the daemon to the last reboot request it rebooted the node for.
+	CurrentRebootAnnotationKey = "machineconfiguration.openshift.io/currentReboot"
+	// RollbackOSAnnotationKey is set on a node by admins, e.g. to the RFC 3339 time of the request, to roll its OS back
+	// to the previous rpm-ostree deployment and reboot into it. Changing it requests another rollback.
+	RollbackOSAnnotationKey = "machineconfiguration.openshift.io/rollbackOS"
+	// CurrentRollbackOSAnnotationKey is set by the daemon to the last OS rollback request it handled.
+	CurrentRollbackOSAnnotationKey = "machineconfiguration.openshift.io/currentRollbackOS"
+	// RolledBackOSImageAnnotationKey is set by the daemon to the OS image the node was rolled back to on request, until
+	// it is updated to another OS image. The node isn't degraded for not running the OS image of its config meanwhile.
+	RolledBackOSImageAnnotationKey = "machineconfiguration.openshift.io/rolledBackOSImage"
+	// RebootCompletedAnnotationKey is set on a pool by the node controller to the last reboot request all of its nodes rebooted for.
+	RebootCompletedAnnotationKey = "machineconfiguration.openshift.io/rebootCompleted"
+	// DesiredImagePullAnnotationKey is set by the node controller to the config a node has to pre-pull the OS image of ahead of its update.
+	DesiredImagePullAnnotationKey = "machineconfiguration.openshift.io/desiredImagePull"
+	// CurrentImagePullAnnotationKey is set by the daemon to the last config it pre-pulled the OS image of.
+	CurrentImagePullAnnotationKey = "machineconfiguration.openshift.io/currentImagePull"
+	// LiveAppliedConfigAnnotationKey is set by the daemon to the config whose OS changes it applied live to the booted deployment, until the node reboots into them.
+	LiveAppliedConfigAnnotationKey = "machineconfiguration.openshift.io/liveAppliedConfig"
+	// StageOSUpdateAnnotationKey is set to "true" by the node controller for the daemon to also stage the OS update of the config it pre-pulls the OS image of.
+	StageOSUpdateAnnotationKey = "machineconfiguration.openshift.io/stageOSUpdate"
+	// PinnedOSImageAnnotationKey is set by the daemon to the OS image, tag and digest, it rebased to when the osImageURL of its config is a tag.
+	PinnedOSImageAnnotationKey = "machineconfiguration.openshift.io/pinnedOSImage"
+	// PinnedDeploymentAnnotationKey is set by the daemon to the ID of the last known-good deployment it pinned, empty if it
+	// pinned none. Only that deployment is unpinned once the node completes its next update.
+	PinnedDeploymentAnnotationKey = "machineconfiguration.openshift.io/pinnedDeployment"
+	// RollbackDeploymentsAnnotationKey is set by the node controller to the rollbackDeployments of the pool of the node, empty if it is unset.
+	RollbackDeploymentsAnnotationKey = "machineconfiguration.openshift.io/rollbackDeployments"
+	// RebootDelayAnnotationKey is set by the node controller to the gracefulReboot delay of the pool of the node, empty if it is unset.
+	RebootDelayAnnotationKey = "machineconfiguration.openshift.io/rebootDelay"
+	// RebootWallMessageAnnotationKey is set by the node controller to the gracefulReboot wall message of the pool of the node.
+	RebootWallMessageAnnotationKey = "machineconfiguration.openshift.io/rebootWallMessage"
+	// ScheduledRebootAnnotationKey is set by the daemon to the time, in RFC3339, systemd-logind is scheduled to reboot the node at, empty if it isn't.
+	ScheduledRebootAnnotationKey = "machineconfiguration.openshift.io/scheduledReboot"
+	// DrainStartedAnnotationKey is set by the daemon to the RFC 3339 time it started draining the node, and emptied once drained.
+	DrainStartedAnnotationKey = "machineconfiguration.openshift.io/drainStarted"
+	// ForceDrainAnnotationKey is set by the node controller to the config the daemon deletes the pods left on the node
+	// for, ignoring their PodDisruptionBudgets, when its drain got stuck.
+	ForceDrainAnnotationKey = "machineconfiguration.openshift.io/forceDrain"
+	// DrainSkippedAnnotationKey is set by the node controller to the config the node isn't updated to because its drain
+	// got stuck. Removing it updates the node again.
+	DrainSkippedAnnotationKey = "machineconfiguration.openshift.io/drainSkipped"
+	// PendingOSUpdateDiffAnnotationKey is set by the daemon to the packages the OS update staged on the node changes,
+	// as JSON, until the node reboots into it
+	PendingOSUpdateDiffAnnotationKey = "machineconfiguration.openshift.io/pendingOSUpdateDiff"
+	// KernelArgumentsDriftAnnotationKey is set by the daemon to the kernel arguments of the current config missing from
+	// the running kernel or from the booted deployment, as JSON, empty when none are
+	KernelArgumentsDriftAnnotationKey = "machineconfiguration.openshift.io/kernelArgumentsDrift"
+	// RemediateKernelArgumentsDriftAnnotationKey is set to "true" on a node by admins for the daemon to append the kernel
+	// arguments missing from the booted deployment, so the node boots with them on its next reboot.
+	RemediateKernelArgumentsDriftAnnotationKey = "machineconfiguration.openshift.io/remediateKernelArgumentsDrift"
+	// HostOSAnnotationKey is set by the daemon to the operating system of the host, as JSON
+	HostOSAnnotationKey = "machineconfiguration.openshift.io/hostOS"
+	// OSVariantLabelKey is set by the daemon to the variant of the operating system of the host: rhcos, fcos, scos or rhel
+	OSVariantLabelKey = "machineconfiguration.openshift.io/osVariant"
+	// OSVersionLabelKey is set by the daemon to the version of the operating system of the host
+	OSVersionLabelKey = "machineconfiguration.openshift.io/osVersion"
 	// InitialNodeAnnotationsFilePath defines the path at which it will find the node annotations it needs to set on the node once it comes up for the first time.
 	// The Machine Config Server writes the node annotations to this path.
 	InitialNodeAnnotationsFilePath = "/etc/machine-config-daemon/node-annotations.json"
@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 
@@ -68,7 +67,7 @@ func setRootDeviceSchedulerBFQ() error {
 // updateOstreeObjectSync enables "per-object-fsync" which helps avoid
 // latency spikes for etcd; see https://github.com/ostreedev/ostree/pull/2152
 func updateOstreeObjectSync() error {
-	if err := exec.Command("ostree", "--repo=/sysroot/ostree/repo", "config", "set", "core.per-object-fsync", "true").Run(); err != nil {
+	if err := hostCommander.Command("ostree", "--repo=/sysroot/ostree/repo", "config", "set", "core.per-object-fsync", "true").Run(); err != nil {
 		return errors.Wrapf(err, "Failed to set per-object-fsync for ostree")
 	}
 	return nil
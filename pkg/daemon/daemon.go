@@ -44,6 +44,7 @@ import (
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	mcfgclientset "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned"
 	mcfginformersv1 "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions/machineconfiguration.openshift.io/v1"
 	mcfglistersv1 "github.com/openshift/machine-config-operator/pkg/generated/listers/machineconfiguration.openshift.io/v1"
 )
@@ -73,6 +74,13 @@ type Daemon struct {
 	// kubeClient allows interaction with Kubernetes, including the node we are running on.
 	kubeClient kubernetes.Interface
 
+	// mcfgClient writes the MachineConfigNode of the node, it's nil without a cluster
+	mcfgClient mcfgclientset.Interface
+
+	// machineConfigNode is the MachineConfigNode of the node as last written
+	machineConfigNode     *mcfgv1.MachineConfigNode
+	machineConfigNodeLock sync.Mutex
+
 	// recorder sends events to the apiserver
 	recorder record.EventRecorder
 
@@ -83,6 +91,11 @@ type Daemon struct {
 	mcLister       mcfglistersv1.MachineConfigLister
 	mcListerSynced cache.InformerSynced
 
+	// kapLister is nil without a cluster, e.g. on firstboot, where the configs were validated
+	// against the KernelArgumentPolicies when rendered
+	kapLister       mcfglistersv1.KernelArgumentPolicyLister
+	kapListerSynced cache.InformerSynced
+
 	// skipReboot skips the reboot after a sync, only valid with onceFrom != ""
 	skipReboot bool
 
@@ -106,7 +119,7 @@ type Daemon struct {
 
 	queue       workqueue.RateLimitingInterface
 	enqueueNode func(*corev1.Node)
-	syncHandler func(node string) error
+	syncHandler func(ctx context.Context, node string) error
 
 	// isControlPlane is true if this node is a control plane (master).
 	// The machine may also be a worker (with schedulable masters).
@@ -171,7 +184,7 @@ var (
 // if kubelet failed to shutdown - that way the machine will still eventually reboot
 // as systemd will time out the stop invocation.
 func rebootCommand(rationale string) *exec.Cmd {
-	return exec.Command("systemd-run", "--unit", "machine-config-daemon-reboot",
+	return hostCommander.Command("systemd-run", "--unit", "machine-config-daemon-reboot",
 		"--description", fmt.Sprintf("machine-config-daemon: %s", rationale), "/bin/sh", "-c", "systemctl stop kubelet.service; systemctl reboot")
 }
 
@@ -212,7 +225,7 @@ func New(
 
 	// Only pull the osImageURL from OSTree when we are on RHCOS or FCOS
 	if os.IsCoreOSVariant() {
-		osImageURL, osVersion, err = nodeUpdaterClient.GetBootedOSImageURL()
+		osImageURL, osVersion, err = nodeUpdaterClient.GetBootedOSImageURL(context.Background())
 		if err != nil {
 			return nil, fmt.Errorf("error reading osImageURL from rpm-ostree: %v", err)
 		}
@@ -231,7 +244,7 @@ func New(
 	loggerSupportsJournal := true
 	if !mock {
 		if os.IsLikeTraditionalRHEL7() {
-			loggerOutput, err := exec.Command("logger", "--help").CombinedOutput()
+			loggerOutput, err := hostCommander.Command("logger", "--help").CombinedOutput()
 			if err != nil {
 				return nil, errors.Wrapf(err, "running logger --help")
 			}
@@ -260,15 +273,18 @@ func New(
 func (dn *Daemon) ClusterConnect(
 	name string,
 	kubeClient kubernetes.Interface,
+	mcfgClient mcfgclientset.Interface,
 	mcInformer mcfginformersv1.MachineConfigInformer,
+	kapInformer mcfginformersv1.KernelArgumentPolicyInformer,
 	nodeInformer coreinformersv1.NodeInformer,
 	kubeletHealthzEnabled bool,
 	kubeletHealthzEndpoint string,
 ) {
 	dn.name = name
 	dn.kubeClient = kubeClient
+	dn.mcfgClient = mcfgClient
 
-	dn.nodeWriter = newNodeWriter()
+	dn.nodeWriter = newNodeWriter(dn.syncMachineConfigNode)
 	go dn.nodeWriter.Run(dn.stopCh)
 
 	// Other controllers start out with the default controller limiter which retries
@@ -293,6 +309,8 @@ func (dn *Daemon) ClusterConnect(
 	dn.nodeListerSynced = nodeInformer.Informer().HasSynced
 	dn.mcLister = mcInformer.Lister()
 	dn.mcListerSynced = mcInformer.Informer().HasSynced
+	dn.kapLister = kapInformer.Lister()
+	dn.kapListerSynced = kapInformer.Informer().HasSynced
 
 	dn.enqueueNode = dn.enqueueDefault
 	dn.syncHandler = dn.syncNode
@@ -332,12 +350,12 @@ func (w writer) Write(p []byte) (n int, err error) {
 
 // worker runs a worker thread that just dequeues items, processes them, and marks them done.
 // It enforces that the syncHandler is never invoked concurrently with the same key.
-func (dn *Daemon) worker() {
-	for dn.processNextWorkItem() {
+func (dn *Daemon) worker(ctx context.Context) {
+	for dn.processNextWorkItem(ctx) {
 	}
 }
 
-func (dn *Daemon) processNextWorkItem() bool {
+func (dn *Daemon) processNextWorkItem(ctx context.Context) bool {
 	select {
 	case <-dn.stopCh:
 		return false
@@ -348,7 +366,7 @@ func (dn *Daemon) processNextWorkItem() bool {
 		}
 		defer dn.queue.Done(key)
 
-		err := dn.syncHandler(key.(string))
+		err := dn.syncHandler(ctx, key.(string))
 		dn.handleErr(err, key)
 	}
 	return true
@@ -377,7 +395,7 @@ func (dn *Daemon) updateErrorState(err error) {
 
 // initializeNode is called the first time we get our node object; however to
 // ensure we handle failures: everything called from here should be idempotent.
-func (dn *Daemon) initializeNode() error {
+func (dn *Daemon) initializeNode(ctx context.Context) error {
 	if dn.nodeInitialized {
 		return nil
 	}
@@ -391,15 +409,21 @@ func (dn *Daemon) initializeNode() error {
 	} else {
 		glog.Infof("Node %s is not labeled %s", dn.node.Name, ctrlcommon.MasterLabel)
 	}
+	if err := dn.setHostOS(); err != nil {
+		return err
+	}
+	if err := dn.syncLiveApplied(ctx); err != nil {
+		return err
+	}
 	dn.nodeInitialized = true
 	return nil
 }
 
-func (dn *Daemon) syncNode(key string) error {
-	startTime := time.Now()
+func (dn *Daemon) syncNode(ctx context.Context, key string) error {
+	startTime := hostClock.Now()
 	glog.V(4).Infof("Started syncing node %q (%v)", key, startTime)
 	defer func() {
-		glog.V(4).Infof("Finished syncing node %q (%v)", key, time.Since(startTime))
+		glog.V(4).Infof("Finished syncing node %q (%v)", key, hostClock.Since(startTime))
 	}()
 
 	_, name, err := cache.SplitMetaNamespaceKey(key)
@@ -430,12 +454,13 @@ func (dn *Daemon) syncNode(key string) error {
 	node = node.DeepCopy()
 	if dn.node == nil {
 		dn.node = node
-		if err := dn.initializeNode(); err != nil {
+		if err := dn.initializeNode(ctx); err != nil {
 			return err
 		}
 	} else {
 		dn.node = node
 	}
+	dn.syncMachineConfigNode(node)
 
 	// Take care of the very first sync of the MCD on a node.
 	// This loads the node annotation from the bootstrap (if we're really bootstrapping)
@@ -449,7 +474,7 @@ func (dn *Daemon) syncNode(key string) error {
 		if err := removeIgnitionArtifacts(); err != nil {
 			return err
 		}
-		if err := dn.checkStateOnFirstRun(); err != nil {
+		if err := dn.checkStateOnFirstRun(ctx); err != nil {
 			return err
 		}
 		// finished syncing node for the first time;
@@ -465,10 +490,19 @@ func (dn *Daemon) syncNode(key string) error {
 		return errors.Wrapf(err, "prepping update")
 	}
 	if current != nil || desired != nil {
-		if err := dn.triggerUpdateWithMachineConfig(current, desired); err != nil {
+		if err := dn.triggerUpdateWithMachineConfig(ctx, current, desired); err != nil {
 			return err
 		}
 	}
+	if request := pendingOSRollback(dn.node); request != "" {
+		return dn.performOSRollback(ctx, request)
+	}
+	if request := pendingRebootRequest(dn.node); request != "" {
+		return dn.performRequestedReboot(ctx, request)
+	}
+	if config := pendingImagePull(dn.node); config != "" {
+		return dn.performImagePull(ctx, config)
+	}
 	glog.V(2).Infof("Node %s is already synced", node.Name)
 	return nil
 }
@@ -491,7 +525,7 @@ const (
 
 // detectEarlySSHAccessesFromBoot annotates the node if we find a login before the daemon started up.
 func (dn *Daemon) detectEarlySSHAccessesFromBoot() error {
-	journalOutput, err := exec.Command("journalctl", "-b", "-o", "cat", "-u", logindUnit, "MESSAGE_ID="+sdMessageSessionStart).CombinedOutput()
+	journalOutput, err := hostCommander.Command("journalctl", "-b", "-o", "cat", "-u", logindUnit, "MESSAGE_ID="+sdMessageSessionStart).CombinedOutput()
 	if err != nil {
 		return err
 	}
@@ -506,7 +540,7 @@ func (dn *Daemon) detectEarlySSHAccessesFromBoot() error {
 }
 
 // RunOnceFrom is the primary entrypoint for the non-cluster case
-func (dn *Daemon) RunOnceFrom(onceFrom string, skipReboot bool) error {
+func (dn *Daemon) RunOnceFrom(ctx context.Context, onceFrom string, skipReboot bool) error {
 	dn.skipReboot = skipReboot
 	configi, contentFrom, err := dn.senseAndLoadOnceFrom(onceFrom)
 	if err != nil {
@@ -516,17 +550,64 @@ func (dn *Daemon) RunOnceFrom(onceFrom string, skipReboot bool) error {
 	switch c := configi.(type) {
 	case ign3types.Config:
 		glog.V(2).Info("Daemon running directly from Ignition")
-		return dn.runOnceFromIgnition(c)
+		return dn.runOnceFromIgnition(ctx, c)
 	case mcfgv1.MachineConfig:
 		glog.V(2).Info("Daemon running directly from MachineConfig")
-		return dn.runOnceFromMachineConfig(c, contentFrom)
+		return dn.runOnceFromMachineConfig(ctx, c, contentFrom)
 	}
 	return errors.New("unsupported onceFrom type provided")
 }
 
+// RunApply applies the rendered MachineConfig mc to the host a single time, without any
+// cluster connection. The config stored on disk by a previous update is the starting point
+// if present, so files and units it owned are cleaned up, and mc is stored on disk in turn
+// for the daemon to pick up once the node is back in a cluster.
+func (dn *Daemon) RunApply(ctx context.Context, mc *mcfgv1.MachineConfig, skipReboot bool) error {
+	oldConfig, err := dn.getApplyStartingConfig()
+	if err != nil {
+		return err
+	}
+
+	mcDiffNotEmpty, err := dn.compareMachineConfig(oldConfig, mc)
+	if err != nil {
+		return errors.Wrapf(err, "failed to compare MachineConfig")
+	}
+	if !mcDiffNotEmpty {
+		glog.Infof("Host is already at %s, nothing to apply", mc.GetName())
+		return nil
+	}
+
+	dn.skipReboot = skipReboot
+	return dn.update(ctx, oldConfig, mc)
+}
+
+// PlanApply returns what RunApply would do to the host to apply mc, without doing it
+func (dn *Daemon) PlanApply(mc *mcfgv1.MachineConfig) (*UpdatePlan, error) {
+	oldConfig, err := dn.getApplyStartingConfig()
+	if err != nil {
+		return nil, err
+	}
+	return dn.planUpdate(oldConfig, mc)
+}
+
+// getApplyStartingConfig returns the config stored on disk by the last update, or an empty
+// config reflecting the booted OS if there is none
+func (dn *Daemon) getApplyStartingConfig() (*mcfgv1.MachineConfig, error) {
+	oldConfig, err := dn.getCurrentConfigOnDisk()
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, errors.Wrapf(err, "failed to read current config from %s", dn.currentConfigPath)
+		}
+		// Like on firstboot, start from an empty config reflecting the booted OS
+		oldConfig = canonicalizeEmptyMC(nil)
+		oldConfig.Spec.OSImageURL = dn.bootedOSImageURL
+	}
+	return oldConfig, nil
+}
+
 // RunFirstbootCompleteMachineconfig is run via systemd on the first boot
 // to complete processing of the target MachineConfig.
-func (dn *Daemon) RunFirstbootCompleteMachineconfig() error {
+func (dn *Daemon) RunFirstbootCompleteMachineconfig(ctx context.Context) error {
 	data, err := ioutil.ReadFile(constants.MachineConfigEncapsulatedPath)
 	if err != nil {
 		return err
@@ -557,7 +638,7 @@ func (dn *Daemon) RunFirstbootCompleteMachineconfig() error {
 	}
 
 	dn.skipReboot = true
-	err = dn.update(nil, &mc)
+	err = dn.update(ctx, nil, &mc)
 	if err != nil {
 		return err
 	}
@@ -568,7 +649,11 @@ func (dn *Daemon) RunFirstbootCompleteMachineconfig() error {
 	}
 
 	dn.skipReboot = false
-	return dn.reboot(fmt.Sprintf("Completing firstboot provisioning to %s", mc.GetName()))
+	return dn.reboot(ctx, rebootReason{
+		Message:     fmt.Sprintf("Completing firstboot provisioning to %s", mc.GetName()),
+		Config:      mc.GetName(),
+		RequestedBy: rebootRequestedByFirstboot,
+	})
 }
 
 // InterruptHandler ensures that shutdown operations are blocked until an
@@ -615,14 +700,22 @@ func (dn *Daemon) Run(stopCh <-chan struct{}, exitCh <-chan error) error {
 		go dn.runKubeletHealthzMonitor(stopCh, dn.exitCh)
 	}
 
+	go dn.runKubeletClientCertMonitor(stopCh)
+
 	defer utilruntime.HandleCrash()
 	defer dn.queue.ShutDown()
 
-	if !cache.WaitForCacheSync(stopCh, dn.nodeListerSynced, dn.mcListerSynced) {
+	if !cache.WaitForCacheSync(stopCh, dn.nodeListerSynced, dn.mcListerSynced, dn.kapListerSynced) {
 		return errors.New("failed to sync initial listers cache")
 	}
 
-	go wait.Until(dn.worker, time.Second, stopCh)
+	// the rpm-ostree commands of the sync loop are cancelled once the daemon stops
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go wait.UntilWithContext(ctx, dn.worker, time.Second)
+	go dn.runKernelArgumentsDriftMonitor(ctx)
+	go dn.runConfigDriftMonitor(stopCh)
 
 	select {
 	case <-stopCh:
@@ -638,7 +731,7 @@ func (dn *Daemon) Run(stopCh <-chan struct{}, exitCh <-chan error) error {
 }
 
 func (dn *Daemon) runLoginMonitor(stopCh <-chan struct{}, exitCh chan<- error) {
-	cmd := exec.Command("journalctl", "-b", "-f", "-o", "cat", "-u", logindUnit, "MESSAGE_ID="+sdMessageSessionStart)
+	cmd := hostCommander.Command("journalctl", "-b", "-f", "-o", "cat", "-u", logindUnit, "MESSAGE_ID="+sdMessageSessionStart)
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		exitCh <- err
@@ -694,7 +787,7 @@ func (dn *Daemon) runKubeletHealthzMonitor(stopCh <-chan struct{}, exitCh chan<-
 		select {
 		case <-stopCh:
 			return
-		case <-time.After(kubeletHealthzPollingInterval):
+		case <-hostClock.After(kubeletHealthzPollingInterval):
 			err := dn.getHealth()
 			if err != nil {
 				failureCount++
@@ -844,7 +937,7 @@ func (dn *Daemon) getStateAndConfigs(pendingConfigName string) (*stateAndConfigs
 func (dn *Daemon) LogSystemData() {
 	// Print status if available
 	if dn.os.IsCoreOSVariant() {
-		status, err := dn.NodeUpdaterClient.GetStatus()
+		status, err := dn.NodeUpdaterClient.GetStatus(context.Background())
 		if err != nil {
 			glog.Fatalf("unable to get rpm-ostree status: %s", err)
 		}
@@ -942,7 +1035,7 @@ func (dn *Daemon) storeCurrentConfigOnDisk(current *mcfgv1.MachineConfig) error
 // changes, so just rename the file to .bak the same as the -firstboot
 // path does.
 func upgradeHackFor44AndBelow() error {
-	_, err := os.Stat(constants.MachineConfigEncapsulatedPath)
+	_, err := hostFileStater.Stat(constants.MachineConfigEncapsulatedPath)
 	if err == nil {
 		glog.Warningf("Failed to complete machine-config-daemon-firstboot before joining cluster!")
 		// Removing this file signals completion of the initial MC processing.
@@ -971,7 +1064,7 @@ func removeIgnitionArtifacts() error {
 //
 // Some more background in this PR: https://github.com/openshift/machine-config-operator/pull/245
 //nolint:gocyclo
-func (dn *Daemon) checkStateOnFirstRun() error {
+func (dn *Daemon) checkStateOnFirstRun(ctx context.Context) error {
 	node, err := dn.loadNodeAnnotations(dn.node)
 	if err != nil {
 		return err
@@ -979,6 +1072,10 @@ func (dn *Daemon) checkStateOnFirstRun() error {
 	// Update our cached copy
 	dn.node = node
 
+	if err := dn.detectReboot(); err != nil {
+		return fmt.Errorf("error detecting the reason of the last reboot: %v", err)
+	}
+
 	pendingState, err := dn.getPendingState()
 	if err != nil {
 		return err
@@ -1017,7 +1114,11 @@ func (dn *Daemon) checkStateOnFirstRun() error {
 		if err := dn.finalizeBeforeReboot(state.pendingConfig); err != nil {
 			return err
 		}
-		return dn.reboot(fmt.Sprintf("Node will reboot into config %v", state.pendingConfig.GetName()))
+		return dn.reboot(ctx, rebootReason{
+			Message:     fmt.Sprintf("Node will reboot into config %v", state.pendingConfig.GetName()),
+			Config:      state.pendingConfig.GetName(),
+			RequestedBy: rebootRequestedByDrainRetry,
+		})
 	}
 
 	if err := dn.detectEarlySSHAccessesFromBoot(); err != nil {
@@ -1035,16 +1136,20 @@ func (dn *Daemon) checkStateOnFirstRun() error {
 			if err != nil {
 				return err
 			}
-			if err := dn.updateOS(state.currentConfig, osImageContentDir); err != nil {
-				return err
-			}
-			if err := os.RemoveAll(osImageContentDir); err != nil {
+			err = dn.updateOS(ctx, state.currentConfig, osImageContentDir)
+			releaseOSImageContent(osImageContentDir, err)
+			if err != nil {
 				return err
 			}
 			if err := dn.finalizeBeforeReboot(state.currentConfig); err != nil {
 				return err
 			}
-			return dn.reboot(fmt.Sprintf("Node will reboot into config %v", state.currentConfig.GetName()))
+			return dn.reboot(ctx, rebootReason{
+				Message:     fmt.Sprintf("Node will reboot into config %v", state.currentConfig.GetName()),
+				Config:      state.currentConfig.GetName(),
+				Changes:     []string{"osUpdate"},
+				RequestedBy: rebootRequestedByBootstrap,
+			})
 		}
 		glog.Info("No bootstrap pivot required; unlinking bootstrap node annotations")
 
@@ -1091,19 +1196,23 @@ func (dn *Daemon) checkStateOnFirstRun() error {
 		expectedConfig = state.currentConfig
 	}
 
-	if _, err := os.Stat(constants.MachineConfigDaemonForceFile); err != nil {
-		if err := dn.validateOnDiskState(expectedConfig); err != nil {
+	if _, err := hostFileStater.Stat(constants.MachineConfigDaemonForceFile); err != nil {
+		err := dn.validateOnDiskState(expectedConfig)
+		if reportErr := dn.reportConfigDrift(expectedConfig.GetName(), err); reportErr != nil && reportErr != err {
+			glog.Warningf("Failed to report the config drift: %v", reportErr)
+		}
+		if err != nil {
 			return fmt.Errorf("unexpected on-disk state validating against %s: %v", expectedConfig.GetName(), err)
 		}
 		glog.Info("Validated on-disk state")
 	} else {
 		glog.Infof("Skipping on-disk validation; %s present", constants.MachineConfigDaemonForceFile)
-		return dn.triggerUpdateWithMachineConfig(state.currentConfig, state.desiredConfig)
+		return dn.triggerUpdateWithMachineConfig(ctx, state.currentConfig, state.desiredConfig)
 	}
 
 	// We've validated state. Now, ensure that node is in desired state
 	var inDesiredConfig bool
-	if inDesiredConfig, err = dn.updateConfigAndState(state); err != nil {
+	if inDesiredConfig, err = dn.updateConfigAndState(ctx, state); err != nil {
 		return err
 	}
 	if inDesiredConfig {
@@ -1115,11 +1224,11 @@ func (dn *Daemon) checkStateOnFirstRun() error {
 	}
 	// currentConfig != desiredConfig, and we're not booting up into the desiredConfig.
 	// Kick off an update.
-	return dn.triggerUpdateWithMachineConfig(state.currentConfig, state.desiredConfig)
+	return dn.triggerUpdateWithMachineConfig(ctx, state.currentConfig, state.desiredConfig)
 }
 
 // updateConfigAndState updates node to desired state, labels nodes as done and uncordon
-func (dn *Daemon) updateConfigAndState(state *stateAndConfigs) (bool, error) {
+func (dn *Daemon) updateConfigAndState(ctx context.Context, state *stateAndConfigs) (bool, error) {
 	// In the case where we had a pendingConfig, make that now currentConfig.
 	// We update the node annotation, delete the state file, etc.
 	if state.pendingConfig != nil {
@@ -1155,6 +1264,8 @@ func (dn *Daemon) updateConfigAndState(state *stateAndConfigs) (bool, error) {
 				MCDUpdateState.WithLabelValues("", err.Error()).SetToCurrentTime()
 				return inDesiredConfig, err
 			}
+			dn.pinKnownGoodDeployment(ctx)
+			dn.cleanupRollbackDeployments(ctx)
 		}
 		// If we're degraded here, it means we got an error likely on startup and we retried.
 		// If that's the case, clear it out.
@@ -1177,7 +1288,7 @@ func (dn *Daemon) updateConfigAndState(state *stateAndConfigs) (bool, error) {
 // runOnceFromMachineConfig utilizes a parsed machineConfig and executes in onceFrom
 // mode. If the content was remote, it executes cluster calls, otherwise it assumes
 // no cluster is present yet.
-func (dn *Daemon) runOnceFromMachineConfig(machineConfig mcfgv1.MachineConfig, contentFrom onceFromOrigin) error {
+func (dn *Daemon) runOnceFromMachineConfig(ctx context.Context, machineConfig mcfgv1.MachineConfig, contentFrom onceFromOrigin) error {
 	if contentFrom == onceFromRemoteConfig {
 		if dn.kubeClient == nil {
 			panic("running in onceFrom mode with a remote MachineConfig without a cluster")
@@ -1192,7 +1303,7 @@ func (dn *Daemon) runOnceFromMachineConfig(machineConfig mcfgv1.MachineConfig, c
 			return nil
 		}
 		// At this point we have verified we need to update
-		if err := dn.triggerUpdateWithMachineConfig(current, &machineConfig); err != nil {
+		if err := dn.triggerUpdateWithMachineConfig(ctx, current, &machineConfig); err != nil {
 			dn.nodeWriter.SetDegraded(err, dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name)
 			return err
 		}
@@ -1200,14 +1311,14 @@ func (dn *Daemon) runOnceFromMachineConfig(machineConfig mcfgv1.MachineConfig, c
 	}
 	if contentFrom == onceFromLocalConfig {
 		// Execute update without hitting the cluster
-		return dn.update(nil, &machineConfig)
+		return dn.update(ctx, nil, &machineConfig)
 	}
 	// Otherwise return an error as the input format is unsupported
 	return fmt.Errorf("%v is not a path nor url; can not run once", contentFrom)
 }
 
 // runOnceFromIgnition executes MCD's subset of Ignition functionality in onceFrom mode
-func (dn *Daemon) runOnceFromIgnition(ignConfig ign3types.Config) error {
+func (dn *Daemon) runOnceFromIgnition(ctx context.Context, ignConfig ign3types.Config) error {
 	// Execute update without hitting the cluster
 	if err := dn.writeFiles(ignConfig.Storage.Files); err != nil {
 		return err
@@ -1219,13 +1330,16 @@ func (dn *Daemon) runOnceFromIgnition(ignConfig ign3types.Config) error {
 	// case.  We use this file to suppress things like kubelet and SDN
 	// starting on CoreOS during the firstboot/pivot boot, but there's
 	// no such thing on classic RHEL.
-	_, err := os.Stat(constants.MachineConfigEncapsulatedPath)
+	_, err := hostFileStater.Stat(constants.MachineConfigEncapsulatedPath)
 	if err == nil {
 		if err := os.Remove(constants.MachineConfigEncapsulatedPath); err != nil {
 			return errors.Wrapf(err, "failed to remove %s", constants.MachineConfigEncapsulatedPath)
 		}
 	}
-	return dn.reboot("runOnceFromIgnition complete")
+	return dn.reboot(ctx, rebootReason{
+		Message:     "runOnceFromIgnition complete",
+		RequestedBy: rebootRequestedByOnceFrom,
+	})
 }
 
 func (dn *Daemon) handleNodeEvent(node interface{}) {
@@ -1288,6 +1402,14 @@ func (dn *Daemon) completeUpdate(desiredConfigName string) error {
 		return err
 	}
 
+	if err := dn.clearDeploymentDiff(); err != nil {
+		return errors.Wrap(err, "error clearing the package changes of the OS update")
+	}
+
+	if err := dn.clearOSRollback(desiredConfigName); err != nil {
+		return errors.Wrap(err, "error clearing the OS rollback")
+	}
+
 	dn.logSystem("completed update for config %s", desiredConfigName)
 
 	return nil
@@ -1295,7 +1417,7 @@ func (dn *Daemon) completeUpdate(desiredConfigName string) error {
 
 // triggerUpdateWithMachineConfig starts the update. It queries the cluster for
 // the current and desired config if they weren't passed.
-func (dn *Daemon) triggerUpdateWithMachineConfig(currentConfig, desiredConfig *mcfgv1.MachineConfig) error {
+func (dn *Daemon) triggerUpdateWithMachineConfig(ctx context.Context, currentConfig, desiredConfig *mcfgv1.MachineConfig) error {
 	if currentConfig == nil {
 		ccAnnotation, err := getNodeAnnotation(dn.node, constants.CurrentMachineConfigAnnotationKey)
 		if err != nil {
@@ -1319,7 +1441,7 @@ func (dn *Daemon) triggerUpdateWithMachineConfig(currentConfig, desiredConfig *m
 	}
 
 	// run the update process. this function doesn't currently return.
-	return dn.update(currentConfig, desiredConfig)
+	return dn.update(ctx, currentConfig, desiredConfig)
 }
 
 // validateOnDiskState compares the on-disk state against what a configuration
@@ -1327,36 +1449,32 @@ func (dn *Daemon) triggerUpdateWithMachineConfig(currentConfig, desiredConfig *m
 // is stomping on our files, we want to highlight that and mark the system
 // degraded.
 func (dn *Daemon) validateOnDiskState(currentConfig *mcfgv1.MachineConfig) error {
-	// Be sure we're booted into the OS we expect
+	// Be sure we're booted into the OS we expect, or the one the node was rolled back to on request
 	osMatch := dn.checkOS(currentConfig.Spec.OSImageURL)
-	if !osMatch {
+	if !osMatch && dn.osRolledBack() {
+		glog.Warningf("Booted into %s the node was rolled back to, instead of %s of config %s", dn.bootedOSImageURL, currentConfig.Spec.OSImageURL, currentConfig.GetName())
+	} else if !osMatch {
 		return errors.Errorf("expected target osImageURL %q, have %q", currentConfig.Spec.OSImageURL, dn.bootedOSImageURL)
 	}
 	// And the rest of the disk state
+	return validateOnDiskFilesAndUnits(currentConfig)
+}
+
+// validateOnDiskFilesAndUnits compares the files and units on disk against the ones of the config,
+// returning a *configDriftError listing all the paths that differ
+func validateOnDiskFilesAndUnits(config *mcfgv1.MachineConfig) error {
 	// We want to verify the disk state in the spec version that it was created with,
 	// to remove possibilities of behaviour changes due to translation
-	ignconfigi, err := ctrlcommon.IgnParseWrapper(currentConfig.Spec.Config.Raw)
+	ignconfigi, err := ctrlcommon.IgnParseWrapper(config.Spec.Config.Raw)
 	if err != nil {
 		return errors.Errorf("Failed to parse Ignition for validation: %s", err)
 	}
 
 	switch typedConfig := ignconfigi.(type) {
 	case ign3types.Config:
-		if err := checkV3Files(ignconfigi.(ign3types.Config).Storage.Files); err != nil {
-			return err
-		}
-		if err := checkV3Units(ignconfigi.(ign3types.Config).Systemd.Units); err != nil {
-			return err
-		}
-		return nil
+		return combineConfigDrift(checkV3Files(typedConfig.Storage.Files), checkV3Units(typedConfig.Systemd.Units))
 	case ign2types.Config:
-		if err := checkV2Files(ignconfigi.(ign2types.Config).Storage.Files); err != nil {
-			return err
-		}
-		if err := checkV2Units(ignconfigi.(ign2types.Config).Systemd.Units); err != nil {
-			return err
-		}
-		return nil
+		return combineConfigDrift(checkV2Files(typedConfig.Storage.Files), checkV2Units(typedConfig.Systemd.Units))
 	default:
 		return errors.Errorf("unexpected type for ignition config: %v", typedConfig)
 	}
@@ -1385,12 +1503,13 @@ func (dn *Daemon) checkOS(osImageURL string) bool {
 		return true
 	}
 
-	return compareOSImageURL(dn.bootedOSImageURL, osImageURL)
+	return dn.osImageMatches(osImageURL)
 }
 
 // checkUnits validates the contents of all the units in the
-// target config and returns true if they match.
+// target config and returns a *configDriftError listing the ones that don't match.
 func checkV3Units(units []ign3types.Unit) error {
+	var drift []error
 	for _, u := range units {
 		for j := range u.Dropins {
 			path := filepath.Join(pathSystemd, u.Name+".d", u.Dropins[j].Name)
@@ -1413,7 +1532,7 @@ func checkV3Units(units []ign3types.Unit) error {
 			// To maintain backwards compatibility, we allow existing zero length files to exist.
 			// Thus we are also ok if the dropin exists but has no content
 			if err := checkFileContentsAndMode(path, []byte(content), defaultFilePermissions); err != nil {
-				return err
+				drift = append(drift, err)
 			}
 		}
 
@@ -1423,28 +1542,26 @@ func checkV3Units(units []ign3types.Unit) error {
 
 		path := filepath.Join(pathSystemd, u.Name)
 		if u.Mask != nil && *u.Mask {
-			link, err := filepath.EvalSymlinks(path)
-			if err != nil {
-				return errors.Wrapf(err, "state validation: error while evaluation symlink for path %q", path)
-			}
-			if strings.Compare(pathDevNull, link) != 0 {
-				return errors.Errorf("state validation: invalid unit masked setting. path: %q; expected: %v; received: %v", path, pathDevNull, link)
+			if err := checkUnitMasked(path); err != nil {
+				drift = append(drift, err)
+				continue
 			}
 		}
 		if err := checkFileContentsAndMode(path, []byte(*u.Contents), defaultFilePermissions); err != nil {
-			return err
+			drift = append(drift, err)
 		}
 
 	}
-	return nil
+	return newConfigDriftError(drift)
 }
 
 func checkV2Units(units []ign2types.Unit) error {
+	var drift []error
 	for _, u := range units {
 		for j := range u.Dropins {
 			path := filepath.Join(pathSystemd, u.Name+".d", u.Dropins[j].Name)
 			if err := checkFileContentsAndMode(path, []byte(u.Dropins[j].Contents), defaultFilePermissions); err != nil {
-				return err
+				drift = append(drift, err)
 			}
 		}
 
@@ -1454,28 +1571,38 @@ func checkV2Units(units []ign2types.Unit) error {
 
 		path := filepath.Join(pathSystemd, u.Name)
 		if u.Mask {
-			link, err := filepath.EvalSymlinks(path)
-			if err != nil {
-				return errors.Wrapf(err, "state validation: error while evaluation symlink for path %q", path)
-			}
-			if strings.Compare(pathDevNull, link) != 0 {
-				return errors.Errorf("state validation: invalid unit masked setting. path: %q; expected: %v; received: %v", path, pathDevNull, link)
+			if err := checkUnitMasked(path); err != nil {
+				drift = append(drift, err)
+				continue
 			}
 		}
 		if err := checkFileContentsAndMode(path, []byte(u.Contents), defaultFilePermissions); err != nil {
-			return err
+			drift = append(drift, err)
 		}
 
 	}
+	return newConfigDriftError(drift)
+}
+
+// checkUnitMasked returns an error if the unit at path isn't linked to /dev/null
+func checkUnitMasked(path string) error {
+	link, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return errors.Wrapf(err, "state validation: error while evaluation symlink for path %q", path)
+	}
+	if strings.Compare(pathDevNull, link) != 0 {
+		return errors.Errorf("state validation: invalid unit masked setting. path: %q; expected: %v; received: %v", path, pathDevNull, link)
+	}
 	return nil
 }
 
 // checkFiles validates the contents of  all the files in the
-// target config.
+// target config and returns a *configDriftError listing the ones that don't match.
 
 // V3 files should not have any duplication anymore, so there is
 // no need to check for overwrites.
 func checkV3Files(files []ign3types.File) error {
+	var drift []error
 	for _, f := range files {
 		if len(f.Append) > 0 {
 			return fmt.Errorf("found an append section when checking files. Append is not supported")
@@ -1493,13 +1620,14 @@ func checkV3Files(files []ign3types.File) error {
 			}
 		}
 		if err := checkFileContentsAndMode(f.Path, contents.Data, mode); err != nil {
-			return err
+			drift = append(drift, err)
 		}
 	}
-	return nil
+	return newConfigDriftError(drift)
 }
 
 func checkV2Files(files []ign2types.File) error {
+	var drift []error
 	checkedFiles := make(map[string]bool)
 	for i := len(files) - 1; i >= 0; i-- {
 		f := files[i]
@@ -1519,11 +1647,11 @@ func checkV2Files(files []ign2types.File) error {
 			return errors.Wrapf(err, "couldn't parse file %q", f.Path)
 		}
 		if err := checkFileContentsAndMode(f.Path, contents.Data, mode); err != nil {
-			return err
+			drift = append(drift, err)
 		}
 		checkedFiles[f.Path] = true
 	}
-	return nil
+	return newConfigDriftError(drift)
 }
 
 // checkFileContentsAndMode reads the file from the filepath and compares its
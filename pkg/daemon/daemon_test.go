@@ -1,6 +1,7 @@
 package daemon
 
 import (
+	"context"
 	"encoding/json"
 	"io/ioutil"
 	"os"
@@ -172,13 +173,16 @@ func (f *fixture) newController() *Daemon {
 	}
 	d.ClusterConnect("node_name_test",
 		f.kubeclient,
+		f.client,
 		i.Machineconfiguration().V1().MachineConfigs(),
+		i.Machineconfiguration().V1().KernelArgumentPolicies(),
 		k8sI.Core().V1().Nodes(),
 		false,
 		"",
 	)
 
 	d.mcListerSynced = alwaysReady
+	d.kapListerSynced = alwaysReady
 	d.nodeListerSynced = alwaysReady
 	d.recorder = &record.FakeRecorder{}
 
@@ -211,7 +215,7 @@ func (f *fixture) runExpectError(node string) {
 func (f *fixture) runController(node string, expectError bool) {
 	d := f.newController()
 
-	err := d.syncHandler(node)
+	err := d.syncHandler(context.Background(), node)
 	if !expectError && err != nil {
 		f.t.Errorf("error syncing node: %v", err)
 	} else if expectError && err == nil {
@@ -419,3 +423,24 @@ func TestPrepUpdateFromClusterOnDiskDrift(t *testing.T) {
 	require.Equal(t, onDiskMC.GetName(), current.GetName())
 	require.Equal(t, desired.GetName(), "test2")
 }
+
+func TestRunApply(t *testing.T) {
+	tmpCurrentConfig, err := ioutil.TempFile("", "currentconfig")
+	require.Nil(t, err)
+	defer os.Remove(tmpCurrentConfig.Name())
+
+	dn := &Daemon{currentConfigPath: tmpCurrentConfig.Name()}
+	mc := helpers.NewMachineConfig("rendered-worker-1", nil, "registry.example.com/os@sha256:aaa", nil)
+
+	// the on disk config can't be read
+	_, err = tmpCurrentConfig.WriteString("{")
+	require.Nil(t, err)
+	require.NotNil(t, dn.RunApply(context.Background(), mc, true))
+
+	// the host already is at the config, nothing to apply
+	require.Nil(t, dn.storeCurrentConfigOnDisk(helpers.NewMachineConfig("rendered-worker-0", nil, "registry.example.com/os@sha256:aaa", nil)))
+	require.Nil(t, dn.RunApply(context.Background(), mc, true))
+	onDisk, err := dn.getCurrentConfigOnDisk()
+	require.Nil(t, err)
+	require.Equal(t, "rendered-worker-0", onDisk.GetName())
+}
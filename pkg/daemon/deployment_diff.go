@@ -0,0 +1,164 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+// The changes of a PackageChange
+const (
+	PackageAdded      = "Added"
+	PackageRemoved    = "Removed"
+	PackageUpgraded   = "Upgraded"
+	PackageDowngraded = "Downgraded"
+)
+
+// maxDeploymentDiffAnnotationPackages is how many package changes the
+// PendingOSUpdateDiffAnnotationKey annotation lists, the full diff is logged
+const maxDeploymentDiffAnnotationPackages = 200
+
+// PackageChange is a package changed between two deployments
+type PackageChange struct {
+	Name string `json:"name"`
+	// Change is one of PackageAdded, PackageRemoved, PackageUpgraded or PackageDowngraded
+	Change string `json:"change"`
+	// PreviousVersion is the version-release of the package before, empty if it is added
+	PreviousVersion string `json:"previousVersion,omitempty"`
+	// NewVersion is the version-release of the package after, empty if it is removed
+	NewVersion string `json:"newVersion,omitempty"`
+}
+
+// rpmOstreeDBDiff is the output of `rpm-ostree db diff --format=json`
+type rpmOstreeDBDiff struct {
+	// PkgDiff are the changed packages, as [name, type, {"PreviousPackage": [name, evr, arch], "NewPackage": [...]}]
+	PkgDiff [][]json.RawMessage `json:"pkgdiff"`
+}
+
+// the package diff types of rpm-ostree, in its order
+var rpmOstreePackageDiffTypes = []string{PackageUpgraded, PackageDowngraded, PackageRemoved, PackageAdded}
+
+// parseDBDiff parses the output of `rpm-ostree db diff --format=json`
+func parseDBDiff(output []byte) ([]PackageChange, error) {
+	var diff rpmOstreeDBDiff
+	if err := json.Unmarshal(output, &diff); err != nil {
+		return nil, errors.Wrap(err, "failed to parse `rpm-ostree db diff --format=json` output")
+	}
+	changes := []PackageChange{}
+	for _, entry := range diff.PkgDiff {
+		if len(entry) != 3 {
+			return nil, fmt.Errorf("invalid rpm-ostree package diff %s", entry)
+		}
+		var (
+			change  PackageChange
+			typ     int
+			details map[string][]string
+		)
+		if err := json.Unmarshal(entry[0], &change.Name); err != nil {
+			return nil, errors.Wrapf(err, "invalid rpm-ostree package diff name %s", entry[0])
+		}
+		if err := json.Unmarshal(entry[1], &typ); err != nil || typ < 0 || typ >= len(rpmOstreePackageDiffTypes) {
+			return nil, fmt.Errorf("invalid rpm-ostree package diff type %s of %s", entry[1], change.Name)
+		}
+		if err := json.Unmarshal(entry[2], &details); err != nil {
+			return nil, errors.Wrapf(err, "invalid rpm-ostree package diff details of %s", change.Name)
+		}
+		change.Change = rpmOstreePackageDiffTypes[typ]
+		// packages are [name, evr, arch]
+		if pkg := details["PreviousPackage"]; len(pkg) > 1 {
+			change.PreviousVersion = pkg[1]
+		}
+		if pkg := details["NewPackage"]; len(pkg) > 1 {
+			change.NewVersion = pkg[1]
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// deploymentDiffAnnotation is the value of the PendingOSUpdateDiffAnnotationKey annotation
+type deploymentDiffAnnotation struct {
+	Added      int `json:"added"`
+	Removed    int `json:"removed"`
+	Upgraded   int `json:"upgraded"`
+	Downgraded int `json:"downgraded"`
+	// Packages are the first maxDeploymentDiffAnnotationPackages package changes
+	Packages []PackageChange `json:"packages"`
+	// Truncated is set when Packages doesn't list all the package changes
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// newDeploymentDiffAnnotation returns the PendingOSUpdateDiffAnnotationKey annotation of changes
+func newDeploymentDiffAnnotation(changes []PackageChange) (string, error) {
+	annotation := deploymentDiffAnnotation{Packages: changes}
+	for _, change := range changes {
+		switch change.Change {
+		case PackageAdded:
+			annotation.Added++
+		case PackageRemoved:
+			annotation.Removed++
+		case PackageUpgraded:
+			annotation.Upgraded++
+		case PackageDowngraded:
+			annotation.Downgraded++
+		}
+	}
+	if len(changes) > maxDeploymentDiffAnnotationPackages {
+		annotation.Packages = changes[:maxDeploymentDiffAnnotationPackages]
+		annotation.Truncated = true
+	}
+	value, err := json.Marshal(annotation)
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+// reportDeploymentDiff logs the packages the staged deployment changes and publishes them on the
+// node, so what the OS update changes is known before the reboot. Failing to doesn't fail the update.
+func (dn *Daemon) reportDeploymentDiff(ctx context.Context) {
+	changes, err := NewNodeUpdaterClient().GetDeploymentDiff(ctx)
+	if err != nil {
+		glog.Warningf("Failed to get the package changes of the staged deployment: %v", err)
+		return
+	}
+	if changes == nil {
+		return
+	}
+	glog.Infof("Staged deployment changes %d packages", len(changes))
+	for _, change := range changes {
+		switch change.Change {
+		case PackageAdded:
+			glog.Infof("%s %s %s", change.Change, change.Name, change.NewVersion)
+		case PackageRemoved:
+			glog.Infof("%s %s %s", change.Change, change.Name, change.PreviousVersion)
+		default:
+			glog.Infof("%s %s %s -> %s", change.Change, change.Name, change.PreviousVersion, change.NewVersion)
+		}
+	}
+
+	if dn.nodeWriter == nil || dn.node == nil {
+		return
+	}
+	value, err := newDeploymentDiffAnnotation(changes)
+	if err != nil {
+		glog.Warningf("Failed to encode the package changes of the staged deployment: %v", err)
+		return
+	}
+	if err := dn.nodeWriter.SetPendingOSUpdateDiff(value, dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name); err != nil {
+		glog.Warningf("Failed to publish the package changes of the staged deployment: %v", err)
+	}
+}
+
+// clearDeploymentDiff removes the package changes of the deployment the node booted into
+func (dn *Daemon) clearDeploymentDiff() error {
+	if dn.nodeWriter == nil || dn.node == nil || dn.node.Annotations[constants.PendingOSUpdateDiffAnnotationKey] == "" {
+		return nil
+	}
+	return dn.nodeWriter.SetPendingOSUpdateDiff("", dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name)
+}
@@ -0,0 +1,70 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDBDiff(t *testing.T) {
+	output := `{
+  "pkgdiff": [
+    ["kernel", 0, {"PreviousPackage": ["kernel", "4.18.0-305.el8", "x86_64"], "NewPackage": ["kernel", "4.18.0-305.3.1.el8", "x86_64"]}],
+    ["podman", 1, {"PreviousPackage": ["podman", "3.2.0-1.el8", "x86_64"], "NewPackage": ["podman", "3.0.1-7.el8", "x86_64"]}],
+    ["nano", 2, {"PreviousPackage": ["nano", "2.9.8-1.el8", "x86_64"]}],
+    ["usbguard", 3, {"NewPackage": ["usbguard", "1.0.0-2.el8", "x86_64"]}]
+  ],
+  "advisories": []
+}`
+	changes, err := parseDBDiff([]byte(output))
+	require.NoError(t, err)
+	assert.Equal(t, []PackageChange{
+		{Name: "kernel", Change: PackageUpgraded, PreviousVersion: "4.18.0-305.el8", NewVersion: "4.18.0-305.3.1.el8"},
+		{Name: "podman", Change: PackageDowngraded, PreviousVersion: "3.2.0-1.el8", NewVersion: "3.0.1-7.el8"},
+		{Name: "nano", Change: PackageRemoved, PreviousVersion: "2.9.8-1.el8"},
+		{Name: "usbguard", Change: PackageAdded, NewVersion: "1.0.0-2.el8"},
+	}, changes)
+
+	changes, err = parseDBDiff([]byte(`{"pkgdiff": []}`))
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	_, err = parseDBDiff([]byte(`{"pkgdiff": [["kernel", 7, {}]]}`))
+	assert.Error(t, err)
+	_, err = parseDBDiff([]byte(`not json`))
+	assert.Error(t, err)
+}
+
+func TestNewDeploymentDiffAnnotation(t *testing.T) {
+	var changes []PackageChange
+	for i := 0; i < maxDeploymentDiffAnnotationPackages+1; i++ {
+		changes = append(changes, PackageChange{Name: fmt.Sprintf("pkg-%d", i), Change: PackageUpgraded, PreviousVersion: "1-1", NewVersion: "1-2"})
+	}
+	changes = append(changes, PackageChange{Name: "usbguard", Change: PackageAdded, NewVersion: "1.0.0-2.el8"})
+
+	value, err := newDeploymentDiffAnnotation(changes)
+	require.NoError(t, err)
+	var annotation deploymentDiffAnnotation
+	require.NoError(t, json.Unmarshal([]byte(value), &annotation))
+	assert.Equal(t, maxDeploymentDiffAnnotationPackages+1, annotation.Upgraded)
+	assert.Equal(t, 1, annotation.Added)
+	assert.Len(t, annotation.Packages, maxDeploymentDiffAnnotationPackages)
+	assert.True(t, annotation.Truncated)
+
+	value, err = newDeploymentDiffAnnotation(changes[:1])
+	require.NoError(t, err)
+	assert.Equal(t, `{"added":0,"removed":0,"upgraded":1,"downgraded":0,"packages":[{"name":"pkg-0","change":"Upgraded","previousVersion":"1-1","newVersion":"1-2"}]}`, value)
+}
+
+func TestStagedDeployment(t *testing.T) {
+	booted, staged := stagedDeployment([]RpmOstreeDeployment{{ID: "new"}, {ID: "booted", Booted: true}, {ID: "old"}})
+	assert.Equal(t, "booted", booted.ID)
+	assert.Equal(t, "new", staged.ID)
+
+	booted, staged = stagedDeployment([]RpmOstreeDeployment{{ID: "booted", Booted: true}, {ID: "old"}})
+	assert.Equal(t, "booted", booted.ID)
+	assert.Nil(t, staged)
+}
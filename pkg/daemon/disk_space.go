@@ -0,0 +1,47 @@
+package daemon
+
+import (
+	"fmt"
+
+	"github.com/golang/glog"
+)
+
+// osImageSpaceFactor is how much disk space an OS image takes once pulled and unpacked, relative
+// to the size of its compressed layers
+const osImageSpaceFactor = 2
+
+// osImageSpacePaths are the filesystems an OS update writes the OS image to: the container
+// storage and the OSTree repository
+var osImageSpacePaths = []string{"/var", "/sysroot"}
+
+// checkOSImageDiskSpace checks there is enough free disk space to pull the OS image before it is,
+// so the update fails early with the reason rather than with ENOSPC halfway through the pull,
+// leaving partial layers behind. The images whose size can't be estimated aren't checked.
+func checkOSImageDiskSpace(imgURL string) error {
+	size, err := estimateImageSize(osImagePullSpec(imgURL))
+	if err != nil {
+		glog.Warningf("Failed to estimate the size of OS image %s, not checking the free disk space: %v", imgURL, err)
+		return nil
+	}
+	return checkDiskSpace(imgURL, uint64(size)*osImageSpaceFactor)
+}
+
+// checkDiskSpace checks the filesystems the OS image is written to have needed bytes free
+func checkDiskSpace(imgURL string, needed uint64) error {
+	for _, path := range osImageSpacePaths {
+		free, err := hostFreeSpace(path)
+		if err != nil {
+			glog.Warningf("Failed to get the free disk space of %s: %v", path, err)
+			continue
+		}
+		if free < needed {
+			return fmt.Errorf("not enough free disk space on %s for OS image %s: %s free, about %s needed", path, imgURL, formatBytes(free), formatBytes(needed))
+		}
+	}
+	return nil
+}
+
+// formatBytes formats a size in GiB, e.g. 1.5GiB
+func formatBytes(size uint64) string {
+	return fmt.Sprintf("%.1fGiB", float64(size)/(1<<30))
+}
@@ -0,0 +1,28 @@
+package daemon
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckDiskSpace(t *testing.T) {
+	free := map[string]uint64{"/var": 8 << 30, "/sysroot": 3 << 30}
+	defer func(f func(string) (uint64, error)) { hostFreeSpace = f }(hostFreeSpace)
+	hostFreeSpace = func(path string) (uint64, error) {
+		size, ok := free[path]
+		if !ok {
+			return 0, fmt.Errorf("no such filesystem %s", path)
+		}
+		return size, nil
+	}
+
+	assert.Nil(t, checkDiskSpace("registry.example.com/os@sha256:aaa", 2<<30))
+	assert.EqualError(t, checkDiskSpace("registry.example.com/os@sha256:aaa", 4<<30),
+		"not enough free disk space on /sysroot for OS image registry.example.com/os@sha256:aaa: 3.0GiB free, about 4.0GiB needed")
+
+	// a filesystem whose free space is unknown isn't checked
+	delete(free, "/sysroot")
+	assert.Nil(t, checkDiskSpace("registry.example.com/os@sha256:aaa", 4<<30))
+}
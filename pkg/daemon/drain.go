@@ -9,6 +9,8 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/kubectl/pkg/drain"
+
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
 )
 
 func (dn *Daemon) drainRequired() bool {
@@ -52,7 +54,14 @@ func (dn *Daemon) drain() error {
 	}
 	var lastErr error
 	if err := wait.ExponentialBackoff(backoff, func() (bool, error) {
-		err := drain.RunNodeDrain(dn.drainer, dn.node.Name)
+		drainer := dn.drainer
+		if dn.forceDrainRequested() {
+			glog.Infof("Drain escalated, deleting the pods left ignoring their PodDisruptionBudgets")
+			forced := *dn.drainer
+			forced.DisableEviction = true
+			drainer = &forced
+		}
+		err := drain.RunNodeDrain(drainer, dn.node.Name)
 		if err != nil {
 			lastErr = err
 			glog.Infof("Draining failed with: %v, retrying", err)
@@ -75,6 +84,43 @@ func (dn *Daemon) drain() error {
 	return nil
 }
 
+// desiredConfigChanged returns the desired config of the node when it isn't config anymore. The
+// node controller moves the nodes whose drain got stuck back to their current config, the update
+// to config mustn't go on once drained then.
+func (dn *Daemon) desiredConfigChanged(config string) (string, bool) {
+	if dn.kubeClient == nil {
+		return "", false
+	}
+	node, err := dn.nodeLister.Get(dn.name)
+	if err != nil {
+		return "", false
+	}
+	desired := node.Annotations[constants.DesiredMachineConfigAnnotationKey]
+	return desired, desired != config
+}
+
+// abortUpdate uncordons the node drained for an update its desired config changed from meanwhile,
+// and sets it back to Done in its current config. The next sync updates it to its desired config.
+func (dn *Daemon) abortUpdate(currentConfigName, desiredConfigName string) error {
+	dn.logSystem("Desired config changed to %s while draining the node, staying in %s", desiredConfigName, currentConfigName)
+	if err := dn.cordonOrUncordonNode(false); err != nil {
+		return err
+	}
+	dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeNormal, "Uncordon", "Update aborted, desired config changed to %s while draining", desiredConfigName)
+	return dn.nodeWriter.SetDone(dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name, currentConfigName)
+}
+
+// forceDrainRequested returns whether the node controller escalated the stuck drain of the node for
+// its desired config to deleting the pods left, ignoring their PodDisruptionBudgets
+func (dn *Daemon) forceDrainRequested() bool {
+	node, err := dn.nodeLister.Get(dn.name)
+	if err != nil {
+		return false
+	}
+	force := node.Annotations[constants.ForceDrainAnnotationKey]
+	return force != "" && force == node.Annotations[constants.DesiredMachineConfigAnnotationKey]
+}
+
 func (dn *Daemon) performDrain() error {
 	// Skip drain process when we're not cluster driven
 	if dn.kubeClient == nil {
@@ -96,16 +142,27 @@ func (dn *Daemon) performDrain() error {
 	// We are here, that means we need to cordon and drain node
 	MCDDrainErr.WithLabelValues(dn.node.Name, "").Set(0)
 	dn.logSystem("Update prepared; beginning drain")
-	startTime := time.Now()
+	startTime := hostClock.Now()
 
 	dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeNormal, "Drain", "Draining node to update config.")
 
+	// the drain watchdog of the node controller times the drain from its first try
+	if dn.node.Annotations[constants.DrainStartedAnnotationKey] == "" {
+		if err := dn.nodeWriter.SetDrainStarted(startTime.UTC().Format(time.RFC3339), dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name); err != nil {
+			return errors.Wrap(err, "error recording the drain start")
+		}
+	}
+
 	if err := dn.drain(); err != nil {
 		return err
 	}
 
+	if err := dn.nodeWriter.SetDrainStarted("", dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name); err != nil {
+		return errors.Wrap(err, "error recording the drain completion")
+	}
+
 	dn.logSystem("drain complete")
-	t := time.Since(startTime).Seconds()
+	t := hostClock.Since(startTime).Seconds()
 	glog.Infof("Successful drain took %v seconds", t)
 	MCDDrainErr.WithLabelValues(dn.node.Name, "").Set(0)
 
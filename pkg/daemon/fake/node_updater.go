@@ -0,0 +1,509 @@
+// Package fake provides test doubles for the daemon's interfaces to the host, so code
+// depending on them, in the daemon as well as downstream and e2e tests, can be tested without a
+// CoreOS host
+package fake
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/daemon"
+)
+
+// The NodeUpdaterClient method names, used as keys of Errors and in the recorded Calls
+const (
+	GetStatusMethod              = "GetStatus"
+	GetBootedOSImageURLMethod    = "GetBootedOSImageURL"
+	RebaseMethod                 = "Rebase"
+	GetBootedDeploymentMethod    = "GetBootedDeployment"
+	GetStateMethod               = "GetState"
+	RollbackMethod               = "Rollback"
+	GetDeploymentDiffMethod      = "GetDeploymentDiff"
+	InstallPackagesMethod        = "InstallPackages"
+	RemovePackagesMethod         = "RemovePackages"
+	GetRollbackDeploymentMethod  = "GetRollbackDeployment"
+	StageRebaseMethod            = "StageRebase"
+	GetPrestagedOSImageURLMethod = "GetPrestagedOSImageURL"
+	ApplyLiveMethod              = "ApplyLive"
+	PinCurrentDeploymentMethod   = "PinCurrentDeployment"
+	UnpinDeploymentMethod        = "UnpinDeployment"
+	SwitchKernelMethod           = "SwitchKernel"
+)
+
+var (
+	defaultKernelPackages = []string{"kernel", "kernel-core", "kernel-modules", "kernel-modules-extra"}
+	kernelTypePackages    = map[string][]string{
+		ctrlcommon.KernelTypeRealtime: {"kernel-rt-core", "kernel-rt-modules", "kernel-rt-modules-extra", "kernel-rt-kvm"},
+		ctrlcommon.KernelType64kPages: {"kernel-64k-core", "kernel-64k-modules", "kernel-64k-modules-extra"},
+	}
+)
+
+var _ daemon.NodeUpdaterClient = &NodeUpdaterClient{}
+
+// Call is a recorded NodeUpdaterClient method call
+type Call struct {
+	Method string
+	Args   []string
+}
+
+// NodeUpdaterClient is a scriptable daemon.NodeUpdaterClient backed by a canned rpm-ostree
+// state. Rebase stages a new deployment the way rpm-ostree would and Reboot boots into it.
+type NodeUpdaterClient struct {
+	mu sync.Mutex
+
+	// Deployments is the rpm-ostree state, the first deployment is the one booted next
+	Deployments []daemon.RpmOstreeDeployment
+	// Status is the text returned by GetStatus
+	Status string
+	// DeploymentDiff are the package changes GetDeploymentDiff returns while a deployment is staged
+	DeploymentDiff []daemon.PackageChange
+	// FinalizationLocked is set while the staged deployment was staged ahead by StageRebase, a
+	// Reboot discards it then
+	FinalizationLocked bool
+	// Errors are returned by every call of the method they are keyed by, before the state
+	// is looked at or changed
+	Errors map[string]error
+
+	calls []Call
+	// queuedErrors are returned by the next calls of the method they are keyed by, one per call,
+	// before Errors
+	queuedErrors map[string][]error
+}
+
+// NewNodeUpdaterClient returns a fake booted into a deployment of osImageURL
+func NewNodeUpdaterClient(osImageURL, version string) *NodeUpdaterClient {
+	return &NodeUpdaterClient{
+		Deployments: []daemon.RpmOstreeDeployment{newDeployment(0, osImageURL, version, true)},
+		Errors:      map[string]error{},
+	}
+}
+
+// QueueErrors scripts the next calls of method to return errs, one per call, in order, e.g. to fail
+// the first Rebase only. A nil error lets its call go on, Errors only applies once they are all
+// returned.
+func (c *NodeUpdaterClient) QueueErrors(method string, errs ...error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.queuedErrors == nil {
+		c.queuedErrors = map[string][]error{}
+	}
+	c.queuedErrors[method] = append(c.queuedErrors[method], errs...)
+}
+
+// Calls returns the method calls made so far, in order
+func (c *NodeUpdaterClient) Calls() []Call {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	calls := make([]Call, len(c.calls))
+	copy(calls, c.calls)
+	return calls
+}
+
+// CallCount returns how many times method was called
+func (c *NodeUpdaterClient) CallCount(method string) int {
+	count := 0
+	for _, call := range c.Calls() {
+		if call.Method == method {
+			count++
+		}
+	}
+	return count
+}
+
+// GetStatus returns Status
+func (c *NodeUpdaterClient) GetStatus(context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.record(GetStatusMethod); err != nil {
+		return "", err
+	}
+	return c.Status, nil
+}
+
+// GetBootedOSImageURL returns the pivot:// origin and version of the booted deployment
+func (c *NodeUpdaterClient) GetBootedOSImageURL(context.Context) (string, string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.record(GetBootedOSImageURLMethod); err != nil {
+		return "", "", err
+	}
+	booted, err := c.booted()
+	if err != nil {
+		return "", "", err
+	}
+	return pivotURL(booted), booted.Version, nil
+}
+
+// Rebase stages a deployment of imgURL unless the booted one already is. The deployment staged
+// ahead of it by StageRebase is unlocked and used if it is one of imgURL.
+func (c *NodeUpdaterClient) Rebase(_ context.Context, imgURL, osImageContentDir string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.record(RebaseMethod, imgURL, osImageContentDir); err != nil {
+		return false, err
+	}
+	if c.FinalizationLocked && !c.Deployments[0].Booted && pivotURL(&c.Deployments[0]) == imgURL {
+		c.FinalizationLocked = false
+		return true, nil
+	}
+	c.FinalizationLocked = false
+	return c.rebase(imgURL)
+}
+
+// StageRebase stages a deployment of imgURL like Rebase, locking its finalization
+func (c *NodeUpdaterClient) StageRebase(_ context.Context, imgURL, osImageContentDir string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.record(StageRebaseMethod, imgURL, osImageContentDir); err != nil {
+		return false, err
+	}
+	changed, err := c.rebase(imgURL)
+	c.FinalizationLocked = changed
+	return changed, err
+}
+
+// GetPrestagedOSImageURL returns the pivot:// origin of the deployment staged by StageRebase
+func (c *NodeUpdaterClient) GetPrestagedOSImageURL(context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.record(GetPrestagedOSImageURLMethod); err != nil {
+		return "", err
+	}
+	if !c.FinalizationLocked || len(c.Deployments) == 0 || c.Deployments[0].Booted {
+		return "", nil
+	}
+	return pivotURL(&c.Deployments[0]), nil
+}
+
+func (c *NodeUpdaterClient) rebase(imgURL string) (bool, error) {
+	booted, err := c.booted()
+	if err != nil {
+		return false, err
+	}
+	if pivotURL(booted) == imgURL {
+		return false, nil
+	}
+
+	// rpm-ostree keeps a single staged deployment, replace the previous one if any
+	var serial int32
+	var deployments []daemon.RpmOstreeDeployment
+	for i, d := range c.Deployments {
+		if d.Serial >= serial {
+			serial = d.Serial + 1
+		}
+		if i > 0 || d.Booted {
+			deployments = append(deployments, d)
+		}
+	}
+	// the packages layered and removed are kept, on top of the new OS image
+	deployment := newDeployment(serial, imgURL, "", false)
+	deployment.RequestedPackages = append([]string{}, booted.RequestedPackages...)
+	deployment.RequestedBaseRemovals = append([]string{}, booted.RequestedBaseRemovals...)
+	deployments = append([]daemon.RpmOstreeDeployment{deployment}, deployments...)
+	c.Deployments = deployments
+	return true, nil
+}
+
+// GetBootedDeployment returns a copy of the booted deployment
+func (c *NodeUpdaterClient) GetBootedDeployment(context.Context) (*daemon.RpmOstreeDeployment, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.record(GetBootedDeploymentMethod); err != nil {
+		return nil, err
+	}
+	booted, err := c.booted()
+	if err != nil {
+		return nil, err
+	}
+	deployment := *booted
+	return &deployment, nil
+}
+
+// GetState returns a copy of Deployments
+func (c *NodeUpdaterClient) GetState(context.Context) (*daemon.RpmOstreeState, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.record(GetStateMethod); err != nil {
+		return nil, err
+	}
+	return &daemon.RpmOstreeState{Deployments: append([]daemon.RpmOstreeDeployment{}, c.Deployments...)}, nil
+}
+
+// GetRollbackDeployment returns a copy of the deployment after the booted one if nothing is staged
+func (c *NodeUpdaterClient) GetRollbackDeployment(context.Context) (*daemon.RpmOstreeDeployment, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.record(GetRollbackDeploymentMethod); err != nil {
+		return nil, err
+	}
+	if len(c.Deployments) < 2 || !c.Deployments[0].Booted {
+		return nil, nil
+	}
+	deployment := c.Deployments[1]
+	return &deployment, nil
+}
+
+// Rollback makes the rollback deployment the one booted next if it is a deployment of imgURL,
+// or any deployment for an empty imgURL, and nothing is staged
+func (c *NodeUpdaterClient) Rollback(_ context.Context, imgURL string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.record(RollbackMethod, imgURL); err != nil {
+		return false, err
+	}
+	if len(c.Deployments) < 2 || !c.Deployments[0].Booted || (imgURL != "" && pivotURL(&c.Deployments[1]) != imgURL) {
+		return false, nil
+	}
+	c.Deployments[0], c.Deployments[1] = c.Deployments[1], c.Deployments[0]
+	return true, nil
+}
+
+// GetDeploymentDiff returns DeploymentDiff if a deployment is staged, nil otherwise
+func (c *NodeUpdaterClient) GetDeploymentDiff(context.Context) ([]daemon.PackageChange, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.record(GetDeploymentDiffMethod); err != nil {
+		return nil, err
+	}
+	if _, err := c.booted(); err != nil {
+		return nil, err
+	}
+	if c.Deployments[0].Booted {
+		return nil, nil
+	}
+	return append([]daemon.PackageChange{}, c.DeploymentDiff...), nil
+}
+
+// InstallPackages adds the packages to the requested packages of the staged deployment, staging
+// one from the booted deployment if there is none
+func (c *NodeUpdaterClient) InstallPackages(_ context.Context, packages []string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.record(InstallPackagesMethod, packages...); err != nil {
+		return err
+	}
+	staged, err := c.staged()
+	if err != nil {
+		return err
+	}
+	for _, pkg := range packages {
+		if !contains(staged.RequestedPackages, pkg) {
+			staged.RequestedPackages = append(staged.RequestedPackages, pkg)
+		}
+	}
+	return nil
+}
+
+// RemovePackages removes the packages from the requested packages of the staged deployment,
+// staging one from the booted deployment if there is none. It fails if one isn't layered.
+func (c *NodeUpdaterClient) RemovePackages(_ context.Context, packages []string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.record(RemovePackagesMethod, packages...); err != nil {
+		return err
+	}
+	staged, err := c.staged()
+	if err != nil {
+		return err
+	}
+	for _, pkg := range packages {
+		if !contains(staged.RequestedPackages, pkg) {
+			return fmt.Errorf("package %s is not layered", pkg)
+		}
+	}
+	var requested []string
+	for _, pkg := range staged.RequestedPackages {
+		if !contains(packages, pkg) {
+			requested = append(requested, pkg)
+		}
+	}
+	staged.RequestedPackages = requested
+	return nil
+}
+
+// ApplyLive marks the booted deployment as live replaced by the staged one, which must only add
+// packages to it
+func (c *NodeUpdaterClient) ApplyLive(context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.record(ApplyLiveMethod); err != nil {
+		return err
+	}
+	booted, err := c.booted()
+	if err != nil {
+		return err
+	}
+	if c.Deployments[0].Booted {
+		return fmt.Errorf("no staged deployment to apply live")
+	}
+	for _, pkg := range booted.RequestedPackages {
+		if !contains(c.Deployments[0].RequestedPackages, pkg) {
+			return fmt.Errorf("package %s is removed, only added packages can be applied live", pkg)
+		}
+	}
+	booted.LiveReplaced = c.Deployments[0].ID
+	return nil
+}
+
+// PinCurrentDeployment pins the booted deployment, returning its ID and whether it wasn't pinned
+func (c *NodeUpdaterClient) PinCurrentDeployment(context.Context) (string, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.record(PinCurrentDeploymentMethod); err != nil {
+		return "", false, err
+	}
+	booted, err := c.booted()
+	if err != nil {
+		return "", false, err
+	}
+	pinned := !booted.Pinned
+	booted.Pinned = true
+	return booted.ID, pinned, nil
+}
+
+// UnpinDeployment unpins the deployment of the given ID unless it is the booted one
+func (c *NodeUpdaterClient) UnpinDeployment(_ context.Context, id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.record(UnpinDeploymentMethod, id); err != nil {
+		return err
+	}
+	for i := range c.Deployments {
+		if c.Deployments[i].ID == id && !c.Deployments[i].Booted {
+			c.Deployments[i].Pinned = false
+		}
+	}
+	return nil
+}
+
+// SwitchKernel removes the default kernel packages from the staged deployment and layers the
+// realtime ones, or the other way around, staging one from the booted deployment if there is none
+func (c *NodeUpdaterClient) SwitchKernel(_ context.Context, kernelType string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.record(SwitchKernelMethod, kernelType); err != nil {
+		return err
+	}
+	staged, err := c.staged()
+	if err != nil {
+		return err
+	}
+	var requested []string
+	for _, pkg := range staged.RequestedPackages {
+		if !isKernelTypePackage(pkg) {
+			requested = append(requested, pkg)
+		}
+	}
+	var removals []string
+	for _, pkg := range staged.RequestedBaseRemovals {
+		if !contains(defaultKernelPackages, pkg) {
+			removals = append(removals, pkg)
+		}
+	}
+	if pkgs, ok := kernelTypePackages[kernelType]; ok {
+		requested = append(requested, pkgs...)
+		removals = append(removals, defaultKernelPackages...)
+	}
+	staged.RequestedPackages = requested
+	staged.RequestedBaseRemovals = removals
+	return nil
+}
+
+// isKernelTypePackage returns whether pkg is a package of a kernel replacing the default one
+func isKernelTypePackage(pkg string) bool {
+	for _, pkgs := range kernelTypePackages {
+		if contains(pkgs, pkg) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reboot boots into the first deployment, as the host would after the daemon reboots it, unless
+// its finalization is locked
+func (c *NodeUpdaterClient) Reboot() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.FinalizationLocked {
+		c.FinalizationLocked = false
+		if len(c.Deployments) > 0 && !c.Deployments[0].Booted {
+			c.Deployments = c.Deployments[1:]
+		}
+	}
+	for i := range c.Deployments {
+		c.Deployments[i].Booted = i == 0
+		c.Deployments[i].LiveReplaced = ""
+	}
+}
+
+func (c *NodeUpdaterClient) record(method string, args ...string) error {
+	c.calls = append(c.calls, Call{Method: method, Args: args})
+	if queued := c.queuedErrors[method]; len(queued) > 0 {
+		c.queuedErrors[method] = queued[1:]
+		return queued[0]
+	}
+	return c.Errors[method]
+}
+
+func (c *NodeUpdaterClient) booted() (*daemon.RpmOstreeDeployment, error) {
+	for i := range c.Deployments {
+		if c.Deployments[i].Booted {
+			return &c.Deployments[i], nil
+		}
+	}
+	return nil, fmt.Errorf("not currently booted in a deployment")
+}
+
+// staged returns the staged deployment, staging a copy of the booted one if there is none
+func (c *NodeUpdaterClient) staged() (*daemon.RpmOstreeDeployment, error) {
+	booted, err := c.booted()
+	if err != nil {
+		return nil, err
+	}
+	if !c.Deployments[0].Booted {
+		return &c.Deployments[0], nil
+	}
+	staged := *booted
+	staged.Booted = false
+	staged.Pinned = false
+	for _, d := range c.Deployments {
+		if d.Serial >= staged.Serial {
+			staged.Serial = d.Serial + 1
+		}
+	}
+	staged.ID = fmt.Sprintf("rhcos-%d", staged.Serial)
+	staged.RequestedPackages = append([]string{}, booted.RequestedPackages...)
+	staged.RequestedBaseRemovals = append([]string{}, booted.RequestedBaseRemovals...)
+	c.Deployments = append([]daemon.RpmOstreeDeployment{staged}, c.Deployments...)
+	return &c.Deployments[0], nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func newDeployment(serial int32, osImageURL, version string, booted bool) daemon.RpmOstreeDeployment {
+	return daemon.RpmOstreeDeployment{
+		ID:           fmt.Sprintf("rhcos-%d", serial),
+		OSName:       "rhcos",
+		Serial:       serial,
+		Version:      version,
+		Booted:       booted,
+		CustomOrigin: []string{"pivot://" + osImageURL},
+	}
+}
+
+func pivotURL(deployment *daemon.RpmOstreeDeployment) string {
+	if len(deployment.CustomOrigin) > 0 && strings.HasPrefix(deployment.CustomOrigin[0], "pivot://") {
+		return deployment.CustomOrigin[0][len("pivot://"):]
+	}
+	return ""
+}
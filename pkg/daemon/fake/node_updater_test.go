@@ -0,0 +1,267 @@
+package fake
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/daemon"
+)
+
+func TestNodeUpdaterClientRebase(t *testing.T) {
+	client := NewNodeUpdaterClient("registry.example.com/os@sha256:aaa", "47.1")
+
+	url, version, err := client.GetBootedOSImageURL(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "registry.example.com/os@sha256:aaa", url)
+	assert.Equal(t, "47.1", version)
+
+	changed, err := client.Rebase(context.Background(), "registry.example.com/os@sha256:aaa", "/run/mco")
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	changed, err = client.Rebase(context.Background(), "registry.example.com/os@sha256:bbb", "/run/mco")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	changed, err = client.Rebase(context.Background(), "registry.example.com/os@sha256:ccc", "/run/mco")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	// the second rebase replaces the staged deployment
+	require.Len(t, client.Deployments, 2)
+
+	// still booted in the original deployment until the reboot
+	url, _, err = client.GetBootedOSImageURL(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "registry.example.com/os@sha256:aaa", url)
+
+	client.Reboot()
+	booted, err := client.GetBootedDeployment(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pivot://registry.example.com/os@sha256:ccc"}, booted.CustomOrigin)
+
+	assert.Equal(t, 3, client.CallCount(RebaseMethod))
+	assert.Equal(t, Call{Method: RebaseMethod, Args: []string{"registry.example.com/os@sha256:aaa", "/run/mco"}}, client.Calls()[1])
+}
+
+func TestNodeUpdaterClientErrors(t *testing.T) {
+	client := NewNodeUpdaterClient("registry.example.com/os@sha256:aaa", "47.1")
+	client.Errors[RebaseMethod] = errors.New("rpm-ostree failed")
+
+	_, err := client.Rebase(context.Background(), "registry.example.com/os@sha256:bbb", "/run/mco")
+	assert.EqualError(t, err, "rpm-ostree failed")
+	assert.Len(t, client.Deployments, 1)
+
+	client.Deployments[0].Booted = false
+	_, err = client.GetBootedDeployment(context.Background())
+	assert.Error(t, err)
+}
+
+func TestNodeUpdaterClientRollback(t *testing.T) {
+	client := NewNodeUpdaterClient("registry.example.com/os@sha256:aaa", "47.1")
+	changed, err := client.Rollback(context.Background(), "registry.example.com/os@sha256:aaa")
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	_, err = client.Rebase(context.Background(), "registry.example.com/os@sha256:bbb", "/run/mco")
+	require.NoError(t, err)
+	client.Reboot()
+
+	changed, err = client.Rollback(context.Background(), "registry.example.com/os@sha256:ccc")
+	require.NoError(t, err)
+	assert.False(t, changed)
+	changed, err = client.Rollback(context.Background(), "registry.example.com/os@sha256:aaa")
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	client.Reboot()
+	url, _, err := client.GetBootedOSImageURL(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "registry.example.com/os@sha256:aaa", url)
+}
+
+func TestNodeUpdaterClientDeploymentDiff(t *testing.T) {
+	client := NewNodeUpdaterClient("registry.example.com/os@sha256:aaa", "47.1")
+	client.DeploymentDiff = []daemon.PackageChange{{Name: "kernel", Change: daemon.PackageUpgraded, PreviousVersion: "4.18.0-305.el8", NewVersion: "4.18.0-305.3.1.el8"}}
+	changes, err := client.GetDeploymentDiff(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, changes)
+
+	_, err = client.Rebase(context.Background(), "registry.example.com/os@sha256:bbb", "/run/mco")
+	require.NoError(t, err)
+	changes, err = client.GetDeploymentDiff(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, client.DeploymentDiff, changes)
+}
+
+func TestNodeUpdaterClientPackages(t *testing.T) {
+	client := NewNodeUpdaterClient("registry.example.com/os@sha256:aaa", "47.1")
+	require.NoError(t, client.InstallPackages(context.Background(), []string{"usbguard", "kata-containers"}))
+	require.NoError(t, client.InstallPackages(context.Background(), []string{"usbguard"}))
+	assert.Len(t, client.Deployments, 2)
+	assert.Equal(t, []string{"usbguard", "kata-containers"}, client.Deployments[0].RequestedPackages)
+
+	assert.Error(t, client.RemovePackages(context.Background(), []string{"kernel-devel"}))
+	require.NoError(t, client.RemovePackages(context.Background(), []string{"usbguard"}))
+	assert.Equal(t, []string{"kata-containers"}, client.Deployments[0].RequestedPackages)
+
+	client.Reboot()
+	booted, err := client.GetBootedDeployment(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kata-containers"}, booted.RequestedPackages)
+	assert.Equal(t, "registry.example.com/os@sha256:aaa", pivotURL(booted))
+}
+
+func TestNodeUpdaterClientRollbackAny(t *testing.T) {
+	client := NewNodeUpdaterClient("registry.example.com/os@sha256:aaa", "47.1")
+	rollback, err := client.GetRollbackDeployment(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, rollback)
+
+	_, err = client.Rebase(context.Background(), "registry.example.com/os@sha256:bbb", "/run/mco")
+	require.NoError(t, err)
+	client.Reboot()
+
+	rollback, err = client.GetRollbackDeployment(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pivot://registry.example.com/os@sha256:aaa"}, rollback.CustomOrigin)
+	changed, err := client.Rollback(context.Background(), "")
+	require.NoError(t, err)
+	assert.True(t, changed)
+}
+
+func TestNodeUpdaterClientStageRebase(t *testing.T) {
+	client := NewNodeUpdaterClient("registry.example.com/os@sha256:aaa", "47.1")
+
+	changed, err := client.StageRebase(context.Background(), "registry.example.com/os@sha256:bbb", "/run/mco")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	prestaged, err := client.GetPrestagedOSImageURL(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "registry.example.com/os@sha256:bbb", prestaged)
+
+	// a reboot before the update discards the deployment staged ahead
+	client.Reboot()
+	require.Len(t, client.Deployments, 1)
+	prestaged, err = client.GetPrestagedOSImageURL(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, prestaged)
+
+	// the update uses the deployment staged ahead, which is booted into then
+	_, err = client.StageRebase(context.Background(), "registry.example.com/os@sha256:bbb", "/run/mco")
+	require.NoError(t, err)
+	changed, err = client.Rebase(context.Background(), "registry.example.com/os@sha256:bbb", "/run/mco")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.False(t, client.FinalizationLocked)
+	client.Reboot()
+	url, _, err := client.GetBootedOSImageURL(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "registry.example.com/os@sha256:bbb", url)
+}
+
+func TestNodeUpdaterClientApplyLive(t *testing.T) {
+	client := NewNodeUpdaterClient("registry.example.com/os@sha256:aaa", "47.1")
+	assert.Error(t, client.ApplyLive(context.Background()))
+
+	require.NoError(t, client.InstallPackages(context.Background(), []string{"usbguard"}))
+	require.NoError(t, client.ApplyLive(context.Background()))
+	booted, err := client.GetBootedDeployment(context.Background())
+	require.NoError(t, err)
+	assert.NotEmpty(t, booted.LiveReplaced)
+
+	client.Reboot()
+	booted, err = client.GetBootedDeployment(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, booted.LiveReplaced)
+	assert.Equal(t, []string{"usbguard"}, booted.RequestedPackages)
+
+	// only added packages are applied live
+	require.NoError(t, client.RemovePackages(context.Background(), []string{"usbguard"}))
+	assert.Error(t, client.ApplyLive(context.Background()))
+}
+
+func TestNodeUpdaterClientPinDeployment(t *testing.T) {
+	client := NewNodeUpdaterClient("registry.example.com/os@sha256:aaa", "47.1")
+	previous, pinned, err := client.PinCurrentDeployment(context.Background())
+	require.NoError(t, err)
+	assert.True(t, pinned)
+	_, pinned, err = client.PinCurrentDeployment(context.Background())
+	require.NoError(t, err)
+	assert.False(t, pinned)
+
+	// the known-good deployment stays pinned until the node completes the next update
+	_, err = client.Rebase(context.Background(), "registry.example.com/os@sha256:bbb", "/run/mco")
+	require.NoError(t, err)
+	assert.False(t, client.Deployments[0].Pinned)
+	client.Reboot()
+	assert.True(t, client.Deployments[1].Pinned)
+
+	current, _, err := client.PinCurrentDeployment(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, client.UnpinDeployment(context.Background(), current))
+	assert.True(t, client.Deployments[0].Pinned)
+	require.NoError(t, client.UnpinDeployment(context.Background(), previous))
+	assert.True(t, client.Deployments[0].Pinned)
+	assert.False(t, client.Deployments[1].Pinned)
+}
+
+func TestNodeUpdaterClientGetState(t *testing.T) {
+	client := NewNodeUpdaterClient("registry.example.com/os@sha256:aaa", "47.1")
+	_, err := client.Rebase(context.Background(), "registry.example.com/os@sha256:bbb", "/run/mco")
+	require.NoError(t, err)
+
+	state, err := client.GetState(context.Background())
+	require.NoError(t, err)
+	require.Len(t, state.Deployments, 2)
+	assert.False(t, state.Deployments[0].Booted)
+	assert.True(t, state.Deployments[1].Booted)
+
+	// the state returned is a copy
+	state.Deployments[0].Booted = true
+	assert.False(t, client.Deployments[0].Booted)
+}
+
+func TestNodeUpdaterClientQueueErrors(t *testing.T) {
+	client := NewNodeUpdaterClient("registry.example.com/os@sha256:aaa", "47.1")
+	pullErr := errors.New("pull failed")
+	client.QueueErrors(RebaseMethod, pullErr, nil)
+	client.Errors[RebaseMethod] = errors.New("rebase failed")
+
+	_, err := client.Rebase(context.Background(), "registry.example.com/os@sha256:bbb", "/run/mco")
+	assert.Equal(t, pullErr, err)
+	changed, err := client.Rebase(context.Background(), "registry.example.com/os@sha256:bbb", "/run/mco")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	_, err = client.Rebase(context.Background(), "registry.example.com/os@sha256:ccc", "/run/mco")
+	assert.EqualError(t, err, "rebase failed")
+	assert.Equal(t, 3, client.CallCount(RebaseMethod))
+}
+
+func TestNodeUpdaterClientSwitchKernel(t *testing.T) {
+	client := NewNodeUpdaterClient("registry.example.com/os@sha256:aaa", "47.1")
+	require.NoError(t, client.InstallPackages(context.Background(), []string{"usbguard"}))
+	require.NoError(t, client.SwitchKernel(context.Background(), ctrlcommon.KernelTypeRealtime))
+	assert.Equal(t, []string{"usbguard", "kernel-rt-core", "kernel-rt-modules", "kernel-rt-modules-extra", "kernel-rt-kvm"}, client.Deployments[0].RequestedPackages)
+	assert.Equal(t, defaultKernelPackages, client.Deployments[0].RequestedBaseRemovals)
+	client.Reboot()
+
+	// a rebase keeps the realtime kernel
+	_, err := client.Rebase(context.Background(), "registry.example.com/os@sha256:bbb", "/run/mco")
+	require.NoError(t, err)
+	assert.Equal(t, defaultKernelPackages, client.Deployments[0].RequestedBaseRemovals)
+
+	require.NoError(t, client.SwitchKernel(context.Background(), ctrlcommon.KernelTypeDefault))
+	assert.Equal(t, []string{"usbguard"}, client.Deployments[0].RequestedPackages)
+	assert.Empty(t, client.Deployments[0].RequestedBaseRemovals)
+
+	// the 64k-pages kernel replaces the realtime one
+	require.NoError(t, client.SwitchKernel(context.Background(), ctrlcommon.KernelTypeRealtime))
+	require.NoError(t, client.SwitchKernel(context.Background(), ctrlcommon.KernelType64kPages))
+	assert.Equal(t, []string{"usbguard", "kernel-64k-core", "kernel-64k-modules", "kernel-64k-modules-extra"}, client.Deployments[0].RequestedPackages)
+	assert.Equal(t, defaultKernelPackages, client.Deployments[0].RequestedBaseRemovals)
+	assert.Equal(t, 4, client.CallCount(SwitchKernelMethod))
+}
@@ -0,0 +1,167 @@
+package daemon
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	pivotutils "github.com/openshift/machine-config-operator/pkg/daemon/pivot/utils"
+)
+
+const (
+	// proxyEnvPath holds the cluster proxy of the ControllerConfig, rendered into every config
+	proxyEnvPath = "/etc/mco/proxy.env"
+	// registryAuthFileEnv points podman and skopeo at the pull secret
+	registryAuthFileEnv = "REGISTRY_AUTH_FILE"
+)
+
+// proxyEnvKeys are the variables the cluster proxy is passed in. Both cases are set as curl,
+// and so ostree and rpm-ostree, only read the lowercase http_proxy.
+var proxyEnvKeys = []string{"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "http_proxy", "https_proxy", "no_proxy"}
+
+// commander builds the commands the daemon runs on the host
+type commander interface {
+	Command(name string, arg ...string) *exec.Cmd
+}
+
+// clock tells the time and waits for the daemon
+type clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	Sleep(d time.Duration)
+	After(d time.Duration) <-chan time.Time
+}
+
+// fileStater looks up the files whose presence drives the update flow, e.g. the force file
+// or the kubelet pull secret
+type fileStater interface {
+	Stat(name string) (os.FileInfo, error)
+}
+
+type execCommander struct{}
+
+func (execCommander) Command(name string, arg ...string) *exec.Cmd { return exec.Command(name, arg...) }
+
+// hostEnvCommander sets up the environment of every command run on the host the same way:
+// the cluster proxy and the pull secret, so the registry and repository clients don't each
+// need to be told about them
+type hostEnvCommander struct {
+	commander
+	proxyEnvPath string
+}
+
+func (c hostEnvCommander) Command(name string, arg ...string) *exec.Cmd {
+	cmd := c.commander.Command(name, arg...)
+	base := cmd.Env
+	if base == nil {
+		base = os.Environ()
+	}
+	proxy, err := ioutil.ReadFile(c.proxyEnvPath)
+	if err != nil {
+		// the daemonset has the proxy in the daemon's environment already
+		proxy = nil
+	}
+	authFile := ""
+	if _, err := hostFileStater.Stat(kubeletAuthFile); err == nil {
+		authFile = kubeletAuthFile
+	}
+	cmd.Env = commandEnv(base, parseProxyEnv(proxy), authFile)
+	return cmd
+}
+
+// parseProxyEnv returns the proxy variables set in the contents of a proxy.env file
+func parseProxyEnv(data []byte) map[string]string {
+	proxy := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 || kv[1] == "" {
+			continue
+		}
+		proxy[strings.ToUpper(kv[0])] = kv[1]
+	}
+	return proxy
+}
+
+// commandEnv returns base with the proxy variables replaced by the ones of proxy, if it sets
+// any, and the registry auth file set to authFile, if not empty
+func commandEnv(base []string, proxy map[string]string, authFile string) []string {
+	env := make([]string, 0, len(base)+len(proxyEnvKeys)+1)
+	for _, kv := range base {
+		key := strings.SplitN(kv, "=", 2)[0]
+		if len(proxy) > 0 && isProxyEnvKey(key) {
+			continue
+		}
+		if authFile != "" && key == registryAuthFileEnv {
+			continue
+		}
+		env = append(env, kv)
+	}
+	if len(proxy) > 0 {
+		for _, key := range proxyEnvKeys {
+			if value, ok := proxy[strings.ToUpper(key)]; ok {
+				env = append(env, key+"="+value)
+			}
+		}
+	}
+	if authFile != "" {
+		env = append(env, registryAuthFileEnv+"="+authFile)
+	}
+	return env
+}
+
+func isProxyEnvKey(key string) bool {
+	for _, k := range proxyEnvKeys {
+		if key == k {
+			return true
+		}
+	}
+	return false
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) Since(t time.Time) time.Duration        { return time.Since(t) }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+type osFileStater struct{}
+
+func (osFileStater) Stat(name string) (os.FileInfo, error) { return os.Stat(name) }
+
+// statfsFreeSpace returns the free space of the filesystem of path available to the daemon
+func statfsFreeSpace(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return stat.Bavail * uint64(stat.Bsize), nil
+}
+
+// The host the daemon acts on. Tests swap these out to run the update flow against recorded
+// commands, a fake clock and fake files instead.
+var (
+	hostCommander  commander  = hostEnvCommander{execCommander{}, proxyEnvPath}
+	hostClock      clock      = realClock{}
+	hostFileStater fileStater = osFileStater{}
+	hostFreeSpace             = statfsFreeSpace
+	hostArch                  = runtime.GOARCH
+)
+
+func init() {
+	// the pivot utilities run podman, oc and rpm-ostree too
+	pivotutils.ExecCommand = func(name string, arg ...string) *exec.Cmd {
+		return hostCommander.Command(name, arg...)
+	}
+}
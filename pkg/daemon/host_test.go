@@ -0,0 +1,309 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	pivotutils "github.com/openshift/machine-config-operator/pkg/daemon/pivot/utils"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+// setHost replaces the non-nil host interfaces, including the commands run by the pivot
+// utilities, and returns a function restoring the previous ones
+func setHost(cmd commander, clk clock, stater fileStater) (restore func()) {
+	oldCommander, oldClock, oldFileStater, oldPivotCommand := hostCommander, hostClock, hostFileStater, pivotutils.ExecCommand
+	if cmd != nil {
+		hostCommander = cmd
+		pivotutils.ExecCommand = cmd.Command
+	}
+	if clk != nil {
+		hostClock = clk
+	}
+	if stater != nil {
+		hostFileStater = stater
+	}
+	return func() {
+		hostCommander, hostClock, hostFileStater, pivotutils.ExecCommand = oldCommander, oldClock, oldFileStater, oldPivotCommand
+	}
+}
+
+func TestHostEnvCommander(t *testing.T) {
+	dir, err := ioutil.TempDir("", "proxy-env")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	proxyEnv := filepath.Join(dir, "proxy.env")
+	stater := helpers.NewFakeFileStater()
+	defer setHost(nil, nil, stater)()
+	cmd := hostEnvCommander{helpers.NewCommandRecorder(), proxyEnv}
+
+	// without the proxy.env and the pull secret the environment is left alone
+	env := cmd.Command("podman", "pull", "-q", "quay.io/os").Env
+	assert.Equal(t, []string{"FAKE_OUTPUT=", "FAKE_EXIT_CODE=0"}, env)
+
+	require.Nil(t, ioutil.WriteFile(proxyEnv, []byte(`# Proxy environment variables will be populated in this file.
+HTTP_PROXY=http://proxy.example.com:3128
+HTTPS_PROXY=http://proxy.example.com:3129
+NO_PROXY=.cluster.local,10.0.0.0/16
+`), 0644))
+	stater.Add(kubeletAuthFile)
+	env = cmd.Command("rpm-ostree", "rebase", "pivot://quay.io/os").Env
+	assert.Equal(t, []string{
+		"FAKE_OUTPUT=",
+		"FAKE_EXIT_CODE=0",
+		"HTTP_PROXY=http://proxy.example.com:3128",
+		"HTTPS_PROXY=http://proxy.example.com:3129",
+		"NO_PROXY=.cluster.local,10.0.0.0/16",
+		"http_proxy=http://proxy.example.com:3128",
+		"https_proxy=http://proxy.example.com:3129",
+		"no_proxy=.cluster.local,10.0.0.0/16",
+		"REGISTRY_AUTH_FILE=" + kubeletAuthFile,
+	}, env)
+}
+
+func TestCommandEnv(t *testing.T) {
+	base := []string{"PATH=/usr/bin", "HTTP_PROXY=http://stale:3128", "https_proxy=http://stale:3128", "REGISTRY_AUTH_FILE=/root/auth.json"}
+
+	// the daemon's own proxy is kept when the config has none
+	assert.Equal(t, base, commandEnv(base, map[string]string{}, ""))
+
+	assert.Equal(t, []string{
+		"PATH=/usr/bin",
+		"HTTPS_PROXY=http://proxy:3128",
+		"https_proxy=http://proxy:3128",
+		"REGISTRY_AUTH_FILE=" + kubeletAuthFile,
+	}, commandEnv(base, map[string]string{"HTTPS_PROXY": "http://proxy:3128"}, kubeletAuthFile))
+}
+
+func TestRetryIfNecessary(t *testing.T) {
+	clk := helpers.NewFakeClock(time.Unix(0, 0))
+	defer setHost(nil, clk, nil)()
+
+	attempts := 0
+	err := retryIfNecessary(context.Background(), func() error {
+		attempts++
+		return errors.New("registry unavailable")
+	})
+	assert.EqualError(t, err, "registry unavailable")
+	assert.Equal(t, cmdRetriesCount+1, attempts)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, clk.Sleeps())
+
+	attempts = 0
+	err = retryIfNecessary(context.Background(), func() error {
+		attempts++
+		if attempts == 1 {
+			return errors.New("registry unavailable")
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, attempts)
+}
+
+func TestVerifyOSImageSignature(t *testing.T) {
+	const image = "registry.example.com/os@sha256:aaa"
+	recorder := helpers.NewCommandRecorder()
+	clk := helpers.NewFakeClock(time.Now())
+	defer setHost(recorder, clk, nil)()
+
+	require.Nil(t, verifyOSImageSignature(image))
+	recorder.AssertCalled(t, "podman", "pull", "-q", image)
+
+	recorder.Reset()
+	recorder.Respond("Error: Source image rejected: A signature was required, but no signature exists\n", 125, "podman", "pull")
+	err := verifyOSImageSignature("ostree-unverified-registry:" + image)
+	assert.True(t, IsOSImageVerificationError(err))
+	assert.EqualError(t, err, "OS image ostree-unverified-registry:"+image+" rejected by the signature policy: A signature was required, but no signature exists")
+	assert.Len(t, recorder.Calls(), 1)
+
+	// other pull errors are retried
+	recorder.Reset()
+	recorder.Respond("Error: connection refused", 125, "podman", "pull")
+	err = verifyOSImageSignature(image)
+	assert.Error(t, err)
+	assert.False(t, IsOSImageVerificationError(err))
+	assert.Len(t, recorder.Calls(), numRetriesNetCommands+1)
+
+	// ostree verifies the signed OSTree container references
+	recorder.Reset()
+	require.Nil(t, verifyOSImageSignature("ostree-image-signed:docker://"+image))
+	assert.Empty(t, recorder.Calls())
+}
+
+func TestRebasePrestaged(t *testing.T) {
+	const (
+		booted = "ostree-unverified-registry:registry.example.com/os@sha256:aaa"
+		staged = "ostree-unverified-registry:registry.example.com/os@sha256:bbb"
+	)
+	recorder := helpers.NewCommandRecorder()
+	recorder.Respond(`{"deployments": [
+		{"id": "rhcos-1", "container-image-reference": "`+staged+`"},
+		{"id": "rhcos-0", "booted": true, "container-image-reference": "`+booted+`"}
+	]}`, 0, "rpm-ostree", "status", "--json")
+	stater := helpers.NewFakeFileStater()
+	defer setHost(recorder, nil, stater)()
+	client := &RpmOstreeClient{}
+
+	// nothing is staged ahead without the finalization lock
+	prestaged, err := client.GetPrestagedOSImageURL(context.Background())
+	require.Nil(t, err)
+	assert.Empty(t, prestaged)
+
+	changed, err := client.StageRebase(context.Background(), staged, "")
+	require.Nil(t, err)
+	assert.True(t, changed)
+	recorder.AssertCalled(t, "rpm-ostree", "rebase", "--experimental", staged, "--lock-finalization")
+
+	stater.Add(stagedDeploymentLockFile)
+	prestaged, err = client.GetPrestagedOSImageURL(context.Background())
+	require.Nil(t, err)
+	assert.Equal(t, staged, prestaged)
+
+	// the update uses the deployment staged ahead
+	recorder.Reset()
+	changed, err = client.Rebase(context.Background(), staged, "")
+	require.Nil(t, err)
+	assert.True(t, changed)
+	recorder.AssertNotCalled(t, "rpm-ostree", "rebase")
+
+	// and replaces it for another image
+	changed, err = client.Rebase(context.Background(), "ostree-unverified-registry:registry.example.com/os@sha256:ccc", "")
+	require.Nil(t, err)
+	assert.True(t, changed)
+	recorder.AssertCalled(t, "rpm-ostree", "rebase", "--experimental", "ostree-unverified-registry:registry.example.com/os@sha256:ccc")
+}
+
+func TestRunGetOutContext(t *testing.T) {
+	defer setHost(execCommander{}, nil, nil)()
+
+	out, err := runGetOutContext(context.Background(), "echo", "hello")
+	require.Nil(t, err)
+	assert.Equal(t, "hello\n", string(out))
+
+	// a hung command is terminated once the context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = runGetOutContext(ctx, "sleep", "30")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.True(t, time.Since(start) < commandTerminationGracePeriod)
+}
+
+func TestVerifyStagedDeployment(t *testing.T) {
+	recorder := helpers.NewCommandRecorder()
+	recorder.Respond(`{"deployments": [
+		{"id": "rhcos-1", "checksum": "ccc", "base-checksum": "bbb"},
+		{"id": "rhcos-0", "booted": true, "checksum": "aaa"}
+	]}`, 0, "rpm-ostree", "status", "--json")
+	defer setHost(recorder, nil, nil)()
+	client := &RpmOstreeClient{}
+
+	// the packages layered on the staged deployment don't change its base commit
+	assert.Nil(t, client.verifyStagedDeployment(context.Background(), "bbb"))
+	assert.NotNil(t, client.verifyStagedDeployment(context.Background(), "ccc"))
+	assert.NotNil(t, client.verifyStagedDeployment(context.Background(), "aaa"))
+
+	// the booted deployment is booted next when nothing is staged
+	recorder.Respond(`{"deployments": [
+		{"id": "rhcos-0", "booted": true, "checksum": "aaa"}
+	]}`, 0, "rpm-ostree", "status", "--json")
+	assert.Nil(t, client.verifyStagedDeployment(context.Background(), "aaa"))
+	assert.NotNil(t, client.verifyStagedDeployment(context.Background(), "bbb"))
+}
+
+func TestPinDeployment(t *testing.T) {
+	recorder := helpers.NewCommandRecorder()
+	recorder.Respond(`{"deployments": [
+		{"id": "rhcos-3"},
+		{"id": "rhcos-2", "booted": true},
+		{"id": "rhcos-1", "pinned": true},
+		{"id": "rhcos-0", "pinned": true}
+	]}`, 0, "rpm-ostree", "status", "--json")
+	defer setHost(recorder, nil, nil)()
+	client := &RpmOstreeClient{}
+
+	id, pinned, err := client.PinCurrentDeployment(context.Background())
+	require.Nil(t, err)
+	assert.Equal(t, "rhcos-2", id)
+	assert.True(t, pinned)
+	recorder.AssertCalled(t, "ostree", "admin", "pin", "1")
+
+	// only the given deployment is unpinned, the ones pinned by admins stay pinned
+	require.Nil(t, client.UnpinDeployment(context.Background(), "rhcos-1"))
+	recorder.AssertCalled(t, "ostree", "admin", "pin", "--unpin", "2")
+	recorder.AssertNotCalled(t, "ostree", "admin", "pin", "--unpin", "3")
+	require.Nil(t, client.UnpinDeployment(context.Background(), "rhcos-2"))
+	recorder.AssertNotCalled(t, "ostree", "admin", "pin", "--unpin", "1")
+}
+
+func TestSwitchKernel(t *testing.T) {
+	recorder := helpers.NewCommandRecorder()
+	recorder.Respond(`{"deployments": [{"id": "rhcos-1", "booted": true}]}`, 0, "rpm-ostree", "status", "--json")
+	defer setHost(recorder, nil, nil)()
+	client := &RpmOstreeClient{}
+
+	require.Nil(t, client.SwitchKernel(context.Background(), ctrlcommon.KernelTypeDefault))
+	recorder.AssertNotCalled(t, "rpm-ostree", "override")
+	require.Nil(t, client.SwitchKernel(context.Background(), ctrlcommon.KernelTypeRealtime))
+	recorder.AssertCalled(t, "rpm-ostree", "override", "remove", "kernel", "kernel-core", "kernel-modules", "kernel-modules-extra",
+		"--install", "kernel-rt-core", "--install", "kernel-rt-modules", "--install", "kernel-rt-modules-extra", "--install", "kernel-rt-kvm")
+
+	// the deployment rebased to keeps the realtime kernel, its packages are updated
+	recorder.Reset()
+	recorder.Respond(`{"deployments": [
+		{"id": "rhcos-2", "requested-packages": ["kernel-rt-core", "kernel-rt-modules", "kernel-rt-modules-extra", "kernel-rt-kvm"]},
+		{"id": "rhcos-1", "booted": true}
+	]}`, 0, "rpm-ostree", "status", "--json")
+	require.Nil(t, client.SwitchKernel(context.Background(), ctrlcommon.KernelTypeRealtime))
+	recorder.AssertCalled(t, "rpm-ostree", "update")
+	require.Nil(t, client.SwitchKernel(context.Background(), ctrlcommon.KernelTypeDefault))
+	recorder.AssertCalled(t, "rpm-ostree", "override", "reset", "kernel", "kernel-core", "kernel-modules", "kernel-modules-extra",
+		"--uninstall", "kernel-rt-core", "--uninstall", "kernel-rt-modules", "--uninstall", "kernel-rt-modules-extra", "--uninstall", "kernel-rt-kvm")
+	recorder.AssertNotCalled(t, "rpm-ostree", "override", "remove")
+
+	// switching from the realtime kernel to the 64k-pages one resets the override first
+	recorder.Reset()
+	require.Nil(t, client.SwitchKernel(context.Background(), ctrlcommon.KernelType64kPages))
+	calls := recorder.Calls()
+	require.Len(t, calls, 3)
+	assert.Equal(t, []string{"rpm-ostree", "override", "reset"}, calls[1][:3])
+	assert.Equal(t, []string{"rpm-ostree", "override", "remove", "kernel", "kernel-core", "kernel-modules", "kernel-modules-extra",
+		"--install", "kernel-64k-core", "--install", "kernel-64k-modules", "--install", "kernel-64k-modules-extra"}, calls[2])
+}
+
+func TestPreloadedOSImage(t *testing.T) {
+	const (
+		hex    = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+		imgURL = "registry.example.com/os@sha256:" + hex
+	)
+	recorder := helpers.NewCommandRecorder()
+	recorder.Respond("", 1, "podman", "image", "exists")
+	recorder.Respond(`[{"Id": "aaa", "Labels": {"com.coreos.ostree-commit": "bbb"}}]`, 0, "podman", "image", "inspect")
+	stater := helpers.NewFakeFileStater()
+	defer setHost(recorder, nil, stater)()
+
+	// neither pulled nor pre-loaded
+	assert.False(t, osImagePulled(imgURL))
+	recorder.AssertNotCalled(t, "skopeo")
+
+	stater.Add(filepath.Join(preloadedOSImagesDir, hex))
+	assert.True(t, osImagePulled(imgURL))
+	recorder.AssertCalled(t, "skopeo", "copy", "oci:"+filepath.Join(preloadedOSImagesDir, hex), "containers-storage:"+imgURL)
+
+	// only the images pinned by digest are pre-loaded
+	recorder.Reset()
+	assert.False(t, osImagePulled("registry.example.com/os:latest"))
+	recorder.AssertNotCalled(t, "skopeo")
+
+	labels, err := localImageLabels(imgURL)
+	require.Nil(t, err)
+	assert.Equal(t, map[string]string{"com.coreos.ostree-commit": "bbb"}, labels)
+}
@@ -2,14 +2,21 @@ package daemon
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"math"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/containers/image/v5/docker"
+	"github.com/containers/image/v5/docker/reference"
 	"github.com/containers/image/v5/image"
+	"github.com/containers/image/v5/manifest"
+	"github.com/containers/image/v5/pkg/sysregistriesv2"
 	"github.com/containers/image/v5/types"
+	"github.com/golang/glog"
+	"github.com/opencontainers/go-digest"
 	"github.com/pkg/errors"
 )
 
@@ -24,7 +31,7 @@ func retryIfNecessary(ctx context.Context, operation func() error) error {
 		delay := time.Duration(int(math.Pow(2, float64(attempt)))) * time.Second
 		fmt.Printf("Warning: failed, retrying in %s ... (%d/%d)", delay, attempt+1, cmdRetriesCount)
 		select {
-		case <-time.After(delay):
+		case <-hostClock.After(delay):
 			break
 		case <-ctx.Done():
 			return err
@@ -86,3 +93,203 @@ func imageInspect(imageName string) (*types.ImageInspectInfo, error) {
 
 	return imgInspect, nil
 }
+
+// maxCachedImageInspections is how many OS images the labels are cached of
+const maxCachedImageInspections = 8
+
+// imageInspectionCache caches the labels of the OS images inspected, by digest. An image pinned by
+// digest never changes, so retrying an update to it doesn't inspect, nor pull, the image again.
+type imageInspectionCache struct {
+	mu     sync.Mutex
+	labels map[digest.Digest]map[string]string
+	// order is the digests cached, oldest first
+	order []digest.Digest
+}
+
+var osImageInspections = &imageInspectionCache{labels: map[digest.Digest]map[string]string{}}
+
+func (c *imageInspectionCache) get(dgst digest.Digest) (map[string]string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	labels, ok := c.labels[dgst]
+	return labels, ok
+}
+
+func (c *imageInspectionCache) add(dgst digest.Digest, labels map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.labels[dgst]; ok {
+		return
+	}
+	if len(c.order) == maxCachedImageInspections {
+		delete(c.labels, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.labels[dgst] = labels
+	c.order = append(c.order, dgst)
+}
+
+// imageDigest returns the digest imgURL is pinned to, empty if it isn't
+func imageDigest(imgURL string) digest.Digest {
+	named, err := reference.ParseNormalizedNamed(imgURL)
+	if err != nil {
+		return ""
+	}
+	canonical, ok := named.(reference.Canonical)
+	if !ok {
+		return ""
+	}
+	return canonical.Digest()
+}
+
+// inspectOSImageLabels returns the labels of the OS image, read from the registry without pulling
+// the image. The labels of the images pinned by digest are cached.
+func inspectOSImageLabels(imgURL string) (map[string]string, error) {
+	dgst := imageDigest(imgURL)
+	if dgst != "" {
+		if labels, ok := osImageInspections.get(dgst); ok {
+			glog.Infof("Using the cached inspection of %s", imgURL)
+			return labels, nil
+		}
+	}
+
+	var (
+		labels map[string]string
+		err    error
+	)
+	if osImagePulled(imgURL) {
+		// pulled or pre-loaded already, the registry may not even be reachable
+		if labels, err = localImageLabels(imgURL); err != nil {
+			return nil, errors.Wrapf(err, "inspecting OS image %s", imgURL)
+		}
+	} else {
+		imageData, err := imageInspect(imgURL)
+		if err != nil {
+			return nil, errors.Wrapf(err, "inspecting OS image %s", imgURL)
+		}
+		labels = imageData.Labels
+	}
+	if dgst != "" {
+		osImageInspections.add(dgst, labels)
+	}
+	return labels, nil
+}
+
+// isImageTag returns whether imgURL references an image by tag rather than by digest
+func isImageTag(imgURL string) bool {
+	named, err := reference.ParseNormalizedNamed(imgURL)
+	if err != nil {
+		return false
+	}
+	_, canonical := named.(reference.Canonical)
+	return !canonical
+}
+
+// resolveImageDigest returns the digest the tag of imgURL currently references in the registry
+func resolveImageDigest(imgURL string) (digest.Digest, error) {
+	var (
+		src           types.ImageSource
+		manifestBytes []byte
+		err           error
+	)
+
+	ctx := context.Background()
+	sys := &types.SystemContext{AuthFilePath: kubeletAuthFile}
+
+	if err := retryIfNecessary(ctx, func() error {
+		src, err = newDockerImageSource(ctx, sys, imgURL)
+		return err
+	}); err != nil {
+		return "", errors.Wrapf(err, "Error parsing image name %q", imgURL)
+	}
+
+	defer src.Close()
+
+	if err := retryIfNecessary(ctx, func() error {
+		manifestBytes, _, err = src.GetManifest(ctx, nil)
+		return err
+	}); err != nil {
+		return "", err
+	}
+
+	return manifest.Digest(manifestBytes)
+}
+
+// osImagePullSources returns the references to pull imgURL from, in order: the mirrors of its
+// repository in the registries.conf of the host, which the ImageContentSourcePolicies of the
+// cluster are rendered into, then imgURL itself. containers/image and podman try them
+// already, this is for the clients which don't read registries.conf.
+func osImagePullSources(sys *types.SystemContext, imgURL string) []string {
+	named, err := reference.ParseNormalizedNamed(imgURL)
+	if err != nil {
+		return []string{imgURL}
+	}
+	registry, err := sysregistriesv2.FindRegistry(sys, named.Name())
+	if err != nil {
+		glog.Warningf("Failed to read the mirrors of %s, pulling it from its source: %v", imgURL, err)
+		return []string{imgURL}
+	}
+	if registry == nil {
+		return []string{imgURL}
+	}
+	sources, err := registry.PullSourcesFromReference(named)
+	if err != nil {
+		glog.Warningf("Failed to read the mirrors of %s, pulling it from its source: %v", imgURL, err)
+		return []string{imgURL}
+	}
+	refs := make([]string, 0, len(sources))
+	for _, source := range sources {
+		refs = append(refs, source.Reference.String())
+	}
+	return refs
+}
+
+// localImageLabels returns the labels of the image in the container storage of the host
+func localImageLabels(imgURL string) (map[string]string, error) {
+	output, err := runGetOut("podman", "image", "inspect", imgURL)
+	if err != nil {
+		return nil, err
+	}
+	var images []struct {
+		Labels map[string]string `json:"Labels"`
+	}
+	if err := json.Unmarshal(output, &images); err != nil {
+		return nil, errors.Wrap(err, "parsing podman image inspect output")
+	}
+	if len(images) != 1 {
+		return nil, fmt.Errorf("podman image inspect returned %d images", len(images))
+	}
+	return images[0].Labels, nil
+}
+
+// estimateImageSize returns the size of the compressed layers of the image, from its manifest
+func estimateImageSize(imageName string) (int64, error) {
+	var src types.ImageSource
+
+	ctx := context.Background()
+	sys := &types.SystemContext{AuthFilePath: kubeletAuthFile}
+
+	if err := retryIfNecessary(ctx, func() error {
+		var err error
+		src, err = newDockerImageSource(ctx, sys, imageName)
+		return err
+	}); err != nil {
+		return 0, errors.Wrapf(err, "Error parsing image name %q", imageName)
+	}
+
+	defer src.Close()
+
+	img, err := image.FromUnparsedImage(ctx, sys, image.UnparsedInstance(src, nil))
+	if err != nil {
+		return 0, fmt.Errorf("Error parsing manifest for image: %v", err)
+	}
+
+	var size int64
+	for _, layer := range img.LayerInfos() {
+		if layer.Size < 0 {
+			return 0, fmt.Errorf("size of layer %s unknown", layer.Digest)
+		}
+		size += layer.Size
+	}
+	return size, nil
+}
@@ -0,0 +1,127 @@
+package daemon
+
+import (
+	"context"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	pivotutils "github.com/openshift/machine-config-operator/pkg/daemon/pivot/utils"
+)
+
+// pendingImagePull returns the config the node controller set the node to pre-pull the OS image
+// of and it hasn't yet, empty if there is none. Updates go first, the node has to be done
+// updating to its desired config.
+func pendingImagePull(node *corev1.Node) string {
+	desired := node.Annotations[constants.DesiredImagePullAnnotationKey]
+	if desired == "" || desired == node.Annotations[constants.CurrentImagePullAnnotationKey] {
+		return ""
+	}
+	if node.Annotations[constants.CurrentMachineConfigAnnotationKey] != node.Annotations[constants.DesiredMachineConfigAnnotationKey] ||
+		node.Annotations[constants.MachineConfigDaemonStateAnnotationKey] != constants.MachineConfigDaemonStateDone {
+		return ""
+	}
+	return desired
+}
+
+// performImagePull pulls the OS image of the config into the container storage of the host, so
+// the update to it copies the OS from there rather than downloading it while the node is drained.
+// The OS update is staged too when the node controller asks for it, only the reboot is left to
+// the update then.
+func (dn *Daemon) performImagePull(ctx context.Context, configName string) error {
+	config, err := dn.mcLister.Get(configName)
+	if err != nil {
+		return err
+	}
+	currentConfigName, err := getNodeAnnotation(dn.node, constants.CurrentMachineConfigAnnotationKey)
+	if err != nil {
+		return err
+	}
+	currentConfig, err := dn.mcLister.Get(currentConfigName)
+	if err != nil {
+		return err
+	}
+
+	imgURL := config.Spec.OSImageURL
+	// rpm-ostree pulls the OSTree container images itself, out of the container storage
+	if dn.os.IsCoreOSVariant() && imgURL != "" && imgURL != currentConfig.Spec.OSImageURL && !IsOSTreeContainerReference(imgURL) && !osImagePulled(imgURL) {
+		// only the image of the next update is kept around
+		if previous := dn.node.Annotations[constants.CurrentImagePullAnnotationKey]; previous != "" {
+			if previousConfig, err := dn.mcLister.Get(previous); err == nil && previousConfig.Spec.OSImageURL != imgURL {
+				hostCommander.Command("podman", "rmi", previousConfig.Spec.OSImageURL).Run()
+			}
+		}
+		if err := checkOSImageDiskSpace(imgURL); err != nil {
+			return err
+		}
+		dn.logSystem("Pre-pulling OS image %s of %s", imgURL, configName)
+		if _, err := pivotutils.RunExtBackground(numRetriesNetCommands, "podman", "pull", "-q", imgURL); err != nil {
+			return errors.Wrapf(err, "pre-pulling OS image %s", imgURL)
+		}
+		if dn.recorder != nil {
+			dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeNormal, "OSImagePulled", "Pre-pulled OS image %s of %s", imgURL, configName)
+		}
+	}
+	if dn.os.IsCoreOSVariant() && imgURL != "" && imgURL != currentConfig.Spec.OSImageURL && dn.node.Annotations[constants.StageOSUpdateAnnotationKey] == "true" {
+		// the update stages it itself if this fails
+		if err := dn.stageOSUpdate(ctx, imgURL, configName); err != nil {
+			glog.Warningf("Failed to stage the OS update to %s ahead of the update: %v", imgURL, err)
+			if dn.recorder != nil {
+				dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeWarning, "OSUpdatePrestageFailed", "Failed to stage the OS update to %s of %s: %v", imgURL, configName, err)
+			}
+		}
+	}
+	if err := dn.nodeWriter.SetCurrentImagePull(configName, dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name); err != nil {
+		return errors.Wrap(err, "error recording the image pull")
+	}
+	return nil
+}
+
+// stageOSUpdate stages the rebase to the OS image of the config ahead of the update to it. The
+// deployment is discarded rather than booted into if the node reboots before its update.
+func (dn *Daemon) stageOSUpdate(ctx context.Context, imgURL, configName string) (retErr error) {
+	imgURL, err := dn.pinOSImage(imgURL)
+	if err != nil {
+		return err
+	}
+	prestaged, err := dn.NodeUpdaterClient.GetPrestagedOSImageURL(ctx)
+	if err != nil {
+		return err
+	}
+	if prestaged == imgURL {
+		return nil
+	}
+	var osImageContentDir string
+	if !IsOSTreeContainerReference(imgURL) {
+		if osImageContentDir, err = ExtractOSImage(imgURL); err != nil {
+			return err
+		}
+		defer func() { releaseOSImageContent(osImageContentDir, retErr) }()
+	}
+	dn.logSystem("Staging the OS update to %s of %s", imgURL, configName)
+	if _, err := dn.NodeUpdaterClient.StageRebase(ctx, imgURL, osImageContentDir); err != nil {
+		return err
+	}
+	if dn.recorder != nil {
+		dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeNormal, "OSUpdatePrestaged", "Staged the OS update to %s of %s", imgURL, configName)
+	}
+	return nil
+}
+
+// discardPrestagedOSUpdate removes the OS update staged ahead of the update to it unless it is to
+// keepImage, and returns the OS image of the one kept, if any
+func (dn *Daemon) discardPrestagedOSUpdate(ctx context.Context, keepImage string) (string, error) {
+	prestaged, err := dn.NodeUpdaterClient.GetPrestagedOSImageURL(ctx)
+	if err != nil {
+		return "", err
+	}
+	if prestaged == "" || (keepImage != "" && prestaged == keepImage) {
+		return prestaged, nil
+	}
+	glog.Infof("Discarding the OS update to %s staged ahead", prestaged)
+	if err := removePendingDeployment(ctx); err != nil {
+		return "", errors.Wrapf(err, "discarding the OS update to %s staged ahead", prestaged)
+	}
+	return "", nil
+}
@@ -0,0 +1,67 @@
+package daemon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+func TestPendingImagePull(t *testing.T) {
+	tests := []struct {
+		name     string
+		annos    map[string]string
+		expected string
+	}{{
+		name: "no pull",
+		annos: map[string]string{
+			constants.CurrentMachineConfigAnnotationKey:     "v1",
+			constants.DesiredMachineConfigAnnotationKey:     "v1",
+			constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateDone,
+		},
+	}, {
+		name: "pending pull",
+		annos: map[string]string{
+			constants.CurrentMachineConfigAnnotationKey:     "v1",
+			constants.DesiredMachineConfigAnnotationKey:     "v1",
+			constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateDone,
+			constants.DesiredImagePullAnnotationKey:         "v2",
+			constants.CurrentImagePullAnnotationKey:         "v1",
+		},
+		expected: "v2",
+	}, {
+		name: "already pulled",
+		annos: map[string]string{
+			constants.CurrentMachineConfigAnnotationKey:     "v1",
+			constants.DesiredMachineConfigAnnotationKey:     "v1",
+			constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateDone,
+			constants.DesiredImagePullAnnotationKey:         "v2",
+			constants.CurrentImagePullAnnotationKey:         "v2",
+		},
+	}, {
+		name: "updating",
+		annos: map[string]string{
+			constants.CurrentMachineConfigAnnotationKey:     "v1",
+			constants.DesiredMachineConfigAnnotationKey:     "v2",
+			constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateWorking,
+			constants.DesiredImagePullAnnotationKey:         "v2",
+		},
+	}, {
+		name: "degraded",
+		annos: map[string]string{
+			constants.CurrentMachineConfigAnnotationKey:     "v1",
+			constants.DesiredMachineConfigAnnotationKey:     "v1",
+			constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateDegraded,
+			constants.DesiredImagePullAnnotationKey:         "v2",
+		},
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0", Annotations: test.annos}}
+			assert.Equal(t, test.expected, pendingImagePull(node))
+		})
+	}
+}
@@ -2,10 +2,10 @@ package daemon
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
-	"os/exec"
 	"strings"
 
 	// Enable sha256 in container image references
@@ -42,7 +42,7 @@ func isArgTunable(arg string) (bool, error) {
 		return false, errors.Errorf("failed to get OS for determining whether kernel arg is tuneable: %v", err)
 	}
 
-	if os.IsRHCOS() {
+	if os.IsRHCOSLike() {
 		return tuneableRHCOSArgsAllowlist[arg], nil
 	} else if os.IsFCOS() {
 		return tuneableFCOSArgsAllowlist[arg], nil
@@ -50,21 +50,138 @@ func isArgTunable(arg string) (bool, error) {
 	return false, nil
 }
 
-// isArgInUse checks to see if the argument is already in use by the system currently
-func isArgInUse(arg, cmdLinePath string) (bool, error) {
+// KernelArgument is a kernel argument parsed from a command line, e.g. `nosmt` or
+// `bar="hello world"`
+type KernelArgument struct {
+	Key string
+	// Value is the value of the argument without its quotes, empty if it has none
+	Value string
+	// HasValue is set for the arguments with a value, even an empty one as in `foo=`
+	HasValue bool
+	// Quoted is set if the value, or the whole argument, was quoted
+	Quoted bool
+
+	// raw is the argument as parsed, rpm-ostree only deletes an argument as it was appended
+	raw string
+}
+
+// ParseKernelArgument parses a single kernel argument as split by splitKernelArguments. The
+// kernel only handles quotes around spaces, the value can't contain any.
+func ParseKernelArgument(arg string) KernelArgument {
+	arg = strings.TrimSpace(arg)
+	karg := KernelArgument{raw: arg}
+	// the kernel accepts the whole argument in quotes, "foo=bar baz"
+	if len(arg) > 1 && arg[0] == '"' && arg[len(arg)-1] == '"' {
+		arg = arg[1 : len(arg)-1]
+		karg.Quoted = true
+	}
+	i := strings.Index(arg, "=")
+	if i < 0 {
+		karg.Key = arg
+		return karg
+	}
+	karg.Key, karg.Value, karg.HasValue = arg[:i], arg[i+1:], true
+	if len(karg.Value) > 1 && karg.Value[0] == '"' && karg.Value[len(karg.Value)-1] == '"' {
+		karg.Value = karg.Value[1 : len(karg.Value)-1]
+		karg.Quoted = true
+	}
+	return karg
+}
+
+// String returns the argument as it was parsed, or else as passed to the kernel with its value
+// quoted if Quoted is set
+func (k KernelArgument) String() string {
+	if k.raw != "" {
+		return k.raw
+	}
+	if !k.HasValue {
+		return k.Key
+	}
+	if k.Quoted {
+		return k.Key + `="` + k.Value + `"`
+	}
+	return k.Key + "=" + k.Value
+}
+
+// Equal returns whether the arguments are the same, however they are quoted
+func (k KernelArgument) Equal(other KernelArgument) bool {
+	return k.Key == other.Key && k.Value == other.Value && k.HasValue == other.HasValue
+}
+
+// KernelArguments are kernel arguments in command line order. A key can be repeated, e.g.
+// hugepagesz and hugepages for each page size.
+type KernelArguments []KernelArgument
+
+// ParseKernelArguments parses a kernel command line
+func ParseKernelArguments(cmdLine string) KernelArguments {
+	var kargs KernelArguments
+	for _, arg := range splitKernelArguments(cmdLine) {
+		kargs = append(kargs, ParseKernelArgument(arg))
+	}
+	return kargs
+}
+
+// Has returns whether the arguments include arg
+func (k KernelArguments) Has(arg KernelArgument) bool {
+	for _, karg := range k {
+		if karg.Equal(arg) {
+			return true
+		}
+	}
+	return false
+}
+
+// Values returns the values of the arguments with key, in command line order
+func (k KernelArguments) Values(key string) []string {
+	var values []string
+	for _, karg := range k {
+		if karg.Key == key {
+			values = append(values, karg.Value)
+		}
+	}
+	return values
+}
+
+// Missing returns the desired arguments not in the arguments, a desired argument repeated n
+// times has to be in them n times
+func (k KernelArguments) Missing(desired KernelArguments) KernelArguments {
+	remaining := append(KernelArguments{}, k...)
+	var missing KernelArguments
+	for _, arg := range desired {
+		found := false
+		for i, karg := range remaining {
+			if karg.Equal(arg) {
+				remaining = append(remaining[:i], remaining[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, arg)
+		}
+	}
+	return missing
+}
+
+// GetKernelArgs returns the arguments of the running kernel, from /proc/cmdline by default
+func GetKernelArgs(cmdLinePath string) (KernelArguments, error) {
 	if cmdLinePath == "" {
 		cmdLinePath = CmdLineFile
 	}
 	content, err := ioutil.ReadFile(cmdLinePath)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
+	return ParseKernelArguments(string(content)), nil
+}
 
-	checkable := string(content)
-	if strings.Contains(checkable, arg) {
-		return true, nil
+// isArgInUse checks to see if the argument is already in use by the system currently
+func isArgInUse(arg, cmdLinePath string) (bool, error) {
+	kargs, err := GetKernelArgs(cmdLinePath)
+	if err != nil {
+		return false, err
 	}
-	return false, nil
+	return kargs.Has(ParseKernelArgument(arg)), nil
 }
 
 // parseTuningFile parses the kernel argument tuning file
@@ -144,7 +261,7 @@ func parseTuningFile(tuningFilePath, cmdLinePath string) ([]types.TuneArgument,
 }
 
 // UpdateTuningArgs executes additions and removals of kernel tuning arguments
-func UpdateTuningArgs(tuningFilePath, cmdLinePath string) (bool, error) {
+func UpdateTuningArgs(ctx context.Context, tuningFilePath, cmdLinePath string) (bool, error) {
 	if cmdLinePath == "" {
 		cmdLinePath = CmdLineFile
 	}
@@ -158,7 +275,7 @@ func UpdateTuningArgs(tuningFilePath, cmdLinePath string) (bool, error) {
 	for _, toAdd := range additions {
 		if toAdd.Bare {
 			changed = true
-			err := exec.Command("rpm-ostree", "kargs", fmt.Sprintf("--append=%s", toAdd.Key)).Run()
+			_, err := runRpmOstree(ctx, "kargs", fmt.Sprintf("--append=%s", toAdd.Key))
 			if err != nil {
 				return false, errors.Wrapf(err, "adding karg")
 			}
@@ -170,7 +287,7 @@ func UpdateTuningArgs(tuningFilePath, cmdLinePath string) (bool, error) {
 	for _, toDelete := range deletions {
 		if toDelete.Bare {
 			changed = true
-			err := exec.Command("rpm-ostree", "kargs", fmt.Sprintf("--delete=%s", toDelete.Key)).Run()
+			_, err := runRpmOstree(ctx, "kargs", fmt.Sprintf("--delete=%s", toDelete.Key))
 			if err != nil {
 				return false, errors.Wrapf(err, "deleting karg")
 			}
@@ -0,0 +1,129 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+const kernelArgumentsDriftCheckInterval = 10 * time.Minute
+
+// kernelArgumentsDrift is the drift of the kernel arguments of a node from its current config,
+// recorded in its kernelArgumentsDrift annotation. Arguments the node has on top of the ones of
+// the config aren't drift, the OS sets its own, e.g. root and ostree.
+type kernelArgumentsDrift struct {
+	// MissingRunning are the arguments the running kernel wasn't booted with
+	MissingRunning []string `json:"missingRunning,omitempty"`
+	// MissingDeployment are the arguments the booted deployment doesn't boot with
+	MissingDeployment []string `json:"missingDeployment,omitempty"`
+	// Remediated is set once MissingDeployment were appended for the next reboot
+	Remediated bool `json:"remediated,omitempty"`
+}
+
+// getKernelArgumentsDrift returns the expected kernel arguments missing from the running ones,
+// and from the ones of the booted deployment, nil if none are
+func getKernelArgumentsDrift(expected, running, deployment KernelArguments) *kernelArgumentsDrift {
+	drift := &kernelArgumentsDrift{}
+	for _, arg := range running.Missing(expected) {
+		drift.MissingRunning = append(drift.MissingRunning, arg.String())
+	}
+	for _, arg := range deployment.Missing(expected) {
+		drift.MissingDeployment = append(drift.MissingDeployment, arg.String())
+	}
+	if len(drift.MissingRunning) == 0 && len(drift.MissingDeployment) == 0 {
+		return nil
+	}
+	return drift
+}
+
+// runKernelArgumentsDriftMonitor periodically checks the kernel arguments of the node didn't
+// drift from its current config, e.g. after `rpm-ostree kargs` was run by hand
+func (dn *Daemon) runKernelArgumentsDriftMonitor(ctx context.Context) {
+	for {
+		if err := dn.checkKernelArgumentsDrift(ctx); err != nil {
+			glog.Errorf("Failed to check the kernel arguments drift: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-hostClock.After(kernelArgumentsDriftCheckInterval):
+		}
+	}
+}
+
+// checkKernelArgumentsDrift compares the kernel arguments of the current config of the node with
+// /proc/cmdline and `rpm-ostree kargs`, and records the drift on the node. With the
+// remediateKernelArgumentsDrift annotation the arguments missing from the booted deployment are
+// appended, the node boots with them on its next reboot rather than being rebooted now.
+func (dn *Daemon) checkKernelArgumentsDrift(ctx context.Context) error {
+	if dn.node == nil || !dn.os.IsCoreOSVariant() {
+		return nil
+	}
+	dn.updateActiveLock.Lock()
+	defer dn.updateActiveLock.Unlock()
+
+	// the kernel arguments change along with updates
+	current := dn.node.Annotations[constants.CurrentMachineConfigAnnotationKey]
+	if current == "" || current != dn.node.Annotations[constants.DesiredMachineConfigAnnotationKey] ||
+		dn.node.Annotations[constants.MachineConfigDaemonStateAnnotationKey] != constants.MachineConfigDaemonStateDone {
+		return nil
+	}
+	// a deployment staged for the next boot has its own kernel arguments
+	state, err := dn.NodeUpdaterClient.GetState(ctx)
+	if err != nil {
+		return err
+	}
+	if len(state.Deployments) == 0 || !state.Deployments[0].Booted {
+		return nil
+	}
+	config, err := dn.mcLister.Get(current)
+	if err != nil {
+		return err
+	}
+	running, err := GetKernelArgs("")
+	if err != nil {
+		return err
+	}
+	out, err := runRpmOstree(ctx, "kargs")
+	if err != nil {
+		return errors.Wrap(err, "error reading the kernel arguments of the booted deployment")
+	}
+
+	drift := getKernelArgumentsDrift(parseKernelArguments(config.Spec.KernelArguments), running, ParseKernelArguments(string(out)))
+	annotation := ""
+	if drift != nil {
+		if len(drift.MissingDeployment) > 0 && dn.node.Annotations[constants.RemediateKernelArgumentsDriftAnnotationKey] == "true" {
+			args := []string{"kargs"}
+			for _, arg := range drift.MissingDeployment {
+				args = append(args, "--append="+arg)
+			}
+			dn.logSystem("Appending the kernel arguments missing from the booted deployment for the next reboot: %s", strings.Join(drift.MissingDeployment, " "))
+			if _, err := runRpmOstree(ctx, args...); err != nil {
+				return err
+			}
+			drift.Remediated = true
+		}
+		data, err := json.Marshal(drift)
+		if err != nil {
+			return err
+		}
+		annotation = string(data)
+	}
+	if annotation == dn.node.Annotations[constants.KernelArgumentsDriftAnnotationKey] {
+		return nil
+	}
+	if drift != nil {
+		glog.Warningf("Kernel arguments drifted from config %s: %s", current, annotation)
+		if dn.recorder != nil {
+			dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeWarning, "KernelArgumentsDrift", "Kernel arguments drifted from config %s: %s", current, annotation)
+		}
+	}
+	return dn.nodeWriter.SetKernelArgumentsDrift(annotation, dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name)
+}
@@ -0,0 +1,23 @@
+package daemon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetKernelArgumentsDrift(t *testing.T) {
+	expected := ParseKernelArguments("nosmt hugepages=4")
+	running := ParseKernelArguments("BOOT_IMAGE=/vmlinuz root=UUID=abc nosmt hugepages=4")
+
+	assert.Nil(t, getKernelArgumentsDrift(expected, running, running))
+
+	// kargs removed from the deployment by hand, the node didn't reboot yet
+	deployment := ParseKernelArguments("root=UUID=abc hugepages=2")
+	assert.Equal(t, &kernelArgumentsDrift{MissingDeployment: []string{"nosmt", "hugepages=4"}},
+		getKernelArgumentsDrift(expected, running, deployment))
+
+	// the node rebooted into them
+	assert.Equal(t, &kernelArgumentsDrift{MissingRunning: []string{"nosmt", "hugepages=4"}, MissingDeployment: []string{"nosmt", "hugepages=4"}},
+		getKernelArgumentsDrift(expected, deployment, deployment))
+}
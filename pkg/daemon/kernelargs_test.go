@@ -0,0 +1,74 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKernelArgument(t *testing.T) {
+	tests := []struct {
+		arg      string
+		expected KernelArgument
+	}{{
+		arg:      "nosmt",
+		expected: KernelArgument{Key: "nosmt"},
+	}, {
+		arg:      "hugepages=4",
+		expected: KernelArgument{Key: "hugepages", Value: "4", HasValue: true},
+	}, {
+		arg:      "console=",
+		expected: KernelArgument{Key: "console", HasValue: true},
+	}, {
+		arg:      `bar="hello world"`,
+		expected: KernelArgument{Key: "bar", Value: "hello world", HasValue: true, Quoted: true},
+	}, {
+		arg:      `"bar=hello world"`,
+		expected: KernelArgument{Key: "bar", Value: "hello world", HasValue: true, Quoted: true},
+	}, {
+		arg:      "mitigations=auto,nosmt",
+		expected: KernelArgument{Key: "mitigations", Value: "auto,nosmt", HasValue: true},
+	}}
+	for _, test := range tests {
+		t.Run(test.arg, func(t *testing.T) {
+			karg := ParseKernelArgument(test.arg)
+			assert.True(t, test.expected.Equal(karg))
+			assert.Equal(t, test.expected.Quoted, karg.Quoted)
+			assert.Equal(t, test.arg, karg.String())
+		})
+	}
+
+	assert.Equal(t, `bar="hello world"`, KernelArgument{Key: "bar", Value: "hello world", HasValue: true, Quoted: true}.String())
+	assert.Equal(t, "console=", KernelArgument{Key: "console", HasValue: true}.String())
+}
+
+func TestKernelArguments(t *testing.T) {
+	kargs := ParseKernelArguments(`BOOT_IMAGE=/vmlinuz hugepagesz=1G hugepages=4 hugepagesz=2M hugepages=4 bar="hello world" nosmt`)
+	require.Len(t, kargs, 7)
+	assert.Equal(t, []string{"1G", "2M"}, kargs.Values("hugepagesz"))
+	assert.True(t, kargs.Has(ParseKernelArgument(`"bar=hello world"`)))
+	assert.False(t, kargs.Has(ParseKernelArgument("bar")))
+
+	assert.Empty(t, kargs.Missing(ParseKernelArguments("nosmt hugepages=4 hugepages=4")))
+	assert.Equal(t, ParseKernelArguments("hugepages=4 hugepages=6"), kargs.Missing(ParseKernelArguments("hugepages=4 hugepages=4 hugepages=4 hugepages=6")))
+}
+
+func TestIsArgInUse(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cmdline")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	cmdLine := filepath.Join(dir, "cmdline")
+	require.Nil(t, ioutil.WriteFile(cmdLine, []byte("BOOT_IMAGE=/vmlinuz mitigations=auto,nosmt\n"), 0644))
+
+	inUse, err := isArgInUse("mitigations=auto,nosmt", cmdLine)
+	require.Nil(t, err)
+	assert.True(t, inUse)
+	// only whole arguments are in use
+	inUse, err = isArgInUse("nosmt", cmdLine)
+	require.Nil(t, err)
+	assert.False(t, inUse)
+}
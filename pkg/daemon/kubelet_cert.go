@@ -0,0 +1,207 @@
+package daemon
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"github.com/vincent-petithory/dataurl"
+	"k8s.io/client-go/tools/clientcmd"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+const (
+	// kubeletClientCertPath is the client certificate the kubelet talks to the API server with
+	kubeletClientCertPath = "/var/lib/kubelet/pki/kubelet-client-current.pem"
+	// kubeletBootstrapKubeconfigPath is the kubeconfig the kubelet requests a new client
+	// certificate with, it is served by the MCS along with the Ignition config
+	kubeletBootstrapKubeconfigPath = "/etc/kubernetes/kubeconfig"
+	// rootCAPath is the CA the MCS certificate is signed with
+	rootCAPath = "/etc/kubernetes/ca.crt"
+	// machineConfigServerPort is the port the MCS listens on, on the API server hosts
+	machineConfigServerPort = "22623"
+
+	kubeletClientCertCheckInterval = 10 * time.Minute
+	mcsRequestTimeout              = 30 * time.Second
+)
+
+// kubeletClientCertExpired returns whether the certificate in the PEM file at path, holding the
+// certificate followed by its key, has expired at now
+func kubeletClientCertExpired(path string, now time.Time) (bool, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return false, fmt.Errorf("no certificate found in %s", path)
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return false, errors.Wrapf(err, "parsing certificate in %s", path)
+		}
+		return now.After(cert.NotAfter), nil
+	}
+}
+
+// machineConfigServerURL returns the URL the MCS serves the Ignition config of pool from,
+// on the host of the API server of the kubeconfig
+func machineConfigServerURL(kubeconfigPath, pool string) (string, error) {
+	cfg, err := clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+	if err != nil {
+		return "", errors.Wrapf(err, "loading %s", kubeconfigPath)
+	}
+	server, err := url.Parse(cfg.Host)
+	if err != nil {
+		return "", errors.Wrapf(err, "parsing API server URL %s", cfg.Host)
+	}
+	return fmt.Sprintf("https://%s/config/%s", net.JoinHostPort(server.Hostname(), machineConfigServerPort), pool), nil
+}
+
+// fetchBootstrapKubeconfig fetches the Ignition config of pool from the MCS at mcsURL and
+// returns the bootstrap kubeconfig in it
+func fetchBootstrapKubeconfig(mcsURL string, rootCA []byte) ([]byte, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(rootCA) {
+		return nil, fmt.Errorf("no certificate found in the root CA")
+	}
+	client := http.Client{
+		Timeout:   mcsRequestTimeout,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}},
+	}
+	req, err := http.NewRequest("GET", mcsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.coreos.ignition+json;version=3.2.0")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "requesting %s", mcsURL)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("requesting %s: %s", mcsURL, resp.Status)
+	}
+
+	ignCfg, err := ctrlcommon.ParseAndConvertConfig(body)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range ignCfg.Storage.Files {
+		if f.Path != kubeletBootstrapKubeconfigPath || f.Contents.Source == nil {
+			continue
+		}
+		contents, err := dataurl.DecodeString(*f.Contents.Source)
+		if err != nil {
+			return nil, errors.Wrapf(err, "decoding %s", f.Path)
+		}
+		return contents.Data, nil
+	}
+	return nil, fmt.Errorf("no %s served by the MCS", kubeletBootstrapKubeconfigPath)
+}
+
+// RecoverKubeletClientCert re-bootstraps the kubelet if its client certificate expired, e.g.
+// while the node was powered off: the bootstrap kubeconfig is refreshed from the MCS, the expired
+// certificate moved out of the way and the kubelet restarted so it requests a new certificate.
+// It returns whether a recovery happened. It doesn't need the API server, the kubelet's
+// certificate signing request still has to be approved for the node to rejoin the cluster.
+func RecoverKubeletClientCert(pool string) (bool, error) {
+	expired, err := kubeletClientCertExpired(kubeletClientCertPath, hostClock.Now())
+	if os.IsNotExist(err) {
+		// the kubelet hasn't bootstrapped yet or is already re-bootstrapping
+		return false, nil
+	}
+	if err != nil || !expired {
+		return false, err
+	}
+	glog.Warningf("Kubelet client certificate %s has expired, recovering it", kubeletClientCertPath)
+
+	mcsURL, err := machineConfigServerURL(kubeletBootstrapKubeconfigPath, pool)
+	if err != nil {
+		return false, err
+	}
+	rootCA, err := ioutil.ReadFile(rootCAPath)
+	if err != nil {
+		return false, err
+	}
+	kubeconfig, err := fetchBootstrapKubeconfig(mcsURL, rootCA)
+	if err != nil {
+		return false, errors.Wrap(err, "fetching the bootstrap kubeconfig from the MCS")
+	}
+	if err := writeFileAtomicallyWithDefaults(kubeletBootstrapKubeconfigPath, kubeconfig); err != nil {
+		return false, err
+	}
+	if err := os.Rename(kubeletClientCertPath, kubeletClientCertPath+".expired"); err != nil {
+		return false, errors.Wrap(err, "moving the expired kubelet client certificate")
+	}
+	if out, err := hostCommander.Command("systemctl", "restart", "kubelet.service").CombinedOutput(); err != nil {
+		return false, errors.Wrapf(err, "restarting the kubelet: %s", out)
+	}
+	glog.Info("Kubelet restarted with the refreshed bootstrap kubeconfig, its certificate signing request needs to be approved")
+	return true, nil
+}
+
+// runKubeletClientCertMonitor recovers the kubelet client certificate when it expires, flagging
+// the node so the node controller doesn't update it meanwhile
+func (dn *Daemon) runKubeletClientCertMonitor(stopCh <-chan struct{}) {
+	for {
+		if err := dn.checkKubeletClientCert(); err != nil {
+			glog.Errorf("Failed to recover the kubelet client certificate: %v", err)
+		}
+		select {
+		case <-stopCh:
+			return
+		case <-hostClock.After(kubeletClientCertCheckInterval):
+		}
+	}
+}
+
+func (dn *Daemon) checkKubeletClientCert() error {
+	expired, err := kubeletClientCertExpired(kubeletClientCertPath, hostClock.Now())
+	if os.IsNotExist(err) {
+		// the kubelet hasn't bootstrapped yet or waits for its new certificate to be approved
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	recovering := dn.node != nil && dn.node.Annotations[constants.KubeletCertRecoveryAnnotationKey] == constants.KubeletCertRecoveryInProgress
+	if !expired {
+		if recovering {
+			glog.Info("Kubelet client certificate recovered")
+			return dn.nodeWriter.SetKubeletCertRecovery("", dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name)
+		}
+		return nil
+	}
+
+	// the annotation only reports the recovery, which mustn't depend on the API server
+	if err := dn.nodeWriter.SetKubeletCertRecovery(constants.KubeletCertRecoveryInProgress, dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name); err != nil {
+		glog.Warningf("Failed to annotate the node with the kubelet client certificate recovery: %v", err)
+	}
+	pool := "worker"
+	if dn.isControlPlane {
+		pool = "master"
+	}
+	_, err = RecoverKubeletClientCert(pool)
+	return err
+}
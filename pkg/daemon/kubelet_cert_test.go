@@ -0,0 +1,132 @@
+package daemon
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vincent-petithory/dataurl"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+// writeKubeletClientCert writes a kubelet-client-current.pem like file, with the key first
+func writeKubeletClientCert(t *testing.T, path string, notAfter time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "system:node:node-0"},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.Nil(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.Nil(t, err)
+	data := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	require.Nil(t, ioutil.WriteFile(path, data, 0600))
+}
+
+func TestKubeletClientCertExpired(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubelet-cert")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "kubelet-client-current.pem")
+	now := time.Now()
+
+	writeKubeletClientCert(t, path, now.Add(time.Hour))
+	expired, err := kubeletClientCertExpired(path, now)
+	require.Nil(t, err)
+	assert.False(t, expired)
+
+	writeKubeletClientCert(t, path, now.Add(-time.Hour))
+	expired, err = kubeletClientCertExpired(path, now)
+	require.Nil(t, err)
+	assert.True(t, expired)
+
+	require.Nil(t, ioutil.WriteFile(path, []byte("garbage"), 0600))
+	_, err = kubeletClientCertExpired(path, now)
+	assert.NotNil(t, err)
+
+	_, err = kubeletClientCertExpired(filepath.Join(dir, "missing"), now)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestMachineConfigServerURL(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubelet-cert")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	kubeconfig := filepath.Join(dir, "kubeconfig")
+	require.Nil(t, ioutil.WriteFile(kubeconfig, []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: local
+  cluster:
+    server: https://api-int.cluster.example.com:6443
+users:
+- name: kubelet
+  user:
+    token: secret
+contexts:
+- name: kubelet
+  context:
+    cluster: local
+    user: kubelet
+current-context: kubelet
+`), 0600))
+
+	url, err := machineConfigServerURL(kubeconfig, "worker")
+	require.Nil(t, err)
+	assert.Equal(t, "https://api-int.cluster.example.com:22623/config/worker", url)
+}
+
+func TestFetchBootstrapKubeconfig(t *testing.T) {
+	ignCfg := ctrlcommon.NewIgnConfig()
+	ignCfg.Storage.Files = append(ignCfg.Storage.Files, ign3types.File{
+		Node: ign3types.Node{Path: kubeletBootstrapKubeconfigPath},
+		FileEmbedded1: ign3types.FileEmbedded1{
+			Contents: ign3types.Resource{Source: helpers.StrToPtr(dataurl.EncodeBytes([]byte("refreshed kubeconfig")))},
+		},
+	})
+	served := helpers.MarshalOrDie(ignCfg)
+
+	var accept string
+	mcs := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/config/worker" {
+			http.NotFound(w, r)
+			return
+		}
+		accept = r.Header.Get("Accept")
+		w.Write(served)
+	}))
+	defer mcs.Close()
+	rootCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: mcs.Certificate().Raw})
+
+	kubeconfig, err := fetchBootstrapKubeconfig(mcs.URL+"/config/worker", rootCA)
+	require.Nil(t, err)
+	assert.Equal(t, "refreshed kubeconfig", string(kubeconfig))
+	assert.Contains(t, accept, "application/vnd.coreos.ignition+json")
+
+	_, err = fetchBootstrapKubeconfig(mcs.URL+"/config/infra", rootCA)
+	assert.NotNil(t, err)
+
+	_, err = fetchBootstrapKubeconfig(mcs.URL+"/config/worker", []byte("garbage"))
+	assert.NotNil(t, err)
+}
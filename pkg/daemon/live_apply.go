@@ -0,0 +1,44 @@
+package daemon
+
+import (
+	"context"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+// applyLive applies the OS changes staged for the config to the booted deployment, and records
+// that the node still has to reboot into them
+func (dn *Daemon) applyLive(ctx context.Context, configName string) error {
+	dn.logSystem("Applying the OS changes of %s live", configName)
+	if err := dn.NodeUpdaterClient.ApplyLive(ctx); err != nil {
+		return errors.Wrap(err, "error applying the OS changes live")
+	}
+	if err := dn.nodeWriter.SetLiveApplied(configName, dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name); err != nil {
+		return errors.Wrap(err, "error recording the live applied OS changes")
+	}
+	if dn.recorder != nil {
+		dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeNormal, "LiveApplied", "Applied the OS changes of %s without a reboot", configName)
+	}
+	return nil
+}
+
+// syncLiveApplied clears the config whose OS changes were applied live once the node rebooted into them
+func (dn *Daemon) syncLiveApplied(ctx context.Context) error {
+	config := dn.node.Annotations[constants.LiveAppliedConfigAnnotationKey]
+	if config == "" {
+		return nil
+	}
+	booted, err := dn.NodeUpdaterClient.GetBootedDeployment(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error getting the booted deployment")
+	}
+	if booted.LiveReplaced != "" {
+		glog.Infof("OS changes of %s applied live, pending a reboot", config)
+		return nil
+	}
+	glog.Infof("Rebooted into the OS changes of %s applied live", config)
+	return dn.nodeWriter.SetLiveApplied("", dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name)
+}
@@ -0,0 +1,129 @@
+package daemon
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+const (
+	// pendingRebootScheduledUpdate is the reason of the graceful reboot completing the update of the node
+	pendingRebootScheduledUpdate = "ScheduledUpdate"
+	// pendingRebootRequested is the reason of the reboot of the node for the reboot request of its pool
+	pendingRebootRequested = "RebootRequested"
+)
+
+// machineConfigNodeStatus returns the status of the MachineConfigNode of the node, from the
+// annotations the daemon and the node controller set on it. lastTransitionTime is left unset.
+func machineConfigNodeStatus(node *corev1.Node, stagedOSImage string) mcfgv1.MachineConfigNodeStatus {
+	status := mcfgv1.MachineConfigNodeStatus{
+		CurrentConfig: node.Annotations[constants.CurrentMachineConfigAnnotationKey],
+		DesiredConfig: node.Annotations[constants.DesiredMachineConfigAnnotationKey],
+		Phase:         mcfgv1.MachineConfigNodePhase(node.Annotations[constants.MachineConfigDaemonStateAnnotationKey]),
+		StagedOSImage: stagedOSImage,
+	}
+	if status.Phase == mcfgv1.MachineConfigNodeDegraded || status.Phase == mcfgv1.MachineConfigNodeUnreconcilable {
+		status.LastError = node.Annotations[constants.MachineConfigDaemonReasonAnnotationKey]
+	}
+	if scheduled := node.Annotations[constants.ScheduledRebootAnnotationKey]; scheduled != "" {
+		status.PendingReboot = &mcfgv1.MachineConfigNodeReboot{Reason: pendingRebootScheduledUpdate}
+		if t, err := time.Parse(time.RFC3339, scheduled); err == nil {
+			status.PendingReboot.Time = &metav1.Time{Time: t}
+		}
+	} else if desired := node.Annotations[constants.DesiredRebootAnnotationKey]; desired != "" && desired != node.Annotations[constants.CurrentRebootAnnotationKey] {
+		status.PendingReboot = &mcfgv1.MachineConfigNodeReboot{Reason: pendingRebootRequested}
+	}
+	return status
+}
+
+// syncMachineConfigNode creates or updates the MachineConfigNode of the node to report on its
+// current state. Failures are only logged, the update of the node doesn't depend on it.
+func (dn *Daemon) syncMachineConfigNode(node *corev1.Node) {
+	if dn.mcfgClient == nil {
+		return
+	}
+	dn.machineConfigNodeLock.Lock()
+	defer dn.machineConfigNodeLock.Unlock()
+
+	// the OS image is only staged ahead along with changes of the state of the node
+	status := machineConfigNodeStatus(node, "")
+	if dn.machineConfigNode != nil && machineConfigNodeStateEqual(status, dn.machineConfigNode.Status) {
+		return
+	}
+	if dn.NodeUpdaterClient != nil {
+		var err error
+		if status.StagedOSImage, err = dn.NodeUpdaterClient.GetPrestagedOSImageURL(context.Background()); err != nil {
+			glog.V(2).Infof("Failed to get the OS image staged ahead: %v", err)
+		}
+	}
+	mcn, err := dn.updateMachineConfigNode(node, status)
+	if err != nil {
+		glog.Warningf("Failed to update the MachineConfigNode of node %s: %v", node.Name, err)
+		return
+	}
+	dn.machineConfigNode = mcn
+}
+
+// machineConfigNodeStateEqual returns whether the statuses only differ in the OS image staged
+// ahead and their lastTransitionTime
+func machineConfigNodeStateEqual(a, b mcfgv1.MachineConfigNodeStatus) bool {
+	a.StagedOSImage, a.LastTransitionTime = b.StagedOSImage, b.LastTransitionTime
+	return equality.Semantic.DeepEqual(a, b)
+}
+
+// updateMachineConfigNode writes the status to the MachineConfigNode of the node, creating it if
+// needed, and returns it. Nothing is written if the status didn't change since the last write.
+func (dn *Daemon) updateMachineConfigNode(node *corev1.Node, status mcfgv1.MachineConfigNodeStatus) (*mcfgv1.MachineConfigNode, error) {
+	client := dn.mcfgClient.MachineconfigurationV1().MachineConfigNodes()
+	mcn := dn.machineConfigNode
+	if mcn == nil {
+		var err error
+		mcn, err = client.Get(context.TODO(), node.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			mcn, err = client.Create(context.TODO(), &mcfgv1.MachineConfigNode{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: node.Name,
+					// garbage collected along with the node. Blocking the deletion of the node
+					// would require updating nodes/finalizers.
+					OwnerReferences: []metav1.OwnerReference{{
+						APIVersion:         corev1.SchemeGroupVersion.String(),
+						Kind:               "Node",
+						Name:               node.Name,
+						UID:                node.UID,
+						Controller:         pointer.BoolPtr(true),
+						BlockOwnerDeletion: pointer.BoolPtr(false),
+					}},
+				},
+				Spec: mcfgv1.MachineConfigNodeSpec{NodeName: node.Name},
+			}, metav1.CreateOptions{})
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	status.LastTransitionTime = mcn.Status.LastTransitionTime
+	if status.Phase != mcn.Status.Phase || status.LastTransitionTime.IsZero() {
+		status.LastTransitionTime = metav1.Now()
+	}
+	if equality.Semantic.DeepEqual(status, mcn.Status) {
+		return mcn, nil
+	}
+	newMCN := mcn.DeepCopy()
+	newMCN.Status = status
+	updated, err := client.UpdateStatus(context.TODO(), newMCN, metav1.UpdateOptions{})
+	if apierrors.IsConflict(err) || apierrors.IsNotFound(err) {
+		// written by a previous daemon, or deleted meanwhile, read it again on the next sync
+		dn.machineConfigNode = nil
+	}
+	return updated, err
+}
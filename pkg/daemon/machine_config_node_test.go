@@ -0,0 +1,92 @@
+package daemon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/fake"
+)
+
+// prestagedOSImageClient counts the calls of GetPrestagedOSImageURL
+type prestagedOSImageClient struct {
+	RpmOstreeClientMock
+	calls int
+}
+
+func (c *prestagedOSImageClient) GetPrestagedOSImageURL(context.Context) (string, error) {
+	c.calls++
+	return "quay.io/openshift/os@sha256:abc", nil
+}
+
+func TestSyncMachineConfigNode(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0", UID: types.UID("node-0-uid"), Annotations: map[string]string{
+		constants.CurrentMachineConfigAnnotationKey:     "rendered-worker-old",
+		constants.DesiredMachineConfigAnnotationKey:     "rendered-worker-new",
+		constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateWorking,
+		constants.ScheduledRebootAnnotationKey:          "2021-03-01T12:00:00Z",
+	}}}
+	client := fake.NewSimpleClientset()
+	nodeUpdater := &prestagedOSImageClient{}
+	dn := &Daemon{mcfgClient: client, NodeUpdaterClient: nodeUpdater}
+
+	dn.syncMachineConfigNode(node)
+	mcn, err := client.MachineconfigurationV1().MachineConfigNodes().Get(context.TODO(), node.Name, metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Equal(t, node.Name, mcn.Spec.NodeName)
+	require.Len(t, mcn.OwnerReferences, 1)
+	assert.Equal(t, node.UID, mcn.OwnerReferences[0].UID)
+	assert.False(t, *mcn.OwnerReferences[0].BlockOwnerDeletion)
+	assert.Equal(t, "quay.io/openshift/os@sha256:abc", mcn.Status.StagedOSImage)
+	assert.Equal(t, "rendered-worker-old", mcn.Status.CurrentConfig)
+	assert.Equal(t, "rendered-worker-new", mcn.Status.DesiredConfig)
+	assert.Equal(t, mcfgv1.MachineConfigNodeWorking, mcn.Status.Phase)
+	require.NotNil(t, mcn.Status.PendingReboot)
+	assert.Equal(t, pendingRebootScheduledUpdate, mcn.Status.PendingReboot.Reason)
+	assert.True(t, mcn.Status.PendingReboot.Time.Time.Equal(time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)))
+	assert.False(t, mcn.Status.LastTransitionTime.IsZero())
+
+	// nothing is written, nor the staged OS image read, while the status doesn't change
+	actions := len(client.Actions())
+	dn.syncMachineConfigNode(node)
+	assert.Len(t, client.Actions(), actions)
+	assert.Equal(t, 1, nodeUpdater.calls)
+
+	node.Annotations[constants.MachineConfigDaemonStateAnnotationKey] = constants.MachineConfigDaemonStateDegraded
+	node.Annotations[constants.MachineConfigDaemonReasonAnnotationKey] = "failed to drain"
+	node.Annotations[constants.ScheduledRebootAnnotationKey] = ""
+	dn.syncMachineConfigNode(node)
+	mcn, err = client.MachineconfigurationV1().MachineConfigNodes().Get(context.TODO(), node.Name, metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Equal(t, mcfgv1.MachineConfigNodeDegraded, mcn.Status.Phase)
+	assert.Equal(t, "failed to drain", mcn.Status.LastError)
+	assert.Nil(t, mcn.Status.PendingReboot)
+	assert.Equal(t, 2, nodeUpdater.calls)
+}
+
+func TestMachineConfigNodeStatusRebootRequested(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+		constants.CurrentMachineConfigAnnotationKey:      "rendered-worker",
+		constants.DesiredMachineConfigAnnotationKey:      "rendered-worker",
+		constants.MachineConfigDaemonStateAnnotationKey:  constants.MachineConfigDaemonStateDone,
+		constants.MachineConfigDaemonReasonAnnotationKey: "stale",
+		constants.DesiredRebootAnnotationKey:             "2021-03-01T12:00:00Z",
+		constants.CurrentRebootAnnotationKey:             "2021-02-01T12:00:00Z",
+	}}}
+	status := machineConfigNodeStatus(node, "quay.io/openshift/os@sha256:abc")
+	assert.Equal(t, mcfgv1.MachineConfigNodeDone, status.Phase)
+	assert.Empty(t, status.LastError)
+	assert.Equal(t, "quay.io/openshift/os@sha256:abc", status.StagedOSImage)
+	assert.Equal(t, &mcfgv1.MachineConfigNodeReboot{Reason: pendingRebootRequested}, status.PendingReboot)
+
+	node.Annotations[constants.CurrentRebootAnnotationKey] = "2021-03-01T12:00:00Z"
+	assert.Nil(t, machineConfigNodeStatus(node, "").PendingReboot)
+}
@@ -110,6 +110,7 @@ func StartMetricsListener(addr string, stopCh chan struct{}) {
 	glog.Infof("Starting metrics listener on %s", addr)
 	mux := http.NewServeMux()
 	mux.Handle("/metrics", promhttp.Handler())
+	mux.Handle(UpdateLogPath, updateLogs)
 	s := http.Server{Addr: addr, Handler: mux}
 
 	go func() {
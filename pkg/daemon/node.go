@@ -7,8 +7,10 @@ import (
 	"os"
 
 	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/internal"
 	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 func (dn *Daemon) loadNodeAnnotations(node *corev1.Node) (*corev1.Node, error) {
@@ -67,3 +69,77 @@ func getNodeAnnotationExt(node *corev1.Node, k string, allowNoent bool) (string,
 
 	return v, nil
 }
+
+// hostOS is the operating system of the host as published in the HostOSAnnotationKey annotation
+type hostOS struct {
+	ID        string `json:"id"`
+	VariantID string `json:"variantID,omitempty"`
+	VersionID string `json:"versionID,omitempty"`
+	Variant   string `json:"variant,omitempty"`
+	CoreOS    bool   `json:"coreOS"`
+}
+
+// setHostOS publishes the operating system of the host on the node: the HostOSAnnotationKey
+// annotation and, when they're known, the OSVariantLabelKey and OSVersionLabelKey labels the
+// node controller rolls up in the status of the pool.
+func (dn *Daemon) setHostOS() error {
+	value, err := json.Marshal(hostOS{
+		ID:        dn.os.ID,
+		VariantID: dn.os.VariantID,
+		VersionID: dn.os.VersionID,
+		Variant:   dn.os.Variant(),
+		CoreOS:    dn.os.IsCoreOSVariant(),
+	})
+	if err != nil {
+		return err
+	}
+	labels := map[string]string{
+		constants.OSVariantLabelKey: dn.os.Variant(),
+		constants.OSVersionLabelKey: dn.os.VersionID,
+	}
+	for k, v := range labels {
+		if v == "" || len(validation.IsValidLabelValue(v)) > 0 {
+			delete(labels, k)
+		}
+	}
+	if !hostOSChanged(dn.node, string(value), labels) {
+		return nil
+	}
+
+	glog.Infof("Setting host OS of node %s: %s", dn.node.Name, value)
+	node, err := internal.UpdateNodeRetry(dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.node.Name, func(node *corev1.Node) {
+		if node.Annotations == nil {
+			node.Annotations = map[string]string{}
+		}
+		node.Annotations[constants.HostOSAnnotationKey] = string(value)
+		if node.Labels == nil {
+			node.Labels = map[string]string{}
+		}
+		for _, k := range []string{constants.OSVariantLabelKey, constants.OSVersionLabelKey} {
+			if v, ok := labels[k]; ok {
+				node.Labels[k] = v
+			} else {
+				delete(node.Labels, k)
+			}
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set host OS of node %s: %v", dn.node.Name, err)
+	}
+	dn.node = node
+	return nil
+}
+
+// hostOSChanged returns whether the node doesn't have the host OS annotation and labels yet
+func hostOSChanged(node *corev1.Node, annotation string, labels map[string]string) bool {
+	if node.Annotations[constants.HostOSAnnotationKey] != annotation {
+		return true
+	}
+	for _, k := range []string{constants.OSVariantLabelKey, constants.OSVersionLabelKey} {
+		v, ok := node.Labels[k]
+		if want, wanted := labels[k]; ok != wanted || v != want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package daemon
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+// pinnedOSImage returns the OS image, pinned by digest, the node rebased to for the tag imgURL,
+// empty if it didn't
+func pinnedOSImage(node *corev1.Node, imgURL string) string {
+	if node == nil || imgURL == "" {
+		return ""
+	}
+	pinned := node.Annotations[constants.PinnedOSImageAnnotationKey]
+	i := strings.LastIndex(pinned, "@")
+	if i < 0 || pinned[:i] != imgURL {
+		return ""
+	}
+	return pinned
+}
+
+// osImageMatches returns whether the node is booted into imgURL, or into the digest it pinned
+// the tag imgURL to
+func (dn *Daemon) osImageMatches(imgURL string) bool {
+	if compareOSImageURL(dn.bootedOSImageURL, imgURL) {
+		return true
+	}
+	pinned := pinnedOSImage(dn.node, imgURL)
+	return pinned != "" && pinned == dn.bootedOSImageURL
+}
+
+// pinOSImage returns the OS image to rebase to for imgURL: when it is a tag, the digest the node
+// pinned it to, or the digest the tag references now, which is recorded before rebasing. The
+// pinned digest is kept when the tag is pushed again, e.g. when the update is retried, so the node
+// doesn't drift from the OS it was updated to.
+func (dn *Daemon) pinOSImage(imgURL string) (string, error) {
+	// the firstboot has no node to record the pin in
+	if dn.node == nil || !isImageTag(osImagePullSpec(imgURL)) {
+		return imgURL, nil
+	}
+
+	dgst, err := resolveImageDigest(osImagePullSpec(imgURL))
+	if pinned := pinnedOSImage(dn.node, imgURL); pinned != "" {
+		if err != nil {
+			glog.Warningf("Failed to resolve the digest of %s, keeping %s: %v", imgURL, pinned, err)
+		} else if current := fmt.Sprintf("%s@%s", imgURL, dgst); current != pinned {
+			glog.Warningf("OS image tag %s moved to %s, keeping %s", imgURL, dgst, pinned)
+			if dn.recorder != nil {
+				dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeWarning, "OSImageTagMoved", "OS image tag %s now references %s, keeping %s", imgURL, dgst, pinned)
+			}
+		}
+		return pinned, nil
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "error resolving the digest of %s", imgURL)
+	}
+
+	pinned := fmt.Sprintf("%s@%s", imgURL, dgst)
+	glog.Infof("Pinning OS image %s to %s", imgURL, pinned)
+	if err := dn.nodeWriter.SetPinnedOSImage(pinned, dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name); err != nil {
+		return "", errors.Wrap(err, "error recording the pinned OS image")
+	}
+	return pinned, nil
+}
@@ -0,0 +1,54 @@
+package daemon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+func TestIsImageTag(t *testing.T) {
+	assert.True(t, isImageTag("quay.io/openshift/os:4.8"))
+	assert.True(t, isImageTag("quay.io/openshift/os"))
+	assert.False(t, isImageTag("quay.io/openshift/os@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"))
+	assert.False(t, isImageTag("quay.io/openshift/os:4.8@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"))
+	assert.False(t, isImageTag("not a reference"))
+}
+
+func TestPinnedOSImage(t *testing.T) {
+	pinned := "quay.io/openshift/os:4.8@sha256:aaa"
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0", Annotations: map[string]string{
+		constants.PinnedOSImageAnnotationKey: pinned,
+	}}}
+	assert.Equal(t, pinned, pinnedOSImage(node, "quay.io/openshift/os:4.8"))
+	assert.Equal(t, "", pinnedOSImage(node, "quay.io/openshift/os:4.9"))
+	assert.Equal(t, "", pinnedOSImage(node, ""))
+	assert.Equal(t, "", pinnedOSImage(nil, "quay.io/openshift/os:4.8"))
+
+	node.Annotations[constants.PinnedOSImageAnnotationKey] = "ostree-unverified-registry:quay.io/openshift/os:4.8@sha256:aaa"
+	assert.Equal(t, "ostree-unverified-registry:quay.io/openshift/os:4.8@sha256:aaa", pinnedOSImage(node, "ostree-unverified-registry:quay.io/openshift/os:4.8"))
+	assert.Equal(t, "", pinnedOSImage(node, "quay.io/openshift/os:4.8"))
+}
+
+func TestOSImageMatches(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0", Annotations: map[string]string{
+		constants.PinnedOSImageAnnotationKey: "quay.io/openshift/os:4.8@sha256:aaa",
+	}}}
+	dn := &Daemon{node: node, bootedOSImageURL: "quay.io/openshift/os:4.8@sha256:aaa"}
+	assert.True(t, dn.osImageMatches("quay.io/openshift/os:4.8"))
+	assert.True(t, dn.osImageMatches("quay.io/openshift/os:4.8@sha256:aaa"))
+	assert.True(t, dn.osImageMatches(""))
+	assert.False(t, dn.osImageMatches("quay.io/openshift/os:4.9"))
+
+	// booted into another digest than the one pinned
+	dn.bootedOSImageURL = "quay.io/openshift/os:4.8@sha256:bbb"
+	assert.False(t, dn.osImageMatches("quay.io/openshift/os:4.8"))
+
+	// not pinned
+	dn.node = nil
+	dn.bootedOSImageURL = "quay.io/openshift/os:4.8"
+	assert.True(t, dn.osImageMatches("quay.io/openshift/os:4.8"))
+}
@@ -0,0 +1,75 @@
+package daemon
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+)
+
+// signatureRejectedPrefix starts the errors of the containers/image signature policy checks
+const signatureRejectedPrefix = "Source image rejected: "
+
+// OSImageVerificationError is returned when the signature policy of the host, the
+// /etc/containers/policy.json written from the image policy of the cluster, rejects an OS image
+type OSImageVerificationError struct {
+	// Image is the rejected OS image
+	Image string
+	// Reason is why the policy rejected it
+	Reason string
+}
+
+func (e *OSImageVerificationError) Error() string {
+	return fmt.Sprintf("OS image %s rejected by the signature policy: %s", e.Image, e.Reason)
+}
+
+// IsOSImageVerificationError returns whether err was caused by an OS image being rejected by
+// the signature policy of the host
+func IsOSImageVerificationError(err error) bool {
+	_, ok := errors.Cause(err).(*OSImageVerificationError)
+	return ok
+}
+
+// signatureRejection returns why the signature policy rejected an image in the output of podman,
+// empty if it didn't
+func signatureRejection(output string) string {
+	i := strings.Index(output, signatureRejectedPrefix)
+	if i < 0 {
+		return ""
+	}
+	reason := output[i+len(signatureRejectedPrefix):]
+	if j := strings.IndexByte(reason, '\n'); j >= 0 {
+		reason = reason[:j]
+	}
+	return strings.TrimSpace(reason)
+}
+
+// verifyOSImageSignature verifies imgURL against the signature policy of the host before the
+// node is rebased to it, by pulling it with podman which enforces the policy. The OSTree container
+// references verified by ostree itself are left to rpm-ostree.
+func verifyOSImageSignature(imgURL string) error {
+	if strings.HasPrefix(imgURL, "ostree-image-signed:") || strings.HasPrefix(imgURL, "ostree-remote-") {
+		return nil
+	}
+	pullSpec := osImagePullSpec(imgURL)
+	glog.Infof("Verifying OS image %s against the signature policy", pullSpec)
+	delay := 5 * time.Second
+	for attempt := 0; ; attempt++ {
+		// the pull secret is passed by hostCommander
+		output, err := hostCommander.Command("podman", "pull", "-q", pullSpec).CombinedOutput()
+		if err == nil {
+			return nil
+		}
+		if reason := signatureRejection(string(output)); reason != "" {
+			return &OSImageVerificationError{Image: imgURL, Reason: reason}
+		}
+		if attempt == numRetriesNetCommands {
+			return errors.Wrapf(err, "failed to verify OS image %s: %s", pullSpec, output)
+		}
+		glog.Warningf("Failed to pull OS image %s for verification, retrying in %v: %s", pullSpec, delay, output)
+		hostClock.Sleep(delay)
+		delay *= 2
+	}
+}
@@ -0,0 +1,210 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+// pendingOSRollback returns the OS rollback request set on the node by admins the daemon didn't
+// handle yet, empty if there is none. Config updates go first, the node has to be done updating
+// to its desired config.
+func pendingOSRollback(node *corev1.Node) string {
+	desired := node.Annotations[constants.RollbackOSAnnotationKey]
+	if desired == "" || desired == node.Annotations[constants.CurrentRollbackOSAnnotationKey] {
+		return ""
+	}
+	if node.Annotations[constants.CurrentMachineConfigAnnotationKey] != node.Annotations[constants.DesiredMachineConfigAnnotationKey] ||
+		node.Annotations[constants.MachineConfigDaemonStateAnnotationKey] != constants.MachineConfigDaemonStateDone {
+		return ""
+	}
+	return desired
+}
+
+// osRolledBack returns whether the node was rolled back on request to the OS image it is booted into
+func (dn *Daemon) osRolledBack() bool {
+	if dn.node == nil || dn.bootedOSImageURL == "" {
+		return false
+	}
+	return dn.node.Annotations[constants.RolledBackOSImageAnnotationKey] == dn.bootedOSImageURL
+}
+
+// performOSRollback drains the node and reboots it into the previous rpm-ostree deployment, in
+// its current config otherwise. The node keeps the OS image it was rolled back to until it is
+// updated to another one, e.g. once its pool targets a fixed OS image.
+func (dn *Daemon) performOSRollback(ctx context.Context, request string) error {
+	dn.updateActiveLock.Lock()
+	defer dn.updateActiveLock.Unlock()
+
+	// the request is handled, done or not, so it isn't retried forever
+	refuse := func(format string, args ...interface{}) error {
+		msg := fmt.Sprintf(format, args...)
+		glog.Warningf("Not rolling back the OS (%s): %s", request, msg)
+		if dn.recorder != nil {
+			dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeWarning, "OSRollbackRefused", msg)
+		}
+		return dn.nodeWriter.SetOSRollback(request, dn.node.Annotations[constants.RolledBackOSImageAnnotationKey], dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name)
+	}
+	if !dn.os.IsCoreOSVariant() {
+		return refuse("OS rollback is not supported on %s", dn.os.name())
+	}
+	// the previous deployment is only the rollback one once nothing is staged
+	if _, err := dn.discardPrestagedOSUpdate(ctx, ""); err != nil {
+		return err
+	}
+	rollback, err := dn.NodeUpdaterClient.GetRollbackDeployment(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error getting the rollback deployment")
+	}
+	if rollback == nil {
+		return refuse("there is no previous deployment to roll back to")
+	}
+	imgURL := deploymentOSImageURL(rollback)
+	if imgURL == "" {
+		return refuse("the previous deployment %s isn't of an OS image", rollback.ID)
+	}
+
+	currentConfigName, err := getNodeAnnotation(dn.node, constants.CurrentMachineConfigAnnotationKey)
+	if err != nil {
+		return err
+	}
+	currentConfig, err := dn.mcLister.Get(currentConfigName)
+	if err != nil {
+		return err
+	}
+
+	dn.logSystem("OS rollback requested on the node (%s), rolling back from %s to %s", request, dn.bootedOSImageURL, imgURL)
+	if err := dn.nodeWriter.SetWorking(dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name); err != nil {
+		return errors.Wrap(err, "error setting node's state to Working")
+	}
+	if err := dn.performDrain(); err != nil {
+		return err
+	}
+	rolledBack, err := dn.NodeUpdaterClient.Rollback(ctx, "")
+	if err != nil {
+		return errors.Wrap(err, "error rolling back the OS")
+	}
+	if !rolledBack {
+		return fmt.Errorf("previous deployment %s went away", rollback.ID)
+	}
+	if err := dn.finalizeBeforeReboot(currentConfig); err != nil {
+		return err
+	}
+	if err := dn.nodeWriter.SetOSRollback(request, imgURL, dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name); err != nil {
+		return errors.Wrap(err, "error recording the OS rollback")
+	}
+	if dn.recorder != nil {
+		dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeNormal, "OSRollback", "Rolled back the OS to %s", imgURL)
+	}
+	return dn.reboot(ctx, rebootReason{
+		Message:     fmt.Sprintf("Node will reboot into the previous OS %s for the OS rollback request %s", imgURL, request),
+		Config:      currentConfigName,
+		RequestedBy: rebootRequestedByOSRollback,
+	})
+}
+
+// clearOSRollback forgets the OS image the node was rolled back to once it runs another one, or
+// once its config is of that OS image too
+func (dn *Daemon) clearOSRollback(configName string) error {
+	if dn.nodeWriter == nil || dn.node == nil {
+		return nil
+	}
+	rolledBack := dn.node.Annotations[constants.RolledBackOSImageAnnotationKey]
+	if rolledBack == "" {
+		return nil
+	}
+	if rolledBack == dn.bootedOSImageURL {
+		config, err := dn.mcLister.Get(configName)
+		if err != nil {
+			return err
+		}
+		if config.Spec.OSImageURL != rolledBack {
+			return nil
+		}
+	}
+	glog.Infof("Node is done with the OS image %s it was rolled back to", rolledBack)
+	return dn.nodeWriter.SetOSRollback(dn.node.Annotations[constants.CurrentRollbackOSAnnotationKey], "", dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name)
+}
+
+// pinKnownGoodDeployment pins the deployment the node completed its update in, and unpins the
+// one it pinned before, so the last known-good OS can still be rolled back to after a failed
+// update, however many updates were staged since. The pinned deployment is recorded on the node
+// so that the deployments pinned by admins are never unpinned.
+func (dn *Daemon) pinKnownGoodDeployment(ctx context.Context) {
+	if !dn.os.IsCoreOSVariant() || dn.node == nil {
+		return
+	}
+	previous := dn.node.Annotations[constants.PinnedDeploymentAnnotationKey]
+	booted, pinned, err := dn.NodeUpdaterClient.PinCurrentDeployment(ctx)
+	if err != nil {
+		glog.Warningf("Failed to pin the booted deployment: %v", err)
+		return
+	}
+	// the booted deployment was pinned by admins if it was pinned already and not by the daemon,
+	// it is the known-good one anyway
+	current := ""
+	if pinned || booted == previous {
+		current = booted
+	}
+	if previous != "" && previous != booted {
+		if err := dn.NodeUpdaterClient.UnpinDeployment(ctx, previous); err != nil {
+			glog.Warningf("Failed to unpin the previous known-good deployment %s: %v", previous, err)
+		}
+	}
+	if current == previous {
+		return
+	}
+	if err := dn.nodeWriter.SetPinnedDeployment(current, dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name); err != nil {
+		glog.Warningf("Failed to record the pinned deployment %s: %v", current, err)
+	}
+}
+
+// cleanupRollbackDeployments removes the rollback deployment of the node once updated if its
+// pool keeps none, freeing space on /sysroot. rollbackDeployments is validated to 0 or 1:
+// rpm-ostree keeps at most one unpinned rollback deployment, and cleanup removes it while
+// keeping the pinned last known-good deployment.
+func (dn *Daemon) cleanupRollbackDeployments(ctx context.Context) {
+	if !dn.os.IsCoreOSVariant() || dn.node == nil {
+		return
+	}
+	value := dn.node.Annotations[constants.RollbackDeploymentsAnnotationKey]
+	if value == "" {
+		return
+	}
+	keep, err := strconv.Atoi(value)
+	if err != nil {
+		glog.Warningf("Ignoring invalid %s annotation %q: %v", constants.RollbackDeploymentsAnnotationKey, value, err)
+		return
+	}
+	// cleanup removes every unpinned rollback deployment, e.g. the previous known-good one too
+	// once unpinned, so it only runs when the pool keeps none
+	if keep != 0 {
+		return
+	}
+	state, err := dn.NodeUpdaterClient.GetState(ctx)
+	if err != nil {
+		glog.Warningf("Failed to get the deployments: %v", err)
+		return
+	}
+	var rollbacks []string
+	booted := false
+	for _, deployment := range state.Deployments {
+		if booted && !deployment.Pinned {
+			rollbacks = append(rollbacks, deployment.ID)
+		}
+		booted = booted || deployment.Booted
+	}
+	if len(rollbacks) == 0 {
+		return
+	}
+	glog.Infof("Removing the rollback deployments %v, the pool keeps none", rollbacks)
+	if err := removeRollbackDeployment(ctx); err != nil {
+		glog.Warningf("Failed to remove the rollback deployment: %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+package daemon
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	corev1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func TestPendingOSRollback(t *testing.T) {
+	request := "2021-03-01T12:00:00Z"
+	tests := []struct {
+		name     string
+		annos    map[string]string
+		expected string
+	}{{
+		name: "no request",
+		annos: map[string]string{
+			constants.CurrentMachineConfigAnnotationKey:     "v1",
+			constants.DesiredMachineConfigAnnotationKey:     "v1",
+			constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateDone,
+		},
+	}, {
+		name: "pending request",
+		annos: map[string]string{
+			constants.CurrentMachineConfigAnnotationKey:     "v1",
+			constants.DesiredMachineConfigAnnotationKey:     "v1",
+			constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateDone,
+			constants.RollbackOSAnnotationKey:               request,
+		},
+		expected: request,
+	}, {
+		name: "already rolled back",
+		annos: map[string]string{
+			constants.CurrentMachineConfigAnnotationKey:     "v1",
+			constants.DesiredMachineConfigAnnotationKey:     "v1",
+			constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateDone,
+			constants.RollbackOSAnnotationKey:               request,
+			constants.CurrentRollbackOSAnnotationKey:        request,
+		},
+	}, {
+		name: "updating",
+		annos: map[string]string{
+			constants.CurrentMachineConfigAnnotationKey:     "v0",
+			constants.DesiredMachineConfigAnnotationKey:     "v1",
+			constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateWorking,
+			constants.RollbackOSAnnotationKey:               request,
+		},
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0", Annotations: test.annos}}
+			assert.Equal(t, test.expected, pendingOSRollback(node))
+		})
+	}
+}
+
+func TestOSRolledBack(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0", Annotations: map[string]string{
+		constants.RolledBackOSImageAnnotationKey: "registry.example.com/os@sha256:aaa",
+	}}}
+	dn := &Daemon{node: node, bootedOSImageURL: "registry.example.com/os@sha256:aaa"}
+	assert.True(t, dn.osRolledBack())
+
+	dn.bootedOSImageURL = "registry.example.com/os@sha256:bbb"
+	assert.False(t, dn.osRolledBack())
+
+	node.Annotations[constants.RolledBackOSImageAnnotationKey] = ""
+	dn.bootedOSImageURL = ""
+	assert.False(t, dn.osRolledBack())
+}
+
+func TestCleanupRollbackDeployments(t *testing.T) {
+	recorder := helpers.NewCommandRecorder()
+	recorder.Respond(`{"deployments": [
+		{"id": "rhcos-2", "booted": true, "pinned": true},
+		{"id": "rhcos-1"},
+		{"id": "rhcos-0"}
+	]}`, 0, "rpm-ostree", "status", "--json")
+	defer setHost(recorder, nil, nil)()
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0", Annotations: map[string]string{}}}
+	dn := &Daemon{node: node, os: OperatingSystem{ID: "rhcos"}, NodeUpdaterClient: &RpmOstreeClient{}}
+
+	// the rpm-ostree default is kept unless the pool sets it
+	dn.cleanupRollbackDeployments(context.Background())
+	recorder.AssertNotCalled(t, "rpm-ostree", "cleanup", "-r")
+
+	// the unpinned previous known-good deployment is kept along with the rollback one
+	node.Annotations[constants.RollbackDeploymentsAnnotationKey] = "1"
+	dn.cleanupRollbackDeployments(context.Background())
+	recorder.AssertNotCalled(t, "rpm-ostree", "cleanup", "-r")
+
+	node.Annotations[constants.RollbackDeploymentsAnnotationKey] = "0"
+	dn.cleanupRollbackDeployments(context.Background())
+	recorder.AssertCalled(t, "rpm-ostree", "cleanup", "-r")
+}
+
+func TestPinKnownGoodDeployment(t *testing.T) {
+	recorder := helpers.NewCommandRecorder()
+	recorder.Respond(`{"deployments": [
+		{"id": "rhcos-2", "booted": true},
+		{"id": "rhcos-1", "pinned": true},
+		{"id": "rhcos-0", "pinned": true}
+	]}`, 0, "rpm-ostree", "status", "--json")
+	defer setHost(recorder, nil, nil)()
+
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0", Annotations: map[string]string{
+		constants.PinnedDeploymentAnnotationKey: "rhcos-1",
+	}}}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	require.Nil(t, indexer.Add(node))
+	nodeWriter := newNodeWriter(nil)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go nodeWriter.Run(stopCh)
+	kubeClient := k8sfake.NewSimpleClientset(node)
+	dn := &Daemon{
+		name:              node.Name,
+		node:              node,
+		kubeClient:        kubeClient,
+		nodeLister:        corev1lister.NewNodeLister(indexer),
+		nodeWriter:        nodeWriter,
+		os:                OperatingSystem{ID: "rhcos"},
+		NodeUpdaterClient: &RpmOstreeClient{},
+	}
+	pinnedDeployment := func() string {
+		node, err := kubeClient.CoreV1().Nodes().Get(context.TODO(), node.Name, metav1.GetOptions{})
+		require.Nil(t, err)
+		return node.Annotations[constants.PinnedDeploymentAnnotationKey]
+	}
+
+	// only the deployment the daemon pinned is unpinned, the one pinned by admins is kept
+	dn.pinKnownGoodDeployment(context.Background())
+	recorder.AssertCalled(t, "ostree", "admin", "pin", "0")
+	recorder.AssertCalled(t, "ostree", "admin", "pin", "--unpin", "1")
+	recorder.AssertNotCalled(t, "ostree", "admin", "pin", "--unpin", "2")
+	assert.Equal(t, "rhcos-2", pinnedDeployment())
+
+	// a booted deployment pinned by admins isn't recorded, so it isn't unpinned later on
+	recorder.Reset()
+	recorder.Respond(`{"deployments": [
+		{"id": "rhcos-3", "booted": true, "pinned": true},
+		{"id": "rhcos-2", "pinned": true}
+	]}`, 0, "rpm-ostree", "status", "--json")
+	node.Annotations[constants.PinnedDeploymentAnnotationKey] = "rhcos-2"
+	dn.pinKnownGoodDeployment(context.Background())
+	recorder.AssertNotCalled(t, "ostree", "admin", "pin", "0")
+	recorder.AssertCalled(t, "ostree", "admin", "pin", "--unpin", "1")
+	assert.Equal(t, "", pinnedDeployment())
+}
@@ -17,21 +17,71 @@ type OperatingSystem struct {
 	VersionID string
 }
 
+// The operating systems the MCO tells apart, as returned by OperatingSystem.Variant
+const (
+	OSVariantRHCOS = "rhcos"
+	OSVariantFCOS  = "fcos"
+	OSVariantSCOS  = "scos"
+	OSVariantRHEL  = "rhel"
+)
+
 // IsRHCOS is true if the OS is RHEL CoreOS
 func (os OperatingSystem) IsRHCOS() bool {
 	return os.ID == "rhcos"
 }
 
-// IsFCOS is true if the OS is RHEL CoreOS
+// IsFCOS is true if the OS is Fedora CoreOS
 func (os OperatingSystem) IsFCOS() bool {
 	return os.ID == "fedora" && os.VariantID == "coreos"
 }
 
+// IsSCOS is true if the OS is CentOS Stream CoreOS
+func (os OperatingSystem) IsSCOS() bool {
+	return os.ID == "scos"
+}
+
+// IsRHEL is true if the OS is traditional RHEL or CentOS, whose OS isn't managed by the MCO
+func (os OperatingSystem) IsRHEL() bool {
+	return (os.ID == "rhel" || os.ID == "centos") && os.VariantID != "coreos"
+}
+
 // IsCoreOSVariant is true if the OS is FCOS or a derivative (ostree+Ignition)
-// which includes RHCOS.
+// which includes RHCOS and SCOS.
 func (os OperatingSystem) IsCoreOSVariant() bool {
 	// We should probably add VARIANT_ID=coreos to RHCOS too and key off that
-	return os.IsFCOS() || os.IsRHCOS()
+	return os.IsFCOS() || os.IsRHCOS() || os.IsSCOS()
+}
+
+// IsRHCOSLike is true for the CoreOS variants built from RHEL content, RHCOS and SCOS,
+// which share the supported extensions and tunable kernel arguments
+func (os OperatingSystem) IsRHCOSLike() bool {
+	return os.IsRHCOS() || os.IsSCOS()
+}
+
+// Variant returns which of the OSVariant operating systems the OS is, empty if none
+func (os OperatingSystem) Variant() string {
+	switch {
+	case os.IsRHCOS():
+		return OSVariantRHCOS
+	case os.IsFCOS():
+		return OSVariantFCOS
+	case os.IsSCOS():
+		return OSVariantSCOS
+	case os.IsRHEL():
+		return OSVariantRHEL
+	}
+	return ""
+}
+
+// name returns the variant of the OS, or its ID when the MCO doesn't know it, for messages
+func (os OperatingSystem) name() string {
+	if variant := os.Variant(); variant != "" {
+		return variant
+	}
+	if os.ID != "" {
+		return os.ID
+	}
+	return "an unknown OS"
 }
 
 // IsLikeTraditionalRHEL7 is true if the OS is traditional RHEL7 or CentOS7:
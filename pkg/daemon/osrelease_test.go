@@ -1,6 +1,7 @@
 package daemon
 
 import (
+	"context"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -16,3 +17,38 @@ func TestIsLikeTraditionalRHEL7(t *testing.T) {
 	testOS.VersionID = "6.8"
 	assert.False(t, testOS.IsLikeTraditionalRHEL7())
 }
+
+func TestOSVariant(t *testing.T) {
+	tests := []struct {
+		os      OperatingSystem
+		variant string
+		coreOS  bool
+	}{
+		{os: OperatingSystem{ID: "rhcos", VersionID: "48.84"}, variant: OSVariantRHCOS, coreOS: true},
+		{os: OperatingSystem{ID: "fedora", VariantID: "coreos", VersionID: "34"}, variant: OSVariantFCOS, coreOS: true},
+		{os: OperatingSystem{ID: "scos", VersionID: "410.9"}, variant: OSVariantSCOS, coreOS: true},
+		{os: OperatingSystem{ID: "rhel", VersionID: "8.4"}, variant: OSVariantRHEL},
+		{os: OperatingSystem{ID: "centos", VersionID: "7"}, variant: OSVariantRHEL},
+		{os: OperatingSystem{ID: "fedora", VersionID: "34"}},
+		{os: OperatingSystem{ID: "ubuntu", VersionID: "20.04"}},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.variant, test.os.Variant(), test.os.ID)
+		assert.Equal(t, test.coreOS, test.os.IsCoreOSVariant(), test.os.ID)
+		_, rpmOstree := newNodeUpdaterClientFor(test.os).(*RpmOstreeClient)
+		assert.Equal(t, test.coreOS, rpmOstree, test.os.ID)
+	}
+}
+
+func TestUnsupportedNodeUpdaterClient(t *testing.T) {
+	client := newNodeUpdaterClientFor(OperatingSystem{ID: "rhel", VersionID: "8.4"})
+	osImageURL, version, err := client.GetBootedOSImageURL(context.Background())
+	assert.Nil(t, err)
+	assert.Empty(t, osImageURL)
+	assert.Empty(t, version)
+	_, err = client.Rebase(context.Background(), "registry.example.com/os@sha256:aaa", "")
+	assert.EqualError(t, err, "OS updates are not supported on rhel")
+	rolledBack, err := client.Rollback(context.Background(), "registry.example.com/os@sha256:aaa")
+	assert.Nil(t, err)
+	assert.False(t, rolledBack)
+}
@@ -13,10 +13,14 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 )
 
+// ExecCommand builds the commands run by RunExt and RunExtBackground, tests swap it out
+// to record them instead of running them
+var ExecCommand = exec.Command
+
 // runImpl is the actual shell execution implementation used by other functions.
 func runImpl(command string, args ...string) ([]byte, error) {
 	glog.Infof("Running: %s %s\n", command, strings.Join(args, " "))
-	cmd := exec.Command(command, args...)
+	cmd := ExecCommand(command, args...)
 	// multiplex writes to std streams so we keep seeing logs in MCD/systemd
 	// but we'll still be able to give out something here
 	var b bytes.Buffer
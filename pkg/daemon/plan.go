@@ -0,0 +1,192 @@
+package daemon
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+// UpdatePlan describes what update() does to move the host from one config to another.
+// It is computed without touching the host so it can be reviewed before the update runs.
+type UpdatePlan struct {
+	FromConfig string `json:"fromConfig"`
+	ToConfig   string `json:"toConfig"`
+	// Unreconcilable is why the update can't be applied in place, nothing else is set then
+	Unreconcilable string `json:"unreconcilable,omitempty"`
+
+	FilesToWrite   []string `json:"filesToWrite,omitempty"`
+	FilesToRemove  []string `json:"filesToRemove,omitempty"`
+	UnitsToWrite   []string `json:"unitsToWrite,omitempty"`
+	UnitsToRemove  []string `json:"unitsToRemove,omitempty"`
+	UnitsToEnable  []string `json:"unitsToEnable,omitempty"`
+	UnitsToDisable []string `json:"unitsToDisable,omitempty"`
+	SSHKeys        bool     `json:"sshKeys,omitempty"`
+
+	// OSImageURL is the OS image pivoted to, if it changes
+	OSImageURL string `json:"osImageURL,omitempty"`
+	// KernelArguments are the `rpm-ostree kargs` arguments, if they change
+	KernelArguments []string `json:"kernelArguments,omitempty"`
+	// KernelType is the kernel type switched to, if it changes
+	KernelType string `json:"kernelType,omitempty"`
+	// Extensions are the `rpm-ostree` arguments updating the extensions, if they change
+	Extensions []string `json:"extensions,omitempty"`
+
+	Drain                  bool   `json:"drain"`
+	PostConfigChangeAction string `json:"postConfigChangeAction"`
+}
+
+// planUpdate computes the plan of update(oldConfig, newConfig) without executing any of it
+func (dn *Daemon) planUpdate(oldConfig, newConfig *mcfgv1.MachineConfig) (*UpdatePlan, error) {
+	oldConfig = canonicalizeEmptyMC(oldConfig)
+	plan := &UpdatePlan{
+		FromConfig: oldConfig.GetName(),
+		ToConfig:   newConfig.GetName(),
+	}
+
+	diff, err := reconcilable(oldConfig, newConfig)
+	if err != nil {
+		plan.Unreconcilable = err.Error()
+		return plan, nil
+	}
+
+	oldIgnConfig, err := ctrlcommon.ParseAndConvertConfig(oldConfig.Spec.Config.Raw)
+	if err != nil {
+		return nil, fmt.Errorf("parsing old Ignition config failed with error: %v", err)
+	}
+	newIgnConfig, err := ctrlcommon.ParseAndConvertConfig(newConfig.Spec.Config.Raw)
+	if err != nil {
+		return nil, fmt.Errorf("parsing new Ignition config failed with error: %v", err)
+	}
+	plan.planFiles(oldIgnConfig.Storage.Files, newIgnConfig.Storage.Files)
+	plan.planUnits(oldIgnConfig.Systemd.Units, newIgnConfig.Systemd.Units)
+	plan.SSHKeys = diff.passwd
+
+	if diff.osUpdate {
+		plan.OSImageURL = newConfig.Spec.OSImageURL
+	}
+	if diff.kargs {
+		plan.KernelArguments = generateKargs(oldConfig, newConfig)
+	}
+	if diff.kernelType {
+		plan.KernelType = canonicalizeKernelType(newConfig.Spec.KernelType)
+	}
+	if diff.extensions {
+		plan.Extensions = dn.generateExtensionsArgs(oldConfig, newConfig)
+	}
+
+	// Same as calculatePostConfigChangeAction, without consuming the force file
+	actions := []string{postConfigChangeActionReboot}
+	if _, err := hostFileStater.Stat(constants.MachineConfigDaemonForceFile); err != nil {
+		actions, err = calculatePostConfigChangeActionFromConfigDiffs(oldConfig, newConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+	plan.PostConfigChangeAction = strings.Join(actions, ", ")
+	plan.Drain = ctrlcommon.InSlice(postConfigChangeActionReboot, actions) ||
+		ctrlcommon.InSlice(postConfigChangeActionReloadCrio, actions) ||
+		ctrlcommon.InSlice(postConfigChangeActionRestartCrio, actions) ||
+		ctrlcommon.InSlice(postConfigChangeActionApplyLive, actions)
+	return plan, nil
+}
+
+func (plan *UpdatePlan) planFiles(oldFiles, newFiles []ign3types.File) {
+	oldFileSet := make(map[string]ign3types.File)
+	for _, f := range oldFiles {
+		oldFileSet[f.Path] = f
+	}
+	newFileSet := make(map[string]bool)
+	for _, f := range newFiles {
+		newFileSet[f.Path] = true
+		if old, ok := oldFileSet[f.Path]; !ok || !reflect.DeepEqual(old, f) {
+			plan.FilesToWrite = append(plan.FilesToWrite, f.Path)
+		}
+	}
+	for _, f := range oldFiles {
+		if !newFileSet[f.Path] {
+			plan.FilesToRemove = append(plan.FilesToRemove, f.Path)
+		}
+	}
+	sort.Strings(plan.FilesToWrite)
+	sort.Strings(plan.FilesToRemove)
+}
+
+func (plan *UpdatePlan) planUnits(oldUnits, newUnits []ign3types.Unit) {
+	oldUnitSet := make(map[string]ign3types.Unit)
+	for _, u := range oldUnits {
+		oldUnitSet[u.Name] = u
+	}
+	newUnitSet := make(map[string]bool)
+	for _, u := range newUnits {
+		newUnitSet[u.Name] = true
+		old, ok := oldUnitSet[u.Name]
+		if !ok || !reflect.DeepEqual(old.Contents, u.Contents) || !reflect.DeepEqual(old.Dropins, u.Dropins) || !reflect.DeepEqual(old.Mask, u.Mask) {
+			plan.UnitsToWrite = append(plan.UnitsToWrite, u.Name)
+		}
+		if u.Enabled != nil && (old.Enabled == nil || *old.Enabled != *u.Enabled) {
+			if *u.Enabled {
+				plan.UnitsToEnable = append(plan.UnitsToEnable, u.Name)
+			} else {
+				plan.UnitsToDisable = append(plan.UnitsToDisable, u.Name)
+			}
+		}
+	}
+	for _, u := range oldUnits {
+		if !newUnitSet[u.Name] {
+			plan.UnitsToRemove = append(plan.UnitsToRemove, u.Name)
+		}
+	}
+	sort.Strings(plan.UnitsToWrite)
+	sort.Strings(plan.UnitsToRemove)
+	sort.Strings(plan.UnitsToEnable)
+	sort.Strings(plan.UnitsToDisable)
+}
+
+// String returns the plan in a human readable form
+func (plan *UpdatePlan) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Update from %s to %s\n", plan.FromConfig, plan.ToConfig)
+	if plan.Unreconcilable != "" {
+		fmt.Fprintf(&b, "Can't be applied: %s\n", plan.Unreconcilable)
+		return b.String()
+	}
+	writeList := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "%s:\n", title)
+		for _, item := range items {
+			fmt.Fprintf(&b, "  %s\n", item)
+		}
+	}
+	writeList("Files to write", plan.FilesToWrite)
+	writeList("Files to remove", plan.FilesToRemove)
+	writeList("Units to write", plan.UnitsToWrite)
+	writeList("Units to remove", plan.UnitsToRemove)
+	writeList("Units to enable", plan.UnitsToEnable)
+	writeList("Units to disable", plan.UnitsToDisable)
+	if plan.SSHKeys {
+		fmt.Fprintf(&b, "SSH keys: update\n")
+	}
+	if plan.OSImageURL != "" {
+		fmt.Fprintf(&b, "OS update: pivot to %s\n", plan.OSImageURL)
+	}
+	if len(plan.KernelArguments) > 0 {
+		fmt.Fprintf(&b, "Kernel arguments: rpm-ostree kargs %s\n", strings.Join(plan.KernelArguments, " "))
+	}
+	if plan.KernelType != "" {
+		fmt.Fprintf(&b, "Kernel type: switch to %s\n", plan.KernelType)
+	}
+	if len(plan.Extensions) > 0 {
+		fmt.Fprintf(&b, "Extensions: rpm-ostree %s\n", strings.Join(plan.Extensions, " "))
+	}
+	fmt.Fprintf(&b, "Drain: %t\n", plan.Drain)
+	fmt.Fprintf(&b, "Post config change action: %s\n", plan.PostConfigChangeAction)
+	return b.String()
+}
@@ -0,0 +1,188 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+// rebootReasonPath holds the reason of the reboot the daemon initiated, so it can tell its
+// own reboots from the others on the next boot
+const rebootReasonPath = "/etc/machine-config-daemon/reboot-reason.json"
+
+const (
+	// rebootRequestedByUpdate is a reboot applying a new rendered config
+	rebootRequestedByUpdate = "update"
+	// rebootRequestedByDrainRetry is a reboot into the pending config after the daemon was
+	// interrupted during the drain
+	rebootRequestedByDrainRetry = "drain-retry"
+	// rebootRequestedByBootstrap is the reboot into the OS image of the bootstrap config
+	rebootRequestedByBootstrap = "bootstrap"
+	// rebootRequestedByFirstboot is the reboot completing the firstboot provisioning
+	rebootRequestedByFirstboot = "firstboot"
+	// rebootRequestedByOnceFrom is the reboot after a once-from run
+	rebootRequestedByOnceFrom = "once-from"
+	// rebootRequestedByPool is a reboot requested on the pool of the node
+	rebootRequestedByPool = "pool"
+	// rebootRequestedByOSRollback is the reboot into the previous deployment requested on the node
+	rebootRequestedByOSRollback = "os-rollback"
+	// rebootRequestedByExternal is any reboot the daemon didn't initiate
+	rebootRequestedByExternal = "external"
+)
+
+// rebootReason records why a node rebooted
+type rebootReason struct {
+	// Message is the human readable rationale
+	Message string `json:"message"`
+	// Config is the rendered config the node reboots into, if any
+	Config string `json:"config,omitempty"`
+	// Changes are the kinds of changes between the configs that required the reboot
+	Changes []string `json:"changes,omitempty"`
+	// RequestedBy is the part of the MCO that asked for the reboot, or external
+	RequestedBy string `json:"requestedBy"`
+	// BootID is the boot the reboot ended
+	BootID string `json:"bootID,omitempty"`
+	// Time the reboot was initiated at, or detected at for external reboots
+	Time string `json:"time"`
+}
+
+// String returns the message along with where the reboot came from
+func (r rebootReason) String() string {
+	if r.Config == "" {
+		return fmt.Sprintf("%s (requested by %s)", r.Message, r.RequestedBy)
+	}
+	return fmt.Sprintf("%s (requested by %s, changes: %v)", r.Message, r.RequestedBy, r.Changes)
+}
+
+// changes returns the names of the kinds of changes in the diff
+func (mcDiff *machineConfigDiff) changes() []string {
+	changes := []string{}
+	for _, change := range []struct {
+		changed bool
+		name    string
+	}{
+		{mcDiff.osUpdate, "osUpdate"},
+		{mcDiff.kargs, "kernelArguments"},
+		{mcDiff.fips, "fips"},
+		{mcDiff.passwd, "passwd"},
+		{mcDiff.files, "files"},
+		{mcDiff.units, "units"},
+		{mcDiff.kernelType, "kernelType"},
+		{mcDiff.extensions, "extensions"},
+	} {
+		if change.changed {
+			changes = append(changes, change.name)
+		}
+	}
+	return changes
+}
+
+// recordReboot stores the reason of the reboot about to happen on disk, for the next boot,
+// and on the node
+func (dn *Daemon) recordReboot(reason rebootReason) error {
+	reason.BootID = dn.bootID
+	reason.Time = hostClock.Now().UTC().Format(time.RFC3339)
+	data, err := json.Marshal(reason)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomicallyWithDefaults(rebootReasonPath, data); err != nil {
+		return errors.Wrap(err, "storing the reboot reason")
+	}
+	if dn.nodeWriter == nil {
+		return nil
+	}
+	return dn.nodeWriter.SetRebootReason(string(data), dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name)
+}
+
+// readRebootReason returns the reason of the last reboot the daemon initiated, nil if there
+// is none
+func readRebootReason() (*rebootReason, error) {
+	data, err := ioutil.ReadFile(rebootReasonPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	reason := &rebootReason{}
+	if err := json.Unmarshal(data, reason); err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", rebootReasonPath)
+	}
+	return reason, nil
+}
+
+// classifyBoot returns the reason of the reboot that ended lastBootID, the last boot the
+// daemon saw, given the reboot the daemon initiated last, and whether the daemon initiated it.
+// It returns nil if the node hasn't rebooted since lastBootID.
+func classifyBoot(bootID, lastBootID string, initiated *rebootReason, now time.Time) (reason *rebootReason, ours bool) {
+	if lastBootID == "" || lastBootID == bootID {
+		return nil, false
+	}
+	if initiated != nil && initiated.BootID == lastBootID {
+		return initiated, true
+	}
+	return &rebootReason{
+		Message:     "Node rebooted without the MCO initiating it",
+		RequestedBy: rebootRequestedByExternal,
+		BootID:      lastBootID,
+		Time:        now.UTC().Format(time.RFC3339),
+	}, false
+}
+
+// detectReboot reports whether the node rebooted since the daemon last ran and, if so,
+// whether the daemon or something else rebooted it, then records the current boot
+func (dn *Daemon) detectReboot() error {
+	if dn.nodeWriter == nil || dn.node == nil {
+		return nil
+	}
+	initiated, err := readRebootReason()
+	if err != nil {
+		glog.Warningf("Ignoring the reason of the last reboot: %v", err)
+	}
+	lastBootID := dn.node.Annotations[lastBootIDAnnotationKey]
+	reason, ours := classifyBoot(dn.bootID, lastBootID, initiated, hostClock.Now())
+	if reason != nil {
+		if ours {
+			dn.logSystem("Node rebooted by the MCO: %s", reason)
+			if dn.recorder != nil {
+				dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeNormal, "Rebooted", "Node rebooted by the MCO: %s", reason)
+			}
+		} else {
+			dn.logSystem("Node rebooted without the MCO initiating it (previous boot %s)", lastBootID)
+			if dn.recorder != nil {
+				dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeWarning, "UnexpectedReboot", "Node rebooted without the MCO initiating it")
+			}
+			data, err := json.Marshal(reason)
+			if err != nil {
+				return err
+			}
+			if err := dn.nodeWriter.SetRebootReason(string(data), dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name); err != nil {
+				return err
+			}
+		}
+	}
+	if initiated != nil && initiated.BootID != dn.bootID {
+		if err := os.Remove(rebootReasonPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if lastBootID == dn.bootID {
+		return nil
+	}
+	// the reboot scheduled, if any, happened
+	if dn.node.Annotations[constants.ScheduledRebootAnnotationKey] != "" {
+		if err := dn.nodeWriter.SetScheduledReboot("", dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name); err != nil {
+			return err
+		}
+	}
+	return dn.nodeWriter.SetLastBootID(dn.bootID, dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name)
+}
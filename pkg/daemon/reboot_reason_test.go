@@ -0,0 +1,60 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyBoot(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	initiated := &rebootReason{
+		Message:     "Node will reboot into config rendered-worker-2",
+		Config:      "rendered-worker-2",
+		Changes:     []string{"osUpdate"},
+		RequestedBy: rebootRequestedByUpdate,
+		BootID:      "boot-1",
+	}
+
+	tests := []struct {
+		name       string
+		lastBootID string
+		initiated  *rebootReason
+		wantReason *rebootReason
+		wantOurs   bool
+	}{{
+		name: "first run of the daemon",
+	}, {
+		name:       "daemon restarted",
+		lastBootID: "boot-2",
+		initiated:  initiated,
+	}, {
+		name:       "rebooted by the MCO",
+		lastBootID: "boot-1",
+		initiated:  initiated,
+		wantReason: initiated,
+		wantOurs:   true,
+	}, {
+		name:       "rebooted by someone else",
+		lastBootID: "boot-1",
+		wantReason: &rebootReason{Message: "Node rebooted without the MCO initiating it", RequestedBy: rebootRequestedByExternal, BootID: "boot-1", Time: "2021-03-01T12:00:00Z"},
+	}, {
+		name:       "rebooted by someone else after an older MCO reboot",
+		lastBootID: "boot-0",
+		initiated:  initiated,
+		wantReason: &rebootReason{Message: "Node rebooted without the MCO initiating it", RequestedBy: rebootRequestedByExternal, BootID: "boot-0", Time: "2021-03-01T12:00:00Z"},
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reason, ours := classifyBoot("boot-2", test.lastBootID, test.initiated, now)
+			assert.Equal(t, test.wantReason, reason)
+			assert.Equal(t, test.wantOurs, ours)
+		})
+	}
+}
+
+func TestMachineConfigDiffChanges(t *testing.T) {
+	assert.Equal(t, []string{}, (&machineConfigDiff{}).changes())
+	assert.Equal(t, []string{"osUpdate", "kernelArguments", "units"}, (&machineConfigDiff{osUpdate: true, kargs: true, units: true}).changes())
+}
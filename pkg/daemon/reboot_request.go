@@ -0,0 +1,61 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+// pendingRebootRequest returns the reboot request of the pool the node was targeted for and
+// hasn't rebooted for yet, empty if there is none. Config updates go first, the node has to be
+// done updating to its desired config.
+func pendingRebootRequest(node *corev1.Node) string {
+	desired := node.Annotations[constants.DesiredRebootAnnotationKey]
+	if desired == "" || desired == node.Annotations[constants.CurrentRebootAnnotationKey] {
+		return ""
+	}
+	if node.Annotations[constants.CurrentMachineConfigAnnotationKey] != node.Annotations[constants.DesiredMachineConfigAnnotationKey] ||
+		node.Annotations[constants.MachineConfigDaemonStateAnnotationKey] != constants.MachineConfigDaemonStateDone {
+		return ""
+	}
+	return desired
+}
+
+// performRequestedReboot drains and reboots the node into its current config, the same way an
+// update does, so the pending config completes the reboot on the next boot
+func (dn *Daemon) performRequestedReboot(ctx context.Context, request string) error {
+	dn.updateActiveLock.Lock()
+	defer dn.updateActiveLock.Unlock()
+
+	currentConfigName, err := getNodeAnnotation(dn.node, constants.CurrentMachineConfigAnnotationKey)
+	if err != nil {
+		return err
+	}
+	currentConfig, err := dn.mcLister.Get(currentConfigName)
+	if err != nil {
+		return err
+	}
+
+	dn.logSystem("Reboot requested on the pool of the node (%s)", request)
+	if err := dn.nodeWriter.SetWorking(dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name); err != nil {
+		return errors.Wrap(err, "error setting node's state to Working")
+	}
+	if err := dn.performDrain(); err != nil {
+		return err
+	}
+	if err := dn.finalizeBeforeReboot(currentConfig); err != nil {
+		return err
+	}
+	if err := dn.nodeWriter.SetCurrentReboot(request, dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name); err != nil {
+		return errors.Wrap(err, "error recording the reboot request")
+	}
+	return dn.reboot(ctx, rebootReason{
+		Message:     fmt.Sprintf("Node will reboot for the reboot request %s of its pool", request),
+		Config:      currentConfigName,
+		RequestedBy: rebootRequestedByPool,
+	})
+}
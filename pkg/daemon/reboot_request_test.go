@@ -0,0 +1,67 @@
+package daemon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+func TestPendingRebootRequest(t *testing.T) {
+	request := "2021-03-01T12:00:00Z"
+	tests := []struct {
+		name     string
+		annos    map[string]string
+		expected string
+	}{{
+		name: "no request",
+		annos: map[string]string{
+			constants.CurrentMachineConfigAnnotationKey:     "v1",
+			constants.DesiredMachineConfigAnnotationKey:     "v1",
+			constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateDone,
+		},
+	}, {
+		name: "pending request",
+		annos: map[string]string{
+			constants.CurrentMachineConfigAnnotationKey:     "v1",
+			constants.DesiredMachineConfigAnnotationKey:     "v1",
+			constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateDone,
+			constants.DesiredRebootAnnotationKey:            request,
+		},
+		expected: request,
+	}, {
+		name: "already rebooted",
+		annos: map[string]string{
+			constants.CurrentMachineConfigAnnotationKey:     "v1",
+			constants.DesiredMachineConfigAnnotationKey:     "v1",
+			constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateDone,
+			constants.DesiredRebootAnnotationKey:            request,
+			constants.CurrentRebootAnnotationKey:            request,
+		},
+	}, {
+		name: "updating",
+		annos: map[string]string{
+			constants.CurrentMachineConfigAnnotationKey:     "v0",
+			constants.DesiredMachineConfigAnnotationKey:     "v1",
+			constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateWorking,
+			constants.DesiredRebootAnnotationKey:            request,
+		},
+	}, {
+		name: "degraded",
+		annos: map[string]string{
+			constants.CurrentMachineConfigAnnotationKey:     "v1",
+			constants.DesiredMachineConfigAnnotationKey:     "v1",
+			constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateDegraded,
+			constants.DesiredRebootAnnotationKey:            request,
+		},
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0", Annotations: test.annos}}
+			assert.Equal(t, test.expected, pendingRebootRequest(node))
+		})
+	}
+}
@@ -1,18 +1,21 @@
 package daemon
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
-	"github.com/containers/image/v5/types"
 	"github.com/golang/glog"
-	"github.com/opencontainers/go-digest"
-	pivotutils "github.com/openshift/machine-config-operator/pkg/daemon/pivot/utils"
 	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 )
 
 const (
@@ -20,12 +23,23 @@ const (
 	numRetriesNetCommands = 5
 	// Pull secret.  Written by the machine-config-operator
 	kubeletAuthFile = "/var/lib/kubelet/config.json"
+	// stagedDeploymentLockFile exists while the finalization of the staged deployment is locked,
+	// the deployment is then discarded rather than booted into on the next reboot
+	stagedDeploymentLockFile = "/run/ostree/staged-deployment-locked"
+
+	// rpmOstreeQueryTimeout bounds the rpm-ostree commands reading the state of the host
+	rpmOstreeQueryTimeout = 5 * time.Minute
+	// rpmOstreeTransactionTimeout bounds the rpm-ostree transactions, downloading the OS included
+	rpmOstreeTransactionTimeout = 2 * time.Hour
+	// commandTerminationGracePeriod is how long a command has to exit after SIGTERM before it is killed
+	commandTerminationGracePeriod = 30 * time.Second
 )
 
-// rpmOstreeState houses zero or more RpmOstreeDeployments
+// RpmOstreeState houses zero or more RpmOstreeDeployments, in the order they are booted: the
+// staged deployment first if any, then the booted one and the rollback ones.
 // Subset of `rpm-ostree status --json`
 // https://github.com/projectatomic/rpm-ostree/blob/bce966a9812df141d38e3290f845171ec745aa4e/src/daemon/rpmostreed-deployment-utils.c#L227
-type rpmOstreeState struct {
+type RpmOstreeState struct {
 	Deployments []RpmOstreeDeployment
 }
 
@@ -38,32 +52,47 @@ type RpmOstreeDeployment struct {
 	Version      string   `json:"version"`
 	Timestamp    uint64   `json:"timestamp"`
 	Booted       bool     `json:"booted"`
+	Pinned       bool     `json:"pinned"`
 	Origin       string   `json:"origin"`
 	CustomOrigin []string `json:"custom-origin"`
-}
-
-// imageInspection is a public implementation of
-// https://github.com/containers/skopeo/blob/82186b916faa9c8c70cfa922229bafe5ae024dec/cmd/skopeo/inspect.go#L20-L31
-type imageInspection struct {
-	Name          string `json:",omitempty"`
-	Tag           string `json:",omitempty"`
-	Digest        digest.Digest
-	RepoDigests   []string
-	Created       *time.Time
-	DockerVersion string
-	Labels        map[string]string
-	Architecture  string
-	Os            string
-	Layers        []string
+	// ContainerImageReference is the container image the deployment was rebased to, e.g.
+	// ostree-unverified-registry:quay.io/openshift/os@sha256:..., empty if it wasn't
+	ContainerImageReference string `json:"container-image-reference"`
+	// RequestedPackages are the packages layered on the deployment, e.g. extensions
+	RequestedPackages []string `json:"requested-packages"`
+	// RequestedLocalPackages are the local packages layered on the deployment
+	RequestedLocalPackages []string `json:"requested-local-packages"`
+	// RequestedBaseRemovals are the packages of the OS image removed from the deployment, e.g.
+	// the default kernel when it was switched to the realtime one
+	RequestedBaseRemovals []string `json:"requested-base-removals"`
+	// Staged is true for the deployment staged to be finalized on the next shutdown
+	Staged bool `json:"staged"`
+	// LiveReplaced is the commit applied live to the booted deployment, empty if none was
+	LiveReplaced string `json:"live-replaced"`
+	// BaseChecksum is the commit of the OS image the deployment layers packages on, empty if it
+	// doesn't layer any
+	BaseChecksum string `json:"base-checksum"`
 }
 
 // NodeUpdaterClient is an interface describing how to interact with the host
 // around content deployment
 type NodeUpdaterClient interface {
-	GetStatus() (string, error)
-	GetBootedOSImageURL() (string, string, error)
-	Rebase(string, string) (bool, error)
-	GetBootedDeployment() (*RpmOstreeDeployment, error)
+	GetStatus(context.Context) (string, error)
+	GetBootedOSImageURL(context.Context) (string, string, error)
+	Rebase(context.Context, string, string) (bool, error)
+	GetBootedDeployment(context.Context) (*RpmOstreeDeployment, error)
+	GetState(context.Context) (*RpmOstreeState, error)
+	Rollback(context.Context, string) (bool, error)
+	GetDeploymentDiff(context.Context) ([]PackageChange, error)
+	InstallPackages(context.Context, []string) error
+	RemovePackages(context.Context, []string) error
+	GetRollbackDeployment(context.Context) (*RpmOstreeDeployment, error)
+	StageRebase(context.Context, string, string) (bool, error)
+	GetPrestagedOSImageURL(context.Context) (string, error)
+	ApplyLive(context.Context) error
+	PinCurrentDeployment(context.Context) (string, bool, error)
+	UnpinDeployment(context.Context, string) error
+	SwitchKernel(context.Context, string) error
 }
 
 // RpmOstreeClient provides all RpmOstree related methods in one structure.
@@ -72,15 +101,119 @@ type NodeUpdaterClient interface {
 // TODO(runcom): make this private to pkg/daemon!!!
 type RpmOstreeClient struct{}
 
-// NewNodeUpdaterClient returns a new instance of the default DeploymentClient (RpmOstreeClient)
+// NewNodeUpdaterClient returns the NodeUpdaterClient of the OS of the host: the RpmOstreeClient
+// on the CoreOS variants, whose OS the MCO updates, and a client refusing OS updates elsewhere.
 func NewNodeUpdaterClient() NodeUpdaterClient {
-	return &RpmOstreeClient{}
+	os, err := GetHostRunningOS()
+	if err != nil {
+		glog.Warningf("Failed to detect the host OS, assuming rpm-ostree: %v", err)
+		return &RpmOstreeClient{}
+	}
+	return newNodeUpdaterClientFor(os)
 }
 
-// GetBootedDeployment returns the current deployment found
-func (r *RpmOstreeClient) GetBootedDeployment() (*RpmOstreeDeployment, error) {
-	var rosState rpmOstreeState
-	output, err := runGetOut("rpm-ostree", "status", "--json")
+// newNodeUpdaterClientFor returns the NodeUpdaterClient of os
+func newNodeUpdaterClientFor(os OperatingSystem) NodeUpdaterClient {
+	if os.IsCoreOSVariant() {
+		return &RpmOstreeClient{}
+	}
+	return &unsupportedNodeUpdaterClient{os: os}
+}
+
+// unsupportedNodeUpdaterClient is the NodeUpdaterClient of the operating systems the MCO doesn't
+// update the OS of, e.g. RHEL whose packages are updated outside of the cluster. The host has no
+// OS image and nothing to roll back to.
+type unsupportedNodeUpdaterClient struct {
+	os OperatingSystem
+}
+
+func (c *unsupportedNodeUpdaterClient) notSupported() error {
+	return fmt.Errorf("OS updates are not supported on %s", c.os.name())
+}
+
+// GetStatus returns an error as there is no rpm-ostree status
+func (c *unsupportedNodeUpdaterClient) GetStatus(context.Context) (string, error) {
+	return "", c.notSupported()
+}
+
+// GetBootedOSImageURL returns no OS image
+func (c *unsupportedNodeUpdaterClient) GetBootedOSImageURL(context.Context) (string, string, error) {
+	return "", "", nil
+}
+
+// Rebase returns an error as the OS can't be updated
+func (c *unsupportedNodeUpdaterClient) Rebase(context.Context, string, string) (bool, error) {
+	return false, c.notSupported()
+}
+
+// GetBootedDeployment returns an error as there are no deployments
+func (c *unsupportedNodeUpdaterClient) GetBootedDeployment(context.Context) (*RpmOstreeDeployment, error) {
+	return nil, c.notSupported()
+}
+
+// GetState returns an error as there are no deployments
+func (c *unsupportedNodeUpdaterClient) GetState(context.Context) (*RpmOstreeState, error) {
+	return nil, c.notSupported()
+}
+
+// Rollback does nothing, there is no previous OS to roll back to
+func (c *unsupportedNodeUpdaterClient) Rollback(context.Context, string) (bool, error) {
+	return false, nil
+}
+
+// GetDeploymentDiff returns no changes, nothing is ever staged
+func (c *unsupportedNodeUpdaterClient) GetDeploymentDiff(context.Context) ([]PackageChange, error) {
+	return nil, nil
+}
+
+// GetRollbackDeployment returns no deployment
+func (c *unsupportedNodeUpdaterClient) GetRollbackDeployment(context.Context) (*RpmOstreeDeployment, error) {
+	return nil, nil
+}
+
+// InstallPackages returns an error as packages can't be layered
+func (c *unsupportedNodeUpdaterClient) InstallPackages(context.Context, []string) error {
+	return c.notSupported()
+}
+
+// RemovePackages returns an error as packages can't be layered
+func (c *unsupportedNodeUpdaterClient) RemovePackages(context.Context, []string) error {
+	return c.notSupported()
+}
+
+// StageRebase returns an error as the OS can't be updated
+func (c *unsupportedNodeUpdaterClient) StageRebase(context.Context, string, string) (bool, error) {
+	return false, c.notSupported()
+}
+
+// ApplyLive returns an error as nothing is ever staged
+func (c *unsupportedNodeUpdaterClient) ApplyLive(context.Context) error {
+	return c.notSupported()
+}
+
+// PinCurrentDeployment returns an error as there are no deployments
+func (c *unsupportedNodeUpdaterClient) PinCurrentDeployment(context.Context) (string, bool, error) {
+	return "", false, c.notSupported()
+}
+
+// UnpinDeployment does nothing, there are no deployments pinned
+func (c *unsupportedNodeUpdaterClient) UnpinDeployment(context.Context, string) error {
+	return nil
+}
+
+// SwitchKernel returns an error as the kernel comes with the OS
+func (c *unsupportedNodeUpdaterClient) SwitchKernel(context.Context, string) error {
+	return c.notSupported()
+}
+
+// GetPrestagedOSImageURL returns an empty image URL as nothing is ever staged
+func (c *unsupportedNodeUpdaterClient) GetPrestagedOSImageURL(context.Context) (string, error) {
+	return "", nil
+}
+
+func (r *RpmOstreeClient) loadStatus(ctx context.Context) (*RpmOstreeState, error) {
+	var rosState RpmOstreeState
+	output, err := runRpmOstree(ctx, "status", "--json")
 	if err != nil {
 		return nil, err
 	}
@@ -88,6 +221,20 @@ func (r *RpmOstreeClient) GetBootedDeployment() (*RpmOstreeDeployment, error) {
 	if err := json.Unmarshal(output, &rosState); err != nil {
 		return nil, fmt.Errorf("failed to parse `rpm-ostree status --json` output: %v", err)
 	}
+	return &rosState, nil
+}
+
+// GetState returns all the deployments of the host, as parsed from `rpm-ostree status --json`
+func (r *RpmOstreeClient) GetState(ctx context.Context) (*RpmOstreeState, error) {
+	return r.loadStatus(ctx)
+}
+
+// GetBootedDeployment returns the current deployment found
+func (r *RpmOstreeClient) GetBootedDeployment(ctx context.Context) (*RpmOstreeDeployment, error) {
+	rosState, err := r.loadStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	for _, deployment := range rosState.Deployments {
 		if deployment.Booted {
@@ -100,8 +247,8 @@ func (r *RpmOstreeClient) GetBootedDeployment() (*RpmOstreeDeployment, error) {
 }
 
 // GetStatus returns multi-line human-readable text describing system status
-func (r *RpmOstreeClient) GetStatus() (string, error) {
-	output, err := runGetOut("rpm-ostree", "status")
+func (r *RpmOstreeClient) GetStatus(ctx context.Context) (string, error) {
+	output, err := runRpmOstree(ctx, "status")
 	if err != nil {
 		return "", err
 	}
@@ -111,69 +258,423 @@ func (r *RpmOstreeClient) GetStatus() (string, error) {
 
 // GetBootedOSImageURL returns the image URL as well as the OSTree version (for logging)
 // Returns the empty string if the host doesn't have a custom origin that matches pivot://
+// nor was rebased to a container image
 // (This could be the case for e.g. FCOS, or a future RHCOS which comes not-pivoted by default)
-func (r *RpmOstreeClient) GetBootedOSImageURL() (string, string, error) {
-	bootedDeployment, err := r.GetBootedDeployment()
+func (r *RpmOstreeClient) GetBootedOSImageURL(ctx context.Context) (string, string, error) {
+	bootedDeployment, err := r.GetBootedDeployment(ctx)
 	if err != nil {
 		return "", "", err
 	}
 
-	// the canonical image URL is stored in the custom origin field.
-	osImageURL := ""
-	if len(bootedDeployment.CustomOrigin) > 0 {
-		if strings.HasPrefix(bootedDeployment.CustomOrigin[0], "pivot://") {
-			osImageURL = bootedDeployment.CustomOrigin[0][len("pivot://"):]
+	return deploymentOSImageURL(bootedDeployment), bootedDeployment.Version, nil
+}
+
+// deploymentOSImageURL returns the OS image URL the deployment was rebased to: its container
+// image reference, or else the canonical image URL stored in the custom origin field
+func deploymentOSImageURL(deployment *RpmOstreeDeployment) string {
+	if deployment.ContainerImageReference != "" {
+		return deployment.ContainerImageReference
+	}
+	if len(deployment.CustomOrigin) > 0 && strings.HasPrefix(deployment.CustomOrigin[0], "pivot://") {
+		return deployment.CustomOrigin[0][len("pivot://"):]
+	}
+	return ""
+}
+
+// The transports of the OSTree container references rpm-ostree rebases to natively
+var ostreeContainerTransports = []string{
+	"ostree-unverified-registry:",
+	"ostree-unverified-image:",
+	"ostree-image-signed:",
+	"ostree-remote-registry:",
+	"ostree-remote-image:",
+}
+
+// IsOSTreeContainerReference returns whether imgURL is an OSTree container reference, e.g.
+// ostree-unverified-registry:quay.io/openshift/os@sha256:..., whose commit is in the image
+// itself and which rpm-ostree rebases to without the image being extracted first
+func IsOSTreeContainerReference(imgURL string) bool {
+	for _, transport := range ostreeContainerTransports {
+		if strings.HasPrefix(imgURL, transport) {
+			return true
+		}
+	}
+	return false
+}
+
+// osImagePullSpec returns the image pull spec of imgURL, which is imgURL itself unless it is
+// an OSTree container reference
+func osImagePullSpec(imgURL string) string {
+	for _, transport := range ostreeContainerTransports {
+		if !strings.HasPrefix(imgURL, transport) {
+			continue
+		}
+		image := imgURL[len(transport):]
+		// the remote forms name the OSTree remote verifying the image first
+		if strings.HasPrefix(transport, "ostree-remote-") {
+			if i := strings.Index(image, ":"); i >= 0 {
+				image = image[i+1:]
+			}
 		}
+		// the image forms use a containers transport, only registries can be pulled from
+		return strings.TrimPrefix(image, "docker://")
 	}
+	return imgURL
+}
 
-	return osImageURL, bootedDeployment.Version, nil
+// rollbackDeployment returns the deployment rpm-ostree rolls back to, nil if there is none or
+// something is staged on top of the booted deployment
+func rollbackDeployment(deployments []RpmOstreeDeployment) *RpmOstreeDeployment {
+	if len(deployments) < 2 || !deployments[0].Booted {
+		return nil
+	}
+	return &deployments[1]
 }
 
-func podmanInspect(imgURL string) (imgdata *imageInspection, err error) {
-	// Pull the container image if not already available
-	var authArgs []string
-	if _, err := os.Stat(kubeletAuthFile); err == nil {
-		authArgs = append(authArgs, "--authfile", kubeletAuthFile)
+// canRollbackTo returns true if the rollback deployment is a deployment of imgURL layering the
+// same packages and kernel as the booted one, with nothing staged on top of the booted deployment.
+// Any rollback deployment will do for an empty imgURL. The kernel arguments aren't part of the
+// status of the deployments, Rollback checks them.
+func canRollbackTo(deployments []RpmOstreeDeployment, imgURL string) bool {
+	rollback := rollbackDeployment(deployments)
+	if rollback == nil {
+		return false
+	}
+	if imgURL == "" {
+		return true
+	}
+	booted := deployments[0]
+	if deploymentOSImageURL(rollback) != imgURL {
+		return false
 	}
-	args := []string{"pull", "-q"}
-	args = append(args, authArgs...)
-	args = append(args, imgURL)
-	_, err = pivotutils.RunExt(numRetriesNetCommands, "podman", args...)
+	// the kernel type is switched by replacing the kernel packages of the OS image
+	return sets.NewString(booted.RequestedPackages...).Equal(sets.NewString(rollback.RequestedPackages...)) &&
+		sets.NewString(booted.RequestedLocalPackages...).Equal(sets.NewString(rollback.RequestedLocalPackages...)) &&
+		sets.NewString(booted.RequestedBaseRemovals...).Equal(sets.NewString(rollback.RequestedBaseRemovals...))
+}
+
+// deploymentKargs returns the kernel arguments of the deployment at index in the deployments
+func deploymentKargs(ctx context.Context, index int) (string, error) {
+	output, err := runRpmOstree(ctx, "kargs", fmt.Sprintf("--deploy-index=%d", index))
 	if err != nil {
-		return
+		return "", err
 	}
+	return strings.TrimSpace(string(output)), nil
+}
 
-	inspectArgs := []string{"inspect", "--type=image"}
-	inspectArgs = append(inspectArgs, fmt.Sprintf("%s", imgURL))
-	var output []byte
-	output, err = runGetOut("podman", inspectArgs...)
+// GetRollbackDeployment returns the deployment Rollback goes back to, nil if there is none
+func (r *RpmOstreeClient) GetRollbackDeployment(ctx context.Context) (*RpmOstreeDeployment, error) {
+	rosState, err := r.loadStatus(ctx)
 	if err != nil {
-		return
+		return nil, err
 	}
-	var imagedataArray []imageInspection
-	err = json.Unmarshal(output, &imagedataArray)
+	return rollbackDeployment(rosState.Deployments), nil
+}
+
+// Rollback makes the rollback deployment the default one if it is a deployment of imgURL, which
+// saves pulling imgURL again when going back to the previous config, or whatever it is a
+// deployment of for an empty imgURL. It returns false if the rollback deployment isn't one of
+// imgURL with the packages, kernel and kernel arguments of the booted deployment.
+func (r *RpmOstreeClient) Rollback(ctx context.Context, imgURL string) (bool, error) {
+	rosState, err := r.loadStatus(ctx)
 	if err != nil {
-		err = errors.Wrapf(err, "unmarshaling podman inspect")
-		return
+		return false, err
 	}
-	imgdata = &imagedataArray[0]
-	return
+	if !canRollbackTo(rosState.Deployments, imgURL) {
+		return false, nil
+	}
+	if imgURL != "" {
+		// canRollbackTo made sure the booted deployment is the first one, and the rollback one the second
+		bootedKargs, err := deploymentKargs(ctx, 0)
+		if err != nil {
+			return false, err
+		}
+		rollbackKargs, err := deploymentKargs(ctx, 1)
+		if err != nil {
+			return false, err
+		}
+		if bootedKargs != rollbackKargs {
+			glog.Infof("Not rolling back to the previous deployment of %s, its kernel arguments differ", imgURL)
+			return false, nil
+		}
+	}
+	glog.Infof("Rolling back to the previous deployment of %s", imgURL)
+	if _, err := runRpmOstree(ctx, "rollback"); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// stagedDeployment returns the booted deployment and the one booted next, nil if it is the booted one
+func stagedDeployment(deployments []RpmOstreeDeployment) (booted, staged *RpmOstreeDeployment) {
+	for i := range deployments {
+		if deployments[i].Booted {
+			booted = &deployments[i]
+			if i > 0 {
+				staged = &deployments[0]
+			}
+			break
+		}
+	}
+	return booted, staged
+}
+
+// GetDeploymentDiff returns the packages the staged deployment changes from the booted one,
+// nil if nothing is staged
+func (r *RpmOstreeClient) GetDeploymentDiff(ctx context.Context) ([]PackageChange, error) {
+	rosState, err := r.loadStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	booted, staged := stagedDeployment(rosState.Deployments)
+	if booted == nil {
+		return nil, fmt.Errorf("not currently booted in a deployment")
+	}
+	if staged == nil {
+		return nil, nil
+	}
+	output, err := runRpmOstree(ctx, "db", "diff", "--format=json", booted.Checksum, staged.Checksum)
+	if err != nil {
+		return nil, err
+	}
+	return parseDBDiff(output)
+}
+
+// InstallPackages layers the packages from the rpm-md repos of the host on the staged deployment,
+// the packages already layered are skipped
+func (r *RpmOstreeClient) InstallPackages(ctx context.Context, packages []string) error {
+	args := append([]string{"install", "--idempotent"}, packages...)
+	_, err := runRpmOstree(ctx, args...)
+	return err
+}
+
+// RemovePackages removes the layered packages from the staged deployment
+func (r *RpmOstreeClient) RemovePackages(ctx context.Context, packages []string) error {
+	args := append([]string{"uninstall"}, packages...)
+	_, err := runRpmOstree(ctx, args...)
+	return err
+}
+
+// ApplyLive applies the changes of the staged deployment to the booted one without a reboot. Only
+// packages added are, the deployment is still booted into on the next reboot.
+func (r *RpmOstreeClient) ApplyLive(ctx context.Context) error {
+	_, err := runRpmOstree(ctx, "ex", "apply-live")
+	return err
+}
+
+// PinCurrentDeployment pins the booted deployment with ostree, so neither the next updates nor
+// rpm-ostree cleanup remove it and it can still be rolled back to. It returns the ID of the booted
+// deployment and whether it pinned it, false if it was pinned already.
+func (r *RpmOstreeClient) PinCurrentDeployment(ctx context.Context) (string, bool, error) {
+	rosState, err := r.loadStatus(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	// ostree indexes the deployments in the order rpm-ostree lists them
+	for i, deployment := range rosState.Deployments {
+		if !deployment.Booted {
+			continue
+		}
+		if deployment.Pinned {
+			return deployment.ID, false, nil
+		}
+		glog.Infof("Pinning the booted deployment %s", deployment.ID)
+		if _, err := runGetOutContext(ctx, "ostree", "admin", "pin", strconv.Itoa(i)); err != nil {
+			return "", false, err
+		}
+		return deployment.ID, true, nil
+	}
+	return "", false, fmt.Errorf("not currently booted in a deployment")
+}
+
+// UnpinDeployment unpins the deployment of the given ID, unless it is the booted one. The
+// deployments pinned by others are left alone.
+func (r *RpmOstreeClient) UnpinDeployment(ctx context.Context, id string) error {
+	rosState, err := r.loadStatus(ctx)
+	if err != nil {
+		return err
+	}
+	for i, deployment := range rosState.Deployments {
+		if deployment.ID != id {
+			continue
+		}
+		if deployment.Booted || !deployment.Pinned {
+			return nil
+		}
+		glog.Infof("Unpinning deployment %s", deployment.ID)
+		_, err := runGetOutContext(ctx, "ostree", "admin", "pin", "--unpin", strconv.Itoa(i))
+		return err
+	}
+	return nil
+}
+
+var (
+	// defaultKernelPackages are the packages of the default kernel of the OS image
+	defaultKernelPackages = []string{"kernel", "kernel-core", "kernel-modules", "kernel-modules-extra"}
+	// kernelTypePackages are the packages of the kernels replacing the default one, by kernel
+	// type, installed from the repositories of the OS image
+	kernelTypePackages = map[string][]string{
+		ctrlcommon.KernelTypeRealtime: {"kernel-rt-core", "kernel-rt-modules", "kernel-rt-modules-extra", "kernel-rt-kvm"},
+		ctrlcommon.KernelType64kPages: {"kernel-64k-core", "kernel-64k-modules", "kernel-64k-modules-extra"},
+	}
+)
+
+// deploymentKernelType returns the type of the kernel the deployment boots
+func deploymentKernelType(deployment *RpmOstreeDeployment) string {
+	for kernelType, pkgs := range kernelTypePackages {
+		if ctrlcommon.InSlice(pkgs[0], deployment.RequestedPackages) {
+			return kernelType
+		}
+	}
+	return ctrlcommon.KernelTypeDefault
+}
+
+// SwitchKernel switches the deployment booted next to the kernel of kernelType: the default
+// kernel of the OS image is overridden with the one of kernelType, or the override is reset. A
+// Rebase keeps the override, so switching a deployment already on the kernel of kernelType
+// updates its packages to the ones of the OS image it was rebased to instead.
+func (r *RpmOstreeClient) SwitchKernel(ctx context.Context, kernelType string) error {
+	rosState, err := r.loadStatus(ctx)
+	if err != nil {
+		return err
+	}
+	if len(rosState.Deployments) == 0 {
+		return fmt.Errorf("not currently booted in a deployment")
+	}
+	current := deploymentKernelType(&rosState.Deployments[0])
+
+	var commands [][]string
+	if kernelType == current {
+		if current != ctrlcommon.KernelTypeDefault {
+			commands = append(commands, []string{"update"})
+		}
+	} else {
+		if current != ctrlcommon.KernelTypeDefault {
+			args := append([]string{"override", "reset"}, defaultKernelPackages...)
+			for _, pkg := range kernelTypePackages[current] {
+				args = append(args, "--uninstall", pkg)
+			}
+			commands = append(commands, args)
+		}
+		if kernelType != ctrlcommon.KernelTypeDefault {
+			args := append([]string{"override", "remove"}, defaultKernelPackages...)
+			for _, pkg := range kernelTypePackages[kernelType] {
+				args = append(args, "--install", pkg)
+			}
+			commands = append(commands, args)
+		}
+	}
+	for _, args := range commands {
+		glog.Infof("Switching to kernelType=%s, invoking rpm-ostree %+q", kernelType, args)
+		if _, err := runRpmOstree(ctx, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetPrestagedOSImageURL returns the OS image URL of the deployment staged ahead of the update to
+// it by StageRebase, empty if there is none
+func (r *RpmOstreeClient) GetPrestagedOSImageURL(ctx context.Context) (string, error) {
+	if _, err := hostFileStater.Stat(stagedDeploymentLockFile); err != nil {
+		return "", nil
+	}
+	rosState, err := r.loadStatus(ctx)
+	if err != nil {
+		return "", err
+	}
+	_, staged := stagedDeployment(rosState.Deployments)
+	if staged == nil {
+		return "", nil
+	}
+	return deploymentOSImageURL(staged), nil
+}
+
+// StageRebase stages the rebase to imgURL like Rebase, ahead of the update to it: the finalization
+// of the deployment is locked so a reboot before the update discards it rather than booting into
+// it. Rebase unlocks it.
+func (r *RpmOstreeClient) StageRebase(ctx context.Context, imgURL, osImageContentDir string) (bool, error) {
+	return r.rebase(ctx, imgURL, osImageContentDir, true)
+}
+
+// Rebase potentially rebases system if not already rebased. OSTree container references are
+// rebased to natively, osImageContentDir is only used for the other OS images. The OS image has to
+// be allowed by the signature policy of the host, an OSImageVerificationError is returned otherwise.
+// The rebase staged ahead by StageRebase is used if it is to imgURL.
+func (r *RpmOstreeClient) Rebase(ctx context.Context, imgURL, osImageContentDir string) (bool, error) {
+	prestaged, err := r.GetPrestagedOSImageURL(ctx)
+	if err != nil {
+		return false, err
+	}
+	if prestaged != "" && prestaged == imgURL {
+		glog.Infof("Using the deployment of %s staged ahead of the update", imgURL)
+		if !IsOSTreeContainerReference(imgURL) {
+			labels, err := inspectOSImageLabels(imgURL)
+			if err != nil {
+				return false, err
+			}
+			if csum := labels["com.coreos.ostree-commit"]; csum != "" {
+				if err := r.verifyStagedDeployment(ctx, csum); err != nil {
+					return false, err
+				}
+			}
+		}
+		if err := os.Remove(stagedDeploymentLockFile); err != nil && !os.IsNotExist(err) {
+			return false, errors.Wrap(err, "unlocking the finalization of the staged deployment")
+		}
+		return true, nil
+	}
+	return r.rebase(ctx, imgURL, osImageContentDir, false)
+}
 
+// rebaseToContainer rebases to the OSTree container reference imgURL, which rpm-ostree pulls itself
+func (r *RpmOstreeClient) rebaseToContainer(ctx context.Context, imgURL string, booted *RpmOstreeDeployment, lockFinalization bool) (bool, error) {
+	if booted.ContainerImageReference == imgURL {
+		glog.Infof("Already booted into %s", imgURL)
+		return false, nil
+	}
+	if err := verifyOSImageSignature(imgURL); err != nil {
+		return false, err
+	}
+	// the image pulled for the verification isn't used, rpm-ostree pulls it itself
+	if !strings.HasPrefix(imgURL, "ostree-image-signed:") && !strings.HasPrefix(imgURL, "ostree-remote-") {
+		defer hostCommander.Command("podman", "rmi", osImagePullSpec(imgURL)).Run()
+	}
+	glog.Infof("Executing rebase to container image %s", imgURL)
+	args := []string{"rebase", "--experimental", imgURL}
+	if lockFinalization {
+		args = append(args, "--lock-finalization")
+	}
+	if _, err := runRpmOstree(ctx, args...); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
-// Rebase potentially rebases system if not already rebased.
-func (r *RpmOstreeClient) Rebase(imgURL, osImageContentDir string) (changed bool, err error) {
+// rebase rebases to imgURL unless it is booted, locking the finalization of the deployment staged
+// if lockFinalization is set
+func (r *RpmOstreeClient) rebase(ctx context.Context, imgURL, osImageContentDir string, lockFinalization bool) (changed bool, err error) {
 	var (
 		ostreeCsum    string
 		ostreeVersion string
 	)
-	defaultDeployment, err := r.GetBootedDeployment()
+	defaultDeployment, err := r.GetBootedDeployment(ctx)
 	if err != nil {
 		return
 	}
 
+	if IsOSTreeContainerReference(imgURL) {
+		return r.rebaseToContainer(ctx, imgURL, defaultDeployment, lockFinalization)
+	}
+
+	if err = verifyOSImageSignature(imgURL); err != nil {
+		return
+	}
+
 	previousPivot := ""
-	if len(defaultDeployment.CustomOrigin) > 0 {
+	if defaultDeployment.ContainerImageReference != "" {
+		glog.Infof("Previous container image: %s", defaultDeployment.ContainerImageReference)
+	} else if len(defaultDeployment.CustomOrigin) > 0 {
 		if strings.HasPrefix(defaultDeployment.CustomOrigin[0], "pivot://") {
 			previousPivot = defaultDeployment.CustomOrigin[0][len("pivot://"):]
 			glog.Infof("Previous pivot: %s", previousPivot)
@@ -184,24 +685,12 @@ func (r *RpmOstreeClient) Rebase(imgURL, osImageContentDir string) (changed bool
 		glog.Info("Current origin is not custom")
 	}
 
-	var imageData *types.ImageInspectInfo
-	if imageData, err = imageInspect(imgURL); err != nil {
-		if err != nil {
-			var podmanImgData *imageInspection
-			glog.Infof("Falling back to using podman inspect")
-			if podmanImgData, err = podmanInspect(imgURL); err != nil {
-				return
-			}
-			ostreeCsum = podmanImgData.Labels["com.coreos.ostree-commit"]
-			ostreeVersion = podmanImgData.Labels["version"]
-		}
-	} else {
-		ostreeCsum = imageData.Labels["com.coreos.ostree-commit"]
-		ostreeVersion = imageData.Labels["version"]
+	var labels map[string]string
+	if labels, err = inspectOSImageLabels(imgURL); err != nil {
+		return
 	}
-	// We may have pulled in OSContainer image as fallback during podmanCopy() or podmanInspect()
-	defer exec.Command("podman", "rmi", imgURL).Run()
-
+	ostreeCsum = labels["com.coreos.ostree-commit"]
+	ostreeVersion = labels["version"]
 	repo := fmt.Sprintf("%s/srv/repo", osImageContentDir)
 
 	// Now we need to figure out the commit to rebase to
@@ -215,7 +704,7 @@ func (r *RpmOstreeClient) Rebase(imgURL, osImageContentDir string) (changed bool
 	} else {
 		glog.Infof("No com.coreos.ostree-commit label found in metadata! Inspecting...")
 		var refText []byte
-		refText, err = runGetOut("ostree", "refs", "--repo", repo)
+		refText, err = runGetOutContext(ctx, "ostree", "refs", "--repo", repo)
 		if err != nil {
 			return
 		}
@@ -223,7 +712,7 @@ func (r *RpmOstreeClient) Rebase(imgURL, osImageContentDir string) (changed bool
 		if len(refs) == 1 {
 			glog.Infof("Using ref %s", refs[0])
 			var ostreeCsumBytes []byte
-			ostreeCsumBytes, err = runGetOut("ostree", "rev-parse", "--repo", repo, refs[0])
+			ostreeCsumBytes, err = runGetOutContext(ctx, "ostree", "rev-parse", "--repo", repo, refs[0])
 			if err != nil {
 				return
 			}
@@ -244,8 +733,14 @@ func (r *RpmOstreeClient) Rebase(imgURL, osImageContentDir string) (changed bool
 
 	args := []string{"rebase", "--experimental", fmt.Sprintf("%s:%s", repo, ostreeCsum),
 		"--custom-origin-url", customURL, "--custom-origin-description", "Managed by machine-config-operator"}
+	if lockFinalization {
+		args = append(args, "--lock-finalization")
+	}
 
-	if _, err = runGetOut("rpm-ostree", args...); err != nil {
+	if _, err = runRpmOstree(ctx, args...); err != nil {
+		return
+	}
+	if err = r.verifyStagedDeployment(ctx, ostreeCsum); err != nil {
 		return
 	}
 
@@ -253,13 +748,88 @@ func (r *RpmOstreeClient) Rebase(imgURL, osImageContentDir string) (changed bool
 	return
 }
 
+// verifyStagedDeployment checks that the deployment booted next is of the OSTree commit csum, so
+// a deployment staged wrong fails the update rather than degrading the node after the reboot
+func (r *RpmOstreeClient) verifyStagedDeployment(ctx context.Context, csum string) error {
+	rosState, err := r.loadStatus(ctx)
+	if err != nil {
+		return err
+	}
+	booted, staged := stagedDeployment(rosState.Deployments)
+	if staged == nil {
+		staged = booted
+	}
+	if staged == nil {
+		return fmt.Errorf("not currently booted in a deployment")
+	}
+	checksum := staged.BaseChecksum
+	if checksum == "" {
+		checksum = staged.Checksum
+	}
+	if checksum != csum {
+		return fmt.Errorf("staged deployment %s is of commit %s, expected %s", staged.ID, checksum, csum)
+	}
+	glog.Infof("Verified staged deployment %s is of commit %s", staged.ID, csum)
+	return nil
+}
+
 // runGetOut executes a command, logging it, and return the stdout output.
 func runGetOut(command string, args ...string) ([]byte, error) {
+	return runGetOutContext(context.Background(), command, args...)
+}
+
+// runGetOutContext executes a command like runGetOut, terminating it once ctx is done: it is sent
+// SIGTERM, and killed if it doesn't exit within commandTerminationGracePeriod.
+func runGetOutContext(ctx context.Context, command string, args ...string) ([]byte, error) {
 	glog.Infof("Running captured: %s %s", command, strings.Join(args, " "))
-	cmd := exec.Command(command, args...)
-	rawOut, err := cmd.CombinedOutput()
+	cmd := hostCommander.Command(command, args...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Start(); err != nil {
+		return nil, errors.Wrapf(err, "error running %s %s", command, strings.Join(args, " "))
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		glog.Warningf("Terminating %s %s: %v", command, strings.Join(args, " "), ctx.Err())
+		cmd.Process.Signal(syscall.SIGTERM)
+		select {
+		case <-done:
+		case <-time.After(commandTerminationGracePeriod):
+			cmd.Process.Kill()
+			<-done
+		}
+		err = ctx.Err()
+	}
 	if err != nil {
-		return nil, errors.Wrapf(err, "error running %s %s: %s", command, strings.Join(args, " "), string(rawOut))
+		return nil, errors.Wrapf(err, "error running %s %s: %s", command, strings.Join(args, " "), out.String())
+	}
+	return out.Bytes(), nil
+}
+
+// runRpmOstree runs rpm-ostree with the timeout of the operation on top of ctx. The transaction of
+// a command timing out or cancelled is cancelled, rpm-ostreed would go on with it otherwise and
+// refuse the next ones.
+func runRpmOstree(ctx context.Context, args ...string) ([]byte, error) {
+	timeout := rpmOstreeTransactionTimeout
+	if len(args) > 0 && (args[0] == "status" || args[0] == "db") {
+		timeout = rpmOstreeQueryTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	output, err := runGetOutContext(ctx, "rpm-ostree", args...)
+	if ctx.Err() != nil && timeout == rpmOstreeTransactionTimeout {
+		// ctx is done already, the cancellation gets a context of its own
+		cancelCtx, cancelCancel := context.WithTimeout(context.Background(), rpmOstreeQueryTimeout)
+		defer cancelCancel()
+		if _, cancelErr := runGetOutContext(cancelCtx, "rpm-ostree", "cancel"); cancelErr != nil {
+			glog.Warningf("Failed to cancel the rpm-ostree transaction: %v", cancelErr)
+		}
 	}
-	return rawOut, nil
+	return output, err
 }
@@ -1,5 +1,21 @@
 package daemon
 
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containers/image/v5/types"
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
 /*
  * This file contains test code for the rpm-ostree client. It is meant to be used when
  * testing the daemon and mocking the responses that would normally be executed by the
@@ -22,7 +38,7 @@ type RpmOstreeClientMock struct {
 
 // GetBootedOSImageURL implements a test version of RpmOStreeClients GetBootedOSImageURL.
 // It returns an OsImageURL, Version, and Error as defined in GetBootedOSImageURLReturns in order.
-func (r RpmOstreeClientMock) GetBootedOSImageURL() (string, string, error) {
+func (r RpmOstreeClientMock) GetBootedOSImageURL(context.Context) (string, string, error) {
 	returnValues := r.GetBootedOSImageURLReturns[0]
 	if len(r.GetBootedOSImageURLReturns) > 1 {
 		r.GetBootedOSImageURLReturns = r.GetBootedOSImageURLReturns[1:]
@@ -31,14 +47,255 @@ func (r RpmOstreeClientMock) GetBootedOSImageURL() (string, string, error) {
 }
 
 // PullAndRebase is a mock
-func (r RpmOstreeClientMock) Rebase(string, string) (bool, error) {
+func (r RpmOstreeClientMock) Rebase(context.Context, string, string) (bool, error) {
 	return false, nil
 }
 
-func (r RpmOstreeClientMock) GetStatus() (string, error) {
+func (r RpmOstreeClientMock) GetStatus(context.Context) (string, error) {
 	return "rpm-ostree mock: blah blah some status here", nil
 }
 
-func (r RpmOstreeClientMock) GetBootedDeployment() (*RpmOstreeDeployment, error) {
+func (r RpmOstreeClientMock) GetBootedDeployment(context.Context) (*RpmOstreeDeployment, error) {
 	return &RpmOstreeDeployment{}, nil
 }
+
+// GetState is a mock
+func (r RpmOstreeClientMock) GetState(context.Context) (*RpmOstreeState, error) {
+	return &RpmOstreeState{}, nil
+}
+
+// Rollback is a mock
+func (r RpmOstreeClientMock) Rollback(context.Context, string) (bool, error) {
+	return false, nil
+}
+
+// GetDeploymentDiff is a mock
+func (r RpmOstreeClientMock) GetDeploymentDiff(context.Context) ([]PackageChange, error) {
+	return nil, nil
+}
+
+// GetRollbackDeployment is a mock
+func (r RpmOstreeClientMock) GetRollbackDeployment(context.Context) (*RpmOstreeDeployment, error) {
+	return nil, nil
+}
+
+// InstallPackages is a mock
+func (r RpmOstreeClientMock) InstallPackages(context.Context, []string) error {
+	return nil
+}
+
+// RemovePackages is a mock
+func (r RpmOstreeClientMock) RemovePackages(context.Context, []string) error {
+	return nil
+}
+
+// StageRebase is a mock
+func (r RpmOstreeClientMock) StageRebase(context.Context, string, string) (bool, error) {
+	return false, nil
+}
+
+// GetPrestagedOSImageURL is a mock
+func (r RpmOstreeClientMock) GetPrestagedOSImageURL(context.Context) (string, error) {
+	return "", nil
+}
+
+// ApplyLive is a mock
+func (r RpmOstreeClientMock) ApplyLive(context.Context) error {
+	return nil
+}
+
+// PinCurrentDeployment is a mock
+func (r RpmOstreeClientMock) PinCurrentDeployment(context.Context) (string, bool, error) {
+	return "", false, nil
+}
+
+// UnpinDeployment is a mock
+func (r RpmOstreeClientMock) UnpinDeployment(context.Context, string) error {
+	return nil
+}
+
+// SwitchKernel is a mock
+func (r RpmOstreeClientMock) SwitchKernel(context.Context, string) error {
+	return nil
+}
+
+func TestCanRollbackTo(t *testing.T) {
+	deployment := func(imgURL string, booted bool, packages ...string) RpmOstreeDeployment {
+		return RpmOstreeDeployment{Booted: booted, CustomOrigin: []string{"pivot://" + imgURL}, RequestedPackages: packages}
+	}
+	tests := []struct {
+		name        string
+		deployments []RpmOstreeDeployment
+		expected    bool
+	}{{
+		name:        "previous deployment",
+		deployments: []RpmOstreeDeployment{deployment("os:new", true, "usbguard"), deployment("os:old", false, "usbguard")},
+		expected:    true,
+	}, {
+		name:        "other image",
+		deployments: []RpmOstreeDeployment{deployment("os:new", true), deployment("os:older", false)},
+	}, {
+		name:        "other packages",
+		deployments: []RpmOstreeDeployment{deployment("os:new", true, "usbguard"), deployment("os:old", false)},
+	}, {
+		name: "other kernel",
+		deployments: []RpmOstreeDeployment{
+			{Booted: true, CustomOrigin: []string{"pivot://os:new"}, RequestedPackages: []string{"kernel-rt-core"}, RequestedBaseRemovals: []string{"kernel", "kernel-core"}},
+			{CustomOrigin: []string{"pivot://os:old"}, RequestedPackages: []string{"kernel-rt-core"}},
+		},
+	}, {
+		name:        "staged deployment",
+		deployments: []RpmOstreeDeployment{deployment("os:newer", false), deployment("os:new", true), deployment("os:old", false)},
+	}, {
+		name: "previous container image",
+		deployments: []RpmOstreeDeployment{
+			{Booted: true, ContainerImageReference: "ostree-unverified-registry:os:new"},
+			{ContainerImageReference: "os:old"},
+		},
+		expected: true,
+	}, {
+		name:        "no rollback deployment",
+		deployments: []RpmOstreeDeployment{deployment("os:new", true)},
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := canRollbackTo(test.deployments, "os:old"); got != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestCanRollbackToAny(t *testing.T) {
+	deployments := []RpmOstreeDeployment{
+		{Booted: true, CustomOrigin: []string{"pivot://os:new"}, RequestedPackages: []string{"usbguard"}},
+		{CustomOrigin: []string{"pivot://os:older"}},
+	}
+	assert.True(t, canRollbackTo(deployments, ""))
+	assert.False(t, canRollbackTo(deployments[:1], ""))
+	assert.Equal(t, "os:older", deploymentOSImageURL(rollbackDeployment(deployments)))
+}
+
+func TestRollbackKargs(t *testing.T) {
+	recorder := helpers.NewCommandRecorder()
+	recorder.Respond(`{"deployments": [
+		{"id": "rhcos-1", "booted": true, "custom-origin": ["pivot://os:new"]},
+		{"id": "rhcos-0", "custom-origin": ["pivot://os:old"]}
+	]}`, 0, "rpm-ostree", "status", "--json")
+	recorder.Respond("root=UUID=aaa rw nosmt\n", 0, "rpm-ostree", "kargs", "--deploy-index=0")
+	recorder.Respond("root=UUID=aaa rw\n", 0, "rpm-ostree", "kargs", "--deploy-index=1")
+	defer setHost(recorder, nil, nil)()
+	client := &RpmOstreeClient{}
+
+	// the previous deployment boots with other kernel arguments
+	rolledBack, err := client.Rollback(context.Background(), "os:old")
+	require.Nil(t, err)
+	assert.False(t, rolledBack)
+	recorder.AssertNotCalled(t, "rpm-ostree", "rollback")
+
+	// they aren't checked when rolling back on request
+	rolledBack, err = client.Rollback(context.Background(), "")
+	require.Nil(t, err)
+	assert.True(t, rolledBack)
+	recorder.AssertCalled(t, "rpm-ostree", "rollback")
+
+	recorder.Reset()
+	recorder.Respond("root=UUID=aaa rw nosmt\n", 0, "rpm-ostree", "kargs", "--deploy-index=1")
+	rolledBack, err = client.Rollback(context.Background(), "os:old")
+	require.Nil(t, err)
+	assert.True(t, rolledBack)
+	recorder.AssertCalled(t, "rpm-ostree", "rollback")
+}
+
+func TestOSImagePullSpec(t *testing.T) {
+	tests := []struct {
+		imgURL    string
+		container bool
+		pullSpec  string
+	}{
+		{imgURL: "quay.io/openshift/os@sha256:aaa", pullSpec: "quay.io/openshift/os@sha256:aaa"},
+		{imgURL: "ostree-unverified-registry:quay.io/openshift/os@sha256:aaa", container: true, pullSpec: "quay.io/openshift/os@sha256:aaa"},
+		{imgURL: "ostree-unverified-image:docker://quay.io/openshift/os@sha256:aaa", container: true, pullSpec: "quay.io/openshift/os@sha256:aaa"},
+		{imgURL: "ostree-image-signed:docker://quay.io/openshift/os@sha256:aaa", container: true, pullSpec: "quay.io/openshift/os@sha256:aaa"},
+		{imgURL: "ostree-remote-registry:rhcos:quay.io/openshift/os@sha256:aaa", container: true, pullSpec: "quay.io/openshift/os@sha256:aaa"},
+		{imgURL: "ostree-remote-image:rhcos:docker://quay.io/openshift/os@sha256:aaa", container: true, pullSpec: "quay.io/openshift/os@sha256:aaa"},
+	}
+	for _, test := range tests {
+		if got := IsOSTreeContainerReference(test.imgURL); got != test.container {
+			t.Errorf("IsOSTreeContainerReference(%s): got %v, want %v", test.imgURL, got, test.container)
+		}
+		if got := osImagePullSpec(test.imgURL); got != test.pullSpec {
+			t.Errorf("osImagePullSpec(%s): got %s, want %s", test.imgURL, got, test.pullSpec)
+		}
+	}
+}
+
+func TestDeploymentOSImageURL(t *testing.T) {
+	tests := []struct {
+		deployment RpmOstreeDeployment
+		expected   string
+	}{
+		{deployment: RpmOstreeDeployment{CustomOrigin: []string{"pivot://quay.io/openshift/os@sha256:aaa"}}, expected: "quay.io/openshift/os@sha256:aaa"},
+		{deployment: RpmOstreeDeployment{ContainerImageReference: "ostree-unverified-registry:quay.io/openshift/os@sha256:aaa"}, expected: "ostree-unverified-registry:quay.io/openshift/os@sha256:aaa"},
+		{deployment: RpmOstreeDeployment{CustomOrigin: []string{"https://example.com"}}},
+		{deployment: RpmOstreeDeployment{}},
+	}
+	for _, test := range tests {
+		if got := deploymentOSImageURL(&test.deployment); got != test.expected {
+			t.Errorf("deploymentOSImageURL(%+v): got %s, want %s", test.deployment, got, test.expected)
+		}
+	}
+}
+
+func TestOSImagePullSources(t *testing.T) {
+	dir, err := ioutil.TempDir("", "registries")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	conf := filepath.Join(dir, "registries.conf")
+	require.Nil(t, ioutil.WriteFile(conf, []byte(`unqualified-search-registries = []
+
+[[registry]]
+  prefix = ""
+  location = "quay.io/openshift-release-dev/ocp-v4.0-art-dev"
+  mirror-by-digest-only = true
+
+  [[registry.mirror]]
+    location = "mirror.example.com/ocp/release"
+`), 0644))
+	sys := &types.SystemContext{SystemRegistriesConfPath: conf}
+
+	dgst := "@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	assert.Equal(t, []string{
+		"mirror.example.com/ocp/release" + dgst,
+		"quay.io/openshift-release-dev/ocp-v4.0-art-dev" + dgst,
+	}, osImagePullSources(sys, "quay.io/openshift-release-dev/ocp-v4.0-art-dev"+dgst))
+
+	// the mirrors are only used for digests
+	assert.Equal(t, []string{"quay.io/openshift-release-dev/ocp-v4.0-art-dev:latest"}, osImagePullSources(sys, "quay.io/openshift-release-dev/ocp-v4.0-art-dev:latest"))
+	assert.Equal(t, []string{"registry.example.com/os" + dgst}, osImagePullSources(sys, "registry.example.com/os"+dgst))
+}
+
+func TestImageDigest(t *testing.T) {
+	dgst := "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	assert.Equal(t, dgst, imageDigest("quay.io/openshift/os@"+dgst).String())
+	assert.Empty(t, imageDigest("quay.io/openshift/os:latest"))
+	assert.Empty(t, imageDigest("not a valid reference"))
+}
+
+func TestImageInspectionCache(t *testing.T) {
+	c := &imageInspectionCache{labels: map[digest.Digest]map[string]string{}}
+	for i := 0; i <= maxCachedImageInspections; i++ {
+		c.add(digest.FromString(fmt.Sprint(i)), map[string]string{"version": fmt.Sprint(i)})
+	}
+
+	// the oldest inspection was evicted
+	_, ok := c.get(digest.FromString("0"))
+	assert.False(t, ok)
+	labels, ok := c.get(digest.FromString("1"))
+	assert.True(t, ok)
+	assert.Equal(t, "1", labels["version"])
+	labels, ok = c.get(digest.FromString(fmt.Sprint(maxCachedImageInspections)))
+	assert.True(t, ok)
+	assert.Equal(t, fmt.Sprint(maxCachedImageInspections), labels["version"])
+	assert.Len(t, c.labels, maxCachedImageInspections)
+}
@@ -0,0 +1,146 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+// scheduledRebootPollInterval is how often the daemon checks whether the update a scheduled reboot
+// applies was aborted
+const scheduledRebootPollInterval = 10 * time.Second
+
+// gracefulReboot returns the delay and wall message the node controller set for the reboots of
+// the node, a zero delay if the node reboots at once
+func (dn *Daemon) gracefulReboot() (time.Duration, string) {
+	if dn.node == nil || dn.nodeWriter == nil {
+		return 0, ""
+	}
+	value := dn.node.Annotations[constants.RebootDelayAnnotationKey]
+	if value == "" {
+		return 0, ""
+	}
+	delay, err := time.ParseDuration(value)
+	if err != nil {
+		glog.Warningf("Ignoring invalid %s annotation %q: %v", constants.RebootDelayAnnotationKey, value, err)
+		return 0, ""
+	}
+	return delay, dn.node.Annotations[constants.RebootWallMessageAnnotationKey]
+}
+
+// callLogind calls method of the systemd-logind manager over DBus
+func callLogind(method string, signatureAndArgs ...string) error {
+	args := append([]string{"call", "org.freedesktop.login1", "/org/freedesktop/login1", "org.freedesktop.login1.Manager", method}, signatureAndArgs...)
+	if out, err := hostCommander.Command("busctl", args...).CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "calling logind %s: %s", method, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// scheduleReboot has systemd-logind reboot the node after delay, broadcasting wallMessage to the
+// logged in users, and records when on the node. A reboot scheduled before the daemon restarted
+// is kept rather than pushed back.
+func (dn *Daemon) scheduleReboot(delay time.Duration, wallMessage string) (time.Time, error) {
+	now := hostClock.Now()
+	when := now.Add(delay)
+	if scheduled, err := time.Parse(time.RFC3339, dn.node.Annotations[constants.ScheduledRebootAnnotationKey]); err == nil && scheduled.After(now) {
+		when = scheduled
+	}
+
+	if wallMessage != "" {
+		if err := callLogind("SetWallMessage", "sb", wallMessage, "true"); err != nil {
+			glog.Warningf("Failed to set the wall message of the reboot: %v", err)
+		}
+	}
+	if err := callLogind("ScheduleShutdown", "st", "reboot", strconv.FormatInt(when.UnixNano()/int64(time.Microsecond), 10)); err != nil {
+		return time.Time{}, err
+	}
+
+	dn.logSystem("Reboot scheduled at %s", when.UTC().Format(time.RFC3339))
+	if dn.recorder != nil {
+		dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeNormal, "RebootScheduled", "Node will reboot at %s", when.UTC().Format(time.RFC3339))
+	}
+	if err := dn.nodeWriter.SetScheduledReboot(when.UTC().Format(time.RFC3339), dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name); err != nil {
+		glog.Errorf("Failed to record the scheduled reboot: %v", err)
+	}
+	return when, nil
+}
+
+// waitScheduledReboot waits for the reboot scheduled at when. The reboot applying an update is
+// cancelled if the node is targeted at another config before it, the error returned then rolling
+// the update back. The update isn't done until the node reboots: updateActiveLock is held on
+// purpose while waiting, so neither another update, the config drift checks nor a termination
+// of the daemon interleave with the pending one.
+func (dn *Daemon) waitScheduledReboot(ctx context.Context, reason rebootReason, when time.Time) error {
+	for deadline := when.Add(defaultRebootTimeout); hostClock.Now().Before(deadline); {
+		<-hostClock.After(scheduledRebootPollInterval)
+		if reason.RequestedBy != rebootRequestedByUpdate || !hostClock.Now().Before(when) {
+			continue
+		}
+		node, err := dn.nodeLister.Get(dn.name)
+		if err != nil {
+			glog.Warningf("Failed to get the node while waiting for the scheduled reboot: %v", err)
+			continue
+		}
+		if desired := node.Annotations[constants.DesiredMachineConfigAnnotationKey]; desired != "" && desired != reason.Config {
+			return dn.cancelScheduledReboot(ctx, reason, desired)
+		}
+	}
+
+	MCDRebootErr.WithLabelValues(dn.node.Name, "reboot failed", "this error should be unreachable, something is seriously wrong").SetToCurrentTime()
+	return fmt.Errorf("reboot failed; this error should be unreachable, something is seriously wrong")
+}
+
+// cancelScheduledReboot cancels the reboot into the config of reason, the node now targeting
+// desired, and rolls back the pending config along with the OS update staged for it
+func (dn *Daemon) cancelScheduledReboot(ctx context.Context, reason rebootReason, desired string) error {
+	if err := callLogind("CancelScheduledShutdown"); err != nil {
+		return errors.Wrap(err, "cancelling the scheduled reboot")
+	}
+	if err := dn.discardStagedDeployment(ctx); err != nil {
+		return err
+	}
+	dn.logSystem("Cancelled the reboot into config %s, the node now targets %s", reason.Config, desired)
+	if dn.recorder != nil {
+		dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeNormal, "RebootCancelled", "Cancelled the reboot into config %s, the node now targets %s", reason.Config, desired)
+	}
+	if err := dn.nodeWriter.SetScheduledReboot("", dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name); err != nil {
+		glog.Errorf("Failed to clear the scheduled reboot: %v", err)
+	}
+	pending := &mcfgv1.MachineConfig{ObjectMeta: metav1.ObjectMeta{Name: reason.Config}}
+	if out, err := dn.storePendingState(pending, 0); err != nil {
+		return errors.Wrapf(err, "error rolling back pending config: %s", string(out))
+	}
+	return fmt.Errorf("reboot into config %s cancelled, the node now targets %s", reason.Config, desired)
+}
+
+// discardStagedDeployment removes the deployment staged for the cancelled reboot, the booted one
+// being booted next again. A previous deployment rolled back to isn't staged, rolling back the
+// update restores it.
+func (dn *Daemon) discardStagedDeployment(ctx context.Context) error {
+	if !dn.os.IsCoreOSVariant() {
+		return nil
+	}
+	state, err := dn.NodeUpdaterClient.GetState(ctx)
+	if err != nil {
+		return errors.Wrap(err, "getting the deployments")
+	}
+	if len(state.Deployments) == 0 || !state.Deployments[0].Staged {
+		return nil
+	}
+	glog.Infof("Discarding the deployment %s staged for the cancelled reboot", state.Deployments[0].ID)
+	if err := removePendingDeployment(ctx); err != nil {
+		return errors.Wrap(err, "error removing staged deployment")
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package daemon
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	corev1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func TestScheduledReboot(t *testing.T) {
+	recorder := helpers.NewCommandRecorder()
+	recorder.Respond(`{"deployments": [
+		{"id": "rhcos-1", "staged": true},
+		{"id": "rhcos-0", "booted": true}
+	]}`, 0, "rpm-ostree", "status", "--json")
+	clk := helpers.NewFakeClock(time.Unix(1600000000, 0))
+	defer setHost(recorder, clk, nil)()
+
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0", Annotations: map[string]string{
+		constants.DesiredMachineConfigAnnotationKey: "rendered-worker-new",
+		constants.RebootDelayAnnotationKey:          "5m0s",
+		constants.RebootWallMessageAnnotationKey:    "Rebooting for the cluster update",
+	}}}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	require.Nil(t, indexer.Add(node))
+	nodeWriter := newNodeWriter(nil)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go nodeWriter.Run(stopCh)
+	kubeClient := k8sfake.NewSimpleClientset(node)
+	dn := &Daemon{
+		name:                  node.Name,
+		node:                  node,
+		kubeClient:            kubeClient,
+		nodeLister:            corev1lister.NewNodeLister(indexer),
+		nodeWriter:            nodeWriter,
+		loggerSupportsJournal: true,
+		os:                    OperatingSystem{ID: "rhcos"},
+		NodeUpdaterClient:     &RpmOstreeClient{},
+	}
+	scheduledReboot := func() string {
+		node, err := kubeClient.CoreV1().Nodes().Get(context.TODO(), node.Name, metav1.GetOptions{})
+		require.Nil(t, err)
+		return node.Annotations[constants.ScheduledRebootAnnotationKey]
+	}
+
+	delay, wallMessage := dn.gracefulReboot()
+	assert.Equal(t, 5*time.Minute, delay)
+	assert.Equal(t, "Rebooting for the cluster update", wallMessage)
+
+	when, err := dn.scheduleReboot(delay, wallMessage)
+	require.Nil(t, err)
+	assert.Equal(t, clk.Now().Add(5*time.Minute), when)
+	recorder.AssertCalled(t, "busctl", "call", "org.freedesktop.login1", "/org/freedesktop/login1", "org.freedesktop.login1.Manager",
+		"SetWallMessage", "sb", "Rebooting for the cluster update", "true")
+	recorder.AssertCalled(t, "busctl", "call", "org.freedesktop.login1", "/org/freedesktop/login1", "org.freedesktop.login1.Manager",
+		"ScheduleShutdown", "st", "reboot", strconv.FormatInt(when.UnixNano()/int64(time.Microsecond), 10))
+	assert.Equal(t, when.UTC().Format(time.RFC3339), scheduledReboot())
+
+	// the reboot already scheduled is kept when the daemon schedules it again
+	dn.node.Annotations[constants.ScheduledRebootAnnotationKey] = scheduledReboot()
+	clk.Advance(time.Minute)
+	again, err := dn.scheduleReboot(delay, wallMessage)
+	require.Nil(t, err)
+	assert.True(t, when.Equal(again))
+
+	// the update is aborted, the node being targeted at another config
+	updated := node.DeepCopy()
+	updated.Annotations[constants.DesiredMachineConfigAnnotationKey] = "rendered-worker-old"
+	require.Nil(t, indexer.Update(updated))
+	err = dn.waitScheduledReboot(context.Background(), rebootReason{Config: "rendered-worker-new", RequestedBy: rebootRequestedByUpdate}, when)
+	assert.EqualError(t, err, "reboot into config rendered-worker-new cancelled, the node now targets rendered-worker-old")
+	recorder.AssertCalled(t, "busctl", "call", "org.freedesktop.login1", "/org/freedesktop/login1", "org.freedesktop.login1.Manager", "CancelScheduledShutdown")
+	recorder.AssertCalled(t, "rpm-ostree", "cleanup", "-p")
+	recorder.AssertCalled(t, "logger", "--journald")
+	assert.Empty(t, scheduledReboot())
+	assert.True(t, clk.Now().Before(when))
+}
+
+func TestDiscardStagedDeployment(t *testing.T) {
+	recorder := helpers.NewCommandRecorder()
+	defer setHost(recorder, nil, nil)()
+	dn := &Daemon{os: OperatingSystem{ID: "rhcos"}, NodeUpdaterClient: &RpmOstreeClient{}}
+
+	// the previous deployment rolled back to is restored by rolling back the update
+	recorder.Respond(`{"deployments": [
+		{"id": "rhcos-0"},
+		{"id": "rhcos-1", "booted": true}
+	]}`, 0, "rpm-ostree", "status", "--json")
+	require.Nil(t, dn.discardStagedDeployment(context.Background()))
+	recorder.AssertNotCalled(t, "rpm-ostree", "cleanup", "-p")
+
+	recorder.Respond(`{"deployments": [
+		{"id": "rhcos-2", "staged": true},
+		{"id": "rhcos-1", "booted": true}
+	]}`, 0, "rpm-ostree", "status", "--json")
+	require.Nil(t, dn.discardStagedDeployment(context.Background()))
+	recorder.AssertCalled(t, "rpm-ostree", "cleanup", "-p")
+}
+
+func TestGracefulRebootUnset(t *testing.T) {
+	dn := &Daemon{node: &corev1.Node{}, nodeWriter: newNodeWriter(nil)}
+	delay, _ := dn.gracefulReboot()
+	assert.Zero(t, delay)
+
+	dn.node.Annotations = map[string]string{constants.RebootDelayAnnotationKey: "soon"}
+	delay, _ = dn.gracefulReboot()
+	assert.Zero(t, delay)
+}
@@ -3,6 +3,7 @@ package daemon
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -10,10 +11,10 @@ import (
 	"os/user"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
-	"time"
 
 	"github.com/clarketm/json"
 	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
@@ -23,6 +24,7 @@ import (
 	"github.com/vincent-petithory/dataurl"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/uuid"
 
@@ -46,6 +48,13 @@ const (
 	fipsFile              = "/proc/sys/crypto/fips_enabled"
 	extensionsRepo        = "/etc/yum.repos.d/coreos-extensions.repo"
 	osImageContentBaseDir = "/run/mco-machine-os-content/"
+	// preloadedOSImagesDir holds the OS images loaded on the node ahead of their update, e.g. on
+	// edge and disconnected nodes, as OCI layouts named after the hex of their digest
+	preloadedOSImagesDir = "/var/lib/mco/os-images"
+	// osImageContentDirPrefix starts the names of the directories OS images are extracted in
+	osImageContentDirPrefix = "os-content-"
+	// osImageContentExtractedFile marks the OS image content extracted completely
+	osImageContentExtractedFile = ".mco-extracted"
 
 	// These are the actions for a node to take after applying config changes. (e.g. a new machineconfig is applied)
 	// "None" means no special action needs to be taken. A drain will still happen.
@@ -56,6 +65,12 @@ const (
 	// Crio reload will happen when /etc/containers/registries.conf is changed. This will cause
 	// a "systemctl reload crio"
 	postConfigChangeActionReloadCrio = "reload crio"
+	// Crio restart will happen when the default runtime crio drop-in is changed. A reload does not
+	// pick up a new default runtime, so this will cause a "systemctl restart crio"
+	postConfigChangeActionRestartCrio = "restart crio"
+	// Apply live will happen when extensions are only added. Their packages are applied to the
+	// booted deployment with "rpm-ostree ex apply-live", the node reboots into them later.
+	postConfigChangeActionApplyLive = "apply live"
 )
 
 func writeFileAtomicallyWithDefaults(fpath string, b []byte) error {
@@ -106,14 +121,43 @@ func reloadService(name string) error {
 	return err
 }
 
+// restartService restarts a systemd unit and makes sure it came back up active
+func restartService(name string) error {
+	if _, err := runGetOut("systemctl", "restart", name); err != nil {
+		return err
+	}
+	if _, err := runGetOut("systemctl", "is-active", name); err != nil {
+		return fmt.Errorf("%s is not active after restart: %v", name, err)
+	}
+	return nil
+}
+
 // performPostConfigChangeAction takes action based on what postConfigChangeAction has been asked.
 // For non-reboot action, it applies configuration, updates node's config and state.
 // In the end uncordon node to schedule workload.
 // If at any point an error occurs, we reboot the node so that node has correct configuration.
-func (dn *Daemon) performPostConfigChangeAction(postConfigChangeActions []string, configName string) error {
+func (dn *Daemon) performPostConfigChangeAction(ctx context.Context, postConfigChangeActions []string, configName string, changes []string) error {
 	if ctrlcommon.InSlice(postConfigChangeActionReboot, postConfigChangeActions) {
 		dn.logSystem("Rebooting node")
-		return dn.reboot(fmt.Sprintf("Node will reboot into config %s", configName))
+		return dn.reboot(ctx, rebootReason{
+			Message:     fmt.Sprintf("Node will reboot into config %s", configName),
+			Config:      configName,
+			Changes:     changes,
+			RequestedBy: rebootRequestedByUpdate,
+		})
+	}
+
+	if ctrlcommon.InSlice(postConfigChangeActionApplyLive, postConfigChangeActions) {
+		if err := dn.applyLive(ctx, configName); err != nil {
+			glog.Warningf("Failed to apply the OS changes live, rebooting instead: %v", err)
+			dn.logSystem("Rebooting node")
+			return dn.reboot(ctx, rebootReason{
+				Message:     fmt.Sprintf("Node will reboot into config %s", configName),
+				Config:      configName,
+				Changes:     changes,
+				RequestedBy: rebootRequestedByUpdate,
+			})
+		}
 	}
 
 	if ctrlcommon.InSlice(postConfigChangeActionNone, postConfigChangeActions) {
@@ -139,6 +183,22 @@ func (dn *Daemon) performPostConfigChangeAction(postConfigChangeActions []string
 		dn.logSystem("%s config reloaded successfully! Desired config %s has been applied, skipping reboot", serviceName, configName)
 	}
 
+	if ctrlcommon.InSlice(postConfigChangeActionRestartCrio, postConfigChangeActions) {
+		serviceName := "crio"
+
+		if err := restartService(serviceName); err != nil {
+			if dn.recorder != nil {
+				dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeWarning, "FailedServiceRestart", fmt.Sprintf("Restarting %s service failed. Error: %v", serviceName, err))
+			}
+			return fmt.Errorf("Could not apply update: restarting %s failed. Error: %v", serviceName, err)
+		}
+
+		if dn.recorder != nil {
+			dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeNormal, "SkipReboot", "Config changes do not require reboot. Service %s was restarted.", serviceName)
+		}
+		dn.logSystem("%s restarted successfully! Desired config %s has been applied, skipping reboot", serviceName, configName)
+	}
+
 	// We are here, which means reboot was not needed to apply the configuration.
 
 	// Get current state of node, in case of an error reboot
@@ -148,7 +208,7 @@ func (dn *Daemon) performPostConfigChangeAction(postConfigChangeActions []string
 	}
 
 	var inDesiredConfig bool
-	if inDesiredConfig, err = dn.updateConfigAndState(state); err != nil {
+	if inDesiredConfig, err = dn.updateConfigAndState(ctx, state); err != nil {
 		return fmt.Errorf("Could not apply update: setting node's state to Done failed. Error: %v", err)
 	}
 	if inDesiredConfig {
@@ -156,7 +216,7 @@ func (dn *Daemon) performPostConfigChangeAction(postConfigChangeActions []string
 	}
 
 	// currentConfig != desiredConfig, kick off an update
-	return dn.triggerUpdateWithMachineConfig(state.currentConfig, state.desiredConfig)
+	return dn.triggerUpdateWithMachineConfig(ctx, state.currentConfig, state.desiredConfig)
 }
 
 // finalizeBeforeReboot is the last step in an update() and then we take appropriate postConfigChangeAction.
@@ -234,25 +294,49 @@ func addExtensionsRepo(osImageContentDir string) error {
 // podmanRemove kills and removes a container
 func podmanRemove(cid string) {
 	// Ignore errors here
-	exec.Command("podman", "kill", cid).Run()
-	exec.Command("podman", "rm", "-f", cid).Run()
+	hostCommander.Command("podman", "kill", cid).Run()
+	hostCommander.Command("podman", "rm", "-f", cid).Run()
+}
+
+// osImagePulled returns whether the image is in the container storage of the host, e.g. because
+// the node pre-pulled it, loading it there if it is pre-loaded in preloadedOSImagesDir
+func osImagePulled(imgURL string) bool {
+	if hostCommander.Command("podman", "image", "exists", imgURL).Run() == nil {
+		return true
+	}
+	return loadPreloadedOSImage(imgURL)
+}
+
+// loadPreloadedOSImage copies the OS image pinned by digest from its OCI layout in
+// preloadedOSImagesDir, if there is one, into the container storage of the host, and returns
+// whether it did. The copy fails if the layout isn't of that digest.
+func loadPreloadedOSImage(imgURL string) bool {
+	dgst := imageDigest(imgURL)
+	if dgst == "" {
+		return false
+	}
+	dir := filepath.Join(preloadedOSImagesDir, dgst.Encoded())
+	if _, err := hostFileStater.Stat(dir); err != nil {
+		return false
+	}
+	glog.Infof("Loading the pre-loaded OS image %s from %s", imgURL, dir)
+	if _, err := runGetOut("skopeo", "copy", "oci:"+dir, "containers-storage:"+imgURL); err != nil {
+		glog.Warningf("Failed to load the pre-loaded OS image %s, pulling it: %v", imgURL, err)
+		return false
+	}
+	return true
 }
 
 func podmanCopy(imgURL, osImageContentDir string) (err error) {
 	// make sure that osImageContentDir doesn't exist
 	os.RemoveAll(osImageContentDir)
 
-	// Pull the container image
-	var authArgs []string
-	if _, err := os.Stat(kubeletAuthFile); err == nil {
-		authArgs = append(authArgs, "--authfile", kubeletAuthFile)
-	}
-	args := []string{"pull", "-q"}
-	args = append(args, authArgs...)
-	args = append(args, imgURL)
-	_, err = pivotutils.RunExtBackground(numRetriesNetCommands, "podman", args...)
-	if err != nil {
-		return
+	// Pull the container image unless it was pre-pulled, the pull secret is passed by hostCommander
+	if !osImagePulled(imgURL) {
+		_, err = pivotutils.RunExtBackground(numRetriesNetCommands, "podman", "pull", "-q", imgURL)
+		if err != nil {
+			return
+		}
 	}
 
 	// create a container
@@ -263,12 +347,13 @@ func podmanCopy(imgURL, osImageContentDir string) (err error) {
 		return
 	}
 
-	// only delete created container, we will delete container image later as we may need it for podmanInspect()
+	// the content is copied out of the image, it isn't needed any more
+	defer hostCommander.Command("podman", "rmi", imgURL).Run()
 	defer podmanRemove(containerName)
 
 	// copy the content from create container locally into a temp directory under /run/machine-os-content/
 	cid := strings.TrimSpace(string(cidBuf))
-	args = []string{"cp", fmt.Sprintf("%s:/", cid), osImageContentDir}
+	args := []string{"cp", fmt.Sprintf("%s:/", cid), osImageContentDir}
 	_, err = pivotutils.RunExtBackground(numRetriesNetCommands, "podman", args...)
 
 	// Set selinux context to var_run_t to avoid selinux denial
@@ -281,13 +366,16 @@ func podmanCopy(imgURL, osImageContentDir string) (err error) {
 	return
 }
 
-// ExtractOSImage extracts OS image content in a temporary directory under /run/machine-os-content/
-// and returns the path on successful extraction.
-// Note that since we do this in the MCD container, cluster proxy configuration must also be injected
-// into the container. See the MCD daemonset.
+// ExtractOSImage extracts OS image content in a directory under /run/mco-machine-os-content/
+// and returns the path on successful extraction. The content of an image pinned by digest is
+// extracted in a directory of that digest, and reused by the next extractions, e.g. when a failed
+// update is retried, until releaseOSImageContent removes it.
+// The cluster proxy configuration is injected by hostCommander.
 func ExtractOSImage(imgURL string) (osImageContentDir string, err error) {
+	imgURL = osImagePullSpec(imgURL)
+	// oc doesn't read the pull secret from REGISTRY_AUTH_FILE
 	var registryConfig []string
-	if _, err := os.Stat(kubeletAuthFile); err == nil {
+	if _, err := hostFileStater.Stat(kubeletAuthFile); err == nil {
 		registryConfig = append(registryConfig, "--registry-config", kubeletAuthFile)
 	}
 	if err = os.MkdirAll(osImageContentBaseDir, 0755); err != nil {
@@ -295,49 +383,166 @@ func ExtractOSImage(imgURL string) (osImageContentDir string, err error) {
 		return
 	}
 
-	if osImageContentDir, err = ioutil.TempDir(osImageContentBaseDir, "os-content-"); err != nil {
+	dgst := imageDigest(imgURL)
+	if dgst != "" {
+		osImageContentDir = filepath.Join(osImageContentBaseDir, osImageContentDirPrefix+dgst.Encoded())
+		if _, err = os.Stat(filepath.Join(osImageContentDir, osImageContentExtractedFile)); err == nil {
+			glog.Infof("Reusing OS image content of %s extracted in %s", imgURL, osImageContentDir)
+			return
+		}
+		// the partial content of a failed extraction
+		if err = os.RemoveAll(osImageContentDir); err != nil {
+			return
+		}
+	} else if osImageContentDir, err = ioutil.TempDir(osImageContentBaseDir, osImageContentDirPrefix); err != nil {
 		return
 	}
 
+	// the content of a failed extraction isn't left behind
+	defer func() {
+		if err != nil {
+			os.RemoveAll(osImageContentDir)
+			osImageContentDir = ""
+		} else if dgst != "" {
+			err = ioutil.WriteFile(filepath.Join(osImageContentDir, osImageContentExtractedFile), nil, defaultFilePermissions)
+		}
+	}()
+
 	if err = os.MkdirAll(osImageContentDir, 0755); err != nil {
 		err = fmt.Errorf("error creating directory %s: %v", osImageContentDir, err)
 		return
 	}
 
-	// Extract the image
-	args := []string{"image", "extract", "--path", "/:" + osImageContentDir}
-	args = append(args, registryConfig...)
-	args = append(args, imgURL)
-	if _, err = pivotutils.RunExtBackground(cmdRetriesCount, "oc", args...); err != nil {
-		// Workaround fixes for the environment where oc image extract fails.
-		// See https://bugzilla.redhat.com/show_bug.cgi?id=1862979
-		glog.Infof("Falling back to using podman cp to fetch OS image content")
-		if err = podmanCopy(imgURL, osImageContentDir); err != nil {
+	// The image pre-pulled ahead of the update doesn't need downloading again
+	if osImagePulled(imgURL) {
+		glog.Infof("Copying OS image content from the pre-pulled %s", imgURL)
+		err = podmanCopy(imgURL, osImageContentDir)
+		return
+	}
+
+	// Extract the image, from its mirrors first as oc doesn't read registries.conf
+	for _, source := range osImagePullSources(nil, imgURL) {
+		args := []string{"image", "extract", "--path", "/:" + osImageContentDir}
+		args = append(args, registryConfig...)
+		args = append(args, source)
+		if _, err = pivotutils.RunExtBackground(cmdRetriesCount, "oc", args...); err == nil {
 			return
 		}
+		glog.Warningf("Failed to extract OS image content from %s: %v", source, err)
 	}
 
+	// Workaround fixes for the environment where oc image extract fails.
+	// See https://bugzilla.redhat.com/show_bug.cgi?id=1862979
+	glog.Infof("Falling back to using podman cp to fetch OS image content")
+	err = podmanCopy(imgURL, osImageContentDir)
 	return
 }
 
+// releaseOSImageContent removes the OS image content extracted in osImageContentDir once the
+// update it was extracted for is done with it. The content of an image pinned by digest is kept
+// for the retry of a failed update, the content extracted for other images is removed once an
+// update succeeds.
+func releaseOSImageContent(osImageContentDir string, updateErr error) {
+	if osImageContentDir == "" {
+		return
+	}
+	if updateErr != nil {
+		if _, err := os.Stat(filepath.Join(osImageContentDir, osImageContentExtractedFile)); err != nil {
+			os.RemoveAll(osImageContentDir)
+		}
+		return
+	}
+	dirs, err := ioutil.ReadDir(osImageContentBaseDir)
+	if err != nil {
+		glog.Warningf("Failed to list the extracted OS image contents: %v", err)
+		return
+	}
+	for _, dir := range dirs {
+		if !strings.HasPrefix(dir.Name(), osImageContentDirPrefix) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(osImageContentBaseDir, dir.Name())); err != nil {
+			glog.Warningf("Failed to remove the extracted OS image content %s: %v", dir.Name(), err)
+		}
+	}
+}
+
 // Remove pending deployment on OSTree based system
-func removePendingDeployment() error {
+func removePendingDeployment(ctx context.Context) error {
 	args := []string{"cleanup", "-p"}
-	_, err := runGetOut("rpm-ostree", args...)
+	_, err := runRpmOstree(ctx, args...)
+	return err
+}
+
+// Undo the rollback to the previous deployment on OSTree based system, the booted deployment
+// is booted next again
+func undoRollback(ctx context.Context) error {
+	_, err := runRpmOstree(ctx, "rollback")
+	return err
+}
+
+// Remove rollback deployment on OSTree based system, the pinned deployments are kept
+func removeRollbackDeployment(ctx context.Context) error {
+	args := []string{"cleanup", "-r"}
+	_, err := runRpmOstree(ctx, args...)
 	return err
 }
 
-func (dn *Daemon) applyOSChanges(oldConfig, newConfig *mcfgv1.MachineConfig) (retErr error) {
+// applyOSChanges stages the OS changes from oldConfig to newConfig. It returns true if the OS
+// was rolled back to the previous deployment instead, which rolling back again undoes.
+func (dn *Daemon) applyOSChanges(ctx context.Context, oldConfig, newConfig *mcfgv1.MachineConfig) (rolledBack bool, retErr error) {
 	// Extract image and add coreos-extensions repo if we have either OS update or package layering to perform
 	mcDiff, err := newMachineConfigDiff(oldConfig, newConfig)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	if dn.recorder != nil {
 		dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeNormal, "OSUpdateStarted", mcDiff.osChangesString())
 	}
 
+	// A tag is rebased to by the digest it references, so the OS doesn't change when it's pushed again
+	osImageURL := newConfig.Spec.OSImageURL
+	if mcDiff.osUpdate && dn.os.IsCoreOSVariant() {
+		if osImageURL, err = dn.pinOSImage(osImageURL); err != nil {
+			return false, err
+		}
+	}
+
+	// The OS update staged ahead of the update is only kept if it is the one to apply, any other
+	// change would be layered on top of it otherwise
+	var prestaged string
+	if dn.os.IsCoreOSVariant() {
+		keepImage := ""
+		if mcDiff.osUpdate {
+			keepImage = osImageURL
+		}
+		if prestaged, err = dn.discardPrestagedOSUpdate(ctx, keepImage); err != nil {
+			return false, err
+		}
+	}
+
+	// Going back to the previous OS, e.g. when the pool is rolled back, use the rollback
+	// deployment rather than pulling the previous OS image again
+	if mcDiff.osUpdate && !mcDiff.kargs && !mcDiff.extensions && !mcDiff.kernelType && dn.os.IsCoreOSVariant() && prestaged == "" {
+		rolledBack, err := dn.NodeUpdaterClient.Rollback(ctx, osImageURL)
+		if err != nil {
+			glog.Warningf("Failed to roll back to the previous deployment, updating the OS instead: %v", err)
+		} else if rolledBack {
+			if dn.recorder != nil {
+				dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeNormal, "OSUpdateStaged", "Rolled back to the previous deployment of %s", osImageURL)
+			}
+			return true, nil
+		}
+	}
+
+	// Fail early rather than running out of disk space pulling the OS image
+	if mcDiff.osUpdate && dn.os.IsCoreOSVariant() && prestaged == "" && (IsOSTreeContainerReference(osImageURL) || !osImagePulled(osImageURL)) {
+		if err := checkOSImageDiskSpace(osImageURL); err != nil {
+			return false, err
+		}
+	}
+
 	var osImageContentDir string
 	if mcDiff.osUpdate || mcDiff.extensions || mcDiff.kernelType {
 		// When we're going to apply an OS update, switch the block
@@ -350,36 +555,42 @@ func (dn *Daemon) applyOSChanges(oldConfig, newConfig *mcfgv1.MachineConfig) (re
 		if dn.node != nil {
 			if _, isControlPlane := dn.node.Labels[ctrlcommon.MasterLabel]; isControlPlane {
 				if err := setRootDeviceSchedulerBFQ(); err != nil {
-					return err
+					return false, err
 				}
 			}
 		}
 		// We emitted this event before, so keep it
 		if dn.recorder != nil {
-			dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeNormal, "InClusterUpgrade", fmt.Sprintf("Updating from oscontainer %s", newConfig.Spec.OSImageURL))
-		}
-		if osImageContentDir, err = ExtractOSImage(newConfig.Spec.OSImageURL); err != nil {
-			return err
+			dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeNormal, "InClusterUpgrade", fmt.Sprintf("Updating from oscontainer %s", osImageURL))
 		}
-		// Delete extracted OS image once we are done.
-		defer os.RemoveAll(osImageContentDir)
+		// rpm-ostree pulls the OSTree container images itself and the OS update may be staged
+		// already, they're only extracted for their extensions then
+		if (!IsOSTreeContainerReference(osImageURL) && prestaged == "") || mcDiff.extensions || mcDiff.kernelType {
+			if osImageContentDir, err = ExtractOSImage(osImageURL); err != nil {
+				return false, err
+			}
+			// Delete extracted OS image once we are done, unless the update is to be retried
+			defer func() { releaseOSImageContent(osImageContentDir, retErr) }()
 
-		if dn.os.IsCoreOSVariant() {
-			if err := addExtensionsRepo(osImageContentDir); err != nil {
-				return err
+			if dn.os.IsCoreOSVariant() {
+				if err := addExtensionsRepo(osImageContentDir); err != nil {
+					return false, err
+				}
+				defer os.Remove(extensionsRepo)
 			}
-			defer os.Remove(extensionsRepo)
 		}
 	}
 
 	// Update OS
-	if err := dn.updateOS(newConfig, osImageContentDir); err != nil {
+	pinnedConfig := newConfig.DeepCopy()
+	pinnedConfig.Spec.OSImageURL = osImageURL
+	if err := dn.updateOS(ctx, pinnedConfig, osImageContentDir); err != nil {
 		nodeName := ""
 		if dn.node != nil {
 			nodeName = dn.node.Name
 		}
-		MCDPivotErr.WithLabelValues(nodeName, newConfig.Spec.OSImageURL, err.Error()).SetToCurrentTime()
-		return err
+		MCDPivotErr.WithLabelValues(nodeName, osImageURL, err.Error()).SetToCurrentTime()
+		return false, err
 	}
 
 	defer func() {
@@ -390,35 +601,42 @@ func (dn *Daemon) applyOSChanges(oldConfig, newConfig *mcfgv1.MachineConfig) (re
 		if retErr != nil {
 			// Print out the error now so that if we fail to cleanup -p, we don't lose it.
 			glog.Infof("Rolling back applied changes to OS due to error: %v", retErr)
-			if err := removePendingDeployment(); err != nil {
+			if err := removePendingDeployment(ctx); err != nil {
 				retErr = errors.Wrapf(retErr, "error removing staged deployment: %v", err)
 				return
 			}
 		}
 	}()
 
+	if err := injectChaos(chaosFailDuringPivot); err != nil {
+		return false, err
+	}
+
 	// Apply kargs
 	if mcDiff.kargs {
-		if err := dn.updateKernelArguments(oldConfig, newConfig); err != nil {
-			return err
+		if err := dn.updateKernelArguments(ctx, oldConfig, newConfig); err != nil {
+			return false, err
 		}
 	}
 
 	// Switch to real time kernel
-	if err := dn.switchKernel(oldConfig, newConfig); err != nil {
-		return err
+	if err := dn.switchKernel(ctx, oldConfig, newConfig); err != nil {
+		return false, err
 	}
 
 	// Apply extensions
-	if err := dn.applyExtensions(oldConfig, newConfig); err != nil {
-		return err
+	if err := dn.applyExtensions(ctx, oldConfig, newConfig); err != nil {
+		return false, err
+	}
+
+	if dn.os.IsCoreOSVariant() {
+		dn.reportDeploymentDiff(ctx)
 	}
 
 	if dn.recorder != nil {
 		dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeNormal, "OSUpdateStaged", "Changes to OS staged")
 	}
-	return nil
-
+	return false, nil
 }
 
 func calculatePostConfigChangeActionFromFileDiffs(oldIgnConfig, newIgnConfig ign3types.Config) (actions []string) {
@@ -426,8 +644,15 @@ func calculatePostConfigChangeActionFromFileDiffs(oldIgnConfig, newIgnConfig ign
 		"/etc/kubernetes/kubelet-ca.crt",
 		"/var/lib/kubelet/config.json",
 	}
+	// Keep in sync with ImagePolicySigstoreConfigPath in the container runtime config controller
 	filesPostConfigChangeActionReloadCrio := []string{
 		"/etc/containers/registries.conf",
+		"/etc/containers/policy.json",
+		"/etc/containers/registries.d/99-ctrcfg-imagePolicy.yaml",
+	}
+	// Keep in sync with CRIODropInFilePathDefaultRuntime in the container runtime config controller
+	filesPostConfigChangeActionRestartCrio := []string{
+		"/etc/crio/crio.conf.d/01-ctrcfg-defaultRuntime",
 	}
 
 	oldFileSet := make(map[string]ign3types.File)
@@ -468,8 +693,14 @@ func calculatePostConfigChangeActionFromFileDiffs(oldIgnConfig, newIgnConfig ign
 	for _, k := range diffFileSet {
 		if ctrlcommon.InSlice(k, filesPostConfigChangeActionNone) {
 			continue
+		} else if ctrlcommon.InSlice(k, filesPostConfigChangeActionRestartCrio) {
+			actions = []string{postConfigChangeActionRestartCrio}
+			continue
 		} else if ctrlcommon.InSlice(k, filesPostConfigChangeActionReloadCrio) {
-			actions = []string{postConfigChangeActionReloadCrio}
+			// a restart also picks up reloadable changes
+			if !ctrlcommon.InSlice(postConfigChangeActionRestartCrio, actions) {
+				actions = []string{postConfigChangeActionReloadCrio}
+			}
 			continue
 		} else {
 			actions = []string{postConfigChangeActionReboot}
@@ -487,7 +718,7 @@ func calculatePostConfigChangeAction(oldConfig, newConfig *mcfgv1.MachineConfig)
 	// If a machine-config-daemon-force file is present, it means the user wants to
 	// move to desired state without additional validation. We will reboot the node in
 	// this case regardless of what MachineConfig diff is.
-	if _, err := os.Stat(constants.MachineConfigDaemonForceFile); err == nil {
+	if _, err := hostFileStater.Stat(constants.MachineConfigDaemonForceFile); err == nil {
 		if err := os.Remove(constants.MachineConfigDaemonForceFile); err != nil {
 			return []string{}, errors.Wrap(err, "failed to remove force validation file")
 		}
@@ -495,11 +726,17 @@ func calculatePostConfigChangeAction(oldConfig, newConfig *mcfgv1.MachineConfig)
 		return []string{postConfigChangeActionReboot}, nil
 	}
 
+	return calculatePostConfigChangeActionFromConfigDiffs(oldConfig, newConfig)
+}
+
+// calculatePostConfigChangeActionFromConfigDiffs returns the actions needed to apply the
+// changes between the configs, without looking at the host
+func calculatePostConfigChangeActionFromConfigDiffs(oldConfig, newConfig *mcfgv1.MachineConfig) ([]string, error) {
 	diff, err := newMachineConfigDiff(oldConfig, newConfig)
 	if err != nil {
 		return []string{}, err
 	}
-	if diff.osUpdate || diff.kargs || diff.fips || diff.units || diff.kernelType || diff.extensions {
+	if diff.osUpdate || diff.kargs || diff.fips || diff.units || diff.kernelType || (diff.extensions && extensionsRemoved(oldConfig, newConfig)) {
 		// must reboot
 		return []string{postConfigChangeActionReboot}, nil
 	}
@@ -514,11 +751,29 @@ func calculatePostConfigChangeAction(oldConfig, newConfig *mcfgv1.MachineConfig)
 	}
 
 	// We don't actually have to consider ssh keys changes, which is the only section of passwd that is allowed to change
-	return calculatePostConfigChangeActionFromFileDiffs(oldIgnConfig, newIgnConfig), nil
+	actions := calculatePostConfigChangeActionFromFileDiffs(oldIgnConfig, newIgnConfig)
+	if diff.extensions && !ctrlcommon.InSlice(postConfigChangeActionReboot, actions) {
+		if ctrlcommon.InSlice(postConfigChangeActionNone, actions) {
+			actions = []string{}
+		}
+		actions = append(actions, postConfigChangeActionApplyLive)
+	}
+	return actions, nil
+}
+
+// extensionsRemoved returns whether extensions of oldConfig aren't in newConfig. Packages can only
+// be added live.
+func extensionsRemoved(oldConfig, newConfig *mcfgv1.MachineConfig) bool {
+	for _, ext := range oldConfig.Spec.Extensions {
+		if !ctrlcommon.InSlice(ext, newConfig.Spec.Extensions) {
+			return true
+		}
+	}
+	return false
 }
 
 // update the node to the provided node configuration.
-func (dn *Daemon) update(oldConfig, newConfig *mcfgv1.MachineConfig) (retErr error) {
+func (dn *Daemon) update(ctx context.Context, oldConfig, newConfig *mcfgv1.MachineConfig) (retErr error) {
 	oldConfig = canonicalizeEmptyMC(oldConfig)
 
 	// signal that an update is active
@@ -558,6 +813,13 @@ func (dn *Daemon) update(oldConfig, newConfig *mcfgv1.MachineConfig) (retErr err
 		return errors.Wrapf(errUnreconcilable, "%v", wrappedErr)
 	}
 
+	if err := dn.validateKernelArguments(oldConfig, newConfig); err != nil {
+		if dn.recorder != nil {
+			dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeWarning, "KernelArgumentsDenied", "Not updating to %s: %v", newConfigName, err)
+		}
+		return errors.Wrapf(err, "can't update to %s", newConfigName)
+	}
+
 	dn.logSystem("Starting update from %s to %s: %+v", oldConfigName, newConfigName, diff)
 
 	actions, err := calculatePostConfigChangeAction(oldConfig, newConfig)
@@ -565,9 +827,20 @@ func (dn *Daemon) update(oldConfig, newConfig *mcfgv1.MachineConfig) (retErr err
 		return err
 	}
 
-	// Drain if we need to reboot or reload crio configuration
-	if ctrlcommon.InSlice(postConfigChangeActionReboot, actions) || ctrlcommon.InSlice(postConfigChangeActionReloadCrio, actions) {
-		if err := dn.performDrain(); err != nil {
+	// Drain if we need to reboot, reload crio configuration, restart crio or apply packages live
+	if ctrlcommon.InSlice(postConfigChangeActionReboot, actions) ||
+		ctrlcommon.InSlice(postConfigChangeActionReloadCrio, actions) ||
+		ctrlcommon.InSlice(postConfigChangeActionRestartCrio, actions) ||
+		ctrlcommon.InSlice(postConfigChangeActionApplyLive, actions) {
+		drainErr := dn.performDrain()
+		// the node controller may have moved the node back while it was draining
+		if desiredConfigName, changed := dn.desiredConfigChanged(newConfigName); changed {
+			return dn.abortUpdate(oldConfigName, desiredConfigName)
+		}
+		if drainErr != nil {
+			return drainErr
+		}
+		if err := injectChaos(chaosFailAfterDrain); err != nil {
 			return err
 		}
 	} else {
@@ -622,13 +895,22 @@ func (dn *Daemon) update(oldConfig, newConfig *mcfgv1.MachineConfig) (retErr err
 		}
 	}()
 
-	if err := dn.applyOSChanges(oldConfig, newConfig); err != nil {
+	rolledBack, err := dn.applyOSChanges(ctx, oldConfig, newConfig)
+	if err != nil {
 		return err
 	}
 
 	defer func() {
 		if retErr != nil {
-			if err := dn.applyOSChanges(newConfig, oldConfig); err != nil {
+			// the OS rolled back to the previous deployment is undone by rolling back again, to
+			// the booted deployment, rather than by updating the OS
+			if rolledBack {
+				if err := undoRollback(ctx); err != nil {
+					retErr = errors.Wrapf(retErr, "error undoing the rollback to the previous deployment %v", err)
+				}
+				return
+			}
+			if _, err := dn.applyOSChanges(ctx, newConfig, oldConfig); err != nil {
 				retErr = errors.Wrapf(retErr, "error rolling back changes to OS %v", err)
 				return
 			}
@@ -637,7 +919,7 @@ func (dn *Daemon) update(oldConfig, newConfig *mcfgv1.MachineConfig) (retErr err
 
 	// Ideally we would want to update kernelArguments only via MachineConfigs.
 	// We are keeping this to maintain compatibility and OKD requirement.
-	tuningChanged, err := UpdateTuningArgs(KernelTuningFile, CmdLineFile)
+	tuningChanged, err := UpdateTuningArgs(ctx, KernelTuningFile, CmdLineFile)
 	if err != nil {
 		return err
 	}
@@ -649,7 +931,7 @@ func (dn *Daemon) update(oldConfig, newConfig *mcfgv1.MachineConfig) (retErr err
 		return err
 	}
 
-	return dn.performPostConfigChangeAction(actions, newConfig.GetName())
+	return dn.performPostConfigChangeAction(ctx, actions, newConfig.GetName(), diff.changes())
 }
 
 // machineConfigDiff represents an ad-hoc difference between two MachineConfig objects.
@@ -694,8 +976,8 @@ func (mcDiff *machineConfigDiff) osChangesString() string {
 
 // canonicalizeKernelType returns a valid kernelType. We consider empty("") and default kernelType as same
 func canonicalizeKernelType(kernelType string) string {
-	if kernelType == ctrlcommon.KernelTypeRealtime {
-		return ctrlcommon.KernelTypeRealtime
+	if kernelType == ctrlcommon.KernelTypeRealtime || kernelType == ctrlcommon.KernelType64kPages {
+		return kernelType
 	}
 	return ctrlcommon.KernelTypeDefault
 }
@@ -929,26 +1211,27 @@ func splitKernelArguments(args string) []string {
 	return split
 }
 
-// parseKernelArguments separates out kargs from each entry and returns it as a map for
-// easy comparison
-func parseKernelArguments(kargs []string) []string {
-	parsed := []string{}
+// parseKernelArguments separates out kargs from each entry of the kernelArguments of a config
+func parseKernelArguments(kargs []string) KernelArguments {
+	parsed := KernelArguments{}
 	for _, k := range kargs {
-		for _, arg := range splitKernelArguments(k) {
-			parsed = append(parsed, strings.TrimSpace(arg))
-		}
+		parsed = append(parsed, ParseKernelArguments(k)...)
 	}
 	return parsed
 }
 
 // generateKargs performs a diff between the old/new MC kernelArguments,
 // and generates the command line arguments suitable for `rpm-ostree kargs`.
+// Arguments whose value changes are replaced in place if that's all that changes.
 // Note what we really should be doing though is also looking at the *current*
 // kernel arguments in case there was drift.  But doing that requires us knowing
 // what the "base" arguments are. See https://github.com/ostreedev/ostree/issues/479
 func generateKargs(oldConfig, newConfig *mcfgv1.MachineConfig) []string {
 	oldKargs := parseKernelArguments(oldConfig.Spec.KernelArguments)
 	newKargs := parseKernelArguments(newConfig.Spec.KernelArguments)
+	if replaced, ok := replaceKargs(oldKargs, newKargs); ok {
+		return replaced
+	}
 	cmdArgs := []string{}
 
 	// To keep kernel argument processing simpler and bug free, we first delete all
@@ -956,16 +1239,62 @@ func generateKargs(oldConfig, newConfig *mcfgv1.MachineConfig) []string {
 	// kernel arguments present in the new rendered MachineConfig.
 	// See https://bugzilla.redhat.com/show_bug.cgi?id=1866546#c10.
 	for _, arg := range oldKargs {
-		cmdArgs = append(cmdArgs, "--delete="+arg)
+		cmdArgs = append(cmdArgs, "--delete="+arg.String())
 	}
 	for _, arg := range newKargs {
-		cmdArgs = append(cmdArgs, "--append="+arg)
+		cmdArgs = append(cmdArgs, "--append="+arg.String())
 	}
 	return cmdArgs
 }
 
+// replaceKargs returns the `rpm-ostree kargs --replace=key=old=new` arguments changing oldKargs
+// into newKargs in place, when only the values of arguments whose key isn't repeated change. A
+// replaced argument is never missing from the node, unlike a deleted argument appended again
+// when the update is interrupted in between.
+func replaceKargs(oldKargs, newKargs KernelArguments) ([]string, bool) {
+	if len(oldKargs) != len(newKargs) {
+		return nil, false
+	}
+	cmdArgs := []string{}
+	for i, oldArg := range oldKargs {
+		newArg := newKargs[i]
+		if oldArg.Equal(newArg) {
+			continue
+		}
+		// rpm-ostree splits the replacement on =, and the order of repeated keys matters
+		if oldArg.Key != newArg.Key || !oldArg.HasValue || !newArg.HasValue || oldArg.Quoted || newArg.Quoted ||
+			strings.Contains(oldArg.Value, "=") || strings.Contains(newArg.Value, "=") ||
+			len(oldKargs.Values(oldArg.Key)) > 1 || len(newKargs.Values(newArg.Key)) > 1 {
+			return nil, false
+		}
+		cmdArgs = append(cmdArgs, fmt.Sprintf("--replace=%s=%s=%s", oldArg.Key, oldArg.Value, newArg.Value))
+	}
+	return cmdArgs, len(cmdArgs) > 0
+}
+
+// validateKernelArguments makes sure the KernelArgumentPolicies don't deny the kernel arguments
+// newConfig adds, in case a policy changed since newConfig was rendered. The arguments the node
+// already has are left alone.
+func (dn *Daemon) validateKernelArguments(oldConfig, newConfig *mcfgv1.MachineConfig) error {
+	if dn.kapLister == nil {
+		return nil
+	}
+	policies, err := dn.kapLister.List(labels.Everything())
+	if err != nil {
+		return errors.Wrap(err, "error listing the KernelArgumentPolicies")
+	}
+	oldKargs := parseKernelArguments(oldConfig.Spec.KernelArguments)
+	var added []string
+	for _, arg := range parseKernelArguments(newConfig.Spec.KernelArguments) {
+		if !oldKargs.Has(arg) {
+			added = append(added, arg.String())
+		}
+	}
+	return ctrlcommon.ValidateKernelArguments(added, policies)
+}
+
 // updateKernelArguments adjusts the kernel args
-func (dn *Daemon) updateKernelArguments(oldConfig, newConfig *mcfgv1.MachineConfig) error {
+func (dn *Daemon) updateKernelArguments(ctx context.Context, oldConfig, newConfig *mcfgv1.MachineConfig) error {
 	kargs := generateKargs(oldConfig, newConfig)
 	if len(kargs) == 0 {
 		return nil
@@ -976,11 +1305,13 @@ func (dn *Daemon) updateKernelArguments(oldConfig, newConfig *mcfgv1.MachineConf
 
 	args := append([]string{"kargs"}, kargs...)
 	dn.logSystem("Running rpm-ostree %v", args)
-	_, err := runGetOut("rpm-ostree", args...)
+	_, err := runRpmOstree(ctx, args...)
 	return err
 }
 
-func (dn *Daemon) generateExtensionsArgs(oldConfig, newConfig *mcfgv1.MachineConfig) []string {
+// extensionsPackages returns the packages to install and uninstall to go from the extensions of
+// oldConfig to the ones of newConfig
+func (dn *Daemon) extensionsPackages(oldConfig, newConfig *mcfgv1.MachineConfig) (install, uninstall []string) {
 	removed := []string{}
 	added := []string{}
 
@@ -1003,23 +1334,18 @@ func (dn *Daemon) generateExtensionsArgs(oldConfig, newConfig *mcfgv1.MachineCon
 			added = append(added, ext)
 		}
 	}
+	sort.Strings(removed)
+	sort.Strings(added)
 
 	// Supported extensions has package list info that is required
 	// to enable an extension
-
-	extArgs := []string{"update"}
-
-	if dn.os.IsRHCOS() {
+	if dn.os.IsRHCOSLike() {
 		extensions := getSupportedExtensions()
 		for _, ext := range added {
-			for _, pkg := range extensions[ext] {
-				extArgs = append(extArgs, "--install", pkg)
-			}
+			install = append(install, extensions[ext]...)
 		}
 		for _, ext := range removed {
-			for _, pkg := range extensions[ext] {
-				extArgs = append(extArgs, "--uninstall", pkg)
-			}
+			uninstall = append(uninstall, extensions[ext]...)
 		}
 	}
 
@@ -1029,14 +1355,22 @@ func (dn *Daemon) generateExtensionsArgs(oldConfig, newConfig *mcfgv1.MachineCon
 	// TODO: Once the package list has been stabilized, we can make use of the group and add
 	// all the packages required to enable OKD as a single extension.
 	if dn.os.IsFCOS() {
-		for _, ext := range added {
-			extArgs = append(extArgs, "--install", ext)
-		}
-		for _, ext := range removed {
-			extArgs = append(extArgs, "--uninstall", ext)
-		}
+		install = append(install, added...)
+		uninstall = append(uninstall, removed...)
 	}
 
+	return install, uninstall
+}
+
+func (dn *Daemon) generateExtensionsArgs(oldConfig, newConfig *mcfgv1.MachineConfig) []string {
+	install, uninstall := dn.extensionsPackages(oldConfig, newConfig)
+	extArgs := []string{"update"}
+	for _, pkg := range install {
+		extArgs = append(extArgs, "--install", pkg)
+	}
+	for _, pkg := range uninstall {
+		extArgs = append(extArgs, "--uninstall", pkg)
+	}
 	return extArgs
 }
 
@@ -1069,7 +1403,7 @@ func validateExtensions(exts []string) error {
 
 }
 
-func (dn *Daemon) applyExtensions(oldConfig, newConfig *mcfgv1.MachineConfig) error {
+func (dn *Daemon) applyExtensions(ctx context.Context, oldConfig, newConfig *mcfgv1.MachineConfig) error {
 	extensionsEmpty := len(oldConfig.Spec.Extensions) == 0 && len(newConfig.Spec.Extensions) == 0
 	if (extensionsEmpty) ||
 		(reflect.DeepEqual(oldConfig.Spec.Extensions, newConfig.Spec.Extensions) && oldConfig.Spec.OSImageURL == newConfig.Spec.OSImageURL) {
@@ -1080,21 +1414,38 @@ func (dn *Daemon) applyExtensions(oldConfig, newConfig *mcfgv1.MachineConfig) er
 		return fmt.Errorf("extensions is not supported on non-CoreOS nodes ")
 	}
 
-	// Validate extensions allowlist on RHCOS nodes
-	if err := validateExtensions(newConfig.Spec.Extensions); err != nil && dn.os.IsRHCOS() {
+	// Validate extensions allowlist on RHCOS and SCOS nodes
+	if err := validateExtensions(newConfig.Spec.Extensions); err != nil && dn.os.IsRHCOSLike() {
 		return err
 	}
 
-	args := dn.generateExtensionsArgs(oldConfig, newConfig)
-	glog.Infof("Applying extensions : %+q", args)
-	_, err := runGetOut("rpm-ostree", args...)
+	// Along with an OS update, the layered packages are updated to the new OS in the same transaction
+	if oldConfig.Spec.OSImageURL != newConfig.Spec.OSImageURL {
+		args := dn.generateExtensionsArgs(oldConfig, newConfig)
+		glog.Infof("Applying extensions : %+q", args)
+		_, err := runRpmOstree(ctx, args...)
+		return err
+	}
 
-	return err
+	install, uninstall := dn.extensionsPackages(oldConfig, newConfig)
+	if len(uninstall) > 0 {
+		glog.Infof("Removing extension packages: %+q", uninstall)
+		if err := dn.NodeUpdaterClient.RemovePackages(ctx, uninstall); err != nil {
+			return err
+		}
+	}
+	if len(install) > 0 {
+		glog.Infof("Installing extension packages: %+q", install)
+		if err := dn.NodeUpdaterClient.InstallPackages(ctx, install); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // switchKernel updates kernel on host with the kernelType specified in MachineConfig.
-// Right now it supports default (traditional) and realtime kernel
-func (dn *Daemon) switchKernel(oldConfig, newConfig *mcfgv1.MachineConfig) error {
+// Right now it supports default (traditional), realtime and, on aarch64, 64k-pages kernel
+func (dn *Daemon) switchKernel(ctx context.Context, oldConfig, newConfig *mcfgv1.MachineConfig) error {
 	// Do nothing if both old and new KernelType are of type default
 	if canonicalizeKernelType(oldConfig.Spec.KernelType) == ctrlcommon.KernelTypeDefault && canonicalizeKernelType(newConfig.Spec.KernelType) == ctrlcommon.KernelTypeDefault {
 		return nil
@@ -1105,45 +1456,21 @@ func (dn *Daemon) switchKernel(oldConfig, newConfig *mcfgv1.MachineConfig) error
 		return fmt.Errorf("updating kernel on non-RHCOS nodes is not supported")
 	}
 
-	defaultKernel := []string{"kernel", "kernel-core", "kernel-modules", "kernel-modules-extra"}
-	realtimeKernel := []string{"kernel-rt-core", "kernel-rt-modules", "kernel-rt-modules-extra", "kernel-rt-kvm"}
-
-	dn.logSystem("Initiating switch from kernel %s to %s", canonicalizeKernelType(oldConfig.Spec.KernelType), canonicalizeKernelType(newConfig.Spec.KernelType))
-
-	if canonicalizeKernelType(oldConfig.Spec.KernelType) == ctrlcommon.KernelTypeRealtime && canonicalizeKernelType(newConfig.Spec.KernelType) == ctrlcommon.KernelTypeDefault {
-		args := []string{"override", "reset"}
-		args = append(args, defaultKernel...)
-		for _, pkg := range realtimeKernel {
-			args = append(args, "--uninstall", pkg)
-		}
-		dn.logSystem("Switching to kernelType=%s, invoking rpm-ostree %+q", newConfig.Spec.KernelType, args)
-		_, err := runGetOut("rpm-ostree", args...)
-		return err
-	}
-
-	if canonicalizeKernelType(oldConfig.Spec.KernelType) == ctrlcommon.KernelTypeDefault && canonicalizeKernelType(newConfig.Spec.KernelType) == ctrlcommon.KernelTypeRealtime {
-		// Switch to RT kernel
-		args := []string{"override", "remove"}
-		args = append(args, defaultKernel...)
-		for _, pkg := range realtimeKernel {
-			args = append(args, "--install", pkg)
-		}
-
-		dn.logSystem("Switching to kernelType=%s, invoking rpm-ostree %+q", newConfig.Spec.KernelType, args)
-		_, err := runGetOut("rpm-ostree", args...)
-		return err
+	oldKernelType := canonicalizeKernelType(oldConfig.Spec.KernelType)
+	newKernelType := canonicalizeKernelType(newConfig.Spec.KernelType)
+	if !ctrlcommon.KernelTypeSupportsArchitecture(newKernelType, hostArch) {
+		return fmt.Errorf("kernelType %s is not supported on %s", newKernelType, hostArch)
 	}
-
-	if canonicalizeKernelType(oldConfig.Spec.KernelType) == ctrlcommon.KernelTypeRealtime && canonicalizeKernelType(newConfig.Spec.KernelType) == ctrlcommon.KernelTypeRealtime {
-		if oldConfig.Spec.OSImageURL != newConfig.Spec.OSImageURL {
-			args := []string{"update"}
-			dn.logSystem("Updating rt-kernel packages on host: %+q", args)
-			_, err := runGetOut("rpm-ostree", args...)
-			return err
+	if oldKernelType == newKernelType {
+		// the kernel packages only need updating along with the OS
+		if oldConfig.Spec.OSImageURL == newConfig.Spec.OSImageURL {
+			return nil
 		}
+		dn.logSystem("Updating %s kernel packages on host", newKernelType)
+	} else {
+		dn.logSystem("Initiating switch from kernel %s to %s", oldKernelType, newKernelType)
 	}
-
-	return nil
+	return dn.NodeUpdaterClient.SwitchKernel(ctx, newKernelType)
 }
 
 // updateFiles writes files specified by the nodeconfig to disk. it also writes
@@ -1186,7 +1513,7 @@ func (dn *Daemon) updateFiles(oldConfig, newConfig *mcfgv1.MachineConfig) error
 }
 
 func restorePath(path string) error {
-	if out, err := exec.Command("cp", "-a", "--reflink=auto", origFileName(path), path).CombinedOutput(); err != nil {
+	if out, err := hostCommander.Command("cp", "-a", "--reflink=auto", origFileName(path), path).CombinedOutput(); err != nil {
 		return errors.Wrapf(err, "restoring %q from orig file %q: %s", path, origFileName(path), string(out))
 	}
 	if err := os.Remove(origFileName(path)); err != nil {
@@ -1257,7 +1584,7 @@ func (dn *Daemon) deleteStaleData(oldIgnConfig, newIgnConfig *ign3types.Config)
 			// and no rpm is claiming it, we assume that the orig file came from a wrongful backup of a MachineConfig
 			// file instead of a file originally on disk. See https://bugzilla.redhat.com/show_bug.cgi?id=1814397
 			var restore bool
-			if _, err := exec.Command("rpm", "-qf", f.Path).CombinedOutput(); err == nil {
+			if _, err := hostCommander.Command("rpm", "-qf", f.Path).CombinedOutput(); err == nil {
 				// File is owned by an rpm
 				restore = true
 			} else if strings.HasPrefix(f.Path, "/etc") && dn.os.IsCoreOSVariant() {
@@ -1382,7 +1709,7 @@ func (dn *Daemon) deleteStaleData(oldIgnConfig, newIgnConfig *ign3types.Config)
 // enableUnits enables a set of systemd units via systemctl, if any fail all fails.
 func (dn *Daemon) enableUnits(units []string) error {
 	args := append([]string{"enable"}, units...)
-	stdouterr, err := exec.Command("systemctl", args...).CombinedOutput()
+	stdouterr, err := hostCommander.Command("systemctl", args...).CombinedOutput()
 	if err != nil {
 		if !dn.os.IsLikeTraditionalRHEL7() {
 			return fmt.Errorf("error enabling units: %s", stdouterr)
@@ -1414,7 +1741,7 @@ func (dn *Daemon) enableUnits(units []string) error {
 				}
 			}
 		}
-		stdouterr, err := exec.Command("systemctl", args...).CombinedOutput()
+		stdouterr, err := hostCommander.Command("systemctl", args...).CombinedOutput()
 		if err != nil {
 			return fmt.Errorf("error enabling units: %s", stdouterr)
 		}
@@ -1426,7 +1753,7 @@ func (dn *Daemon) enableUnits(units []string) error {
 // disableUnits disables a set of systemd units via systemctl, if any fail all fails.
 func (dn *Daemon) disableUnits(units []string) error {
 	args := append([]string{"disable"}, units...)
-	stdouterr, err := exec.Command("systemctl", args...).CombinedOutput()
+	stdouterr, err := hostCommander.Command("systemctl", args...).CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("error disabling unit: %s", stdouterr)
 	}
@@ -1437,7 +1764,7 @@ func (dn *Daemon) disableUnits(units []string) error {
 // presetUnit resets a systemd unit to its preset via systemctl
 func (dn *Daemon) presetUnit(unit ign3types.Unit) error {
 	args := []string{"preset", unit.Name}
-	stdouterr, err := exec.Command("systemctl", args...).CombinedOutput()
+	stdouterr, err := hostCommander.Command("systemctl", args...).CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("error running preset on unit: %s", stdouterr)
 	}
@@ -1594,6 +1921,9 @@ func (dn *Daemon) writeFiles(files []ign3types.File) error {
 		if err := writeFileAtomically(file.Path, contents.Data, defaultDirectoryPermissions, mode, uid, gid); err != nil {
 			return err
 		}
+		if err := injectFileCorruption(file.Path); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -1642,7 +1972,7 @@ func createOrigFile(fromPath, fpath string) error {
 	if err := os.MkdirAll(filepath.Dir(origFileName(fpath)), 0755); err != nil {
 		return errors.Wrapf(err, "creating orig parent dir: %v", err)
 	}
-	if out, err := exec.Command("cp", "-a", "--reflink=auto", fromPath, origFileName(fpath)).CombinedOutput(); err != nil {
+	if out, err := hostCommander.Command("cp", "-a", "--reflink=auto", fromPath, origFileName(fpath)).CombinedOutput(); err != nil {
 		return errors.Wrapf(err, "creating orig file for %q: %s", fpath, string(out))
 	}
 	return nil
@@ -1716,21 +2046,24 @@ func (dn *Daemon) updateSSHKeys(newUsers []ign3types.PasswdUser) error {
 }
 
 // updateOS updates the system OS to the one specified in newConfig
-func (dn *Daemon) updateOS(config *mcfgv1.MachineConfig, osImageContentDir string) error {
+func (dn *Daemon) updateOS(ctx context.Context, config *mcfgv1.MachineConfig, osImageContentDir string) error {
 	if !dn.os.IsCoreOSVariant() {
 		glog.V(2).Info("Updating of non-CoreOS nodes are not supported")
 		return nil
 	}
 
 	newURL := config.Spec.OSImageURL
-	if compareOSImageURL(dn.bootedOSImageURL, newURL) {
+	if dn.osImageMatches(newURL) {
 		return nil
 	}
 
 	glog.Infof("Updating OS to %s", newURL)
 	client := NewNodeUpdaterClient()
-	if _, err := client.Rebase(newURL, osImageContentDir); err != nil {
-		return fmt.Errorf("failed to update OS to %s : %v", newURL, err)
+	if _, err := client.Rebase(ctx, newURL, osImageContentDir); err != nil {
+		if IsOSImageVerificationError(err) && dn.recorder != nil {
+			dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeWarning, "OSImageRejected", err.Error())
+		}
+		return errors.Wrapf(err, "failed to update OS to %s", newURL)
 	}
 
 	return nil
@@ -1740,7 +2073,7 @@ func (dn *Daemon) getPendingStateLegacyLogger() (*journalMsg, error) {
 	glog.Info("logger doesn't support --jounald, grepping the journal")
 
 	cmdLiteral := "journalctl -o cat _UID=0 | grep -v audit | grep OPENSHIFT_MACHINE_CONFIG_DAEMON_LEGACY_LOG_HACK"
-	cmd := exec.Command("bash", "-c", cmdLiteral)
+	cmd := hostCommander.Command("bash", "-c", cmdLiteral)
 	var combinedOutput bytes.Buffer
 	cmd.Stdout = &combinedOutput
 	cmd.Stderr = &combinedOutput
@@ -1803,7 +2136,7 @@ func (dn *Daemon) getPendingState() (*journalMsg, error) {
 	if !dn.loggerSupportsJournal {
 		return dn.getPendingStateLegacyLogger()
 	}
-	journalOutput, err := exec.Command("journalctl", "-o", "json", "_UID=0", fmt.Sprintf("MESSAGE_ID=%s", pendingStateMessageID)).CombinedOutput()
+	journalOutput, err := hostCommander.Command("journalctl", "-o", "json", "_UID=0", fmt.Sprintf("MESSAGE_ID=%s", pendingStateMessageID)).CombinedOutput()
 	if err != nil {
 		return nil, errors.Wrap(err, "error running journalctl -o json")
 	}
@@ -1826,7 +2159,7 @@ func (dn *Daemon) storePendingStateLegacyLogger(pending *mcfgv1.MachineConfig, i
 		}
 	}
 
-	oldLogger := exec.Command("logger", fmt.Sprintf(`{"MESSAGE": "%s", "BOOT_ID": "%s", "PENDING": "%d", "OPENSHIFT_MACHINE_CONFIG_DAEMON_LEGACY_LOG_HACK": "1"}`, pending.GetName(), dn.bootID, isPending))
+	oldLogger := hostCommander.Command("logger", fmt.Sprintf(`{"MESSAGE": "%s", "BOOT_ID": "%s", "PENDING": "%d", "OPENSHIFT_MACHINE_CONFIG_DAEMON_LEGACY_LOG_HACK": "1"}`, pending.GetName(), dn.bootID, isPending))
 	return oldLogger.CombinedOutput()
 }
 
@@ -1834,7 +2167,7 @@ func (dn *Daemon) storePendingState(pending *mcfgv1.MachineConfig, isPending int
 	if !dn.loggerSupportsJournal {
 		return dn.storePendingStateLegacyLogger(pending, isPending)
 	}
-	logger := exec.Command("logger", "--journald")
+	logger := hostCommander.Command("logger", "--journald")
 
 	var pendingState bytes.Buffer
 	pendingState.WriteString(fmt.Sprintf(`MESSAGE_ID=%s
@@ -1850,11 +2183,12 @@ PENDING=%d`, pendingStateMessageID, pending.GetName(), dn.bootID, isPending))
 func (dn *Daemon) logSystem(format string, a ...interface{}) {
 	message := fmt.Sprintf(format, a...)
 	glog.Info(message)
+	updateLogs.add(hostClock.Now(), message)
 	// Since we're chrooted into the host rootfs with /run mounted,
 	// we can just talk to the journald socket.  Doing this as a
 	// subprocess rather than talking to journald in process since
 	// I worry about the golang library having a connection pre-chroot.
-	logger := exec.Command("logger")
+	logger := hostCommander.Command("logger")
 
 	var log bytes.Buffer
 	log.WriteString(fmt.Sprintf("machine-config-daemon[%d]: %s", os.Getpid(), message))
@@ -1868,7 +2202,7 @@ func (dn *Daemon) logSystem(format string, a ...interface{}) {
 // reboot is the final step. it tells systemd-logind to reboot the machine,
 // cleans up the agent's connections, and then sleeps for 7 days. if it wakes up
 // and manages to return, it returns a scary error message.
-func (dn *Daemon) reboot(rationale string) error {
+func (dn *Daemon) reboot(ctx context.Context, reason rebootReason) error {
 	// Now that everything is done, avoid delaying shutdown.
 	dn.Close()
 
@@ -1878,11 +2212,29 @@ func (dn *Daemon) reboot(rationale string) error {
 
 	// We'll only have a recorder if we're cluster driven
 	if dn.recorder != nil {
-		dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeNormal, "Reboot", rationale)
+		dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeNormal, "Reboot", reason.String())
+	}
+	dn.logSystem("initiating reboot: %s", reason)
+
+	// the reason only tells the next boot apart from an unexpected one, don't keep the node
+	// from rebooting if it can't be recorded
+	if err := dn.recordReboot(reason); err != nil {
+		glog.Errorf("Failed to record the reboot reason: %v", err)
+	}
+
+	if err := injectChaos(chaosCrashBeforeReboot); err != nil {
+		return err
+	}
+
+	if delay, wallMessage := dn.gracefulReboot(); delay > 0 {
+		when, err := dn.scheduleReboot(delay, wallMessage)
+		if err == nil {
+			return dn.waitScheduledReboot(ctx, reason, when)
+		}
+		dn.logSystem("failed to schedule the reboot, rebooting now: %v", err)
 	}
-	dn.logSystem("initiating reboot: %s", rationale)
 
-	rebootCmd := rebootCommand(rationale)
+	rebootCmd := rebootCommand(reason.Message)
 
 	// reboot, executed async via systemd-run so that the reboot command is executed
 	// in the context of the host asynchronously from us
@@ -1895,7 +2247,7 @@ func (dn *Daemon) reboot(rationale string) error {
 	}
 
 	// wait to be killed via SIGTERM from the kubelet shutting down
-	time.Sleep(defaultRebootTimeout)
+	hostClock.Sleep(defaultRebootTimeout)
 
 	// if everything went well, this should be unreachable.
 	MCDRebootErr.WithLabelValues(dn.node.Name, "reboot failed", "this error should be unreachable, something is seriously wrong").SetToCurrentTime()
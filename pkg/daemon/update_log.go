@@ -0,0 +1,111 @@
+package daemon
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// maxUpdateLogLines is how many of the last update log lines are kept for new readers
+	maxUpdateLogLines = 1000
+	// updateLogSubscriberBuffer is how many lines a slow follower can lag behind before
+	// it is disconnected
+	updateLogSubscriberBuffer = 100
+
+	// UpdateLogPath is where the metrics listener serves the update log
+	UpdateLogPath = "/update-logs"
+)
+
+// updateLog keeps the update relevant subset of the daemon's logs, the messages it also
+// writes to the journal, and streams them to followers
+type updateLog struct {
+	mu          sync.Mutex
+	lines       []string
+	maxLines    int
+	subscribers map[chan string]struct{}
+}
+
+// updateLogs is the update log of the daemon
+var updateLogs = newUpdateLog(maxUpdateLogLines)
+
+func newUpdateLog(maxLines int) *updateLog {
+	return &updateLog{
+		maxLines:    maxLines,
+		subscribers: map[chan string]struct{}{},
+	}
+}
+
+// add appends a line and sends it to the followers, disconnecting those which can't keep up
+func (l *updateLog) add(now time.Time, message string) {
+	line := fmt.Sprintf("%s %s", now.UTC().Format(time.RFC3339), message)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, line)
+	if len(l.lines) > l.maxLines {
+		l.lines = l.lines[len(l.lines)-l.maxLines:]
+	}
+	for ch := range l.subscribers {
+		select {
+		case ch <- line:
+		default:
+			delete(l.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// subscribe returns the lines logged so far and a channel receiving the next ones. The channel
+// is closed if the follower doesn't keep up, cancel has to be called once done.
+func (l *updateLog) subscribe() (backlog []string, lines <-chan string, cancel func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	backlog = make([]string, len(l.lines))
+	copy(backlog, l.lines)
+	ch := make(chan string, updateLogSubscriberBuffer)
+	l.subscribers[ch] = struct{}{}
+	return backlog, ch, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if _, ok := l.subscribers[ch]; ok {
+			delete(l.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// ServeHTTP writes the update log as text, one line per message. With ?follow=true the new
+// lines are streamed until the client disconnects, like `oc logs -f`.
+func (l *updateLog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	follow, _ := strconv.ParseBool(r.URL.Query().Get("follow"))
+	backlog, lines, cancel := l.subscribe()
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	for _, line := range backlog {
+		fmt.Fprintln(w, line)
+	}
+	if !follow {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return
+	}
+	flusher.Flush()
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				fmt.Fprintln(w, "update log follower fell behind, reconnect to resume")
+				return
+			}
+			fmt.Fprintln(w, line)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
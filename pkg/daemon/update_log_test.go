@@ -0,0 +1,56 @@
+package daemon
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateLog(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	l := newUpdateLog(2)
+	l.add(now, "Starting update from rendered-worker-1 to rendered-worker-2")
+	l.add(now, "Update prepared; beginning drain")
+	l.add(now.Add(time.Minute), "Rebooting node")
+
+	server := httptest.NewServer(l)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	require.Nil(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Nil(t, err)
+	assert.Equal(t, "2021-03-01T12:00:00Z Update prepared; beginning drain\n2021-03-01T12:01:00Z Rebooting node\n", string(body))
+
+	resp, err = http.Get(server.URL + "?follow=true")
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	lines := bufio.NewScanner(resp.Body)
+	for i := 0; i < 2; i++ {
+		require.True(t, lines.Scan())
+	}
+	l.add(now.Add(2*time.Minute), "Node has Desired Config rendered-worker-2, skipping reboot")
+	require.True(t, lines.Scan())
+	assert.Equal(t, "2021-03-01T12:02:00Z Node has Desired Config rendered-worker-2, skipping reboot", lines.Text())
+}
+
+func TestUpdateLogSlowFollower(t *testing.T) {
+	l := newUpdateLog(maxUpdateLogLines)
+	_, lines, cancel := l.subscribe()
+	defer cancel()
+	for i := 0; i <= updateLogSubscriberBuffer; i++ {
+		l.add(time.Now(), "message")
+	}
+	received := 0
+	for range lines {
+		received++
+	}
+	assert.Equal(t, updateLogSubscriberBuffer, received)
+}
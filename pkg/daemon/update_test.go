@@ -1,8 +1,12 @@
 package daemon
 
 import (
+	"context"
 	"fmt"
+	"io/ioutil"
 	"math/rand"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
@@ -10,11 +14,20 @@ import (
 	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	mcfglistersv1 "github.com/openshift/machine-config-operator/pkg/generated/listers/machineconfiguration.openshift.io/v1"
 	"github.com/openshift/machine-config-operator/test/helpers"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/vincent-petithory/dataurl"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
+	corev1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/kubectl/pkg/drain"
 )
 
 // TestUpdateOS verifies the return errors from attempting to update the OS follow expectations
@@ -49,11 +62,11 @@ func TestUpdateOS(t *testing.T) {
 	}
 
 	// This should be a no-op
-	if err := d.updateOS(mcfg, ""); err != nil {
+	if err := d.updateOS(context.Background(), mcfg, ""); err != nil {
 		t.Errorf("Expected no error. Got %s.", err)
 	}
 	// Second call should return an error
-	if err := d.updateOS(differentMcfg, ""); err == expectedError {
+	if err := d.updateOS(context.Background(), differentMcfg, ""); err == expectedError {
 		t.Error("Expected an error. Got none.")
 	}
 }
@@ -221,6 +234,35 @@ func TestReconcilableDiff(t *testing.T) {
 	assert.Equal(t, diff.files, false)
 }
 
+func TestExtensionsPackages(t *testing.T) {
+	oldConfig := &mcfgv1.MachineConfig{Spec: mcfgv1.MachineConfigSpec{Extensions: []string{"usbguard", "kernel-devel"}}}
+	newConfig := &mcfgv1.MachineConfig{Spec: mcfgv1.MachineConfigSpec{Extensions: []string{"usbguard", "sandboxed-containers"}}}
+
+	d := Daemon{os: OperatingSystem{ID: "rhcos"}}
+	install, uninstall := d.extensionsPackages(oldConfig, newConfig)
+	assert.Equal(t, []string{"kata-containers"}, install)
+	assert.Equal(t, []string{"kernel-devel", "kernel-headers"}, uninstall)
+	assert.Equal(t, []string{"update", "--install", "kata-containers", "--uninstall", "kernel-devel", "--uninstall", "kernel-headers"}, d.generateExtensionsArgs(oldConfig, newConfig))
+
+	// FCOS extensions are packages
+	d = Daemon{os: OperatingSystem{ID: "fedora", VariantID: "coreos"}}
+	install, uninstall = d.extensionsPackages(oldConfig, newConfig)
+	assert.Equal(t, []string{"sandboxed-containers"}, install)
+	assert.Equal(t, []string{"kernel-devel"}, uninstall)
+}
+
+func TestSwitchKernelArchitecture(t *testing.T) {
+	oldArch := hostArch
+	defer func() { hostArch = oldArch }()
+	oldConfig := &mcfgv1.MachineConfig{}
+	newConfig := &mcfgv1.MachineConfig{Spec: mcfgv1.MachineConfigSpec{KernelType: ctrlcommon.KernelType64kPages}}
+
+	// the 64k-pages kernel is refused before rpm-ostree is invoked
+	hostArch = "amd64"
+	d := Daemon{os: OperatingSystem{ID: "rhcos"}, NodeUpdaterClient: &RpmOstreeClientMock{}}
+	assert.EqualError(t, d.switchKernel(context.Background(), oldConfig, newConfig), "kernelType 64k-pages is not supported on amd64")
+}
+
 func TestKernelAguments(t *testing.T) {
 	tests := []struct {
 		oldKargs []string
@@ -260,6 +302,26 @@ func TestKernelAguments(t *testing.T) {
 			out: []string{"--delete=hugepagesz=1G", "--delete=hugepages=4", "--delete=hugepagesz=2M", "--delete=hugepages=4",
 				"--append=hugepagesz=1G", "--append=hugepages=4", "--append=hugepagesz=2M", "--append=hugepages=6"},
 		},
+		{
+			oldKargs: []string{"foo", "bar=1 hello=world"},
+			newKargs: []string{"foo", "bar=2", "hello=world"},
+			out:      []string{"--replace=bar=1=2"},
+		},
+		{
+			oldKargs: []string{"hugepagesz=1G hugepages=4"},
+			newKargs: []string{"hugepagesz=2M hugepages=6"},
+			out:      []string{"--replace=hugepagesz=1G=2M", "--replace=hugepages=4=6"},
+		},
+		{
+			oldKargs: []string{"bar=\"hello world\""},
+			newKargs: []string{"bar=\"hello there\""},
+			out:      []string{"--delete=bar=\"hello world\"", "--append=bar=\"hello there\""},
+		},
+		{
+			oldKargs: []string{"foo=1", "bar"},
+			newKargs: []string{"foo=2", "baz"},
+			out:      []string{"--delete=foo=1", "--delete=bar", "--append=foo=2", "--append=baz"},
+		},
 	}
 
 	rand.Seed(time.Now().UnixNano())
@@ -534,6 +596,46 @@ func TestCalculatePostConfigChangeAction(t *testing.T) {
 				},
 			},
 		},
+		"defaultRuntime1": ign3types.File{
+			Node: ign3types.Node{
+				Path: "/etc/crio/crio.conf.d/01-ctrcfg-defaultRuntime",
+			},
+			FileEmbedded1: ign3types.FileEmbedded1{
+				Contents: ign3types.Resource{
+					Source: helpers.StrToPtr(dataurl.EncodeBytes([]byte("default_runtime = \"runc\"\n"))),
+				},
+			},
+		},
+		"defaultRuntime2": ign3types.File{
+			Node: ign3types.Node{
+				Path: "/etc/crio/crio.conf.d/01-ctrcfg-defaultRuntime",
+			},
+			FileEmbedded1: ign3types.FileEmbedded1{
+				Contents: ign3types.Resource{
+					Source: helpers.StrToPtr(dataurl.EncodeBytes([]byte("default_runtime = \"crun\"\n"))),
+				},
+			},
+		},
+		"policy1": ign3types.File{
+			Node: ign3types.Node{
+				Path: "/etc/containers/policy.json",
+			},
+			FileEmbedded1: ign3types.FileEmbedded1{
+				Contents: ign3types.Resource{
+					Source: helpers.StrToPtr(dataurl.EncodeBytes([]byte("{\"default\": [{\"type\": \"insecureAcceptAnything\"}]}\n"))),
+				},
+			},
+		},
+		"policy2": ign3types.File{
+			Node: ign3types.Node{
+				Path: "/etc/containers/policy.json",
+			},
+			FileEmbedded1: ign3types.FileEmbedded1{
+				Contents: ign3types.Resource{
+					Source: helpers.StrToPtr(dataurl.EncodeBytes([]byte("{\"default\": [{\"type\": \"reject\"}]}\n"))),
+				},
+			},
+		},
 		"kubeletCA1": ign3types.File{
 			Node: ign3types.Node{
 				Path: "/etc/kubernetes/kubelet-ca.crt",
@@ -585,6 +687,30 @@ func TestCalculatePostConfigChangeAction(t *testing.T) {
 			newConfig:      helpers.NewMachineConfig("01-test", nil, "dummy://", []ign3types.File{files["registries2"]}),
 			expectedAction: []string{postConfigChangeActionReloadCrio},
 		},
+		{
+			// test that a signature policy change is reload
+			oldConfig:      helpers.NewMachineConfig("00-test", nil, "dummy://", []ign3types.File{files["policy1"]}),
+			newConfig:      helpers.NewMachineConfig("01-test", nil, "dummy://", []ign3types.File{files["policy2"]}),
+			expectedAction: []string{postConfigChangeActionReloadCrio},
+		},
+		{
+			// test that a default runtime change is crio restart
+			oldConfig:      helpers.NewMachineConfig("00-test", nil, "dummy://", []ign3types.File{files["defaultRuntime1"]}),
+			newConfig:      helpers.NewMachineConfig("01-test", nil, "dummy://", []ign3types.File{files["defaultRuntime2"]}),
+			expectedAction: []string{postConfigChangeActionRestartCrio},
+		},
+		{
+			// test that a default runtime change (restart) overwrites registries (reload)
+			oldConfig:      helpers.NewMachineConfig("00-test", nil, "dummy://", []ign3types.File{files["registries1"], files["defaultRuntime1"]}),
+			newConfig:      helpers.NewMachineConfig("01-test", nil, "dummy://", []ign3types.File{files["registries2"], files["defaultRuntime2"]}),
+			expectedAction: []string{postConfigChangeActionRestartCrio},
+		},
+		{
+			// test that a default runtime change (restart) is overwritten by a file change (reboot)
+			oldConfig:      helpers.NewMachineConfig("00-test", nil, "dummy://", []ign3types.File{files["randomfile1"], files["defaultRuntime1"]}),
+			newConfig:      helpers.NewMachineConfig("01-test", nil, "dummy://", []ign3types.File{files["randomfile2"], files["defaultRuntime2"]}),
+			expectedAction: []string{postConfigChangeActionReboot},
+		},
 		{
 			// test that a kubelet CA change is none
 			oldConfig:      helpers.NewMachineConfig("00-test", nil, "dummy://", []ign3types.File{files["kubeletCA1"]}),
@@ -621,6 +747,24 @@ func TestCalculatePostConfigChangeAction(t *testing.T) {
 			newConfig:      helpers.NewMachineConfigExtended("01-test", nil, []ign3types.File{files["pullsecret2"], files["kubeletCA1"]}, []ign3types.Unit{}, []ign3types.SSHAuthorizedKey{"key2"}, []string{}, false, []string{"karg1"}, "default", "dummy://"),
 			expectedAction: []string{postConfigChangeActionReboot},
 		},
+		{
+			// extensions added are applied live
+			oldConfig:      helpers.NewMachineConfigExtended("00-test", nil, []ign3types.File{}, []ign3types.Unit{}, []ign3types.SSHAuthorizedKey{}, []string{"usbguard"}, false, []string{}, "default", "dummy://"),
+			newConfig:      helpers.NewMachineConfigExtended("01-test", nil, []ign3types.File{}, []ign3types.Unit{}, []ign3types.SSHAuthorizedKey{}, []string{"usbguard", "kernel-devel"}, false, []string{}, "default", "dummy://"),
+			expectedAction: []string{postConfigChangeActionApplyLive},
+		},
+		{
+			// along with the files changed
+			oldConfig:      helpers.NewMachineConfigExtended("00-test", nil, []ign3types.File{files["registries1"]}, []ign3types.Unit{}, []ign3types.SSHAuthorizedKey{}, []string{}, false, []string{}, "default", "dummy://"),
+			newConfig:      helpers.NewMachineConfigExtended("01-test", nil, []ign3types.File{files["registries2"]}, []ign3types.Unit{}, []ign3types.SSHAuthorizedKey{}, []string{"usbguard"}, false, []string{}, "default", "dummy://"),
+			expectedAction: []string{postConfigChangeActionReloadCrio, postConfigChangeActionApplyLive},
+		},
+		{
+			// extensions removed need a reboot
+			oldConfig:      helpers.NewMachineConfigExtended("00-test", nil, []ign3types.File{}, []ign3types.Unit{}, []ign3types.SSHAuthorizedKey{}, []string{"usbguard"}, false, []string{}, "default", "dummy://"),
+			newConfig:      helpers.NewMachineConfigExtended("01-test", nil, []ign3types.File{}, []ign3types.Unit{}, []ign3types.SSHAuthorizedKey{}, []string{"kernel-devel"}, false, []string{}, "default", "dummy://"),
+			expectedAction: []string{postConfigChangeActionReboot},
+		},
 	}
 
 	for idx, test := range tests {
@@ -647,3 +791,277 @@ func checkIrreconcilableResults(t *testing.T, key string, reconcilableError erro
 		t.Errorf("Different %s values should not be reconcilable.", key)
 	}
 }
+
+func TestSystemdUnitCommands(t *testing.T) {
+	recorder := helpers.NewCommandRecorder()
+	defer setHost(recorder, nil, nil)()
+
+	dn := &Daemon{}
+	assert.Nil(t, dn.enableUnits([]string{"a.service", "b.service"}))
+	recorder.AssertCalled(t, "systemctl", "enable", "a.service", "b.service")
+
+	recorder.Respond("Failed to disable unit", 1, "systemctl", "disable")
+	err := dn.disableUnits([]string{"a.service"})
+	assert.EqualError(t, err, "error disabling unit: Failed to disable unit")
+
+	assert.Nil(t, dn.presetUnit(ign3types.Unit{Name: "c.service"}))
+	assert.Equal(t, [][]string{
+		{"systemctl", "enable", "a.service", "b.service"},
+		{"systemctl", "disable", "a.service"},
+		{"systemctl", "preset", "c.service"},
+	}, recorder.Calls())
+}
+
+func TestPlanUpdate(t *testing.T) {
+	newFile := func(path, contents string) ign3types.File {
+		return ign3types.File{
+			Node: ign3types.Node{Path: path},
+			FileEmbedded1: ign3types.FileEmbedded1{
+				Contents: ign3types.Resource{Source: helpers.StrToPtr(dataurl.EncodeBytes([]byte(contents)))},
+			},
+		}
+	}
+	oldConfig := helpers.NewMachineConfigExtended("rendered-worker-0", nil,
+		[]ign3types.File{newFile("/etc/kept", "a"), newFile("/etc/changed", "a"), newFile("/etc/removed", "a")},
+		[]ign3types.Unit{{Name: "kept.service", Contents: helpers.StrToPtr("[Unit]"), Enabled: helpers.BoolToPtr(true)}, {Name: "removed.service"}},
+		nil, nil, false, []string{"nosmt"}, "", "registry.example.com/os@sha256:aaa")
+	newConfig := helpers.NewMachineConfigExtended("rendered-worker-1", nil,
+		[]ign3types.File{newFile("/etc/kept", "a"), newFile("/etc/changed", "b"), newFile("/etc/added", "a")},
+		[]ign3types.Unit{{Name: "kept.service", Contents: helpers.StrToPtr("[Unit]"), Enabled: helpers.BoolToPtr(false)}, {Name: "added.service", Contents: helpers.StrToPtr("[Unit]"), Enabled: helpers.BoolToPtr(true)}},
+		nil, nil, false, []string{"nosmt", "quiet"}, "", "registry.example.com/os@sha256:bbb")
+
+	dn := &Daemon{}
+	plan, err := dn.planUpdate(oldConfig, newConfig)
+	require.Nil(t, err)
+	assert.Equal(t, &UpdatePlan{
+		FromConfig:             "rendered-worker-0",
+		ToConfig:               "rendered-worker-1",
+		FilesToWrite:           []string{"/etc/added", "/etc/changed"},
+		FilesToRemove:          []string{"/etc/removed"},
+		UnitsToWrite:           []string{"added.service"},
+		UnitsToRemove:          []string{"removed.service"},
+		UnitsToEnable:          []string{"added.service"},
+		UnitsToDisable:         []string{"kept.service"},
+		OSImageURL:             "registry.example.com/os@sha256:bbb",
+		KernelArguments:        []string{"--delete=nosmt", "--append=nosmt", "--append=quiet"},
+		Drain:                  true,
+		PostConfigChangeAction: postConfigChangeActionReboot,
+	}, plan)
+	assert.Contains(t, plan.String(), "OS update: pivot to registry.example.com/os@sha256:bbb\n")
+
+	// only a pull secret change, applied in place
+	oldConfig = helpers.NewMachineConfig("rendered-worker-0", nil, "", []ign3types.File{newFile("/var/lib/kubelet/config.json", "a")})
+	newConfig = helpers.NewMachineConfig("rendered-worker-1", nil, "", []ign3types.File{newFile("/var/lib/kubelet/config.json", "b")})
+	plan, err = dn.planUpdate(oldConfig, newConfig)
+	require.Nil(t, err)
+	assert.False(t, plan.Drain)
+	assert.Equal(t, postConfigChangeActionNone, plan.PostConfigChangeAction)
+
+	// disks can't be changed on a running host
+	ignCfg := ctrlcommon.NewIgnConfig()
+	ignCfg.Storage.Disks = []ign3types.Disk{{Device: "/dev/sdb"}}
+	newConfig = helpers.CreateMachineConfigFromIgnition(ignCfg)
+	plan, err = dn.planUpdate(oldConfig, newConfig)
+	require.Nil(t, err)
+	assert.NotEmpty(t, plan.Unreconcilable)
+	assert.Contains(t, plan.String(), "Can't be applied")
+}
+
+// TestReleaseOSImageContent verifies the content extracted for a digest is kept for the retry of a failed update
+func TestReleaseOSImageContent(t *testing.T) {
+	base, err := ioutil.TempDir("", "os-content")
+	require.Nil(t, err)
+	defer os.RemoveAll(base)
+
+	extracted := filepath.Join(base, osImageContentDirPrefix+"aaa")
+	require.Nil(t, os.MkdirAll(extracted, 0755))
+	require.Nil(t, ioutil.WriteFile(filepath.Join(extracted, osImageContentExtractedFile), nil, 0644))
+	temp := filepath.Join(base, osImageContentDirPrefix+"123")
+	require.Nil(t, os.MkdirAll(temp, 0755))
+
+	releaseOSImageContent(extracted, fmt.Errorf("broken"))
+	assert.DirExists(t, extracted)
+	releaseOSImageContent(temp, fmt.Errorf("broken"))
+	assert.NoDirExists(t, temp)
+}
+
+// rollbackDeploymentClient rolls back to its deployments like rpm-ostree does
+type rollbackDeploymentClient struct {
+	RpmOstreeClientMock
+	deployments []RpmOstreeDeployment
+	rolledBack  bool
+}
+
+func (c *rollbackDeploymentClient) Rollback(_ context.Context, imgURL string) (bool, error) {
+	c.rolledBack = canRollbackTo(c.deployments, imgURL)
+	return c.rolledBack, nil
+}
+
+// TestApplyOSChangesRollback verifies going back to the OS of the rollback deployment rolls back to it
+func TestApplyOSChangesRollback(t *testing.T) {
+	oldImage := "quay.io/openshift/os@sha256:old"
+	newImage := "quay.io/openshift/os@sha256:new"
+	client := &rollbackDeploymentClient{deployments: []RpmOstreeDeployment{
+		{Booted: true, CustomOrigin: []string{"pivot://" + oldImage}},
+		{CustomOrigin: []string{"pivot://" + newImage}},
+	}}
+	dn := &Daemon{os: OperatingSystem{ID: "rhcos"}, NodeUpdaterClient: client}
+
+	oldConfig := helpers.CreateMachineConfigFromIgnition(ctrlcommon.NewIgnConfig())
+	oldConfig.Spec.OSImageURL = oldImage
+	newConfig := helpers.CreateMachineConfigFromIgnition(ctrlcommon.NewIgnConfig())
+	newConfig.Spec.OSImageURL = newImage
+	rolledBack, err := dn.applyOSChanges(context.Background(), oldConfig, newConfig)
+	require.Nil(t, err)
+	assert.True(t, rolledBack)
+	assert.True(t, client.rolledBack)
+}
+
+// TestValidateKernelArguments verifies only the kernel arguments an update adds are checked against the policies
+func TestValidateKernelArguments(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	dn := &Daemon{kapLister: mcfglistersv1.NewKernelArgumentPolicyLister(indexer)}
+	oldConfig := helpers.NewMachineConfig("old", nil, "", nil)
+	oldConfig.Spec.KernelArguments = []string{"enforcing=0"}
+	newConfig := helpers.NewMachineConfig("new", nil, "", nil)
+	newConfig.Spec.KernelArguments = []string{"enforcing=0 selinux=0"}
+
+	assert.EqualError(t, dn.validateKernelArguments(oldConfig, newConfig), "kernel arguments denied by policy: selinux=0")
+
+	require.Nil(t, indexer.Add(&mcfgv1.KernelArgumentPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "allow-selinux"},
+		Spec:       mcfgv1.KernelArgumentPolicySpec{AllowedKernelArguments: []string{"selinux"}},
+	}))
+	assert.Nil(t, dn.validateKernelArguments(oldConfig, newConfig))
+
+	// without a cluster the configs were validated when rendered
+	dn.kapLister = nil
+	require.Nil(t, indexer.Delete(&mcfgv1.KernelArgumentPolicy{ObjectMeta: metav1.ObjectMeta{Name: "allow-selinux"}}))
+	assert.Nil(t, dn.validateKernelArguments(oldConfig, newConfig))
+}
+
+// TestRestartCrioUpdate checks a default runtime switch drains the node, restarts crio rather
+// than rebooting, and uncordons the node once done
+func TestRestartCrioUpdate(t *testing.T) {
+	recorder := helpers.NewCommandRecorder()
+	defer setHost(recorder, nil, nil)()
+
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0", Annotations: map[string]string{
+		constants.CurrentMachineConfigAnnotationKey:     "rendered-worker-runc",
+		constants.DesiredMachineConfigAnnotationKey:     "rendered-worker-crun",
+		constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateWorking,
+	}}}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"}, Spec: corev1.PodSpec{NodeName: node.Name}}
+	kubeClient := k8sfake.NewSimpleClientset(node, pod)
+	nodeIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	require.Nil(t, nodeIndexer.Add(node))
+	mcIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	require.Nil(t, mcIndexer.Add(helpers.NewMachineConfig("rendered-worker-runc", nil, "", nil)))
+	require.Nil(t, mcIndexer.Add(helpers.NewMachineConfig("rendered-worker-crun", nil, "", nil)))
+	nodeWriter := newNodeWriter(nil)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go nodeWriter.Run(stopCh)
+	dn := &Daemon{
+		name:                  node.Name,
+		node:                  node,
+		kubeClient:            kubeClient,
+		nodeLister:            corev1lister.NewNodeLister(nodeIndexer),
+		mcLister:              mcfglistersv1.NewMachineConfigLister(mcIndexer),
+		nodeWriter:            nodeWriter,
+		recorder:              record.NewFakeRecorder(100),
+		loggerSupportsJournal: true,
+		drainer: &drain.Helper{
+			Client:              kubeClient,
+			Force:               true,
+			IgnoreAllDaemonSets: true,
+			DeleteEmptyDirData:  true,
+			GracePeriodSeconds:  -1,
+			Timeout:             time.Minute,
+			Out:                 ioutil.Discard,
+			ErrOut:              ioutil.Discard,
+		},
+	}
+	getNode := func() *corev1.Node {
+		node, err := kubeClient.CoreV1().Nodes().Get(context.TODO(), node.Name, metav1.GetOptions{})
+		require.Nil(t, err)
+		return node
+	}
+
+	runtimeFile := func(runtime string) ign3types.File {
+		return ign3types.File{
+			Node: ign3types.Node{Path: "/etc/crio/crio.conf.d/01-ctrcfg-defaultRuntime"},
+			FileEmbedded1: ign3types.FileEmbedded1{
+				Contents: ign3types.Resource{Source: helpers.StrToPtr(dataurl.EncodeBytes([]byte("default_runtime = \"" + runtime + "\"\n")))},
+			},
+		}
+	}
+	actions, err := calculatePostConfigChangeAction(
+		helpers.NewMachineConfig("rendered-worker-runc", nil, "", []ign3types.File{runtimeFile("runc")}),
+		helpers.NewMachineConfig("rendered-worker-crun", nil, "", []ign3types.File{runtimeFile("crun")}))
+	require.Nil(t, err)
+	require.Equal(t, []string{postConfigChangeActionRestartCrio}, actions)
+
+	// the running pods are evicted, they're recreated under the new default runtime
+	require.Nil(t, dn.performDrain())
+	assert.True(t, getNode().Spec.Unschedulable)
+	_, err = kubeClient.CoreV1().Pods(pod.Namespace).Get(context.TODO(), pod.Name, metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+	recorder.AssertNotCalled(t, "systemctl", "restart", "crio")
+
+	require.Nil(t, dn.performPostConfigChangeAction(context.Background(), actions, "rendered-worker-crun", nil))
+	recorder.AssertCalled(t, "systemctl", "restart", "crio")
+	recorder.AssertCalled(t, "systemctl", "is-active", "crio")
+	recorder.AssertNotCalled(t, "systemd-run")
+	assert.False(t, getNode().Spec.Unschedulable)
+	require.Eventually(t, func() bool {
+		return getNode().Annotations[constants.CurrentMachineConfigAnnotationKey] == "rendered-worker-crun"
+	}, 10*time.Second, 100*time.Millisecond)
+}
+
+// The node controller moves the node back to its current config when its drain gets stuck, the
+// update doesn't go on once the node is drained then
+func TestAbortUpdateOnDesiredConfigChange(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0", Annotations: map[string]string{
+		constants.CurrentMachineConfigAnnotationKey:     "rendered-worker-old",
+		constants.DesiredMachineConfigAnnotationKey:     "rendered-worker-new",
+		constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateWorking,
+	}}, Spec: corev1.NodeSpec{Unschedulable: true}}
+	kubeClient := k8sfake.NewSimpleClientset(node)
+	nodeIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	require.Nil(t, nodeIndexer.Add(node))
+	nodeWriter := newNodeWriter(nil)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go nodeWriter.Run(stopCh)
+	dn := &Daemon{
+		name:       node.Name,
+		node:       node,
+		kubeClient: kubeClient,
+		nodeLister: corev1lister.NewNodeLister(nodeIndexer),
+		nodeWriter: nodeWriter,
+		recorder:   record.NewFakeRecorder(100),
+		drainer: &drain.Helper{
+			Client: kubeClient,
+			Out:    ioutil.Discard,
+			ErrOut: ioutil.Discard,
+		},
+	}
+
+	_, changed := dn.desiredConfigChanged("rendered-worker-new")
+	assert.False(t, changed)
+
+	moved := node.DeepCopy()
+	moved.Annotations[constants.DesiredMachineConfigAnnotationKey] = "rendered-worker-old"
+	require.Nil(t, nodeIndexer.Update(moved))
+	desired, changed := dn.desiredConfigChanged("rendered-worker-new")
+	require.True(t, changed)
+	assert.Equal(t, "rendered-worker-old", desired)
+
+	require.Nil(t, dn.abortUpdate("rendered-worker-old", desired))
+	updated, err := kubeClient.CoreV1().Nodes().Get(context.TODO(), node.Name, metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.False(t, updated.Spec.Unschedulable)
+	assert.Equal(t, constants.MachineConfigDaemonStateDone, updated.Annotations[constants.MachineConfigDaemonStateAnnotationKey])
+	assert.Equal(t, "rendered-worker-old", updated.Annotations[constants.CurrentMachineConfigAnnotationKey])
+}
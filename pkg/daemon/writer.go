@@ -19,6 +19,10 @@ const (
 	machineConfigDaemonSSHAccessAnnotationKey = "machineconfiguration.openshift.io/ssh"
 	// MachineConfigDaemonSSHAccessValue is the annotation value applied when ssh access is detected
 	machineConfigDaemonSSHAccessValue = "accessed"
+	// lastRebootReasonAnnotationKey is set to the JSON encoded reason of the last reboot of the node
+	lastRebootReasonAnnotationKey = "machineconfiguration.openshift.io/lastRebootReason"
+	// lastBootIDAnnotationKey is the last boot of the node the daemon ran in
+	lastBootIDAnnotationKey = "machineconfiguration.openshift.io/lastBootID"
 )
 
 // message wraps a client and responseChannel
@@ -33,6 +37,8 @@ type message struct {
 // clusterNodeWriter is a single writer to Kubernetes to prevent race conditions
 type clusterNodeWriter struct {
 	writer chan message
+	// written, if set, is called with the node after each write
+	written func(*corev1.Node)
 }
 
 // NodeWriter is the interface to implement a single writer to Kubernetes to prevent race conditions
@@ -43,12 +49,26 @@ type NodeWriter interface {
 	SetUnreconcilable(err error, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error
 	SetDegraded(err error, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error
 	SetSSHAccessed(client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error
+	SetKubeletCertRecovery(state string, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error
+	SetRebootReason(reason string, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error
+	SetLastBootID(bootID string, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error
+	SetCurrentReboot(request string, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error
+	SetCurrentImagePull(config string, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error
+	SetDrainStarted(started string, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error
+	SetPendingOSUpdateDiff(diff string, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error
+	SetOSRollback(request, image string, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error
+	SetLiveApplied(config string, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error
+	SetPinnedOSImage(image string, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error
+	SetKernelArgumentsDrift(drift string, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error
+	SetScheduledReboot(when string, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error
+	SetPinnedDeployment(id string, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error
 }
 
-// newNodeWriter Create a new NodeWriter
-func newNodeWriter() NodeWriter {
+// newNodeWriter Create a new NodeWriter calling written, if set, with the node after each write
+func newNodeWriter(written func(*corev1.Node)) NodeWriter {
 	return &clusterNodeWriter{
-		writer: make(chan message, defaultWriterQueue),
+		writer:  make(chan message, defaultWriterQueue),
+		written: written,
 	}
 }
 
@@ -60,8 +80,11 @@ func (nw *clusterNodeWriter) Run(stop <-chan struct{}) {
 		case <-stop:
 			return
 		case msg := <-nw.writer:
-			_, err := setNodeAnnotations(msg.client, msg.lister, msg.node, msg.annos)
+			node, err := setNodeAnnotations(msg.client, msg.lister, msg.node, msg.annos)
 			msg.responseChannel <- err
+			if err == nil && nw.written != nil {
+				nw.written(node)
+			}
 		}
 	}
 }
@@ -173,6 +196,216 @@ func (nw *clusterNodeWriter) SetSSHAccessed(client corev1client.NodeInterface, l
 	return <-respChan
 }
 
+// SetKubeletCertRecovery sets the state of the kubelet client certificate recovery, empty once it is done
+func (nw *clusterNodeWriter) SetKubeletCertRecovery(state string, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error {
+	annos := map[string]string{
+		constants.KubeletCertRecoveryAnnotationKey: state,
+	}
+	respChan := make(chan error, 1)
+	nw.writer <- message{
+		client:          client,
+		lister:          lister,
+		node:            node,
+		annos:           annos,
+		responseChannel: respChan,
+	}
+	return <-respChan
+}
+
+// SetRebootReason sets the reason of the last reboot
+func (nw *clusterNodeWriter) SetRebootReason(reason string, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error {
+	annos := map[string]string{
+		lastRebootReasonAnnotationKey: reason,
+	}
+	respChan := make(chan error, 1)
+	nw.writer <- message{
+		client:          client,
+		lister:          lister,
+		node:            node,
+		annos:           annos,
+		responseChannel: respChan,
+	}
+	return <-respChan
+}
+
+// SetLastBootID sets the boot the daemon is running in
+func (nw *clusterNodeWriter) SetLastBootID(bootID string, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error {
+	annos := map[string]string{
+		lastBootIDAnnotationKey: bootID,
+	}
+	respChan := make(chan error, 1)
+	nw.writer <- message{
+		client:          client,
+		lister:          lister,
+		node:            node,
+		annos:           annos,
+		responseChannel: respChan,
+	}
+	return <-respChan
+}
+
+// SetCurrentReboot sets the reboot request of the pool the node is rebooting for
+func (nw *clusterNodeWriter) SetCurrentReboot(request string, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error {
+	annos := map[string]string{
+		constants.CurrentRebootAnnotationKey: request,
+	}
+	respChan := make(chan error, 1)
+	nw.writer <- message{
+		client:          client,
+		lister:          lister,
+		node:            node,
+		annos:           annos,
+		responseChannel: respChan,
+	}
+	return <-respChan
+}
+
+// SetCurrentImagePull sets the config the node pre-pulled the OS image of
+func (nw *clusterNodeWriter) SetCurrentImagePull(config string, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error {
+	annos := map[string]string{
+		constants.CurrentImagePullAnnotationKey: config,
+	}
+	respChan := make(chan error, 1)
+	nw.writer <- message{
+		client:          client,
+		lister:          lister,
+		node:            node,
+		annos:           annos,
+		responseChannel: respChan,
+	}
+	return <-respChan
+}
+
+// SetDrainStarted sets when the daemon started draining the node, empty once drained
+func (nw *clusterNodeWriter) SetDrainStarted(started string, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error {
+	annos := map[string]string{
+		constants.DrainStartedAnnotationKey: started,
+	}
+	respChan := make(chan error, 1)
+	nw.writer <- message{
+		client:          client,
+		lister:          lister,
+		node:            node,
+		annos:           annos,
+		responseChannel: respChan,
+	}
+	return <-respChan
+}
+
+// SetPendingOSUpdateDiff sets the packages the staged OS update changes, empty once booted into it
+func (nw *clusterNodeWriter) SetPendingOSUpdateDiff(diff string, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error {
+	annos := map[string]string{
+		constants.PendingOSUpdateDiffAnnotationKey: diff,
+	}
+	respChan := make(chan error, 1)
+	nw.writer <- message{
+		client:          client,
+		lister:          lister,
+		node:            node,
+		annos:           annos,
+		responseChannel: respChan,
+	}
+	return <-respChan
+}
+
+// SetOSRollback sets the last OS rollback request handled and the OS image the node was rolled back to,
+// empty once updated to another one
+func (nw *clusterNodeWriter) SetOSRollback(request, image string, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error {
+	annos := map[string]string{
+		constants.CurrentRollbackOSAnnotationKey: request,
+		constants.RolledBackOSImageAnnotationKey: image,
+	}
+	respChan := make(chan error, 1)
+	nw.writer <- message{
+		client:          client,
+		lister:          lister,
+		node:            node,
+		annos:           annos,
+		responseChannel: respChan,
+	}
+	return <-respChan
+}
+
+// SetLiveApplied sets the config whose OS changes were applied live, empty once the node rebooted into them
+func (nw *clusterNodeWriter) SetLiveApplied(config string, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error {
+	annos := map[string]string{
+		constants.LiveAppliedConfigAnnotationKey: config,
+	}
+	respChan := make(chan error, 1)
+	nw.writer <- message{
+		client:          client,
+		lister:          lister,
+		node:            node,
+		annos:           annos,
+		responseChannel: respChan,
+	}
+	return <-respChan
+}
+
+// SetPinnedOSImage sets the OS image, pinned by digest, the node rebased to for the tag of its config
+func (nw *clusterNodeWriter) SetPinnedOSImage(image string, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error {
+	annos := map[string]string{
+		constants.PinnedOSImageAnnotationKey: image,
+	}
+	respChan := make(chan error, 1)
+	nw.writer <- message{
+		client:          client,
+		lister:          lister,
+		node:            node,
+		annos:           annos,
+		responseChannel: respChan,
+	}
+	return <-respChan
+}
+
+// SetKernelArgumentsDrift sets the kernel arguments of the current config missing from the node, empty if none are
+func (nw *clusterNodeWriter) SetKernelArgumentsDrift(drift string, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error {
+	annos := map[string]string{
+		constants.KernelArgumentsDriftAnnotationKey: drift,
+	}
+	respChan := make(chan error, 1)
+	nw.writer <- message{
+		client:          client,
+		lister:          lister,
+		node:            node,
+		annos:           annos,
+		responseChannel: respChan,
+	}
+	return <-respChan
+}
+
+// SetScheduledReboot sets the time the reboot of the node is scheduled at, empty if none is
+func (nw *clusterNodeWriter) SetScheduledReboot(when string, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error {
+	annos := map[string]string{
+		constants.ScheduledRebootAnnotationKey: when,
+	}
+	respChan := make(chan error, 1)
+	nw.writer <- message{
+		client:          client,
+		lister:          lister,
+		node:            node,
+		annos:           annos,
+		responseChannel: respChan,
+	}
+	return <-respChan
+}
+
+// SetPinnedDeployment sets the ID of the known-good deployment the daemon pinned, empty if it pinned none
+func (nw *clusterNodeWriter) SetPinnedDeployment(id string, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error {
+	annos := map[string]string{
+		constants.PinnedDeploymentAnnotationKey: id,
+	}
+	respChan := make(chan error, 1)
+	nw.writer <- message{
+		client:          client,
+		lister:          lister,
+		node:            node,
+		annos:           annos,
+		responseChannel: respChan,
+	}
+	return <-respChan
+}
+
 func setNodeAnnotations(client corev1client.NodeInterface, lister corev1lister.NodeLister, nodeName string, m map[string]string) (*corev1.Node, error) {
 	node, err := internal.UpdateNodeRetry(client, lister, nodeName, func(node *corev1.Node) {
 		for k, v := range m {
@@ -0,0 +1,106 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	machineconfigurationopenshiftiov1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeKernelArgumentPolicies implements KernelArgumentPolicyInterface
+type FakeKernelArgumentPolicies struct {
+	Fake *FakeMachineconfigurationV1
+}
+
+var kernelargumentpoliciesResource = schema.GroupVersionResource{Group: "machineconfiguration.openshift.io", Version: "v1", Resource: "kernelargumentpolicies"}
+
+var kernelargumentpoliciesKind = schema.GroupVersionKind{Group: "machineconfiguration.openshift.io", Version: "v1", Kind: "KernelArgumentPolicy"}
+
+// Get takes name of the kernelArgumentPolicy, and returns the corresponding kernelArgumentPolicy object, and an error if there is any.
+func (c *FakeKernelArgumentPolicies) Get(ctx context.Context, name string, options v1.GetOptions) (result *machineconfigurationopenshiftiov1.KernelArgumentPolicy, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootGetAction(kernelargumentpoliciesResource, name), &machineconfigurationopenshiftiov1.KernelArgumentPolicy{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*machineconfigurationopenshiftiov1.KernelArgumentPolicy), err
+}
+
+// List takes label and field selectors, and returns the list of KernelArgumentPolicies that match those selectors.
+func (c *FakeKernelArgumentPolicies) List(ctx context.Context, opts v1.ListOptions) (result *machineconfigurationopenshiftiov1.KernelArgumentPolicyList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootListAction(kernelargumentpoliciesResource, kernelargumentpoliciesKind, opts), &machineconfigurationopenshiftiov1.KernelArgumentPolicyList{})
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &machineconfigurationopenshiftiov1.KernelArgumentPolicyList{ListMeta: obj.(*machineconfigurationopenshiftiov1.KernelArgumentPolicyList).ListMeta}
+	for _, item := range obj.(*machineconfigurationopenshiftiov1.KernelArgumentPolicyList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested kernelArgumentPolicies.
+func (c *FakeKernelArgumentPolicies) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewRootWatchAction(kernelargumentpoliciesResource, opts))
+}
+
+// Create takes the representation of a kernelArgumentPolicy and creates it.  Returns the server's representation of the kernelArgumentPolicy, and an error, if there is any.
+func (c *FakeKernelArgumentPolicies) Create(ctx context.Context, kernelArgumentPolicy *machineconfigurationopenshiftiov1.KernelArgumentPolicy, opts v1.CreateOptions) (result *machineconfigurationopenshiftiov1.KernelArgumentPolicy, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootCreateAction(kernelargumentpoliciesResource, kernelArgumentPolicy), &machineconfigurationopenshiftiov1.KernelArgumentPolicy{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*machineconfigurationopenshiftiov1.KernelArgumentPolicy), err
+}
+
+// Update takes the representation of a kernelArgumentPolicy and updates it. Returns the server's representation of the kernelArgumentPolicy, and an error, if there is any.
+func (c *FakeKernelArgumentPolicies) Update(ctx context.Context, kernelArgumentPolicy *machineconfigurationopenshiftiov1.KernelArgumentPolicy, opts v1.UpdateOptions) (result *machineconfigurationopenshiftiov1.KernelArgumentPolicy, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootUpdateAction(kernelargumentpoliciesResource, kernelArgumentPolicy), &machineconfigurationopenshiftiov1.KernelArgumentPolicy{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*machineconfigurationopenshiftiov1.KernelArgumentPolicy), err
+}
+
+// Delete takes name of the kernelArgumentPolicy and deletes it. Returns an error if one occurs.
+func (c *FakeKernelArgumentPolicies) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewRootDeleteAction(kernelargumentpoliciesResource, name), &machineconfigurationopenshiftiov1.KernelArgumentPolicy{})
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeKernelArgumentPolicies) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewRootDeleteCollectionAction(kernelargumentpoliciesResource, listOpts)
+
+	_, err := c.Fake.Invokes(action, &machineconfigurationopenshiftiov1.KernelArgumentPolicyList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched kernelArgumentPolicy.
+func (c *FakeKernelArgumentPolicies) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *machineconfigurationopenshiftiov1.KernelArgumentPolicy, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootPatchSubresourceAction(kernelargumentpoliciesResource, name, pt, data, subresources...), &machineconfigurationopenshiftiov1.KernelArgumentPolicy{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*machineconfigurationopenshiftiov1.KernelArgumentPolicy), err
+}
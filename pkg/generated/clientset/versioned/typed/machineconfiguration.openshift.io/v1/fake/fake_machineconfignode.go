@@ -0,0 +1,117 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	machineconfigurationopenshiftiov1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeMachineConfigNodes implements MachineConfigNodeInterface
+type FakeMachineConfigNodes struct {
+	Fake *FakeMachineconfigurationV1
+}
+
+var machineconfignodesResource = schema.GroupVersionResource{Group: "machineconfiguration.openshift.io", Version: "v1", Resource: "machineconfignodes"}
+
+var machineconfignodesKind = schema.GroupVersionKind{Group: "machineconfiguration.openshift.io", Version: "v1", Kind: "MachineConfigNode"}
+
+// Get takes name of the machineConfigNode, and returns the corresponding machineConfigNode object, and an error if there is any.
+func (c *FakeMachineConfigNodes) Get(ctx context.Context, name string, options v1.GetOptions) (result *machineconfigurationopenshiftiov1.MachineConfigNode, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootGetAction(machineconfignodesResource, name), &machineconfigurationopenshiftiov1.MachineConfigNode{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*machineconfigurationopenshiftiov1.MachineConfigNode), err
+}
+
+// List takes label and field selectors, and returns the list of MachineConfigNodes that match those selectors.
+func (c *FakeMachineConfigNodes) List(ctx context.Context, opts v1.ListOptions) (result *machineconfigurationopenshiftiov1.MachineConfigNodeList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootListAction(machineconfignodesResource, machineconfignodesKind, opts), &machineconfigurationopenshiftiov1.MachineConfigNodeList{})
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &machineconfigurationopenshiftiov1.MachineConfigNodeList{ListMeta: obj.(*machineconfigurationopenshiftiov1.MachineConfigNodeList).ListMeta}
+	for _, item := range obj.(*machineconfigurationopenshiftiov1.MachineConfigNodeList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested machineConfigNodes.
+func (c *FakeMachineConfigNodes) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewRootWatchAction(machineconfignodesResource, opts))
+}
+
+// Create takes the representation of a machineConfigNode and creates it.  Returns the server's representation of the machineConfigNode, and an error, if there is any.
+func (c *FakeMachineConfigNodes) Create(ctx context.Context, machineConfigNode *machineconfigurationopenshiftiov1.MachineConfigNode, opts v1.CreateOptions) (result *machineconfigurationopenshiftiov1.MachineConfigNode, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootCreateAction(machineconfignodesResource, machineConfigNode), &machineconfigurationopenshiftiov1.MachineConfigNode{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*machineconfigurationopenshiftiov1.MachineConfigNode), err
+}
+
+// Update takes the representation of a machineConfigNode and updates it. Returns the server's representation of the machineConfigNode, and an error, if there is any.
+func (c *FakeMachineConfigNodes) Update(ctx context.Context, machineConfigNode *machineconfigurationopenshiftiov1.MachineConfigNode, opts v1.UpdateOptions) (result *machineconfigurationopenshiftiov1.MachineConfigNode, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootUpdateAction(machineconfignodesResource, machineConfigNode), &machineconfigurationopenshiftiov1.MachineConfigNode{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*machineconfigurationopenshiftiov1.MachineConfigNode), err
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeMachineConfigNodes) UpdateStatus(ctx context.Context, machineConfigNode *machineconfigurationopenshiftiov1.MachineConfigNode, opts v1.UpdateOptions) (*machineconfigurationopenshiftiov1.MachineConfigNode, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootUpdateSubresourceAction(machineconfignodesResource, "status", machineConfigNode), &machineconfigurationopenshiftiov1.MachineConfigNode{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*machineconfigurationopenshiftiov1.MachineConfigNode), err
+}
+
+// Delete takes name of the machineConfigNode and deletes it. Returns an error if one occurs.
+func (c *FakeMachineConfigNodes) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewRootDeleteAction(machineconfignodesResource, name), &machineconfigurationopenshiftiov1.MachineConfigNode{})
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeMachineConfigNodes) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewRootDeleteCollectionAction(machineconfignodesResource, listOpts)
+
+	_, err := c.Fake.Invokes(action, &machineconfigurationopenshiftiov1.MachineConfigNodeList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched machineConfigNode.
+func (c *FakeMachineConfigNodes) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *machineconfigurationopenshiftiov1.MachineConfigNode, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootPatchSubresourceAction(machineconfignodesResource, name, pt, data, subresources...), &machineconfigurationopenshiftiov1.MachineConfigNode{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*machineconfigurationopenshiftiov1.MachineConfigNode), err
+}
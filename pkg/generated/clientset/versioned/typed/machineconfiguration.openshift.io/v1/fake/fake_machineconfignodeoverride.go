@@ -0,0 +1,117 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	machineconfigurationopenshiftiov1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeMachineConfigNodeOverrides implements MachineConfigNodeOverrideInterface
+type FakeMachineConfigNodeOverrides struct {
+	Fake *FakeMachineconfigurationV1
+}
+
+var machineconfignodeoverridesResource = schema.GroupVersionResource{Group: "machineconfiguration.openshift.io", Version: "v1", Resource: "machineconfignodeoverrides"}
+
+var machineconfignodeoverridesKind = schema.GroupVersionKind{Group: "machineconfiguration.openshift.io", Version: "v1", Kind: "MachineConfigNodeOverride"}
+
+// Get takes name of the machineConfigNodeOverride, and returns the corresponding machineConfigNodeOverride object, and an error if there is any.
+func (c *FakeMachineConfigNodeOverrides) Get(ctx context.Context, name string, options v1.GetOptions) (result *machineconfigurationopenshiftiov1.MachineConfigNodeOverride, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootGetAction(machineconfignodeoverridesResource, name), &machineconfigurationopenshiftiov1.MachineConfigNodeOverride{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*machineconfigurationopenshiftiov1.MachineConfigNodeOverride), err
+}
+
+// List takes label and field selectors, and returns the list of MachineConfigNodeOverrides that match those selectors.
+func (c *FakeMachineConfigNodeOverrides) List(ctx context.Context, opts v1.ListOptions) (result *machineconfigurationopenshiftiov1.MachineConfigNodeOverrideList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootListAction(machineconfignodeoverridesResource, machineconfignodeoverridesKind, opts), &machineconfigurationopenshiftiov1.MachineConfigNodeOverrideList{})
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &machineconfigurationopenshiftiov1.MachineConfigNodeOverrideList{ListMeta: obj.(*machineconfigurationopenshiftiov1.MachineConfigNodeOverrideList).ListMeta}
+	for _, item := range obj.(*machineconfigurationopenshiftiov1.MachineConfigNodeOverrideList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested machineConfigNodeOverrides.
+func (c *FakeMachineConfigNodeOverrides) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewRootWatchAction(machineconfignodeoverridesResource, opts))
+}
+
+// Create takes the representation of a machineConfigNodeOverride and creates it.  Returns the server's representation of the machineConfigNodeOverride, and an error, if there is any.
+func (c *FakeMachineConfigNodeOverrides) Create(ctx context.Context, machineConfigNodeOverride *machineconfigurationopenshiftiov1.MachineConfigNodeOverride, opts v1.CreateOptions) (result *machineconfigurationopenshiftiov1.MachineConfigNodeOverride, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootCreateAction(machineconfignodeoverridesResource, machineConfigNodeOverride), &machineconfigurationopenshiftiov1.MachineConfigNodeOverride{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*machineconfigurationopenshiftiov1.MachineConfigNodeOverride), err
+}
+
+// Update takes the representation of a machineConfigNodeOverride and updates it. Returns the server's representation of the machineConfigNodeOverride, and an error, if there is any.
+func (c *FakeMachineConfigNodeOverrides) Update(ctx context.Context, machineConfigNodeOverride *machineconfigurationopenshiftiov1.MachineConfigNodeOverride, opts v1.UpdateOptions) (result *machineconfigurationopenshiftiov1.MachineConfigNodeOverride, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootUpdateAction(machineconfignodeoverridesResource, machineConfigNodeOverride), &machineconfigurationopenshiftiov1.MachineConfigNodeOverride{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*machineconfigurationopenshiftiov1.MachineConfigNodeOverride), err
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeMachineConfigNodeOverrides) UpdateStatus(ctx context.Context, machineConfigNodeOverride *machineconfigurationopenshiftiov1.MachineConfigNodeOverride, opts v1.UpdateOptions) (*machineconfigurationopenshiftiov1.MachineConfigNodeOverride, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootUpdateSubresourceAction(machineconfignodeoverridesResource, "status", machineConfigNodeOverride), &machineconfigurationopenshiftiov1.MachineConfigNodeOverride{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*machineconfigurationopenshiftiov1.MachineConfigNodeOverride), err
+}
+
+// Delete takes name of the machineConfigNodeOverride and deletes it. Returns an error if one occurs.
+func (c *FakeMachineConfigNodeOverrides) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewRootDeleteAction(machineconfignodeoverridesResource, name), &machineconfigurationopenshiftiov1.MachineConfigNodeOverride{})
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeMachineConfigNodeOverrides) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewRootDeleteCollectionAction(machineconfignodeoverridesResource, listOpts)
+
+	_, err := c.Fake.Invokes(action, &machineconfigurationopenshiftiov1.MachineConfigNodeOverrideList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched machineConfigNodeOverride.
+func (c *FakeMachineConfigNodeOverrides) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *machineconfigurationopenshiftiov1.MachineConfigNodeOverride, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootPatchSubresourceAction(machineconfignodeoverridesResource, name, pt, data, subresources...), &machineconfigurationopenshiftiov1.MachineConfigNodeOverride{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*machineconfigurationopenshiftiov1.MachineConfigNodeOverride), err
+}
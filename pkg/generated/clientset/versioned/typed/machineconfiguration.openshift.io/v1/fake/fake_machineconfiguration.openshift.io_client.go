@@ -20,6 +20,10 @@ func (c *FakeMachineconfigurationV1) ControllerConfigs() v1.ControllerConfigInte
 	return &FakeControllerConfigs{c}
 }
 
+func (c *FakeMachineconfigurationV1) KernelArgumentPolicies() v1.KernelArgumentPolicyInterface {
+	return &FakeKernelArgumentPolicies{c}
+}
+
 func (c *FakeMachineconfigurationV1) KubeletConfigs() v1.KubeletConfigInterface {
 	return &FakeKubeletConfigs{c}
 }
@@ -28,10 +32,22 @@ func (c *FakeMachineconfigurationV1) MachineConfigs() v1.MachineConfigInterface
 	return &FakeMachineConfigs{c}
 }
 
+func (c *FakeMachineconfigurationV1) MachineConfigNodes() v1.MachineConfigNodeInterface {
+	return &FakeMachineConfigNodes{c}
+}
+
+func (c *FakeMachineconfigurationV1) MachineConfigNodeOverrides() v1.MachineConfigNodeOverrideInterface {
+	return &FakeMachineConfigNodeOverrides{c}
+}
+
 func (c *FakeMachineconfigurationV1) MachineConfigPools() v1.MachineConfigPoolInterface {
 	return &FakeMachineConfigPools{c}
 }
 
+func (c *FakeMachineconfigurationV1) NodeConfigs() v1.NodeConfigInterface {
+	return &FakeNodeConfigs{c}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeMachineconfigurationV1) RESTClient() rest.Interface {
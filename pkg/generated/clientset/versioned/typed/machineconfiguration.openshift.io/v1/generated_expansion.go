@@ -6,8 +6,16 @@ type ContainerRuntimeConfigExpansion interface{}
 
 type ControllerConfigExpansion interface{}
 
+type KernelArgumentPolicyExpansion interface{}
+
 type KubeletConfigExpansion interface{}
 
 type MachineConfigExpansion interface{}
 
+type MachineConfigNodeExpansion interface{}
+
+type MachineConfigNodeOverrideExpansion interface{}
+
 type MachineConfigPoolExpansion interface{}
+
+type NodeConfigExpansion interface{}
@@ -0,0 +1,152 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	"context"
+	"time"
+
+	v1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	scheme "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/scheme"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// KernelArgumentPoliciesGetter has a method to return a KernelArgumentPolicyInterface.
+// A group's client should implement this interface.
+type KernelArgumentPoliciesGetter interface {
+	KernelArgumentPolicies() KernelArgumentPolicyInterface
+}
+
+// KernelArgumentPolicyInterface has methods to work with KernelArgumentPolicy resources.
+type KernelArgumentPolicyInterface interface {
+	Create(ctx context.Context, kernelArgumentPolicy *v1.KernelArgumentPolicy, opts metav1.CreateOptions) (*v1.KernelArgumentPolicy, error)
+	Update(ctx context.Context, kernelArgumentPolicy *v1.KernelArgumentPolicy, opts metav1.UpdateOptions) (*v1.KernelArgumentPolicy, error)
+	Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1.KernelArgumentPolicy, error)
+	List(ctx context.Context, opts metav1.ListOptions) (*v1.KernelArgumentPolicyList, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.KernelArgumentPolicy, err error)
+	KernelArgumentPolicyExpansion
+}
+
+// kernelArgumentPolicies implements KernelArgumentPolicyInterface
+type kernelArgumentPolicies struct {
+	client rest.Interface
+}
+
+// newKernelArgumentPolicies returns a KernelArgumentPolicies
+func newKernelArgumentPolicies(c *MachineconfigurationV1Client) *kernelArgumentPolicies {
+	return &kernelArgumentPolicies{
+		client: c.RESTClient(),
+	}
+}
+
+// Get takes name of the kernelArgumentPolicy, and returns the corresponding kernelArgumentPolicy object, and an error if there is any.
+func (c *kernelArgumentPolicies) Get(ctx context.Context, name string, options metav1.GetOptions) (result *v1.KernelArgumentPolicy, err error) {
+	result = &v1.KernelArgumentPolicy{}
+	err = c.client.Get().
+		Resource("kernelargumentpolicies").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of KernelArgumentPolicies that match those selectors.
+func (c *kernelArgumentPolicies) List(ctx context.Context, opts metav1.ListOptions) (result *v1.KernelArgumentPolicyList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1.KernelArgumentPolicyList{}
+	err = c.client.Get().
+		Resource("kernelargumentpolicies").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested kernelArgumentPolicies.
+func (c *kernelArgumentPolicies) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Resource("kernelargumentpolicies").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a kernelArgumentPolicy and creates it.  Returns the server's representation of the kernelArgumentPolicy, and an error, if there is any.
+func (c *kernelArgumentPolicies) Create(ctx context.Context, kernelArgumentPolicy *v1.KernelArgumentPolicy, opts metav1.CreateOptions) (result *v1.KernelArgumentPolicy, err error) {
+	result = &v1.KernelArgumentPolicy{}
+	err = c.client.Post().
+		Resource("kernelargumentpolicies").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(kernelArgumentPolicy).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a kernelArgumentPolicy and updates it. Returns the server's representation of the kernelArgumentPolicy, and an error, if there is any.
+func (c *kernelArgumentPolicies) Update(ctx context.Context, kernelArgumentPolicy *v1.KernelArgumentPolicy, opts metav1.UpdateOptions) (result *v1.KernelArgumentPolicy, err error) {
+	result = &v1.KernelArgumentPolicy{}
+	err = c.client.Put().
+		Resource("kernelargumentpolicies").
+		Name(kernelArgumentPolicy.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(kernelArgumentPolicy).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the kernelArgumentPolicy and deletes it. Returns an error if one occurs.
+func (c *kernelArgumentPolicies) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	return c.client.Delete().
+		Resource("kernelargumentpolicies").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *kernelArgumentPolicies) DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Resource("kernelargumentpolicies").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched kernelArgumentPolicy.
+func (c *kernelArgumentPolicies) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.KernelArgumentPolicy, err error) {
+	result = &v1.KernelArgumentPolicy{}
+	err = c.client.Patch(pt).
+		Resource("kernelargumentpolicies").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
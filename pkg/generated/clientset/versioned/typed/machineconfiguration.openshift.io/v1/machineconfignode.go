@@ -0,0 +1,168 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	"context"
+	"time"
+
+	v1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	scheme "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/scheme"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// MachineConfigNodesGetter has a method to return a MachineConfigNodeInterface.
+// A group's client should implement this interface.
+type MachineConfigNodesGetter interface {
+	MachineConfigNodes() MachineConfigNodeInterface
+}
+
+// MachineConfigNodeInterface has methods to work with MachineConfigNode resources.
+type MachineConfigNodeInterface interface {
+	Create(ctx context.Context, machineConfigNode *v1.MachineConfigNode, opts metav1.CreateOptions) (*v1.MachineConfigNode, error)
+	Update(ctx context.Context, machineConfigNode *v1.MachineConfigNode, opts metav1.UpdateOptions) (*v1.MachineConfigNode, error)
+	UpdateStatus(ctx context.Context, machineConfigNode *v1.MachineConfigNode, opts metav1.UpdateOptions) (*v1.MachineConfigNode, error)
+	Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1.MachineConfigNode, error)
+	List(ctx context.Context, opts metav1.ListOptions) (*v1.MachineConfigNodeList, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.MachineConfigNode, err error)
+	MachineConfigNodeExpansion
+}
+
+// machineConfigNodes implements MachineConfigNodeInterface
+type machineConfigNodes struct {
+	client rest.Interface
+}
+
+// newMachineConfigNodes returns a MachineConfigNodes
+func newMachineConfigNodes(c *MachineconfigurationV1Client) *machineConfigNodes {
+	return &machineConfigNodes{
+		client: c.RESTClient(),
+	}
+}
+
+// Get takes name of the machineConfigNode, and returns the corresponding machineConfigNode object, and an error if there is any.
+func (c *machineConfigNodes) Get(ctx context.Context, name string, options metav1.GetOptions) (result *v1.MachineConfigNode, err error) {
+	result = &v1.MachineConfigNode{}
+	err = c.client.Get().
+		Resource("machineconfignodes").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of MachineConfigNodes that match those selectors.
+func (c *machineConfigNodes) List(ctx context.Context, opts metav1.ListOptions) (result *v1.MachineConfigNodeList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1.MachineConfigNodeList{}
+	err = c.client.Get().
+		Resource("machineconfignodes").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested machineConfigNodes.
+func (c *machineConfigNodes) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Resource("machineconfignodes").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a machineConfigNode and creates it.  Returns the server's representation of the machineConfigNode, and an error, if there is any.
+func (c *machineConfigNodes) Create(ctx context.Context, machineConfigNode *v1.MachineConfigNode, opts metav1.CreateOptions) (result *v1.MachineConfigNode, err error) {
+	result = &v1.MachineConfigNode{}
+	err = c.client.Post().
+		Resource("machineconfignodes").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(machineConfigNode).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a machineConfigNode and updates it. Returns the server's representation of the machineConfigNode, and an error, if there is any.
+func (c *machineConfigNodes) Update(ctx context.Context, machineConfigNode *v1.MachineConfigNode, opts metav1.UpdateOptions) (result *v1.MachineConfigNode, err error) {
+	result = &v1.MachineConfigNode{}
+	err = c.client.Put().
+		Resource("machineconfignodes").
+		Name(machineConfigNode.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(machineConfigNode).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *machineConfigNodes) UpdateStatus(ctx context.Context, machineConfigNode *v1.MachineConfigNode, opts metav1.UpdateOptions) (result *v1.MachineConfigNode, err error) {
+	result = &v1.MachineConfigNode{}
+	err = c.client.Put().
+		Resource("machineconfignodes").
+		Name(machineConfigNode.Name).
+		SubResource("status").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(machineConfigNode).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the machineConfigNode and deletes it. Returns an error if one occurs.
+func (c *machineConfigNodes) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	return c.client.Delete().
+		Resource("machineconfignodes").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *machineConfigNodes) DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Resource("machineconfignodes").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched machineConfigNode.
+func (c *machineConfigNodes) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.MachineConfigNode, err error) {
+	result = &v1.MachineConfigNode{}
+	err = c.client.Patch(pt).
+		Resource("machineconfignodes").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
@@ -0,0 +1,168 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	"context"
+	"time"
+
+	v1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	scheme "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/scheme"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// MachineConfigNodeOverridesGetter has a method to return a MachineConfigNodeOverrideInterface.
+// A group's client should implement this interface.
+type MachineConfigNodeOverridesGetter interface {
+	MachineConfigNodeOverrides() MachineConfigNodeOverrideInterface
+}
+
+// MachineConfigNodeOverrideInterface has methods to work with MachineConfigNodeOverride resources.
+type MachineConfigNodeOverrideInterface interface {
+	Create(ctx context.Context, machineConfigNodeOverride *v1.MachineConfigNodeOverride, opts metav1.CreateOptions) (*v1.MachineConfigNodeOverride, error)
+	Update(ctx context.Context, machineConfigNodeOverride *v1.MachineConfigNodeOverride, opts metav1.UpdateOptions) (*v1.MachineConfigNodeOverride, error)
+	UpdateStatus(ctx context.Context, machineConfigNodeOverride *v1.MachineConfigNodeOverride, opts metav1.UpdateOptions) (*v1.MachineConfigNodeOverride, error)
+	Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1.MachineConfigNodeOverride, error)
+	List(ctx context.Context, opts metav1.ListOptions) (*v1.MachineConfigNodeOverrideList, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.MachineConfigNodeOverride, err error)
+	MachineConfigNodeOverrideExpansion
+}
+
+// machineConfigNodeOverrides implements MachineConfigNodeOverrideInterface
+type machineConfigNodeOverrides struct {
+	client rest.Interface
+}
+
+// newMachineConfigNodeOverrides returns a MachineConfigNodeOverrides
+func newMachineConfigNodeOverrides(c *MachineconfigurationV1Client) *machineConfigNodeOverrides {
+	return &machineConfigNodeOverrides{
+		client: c.RESTClient(),
+	}
+}
+
+// Get takes name of the machineConfigNodeOverride, and returns the corresponding machineConfigNodeOverride object, and an error if there is any.
+func (c *machineConfigNodeOverrides) Get(ctx context.Context, name string, options metav1.GetOptions) (result *v1.MachineConfigNodeOverride, err error) {
+	result = &v1.MachineConfigNodeOverride{}
+	err = c.client.Get().
+		Resource("machineconfignodeoverrides").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of MachineConfigNodeOverrides that match those selectors.
+func (c *machineConfigNodeOverrides) List(ctx context.Context, opts metav1.ListOptions) (result *v1.MachineConfigNodeOverrideList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1.MachineConfigNodeOverrideList{}
+	err = c.client.Get().
+		Resource("machineconfignodeoverrides").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested machineConfigNodeOverrides.
+func (c *machineConfigNodeOverrides) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Resource("machineconfignodeoverrides").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a machineConfigNodeOverride and creates it.  Returns the server's representation of the machineConfigNodeOverride, and an error, if there is any.
+func (c *machineConfigNodeOverrides) Create(ctx context.Context, machineConfigNodeOverride *v1.MachineConfigNodeOverride, opts metav1.CreateOptions) (result *v1.MachineConfigNodeOverride, err error) {
+	result = &v1.MachineConfigNodeOverride{}
+	err = c.client.Post().
+		Resource("machineconfignodeoverrides").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(machineConfigNodeOverride).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a machineConfigNodeOverride and updates it. Returns the server's representation of the machineConfigNodeOverride, and an error, if there is any.
+func (c *machineConfigNodeOverrides) Update(ctx context.Context, machineConfigNodeOverride *v1.MachineConfigNodeOverride, opts metav1.UpdateOptions) (result *v1.MachineConfigNodeOverride, err error) {
+	result = &v1.MachineConfigNodeOverride{}
+	err = c.client.Put().
+		Resource("machineconfignodeoverrides").
+		Name(machineConfigNodeOverride.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(machineConfigNodeOverride).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *machineConfigNodeOverrides) UpdateStatus(ctx context.Context, machineConfigNodeOverride *v1.MachineConfigNodeOverride, opts metav1.UpdateOptions) (result *v1.MachineConfigNodeOverride, err error) {
+	result = &v1.MachineConfigNodeOverride{}
+	err = c.client.Put().
+		Resource("machineconfignodeoverrides").
+		Name(machineConfigNodeOverride.Name).
+		SubResource("status").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(machineConfigNodeOverride).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the machineConfigNodeOverride and deletes it. Returns an error if one occurs.
+func (c *machineConfigNodeOverrides) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	return c.client.Delete().
+		Resource("machineconfignodeoverrides").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *machineConfigNodeOverrides) DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Resource("machineconfignodeoverrides").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched machineConfigNodeOverride.
+func (c *machineConfigNodeOverrides) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.MachineConfigNodeOverride, err error) {
+	result = &v1.MachineConfigNodeOverride{}
+	err = c.client.Patch(pt).
+		Resource("machineconfignodeoverrides").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
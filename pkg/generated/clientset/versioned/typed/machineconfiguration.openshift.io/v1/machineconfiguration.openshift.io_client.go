@@ -12,9 +12,13 @@ type MachineconfigurationV1Interface interface {
 	RESTClient() rest.Interface
 	ContainerRuntimeConfigsGetter
 	ControllerConfigsGetter
+	KernelArgumentPoliciesGetter
 	KubeletConfigsGetter
 	MachineConfigsGetter
+	MachineConfigNodesGetter
+	MachineConfigNodeOverridesGetter
 	MachineConfigPoolsGetter
+	NodeConfigsGetter
 }
 
 // MachineconfigurationV1Client is used to interact with features provided by the machineconfiguration.openshift.io group.
@@ -30,6 +34,10 @@ func (c *MachineconfigurationV1Client) ControllerConfigs() ControllerConfigInter
 	return newControllerConfigs(c)
 }
 
+func (c *MachineconfigurationV1Client) KernelArgumentPolicies() KernelArgumentPolicyInterface {
+	return newKernelArgumentPolicies(c)
+}
+
 func (c *MachineconfigurationV1Client) KubeletConfigs() KubeletConfigInterface {
 	return newKubeletConfigs(c)
 }
@@ -38,10 +46,22 @@ func (c *MachineconfigurationV1Client) MachineConfigs() MachineConfigInterface {
 	return newMachineConfigs(c)
 }
 
+func (c *MachineconfigurationV1Client) MachineConfigNodes() MachineConfigNodeInterface {
+	return newMachineConfigNodes(c)
+}
+
+func (c *MachineconfigurationV1Client) MachineConfigNodeOverrides() MachineConfigNodeOverrideInterface {
+	return newMachineConfigNodeOverrides(c)
+}
+
 func (c *MachineconfigurationV1Client) MachineConfigPools() MachineConfigPoolInterface {
 	return newMachineConfigPools(c)
 }
 
+func (c *MachineconfigurationV1Client) NodeConfigs() NodeConfigInterface {
+	return newNodeConfigs(c)
+}
+
 // NewForConfig creates a new MachineconfigurationV1Client for the given config.
 func NewForConfig(c *rest.Config) (*MachineconfigurationV1Client, error) {
 	config := *c
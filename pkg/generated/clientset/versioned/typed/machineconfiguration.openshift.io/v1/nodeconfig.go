@@ -0,0 +1,168 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	"context"
+	"time"
+
+	v1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	scheme "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/scheme"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// NodeConfigsGetter has a method to return a NodeConfigInterface.
+// A group's client should implement this interface.
+type NodeConfigsGetter interface {
+	NodeConfigs() NodeConfigInterface
+}
+
+// NodeConfigInterface has methods to work with NodeConfig resources.
+type NodeConfigInterface interface {
+	Create(ctx context.Context, nodeConfig *v1.NodeConfig, opts metav1.CreateOptions) (*v1.NodeConfig, error)
+	Update(ctx context.Context, nodeConfig *v1.NodeConfig, opts metav1.UpdateOptions) (*v1.NodeConfig, error)
+	UpdateStatus(ctx context.Context, nodeConfig *v1.NodeConfig, opts metav1.UpdateOptions) (*v1.NodeConfig, error)
+	Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1.NodeConfig, error)
+	List(ctx context.Context, opts metav1.ListOptions) (*v1.NodeConfigList, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.NodeConfig, err error)
+	NodeConfigExpansion
+}
+
+// nodeConfigs implements NodeConfigInterface
+type nodeConfigs struct {
+	client rest.Interface
+}
+
+// newNodeConfigs returns a NodeConfigs
+func newNodeConfigs(c *MachineconfigurationV1Client) *nodeConfigs {
+	return &nodeConfigs{
+		client: c.RESTClient(),
+	}
+}
+
+// Get takes name of the nodeConfig, and returns the corresponding nodeConfig object, and an error if there is any.
+func (c *nodeConfigs) Get(ctx context.Context, name string, options metav1.GetOptions) (result *v1.NodeConfig, err error) {
+	result = &v1.NodeConfig{}
+	err = c.client.Get().
+		Resource("nodeconfigs").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of NodeConfigs that match those selectors.
+func (c *nodeConfigs) List(ctx context.Context, opts metav1.ListOptions) (result *v1.NodeConfigList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1.NodeConfigList{}
+	err = c.client.Get().
+		Resource("nodeconfigs").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested nodeConfigs.
+func (c *nodeConfigs) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Resource("nodeconfigs").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a nodeConfig and creates it.  Returns the server's representation of the nodeConfig, and an error, if there is any.
+func (c *nodeConfigs) Create(ctx context.Context, nodeConfig *v1.NodeConfig, opts metav1.CreateOptions) (result *v1.NodeConfig, err error) {
+	result = &v1.NodeConfig{}
+	err = c.client.Post().
+		Resource("nodeconfigs").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(nodeConfig).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a nodeConfig and updates it. Returns the server's representation of the nodeConfig, and an error, if there is any.
+func (c *nodeConfigs) Update(ctx context.Context, nodeConfig *v1.NodeConfig, opts metav1.UpdateOptions) (result *v1.NodeConfig, err error) {
+	result = &v1.NodeConfig{}
+	err = c.client.Put().
+		Resource("nodeconfigs").
+		Name(nodeConfig.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(nodeConfig).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *nodeConfigs) UpdateStatus(ctx context.Context, nodeConfig *v1.NodeConfig, opts metav1.UpdateOptions) (result *v1.NodeConfig, err error) {
+	result = &v1.NodeConfig{}
+	err = c.client.Put().
+		Resource("nodeconfigs").
+		Name(nodeConfig.Name).
+		SubResource("status").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(nodeConfig).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the nodeConfig and deletes it. Returns an error if one occurs.
+func (c *nodeConfigs) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	return c.client.Delete().
+		Resource("nodeconfigs").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *nodeConfigs) DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Resource("nodeconfigs").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched nodeConfig.
+func (c *nodeConfigs) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.NodeConfig, err error) {
+	result = &v1.NodeConfig{}
+	err = c.client.Patch(pt).
+		Resource("nodeconfigs").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
@@ -41,12 +41,20 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Machineconfiguration().V1().ContainerRuntimeConfigs().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("controllerconfigs"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Machineconfiguration().V1().ControllerConfigs().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("kernelargumentpolicies"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Machineconfiguration().V1().KernelArgumentPolicies().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("kubeletconfigs"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Machineconfiguration().V1().KubeletConfigs().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("machineconfigs"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Machineconfiguration().V1().MachineConfigs().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("machineconfignodes"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Machineconfiguration().V1().MachineConfigNodes().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("machineconfignodeoverrides"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Machineconfiguration().V1().MachineConfigNodeOverrides().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("machineconfigpools"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Machineconfiguration().V1().MachineConfigPools().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("nodeconfigs"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Machineconfiguration().V1().NodeConfigs().Informer()}, nil
 
 	}
 
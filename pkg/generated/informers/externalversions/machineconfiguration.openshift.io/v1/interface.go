@@ -12,12 +12,20 @@ type Interface interface {
 	ContainerRuntimeConfigs() ContainerRuntimeConfigInformer
 	// ControllerConfigs returns a ControllerConfigInformer.
 	ControllerConfigs() ControllerConfigInformer
+	// KernelArgumentPolicies returns a KernelArgumentPolicyInformer.
+	KernelArgumentPolicies() KernelArgumentPolicyInformer
 	// KubeletConfigs returns a KubeletConfigInformer.
 	KubeletConfigs() KubeletConfigInformer
 	// MachineConfigs returns a MachineConfigInformer.
 	MachineConfigs() MachineConfigInformer
+	// MachineConfigNodes returns a MachineConfigNodeInformer.
+	MachineConfigNodes() MachineConfigNodeInformer
+	// MachineConfigNodeOverrides returns a MachineConfigNodeOverrideInformer.
+	MachineConfigNodeOverrides() MachineConfigNodeOverrideInformer
 	// MachineConfigPools returns a MachineConfigPoolInformer.
 	MachineConfigPools() MachineConfigPoolInformer
+	// NodeConfigs returns a NodeConfigInformer.
+	NodeConfigs() NodeConfigInformer
 }
 
 type version struct {
@@ -41,6 +49,11 @@ func (v *version) ControllerConfigs() ControllerConfigInformer {
 	return &controllerConfigInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
 }
 
+// KernelArgumentPolicies returns a KernelArgumentPolicyInformer.
+func (v *version) KernelArgumentPolicies() KernelArgumentPolicyInformer {
+	return &kernelArgumentPolicyInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
 // KubeletConfigs returns a KubeletConfigInformer.
 func (v *version) KubeletConfigs() KubeletConfigInformer {
 	return &kubeletConfigInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
@@ -51,7 +64,22 @@ func (v *version) MachineConfigs() MachineConfigInformer {
 	return &machineConfigInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
 }
 
+// MachineConfigNodes returns a MachineConfigNodeInformer.
+func (v *version) MachineConfigNodes() MachineConfigNodeInformer {
+	return &machineConfigNodeInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// MachineConfigNodeOverrides returns a MachineConfigNodeOverrideInformer.
+func (v *version) MachineConfigNodeOverrides() MachineConfigNodeOverrideInformer {
+	return &machineConfigNodeOverrideInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
 // MachineConfigPools returns a MachineConfigPoolInformer.
 func (v *version) MachineConfigPools() MachineConfigPoolInformer {
 	return &machineConfigPoolInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
 }
+
+// NodeConfigs returns a NodeConfigInformer.
+func (v *version) NodeConfigs() NodeConfigInformer {
+	return &nodeConfigInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
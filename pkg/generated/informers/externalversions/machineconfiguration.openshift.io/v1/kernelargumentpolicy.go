@@ -0,0 +1,73 @@
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	"context"
+	time "time"
+
+	machineconfigurationopenshiftiov1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	versioned "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned"
+	internalinterfaces "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions/internalinterfaces"
+	v1 "github.com/openshift/machine-config-operator/pkg/generated/listers/machineconfiguration.openshift.io/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// KernelArgumentPolicyInformer provides access to a shared informer and lister for
+// KernelArgumentPolicies.
+type KernelArgumentPolicyInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() v1.KernelArgumentPolicyLister
+}
+
+type kernelArgumentPolicyInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+}
+
+// NewKernelArgumentPolicyInformer constructs a new informer for KernelArgumentPolicy type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewKernelArgumentPolicyInformer(client versioned.Interface, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredKernelArgumentPolicyInformer(client, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredKernelArgumentPolicyInformer constructs a new informer for KernelArgumentPolicy type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredKernelArgumentPolicyInformer(client versioned.Interface, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.MachineconfigurationV1().KernelArgumentPolicies().List(context.TODO(), options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.MachineconfigurationV1().KernelArgumentPolicies().Watch(context.TODO(), options)
+			},
+		},
+		&machineconfigurationopenshiftiov1.KernelArgumentPolicy{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *kernelArgumentPolicyInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredKernelArgumentPolicyInformer(client, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *kernelArgumentPolicyInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&machineconfigurationopenshiftiov1.KernelArgumentPolicy{}, f.defaultInformer)
+}
+
+func (f *kernelArgumentPolicyInformer) Lister() v1.KernelArgumentPolicyLister {
+	return v1.NewKernelArgumentPolicyLister(f.Informer().GetIndexer())
+}
@@ -0,0 +1,73 @@
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	"context"
+	time "time"
+
+	machineconfigurationopenshiftiov1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	versioned "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned"
+	internalinterfaces "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions/internalinterfaces"
+	v1 "github.com/openshift/machine-config-operator/pkg/generated/listers/machineconfiguration.openshift.io/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// MachineConfigNodeInformer provides access to a shared informer and lister for
+// MachineConfigNodes.
+type MachineConfigNodeInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() v1.MachineConfigNodeLister
+}
+
+type machineConfigNodeInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+}
+
+// NewMachineConfigNodeInformer constructs a new informer for MachineConfigNode type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewMachineConfigNodeInformer(client versioned.Interface, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredMachineConfigNodeInformer(client, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredMachineConfigNodeInformer constructs a new informer for MachineConfigNode type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredMachineConfigNodeInformer(client versioned.Interface, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.MachineconfigurationV1().MachineConfigNodes().List(context.TODO(), options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.MachineconfigurationV1().MachineConfigNodes().Watch(context.TODO(), options)
+			},
+		},
+		&machineconfigurationopenshiftiov1.MachineConfigNode{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *machineConfigNodeInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredMachineConfigNodeInformer(client, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *machineConfigNodeInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&machineconfigurationopenshiftiov1.MachineConfigNode{}, f.defaultInformer)
+}
+
+func (f *machineConfigNodeInformer) Lister() v1.MachineConfigNodeLister {
+	return v1.NewMachineConfigNodeLister(f.Informer().GetIndexer())
+}
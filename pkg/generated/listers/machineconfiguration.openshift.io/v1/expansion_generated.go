@@ -10,6 +10,10 @@ type ContainerRuntimeConfigListerExpansion interface{}
 // ControllerConfigLister.
 type ControllerConfigListerExpansion interface{}
 
+// KernelArgumentPolicyListerExpansion allows custom methods to be added to
+// KernelArgumentPolicyLister.
+type KernelArgumentPolicyListerExpansion interface{}
+
 // KubeletConfigListerExpansion allows custom methods to be added to
 // KubeletConfigLister.
 type KubeletConfigListerExpansion interface{}
@@ -18,6 +22,18 @@ type KubeletConfigListerExpansion interface{}
 // MachineConfigLister.
 type MachineConfigListerExpansion interface{}
 
+// MachineConfigNodeListerExpansion allows custom methods to be added to
+// MachineConfigNodeLister.
+type MachineConfigNodeListerExpansion interface{}
+
+// MachineConfigNodeOverrideListerExpansion allows custom methods to be added to
+// MachineConfigNodeOverrideLister.
+type MachineConfigNodeOverrideListerExpansion interface{}
+
 // MachineConfigPoolListerExpansion allows custom methods to be added to
 // MachineConfigPoolLister.
 type MachineConfigPoolListerExpansion interface{}
+
+// NodeConfigListerExpansion allows custom methods to be added to
+// NodeConfigLister.
+type NodeConfigListerExpansion interface{}
@@ -0,0 +1,52 @@
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	v1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// KernelArgumentPolicyLister helps list KernelArgumentPolicies.
+// All objects returned here must be treated as read-only.
+type KernelArgumentPolicyLister interface {
+	// List lists all KernelArgumentPolicies in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1.KernelArgumentPolicy, err error)
+	// Get retrieves the KernelArgumentPolicy from the index for a given name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1.KernelArgumentPolicy, error)
+	KernelArgumentPolicyListerExpansion
+}
+
+// kernelArgumentPolicyLister implements the KernelArgumentPolicyLister interface.
+type kernelArgumentPolicyLister struct {
+	indexer cache.Indexer
+}
+
+// NewKernelArgumentPolicyLister returns a new KernelArgumentPolicyLister.
+func NewKernelArgumentPolicyLister(indexer cache.Indexer) KernelArgumentPolicyLister {
+	return &kernelArgumentPolicyLister{indexer: indexer}
+}
+
+// List lists all KernelArgumentPolicies in the indexer.
+func (s *kernelArgumentPolicyLister) List(selector labels.Selector) (ret []*v1.KernelArgumentPolicy, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.KernelArgumentPolicy))
+	})
+	return ret, err
+}
+
+// Get retrieves the KernelArgumentPolicy from the index for a given name.
+func (s *kernelArgumentPolicyLister) Get(name string) (*v1.KernelArgumentPolicy, error) {
+	obj, exists, err := s.indexer.GetByKey(name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1.Resource("kernelargumentpolicy"), name)
+	}
+	return obj.(*v1.KernelArgumentPolicy), nil
+}
@@ -0,0 +1,52 @@
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	v1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// MachineConfigNodeLister helps list MachineConfigNodes.
+// All objects returned here must be treated as read-only.
+type MachineConfigNodeLister interface {
+	// List lists all MachineConfigNodes in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1.MachineConfigNode, err error)
+	// Get retrieves the MachineConfigNode from the index for a given name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1.MachineConfigNode, error)
+	MachineConfigNodeListerExpansion
+}
+
+// machineConfigNodeLister implements the MachineConfigNodeLister interface.
+type machineConfigNodeLister struct {
+	indexer cache.Indexer
+}
+
+// NewMachineConfigNodeLister returns a new MachineConfigNodeLister.
+func NewMachineConfigNodeLister(indexer cache.Indexer) MachineConfigNodeLister {
+	return &machineConfigNodeLister{indexer: indexer}
+}
+
+// List lists all MachineConfigNodes in the indexer.
+func (s *machineConfigNodeLister) List(selector labels.Selector) (ret []*v1.MachineConfigNode, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.MachineConfigNode))
+	})
+	return ret, err
+}
+
+// Get retrieves the MachineConfigNode from the index for a given name.
+func (s *machineConfigNodeLister) Get(name string) (*v1.MachineConfigNode, error) {
+	obj, exists, err := s.indexer.GetByKey(name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1.Resource("machineconfignode"), name)
+	}
+	return obj.(*v1.MachineConfigNode), nil
+}
@@ -0,0 +1,52 @@
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	v1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// MachineConfigNodeOverrideLister helps list MachineConfigNodeOverrides.
+// All objects returned here must be treated as read-only.
+type MachineConfigNodeOverrideLister interface {
+	// List lists all MachineConfigNodeOverrides in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1.MachineConfigNodeOverride, err error)
+	// Get retrieves the MachineConfigNodeOverride from the index for a given name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1.MachineConfigNodeOverride, error)
+	MachineConfigNodeOverrideListerExpansion
+}
+
+// machineConfigNodeOverrideLister implements the MachineConfigNodeOverrideLister interface.
+type machineConfigNodeOverrideLister struct {
+	indexer cache.Indexer
+}
+
+// NewMachineConfigNodeOverrideLister returns a new MachineConfigNodeOverrideLister.
+func NewMachineConfigNodeOverrideLister(indexer cache.Indexer) MachineConfigNodeOverrideLister {
+	return &machineConfigNodeOverrideLister{indexer: indexer}
+}
+
+// List lists all MachineConfigNodeOverrides in the indexer.
+func (s *machineConfigNodeOverrideLister) List(selector labels.Selector) (ret []*v1.MachineConfigNodeOverride, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.MachineConfigNodeOverride))
+	})
+	return ret, err
+}
+
+// Get retrieves the MachineConfigNodeOverride from the index for a given name.
+func (s *machineConfigNodeOverrideLister) Get(name string) (*v1.MachineConfigNodeOverride, error) {
+	obj, exists, err := s.indexer.GetByKey(name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1.Resource("machineconfignodeoverride"), name)
+	}
+	return obj.(*v1.MachineConfigNodeOverride), nil
+}
@@ -0,0 +1,52 @@
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	v1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// NodeConfigLister helps list NodeConfigs.
+// All objects returned here must be treated as read-only.
+type NodeConfigLister interface {
+	// List lists all NodeConfigs in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1.NodeConfig, err error)
+	// Get retrieves the NodeConfig from the index for a given name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1.NodeConfig, error)
+	NodeConfigListerExpansion
+}
+
+// nodeConfigLister implements the NodeConfigLister interface.
+type nodeConfigLister struct {
+	indexer cache.Indexer
+}
+
+// NewNodeConfigLister returns a new NodeConfigLister.
+func NewNodeConfigLister(indexer cache.Indexer) NodeConfigLister {
+	return &nodeConfigLister{indexer: indexer}
+}
+
+// List lists all NodeConfigs in the indexer.
+func (s *nodeConfigLister) List(selector labels.Selector) (ret []*v1.NodeConfig, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.NodeConfig))
+	})
+	return ret, err
+}
+
+// Get retrieves the NodeConfig from the index for a given name.
+func (s *nodeConfigLister) Get(name string) (*v1.NodeConfig, error) {
+	obj, exists, err := s.indexer.GetByKey(name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1.Resource("nodeconfig"), name)
+	}
+	return obj.(*v1.NodeConfig), nil
+}
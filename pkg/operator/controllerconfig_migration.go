@@ -0,0 +1,162 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+const (
+	// controllerConfigSchemaVersionAnnotationKey records which of the controllerConfigMigrations
+	// have already been applied to the ControllerConfig stored in the cluster.
+	controllerConfigSchemaVersionAnnotationKey = "machineconfiguration.openshift.io/controllerconfig-schema-version"
+)
+
+// controllerConfigMigration moves a stored ControllerConfig to schema version `version`
+// from the version right before it. migrate gets the ControllerConfig rendered by this
+// release so it can tell stale values apart from current ones.
+type controllerConfigMigration struct {
+	version     int
+	description string
+	migrate     func(existing *mcfgv1.ControllerConfig, required *mcfgv1.ControllerConfig) error
+}
+
+// controllerConfigMigrations must be kept sorted by version, without gaps. Once shipped a
+// migration must never change, add a new one instead.
+var controllerConfigMigrations = []controllerConfigMigration{
+	{
+		version:     1,
+		description: "prune fields left behind by releases that no longer set them",
+		migrate: func(existing, required *mcfgv1.ControllerConfig) error {
+			// Applying the ControllerConfig only overwrites fields that are set in the
+			// rendered spec, so values that a previous release set and this one
+			// dropped would otherwise stay around forever.
+			existing.Spec = *required.Spec.DeepCopy()
+			return nil
+		},
+	},
+}
+
+// latestControllerConfigSchemaVersion returns the version a ControllerConfig is at once all migrations ran.
+func latestControllerConfigSchemaVersion() int {
+	if len(controllerConfigMigrations) == 0 {
+		return 0
+	}
+	return controllerConfigMigrations[len(controllerConfigMigrations)-1].version
+}
+
+// getControllerConfigSchemaVersion returns the schema version stored on the ControllerConfig.
+// ControllerConfigs created before the migration framework existed are at version 0.
+func getControllerConfigSchemaVersion(cc *mcfgv1.ControllerConfig) (int, error) {
+	val, ok := cc.Annotations[controllerConfigSchemaVersionAnnotationKey]
+	if !ok {
+		return 0, nil
+	}
+	version, err := strconv.Atoi(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s annotation %q on ControllerConfig %s: %v", controllerConfigSchemaVersionAnnotationKey, val, cc.Name, err)
+	}
+	return version, nil
+}
+
+func setControllerConfigSchemaVersion(cc *mcfgv1.ControllerConfig, version int) {
+	if cc.Annotations == nil {
+		cc.Annotations = map[string]string{}
+	}
+	cc.Annotations[controllerConfigSchemaVersionAnnotationKey] = strconv.Itoa(version)
+}
+
+// migrateControllerConfig runs in order every migration newer than the schema version of existing
+// and returns the descriptions of the ones that were applied.
+func migrateControllerConfig(existing, required *mcfgv1.ControllerConfig, migrations []controllerConfigMigration) ([]string, error) {
+	from, err := getControllerConfigSchemaVersion(existing)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []string
+	for _, m := range migrations {
+		if m.version <= from {
+			continue
+		}
+		if err := m.migrate(existing, required); err != nil {
+			return applied, fmt.Errorf("migrating ControllerConfig %s to schema version %d (%s) failed: %v", existing.Name, m.version, m.description, err)
+		}
+		glog.Infof("Migrated ControllerConfig %s to schema version %d: %s", existing.Name, m.version, m.description)
+		setControllerConfigSchemaVersion(existing, m.version)
+		applied = append(applied, m.description)
+	}
+	return applied, nil
+}
+
+// syncControllerConfigSchema brings the ControllerConfig stored in the cluster up to the
+// latest schema version before the rendered one is applied on top of it. required gets
+// stamped with the latest version so that applying it never downgrades the stored one.
+func (optr *Operator) syncControllerConfigSchema(required *mcfgv1.ControllerConfig) error {
+	latest := latestControllerConfigSchemaVersion()
+	setControllerConfigSchemaVersion(required, latest)
+
+	existing, err := optr.client.MachineconfigurationV1().ControllerConfigs().Get(context.TODO(), required.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		// brand new ControllerConfigs are created at the latest version
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	from, err := getControllerConfigSchemaVersion(existing)
+	if err != nil {
+		return err
+	}
+	if from >= latest {
+		return nil
+	}
+
+	migrated := existing.DeepCopy()
+	applied, err := migrateControllerConfig(migrated, required, controllerConfigMigrations)
+	if err != nil {
+		optr.eventRecorder.Eventf(getControllerConfigRef(existing), corev1.EventTypeWarning, "SchemaMigrationFailed", "%v", err)
+		cond := mcfgv1.NewControllerConfigStatusCondition(mcfgv1.ControllerConfigSchemaMigrated, corev1.ConditionFalse, "SchemaMigrationFailed", err.Error())
+		optr.setControllerConfigSchemaCondition(existing, cond)
+		return err
+	}
+	updated, err := optr.client.MachineconfigurationV1().ControllerConfigs().Update(context.TODO(), migrated, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("updating migrated ControllerConfig %s failed: %v", migrated.Name, err)
+	}
+	optr.eventRecorder.Eventf(getControllerConfigRef(existing), corev1.EventTypeNormal, "SchemaMigrated", "Migrated from schema version %d to %d: %v", from, latest, applied)
+	message := fmt.Sprintf("Migrated from schema version %d to %d", from, latest)
+	cond := mcfgv1.NewControllerConfigStatusCondition(mcfgv1.ControllerConfigSchemaMigrated, corev1.ConditionTrue, "SchemaMigrated", message)
+	optr.setControllerConfigSchemaCondition(updated, cond)
+	return nil
+}
+
+// setControllerConfigSchemaCondition records the outcome of the migration of the ControllerConfig
+// in its status, so it outlives the events. Failing to is only logged, the sync doesn't depend on it.
+func (optr *Operator) setControllerConfigSchemaCondition(cc *mcfgv1.ControllerConfig, cond *mcfgv1.ControllerConfigStatusCondition) {
+	updated := cc.DeepCopy()
+	mcfgv1.SetControllerConfigStatusCondition(&updated.Status, *cond)
+	if equality.Semantic.DeepEqual(cc.Status, updated.Status) {
+		return
+	}
+	if _, err := optr.client.MachineconfigurationV1().ControllerConfigs().UpdateStatus(context.TODO(), updated, metav1.UpdateOptions{}); err != nil {
+		glog.Warningf("Failed to set the %s condition of ControllerConfig %s: %v", cond.Type, cc.Name, err)
+	}
+}
+
+func getControllerConfigRef(cc *mcfgv1.ControllerConfig) *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		Kind: "ControllerConfig",
+		Name: cc.GetName(),
+		UID:  cc.GetUID(),
+	}
+}
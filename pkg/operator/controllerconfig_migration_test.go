@@ -0,0 +1,110 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	fakemcfgclientset "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/fake"
+)
+
+func TestControllerConfigMigrationsAreOrdered(t *testing.T) {
+	for idx, m := range controllerConfigMigrations {
+		assert.Equal(t, idx+1, m.version, "migration %q", m.description)
+	}
+}
+
+func TestMigrateControllerConfig(t *testing.T) {
+	var calls []int
+	migrations := []controllerConfigMigration{
+		{version: 1, description: "one", migrate: func(existing, _ *mcfgv1.ControllerConfig) error {
+			calls = append(calls, 1)
+			return nil
+		}},
+		{version: 2, description: "two", migrate: func(existing, required *mcfgv1.ControllerConfig) error {
+			calls = append(calls, 2)
+			existing.Spec.CloudProviderConfig = required.Spec.CloudProviderConfig
+			return nil
+		}},
+		{version: 3, description: "three", migrate: func(existing, _ *mcfgv1.ControllerConfig) error {
+			calls = append(calls, 3)
+			return fmt.Errorf("boom")
+		}},
+	}
+	required := &mcfgv1.ControllerConfig{}
+
+	// Only migrations newer than the stored version run, and they run in order
+	calls = nil
+	existing := &mcfgv1.ControllerConfig{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{controllerConfigSchemaVersionAnnotationKey: "1"}},
+		Spec:       mcfgv1.ControllerConfigSpec{CloudProviderConfig: "stale"},
+	}
+	applied, err := migrateControllerConfig(existing, required, migrations[:2])
+	require.Nil(t, err)
+	assert.Equal(t, []int{2}, calls)
+	assert.Equal(t, []string{"two"}, applied)
+	assert.Equal(t, "", existing.Spec.CloudProviderConfig)
+	assert.Equal(t, "2", existing.Annotations[controllerConfigSchemaVersionAnnotationKey])
+
+	// ControllerConfigs without the annotation run every migration
+	calls = nil
+	existing = &mcfgv1.ControllerConfig{}
+	_, err = migrateControllerConfig(existing, required, migrations[:2])
+	require.Nil(t, err)
+	assert.Equal(t, []int{1, 2}, calls)
+
+	// A failing migration stops at the last successful version
+	calls = nil
+	existing = &mcfgv1.ControllerConfig{}
+	applied, err = migrateControllerConfig(existing, required, migrations)
+	require.NotNil(t, err)
+	assert.Equal(t, []string{"one", "two"}, applied)
+	assert.Equal(t, "2", existing.Annotations[controllerConfigSchemaVersionAnnotationKey])
+
+	// An unparsable version is an error
+	existing = &mcfgv1.ControllerConfig{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{controllerConfigSchemaVersionAnnotationKey: "x"}},
+	}
+	_, err = migrateControllerConfig(existing, required, migrations)
+	require.NotNil(t, err)
+}
+
+func TestSyncControllerConfigSchema(t *testing.T) {
+	existing := &mcfgv1.ControllerConfig{ObjectMeta: metav1.ObjectMeta{Name: "machine-config-controller"}}
+	client := fakemcfgclientset.NewSimpleClientset(existing)
+	optr := &Operator{client: client, eventRecorder: record.NewFakeRecorder(10)}
+
+	// the outcome of the migration is recorded in the status
+	require.Nil(t, optr.syncControllerConfigSchema(&mcfgv1.ControllerConfig{ObjectMeta: metav1.ObjectMeta{Name: existing.Name}}))
+	cc, err := client.MachineconfigurationV1().ControllerConfigs().Get(context.TODO(), existing.Name, metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Equal(t, fmt.Sprint(latestControllerConfigSchemaVersion()), cc.Annotations[controllerConfigSchemaVersionAnnotationKey])
+	cond := mcfgv1.GetControllerConfigStatusCondition(cc.Status, mcfgv1.ControllerConfigSchemaMigrated)
+	require.NotNil(t, cond)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+
+	// a failing migration leaves the stored version alone
+	defer func(migrations []controllerConfigMigration) { controllerConfigMigrations = migrations }(controllerConfigMigrations)
+	controllerConfigMigrations = append(controllerConfigMigrations, controllerConfigMigration{
+		version:     latestControllerConfigSchemaVersion() + 1,
+		description: "fail",
+		migrate: func(_, _ *mcfgv1.ControllerConfig) error {
+			return fmt.Errorf("boom")
+		},
+	})
+	require.NotNil(t, optr.syncControllerConfigSchema(&mcfgv1.ControllerConfig{ObjectMeta: metav1.ObjectMeta{Name: existing.Name}}))
+	failed, err := client.MachineconfigurationV1().ControllerConfigs().Get(context.TODO(), existing.Name, metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Equal(t, cc.Annotations, failed.Annotations)
+	cond = mcfgv1.GetControllerConfigStatusCondition(failed.Status, mcfgv1.ControllerConfigSchemaMigrated)
+	require.NotNil(t, cond)
+	assert.Equal(t, corev1.ConditionFalse, cond.Status)
+	assert.Contains(t, cond.Message, "boom")
+}
@@ -0,0 +1,140 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+const (
+	// DefaultHealthBindAddress is the address the operator serves /healthz and /readyz on
+	DefaultHealthBindAddress = ":8080"
+
+	// syncStallTimeout is how long a sync may run before the operator is considered wedged. It
+	// outlasts the wait for the required pools to update.
+	syncStallTimeout = 20 * time.Minute
+)
+
+// Health tracks the state of the sync loop for the liveness and readiness probes of the
+// operator. It's served before the leader election, the operator only updating it once leading.
+type Health struct {
+	mu                 sync.Mutex
+	leading            bool
+	cachesSynced       bool
+	syncStarted        time.Time
+	lastSuccessfulSync time.Time
+	now                func() time.Time
+}
+
+// NewHealth returns the Health of an operator not leading yet
+func NewHealth() *Health {
+	return &Health{now: time.Now}
+}
+
+// setLeading records the operator leads and whether its caches synced
+func (h *Health) setLeading(cachesSynced bool) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.leading = true
+	h.cachesSynced = cachesSynced
+}
+
+// syncStart records a sync started
+func (h *Health) syncStart() {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.syncStarted = h.now()
+}
+
+// syncDone records the sync finished, successfully if err is nil
+func (h *Health) syncDone(err error) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err == nil {
+		h.lastSuccessfulSync = h.now()
+	}
+	h.syncStarted = time.Time{}
+}
+
+// healthy returns an error when a sync has been running for longer than syncStallTimeout
+func (h *Health) healthy() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.syncStarted.IsZero() && h.now().Sub(h.syncStarted) > syncStallTimeout {
+		return fmt.Errorf("sync running since %s", h.syncStarted.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// ready returns an error when the operator leads but its caches didn't sync, or no sync
+// succeeded yet. A standby operator is ready to take over.
+func (h *Health) ready() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	switch {
+	case !h.leading:
+		return nil
+	case !h.cachesSynced:
+		return fmt.Errorf("caches not synced")
+	case h.lastSuccessfulSync.IsZero():
+		return fmt.Errorf("no successful sync yet")
+	}
+	return nil
+}
+
+// handler returns the handler of /healthz and /readyz
+func (h *Health) handler() http.Handler {
+	probe := func(check func() error) http.HandlerFunc {
+		return func(w http.ResponseWriter, _ *http.Request) {
+			if err := check(); err != nil {
+				http.Error(w, err.Error(), http.StatusServiceUnavailable)
+				return
+			}
+			h.mu.Lock()
+			lastSuccessfulSync := h.lastSuccessfulSync
+			h.mu.Unlock()
+			if lastSuccessfulSync.IsZero() {
+				fmt.Fprintln(w, "ok")
+				return
+			}
+			fmt.Fprintf(w, "ok, last successful sync at %s\n", lastSuccessfulSync.UTC().Format(time.RFC3339))
+		}
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", probe(h.healthy))
+	mux.Handle("/readyz", probe(h.ready))
+	return mux
+}
+
+// StartHealthListener serves /healthz and /readyz on addr until stopCh is closed
+func (h *Health) StartHealthListener(addr string, stopCh <-chan struct{}) {
+	if addr == "" {
+		addr = DefaultHealthBindAddress
+	}
+
+	glog.Infof("Starting health listener on %s", addr)
+	s := http.Server{Addr: addr, Handler: h.handler()}
+
+	go func() {
+		if err := s.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			glog.Errorf("health listener exited with error: %v", err)
+		}
+	}()
+	<-stopCh
+	if err := s.Shutdown(context.Background()); err != nil {
+		glog.Errorf("error stopping health listener: %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package operator
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealth(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	h := NewHealth()
+	h.now = func() time.Time { return now }
+	probe := func(path string) int {
+		rec := httptest.NewRecorder()
+		h.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+
+	// a standby operator is healthy and ready to take over
+	assert.Equal(t, http.StatusOK, probe("/healthz"))
+	assert.Equal(t, http.StatusOK, probe("/readyz"))
+
+	h.setLeading(false)
+	assert.Equal(t, http.StatusServiceUnavailable, probe("/readyz"))
+	h.setLeading(true)
+	assert.Equal(t, http.StatusServiceUnavailable, probe("/readyz"))
+
+	h.syncStart()
+	h.syncDone(errors.New("mocked"))
+	assert.Equal(t, http.StatusServiceUnavailable, probe("/readyz"))
+	h.syncStart()
+	h.syncDone(nil)
+	assert.Equal(t, http.StatusOK, probe("/readyz"))
+
+	// a wedged sync
+	h.syncStart()
+	now = now.Add(syncStallTimeout)
+	assert.Equal(t, http.StatusOK, probe("/healthz"))
+	now = now.Add(time.Second)
+	assert.Equal(t, http.StatusServiceUnavailable, probe("/healthz"))
+	h.syncDone(nil)
+	assert.Equal(t, http.StatusOK, probe("/healthz"))
+
+	// operators built without a health don't track it
+	var none *Health
+	none.syncStart()
+	none.syncDone(nil)
+}
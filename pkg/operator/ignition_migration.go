@@ -0,0 +1,65 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+// noSpec2ConversionLoss is the migration report of the configs converted without loss
+const noSpec2ConversionLoss = "Converted from Ignition spec 2 without loss"
+
+// syncIgnitionMigration converts the MachineConfigs still written against Ignition spec 2 to
+// spec 3, annotating them with what the conversion dropped. The conversion is the one rendering
+// already does, so the rendered configs don't change. The configs which can't be converted
+// are left as is and block upgrades.
+func (optr *Operator) syncIgnitionMigration(_ *renderConfig) error {
+	optr.unconvertibleConfigs = nil
+	configs, err := optr.mcLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, mc := range configs {
+		// controllers write their configs against spec 3 already
+		if len(mc.Spec.Config.Raw) == 0 || metav1.GetControllerOf(mc) != nil {
+			continue
+		}
+		converted, losses, err := ctrlcommon.ConvertSpec2Config(mc.Spec.Config.Raw)
+		if err != nil {
+			optr.unconvertibleConfigs = append(optr.unconvertibleConfigs, mc.Name)
+			optr.eventRecorder.Eventf(mc, corev1.EventTypeWarning, "IgnitionSpec2Unconvertible", "MachineConfig %s can't be converted to Ignition spec 3: %v", mc.Name, err)
+			continue
+		}
+		if converted == nil {
+			continue
+		}
+
+		report := noSpec2ConversionLoss
+		if len(losses) > 0 {
+			report = strings.Join(losses, "; ")
+		}
+		newMC := mc.DeepCopy()
+		newMC.Spec.Config.Raw = converted
+		if newMC.Annotations == nil {
+			newMC.Annotations = map[string]string{}
+		}
+		newMC.Annotations[ctrlcommon.IgnitionSpec2MigrationAnnotationKey] = report
+		if _, err := optr.client.MachineconfigurationV1().MachineConfigs().Update(context.TODO(), newMC, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to migrate MachineConfig %s to Ignition spec 3: %v", mc.Name, err)
+		}
+		glog.Infof("Converted MachineConfig %s to Ignition spec 3: %s", mc.Name, report)
+		if len(losses) > 0 {
+			optr.eventRecorder.Eventf(mc, corev1.EventTypeWarning, "IgnitionSpec2Migrated", "Converted MachineConfig %s to Ignition spec 3: %s", mc.Name, report)
+		} else {
+			optr.eventRecorder.Eventf(mc, corev1.EventTypeNormal, "IgnitionSpec2Migrated", "Converted MachineConfig %s to Ignition spec 3", mc.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package operator
+
+import (
+	"context"
+	"testing"
+
+	ign2types "github.com/coreos/ignition/config/v2_2/types"
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	fakemcfgclientset "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/fake"
+	mcfginformers "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func newSpec2MachineConfig(name string, ign2Config ign2types.Config) *mcfgv1.MachineConfig {
+	mc := helpers.NewMachineConfig(name, nil, "", nil)
+	mc.Spec.Config.Raw = helpers.MarshalOrDie(ign2Config)
+	return mc
+}
+
+func TestSyncIgnitionMigration(t *testing.T) {
+	convertible := ign2types.Config{Ignition: ign2types.Ignition{Version: "2.2.0"}}
+	convertible.Passwd.Users = []ign2types.PasswdUser{{Name: "core", SSHAuthorizedKeys: []ign2types.SSHAuthorizedKey{"ssh-rsa AAAA"}}}
+	unconvertible := ign2types.Config{Ignition: ign2types.Ignition{Version: "2.2.0"}}
+	unconvertible.Passwd.Users = []ign2types.PasswdUser{{Name: "admin"}}
+
+	configs := []*mcfgv1.MachineConfig{
+		newSpec2MachineConfig("99-ssh", convertible),
+		newSpec2MachineConfig("99-admin", unconvertible),
+		helpers.NewMachineConfig("99-spec3", nil, "", []ign3types.File{{Node: ign3types.Node{Path: "/etc/motd"}}}),
+	}
+	client := fakemcfgclientset.NewSimpleClientset(configs[0], configs[1], configs[2])
+	mcInformer := mcfginformers.NewSharedInformerFactory(client, 0).Machineconfiguration().V1().MachineConfigs()
+	for _, mc := range configs {
+		mcInformer.Informer().GetIndexer().Add(mc)
+	}
+	optr := &Operator{
+		client:        client,
+		eventRecorder: &record.FakeRecorder{},
+		mcLister:      mcInformer.Lister(),
+	}
+
+	require.Nil(t, optr.syncIgnitionMigration(nil))
+	assert.Equal(t, []string{"99-admin"}, optr.unconvertibleConfigs)
+
+	migrated, err := client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), "99-ssh", metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Equal(t, noSpec2ConversionLoss, migrated.Annotations[ctrlcommon.IgnitionSpec2MigrationAnnotationKey])
+	ign3Config, err := ctrlcommon.ParseAndConvertConfig(migrated.Spec.Config.Raw)
+	require.Nil(t, err)
+	assert.Equal(t, "3.2.0", ign3Config.Ignition.Version)
+	assert.Equal(t, []ign3types.SSHAuthorizedKey{"ssh-rsa AAAA"}, ign3Config.Passwd.Users[0].SSHAuthorizedKeys)
+
+	for _, name := range []string{"99-admin", "99-spec3"} {
+		mc, err := client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), name, metav1.GetOptions{})
+		require.Nil(t, err)
+		assert.NotContains(t, mc.Annotations, ctrlcommon.IgnitionSpec2MigrationAnnotationKey)
+	}
+}
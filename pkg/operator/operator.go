@@ -8,6 +8,7 @@ import (
 
 	"github.com/golang/glog"
 
+	configv1 "github.com/openshift/api/config/v1"
 	configclientset "github.com/openshift/client-go/config/clientset/versioned"
 	corev1 "k8s.io/api/core/v1"
 	apiextclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
@@ -28,6 +29,7 @@ import (
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
 	"k8s.io/client-go/util/workqueue"
 
 	configinformersv1 "github.com/openshift/client-go/config/informers/externalversions/config/v1"
@@ -49,6 +51,14 @@ const (
 
 	// osImageConfigMapName is the name of our configmap for the osImageURL
 	osImageConfigMapName = "machine-config-osimageurl"
+
+	// DefaultDegradedGracePeriod is how long syncs have to keep failing before the operator
+	// reports Degraded, outlasting the retries of a single failing sync
+	DefaultDegradedGracePeriod = 2 * time.Minute
+
+	// statusWriteQPS and statusWriteBurst bound the rate of the clusteroperator status writes
+	statusWriteQPS   = 1
+	statusWriteBurst = 5
 )
 
 // Operator defines machince config operator.
@@ -82,6 +92,8 @@ type Operator struct {
 	proxyLister      configlistersv1.ProxyLister
 	oseKubeAPILister corelisterv1.ConfigMapLister
 	dnsLister        configlistersv1.DNSLister
+	nodeLister       corelisterv1.NodeLister
+	mcnLister        mcfglistersv1.MachineConfigNodeLister
 
 	crdListerSynced                  cache.InformerSynced
 	deployListerSynced               cache.InformerSynced
@@ -99,6 +111,22 @@ type Operator struct {
 	proxyListerSynced                cache.InformerSynced
 	oseKubeAPIListerSynced           cache.InformerSynced
 	dnsListerSynced                  cache.InformerSynced
+	nodeListerSynced                 cache.InformerSynced
+	mcnListerSynced                  cache.InformerSynced
+
+	// degradedGracePeriod is how long syncs have to keep failing before Degraded is reported,
+	// so that transient failures don't flip the clusteroperator conditions back and forth
+	degradedGracePeriod time.Duration
+	// failingSince is when the syncs started failing, zero if the last one succeeded
+	failingSince time.Time
+
+	// health tracks the sync loop for the liveness and readiness probes
+	health *Health
+
+	// statusWriteLimiter throttles the writes of the clusteroperator status
+	statusWriteLimiter flowcontrol.RateLimiter
+	// lastWrittenClusterOperator is the clusteroperator as of the last status write
+	lastWrittenClusterOperator *configv1.ClusterOperator
 
 	// queue only ever has one item, but it has nice error handling backoff/retry semantics
 	queue workqueue.RateLimitingInterface
@@ -106,11 +134,24 @@ type Operator struct {
 	stopCh <-chan struct{}
 
 	renderConfig *renderConfig
+
+	// clusterRebootStatus is the progress of the cluster reboot request, if any, reported in
+	// the clusteroperator extension
+	clusterRebootStatus string
+
+	// unconvertibleConfigs are the MachineConfigs written against Ignition spec 2 which can't be
+	// converted to spec 3, they block upgrades
+	unconvertibleConfigs []string
+
+	// exportedStatus is the endpoint and the status document last exported to it
+	exportedStatus string
 }
 
 // New returns a new machine config operator.
 func New(
 	namespace, name, imagesFile string,
+	degradedGracePeriod time.Duration,
+	health *Health,
 	mcpInformer mcfginformersv1.MachineConfigPoolInformer,
 	mcInformer mcfginformersv1.MachineConfigInformer,
 	controllerConfigInformer mcfginformersv1.ControllerConfigInformer,
@@ -126,6 +167,8 @@ func New(
 	networkInformer configinformersv1.NetworkInformer,
 	proxyInformer configinformersv1.ProxyInformer,
 	dnsInformer configinformersv1.DNSInformer,
+	nodeInformer coreinformersv1.NodeInformer,
+	mcnInformer mcfginformersv1.MachineConfigNodeInformer,
 	client mcfgclientset.Interface,
 	kubeClient kubernetes.Interface,
 	apiExtClient apiextclientset.Interface,
@@ -137,16 +180,19 @@ func New(
 	eventBroadcaster.StartRecordingToSink(&coreclientsetv1.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
 
 	optr := &Operator{
-		namespace:     namespace,
-		name:          name,
-		imagesFile:    imagesFile,
-		vStore:        newVersionStore(),
-		client:        client,
-		kubeClient:    kubeClient,
-		apiExtClient:  apiExtClient,
-		configClient:  configClient,
-		eventRecorder: eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "machineconfigoperator"}),
-		queue:         workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "machineconfigoperator"),
+		namespace:           namespace,
+		name:                name,
+		imagesFile:          imagesFile,
+		degradedGracePeriod: degradedGracePeriod,
+		health:              health,
+		statusWriteLimiter:  flowcontrol.NewTokenBucketRateLimiter(statusWriteQPS, statusWriteBurst),
+		vStore:              newVersionStore(),
+		client:              client,
+		kubeClient:          kubeClient,
+		apiExtClient:        apiExtClient,
+		configClient:        configClient,
+		eventRecorder:       eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "machineconfigoperator"}),
+		queue:               workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "machineconfigoperator"),
 	}
 
 	for _, i := range []cache.SharedIndexInformer{
@@ -200,6 +246,10 @@ func New(
 	optr.networkListerSynced = networkInformer.Informer().HasSynced
 	optr.dnsLister = dnsInformer.Lister()
 	optr.dnsListerSynced = dnsInformer.Informer().HasSynced
+	optr.nodeLister = nodeInformer.Lister()
+	optr.nodeListerSynced = nodeInformer.Informer().HasSynced
+	optr.mcnLister = mcnInformer.Lister()
+	optr.mcnListerSynced = mcnInformer.Informer().HasSynced
 
 	optr.vStore.Set("operator", os.Getenv("RELEASE_VERSION"))
 
@@ -211,6 +261,8 @@ func (optr *Operator) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
 	defer optr.queue.ShutDown()
 
+	optr.health.setLeading(false)
+
 	apiClient := optr.apiExtClient.ApiextensionsV1beta1()
 	_, err := apiClient.CustomResourceDefinitions().Get(context.TODO(), "controllerconfigs.machineconfiguration.openshift.io", metav1.GetOptions{})
 	if err != nil {
@@ -237,7 +289,8 @@ func (optr *Operator) Run(workers int, stopCh <-chan struct{}) {
 		optr.oseKubeAPIListerSynced,
 		optr.mcpListerSynced,
 		optr.mcListerSynced,
-		optr.dnsListerSynced) {
+		optr.dnsListerSynced,
+		optr.nodeListerSynced) {
 		glog.Error("failed to sync caches")
 		return
 	}
@@ -251,6 +304,7 @@ func (optr *Operator) Run(workers int, stopCh <-chan struct{}) {
 			return
 		}
 	}
+	optr.health.setLeading(true)
 
 	glog.Info("Starting MachineConfigOperator")
 	defer glog.Info("Shutting down MachineConfigOperator")
@@ -300,7 +354,9 @@ func (optr *Operator) processNextWorkItem() bool {
 	}
 	defer optr.queue.Done(key)
 
+	optr.health.syncStart()
 	err := optr.syncHandler(key.(string))
+	optr.health.syncDone(err)
 	optr.handleErr(err, key)
 
 	return true
@@ -340,7 +396,11 @@ func (optr *Operator) sync(key string) error {
 		{"MachineConfigPools", optr.syncMachineConfigPools},
 		{"MachineConfigDaemon", optr.syncMachineConfigDaemon},
 		{"MachineConfigController", optr.syncMachineConfigController},
+		{"OperatorReplicas", optr.syncOperatorReplicas},
 		{"MachineConfigServer", optr.syncMachineConfigServer},
+		{"IgnitionMigration", optr.syncIgnitionMigration},
+		{"ClusterReboot", optr.syncClusterReboot},
+		{"StatusExport", optr.syncStatusExport},
 		// this check must always run last since it makes sure the pools are in sync/upgrading correctly
 		{"RequiredPools", optr.syncRequiredMachineConfigPools},
 	}
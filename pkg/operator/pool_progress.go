@@ -0,0 +1,178 @@
+package operator
+
+import (
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+// machineConfigPoolPhases counts the nodes of a pool in each phase of its rollout
+type machineConfigPoolPhases struct {
+	// Pending nodes aren't targeted at the config of the pool yet
+	Pending int32 `json:"pending"`
+	// Updating nodes are being updated to the config of the pool
+	Updating int32 `json:"updating"`
+	// Rebooting nodes are rebooting into the config of the pool
+	Rebooting int32 `json:"rebooting"`
+	// Done nodes are at the config of the pool
+	Done int32 `json:"done"`
+	// Degraded nodes failed to update
+	Degraded int32 `json:"degraded"`
+}
+
+// nodePhase returns the phase of the rollout of targetConfig the node is in
+func nodePhase(node *corev1.Node, targetConfig string) string {
+	current := node.Annotations[daemonconsts.CurrentMachineConfigAnnotationKey]
+	desired := node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey]
+	state := node.Annotations[daemonconsts.MachineConfigDaemonStateAnnotationKey]
+	switch {
+	case state == daemonconsts.MachineConfigDaemonStateDegraded || state == daemonconsts.MachineConfigDaemonStateUnreconcilable:
+		return "degraded"
+	case desired != targetConfig:
+		return "pending"
+	case current == desired && state == daemonconsts.MachineConfigDaemonStateDone:
+		return "done"
+	case state == daemonconsts.MachineConfigDaemonStateWorking &&
+		(node.Annotations[daemonconsts.ScheduledRebootAnnotationKey] != "" || !isNodeConditionTrue(node, corev1.NodeReady)):
+		return "rebooting"
+	default:
+		return "updating"
+	}
+}
+
+// isNodeConditionTrue returns whether the condition of the node is true
+func isNodeConditionTrue(node *corev1.Node, conditionType corev1.NodeConditionType) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == conditionType {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// poolPhases counts the nodes in each phase of the rollout of the config of the pool, and
+// returns the percentage of them done
+func poolPhases(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) (machineConfigPoolPhases, int32) {
+	var phases machineConfigPoolPhases
+	for _, node := range nodes {
+		switch nodePhase(node, pool.Spec.Configuration.Name) {
+		case "degraded":
+			phases.Degraded++
+		case "pending":
+			phases.Pending++
+		case "done":
+			phases.Done++
+		case "rebooting":
+			phases.Rebooting++
+		default:
+			phases.Updating++
+		}
+	}
+	if len(nodes) == 0 {
+		return phases, 100
+	}
+	return phases, phases.Done * 100 / int32(len(nodes))
+}
+
+// nodesByPool returns the nodes managed by the daemon of every pool. Like the node controller,
+// a node matching both the worker pool and a custom pool belongs to the custom pool.
+func (optr *Operator) nodesByPool(pools []*mcfgv1.MachineConfigPool) (map[string][]*corev1.Node, error) {
+	if len(pools) == 0 {
+		return nil, nil
+	}
+	selectors := map[string]labels.Selector{}
+	for _, pool := range pools {
+		selector, err := metav1.LabelSelectorAsSelector(pool.Spec.NodeSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node selector of pool %s: %v", pool.Name, err)
+		}
+		selectors[pool.Name] = selector
+	}
+	nodes, err := optr.nodeLister.List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("listing the nodes: %v", err)
+	}
+
+	ret := map[string][]*corev1.Node{}
+	for _, node := range nodes {
+		if node.Annotations[daemonconsts.CurrentMachineConfigAnnotationKey] == "" {
+			continue
+		}
+		var matching []string
+		for _, pool := range pools {
+			// a nil or empty selector matches nothing
+			if selector := selectors[pool.Name]; !selector.Empty() && selector.Matches(labels.Set(node.Labels)) {
+				matching = append(matching, pool.Name)
+			}
+		}
+		for _, name := range matching {
+			if name == "worker" && len(matching) > 1 {
+				continue
+			}
+			ret[name] = append(ret[name], node)
+		}
+	}
+	return ret, nil
+}
+
+// machineConfigNodeSummary is the state of the update of a node not done updating, from its
+// MachineConfigNode
+type machineConfigNodeSummary struct {
+	Name          string `json:"name"`
+	Phase         string `json:"phase"`
+	DesiredConfig string `json:"desiredConfig"`
+	LastError     string `json:"lastError,omitempty"`
+	// PendingReboot is why the node has a reboot ahead of it
+	PendingReboot string `json:"pendingReboot,omitempty"`
+}
+
+// machineConfigNodes returns the MachineConfigNodes the daemons wrote, by node name. Their
+// informer isn't waited for when the operator starts, it never syncs while their CRD is missing.
+func (optr *Operator) machineConfigNodes() (map[string]*mcfgv1.MachineConfigNode, error) {
+	if !optr.mcnListerSynced() {
+		return nil, fmt.Errorf("the MachineConfigNodes aren't synced yet")
+	}
+	mcns, err := optr.mcnLister.List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("listing the MachineConfigNodes: %v", err)
+	}
+	ret := map[string]*mcfgv1.MachineConfigNode{}
+	for _, mcn := range mcns {
+		ret[mcn.Spec.NodeName] = mcn
+	}
+	return ret, nil
+}
+
+// nodeSummaries summarizes the nodes whose MachineConfigNode reports they aren't done updating,
+// or have a reboot ahead of them, sorted by name
+func nodeSummaries(nodes []*corev1.Node, mcns map[string]*mcfgv1.MachineConfigNode) []machineConfigNodeSummary {
+	var ret []machineConfigNodeSummary
+	for _, node := range nodes {
+		mcn, ok := mcns[node.Name]
+		if !ok {
+			continue
+		}
+		status := mcn.Status
+		if status.Phase == mcfgv1.MachineConfigNodeDone && status.CurrentConfig == status.DesiredConfig && status.PendingReboot == nil {
+			continue
+		}
+		summary := machineConfigNodeSummary{
+			Name:          node.Name,
+			Phase:         string(status.Phase),
+			DesiredConfig: status.DesiredConfig,
+			LastError:     status.LastError,
+		}
+		if status.PendingReboot != nil {
+			summary.PendingReboot = status.PendingReboot.Reason
+		}
+		ret = append(ret, summary)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+	return ret
+}
@@ -0,0 +1,138 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	clientretry "k8s.io/client-go/util/retry"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+const (
+	// clusterRebootConfigMapName is the configmap in the MCO namespace admins create to reboot
+	// all of the pools, one after the other
+	clusterRebootConfigMapName = "machine-config-reboot"
+	// clusterRebootRequestedKey holds the RFC 3339 time of the request
+	clusterRebootRequestedKey = "requested"
+	// clusterRebootOrderKey holds the comma separated names of the pools to reboot, in order.
+	// It defaults to master first then the other pools by name.
+	clusterRebootOrderKey = "order"
+)
+
+// clusterRebootOrder returns the pools to reboot in order, from the declared order if any
+func clusterRebootOrder(order string, pools []*mcfgv1.MachineConfigPool) ([]*mcfgv1.MachineConfigPool, error) {
+	byName := map[string]*mcfgv1.MachineConfigPool{}
+	for _, pool := range pools {
+		byName[pool.Name] = pool
+	}
+	var names []string
+	if strings.TrimSpace(order) == "" {
+		for name := range byName {
+			if name != "master" {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		if _, ok := byName["master"]; ok {
+			names = append([]string{"master"}, names...)
+		}
+	} else {
+		for _, name := range strings.Split(order, ",") {
+			names = append(names, strings.TrimSpace(name))
+		}
+	}
+
+	seen := map[string]bool{}
+	var ordered []*mcfgv1.MachineConfigPool
+	for _, name := range names {
+		pool, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("pool %q in the reboot order doesn't exist", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("pool %q is listed more than once in the reboot order", name)
+		}
+		seen[name] = true
+		ordered = append(ordered, pool)
+	}
+	return ordered, nil
+}
+
+// nextPoolToReboot returns the first pool in order which didn't complete the reboot request,
+// nil if they all did, along with the number of pools which did
+func nextPoolToReboot(request string, ordered []*mcfgv1.MachineConfigPool) (*mcfgv1.MachineConfigPool, int) {
+	for i, pool := range ordered {
+		if pool.Annotations[daemonconsts.RebootCompletedAnnotationKey] != request {
+			return pool, i
+		}
+	}
+	return nil, len(ordered)
+}
+
+// syncClusterReboot rolls the cluster reboot request through the pools: the next pool in order
+// is requested to reboot once the previous one completed its reboot
+func (optr *Operator) syncClusterReboot(_ *renderConfig) error {
+	optr.clusterRebootStatus = ""
+	cm, err := optr.mcoCmLister.ConfigMaps(optr.namespace).Get(clusterRebootConfigMapName)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	request := cm.Data[clusterRebootRequestedKey]
+	if request == "" {
+		return nil
+	}
+	if _, err := time.Parse(time.RFC3339, request); err != nil {
+		optr.clusterRebootStatus = fmt.Sprintf("Ignoring the reboot request: %s must be an RFC 3339 time: %v", clusterRebootRequestedKey, err)
+		return nil
+	}
+	pools, err := optr.mcpLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	ordered, err := clusterRebootOrder(cm.Data[clusterRebootOrderKey], pools)
+	if err != nil {
+		optr.clusterRebootStatus = fmt.Sprintf("Ignoring the reboot request: %v", err)
+		return nil
+	}
+
+	pool, done := nextPoolToReboot(request, ordered)
+	if pool == nil {
+		optr.clusterRebootStatus = fmt.Sprintf("All %d pools rebooted for the request %s", len(ordered), request)
+		return nil
+	}
+	optr.clusterRebootStatus = fmt.Sprintf("Rebooting pool %s for the request %s, %d of %d pools rebooted", pool.Name, request, done, len(ordered))
+	if pool.Annotations[daemonconsts.RebootRequestedAnnotationKey] == request {
+		return nil
+	}
+
+	glog.Infof("Requesting the reboot of pool %s for the cluster reboot request %s", pool.Name, request)
+	if err := clientretry.RetryOnConflict(clientretry.DefaultRetry, func() error {
+		newPool, err := optr.client.MachineconfigurationV1().MachineConfigPools().Get(context.TODO(), pool.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if newPool.Annotations == nil {
+			newPool.Annotations = map[string]string{}
+		}
+		newPool.Annotations[daemonconsts.RebootRequestedAnnotationKey] = request
+		_, err = optr.client.MachineconfigurationV1().MachineConfigPools().Update(context.TODO(), newPool, metav1.UpdateOptions{})
+		return err
+	}); err != nil {
+		return fmt.Errorf("failed to request the reboot of pool %s: %v", pool.Name, err)
+	}
+	optr.eventRecorder.Eventf(pool, corev1.EventTypeNormal, "ClusterRebootPool", "Rebooting pool %s for the cluster reboot request %s", pool.Name, request)
+	return nil
+}
@@ -0,0 +1,111 @@
+package operator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	fakemcfgclientset "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/fake"
+	mcfginformers "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions"
+)
+
+func newRebootPool(name string, annotations map[string]string) *mcfgv1.MachineConfigPool {
+	return &mcfgv1.MachineConfigPool{ObjectMeta: metav1.ObjectMeta{Name: name, Annotations: annotations}}
+}
+
+func TestClusterRebootOrder(t *testing.T) {
+	pools := []*mcfgv1.MachineConfigPool{
+		newRebootPool("worker", nil),
+		newRebootPool("master", nil),
+		newRebootPool("infra", nil),
+	}
+	names := func(pools []*mcfgv1.MachineConfigPool) []string {
+		var names []string
+		for _, pool := range pools {
+			names = append(names, pool.Name)
+		}
+		return names
+	}
+
+	ordered, err := clusterRebootOrder("", pools)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"master", "infra", "worker"}, names(ordered))
+
+	ordered, err = clusterRebootOrder("worker, master", pools)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"worker", "master"}, names(ordered))
+
+	_, err = clusterRebootOrder("master,gpu", pools)
+	assert.NotNil(t, err)
+
+	_, err = clusterRebootOrder("master,worker,master", pools)
+	assert.NotNil(t, err)
+}
+
+func TestNextPoolToReboot(t *testing.T) {
+	request := "2021-03-01T12:00:00Z"
+	ordered := []*mcfgv1.MachineConfigPool{
+		newRebootPool("master", map[string]string{daemonconsts.RebootCompletedAnnotationKey: request}),
+		newRebootPool("worker", map[string]string{daemonconsts.RebootCompletedAnnotationKey: "2021-01-01T12:00:00Z"}),
+	}
+	pool, done := nextPoolToReboot(request, ordered)
+	require.NotNil(t, pool)
+	assert.Equal(t, "worker", pool.Name)
+	assert.Equal(t, 1, done)
+
+	ordered[1].Annotations[daemonconsts.RebootCompletedAnnotationKey] = request
+	pool, done = nextPoolToReboot(request, ordered)
+	assert.Nil(t, pool)
+	assert.Equal(t, 2, done)
+}
+
+func TestSyncClusterReboot(t *testing.T) {
+	request := "2021-03-01T12:00:00Z"
+	namespace := "openshift-machine-config-operator"
+	pools := []*mcfgv1.MachineConfigPool{
+		newRebootPool("master", map[string]string{
+			daemonconsts.RebootRequestedAnnotationKey: request,
+			daemonconsts.RebootCompletedAnnotationKey: request,
+		}),
+		newRebootPool("worker", nil),
+	}
+
+	kubeClient := fake.NewSimpleClientset()
+	cmInformer := informers.NewSharedInformerFactory(kubeClient, 0).Core().V1().ConfigMaps()
+	cmInformer.Informer().GetIndexer().Add(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: clusterRebootConfigMapName, Namespace: namespace},
+		Data:       map[string]string{clusterRebootRequestedKey: request},
+	})
+	client := fakemcfgclientset.NewSimpleClientset(pools[0], pools[1])
+	mcpInformer := mcfginformers.NewSharedInformerFactory(client, 0).Machineconfiguration().V1().MachineConfigPools()
+	for _, pool := range pools {
+		mcpInformer.Informer().GetIndexer().Add(pool)
+	}
+	optr := &Operator{
+		namespace:     namespace,
+		client:        client,
+		eventRecorder: &record.FakeRecorder{},
+		mcoCmLister:   cmInformer.Lister(),
+		mcpLister:     mcpInformer.Lister(),
+	}
+
+	require.Nil(t, optr.syncClusterReboot(nil))
+	assert.Equal(t, "Rebooting pool worker for the request 2021-03-01T12:00:00Z, 1 of 2 pools rebooted", optr.clusterRebootStatus)
+	worker, err := client.MachineconfigurationV1().MachineConfigPools().Get(context.TODO(), "worker", metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Equal(t, request, worker.Annotations[daemonconsts.RebootRequestedAnnotationKey])
+
+	worker.Annotations[daemonconsts.RebootCompletedAnnotationKey] = request
+	mcpInformer.Informer().GetIndexer().Update(worker)
+	require.Nil(t, optr.syncClusterReboot(nil))
+	assert.Equal(t, "All 2 pools rebooted for the request 2021-03-01T12:00:00Z", optr.clusterRebootStatus)
+}
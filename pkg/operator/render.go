@@ -45,6 +45,7 @@ func renderAsset(config *renderConfig, path string) ([]byte, error) {
 	funcs["onPremPlatformIngressIP"] = onPremPlatformIngressIP
 	funcs["onPremPlatformShortName"] = onPremPlatformShortName
 	funcs["onPremPlatformKeepalivedEnableUnicast"] = onPremPlatformKeepalivedEnableUnicast
+	funcs["controlPlaneReplicas"] = controlPlaneReplicas
 
 	if config.Constants == nil {
 		config.Constants = constants.ConstantsByName
@@ -178,6 +179,15 @@ func (rc renderConfig) GenerateProxyCookieSecret() string {
 	return base64.StdEncoding.EncodeToString([]byte(utilrand.String(32)))
 }
 
+// controlPlaneReplicas returns the replicas of the deployments spread over the control plane
+// nodes: two, or one on a single node control plane
+func controlPlaneReplicas(cfg mcfgv1.ControllerConfigSpec) int {
+	if cfg.Infra != nil && cfg.Infra.Status.ControlPlaneTopology == configv1.SingleReplicaTopologyMode {
+		return 1
+	}
+	return 2
+}
+
 func onPremPlatformShortName(cfg mcfgv1.ControllerConfigSpec) interface{} {
 	if cfg.Infra.Status.PlatformStatus != nil {
 		switch cfg.Infra.Status.PlatformStatus.Type {
@@ -100,6 +100,19 @@ func TestRenderAsset(t *testing.T) {
 			},
 		},
 		FindExpected: "image: {MCO: PLACEHOLDER}",
+	}, {
+		// A single replica on single node control planes
+		Path: "manifests/machineconfigcontroller/deployment.yaml",
+		RenderConfig: &renderConfig{
+			TargetNamespace: "testing-namespace",
+			Images: &RenderConfigImages{
+				MachineConfigOperator: "{MCO: PLACEHOLDER}",
+			},
+			ControllerConfig: mcfgv1.ControllerConfigSpec{
+				Infra: &configv1.Infrastructure{Status: configv1.InfrastructureStatus{ControlPlaneTopology: configv1.SingleReplicaTopologyMode}},
+			},
+		},
+		FindExpected: "replicas: 1",
 	}, {
 		// Render same template as previous test
 		// But with a template field missing
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/golang/glog"
 	configv1 "github.com/openshift/api/config/v1"
@@ -14,6 +16,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
@@ -44,13 +47,67 @@ func (optr *Operator) syncVersion() error {
 		optr.eventRecorder.Eventf(mcoObjectRef, corev1.EventTypeNormal, "OperatorVersionChanged", fmt.Sprintf("clusteroperator/machine-config-operator version changed from %v to %v", co.Status.Versions, optr.vStore.GetAll()))
 	}
 
-	co.Status.Versions = optr.vStore.GetAll()
+	versions := optr.vStore.GetAll()
+	if osVersion := optr.operatingSystemVersion(co.Status.Versions); osVersion != "" {
+		versions = append(versions, configv1.OperandVersion{Name: operatingSystemVersionName, Version: osVersion})
+	}
+	co.Status.Versions = versions
 	// TODO(runcom): abstract below with updateStatus
 	optr.setOperatorStatusExtension(&co.Status, nil)
-	_, err = optr.configClient.ConfigV1().ClusterOperators().UpdateStatus(context.TODO(), co, metav1.UpdateOptions{})
+	_, err = optr.updateClusterOperatorStatus(co)
 	return err
 }
 
+// updateClusterOperatorStatus writes the status of the clusteroperator, unless it is the status
+// the operator last wrote and the clusteroperator didn't change since. Writes are throttled so
+// that pools changing status often don't turn into a storm of clusteroperator updates.
+func (optr *Operator) updateClusterOperatorStatus(co *configv1.ClusterOperator) (*configv1.ClusterOperator, error) {
+	last := optr.lastWrittenClusterOperator
+	if last != nil && co.ResourceVersion != "" && co.ResourceVersion == last.ResourceVersion && equality.Semantic.DeepEqual(co.Status, last.Status) {
+		return last, nil
+	}
+	if optr.statusWriteLimiter != nil {
+		optr.statusWriteLimiter.Accept()
+	}
+	updated, err := optr.configClient.ConfigV1().ClusterOperators().UpdateStatus(context.TODO(), co, metav1.UpdateOptions{FieldManager: clusterOperatorFieldManager})
+	if err != nil {
+		optr.lastWrittenClusterOperator = nil
+		return nil, err
+	}
+	optr.lastWrittenClusterOperator = updated
+	return updated, nil
+}
+
+// operatingSystemVersion returns the version of the CoreOS the machines of all the pools run,
+// as published by their daemons. While they run different versions, e.g. during an update, the
+// version previously reported in versions is kept.
+func (optr *Operator) operatingSystemVersion(versions []configv1.OperandVersion) string {
+	var previous string
+	for _, version := range versions {
+		if version.Name == operatingSystemVersionName {
+			previous = version.Version
+		}
+	}
+	pools, err := optr.mcpLister.List(labels.Everything())
+	if err != nil {
+		glog.Warningf("Failed to list the pools for the operating system version: %v", err)
+		return previous
+	}
+	osVersions := sets.NewString()
+	for _, pool := range pools {
+		for _, hostOS := range pool.Status.HostOperatingSystems {
+			// RHEL workers run their own OS, the MCO doesn't update
+			if hostOS.Variant != "rhel" {
+				osVersions.Insert(hostOS.Version)
+			}
+		}
+	}
+	if osVersions.Len() != 1 || osVersions.Has("") {
+		return previous
+	}
+	return osVersions.List()[0]
+}
+
 // syncRelatedObjects handles reporting the relatedObjects to the clusteroperator
 func (optr *Operator) syncRelatedObjects() error {
 	co, err := optr.fetchClusterOperator()
@@ -62,24 +119,33 @@ func (optr *Operator) syncRelatedObjects() error {
 	}
 
 	coCopy := co.DeepCopy()
+	co.Status.RelatedObjects = optr.relatedObjects()
+
+	if !equality.Semantic.DeepEqual(coCopy.Status.RelatedObjects, co.Status.RelatedObjects) {
+		_, err := optr.updateClusterOperatorStatus(co)
+		return err
+	}
+
+	return nil
+}
+
+// relatedObjects returns the objects must-gather collects to debug the MCO
+func (optr *Operator) relatedObjects() []configv1.ObjectReference {
 	// RelatedObjects are consumed by https://github.com/openshift/must-gather
-	co.Status.RelatedObjects = []configv1.ObjectReference{
+	return []configv1.ObjectReference{
 		{Resource: "namespaces", Name: optr.namespace},
 		{Group: "machineconfiguration.openshift.io", Resource: "machineconfigpools"},
 		{Group: "machineconfiguration.openshift.io", Resource: "controllerconfigs"},
 		{Group: "machineconfiguration.openshift.io", Resource: "kubeletconfigs"},
 		{Group: "machineconfiguration.openshift.io", Resource: "containerruntimeconfigs"},
+		// the rendered configs along with the fragments they're generated from
 		{Group: "machineconfiguration.openshift.io", Resource: "machineconfigs"},
+		{Group: "apps", Resource: "daemonsets", Namespace: optr.namespace, Name: "machine-config-daemon"},
+		{Group: "apps", Resource: "daemonsets", Namespace: optr.namespace, Name: "machine-config-server"},
+		{Group: "apps", Resource: "deployments", Namespace: optr.namespace, Name: "machine-config-controller"},
 		// gathered because the machineconfigs created container bootstrap credentials and node configuration that gets reflected via the API and is needed for debugging
 		{Group: "", Resource: "nodes"},
 	}
-
-	if !equality.Semantic.DeepEqual(coCopy.Status.RelatedObjects, co.Status.RelatedObjects) {
-		_, err := optr.configClient.ConfigV1().ClusterOperators().UpdateStatus(context.TODO(), co, metav1.UpdateOptions{})
-		return err
-	}
-
-	return nil
 }
 
 // syncAvailableStatus applies the new condition to the mco's ClusterOperator object.
@@ -157,14 +223,85 @@ func (optr *Operator) syncProgressingStatus() error {
 }
 
 func (optr *Operator) updateStatus(co *configv1.ClusterOperator, status configv1.ClusterOperatorStatusCondition) error {
+	var previous configv1.ConditionStatus
+	if condition := cov1helpers.FindStatusCondition(co.Status.Conditions, status.Type); condition != nil {
+		previous = condition.Status
+	}
 	cov1helpers.SetStatusCondition(&co.Status.Conditions, status)
 	optr.setOperatorStatusExtension(&co.Status, nil)
-	_, err := optr.configClient.ConfigV1().ClusterOperators().UpdateStatus(context.TODO(), co, metav1.UpdateOptions{})
-	return err
+	if _, err := optr.updateClusterOperatorStatus(co); err != nil {
+		return err
+	}
+	if previous != status.Status {
+		optr.recordConditionTransition(co, previous, status)
+	}
+	return nil
+}
+
+// recordConditionTransition records an event on the clusteroperator, and on the pools behind
+// it, when its Available, Progressing or Degraded condition changes from previous to status
+func (optr *Operator) recordConditionTransition(co *configv1.ClusterOperator, previous configv1.ConditionStatus, status configv1.ClusterOperatorStatusCondition) {
+	var poolCondition mcfgv1.MachineConfigPoolConditionType
+	switch status.Type {
+	case configv1.OperatorAvailable:
+	case configv1.OperatorProgressing:
+		poolCondition = mcfgv1.MachineConfigPoolUpdating
+	case configv1.OperatorDegraded:
+		poolCondition = mcfgv1.MachineConfigPoolDegraded
+	default:
+		return
+	}
+	if previous == "" {
+		previous = configv1.ConditionUnknown
+	}
+	eventType := corev1.EventTypeNormal
+	if (status.Type == configv1.OperatorAvailable && status.Status == configv1.ConditionFalse) ||
+		(status.Type == configv1.OperatorDegraded && status.Status == configv1.ConditionTrue) {
+		eventType = corev1.EventTypeWarning
+	}
+	message := fmt.Sprintf("%s changed from %s to %s", status.Type, previous, status.Status)
+	if status.Reason != "" {
+		message = fmt.Sprintf("%s (%s)", message, status.Reason)
+	}
+	if status.Message != "" {
+		message = fmt.Sprintf("%s: %s", message, status.Message)
+	}
+	mcoObjectRef := &corev1.ObjectReference{
+		Kind:      "ClusterOperator",
+		Name:      co.Name,
+		Namespace: co.Namespace,
+		UID:       co.GetUID(),
+	}
+	optr.eventRecorder.Eventf(mcoObjectRef, eventType, "OperatorStatusChanged", message)
+
+	// the pools updating, or degraded, are the ones that made the operator progress, or degrade
+	if poolCondition == "" || status.Status != configv1.ConditionTrue {
+		return
+	}
+	pools, err := optr.mcpLister.List(labels.Everything())
+	if err != nil {
+		glog.Warningf("Failed to list the pools to record the %s transition on: %v", status.Type, err)
+		return
+	}
+	for _, pool := range pools {
+		if isPoolStatusConditionTrue(pool, poolCondition) {
+			optr.eventRecorder.Eventf(pool, eventType, "OperatorStatusChanged", "Operator %s", message)
+		}
+	}
 }
 
 const (
 	asExpectedReason = "AsExpected"
+
+	// operatingSystemVersionName is the operand version of the OS of the machines
+	operatingSystemVersionName = "operating-system"
+
+	// clusterOperatorFieldManager is the field manager of the clusteroperator status writes
+	clusterOperatorFieldManager = "machine-config-operator"
+
+	// operatorFailing is the condition operators reported before Degraded replaced it, which
+	// the ClusterOperator may still have after an upgrade from such a release
+	operatorFailing configv1.ClusterStatusConditionType = "Failing"
 )
 
 func (optr *Operator) clearDegradedStatus(task string) error {
@@ -262,14 +399,40 @@ func (optr *Operator) syncUpgradeableStatus() error {
 		Status: configv1.ConditionTrue,
 		Reason: asExpectedReason,
 	}
+	var pausedPools, degradedNodesPools []string
+	degradedPool := false
 	for _, pool := range pools {
-		degraded := isPoolStatusConditionTrue(pool, mcfgv1.MachineConfigPoolDegraded)
-		if degraded {
-			coStatus.Status = configv1.ConditionFalse
-			coStatus.Reason = "DegradedPool"
-			coStatus.Message = "One or more machine config pool is degraded, please see `oc get mcp` for further details and resolve before upgrading"
+		if isPoolStatusConditionTrue(pool, mcfgv1.MachineConfigPoolDegraded) {
+			degradedPool = true
+		}
+		if pool.Status.DegradedMachineCount > 0 {
+			degradedNodesPools = append(degradedNodesPools, pool.Name)
+		}
+		// a paused pool not on its rendered config would be left behind by the upgrade
+		if pool.Spec.Paused && pool.Spec.Configuration.Name != pool.Status.Configuration.Name {
+			pausedPools = append(pausedPools, pool.Name)
 		}
 	}
+	if len(pausedPools) > 0 {
+		coStatus.Status = configv1.ConditionFalse
+		coStatus.Reason = "PausedPoolPendingConfig"
+		coStatus.Message = fmt.Sprintf("Machine config pools %s are paused with a pending configuration, please unpause them and let them update before upgrading", strings.Join(pausedPools, ", "))
+	}
+	if len(degradedNodesPools) > 0 {
+		coStatus.Status = configv1.ConditionFalse
+		coStatus.Reason = "DegradedNodes"
+		coStatus.Message = fmt.Sprintf("Machine config pools %s have degraded nodes, please see `oc get nodes` for further details and resolve before upgrading", strings.Join(degradedNodesPools, ", "))
+	}
+	if degradedPool {
+		coStatus.Status = configv1.ConditionFalse
+		coStatus.Reason = "DegradedPool"
+		coStatus.Message = "One or more machine config pool is degraded, please see `oc get mcp` for further details and resolve before upgrading"
+	}
+	if len(optr.unconvertibleConfigs) > 0 {
+		coStatus.Status = configv1.ConditionFalse
+		coStatus.Reason = "UnconvertibleIgnitionSpec2"
+		coStatus.Message = fmt.Sprintf("MachineConfigs %s are written against Ignition spec 2 and can't be converted to spec 3, please rewrite them before upgrading", strings.Join(optr.unconvertibleConfigs, ", "))
+	}
 
 	return optr.updateStatus(co, coStatus)
 }
@@ -286,6 +449,8 @@ func (optr *Operator) fetchClusterOperator() (*configv1.ClusterOperator, error)
 		return nil, err
 	}
 	coCopy := co.DeepCopy()
+	// Degraded is reported instead, the next status update drops the stale condition
+	cov1helpers.RemoveStatusCondition(&coCopy.Status.Conditions, operatorFailing)
 	return coCopy, nil
 }
 
@@ -308,32 +473,67 @@ func (optr *Operator) initializeClusterOperator() (*configv1.ClusterOperator, er
 	cov1helpers.SetStatusCondition(&co.Status.Conditions, configv1.ClusterOperatorStatusCondition{
 		Type: configv1.OperatorUpgradeable, Status: configv1.ConditionUnknown, Reason: "NoData"})
 
-	// RelatedObjects are consumed by https://github.com/openshift/must-gather
-	co.Status.RelatedObjects = []configv1.ObjectReference{
-		{Resource: "namespaces", Name: optr.namespace},
-		{Group: "machineconfiguration.openshift.io", Resource: "machineconfigpools", Name: "master"},
-		{Group: "machineconfiguration.openshift.io", Resource: "machineconfigpools", Name: "worker"},
-		{Group: "machineconfiguration.openshift.io", Resource: "controllerconfigs", Name: "machine-config-controller"},
-	}
+	co.Status.RelatedObjects = optr.relatedObjects()
 	// During an installation we report the RELEASE_VERSION as soon as the component is created.
 	// For both normal runs and upgrades, this code isn't hit and we get the right version every
 	// time. This also only contains the operator RELEASE_VERSION when we're here.
 	co.Status.Versions = optr.vStore.GetAll()
-	return optr.configClient.ConfigV1().ClusterOperators().UpdateStatus(context.TODO(), co, metav1.UpdateOptions{})
+	return optr.updateClusterOperatorStatus(co)
+}
+
+// operatorStatusExtensionVersion is the version of operatorStatusExtension, bumped on
+// incompatible changes of its schema
+const operatorStatusExtensionVersion = "v1"
+
+// operatorStatusExtension is the raw extension of the clusteroperator status, parsed by the console
+// and support tooling
+type operatorStatusExtension struct {
+	Version string `json:"version"`
+	// Pools summarizes the status of every pool, sorted by name
+	Pools []machineConfigPoolSummary `json:"pools"`
+	// LastSyncError is the error of the last failed sync
+	LastSyncError string `json:"lastSyncError,omitempty"`
+	// ClusterReboot is the progress of the cluster reboot requested
+	ClusterReboot string `json:"clusterReboot,omitempty"`
+}
+
+// machineConfigPoolSummary is the status of a pool in the operatorStatusExtension
+type machineConfigPoolSummary struct {
+	Name                    string `json:"name"`
+	MachineCount            int32  `json:"machineCount"`
+	UpdatedMachineCount     int32  `json:"updatedMachineCount"`
+	ReadyMachineCount       int32  `json:"readyMachineCount"`
+	UnavailableMachineCount int32  `json:"unavailableMachineCount"`
+	DegradedMachineCount    int32  `json:"degradedMachineCount"`
+	// CurrentConfig is the rendered config all the nodes of the pool are at
+	CurrentConfig string `json:"currentConfig"`
+	// Progress is the percentage of the nodes of the pool at the config of the pool
+	Progress int32 `json:"progress"`
+	// Phases counts the nodes of the pool in each phase of the rollout
+	Phases machineConfigPoolPhases `json:"phases"`
+	// Message is the status of the pool for humans
+	Message string `json:"message"`
+	// Nodes are the nodes of the pool not done updating, or with a reboot ahead of them
+	Nodes []machineConfigNodeSummary `json:"nodes,omitempty"`
 }
 
 // setOperatorStatusExtension sets the raw extension field of the clusteroperator. Today, we set
 // the MCPs statuses and an optional error status which we may get during a sync.
 func (optr *Operator) setOperatorStatusExtension(status *configv1.ClusterOperatorStatus, statusErr error) {
-	statuses, err := optr.allMachineConfigPoolStatus()
+	pools, err := optr.allMachineConfigPoolStatus()
 	if err != nil {
 		glog.Error(err)
 		return
 	}
+	extension := operatorStatusExtension{
+		Version:       operatorStatusExtensionVersion,
+		Pools:         pools,
+		ClusterReboot: optr.clusterRebootStatus,
+	}
 	if statusErr != nil {
-		statuses["lastSyncError"] = statusErr.Error()
+		extension.LastSyncError = statusErr.Error()
 	}
-	raw, err := json.Marshal(statuses)
+	raw, err := json.Marshal(extension)
 	if err != nil {
 		glog.Error(err)
 		return
@@ -341,14 +541,40 @@ func (optr *Operator) setOperatorStatusExtension(status *configv1.ClusterOperato
 	status.Extension.Raw = raw
 }
 
-func (optr *Operator) allMachineConfigPoolStatus() (map[string]string, error) {
+func (optr *Operator) allMachineConfigPoolStatus() ([]machineConfigPoolSummary, error) {
 	pools, err := optr.mcpLister.List(labels.Everything())
 	if err != nil {
 		return nil, err
 	}
-	ret := map[string]string{}
+	sort.Slice(pools, func(i, j int) bool { return pools[i].Name < pools[j].Name })
+	nodes, err := optr.nodesByPool(pools)
+	if err != nil {
+		return nil, err
+	}
+	var mcns map[string]*mcfgv1.MachineConfigNode
+	if len(pools) > 0 {
+		// the pools are still summarized without the MachineConfigNodes, e.g. while upgrading
+		// from a release without them
+		if mcns, err = optr.machineConfigNodes(); err != nil {
+			glog.Warning(err)
+		}
+	}
+	ret := []machineConfigPoolSummary{}
 	for _, pool := range pools {
-		ret[pool.GetName()] = machineConfigPoolStatus(pool)
+		phases, progress := poolPhases(pool, nodes[pool.Name])
+		ret = append(ret, machineConfigPoolSummary{
+			Name:                    pool.Name,
+			MachineCount:            pool.Status.MachineCount,
+			UpdatedMachineCount:     pool.Status.UpdatedMachineCount,
+			ReadyMachineCount:       pool.Status.ReadyMachineCount,
+			UnavailableMachineCount: pool.Status.UnavailableMachineCount,
+			DegradedMachineCount:    pool.Status.DegradedMachineCount,
+			CurrentConfig:           pool.Status.Configuration.Name,
+			Progress:                progress,
+			Phases:                  phases,
+			Message:                 machineConfigPoolStatus(pool),
+			Nodes:                   nodeSummaries(nodes[pool.Name], mcns),
+		})
 	}
 	return ret, nil
 }
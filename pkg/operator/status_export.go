@@ -0,0 +1,173 @@
+package operator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/openshift/machine-config-operator/lib/resourceapply"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+const (
+	// statusExportConfigMapName is the configmap in the MCO namespace admins create to export
+	// the status of the MCO for fleet managers
+	statusExportConfigMapName = "machine-config-status-export"
+	// statusExportEndpointKey optionally holds a URL the status document is POSTed to
+	statusExportEndpointKey = "endpoint"
+
+	// exportedStatusConfigMapName is the configmap in the MCO namespace the status document
+	// is written to
+	exportedStatusConfigMapName = "machine-config-status"
+	// exportedStatusKey is the key of the status document in exportedStatusConfigMapName
+	exportedStatusKey = "status.json"
+
+	// exportedStatusSchemaVersion is the version of the status document schema. Fields are
+	// only ever added to a schema version.
+	exportedStatusSchemaVersion = "v1"
+
+	// statusExportTimeout bounds the POST of the status document to the endpoint
+	statusExportTimeout = 10 * time.Second
+)
+
+// exportedStatus is the compact status document of the MCO fleet managers aggregate
+type exportedStatus struct {
+	SchemaVersion string               `json:"schemaVersion"`
+	Version       string               `json:"version"`
+	Pools         []exportedPoolStatus `json:"pools"`
+}
+
+// exportedPoolStatus is the status of a pool in the status document
+type exportedPoolStatus struct {
+	Name                 string   `json:"name"`
+	RenderedConfig       string   `json:"renderedConfig"`
+	Updated              bool     `json:"updated"`
+	Updating             bool     `json:"updating"`
+	Degraded             bool     `json:"degraded"`
+	MachineCount         int32    `json:"machineCount"`
+	UpdatedMachineCount  int32    `json:"updatedMachineCount"`
+	ReadyMachineCount    int32    `json:"readyMachineCount"`
+	DegradedMachineCount int32    `json:"degradedMachineCount"`
+	DegradedNodes        []string `json:"degradedNodes"`
+}
+
+// degradedNodeNames returns the sorted names of the nodes the daemon reported as degraded
+func degradedNodeNames(nodes []corev1.Node) []string {
+	names := []string{}
+	for _, node := range nodes {
+		switch node.Annotations[daemonconsts.MachineConfigDaemonStateAnnotationKey] {
+		case daemonconsts.MachineConfigDaemonStateDegraded, daemonconsts.MachineConfigDaemonStateUnreconcilable:
+			names = append(names, node.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// buildExportedStatus returns the status document of the pools, listing the nodes of the
+// pools with degraded machines
+func (optr *Operator) buildExportedStatus(pools []*mcfgv1.MachineConfigPool) (*exportedStatus, error) {
+	version, _ := optr.vStore.Get("operator")
+	status := &exportedStatus{
+		SchemaVersion: exportedStatusSchemaVersion,
+		Version:       version,
+		Pools:         []exportedPoolStatus{},
+	}
+	for _, pool := range pools {
+		poolStatus := exportedPoolStatus{
+			Name:                 pool.Name,
+			RenderedConfig:       pool.Status.Configuration.Name,
+			Updated:              mcfgv1.IsMachineConfigPoolConditionTrue(pool.Status.Conditions, mcfgv1.MachineConfigPoolUpdated),
+			Updating:             mcfgv1.IsMachineConfigPoolConditionTrue(pool.Status.Conditions, mcfgv1.MachineConfigPoolUpdating),
+			Degraded:             mcfgv1.IsMachineConfigPoolConditionTrue(pool.Status.Conditions, mcfgv1.MachineConfigPoolDegraded),
+			MachineCount:         pool.Status.MachineCount,
+			UpdatedMachineCount:  pool.Status.UpdatedMachineCount,
+			ReadyMachineCount:    pool.Status.ReadyMachineCount,
+			DegradedMachineCount: pool.Status.DegradedMachineCount,
+			DegradedNodes:        []string{},
+		}
+		if pool.Status.DegradedMachineCount > 0 && pool.Spec.NodeSelector != nil {
+			selector, err := metav1.LabelSelectorAsSelector(pool.Spec.NodeSelector)
+			if err != nil {
+				return nil, fmt.Errorf("invalid node selector of pool %s: %v", pool.Name, err)
+			}
+			nodes, err := optr.kubeClient.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+			if err != nil {
+				return nil, fmt.Errorf("listing the nodes of pool %s: %v", pool.Name, err)
+			}
+			poolStatus.DegradedNodes = degradedNodeNames(nodes.Items)
+		}
+		status.Pools = append(status.Pools, poolStatus)
+	}
+	sort.Slice(status.Pools, func(i, j int) bool { return status.Pools[i].Name < status.Pools[j].Name })
+	return status, nil
+}
+
+// postExportedStatus sends the status document to the endpoint
+func postExportedStatus(endpoint string, document []byte) error {
+	client := &http.Client{Timeout: statusExportTimeout}
+	resp, err := client.Post(endpoint, "application/json", bytes.NewReader(document))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("endpoint answered %s", resp.Status)
+	}
+	return nil
+}
+
+// syncStatusExport writes the status document to the status configmap and, if one is set,
+// to the export endpoint. The endpoint only gets the document when it changed.
+func (optr *Operator) syncStatusExport(_ *renderConfig) error {
+	cm, err := optr.mcoCmLister.ConfigMaps(optr.namespace).Get(statusExportConfigMapName)
+	if apierrors.IsNotFound(err) {
+		optr.exportedStatus = ""
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	pools, err := optr.mcpLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	status, err := optr.buildExportedStatus(pools)
+	if err != nil {
+		return err
+	}
+	document, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+
+	if _, _, err := resourceapply.ApplyConfigMap(optr.kubeClient.CoreV1(), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: exportedStatusConfigMapName, Namespace: optr.namespace},
+		Data:       map[string]string{exportedStatusKey: string(document)},
+	}); err != nil {
+		return fmt.Errorf("writing the exported status: %v", err)
+	}
+
+	endpoint := cm.Data[statusExportEndpointKey]
+	if endpoint == "" || optr.exportedStatus == endpoint+string(document) {
+		return nil
+	}
+	// an unreachable endpoint isn't a failure of the MCO, the document is sent again next sync
+	if err := postExportedStatus(endpoint, document); err != nil {
+		glog.Warningf("Failed to export the status to %s: %v", endpoint, err)
+		return nil
+	}
+	optr.exportedStatus = endpoint + string(document)
+	return nil
+}
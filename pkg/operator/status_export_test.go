@@ -0,0 +1,107 @@
+package operator
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	fakemcfgclientset "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/fake"
+	mcfginformers "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions"
+)
+
+func newExportNode(name, role, state string) *corev1.Node {
+	return &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name:        name,
+		Labels:      map[string]string{"node-role.kubernetes.io/" + role: ""},
+		Annotations: map[string]string{daemonconsts.MachineConfigDaemonStateAnnotationKey: state},
+	}}
+}
+
+func TestSyncStatusExport(t *testing.T) {
+	namespace := "openshift-machine-config-operator"
+	var received []string
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received = append(received, string(body))
+	}))
+	defer endpoint.Close()
+
+	pools := []*mcfgv1.MachineConfigPool{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "worker"},
+			Spec: mcfgv1.MachineConfigPoolSpec{
+				NodeSelector: metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role.kubernetes.io/worker", ""),
+			},
+			Status: mcfgv1.MachineConfigPoolStatus{
+				Configuration:        mcfgv1.MachineConfigPoolStatusConfiguration{ObjectReference: corev1.ObjectReference{Name: "rendered-worker-1"}},
+				Conditions:           []mcfgv1.MachineConfigPoolCondition{{Type: mcfgv1.MachineConfigPoolDegraded, Status: corev1.ConditionTrue}},
+				MachineCount:         2,
+				DegradedMachineCount: 1,
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "master"},
+			Status: mcfgv1.MachineConfigPoolStatus{
+				Configuration:       mcfgv1.MachineConfigPoolStatusConfiguration{ObjectReference: corev1.ObjectReference{Name: "rendered-master-1"}},
+				Conditions:          []mcfgv1.MachineConfigPoolCondition{{Type: mcfgv1.MachineConfigPoolUpdated, Status: corev1.ConditionTrue}},
+				MachineCount:        3,
+				UpdatedMachineCount: 3,
+				ReadyMachineCount:   3,
+			},
+		},
+	}
+
+	kubeClient := fake.NewSimpleClientset(
+		newExportNode("worker-0", "worker", daemonconsts.MachineConfigDaemonStateDone),
+		newExportNode("worker-1", "worker", daemonconsts.MachineConfigDaemonStateDegraded),
+		newExportNode("master-0", "master", daemonconsts.MachineConfigDaemonStateUnreconcilable),
+	)
+	cmInformer := informers.NewSharedInformerFactory(kubeClient, 0).Core().V1().ConfigMaps()
+	client := fakemcfgclientset.NewSimpleClientset()
+	mcpInformer := mcfginformers.NewSharedInformerFactory(client, 0).Machineconfiguration().V1().MachineConfigPools()
+	for _, pool := range pools {
+		mcpInformer.Informer().GetIndexer().Add(pool)
+	}
+	optr := &Operator{
+		namespace:   namespace,
+		kubeClient:  kubeClient,
+		client:      client,
+		vStore:      newVersionStore(),
+		mcoCmLister: cmInformer.Lister(),
+		mcpLister:   mcpInformer.Lister(),
+	}
+	optr.vStore.Set("operator", "4.7.0")
+
+	// nothing is exported until the export is configured
+	require.Nil(t, optr.syncStatusExport(nil))
+	_, err := kubeClient.CoreV1().ConfigMaps(namespace).Get(context.TODO(), exportedStatusConfigMapName, metav1.GetOptions{})
+	assert.NotNil(t, err)
+
+	cmInformer.Informer().GetIndexer().Add(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: statusExportConfigMapName, Namespace: namespace},
+		Data:       map[string]string{statusExportEndpointKey: endpoint.URL},
+	})
+	require.Nil(t, optr.syncStatusExport(nil))
+	expected := `{"schemaVersion":"v1","version":"4.7.0","pools":[` +
+		`{"name":"master","renderedConfig":"rendered-master-1","updated":true,"updating":false,"degraded":false,"machineCount":3,"updatedMachineCount":3,"readyMachineCount":3,"degradedMachineCount":0,"degradedNodes":[]},` +
+		`{"name":"worker","renderedConfig":"rendered-worker-1","updated":false,"updating":false,"degraded":true,"machineCount":2,"updatedMachineCount":0,"readyMachineCount":0,"degradedMachineCount":1,"degradedNodes":["worker-1"]}]}`
+	cm, err := kubeClient.CoreV1().ConfigMaps(namespace).Get(context.TODO(), exportedStatusConfigMapName, metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Equal(t, expected, cm.Data[exportedStatusKey])
+	assert.Equal(t, []string{expected}, received)
+
+	// the endpoint only gets changed documents
+	require.Nil(t, optr.syncStatusExport(nil))
+	assert.Len(t, received, 1)
+}
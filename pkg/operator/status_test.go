@@ -2,17 +2,23 @@ package operator
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/fake"
+	corelisterv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"k8s.io/apimachinery/pkg/util/uuid"
 
 	configv1 "github.com/openshift/api/config/v1"
@@ -20,6 +26,9 @@ import (
 	cov1helpers "github.com/openshift/library-go/pkg/config/clusteroperator/v1helpers"
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	fakemcfgclientset "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/fake"
+	mcfglistersv1 "github.com/openshift/machine-config-operator/pkg/generated/listers/machineconfiguration.openshift.io/v1"
 )
 
 func TestIsMachineConfigPoolConfigurationValid(t *testing.T) {
@@ -600,3 +609,373 @@ func TestInClusterBringUpStayOnErr(t *testing.T) {
 
 	assert.False(t, optr.inClusterBringup)
 }
+
+func TestSyncDegradedStatusDropsFailing(t *testing.T) {
+	optr := &Operator{
+		eventRecorder: &record.FakeRecorder{},
+	}
+	optr.vStore = newVersionStore()
+	optr.vStore.Set("operator", "test-version")
+	optr.mcpLister = &mockMCPLister{}
+	co := &configv1.ClusterOperator{}
+	cov1helpers.SetStatusCondition(&co.Status.Conditions, configv1.ClusterOperatorStatusCondition{Type: operatorFailing, Status: configv1.ConditionTrue})
+	cov1helpers.SetStatusCondition(&co.Status.Conditions, configv1.ClusterOperatorStatusCondition{Type: configv1.OperatorDegraded, Status: configv1.ConditionFalse})
+	optr.configClient = fakeconfigclientset.NewSimpleClientset(co)
+
+	require.Nil(t, optr.syncDegradedStatus(syncError{task: "RenderConfig", err: errors.New("mocked")}))
+	co, err := optr.configClient.ConfigV1().ClusterOperators().Get(context.TODO(), co.Name, metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Nil(t, cov1helpers.FindStatusCondition(co.Status.Conditions, operatorFailing))
+	degraded := cov1helpers.FindStatusCondition(co.Status.Conditions, configv1.OperatorDegraded)
+	require.NotNil(t, degraded)
+	assert.Equal(t, configv1.ConditionTrue, degraded.Status)
+	assert.Equal(t, "RenderConfigFailed", degraded.Reason)
+}
+
+func TestSyncUpgradeableStatus(t *testing.T) {
+	newPool := func(name string) *mcfgv1.MachineConfigPool {
+		pool := &mcfgv1.MachineConfigPool{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		pool.Spec.Configuration.Name = "rendered-" + name + "-1"
+		pool.Status.Configuration.Name = "rendered-" + name + "-1"
+		return pool
+	}
+	paused := newPool("paused")
+	paused.Spec.Paused = true
+	paused.Spec.Configuration.Name = "rendered-paused-2"
+	pausedUpToDate := newPool("paused-up-to-date")
+	pausedUpToDate.Spec.Paused = true
+	degradedNodes := newPool("degraded-nodes")
+	degradedNodes.Status.DegradedMachineCount = 1
+	degraded := newPool("degraded")
+	degraded.Status.Conditions = []mcfgv1.MachineConfigPoolCondition{{Type: mcfgv1.MachineConfigPoolDegraded, Status: corev1.ConditionTrue}}
+
+	for _, tc := range []struct {
+		name          string
+		pools         []*mcfgv1.MachineConfigPool
+		unconvertible []string
+		status        configv1.ConditionStatus
+		reason        string
+	}{
+		{name: "up to date", pools: []*mcfgv1.MachineConfigPool{newPool("worker"), pausedUpToDate}, status: configv1.ConditionTrue, reason: asExpectedReason},
+		{name: "paused pool pending config", pools: []*mcfgv1.MachineConfigPool{newPool("worker"), paused}, status: configv1.ConditionFalse, reason: "PausedPoolPendingConfig"},
+		{name: "degraded nodes", pools: []*mcfgv1.MachineConfigPool{paused, degradedNodes}, status: configv1.ConditionFalse, reason: "DegradedNodes"},
+		{name: "degraded pool", pools: []*mcfgv1.MachineConfigPool{degradedNodes, degraded}, status: configv1.ConditionFalse, reason: "DegradedPool"},
+		{name: "unconvertible configs", pools: []*mcfgv1.MachineConfigPool{degraded}, unconvertible: []string{"99-spec2"}, status: configv1.ConditionFalse, reason: "UnconvertibleIgnitionSpec2"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			for _, pool := range tc.pools {
+				require.Nil(t, indexer.Add(pool))
+			}
+			optr := &Operator{
+				eventRecorder:        &record.FakeRecorder{},
+				mcpLister:            mcfglistersv1.NewMachineConfigPoolLister(indexer),
+				nodeLister:           corelisterv1.NewNodeLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})),
+				mcnLister:            mcfglistersv1.NewMachineConfigNodeLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})),
+				mcnListerSynced:      func() bool { return true },
+				kubeClient:           fake.NewSimpleClientset(),
+				client:               fakemcfgclientset.NewSimpleClientset(),
+				unconvertibleConfigs: tc.unconvertible,
+			}
+			optr.vStore = newVersionStore()
+			optr.vStore.Set("operator", "test-version")
+			optr.configClient = fakeconfigclientset.NewSimpleClientset(&configv1.ClusterOperator{})
+
+			require.Nil(t, optr.syncUpgradeableStatus())
+			co, err := optr.configClient.ConfigV1().ClusterOperators().Get(context.TODO(), "", metav1.GetOptions{})
+			require.Nil(t, err)
+			upgradeable := cov1helpers.FindStatusCondition(co.Status.Conditions, configv1.OperatorUpgradeable)
+			require.NotNil(t, upgradeable)
+			assert.Equal(t, tc.status, upgradeable.Status)
+			assert.Equal(t, tc.reason, upgradeable.Reason)
+		})
+	}
+}
+
+func TestSetOperatorStatusExtension(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, name := range []string{"worker", "master"} {
+		pool := &mcfgv1.MachineConfigPool{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		pool.Spec.NodeSelector = metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/"+name, "")
+		pool.Spec.Configuration.Name = "rendered-" + name + "-2"
+		pool.Status.Configuration.Name = "rendered-" + name + "-1"
+		pool.Status.MachineCount = 2
+		pool.Status.UpdatedMachineCount = 1
+		pool.Status.ReadyMachineCount = 1
+		pool.Status.UnavailableMachineCount = 1
+		pool.Status.Conditions = []mcfgv1.MachineConfigPoolCondition{{Type: mcfgv1.MachineConfigPoolUpdating, Status: corev1.ConditionTrue}}
+		require.Nil(t, indexer.Add(pool))
+	}
+	newNode := func(name, role, current, desired, state string) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"node-role/" + role: ""}, Annotations: map[string]string{
+				daemonconsts.CurrentMachineConfigAnnotationKey:     current,
+				daemonconsts.DesiredMachineConfigAnnotationKey:     desired,
+				daemonconsts.MachineConfigDaemonStateAnnotationKey: state,
+			}},
+			Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}},
+		}
+	}
+	newMCN := func(name string, status mcfgv1.MachineConfigNodeStatus) *mcfgv1.MachineConfigNode {
+		return &mcfgv1.MachineConfigNode{ObjectMeta: metav1.ObjectMeta{Name: name}, Spec: mcfgv1.MachineConfigNodeSpec{NodeName: name}, Status: status}
+	}
+	nodeIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, node := range []*corev1.Node{
+		newNode("master-0", "master", "rendered-master-2", "rendered-master-2", daemonconsts.MachineConfigDaemonStateDone),
+		newNode("master-1", "master", "rendered-master-1", "rendered-master-2", daemonconsts.MachineConfigDaemonStateWorking),
+		newNode("worker-0", "worker", "rendered-worker-1", "rendered-worker-1", daemonconsts.MachineConfigDaemonStateDone),
+		newNode("worker-1", "worker", "rendered-worker-1", "rendered-worker-2", daemonconsts.MachineConfigDaemonStateDegraded),
+	} {
+		require.Nil(t, nodeIndexer.Add(node))
+	}
+	mcnIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, mcn := range []*mcfgv1.MachineConfigNode{
+		newMCN("master-1", mcfgv1.MachineConfigNodeStatus{
+			CurrentConfig: "rendered-master-1", DesiredConfig: "rendered-master-2", Phase: mcfgv1.MachineConfigNodeWorking,
+			PendingReboot: &mcfgv1.MachineConfigNodeReboot{Reason: "ScheduledUpdate"},
+		}),
+		newMCN("worker-0", mcfgv1.MachineConfigNodeStatus{
+			CurrentConfig: "rendered-worker-1", DesiredConfig: "rendered-worker-1", Phase: mcfgv1.MachineConfigNodeDone,
+		}),
+		newMCN("worker-1", mcfgv1.MachineConfigNodeStatus{
+			CurrentConfig: "rendered-worker-1", DesiredConfig: "rendered-worker-2", Phase: mcfgv1.MachineConfigNodeDegraded,
+			LastError: "failed to drain",
+		}),
+	} {
+		require.Nil(t, mcnIndexer.Add(mcn))
+	}
+	optr := &Operator{
+		mcpLister:           mcfglistersv1.NewMachineConfigPoolLister(indexer),
+		nodeLister:          corelisterv1.NewNodeLister(nodeIndexer),
+		mcnLister:           mcfglistersv1.NewMachineConfigNodeLister(mcnIndexer),
+		mcnListerSynced:     func() bool { return true },
+		clusterRebootStatus: "Rebooting pool master",
+	}
+
+	var status configv1.ClusterOperatorStatus
+	optr.setOperatorStatusExtension(&status, errors.New("mocked"))
+	var extension operatorStatusExtension
+	require.Nil(t, json.Unmarshal(status.Extension.Raw, &extension))
+	assert.Equal(t, operatorStatusExtension{
+		Version: operatorStatusExtensionVersion,
+		Pools: []machineConfigPoolSummary{
+			{
+				Name: "master", MachineCount: 2, UpdatedMachineCount: 1, ReadyMachineCount: 1, UnavailableMachineCount: 1, CurrentConfig: "rendered-master-1",
+				Progress: 50, Phases: machineConfigPoolPhases{Updating: 1, Done: 1},
+				Message: "1 (ready 1) out of 2 nodes are updating to latest configuration rendered-master-2",
+				Nodes:   []machineConfigNodeSummary{{Name: "master-1", Phase: "Working", DesiredConfig: "rendered-master-2", PendingReboot: "ScheduledUpdate"}},
+			},
+			{
+				Name: "worker", MachineCount: 2, UpdatedMachineCount: 1, ReadyMachineCount: 1, UnavailableMachineCount: 1, CurrentConfig: "rendered-worker-1",
+				Progress: 0, Phases: machineConfigPoolPhases{Pending: 1, Degraded: 1},
+				Message: "1 (ready 1) out of 2 nodes are updating to latest configuration rendered-worker-2",
+				Nodes:   []machineConfigNodeSummary{{Name: "worker-1", Phase: "Degraded", DesiredConfig: "rendered-worker-2", LastError: "failed to drain"}},
+			},
+		},
+		LastSyncError: "mocked",
+		ClusterReboot: "Rebooting pool master",
+	}, extension)
+}
+
+func TestNodePhase(t *testing.T) {
+	newNode := func(current, desired, state string, ready corev1.ConditionStatus) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+				daemonconsts.CurrentMachineConfigAnnotationKey:     current,
+				daemonconsts.DesiredMachineConfigAnnotationKey:     desired,
+				daemonconsts.MachineConfigDaemonStateAnnotationKey: state,
+			}},
+			Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}}},
+		}
+	}
+	scheduled := newNode("rendered-1", "rendered-2", daemonconsts.MachineConfigDaemonStateWorking, corev1.ConditionTrue)
+	scheduled.Annotations[daemonconsts.ScheduledRebootAnnotationKey] = "2021-03-01T12:00:00Z"
+
+	for _, tc := range []struct {
+		node  *corev1.Node
+		phase string
+	}{
+		{node: newNode("rendered-1", "rendered-1", daemonconsts.MachineConfigDaemonStateDone, corev1.ConditionTrue), phase: "pending"},
+		{node: newNode("rendered-1", "rendered-2", daemonconsts.MachineConfigDaemonStateDone, corev1.ConditionTrue), phase: "updating"},
+		{node: newNode("rendered-1", "rendered-2", daemonconsts.MachineConfigDaemonStateWorking, corev1.ConditionTrue), phase: "updating"},
+		{node: newNode("rendered-1", "rendered-2", daemonconsts.MachineConfigDaemonStateWorking, corev1.ConditionUnknown), phase: "rebooting"},
+		{node: scheduled, phase: "rebooting"},
+		{node: newNode("rendered-2", "rendered-2", daemonconsts.MachineConfigDaemonStateDone, corev1.ConditionTrue), phase: "done"},
+		{node: newNode("rendered-1", "rendered-2", daemonconsts.MachineConfigDaemonStateUnreconcilable, corev1.ConditionTrue), phase: "degraded"},
+	} {
+		assert.Equal(t, tc.phase, nodePhase(tc.node, "rendered-2"))
+	}
+}
+
+func TestSyncRelatedObjects(t *testing.T) {
+	optr := &Operator{namespace: "openshift-machine-config-operator"}
+	optr.configClient = fakeconfigclientset.NewSimpleClientset(&configv1.ClusterOperator{})
+
+	require.Nil(t, optr.syncRelatedObjects())
+	co, err := optr.configClient.ConfigV1().ClusterOperators().Get(context.TODO(), "", metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Contains(t, co.Status.RelatedObjects, configv1.ObjectReference{Resource: "namespaces", Name: "openshift-machine-config-operator"})
+	assert.Contains(t, co.Status.RelatedObjects, configv1.ObjectReference{Group: "machineconfiguration.openshift.io", Resource: "kubeletconfigs"})
+	assert.Contains(t, co.Status.RelatedObjects, configv1.ObjectReference{Group: "apps", Resource: "daemonsets", Namespace: "openshift-machine-config-operator", Name: "machine-config-daemon"})
+	assert.Contains(t, co.Status.RelatedObjects, configv1.ObjectReference{Group: "apps", Resource: "deployments", Namespace: "openshift-machine-config-operator", Name: "machine-config-controller"})
+}
+
+func TestUpdateStatusRecordsTransitions(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, name := range []string{"master", "worker"} {
+		pool := &mcfgv1.MachineConfigPool{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if name == "worker" {
+			pool.Status.Conditions = []mcfgv1.MachineConfigPoolCondition{{Type: mcfgv1.MachineConfigPoolDegraded, Status: corev1.ConditionTrue}}
+		}
+		require.Nil(t, indexer.Add(pool))
+	}
+	recorder := record.NewFakeRecorder(10)
+	optr := &Operator{
+		eventRecorder:   recorder,
+		mcpLister:       mcfglistersv1.NewMachineConfigPoolLister(indexer),
+		nodeLister:      corelisterv1.NewNodeLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})),
+		mcnLister:       mcfglistersv1.NewMachineConfigNodeLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})),
+		mcnListerSynced: func() bool { return true },
+		kubeClient:      fake.NewSimpleClientset(),
+		client:          fakemcfgclientset.NewSimpleClientset(),
+	}
+	optr.vStore = newVersionStore()
+	optr.vStore.Set("operator", "test-version")
+	co := &configv1.ClusterOperator{}
+	cov1helpers.SetStatusCondition(&co.Status.Conditions, configv1.ClusterOperatorStatusCondition{Type: configv1.OperatorProgressing, Status: configv1.ConditionFalse})
+	cov1helpers.SetStatusCondition(&co.Status.Conditions, configv1.ClusterOperatorStatusCondition{Type: configv1.OperatorDegraded, Status: configv1.ConditionFalse})
+	co.Status.Versions = optr.vStore.GetAll()
+	optr.configClient = fakeconfigclientset.NewSimpleClientset(co)
+
+	require.Nil(t, optr.syncDegradedStatus(syncError{task: "RequiredPools", err: errors.New("pool worker is degraded")}))
+	require.Len(t, recorder.Events, 2)
+	assert.Equal(t, "Warning OperatorStatusChanged Degraded changed from False to True (RequiredPoolsFailed): Failed to resync test-version because: pool worker is degraded", <-recorder.Events)
+	assert.Equal(t, "Warning OperatorStatusChanged Operator Degraded changed from False to True (RequiredPoolsFailed): Failed to resync test-version because: pool worker is degraded", <-recorder.Events)
+
+	// no transition, no event
+	require.Nil(t, optr.syncDegradedStatus(syncError{task: "RequiredPools", err: errors.New("pool worker is degraded")}))
+	assert.Len(t, recorder.Events, 0)
+
+	require.Nil(t, optr.syncDegradedStatus(syncError{}))
+	require.Len(t, recorder.Events, 1)
+	assert.Equal(t, "Normal OperatorStatusChanged Degraded changed from True to False", <-recorder.Events)
+}
+
+func TestSyncAllDegradedGracePeriod(t *testing.T) {
+	optr := &Operator{
+		eventRecorder:       &record.FakeRecorder{},
+		degradedGracePeriod: time.Minute,
+	}
+	optr.vStore = newVersionStore()
+	optr.vStore.Set("operator", "test-version")
+	optr.mcpLister = &mockMCPLister{}
+	co := &configv1.ClusterOperator{}
+	cov1helpers.SetStatusCondition(&co.Status.Conditions, configv1.ClusterOperatorStatusCondition{Type: configv1.OperatorDegraded, Status: configv1.ConditionFalse})
+	co.Status.Versions = optr.vStore.GetAll()
+	optr.configClient = fakeconfigclientset.NewSimpleClientset(co)
+	degraded := func() configv1.ConditionStatus {
+		co, err := optr.configClient.ConfigV1().ClusterOperators().Get(context.TODO(), co.Name, metav1.GetOptions{})
+		require.Nil(t, err)
+		return cov1helpers.FindStatusCondition(co.Status.Conditions, configv1.OperatorDegraded).Status
+	}
+	failing := []syncFunc{{name: "fn1", fn: func(config *renderConfig) error { return errors.New("mocked fn1") }}}
+	passing := []syncFunc{{name: "fn1", fn: func(config *renderConfig) error { return nil }}}
+
+	// a transient failure isn't reported
+	assert.NotNil(t, optr.syncAll(failing))
+	assert.Equal(t, configv1.ConditionFalse, degraded())
+	assert.Nil(t, optr.syncAll(passing))
+	assert.True(t, optr.failingSince.IsZero())
+
+	// failures outlasting the grace period are
+	assert.NotNil(t, optr.syncAll(failing))
+	assert.Equal(t, configv1.ConditionFalse, degraded())
+	optr.failingSince = optr.failingSince.Add(-time.Minute)
+	assert.NotNil(t, optr.syncAll(failing))
+	assert.Equal(t, configv1.ConditionTrue, degraded())
+	assert.Nil(t, optr.syncAll(passing))
+	assert.Equal(t, configv1.ConditionFalse, degraded())
+}
+
+func TestUpdateClusterOperatorStatusSkipsUnchanged(t *testing.T) {
+	optr := &Operator{
+		eventRecorder: &record.FakeRecorder{},
+		mcpLister:     &mockMCPLister{},
+	}
+	optr.vStore = newVersionStore()
+	optr.vStore.Set("operator", "test-version")
+	co := &configv1.ClusterOperator{ObjectMeta: metav1.ObjectMeta{ResourceVersion: "1"}}
+	co.Status.Versions = optr.vStore.GetAll()
+	client := fakeconfigclientset.NewSimpleClientset(co)
+	optr.configClient = client
+	updates := func() int {
+		n := 0
+		for _, action := range client.Actions() {
+			if action.GetVerb() == "update" && action.GetSubresource() == "status" {
+				n++
+			}
+		}
+		return n
+	}
+
+	require.Nil(t, optr.syncAvailableStatus())
+	assert.Equal(t, 1, updates())
+	require.Nil(t, optr.syncAvailableStatus())
+	assert.Equal(t, 1, updates())
+
+	// the clusteroperator changed since the last write
+	updated, err := client.ConfigV1().ClusterOperators().Get(context.TODO(), co.Name, metav1.GetOptions{})
+	require.Nil(t, err)
+	updated.ResourceVersion = "2"
+	_, err = client.ConfigV1().ClusterOperators().Update(context.TODO(), updated, metav1.UpdateOptions{})
+	require.Nil(t, err)
+	require.Nil(t, optr.syncAvailableStatus())
+	assert.Equal(t, 2, updates())
+}
+
+func TestSyncVersionOperatingSystem(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	master := &mcfgv1.MachineConfigPool{ObjectMeta: metav1.ObjectMeta{Name: "master"}}
+	master.Status.HostOperatingSystems = []mcfgv1.MachineConfigPoolHostOperatingSystem{{Variant: "rhcos", Version: "47.83.202103251640-0", MachineCount: 3}}
+	worker := &mcfgv1.MachineConfigPool{ObjectMeta: metav1.ObjectMeta{Name: "worker"}}
+	worker.Status.HostOperatingSystems = []mcfgv1.MachineConfigPoolHostOperatingSystem{
+		{Variant: "rhcos", Version: "47.83.202103251640-0", MachineCount: 2},
+		{Variant: "rhel", Version: "7.9", MachineCount: 1},
+	}
+	require.Nil(t, indexer.Add(master))
+	require.Nil(t, indexer.Add(worker))
+	optr := &Operator{
+		eventRecorder:   &record.FakeRecorder{},
+		mcpLister:       mcfglistersv1.NewMachineConfigPoolLister(indexer),
+		nodeLister:      corelisterv1.NewNodeLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})),
+		mcnLister:       mcfglistersv1.NewMachineConfigNodeLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})),
+		mcnListerSynced: func() bool { return true },
+		kubeClient:      fake.NewSimpleClientset(),
+		client:          fakemcfgclientset.NewSimpleClientset(),
+	}
+	optr.vStore = newVersionStore()
+	optr.vStore.Set("operator", "test-version")
+	optr.configClient = fakeconfigclientset.NewSimpleClientset(&configv1.ClusterOperator{})
+	versions := func() []configv1.OperandVersion {
+		co, err := optr.configClient.ConfigV1().ClusterOperators().Get(context.TODO(), "", metav1.GetOptions{})
+		require.Nil(t, err)
+		return co.Status.Versions
+	}
+
+	require.Nil(t, optr.syncVersion())
+	assert.Equal(t, []configv1.OperandVersion{
+		{Name: "operator", Version: "test-version"},
+		{Name: "operating-system", Version: "47.83.202103251640-0"},
+	}, versions())
+
+	// the previous version is kept while the pools update
+	updating := worker.DeepCopy()
+	updating.Status.HostOperatingSystems[0].MachineCount = 1
+	updating.Status.HostOperatingSystems = append(updating.Status.HostOperatingSystems, mcfgv1.MachineConfigPoolHostOperatingSystem{Variant: "rhcos", Version: "48.84.202105281935-0", MachineCount: 1})
+	require.Nil(t, indexer.Update(updating))
+	require.Nil(t, optr.syncVersion())
+	assert.Equal(t, []configv1.OperandVersion{
+		{Name: "operator", Version: "test-version"},
+		{Name: "operating-system", Version: "47.83.202103251640-0"},
+	}, versions())
+}
@@ -36,6 +36,9 @@ import (
 
 const (
 	requiredForUpgradeMachineConfigPoolLabelKey = "operator.machineconfiguration.openshift.io/required-for-upgrade"
+
+	// operatorDeploymentName is the deployment of the operator itself, applied by the CVO
+	operatorDeploymentName = "machine-config-operator"
 )
 
 var (
@@ -80,7 +83,14 @@ func (optr *Operator) syncAll(syncFuncs []syncFunc) error {
 		}
 	}
 
-	if err := optr.syncDegradedStatus(syncErr); err != nil {
+	if syncErr.err == nil {
+		optr.failingSince = time.Time{}
+	} else if optr.failingSince.IsZero() {
+		optr.failingSince = time.Now()
+	}
+	if failing := time.Since(optr.failingSince); syncErr.err != nil && failing < optr.degradedGracePeriod {
+		glog.Warningf("Failed to sync %s, failing for %v, Degraded is reported after %v: %v", syncErr.task, failing.Round(time.Second), optr.degradedGracePeriod, syncErr.err)
+	} else if err := optr.syncDegradedStatus(syncErr); err != nil {
 		return fmt.Errorf("error syncing degraded status: %v", err)
 	}
 
@@ -402,6 +412,9 @@ func (optr *Operator) syncMachineConfigController(config *renderConfig) error {
 	// new controller can roll out too.
 	// https://bugzilla.redhat.com/show_bug.cgi?id=1879099
 	cc.Annotations[daemonconsts.GeneratedByVersionAnnotationKey] = version.Raw
+	if err := optr.syncControllerConfigSchema(cc); err != nil {
+		return err
+	}
 	_, _, err = resourceapply.ApplyControllerConfig(optr.client.MachineconfigurationV1(), cc)
 	if err != nil {
 		return err
@@ -409,6 +422,28 @@ func (optr *Operator) syncMachineConfigController(config *renderConfig) error {
 	return optr.waitForControllerConfigToBeCompleted(cc)
 }
 
+// syncOperatorReplicas scales the deployment of the operator like the ones of its operands. Its
+// manifest leaves the replicas out, they would be reset on every apply of the CVO otherwise.
+func (optr *Operator) syncOperatorReplicas(config *renderConfig) error {
+	deployment, err := optr.deployLister.Deployments(optr.namespace).Get(operatorDeploymentName)
+	if apierrors.IsNotFound(err) {
+		// the operator isn't running in the cluster, e.g. while bootstrapping
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	replicas := int32(controlPlaneReplicas(config.ControllerConfig))
+	if deployment.Spec.Replicas != nil && *deployment.Spec.Replicas == replicas {
+		return nil
+	}
+	deployment = deployment.DeepCopy()
+	deployment.Spec.Replicas = &replicas
+	glog.Infof("Scaling deployment %s to %d replicas", operatorDeploymentName, replicas)
+	_, err = optr.kubeClient.AppsV1().Deployments(optr.namespace).Update(context.TODO(), deployment, metav1.UpdateOptions{})
+	return err
+}
+
 func (optr *Operator) syncMachineConfigDaemon(config *renderConfig) error {
 	for _, path := range []string{
 		"manifests/machineconfigdaemon/clusterrole.yaml",
@@ -581,7 +616,7 @@ func (optr *Operator) syncRequiredMachineConfigPools(_ *renderConfig) error {
 				return false, nil
 			}
 			optr.setOperatorStatusExtension(&co.Status, lastErr)
-			_, err = optr.configClient.ConfigV1().ClusterOperators().UpdateStatus(context.TODO(), co, metav1.UpdateOptions{})
+			_, err = optr.updateClusterOperatorStatus(co)
 			if err != nil {
 				lastErr = errors.Wrapf(lastErr, "failed to update clusteroperator: %v", err)
 				return false, nil
@@ -1,14 +1,19 @@
 package operator
 
 import (
+	"context"
 	"testing"
 
 	configv1 "github.com/openshift/api/config/v1"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes/fake"
+	appslisterv1 "k8s.io/client-go/listers/apps/v1"
+	"k8s.io/client-go/tools/cache"
 
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
 )
@@ -154,3 +159,37 @@ func withCABundle(caBundle string) kubeCloudConfigOption {
 		kubeCloudConfig.Data["ca-bundle.pem"] = caBundle
 	}
 }
+
+func TestSyncOperatorReplicas(t *testing.T) {
+	for _, tc := range []struct {
+		topology configv1.TopologyMode
+		replicas int32
+	}{
+		{topology: configv1.HighlyAvailableTopologyMode, replicas: 2},
+		{topology: configv1.SingleReplicaTopologyMode, replicas: 1},
+	} {
+		t.Run(string(tc.topology), func(t *testing.T) {
+			one := int32(1)
+			deployment := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: operatorDeploymentName, Namespace: "openshift-machine-config-operator"},
+				Spec:       appsv1.DeploymentSpec{Replicas: &one},
+			}
+			kubeClient := fake.NewSimpleClientset(deployment)
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			require.Nil(t, indexer.Add(deployment))
+			optr := &Operator{
+				namespace:    deployment.Namespace,
+				kubeClient:   kubeClient,
+				deployLister: appslisterv1.NewDeploymentLister(indexer),
+			}
+			infra := buildInfra()
+			infra.Status.ControlPlaneTopology = tc.topology
+			config := &renderConfig{ControllerConfig: mcfgv1.ControllerConfigSpec{Infra: infra}}
+
+			require.Nil(t, optr.syncOperatorReplicas(config))
+			updated, err := kubeClient.AppsV1().Deployments(deployment.Namespace).Get(context.TODO(), operatorDeploymentName, metav1.GetOptions{})
+			require.Nil(t, err)
+			assert.Equal(t, tc.replicas, *updated.Spec.Replicas)
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package e2e_rpmostree_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift/machine-config-operator/pkg/daemon"
+)
+
+// These tests exercise the daemon against test/rpm-ostree-mock, they are meant to run in
+// its container through `make test-e2e-rpmostree` and are skipped anywhere else.
+
+const (
+	initialImage = "registry.example.com/machine-os-content@sha256:aaa"
+	updatedImage = "registry.example.com/machine-os-content@sha256:bbb"
+)
+
+func TestMain(m *testing.M) {
+	if os.Getenv("RPMOSTREE_MOCK_STATE") == "" {
+		fmt.Println("RPMOSTREE_MOCK_STATE is not set, skipping the rpm-ostree mock e2e tests")
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// rpmOstree runs the rpm-ostree mock, failing the test on error
+func rpmOstree(t *testing.T, args ...string) string {
+	out, err := exec.Command("rpm-ostree", args...).CombinedOutput()
+	require.Nil(t, err, "rpm-ostree %s: %s", strings.Join(args, " "), out)
+	return string(out)
+}
+
+func cmdline(t *testing.T) string {
+	path := os.Getenv("RPMOSTREE_MOCK_CMDLINE")
+	require.NotEmpty(t, path, "RPMOSTREE_MOCK_CMDLINE is not set")
+	return path
+}
+
+func TestBootedDeployment(t *testing.T) {
+	rpmOstree(t, "mock-init", initialImage, "47.83.1")
+	client := daemon.NewNodeUpdaterClient()
+
+	url, version, err := client.GetBootedOSImageURL(context.Background())
+	require.Nil(t, err)
+	assert.Equal(t, initialImage, url)
+	assert.Equal(t, "47.83.1", version)
+
+	status, err := client.GetStatus(context.Background())
+	require.Nil(t, err)
+	assert.Contains(t, status, "pivot://"+initialImage)
+}
+
+func TestPivotAndRollback(t *testing.T) {
+	rpmOstree(t, "mock-init", initialImage, "47.83.1")
+	client := daemon.NewNodeUpdaterClient()
+
+	// the arguments the daemon rebases with once it extracted the OS container
+	rpmOstree(t, "rebase", "--experimental", "/run/mco-machine-os-content/os-content-123/srv/repo:bbb",
+		"--custom-origin-url", "pivot://"+updatedImage, "--custom-origin-description", "Managed by machine-config-operator")
+
+	// nothing changes until the node reboots
+	url, _, err := client.GetBootedOSImageURL(context.Background())
+	require.Nil(t, err)
+	assert.Equal(t, initialImage, url)
+
+	rpmOstree(t, "mock-reboot")
+	url, _, err = client.GetBootedOSImageURL(context.Background())
+	require.Nil(t, err)
+	assert.Equal(t, updatedImage, url)
+	booted, err := client.GetBootedDeployment(context.Background())
+	require.Nil(t, err)
+	assert.Equal(t, "bbb", booted.Checksum)
+
+	rpmOstree(t, "rollback")
+	rpmOstree(t, "mock-reboot")
+	url, _, err = client.GetBootedOSImageURL(context.Background())
+	require.Nil(t, err)
+	assert.Equal(t, initialImage, url)
+}
+
+func TestTuningKernelArguments(t *testing.T) {
+	hostOS, err := daemon.GetHostRunningOS()
+	require.Nil(t, err)
+	if !hostOS.IsRHCOS() {
+		t.Skip("the tunable kernel arguments are only allowed on RHCOS, the container has to report itself as RHCOS")
+	}
+	rpmOstree(t, "mock-init", initialImage, "47.83.1", "console=ttyS0")
+
+	dir, err := ioutil.TempDir("", "e2e-rpmostree")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	tuningFile := filepath.Join(dir, "kernel-args")
+
+	require.Nil(t, ioutil.WriteFile(tuningFile, []byte("ADD nosmt\n"), 0644))
+	changed, err := daemon.UpdateTuningArgs(context.Background(), tuningFile, cmdline(t))
+	require.Nil(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "console=ttyS0 nosmt\n", rpmOstree(t, "kargs"))
+
+	// applied after the reboot, adding it again is a no-op
+	rpmOstree(t, "mock-reboot")
+	changed, err = daemon.UpdateTuningArgs(context.Background(), tuningFile, cmdline(t))
+	require.Nil(t, err)
+	assert.False(t, changed)
+
+	require.Nil(t, ioutil.WriteFile(tuningFile, []byte("DELETE nosmt\n"), 0644))
+	changed, err = daemon.UpdateTuningArgs(context.Background(), tuningFile, cmdline(t))
+	require.Nil(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "console=ttyS0\n", rpmOstree(t, "kargs"))
+}
+
+func TestFailureModes(t *testing.T) {
+	rpmOstree(t, "mock-init", initialImage, "47.83.1")
+	client := daemon.NewNodeUpdaterClient()
+
+	rpmOstree(t, "mock-fail", "status", "Transaction in progress")
+	_, err := client.GetBootedDeployment(context.Background())
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "Transaction in progress")
+	_, _, err = client.GetBootedOSImageURL(context.Background())
+	assert.NotNil(t, err)
+
+	rpmOstree(t, "mock-fail", "status")
+	_, err = client.GetBootedDeployment(context.Background())
+	assert.Nil(t, err)
+
+	rpmOstree(t, "mock-fail", "rebase", "Not enough free space")
+	out, err := exec.Command("rpm-ostree", "rebase", "--experimental", "repo:bbb", "--custom-origin-url", "pivot://"+updatedImage).CombinedOutput()
+	require.NotNil(t, err)
+	assert.Contains(t, string(out), "Not enough free space")
+	// a failed rebase leaves nothing staged
+	rpmOstree(t, "mock-reboot")
+	url, _, err := client.GetBootedOSImageURL(context.Background())
+	require.Nil(t, err)
+	assert.Equal(t, initialImage, url)
+}
@@ -0,0 +1,119 @@
+package helpers
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// CommandRecorder is a drop-in replacement for exec.Command that records the commands it is
+// asked to build and makes them return canned output instead of running on the host
+type CommandRecorder struct {
+	mu        sync.Mutex
+	calls     [][]string
+	responses []commandResponse
+}
+
+type commandResponse struct {
+	prefix   []string
+	output   string
+	exitCode int
+}
+
+// NewCommandRecorder returns a CommandRecorder whose commands succeed with no output
+// unless told otherwise with Respond
+func NewCommandRecorder() *CommandRecorder {
+	return &CommandRecorder{}
+}
+
+// Respond makes the commands starting with prefix print output and exit with exitCode.
+// The most recently added matching response wins.
+func (r *CommandRecorder) Respond(output string, exitCode int, prefix ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.responses = append(r.responses, commandResponse{prefix: prefix, output: output, exitCode: exitCode})
+}
+
+// Command records the command and returns one printing the canned output of the first
+// matching response
+func (r *CommandRecorder) Command(name string, args ...string) *exec.Cmd {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	call := append([]string{name}, args...)
+	r.calls = append(r.calls, call)
+
+	resp := commandResponse{}
+	for i := len(r.responses) - 1; i >= 0; i-- {
+		if hasPrefix(call, r.responses[i].prefix) {
+			resp = r.responses[i]
+			break
+		}
+	}
+	cmd := exec.Command("/bin/sh", "-c", `printf '%s' "$FAKE_OUTPUT"; exit "$FAKE_EXIT_CODE"`)
+	cmd.Env = []string{"FAKE_OUTPUT=" + resp.output, fmt.Sprintf("FAKE_EXIT_CODE=%d", resp.exitCode)}
+	return cmd
+}
+
+// Calls returns the recorded commands, each one being the name followed by the arguments
+func (r *CommandRecorder) Calls() [][]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	calls := make([][]string, len(r.calls))
+	copy(calls, r.calls)
+	return calls
+}
+
+// Called returns true if a command starting with prefix was recorded
+func (r *CommandRecorder) Called(prefix ...string) bool {
+	for _, call := range r.Calls() {
+		if hasPrefix(call, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// AssertCalled fails the test if no command starting with prefix was recorded
+func (r *CommandRecorder) AssertCalled(t *testing.T, prefix ...string) {
+	t.Helper()
+	if !r.Called(prefix...) {
+		t.Errorf("expected a call to %q, got:\n%s", strings.Join(prefix, " "), r.dump())
+	}
+}
+
+// AssertNotCalled fails the test if a command starting with prefix was recorded
+func (r *CommandRecorder) AssertNotCalled(t *testing.T, prefix ...string) {
+	t.Helper()
+	if r.Called(prefix...) {
+		t.Errorf("expected no call to %q, got:\n%s", strings.Join(prefix, " "), r.dump())
+	}
+}
+
+// Reset forgets the recorded commands, the responses are kept
+func (r *CommandRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
+
+func (r *CommandRecorder) dump() string {
+	var lines []string
+	for _, call := range r.Calls() {
+		lines = append(lines, "  "+strings.Join(call, " "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func hasPrefix(call, prefix []string) bool {
+	if len(prefix) > len(call) {
+		return false
+	}
+	for i := range prefix {
+		if call[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,113 @@
+package helpers
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FakeClock is a clock whose time only moves when it is told to sleep or wait, so code
+// waiting on it runs instantly and deterministically
+type FakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	sleeps []time.Duration
+}
+
+// NewFakeClock returns a FakeClock set to now
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the current fake time
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Since returns the fake time elapsed since t
+func (c *FakeClock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// Sleep records d and moves the clock forward by d without blocking
+func (c *FakeClock) Sleep(d time.Duration) {
+	c.Advance(d)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sleeps = append(c.sleeps, d)
+}
+
+// After sleeps for d and returns a channel that already has the new time in it
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.Sleep(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.Now()
+	return ch
+}
+
+// Advance moves the clock forward by d
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Sleeps returns the durations passed to Sleep and After
+func (c *FakeClock) Sleeps() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]time.Duration{}, c.sleeps...)
+}
+
+// FakeFileStater reports only the files it is given as existing
+type FakeFileStater struct {
+	mu    sync.Mutex
+	files map[string]bool
+}
+
+// NewFakeFileStater returns a FakeFileStater where the paths exist
+func NewFakeFileStater(paths ...string) *FakeFileStater {
+	s := &FakeFileStater{files: map[string]bool{}}
+	for _, path := range paths {
+		s.files[path] = true
+	}
+	return s
+}
+
+// Add makes path exist
+func (s *FakeFileStater) Add(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[path] = true
+}
+
+// Remove makes path not exist
+func (s *FakeFileStater) Remove(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.files, path)
+}
+
+// Stat returns an os.IsNotExist error for the paths that weren't added
+func (s *FakeFileStater) Stat(name string) (os.FileInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.files[name] {
+		return nil, &os.PathError{Op: "stat", Path: name, Err: os.ErrNotExist}
+	}
+	return fakeFileInfo{name: filepath.Base(name)}, nil
+}
+
+type fakeFileInfo struct {
+	name string
+}
+
+func (f fakeFileInfo) Name() string       { return f.name }
+func (f fakeFileInfo) Size() int64        { return 0 }
+func (f fakeFileInfo) Mode() os.FileMode  { return 0644 }
+func (f fakeFileInfo) ModTime() time.Time { return time.Time{} }
+func (f fakeFileInfo) IsDir() bool        { return false }
+func (f fakeFileInfo) Sys() interface{}   { return nil }
@@ -0,0 +1,340 @@
+// rpm-ostree-mock emulates the subset of the rpm-ostree CLI used by the machine-config-daemon,
+// backed by a JSON state file instead of an OSTree sysroot. It is installed as
+// /usr/bin/rpm-ostree in the test/rpm-ostree-mock container so the daemon can be exercised
+// without a CoreOS host.
+//
+// Besides the rpm-ostree subcommands it understands a few mock- subcommands driving the emulation:
+//
+//	mock-init <osImageURL> <version> [karg...]  resets the state to a single booted deployment
+//	mock-reboot                                  boots into the default deployment
+//	mock-fail <subcommand> [message]             makes subcommand fail, no message clears the failure
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	defaultStatePath   = "/var/lib/rpm-ostree-mock/state.json"
+	defaultCmdlinePath = "/var/lib/rpm-ostree-mock/cmdline"
+)
+
+// deployment mirrors the fields of `rpm-ostree status --json` read by the daemon, plus the
+// kernel arguments and package requests the mock keeps track of
+type deployment struct {
+	ID           string   `json:"id"`
+	OSName       string   `json:"osname"`
+	Serial       int32    `json:"serial"`
+	Checksum     string   `json:"checksum"`
+	Version      string   `json:"version"`
+	Timestamp    uint64   `json:"timestamp"`
+	Booted       bool     `json:"booted"`
+	Staged       bool     `json:"staged"`
+	Origin       string   `json:"origin"`
+	CustomOrigin []string `json:"custom-origin"`
+	Kargs        []string `json:"kargs"`
+	Requests     []string `json:"requests,omitempty"`
+}
+
+type state struct {
+	// Deployments are ordered the way rpm-ostree orders them, the first one is the default
+	Deployments []deployment `json:"deployments"`
+	// Failures makes the subcommands they are keyed by print the message and exit 1
+	Failures map[string]string `json:"failures,omitempty"`
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fatalf("usage: rpm-ostree <subcommand> [args...]")
+	}
+	cmd, args := os.Args[1], os.Args[2:]
+
+	if cmd == "mock-init" {
+		if len(args) < 2 {
+			fatalf("usage: rpm-ostree mock-init <osImageURL> <version> [karg...]")
+		}
+		s := &state{Deployments: []deployment{{
+			ID:           "rhcos-0",
+			OSName:       "rhcos",
+			Checksum:     "0",
+			Version:      args[1],
+			Booted:       true,
+			CustomOrigin: []string{"pivot://" + args[0], "Managed by machine-config-operator"},
+			Kargs:        args[2:],
+		}}}
+		check(s.save())
+		check(s.writeCmdline())
+		return
+	}
+
+	s, err := load()
+	check(err)
+	if msg, ok := s.Failures[cmd]; ok {
+		fatalf("error: %s", msg)
+	}
+
+	switch cmd {
+	case "mock-reboot":
+		s.reboot()
+		check(s.writeCmdline())
+	case "mock-fail":
+		if len(args) < 1 {
+			fatalf("usage: rpm-ostree mock-fail <subcommand> [message]")
+		}
+		if s.Failures == nil {
+			s.Failures = map[string]string{}
+		}
+		if len(args) == 1 {
+			delete(s.Failures, args[0])
+		} else {
+			s.Failures[args[0]] = strings.Join(args[1:], " ")
+		}
+	case "status":
+		check(s.status(len(args) > 0 && args[0] == "--json"))
+		return
+	case "rebase":
+		check(s.rebase(args))
+	case "kargs":
+		if len(args) == 0 {
+			fmt.Println(strings.Join(s.pending().Kargs, " "))
+			return
+		}
+		check(s.kargs(args))
+	case "cleanup":
+		s.cleanup(args)
+	case "rollback":
+		check(s.rollback())
+	case "override", "install", "uninstall", "update", "upgrade":
+		d := s.stage()
+		d.Requests = append(d.Requests, strings.Join(os.Args[1:], " "))
+	default:
+		fatalf("error: unsupported subcommand %q", cmd)
+	}
+	check(s.save())
+}
+
+func statePath() string {
+	if path := os.Getenv("RPMOSTREE_MOCK_STATE"); path != "" {
+		return path
+	}
+	return defaultStatePath
+}
+
+func load() (*state, error) {
+	data, err := ioutil.ReadFile(statePath())
+	if err != nil {
+		return nil, fmt.Errorf("reading mock state, run mock-init first: %v", err)
+	}
+	s := &state{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("parsing mock state: %v", err)
+	}
+	return s, nil
+}
+
+func (s *state) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(statePath()), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(statePath(), data, 0644)
+}
+
+// writeCmdline writes the kernel arguments of the booted deployment, standing in for /proc/cmdline
+func (s *state) writeCmdline() error {
+	path := os.Getenv("RPMOSTREE_MOCK_CMDLINE")
+	if path == "" {
+		path = defaultCmdlinePath
+	}
+	booted := s.booted()
+	if booted == nil {
+		return fmt.Errorf("not currently booted in a deployment")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, []byte(strings.Join(append([]string{"BOOT_IMAGE=/vmlinuz"}, booted.Kargs...), " ")+"\n"), 0644)
+}
+
+func (s *state) booted() *deployment {
+	for i := range s.Deployments {
+		if s.Deployments[i].Booted {
+			return &s.Deployments[i]
+		}
+	}
+	return nil
+}
+
+// pending returns the deployment the next change is based on: the staged one if any,
+// the booted one otherwise
+func (s *state) pending() *deployment {
+	if len(s.Deployments) > 0 && s.Deployments[0].Staged {
+		return &s.Deployments[0]
+	}
+	return s.booted()
+}
+
+// stage returns a new staged deployment based on the pending one, replacing the previous
+// staged deployment
+func (s *state) stage() *deployment {
+	next := *s.pending()
+	next.Kargs = append([]string{}, next.Kargs...)
+	next.Requests = append([]string{}, next.Requests...)
+	next.Booted = false
+	next.Staged = true
+	for _, d := range s.Deployments {
+		if d.Serial >= next.Serial {
+			next.Serial = d.Serial + 1
+		}
+	}
+	next.ID = fmt.Sprintf("%s-%d", next.OSName, next.Serial)
+
+	s.cleanup([]string{"-p"})
+	s.Deployments = append([]deployment{next}, s.Deployments...)
+	return &s.Deployments[0]
+}
+
+func (s *state) status(asJSON bool) error {
+	if asJSON {
+		data, err := json.MarshalIndent(s, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	fmt.Println("State: idle\nDeployments:")
+	for _, d := range s.Deployments {
+		marker := " "
+		if d.Booted {
+			marker = "*"
+		}
+		if len(d.CustomOrigin) > 0 {
+			fmt.Printf("%s %s\n", marker, d.CustomOrigin[0])
+			fmt.Printf("              CustomOrigin: %s\n", strings.Join(d.CustomOrigin[1:], " "))
+		} else {
+			fmt.Printf("%s %s\n", marker, d.Origin)
+		}
+		fmt.Printf("                   Version: %s\n", d.Version)
+		if d.Staged {
+			fmt.Printf("                    Staged: yes\n")
+		}
+	}
+	return nil
+}
+
+// rebase handles `rebase --experimental <repo>:<checksum> --custom-origin-url <url> --custom-origin-description <desc>`
+func (s *state) rebase(args []string) error {
+	var ref, url, description string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--experimental":
+		case "--custom-origin-url", "--custom-origin-description":
+			if i+1 == len(args) {
+				return fmt.Errorf("%s requires a value", args[i])
+			}
+			if args[i] == "--custom-origin-url" {
+				url = args[i+1]
+			} else {
+				description = args[i+1]
+			}
+			i++
+		default:
+			ref = args[i]
+		}
+	}
+	if ref == "" {
+		return fmt.Errorf("no refspec given")
+	}
+	d := s.stage()
+	d.Checksum = ref[strings.LastIndex(ref, ":")+1:]
+	d.Version = ""
+	d.Requests = nil
+	d.CustomOrigin = []string{url, description}
+	return nil
+}
+
+func (s *state) kargs(args []string) error {
+	d := s.stage()
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "--append="):
+			d.Kargs = append(d.Kargs, strings.TrimPrefix(arg, "--append="))
+		case strings.HasPrefix(arg, "--delete="):
+			karg := strings.TrimPrefix(arg, "--delete=")
+			found := false
+			for i, k := range d.Kargs {
+				if k == karg {
+					d.Kargs = append(d.Kargs[:i], d.Kargs[i+1:]...)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return fmt.Errorf("no karg %q found", karg)
+			}
+		default:
+			return fmt.Errorf("unsupported kargs argument %q", arg)
+		}
+	}
+	return nil
+}
+
+// cleanup handles `cleanup -p`, removing the staged deployment. The other cleanups have
+// nothing to remove in the mock.
+func (s *state) cleanup(args []string) {
+	for _, arg := range args {
+		if arg == "-p" && len(s.Deployments) > 0 && s.Deployments[0].Staged {
+			s.Deployments = s.Deployments[1:]
+		}
+	}
+}
+
+// rollback makes the previous deployment the default one
+func (s *state) rollback() error {
+	s.cleanup([]string{"-p"})
+	for i, d := range s.Deployments {
+		if !d.Booted {
+			s.Deployments = append([]deployment{d}, append(s.Deployments[:i:i], s.Deployments[i+1:]...)...)
+			return nil
+		}
+	}
+	return fmt.Errorf("no rollback deployment found")
+}
+
+// reboot boots into the default deployment and prunes all but the previously booted one
+func (s *state) reboot() {
+	if len(s.Deployments) == 0 {
+		return
+	}
+	previous := s.booted()
+	deployments := []deployment{s.Deployments[0]}
+	if previous != nil && previous.ID != s.Deployments[0].ID {
+		deployments = append(deployments, *previous)
+	}
+	deployments[0].Booted = true
+	deployments[0].Staged = false
+	for i := 1; i < len(deployments); i++ {
+		deployments[i].Booted = false
+	}
+	s.Deployments = deployments
+}
+
+func check(err error) {
+	if err != nil {
+		fatalf("error: %v", err)
+	}
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}